@@ -117,13 +117,19 @@ processing will proceed even if an individual RLP-file import failure occurs.`,
 		Flags: flags.Merge([]cli.Flag{
 			utils.CacheFlag,
 			utils.SyncModeFlag,
+			utils.ExportOasysSnapshotsFlag,
 		}, utils.DatabaseFlags),
 		Description: `
 Requires a first argument of the file to write to.
 Optional second and third arguments control the first and
 last block to write. In this mode, the file will be appended
 if already existing. If the file ends with .gz, the output will
-be gzipped.`,
+be gzipped.
+
+If --oasys.snapshots is given, the Oasys consensus snapshots persisted
+at the checkpoint blocks, including their attestations, are written along
+with the blocks. They are restored by the import command, so that the
+imported chain can be resumed by a validator without re-deriving them.`,
 	}
 	importHistoryCommand = &cli.Command{
 		Action:    importHistory,
@@ -292,10 +298,10 @@ func importChain(ctx *cli.Context) error {
 	// Start system runtime metrics collection
 	go metrics.CollectProcessMetrics(3 * time.Second)
 
-	stack, _ := makeConfigNode(ctx)
+	stack, cfg := makeConfigNode(ctx)
 	defer stack.Close()
 
-	chain, db := utils.MakeChain(ctx, stack, false)
+	chain, db := utils.MakeChain(ctx, stack, &cfg.Eth, false)
 	defer db.Close()
 
 	// Start periodically gathering memory profiles
@@ -367,17 +373,17 @@ func exportChain(ctx *cli.Context) error {
 		utils.Fatalf("This command requires an argument.")
 	}
 
-	stack, _ := makeConfigNode(ctx)
+	stack, cfg := makeConfigNode(ctx)
 	defer stack.Close()
 
-	chain, db := utils.MakeChain(ctx, stack, true)
+	chain, db := utils.MakeChain(ctx, stack, &cfg.Eth, true)
 	defer db.Close()
 	start := time.Now()
 
 	var err error
 	fp := ctx.Args().First()
 	if ctx.Args().Len() < 3 {
-		err = utils.ExportChain(chain, fp)
+		err = utils.ExportChain(chain, fp, ctx.Bool(utils.ExportOasysSnapshotsFlag.Name))
 	} else {
 		// This can be improved to allow for numbers larger than 9223372036854775807
 		first, ferr := strconv.ParseInt(ctx.Args().Get(1), 10, 64)
@@ -391,7 +397,7 @@ func exportChain(ctx *cli.Context) error {
 		if head := chain.CurrentSnapBlock(); uint64(last) > head.Number.Uint64() {
 			utils.Fatalf("Export error: block number %d larger than head block %d\n", uint64(last), head.Number.Uint64())
 		}
-		err = utils.ExportAppendChain(chain, fp, uint64(first), uint64(last))
+		err = utils.ExportAppendChain(chain, fp, uint64(first), uint64(last), ctx.Bool(utils.ExportOasysSnapshotsFlag.Name))
 	}
 	if err != nil {
 		utils.Fatalf("Export error: %v\n", err)
@@ -405,10 +411,10 @@ func importHistory(ctx *cli.Context) error {
 		utils.Fatalf("usage: %s", ctx.Command.ArgsUsage)
 	}
 
-	stack, _ := makeConfigNode(ctx)
+	stack, cfg := makeConfigNode(ctx)
 	defer stack.Close()
 
-	chain, db := utils.MakeChain(ctx, stack, false)
+	chain, db := utils.MakeChain(ctx, stack, &cfg.Eth, false)
 	defer db.Close()
 
 	var (
@@ -463,10 +469,10 @@ func exportHistory(ctx *cli.Context) error {
 		utils.Fatalf("usage: %s", ctx.Command.ArgsUsage)
 	}
 
-	stack, _ := makeConfigNode(ctx)
+	stack, cfg := makeConfigNode(ctx)
 	defer stack.Close()
 
-	chain, _ := utils.MakeChain(ctx, stack, true)
+	chain, _ := utils.MakeChain(ctx, stack, &cfg.Eth, true)
 	start := time.Now()
 
 	var (
@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -33,11 +34,14 @@ import (
 	"github.com/ethereum/go-ethereum/console/prompt"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli/v2"
 )
@@ -51,6 +55,10 @@ var (
 		Name:  "remove.chain",
 		Usage: "If set, selects the state data for removal",
 	}
+	removeHashStateFlag = &cli.BoolFlag{
+		Name:  "remove.hashstate",
+		Usage: "If set, deletes the hash-scheme state after the conversion",
+	}
 
 	removedbCommand = &cli.Command{
 		Action:    removeDB,
@@ -79,6 +87,8 @@ Remove blockchain and state databases`,
 			dbExportCmd,
 			dbMetadataCmd,
 			dbCheckStateContentCmd,
+			dbMigrateAncientCmd,
+			dbConvertStateSchemeCmd,
 		},
 	}
 	dbInspectCmd = &cli.Command{
@@ -203,6 +213,38 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		}, utils.NetworkFlags, utils.DatabaseFlags),
 		Description: "Shows metadata about the chain status.",
 	}
+	dbMigrateAncientCmd = &cli.Command{
+		Action: migrateAncient,
+		Name:   "migrate-ancient",
+		Usage:  "Migrate the legacy ancient chain layout into the current one",
+		Flags: flags.Merge([]cli.Flag{
+			utils.SyncModeFlag,
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command relocates the chain freezer of the legacy releases from the root
+ancient folder into its own sub folder, upgrading the table layout on the way.
+The files are renamed in place, and the relocated freezer is verified against the
+key-value store before the migration is considered done, otherwise it's rolled
+back. The Oasys snapshots in the key-value store are left as they are.`,
+	}
+	dbConvertStateSchemeCmd = &cli.Command{
+		Action: convertStateScheme,
+		Name:   "convert-state-scheme",
+		Usage:  "Convert the state of the node from the hash scheme to the path scheme in place",
+		Flags: flags.Merge([]cli.Flag{
+			utils.SyncModeFlag,
+			removeHashStateFlag,
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command converts the state of a node running the hash scheme into the
+path scheme, without resyncing the chain. The most recent state persisted on disk
+is rewritten into the path-scheme layout, and the chain is rewound to its block
+on the next start if needed. The chain data and the Oasys consensus snapshots are
+left as they are.
+
+The path scheme keeps only the recent states, so the historical states of an
+archive node are no longer served after the conversion. They are kept in the
+database unless --remove.hashstate is given, and the node must be restarted with
+--state.scheme=path and --gcmode=full.`,
+	}
 )
 
 func removeDB(ctx *cli.Context) error {
@@ -759,3 +801,220 @@ func showMetaData(ctx *cli.Context) error {
 	table.Render()
 	return nil
 }
+
+func migrateAncient(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	ancient := stack.ResolveAncient("chaindata", ctx.String(utils.AncientFlag.Name))
+	if !rawdb.IsLegacyChainFreezer(ancient) {
+		log.Info("Ancient chain layout is up to date", "location", ancient)
+		return nil
+	}
+	db, err := stack.OpenDatabase("chaindata", 0, 0, "", false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return rawdb.MigrateLegacyChainFreezer(db, ancient)
+}
+
+func convertStateScheme(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	switch scheme := rawdb.ReadStateScheme(db); scheme {
+	case rawdb.PathScheme:
+		log.Info("State is already in the path scheme")
+		return nil
+	case "":
+		return errors.New("no state found in the database")
+	}
+	head := rawdb.ReadHeadBlock(db)
+	if head == nil {
+		return errors.New("no head block")
+	}
+	// The most recent states of the hash scheme are only kept in memory, pick the
+	// latest one persisted on disk.
+	header := head.Header()
+	for !rawdb.HasLegacyTrieNode(db, header.Root) {
+		if header.Number.Uint64() == 0 {
+			return errors.New("no persisted state found")
+		}
+		header = rawdb.ReadHeader(db, header.ParentHash, header.Number.Uint64()-1)
+		if header == nil {
+			return errors.New("missing header while looking for the persisted state")
+		}
+	}
+	snapshots := rawdb.CountOasysSnapshots(db)
+
+	fmt.Printf("Converting the state of block %d (%s), root %s, into the path scheme.\n", header.Number, header.Hash().TerminalString(), header.Root.TerminalString())
+	if header.Number.Uint64() != head.NumberU64() {
+		fmt.Printf("The chain will be rewound from block %d to block %d on the next start.\n", head.NumberU64(), header.Number)
+	}
+	fmt.Printf("The historical states won't be served anymore. %d Oasys snapshots are kept.\n", snapshots)
+	if confirm, err := prompt.Stdin.PromptConfirm("Convert the state?"); err != nil {
+		return err
+	} else if !confirm {
+		return nil
+	}
+	start := time.Now()
+	if err := convertStateToPath(db, header.Root); err != nil {
+		return err
+	}
+	if remaining := rawdb.CountOasysSnapshots(db); remaining != snapshots {
+		return fmt.Errorf("oasys snapshots changed during the conversion: %d != %d", remaining, snapshots)
+	}
+	log.Info("Converted state into the path scheme", "number", header.Number, "root", header.Root, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	remove := ctx.Bool(removeHashStateFlag.Name)
+	if !ctx.IsSet(removeHashStateFlag.Name) {
+		confirm, err := prompt.Stdin.PromptConfirm("Delete the hash-scheme state?")
+		if err != nil {
+			return err
+		}
+		remove = confirm
+	}
+	if remove {
+		if err := deleteHashState(db); err != nil {
+			return err
+		}
+	}
+	fmt.Println("Restart the node with --state.scheme=path and --gcmode=full.")
+	return nil
+}
+
+// convertStateToPath writes the trie nodes of the given state in the hash scheme
+// into the path-scheme layout. The root node is written last, as its presence
+// flips the scheme of the database, so an interrupted conversion can be rerun.
+func convertStateToPath(db ethdb.Database, root common.Hash) error {
+	hashdb := triedb.NewDatabase(db, triedb.HashDefaults)
+	defer hashdb.Close()
+
+	t, err := trie.NewStateTrie(trie.StateTrieID(root), hashdb)
+	if err != nil {
+		return err
+	}
+	accIter, err := t.NodeIterator(nil)
+	if err != nil {
+		return err
+	}
+	var (
+		nodes    int
+		accounts int
+		rootBlob []byte
+		start    = time.Now()
+		logged   = time.Now()
+		batch    = db.NewBatch()
+	)
+	flush := func(force bool) error {
+		if force || batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Converting state", "nodes", nodes, "accounts", accounts, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		return nil
+	}
+	for accIter.Next(true) {
+		// Embedded nodes are stored within their parents
+		if accIter.Hash() != (common.Hash{}) {
+			if len(accIter.Path()) == 0 {
+				rootBlob = common.CopyBytes(accIter.NodeBlob())
+			} else {
+				rawdb.WriteAccountTrieNode(batch, accIter.Path(), accIter.NodeBlob())
+			}
+			nodes++
+		}
+		if accIter.Leaf() {
+			accounts++
+			var acc types.StateAccount
+			if err := rlp.DecodeBytes(accIter.LeafBlob(), &acc); err != nil {
+				return err
+			}
+			if acc.Root != types.EmptyRootHash {
+				owner := common.BytesToHash(accIter.LeafKey())
+				storageTrie, err := trie.NewStateTrie(trie.StorageTrieID(root, owner, acc.Root), hashdb)
+				if err != nil {
+					return err
+				}
+				storageIter, err := storageTrie.NodeIterator(nil)
+				if err != nil {
+					return err
+				}
+				for storageIter.Next(true) {
+					if storageIter.Hash() != (common.Hash{}) {
+						rawdb.WriteStorageTrieNode(batch, owner, storageIter.Path(), storageIter.NodeBlob())
+						nodes++
+					}
+					if err := flush(false); err != nil {
+						return err
+					}
+				}
+				if storageIter.Error() != nil {
+					return storageIter.Error()
+				}
+			}
+		}
+		if err := flush(false); err != nil {
+			return err
+		}
+	}
+	if accIter.Error() != nil {
+		return accIter.Error()
+	}
+	// Reset the path-scheme metadata as a freshly synced state, then flip the scheme
+	rawdb.DeleteTrieJournal(batch)
+	rawdb.WritePersistentStateID(batch, 0)
+	if rootBlob != nil {
+		rawdb.WriteAccountTrieNode(batch, nil, rootBlob)
+	}
+	return flush(true)
+}
+
+// deleteHashState deletes the trie nodes of the hash scheme.
+func deleteHashState(db ethdb.Database) error {
+	var (
+		deleted int
+		start   = time.Now()
+		logged  = time.Now()
+		batch   = db.NewBatch()
+		it      = db.NewIterator(nil, nil)
+	)
+	defer it.Release()
+
+	for it.Next() {
+		if !rawdb.IsLegacyTrieNode(it.Key(), it.Value()) {
+			continue
+		}
+		if err := batch.Delete(it.Key()); err != nil {
+			return err
+		}
+		deleted++
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Deleting hash-scheme state", "nodes", deleted, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Deleted hash-scheme state", "nodes", deleted, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
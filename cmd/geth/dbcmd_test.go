@@ -0,0 +1,90 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+	"github.com/holiman/uint256"
+)
+
+func TestConvertStateToPath(t *testing.T) {
+	var (
+		db        = rawdb.NewMemoryDatabase()
+		snapshot  = append(append([]byte{}, rawdb.OasysSnapshotPrefix...), common.Hash{0x01}.Bytes()...)
+		hashState = state.NewDatabaseWithConfig(db, triedb.HashDefaults)
+	)
+	if err := db.Put(snapshot, []byte{0x01}); err != nil {
+		t.Fatal(err)
+	}
+	statedb, _ := state.New(types.EmptyRootHash, hashState, nil)
+	for i := 0; i < 100; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i)))
+		statedb.SetBalance(addr, uint256.NewInt(uint64(i+1)))
+		if i%10 == 0 {
+			statedb.SetState(addr, common.Hash{byte(i)}, common.Hash{0xff})
+		}
+	}
+	root, err := statedb.Commit(0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hashState.TrieDB().Commit(root, false); err != nil {
+		t.Fatal(err)
+	}
+	if scheme := rawdb.ReadStateScheme(db); scheme != "" && scheme != rawdb.HashScheme {
+		t.Fatalf("unexpected scheme before conversion: %s", scheme)
+	}
+	if err := convertStateToPath(db, root); err != nil {
+		t.Fatal(err)
+	}
+	if scheme := rawdb.ReadStateScheme(db); scheme != rawdb.PathScheme {
+		t.Fatalf("unexpected scheme after conversion: %s", scheme)
+	}
+	pathState := state.NewDatabaseWithConfig(db, &triedb.Config{PathDB: pathdb.Defaults})
+	converted, err := state.New(root, pathState, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i)))
+		if balance := converted.GetBalance(addr); balance.Uint64() != uint64(i+1) {
+			t.Fatalf("account %d: balance mismatch: have %d, want %d", i, balance, i+1)
+		}
+		if i%10 == 0 {
+			if value := converted.GetState(addr, common.Hash{byte(i)}); value != (common.Hash{0xff}) {
+				t.Fatalf("account %d: slot mismatch: have %x", i, value)
+			}
+		}
+	}
+	if err := deleteHashState(db); err != nil {
+		t.Fatal(err)
+	}
+	if rawdb.HasLegacyTrieNode(db, root) {
+		t.Fatal("hash-scheme root not deleted")
+	}
+	if blob, _ := db.Get(snapshot); len(blob) == 0 {
+		t.Fatal("oasys snapshot deleted")
+	}
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prysmaticlabs/prysm/v5/crypto/bls"
+	"github.com/prysmaticlabs/prysm/v5/validator/accounts"
+	"github.com/prysmaticlabs/prysm/v5/validator/accounts/iface"
+	"github.com/prysmaticlabs/prysm/v5/validator/keymanager"
+	"github.com/urfave/cli/v2"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+
+	ethaccounts "github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	contracts "github.com/ethereum/go-ethereum/contracts/oasys"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	devnetChainID  = 20240
+	devnetPassword = "oasys-devnet" // Password of all keys, as the devnet is for local testing only
+	devnetGasLimit = 30_000_000
+	devnetTxGas    = 1_000_000
+)
+
+var (
+	devnetValidatorsFlag = &cli.IntFlag{
+		Name:  "validators",
+		Usage: "Number of the validator nodes",
+		Value: 3,
+	}
+	devnetPeriodFlag = &cli.Uint64Flag{
+		Name:  "period",
+		Usage: "Block period in seconds",
+		Value: 1,
+	}
+	devnetEpochFlag = &cli.Uint64Flag{
+		Name:  "epoch",
+		Usage: "Number of blocks in an epoch",
+		Value: 20,
+	}
+	devnetP2PPortFlag = &cli.IntFlag{
+		Name:  "p2p.port",
+		Usage: "P2P listening port of the first node, incremented for each node",
+		Value: 30311,
+	}
+	devnetHTTPPortFlag = &cli.IntFlag{
+		Name:  "http.port",
+		Usage: "HTTP-RPC server listening port of the first node, incremented for each node",
+		Value: node.DefaultHTTPPort,
+	}
+
+	oasysDevnetCommand = &cli.Command{
+		Name:     "devnet",
+		Usage:    "Launch a local Oasys network of validator nodes in process",
+		Action:   oasysDevnet,
+		Category: "OASYS COMMANDS",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			devnetValidatorsFlag,
+			devnetPeriodFlag,
+			devnetEpochFlag,
+			devnetP2PPortFlag,
+			devnetHTTPPortFlag,
+		},
+		Description: `
+	geth oasys devnet --validators 3 --period 1 --epoch 20
+
+generates the keys of the validators including the BLS vote keys, writes a
+genesis with the system contracts, then runs the validator nodes connected to
+each other with the fast finality enabled. The validators join the StakeManager
+in the first epoch, so that they keep producing blocks in the following epochs.
+
+Each node is placed in <datadir>/node<N> and serves HTTP-RPC on the given port
+plus N. All keys are encrypted with the password "oasys-devnet". Never use the
+generated keys on public networks.`,
+	}
+)
+
+type devnetValidator struct {
+	dir     string
+	key     *keystore.Key
+	account ethaccounts.Account
+	voteKey []byte
+}
+
+func oasysDevnet(ctx *cli.Context) error {
+	var (
+		count  = ctx.Int(devnetValidatorsFlag.Name)
+		period = ctx.Uint64(devnetPeriodFlag.Name)
+		epoch  = ctx.Uint64(devnetEpochFlag.Name)
+		root   = ctx.String(utils.DataDirFlag.Name)
+	)
+	if count < 1 {
+		return fmt.Errorf("invalid number of validators: %d", count)
+	}
+	if !ctx.IsSet(utils.DataDirFlag.Name) {
+		dir, err := os.MkdirTemp("", "oasys-devnet")
+		if err != nil {
+			return err
+		}
+		root = dir
+	}
+
+	validators := make([]*devnetValidator, count)
+	for i := range validators {
+		v, err := newDevnetValidator(filepath.Join(root, fmt.Sprintf("node%d", i)))
+		if err != nil {
+			return fmt.Errorf("failed to create validator %d: %v", i, err)
+		}
+		validators[i] = v
+	}
+	genesis, err := devnetGenesis(validators, period, epoch)
+	if err != nil {
+		return err
+	}
+	if data, err := json.MarshalIndent(genesis, "", "  "); err != nil {
+		return err
+	} else if err := os.WriteFile(filepath.Join(root, "genesis.json"), data, 0644); err != nil {
+		return err
+	}
+
+	var (
+		stacks   = make([]*node.Node, count)
+		backends = make([]*eth.Ethereum, count)
+	)
+	defer func() {
+		for _, stack := range stacks {
+			if stack != nil {
+				stack.Close()
+			}
+		}
+	}()
+	for i, v := range validators {
+		stack, backend, err := startDevnetNode(v, genesis, ctx.Int(devnetP2PPortFlag.Name)+i, ctx.Int(devnetHTTPPortFlag.Name)+i)
+		if err != nil {
+			return fmt.Errorf("failed to start node %d: %v", i, err)
+		}
+		stacks[i], backends[i] = stack, backend
+	}
+	// Dial each pair from one side only, otherwise the simultaneous connections
+	// are dropped as duplicates.
+	for i, stack := range stacks {
+		for j, peer := range stacks {
+			if i != j {
+				stack.Server().AddTrustedPeer(peer.Server().Self())
+			}
+			if i < j {
+				stack.Server().AddPeer(peer.Server().Self())
+			}
+		}
+	}
+	threshold := params.InitialEnvironmentValue(genesis.Config.Oasys).ValidatorThreshold
+	for i, v := range validators {
+		if err := joinDevnetValidator(backends[i], v, threshold); err != nil {
+			return fmt.Errorf("failed to join validator %d: %v", i, err)
+		}
+	}
+	log.Info("Oasys devnet is running", "datadir", root, "validators", count, "chainid", devnetChainID)
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+	<-sigc
+	log.Info("Shutting down the devnet")
+	return nil
+}
+
+// newDevnetValidator generates the account and the BLS vote key of a validator.
+func newDevnetValidator(dir string) (*devnetValidator, error) {
+	privkey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	ks := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(privkey, devnetPassword)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "password.txt"), []byte(devnetPassword), 0600); err != nil {
+		return nil, err
+	}
+	voteKey, err := createDevnetVoteKey(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &devnetValidator{
+		dir:     dir,
+		key:     &keystore.Key{Address: account.Address, PrivateKey: privkey},
+		account: account,
+		voteKey: voteKey,
+	}, nil
+}
+
+// createDevnetVoteKey creates a BLS wallet in the data directory and imports a
+// new BLS key into it, returning the public key.
+func createDevnetVoteKey(dir string) ([]byte, error) {
+	manager, err := accounts.NewCLIManager(
+		accounts.WithWalletDir(filepath.Join(dir, BLSWalletPath)),
+		accounts.WithWalletPassword(devnetPassword),
+		accounts.WithKeymanagerType(keymanager.Local),
+		accounts.WithSkipMnemonicConfirm(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	w, err := manager.WalletCreate(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	km, err := w.InitializeKeymanager(context.Background(), iface.InitKeymanagerConfig{ListenForChanges: false})
+	if err != nil {
+		return nil, err
+	}
+	importer, ok := km.(keymanager.Importer)
+	if !ok {
+		return nil, fmt.Errorf("the BLS keymanager cannot import keystores")
+	}
+
+	secretKey, err := bls.RandKey()
+	if err != nil {
+		return nil, err
+	}
+	encryptor := keystorev4.New()
+	cryptoFields, err := encryptor.Encrypt(secretKey.Marshal(), devnetPassword)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	pubkey := secretKey.PublicKey().Marshal()
+	_, err = accounts.ImportAccounts(context.Background(), &accounts.ImportAccountsConfig{
+		Importer: importer,
+		Keystores: []*keymanager.Keystore{{
+			Crypto:  cryptoFields,
+			ID:      id.String(),
+			Pubkey:  fmt.Sprintf("%x", pubkey),
+			Version: encryptor.Version(),
+			Name:    encryptor.Name(),
+		}},
+		AccountPassword: devnetPassword,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pubkey, nil
+}
+
+// devnetGenesis assembles the genesis of the devnet. The initial validators are
+// listed in the extra-data, allowed to join the StakeManager by the AllowList
+// contract, and funded enough to stake the validator threshold.
+func devnetGenesis(validators []*devnetValidator, period, epoch uint64) (*core.Genesis, error) {
+	config := *params.OasysTestnetChainConfig
+	zero := uint64(0)
+	config.ChainID = big.NewInt(devnetChainID)
+	config.ShanghaiTime = &zero
+	config.CancunTime = &zero
+	config.Oasys = &params.OasysConfig{
+		Period:      period,
+		Epoch:       epoch,
+		KeepPeriods: true,
+		Environment: &params.OasysEnvironment{
+			JailThreshold: new(big.Int).SetUint64(epoch),
+		},
+	}
+
+	var (
+		alloc   = oasys.GenesisAlloc()
+		balance = new(big.Int).Mul(params.InitialEnvironmentValue(config.Oasys).ValidatorThreshold, big.NewInt(2))
+		extra   = make([]byte, 32)
+		owners  = make([]common.Address, len(validators))
+	)
+	for i, v := range validators {
+		alloc[v.account.Address] = types.Account{Balance: balance}
+		extra = append(extra, v.account.Address.Bytes()...)
+		owners[i] = v.account.Address
+	}
+	extra = append(extra, make([]byte, crypto.SignatureLength)...)
+
+	address, allowList, err := contracts.BuildAllowListAlloc(owners[0], owners)
+	if err != nil {
+		return nil, err
+	}
+	alloc[address] = allowList
+
+	return &core.Genesis{
+		Config:     &config,
+		Timestamp:  uint64(time.Now().Unix()),
+		ExtraData:  extra,
+		GasLimit:   devnetGasLimit,
+		Difficulty: big.NewInt(1),
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+		Alloc:      alloc,
+	}, nil
+}
+
+func startDevnetNode(v *devnetValidator, genesis *core.Genesis, p2pPort, httpPort int) (*node.Node, *eth.Ethereum, error) {
+	cfg := node.DefaultConfig
+	cfg.Name = clientIdentifier
+	cfg.DataDir = v.dir
+	cfg.UseLightweightKDF = true
+	cfg.P2P.ListenAddr = fmt.Sprintf("127.0.0.1:%d", p2pPort)
+	cfg.P2P.NoDiscovery = true
+	cfg.P2P.NAT = nil
+	cfg.HTTPHost = "127.0.0.1"
+	cfg.HTTPPort = httpPort
+	cfg.HTTPModules = []string{"eth", "net", "web3", "txpool", "miner", "oasys"}
+	cfg.AuthPort = 0
+	cfg.BLSPasswordFile = filepath.Join(v.dir, "password.txt")
+	cfg.BLSWalletDir = filepath.Join(v.dir, BLSWalletPath)
+	cfg.VoteJournalDir = filepath.Join(v.dir, "voteJournal")
+
+	stack, err := node.New(&cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	ks := keystore.NewKeyStore(filepath.Join(v.dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	stack.AccountManager().AddBackend(ks)
+
+	ethcfg := ethconfig.Defaults
+	ethcfg.Genesis = genesis
+	ethcfg.NetworkId = genesis.Config.ChainID.Uint64()
+	ethcfg.SyncMode = downloader.FullSync
+	ethcfg.Miner.Etherbase = v.account.Address
+	ethcfg.Miner.VoteEnable = true
+	backend, err := eth.New(stack, &ethcfg)
+	if err != nil {
+		stack.Close()
+		return nil, nil, err
+	}
+	if err := stack.Start(); err != nil {
+		stack.Close()
+		return nil, nil, err
+	}
+	if err := ks.Unlock(v.account, devnetPassword); err != nil {
+		stack.Close()
+		return nil, nil, err
+	}
+	if err := backend.StartMining(); err != nil {
+		stack.Close()
+		return nil, nil, err
+	}
+	return stack, backend, nil
+}
+
+// joinDevnetValidator sends the transactions registering the validator to the
+// StakeManager, which are included in the first epoch.
+func joinDevnetValidator(backend *eth.Ethereum, v *devnetValidator, threshold *big.Int) error {
+	calls := []struct {
+		method string
+		args   []interface{}
+		value  *big.Int
+	}{
+		{"joinValidator", []interface{}{v.account.Address}, new(big.Int)},
+		{"updateBLSPublicKey", []interface{}{v.voteKey}, new(big.Int)},
+		{"stake", []interface{}{v.account.Address, uint8(0), threshold}, threshold}, // 0 = OAS
+	}
+	var (
+		signer = types.LatestSignerForChainID(backend.BlockChain().Config().ChainID)
+		nonce  = backend.TxPool().Nonce(v.account.Address)
+		txs    []*types.Transaction
+	)
+	for i, call := range calls {
+		to, data, err := oasys.PackStakeManager(call.method, call.args...)
+		if err != nil {
+			return fmt.Errorf("failed to pack %s: %v", call.method, err)
+		}
+		tx, err := types.SignNewTx(v.key.PrivateKey, signer, &types.DynamicFeeTx{
+			ChainID:   backend.BlockChain().Config().ChainID,
+			Nonce:     nonce + uint64(i),
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: big.NewInt(100 * params.GWei),
+			Gas:       devnetTxGas,
+			To:        &to,
+			Value:     call.value,
+			Data:      data,
+		})
+		if err != nil {
+			return err
+		}
+		txs = append(txs, tx)
+	}
+	for _, err := range backend.TxPool().Add(txs, true, false) {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	contracts "github.com/ethereum/go-ethereum/contracts/oasys"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestDevnetValidator(t *testing.T) {
+	dir := t.TempDir()
+	v, err := newDevnetValidator(dir)
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+	if crypto.PubkeyToAddress(v.key.PrivateKey.PublicKey) != v.account.Address || v.key.Address != v.account.Address {
+		t.Errorf("key mismatch: have %x, account %x", v.key.Address, v.account.Address)
+	}
+	if len(v.voteKey) != types.BLSPublicKeyLength {
+		t.Errorf("vote key length mismatch: have %d, want %d", len(v.voteKey), types.BLSPublicKeyLength)
+	}
+	// The node reads the keys and the password from the data directory
+	if password, err := os.ReadFile(filepath.Join(dir, "password.txt")); err != nil || string(password) != devnetPassword {
+		t.Errorf("password mismatch: have %q, %v", password, err)
+	}
+	if keys, err := os.ReadDir(filepath.Join(dir, "keystore")); err != nil || len(keys) != 1 {
+		t.Errorf("keystore mismatch: have %d keys, %v", len(keys), err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, BLSWalletPath)); err != nil {
+		t.Errorf("BLS wallet missing: %v", err)
+	}
+
+	// The transactions joining the StakeManager are packable
+	threshold := params.InitialEnvironmentValue(&params.OasysConfig{}).ValidatorThreshold
+	for method, args := range map[string][]interface{}{
+		"joinValidator":      {v.account.Address},
+		"updateBLSPublicKey": {v.voteKey},
+		"stake":              {v.account.Address, uint8(0), threshold},
+	} {
+		if _, _, err := oasys.PackStakeManager(method, args...); err != nil {
+			t.Errorf("failed to pack %s: %v", method, err)
+		}
+	}
+}
+
+func TestDevnetGenesis(t *testing.T) {
+	validators := make([]*devnetValidator, 3)
+	for i := range validators {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		validators[i] = &devnetValidator{dir: fmt.Sprintf("node%d", i)}
+		validators[i].account.Address = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	genesis, err := devnetGenesis(validators, 1, 20)
+	if err != nil {
+		t.Fatalf("failed to assemble genesis: %v", err)
+	}
+
+	config := genesis.Config
+	if config.ChainID.Cmp(big.NewInt(devnetChainID)) != 0 {
+		t.Errorf("chain id mismatch: have %v, want %d", config.ChainID, devnetChainID)
+	}
+	if config.Oasys.Period != 1 || config.Oasys.Epoch != 20 || !config.Oasys.KeepPeriods {
+		t.Errorf("oasys config mismatch: %+v", config.Oasys)
+	}
+	if !config.IsCancun(common.Big0, 0) {
+		t.Error("cancun not enabled at genesis")
+	}
+
+	// The initial validators are listed in the extra-data
+	if want := 32 + len(validators)*common.AddressLength + crypto.SignatureLength; len(genesis.ExtraData) != want {
+		t.Fatalf("extra-data length mismatch: have %d, want %d", len(genesis.ExtraData), want)
+	}
+	for i, v := range validators {
+		have := common.BytesToAddress(genesis.ExtraData[32+i*common.AddressLength : 32+(i+1)*common.AddressLength])
+		if have != v.account.Address {
+			t.Errorf("validator %d mismatch: have %x, want %x", i, have, v.account.Address)
+		}
+	}
+
+	// The system contracts are allocated, and the validators funded to stake
+	balance := new(big.Int).Mul(params.InitialEnvironmentValue(config.Oasys).ValidatorThreshold, big.NewInt(2))
+	for _, v := range validators {
+		if have := genesis.Alloc[v.account.Address].Balance; have.Cmp(balance) != 0 {
+			t.Errorf("balance mismatch: have %v, want %v", have, balance)
+		}
+	}
+	for address, account := range oasys.GenesisAlloc() {
+		if have, ok := genesis.Alloc[address]; !ok || !bytes.Equal(have.Code, account.Code) {
+			t.Errorf("contract %x mismatch", address)
+		}
+	}
+	if block := genesis.ToBlock(); block.Extra() == nil || block.GasLimit() != devnetGasLimit {
+		t.Errorf("genesis block mismatch: extra %x, gas limit %d", block.Extra(), block.GasLimit())
+	}
+
+	// The validators are allowed to join the StakeManager by the AllowList
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	for address, account := range genesis.Alloc {
+		statedb.SetCode(address, account.Code)
+		for key, val := range account.Storage {
+			statedb.SetState(address, key, val)
+		}
+	}
+	allowList := common.HexToAddress(contracts.AllowListAddress)
+	if len(statedb.GetCode(allowList)) == 0 {
+		t.Fatal("AllowList contract missing")
+	}
+	contains := func(address common.Address) bool {
+		input := append(crypto.Keccak256([]byte("containsAddress(address)"))[:4], common.LeftPadBytes(address.Bytes(), 32)...)
+		ret, _, err := runtime.Call(allowList, input, &runtime.Config{State: statedb})
+		if err != nil {
+			t.Fatalf("failed to call AllowList: %v", err)
+		}
+		return new(big.Int).SetBytes(ret).Sign() != 0
+	}
+	for _, v := range validators {
+		if !contains(v.account.Address) {
+			t.Errorf("validator %x not allowed", v.account.Address)
+		}
+	}
+	if contains(common.Address{0x01}) {
+		t.Error("unknown address allowed")
+	}
+}
+
+func TestDevnetEpoch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the devnet sealing in short mode")
+	}
+	v, err := newDevnetValidator(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+	epoch := uint64(8)
+	genesis, err := devnetGenesis([]*devnetValidator{v}, 1, epoch)
+	if err != nil {
+		t.Fatalf("failed to assemble genesis: %v", err)
+	}
+	stack, backend, err := startDevnetNode(v, genesis, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to start node: %v", err)
+	}
+	defer stack.Close()
+
+	threshold := params.InitialEnvironmentValue(genesis.Config.Oasys).ValidatorThreshold
+	if err := joinDevnetValidator(backend, v, threshold); err != nil {
+		t.Fatalf("failed to join validator: %v", err)
+	}
+
+	// The validators of the second epoch are read from the StakeManager, which
+	// only lists the validator if it joined in the first epoch
+	chain := backend.BlockChain()
+	deadline := time.Now().Add(time.Minute)
+	for chain.CurrentBlock().Number.Uint64() <= 2*epoch {
+		if time.Now().After(deadline) {
+			t.Fatalf("sealing stalled at block %d", chain.CurrentBlock().Number)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	var joined int
+	for number := uint64(1); number <= chain.CurrentBlock().Number.Uint64(); number++ {
+		block := chain.GetBlockByNumber(number)
+		receipts := chain.GetReceiptsByHash(block.Hash())
+		for i, tx := range block.Transactions() {
+			if tx.To() == nil || *tx.To() != common.HexToAddress(contracts.StakeManagerAddress) || i >= len(receipts) {
+				continue
+			}
+			if receipts[i].Status != types.ReceiptStatusSuccessful {
+				t.Errorf("transaction %x reverted in block %d", tx.Hash(), number)
+			}
+			if number > epoch {
+				t.Errorf("transaction %x included after the first epoch in block %d", tx.Hash(), number)
+			}
+			joined++
+		}
+	}
+	if joined < 3 {
+		t.Errorf("joining transactions mismatch: have %d, want at least 3", joined)
+	}
+}
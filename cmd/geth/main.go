@@ -42,6 +42,7 @@ import (
 	"go.uber.org/automaxprocs/maxprocs"
 
 	// Force-load the tracer engines to trigger registration
+	_ "github.com/ethereum/go-ethereum/eth/tracers/consensus"
 	_ "github.com/ethereum/go-ethereum/eth/tracers/js"
 	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
 
@@ -82,6 +83,8 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolPoliciesFlag,
+		utils.TxPoolPolicyConfigFlag,
 		utils.BlobPoolDataDirFlag,
 		utils.BlobPoolDataCapFlag,
 		utils.BlobPoolPriceBumpFlag,
@@ -92,6 +95,7 @@ var (
 		utils.SnapshotFlag,
 		utils.TxLookupLimitFlag, // deprecated
 		utils.TransactionHistoryFlag,
+		utils.TransactionHistoryFinalizedFlag,
 		utils.StateHistoryFlag,
 		utils.LightServeFlag,    // deprecated
 		utils.LightIngressFlag,  // deprecated
@@ -105,12 +109,14 @@ var (
 		utils.BloomFilterSizeFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
+		utils.CacheConsensusFlag,
 		utils.CacheTrieFlag,
 		utils.CacheTrieJournalFlag,   // deprecated
 		utils.CacheTrieRejournalFlag, // deprecated
 		utils.CacheGCFlag,
 		utils.CacheSnapshotFlag,
 		utils.CacheNoPrefetchFlag,
+		utils.ParallelTxFlag,
 		utils.CachePreimagesFlag,
 		utils.CacheLogSizeFlag,
 		utils.FDLimitFlag,
@@ -123,8 +129,13 @@ var (
 		utils.MinerGasLimitFlag,
 		utils.MinerGasPriceFlag,
 		utils.MinerEtherbaseFlag,
+		utils.MinerSignersFlag,
 		utils.MinerExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
+		utils.MinerMinPeersFlag,
+		utils.MinerAttestationWaitFlag,
+		utils.MinerHealthBeaconFlag,
+		utils.MinerSystemGasReserveFlag,
 		utils.MinerNewPayloadTimeout,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
@@ -146,6 +157,7 @@ var (
 		utils.GpoPercentileFlag,
 		utils.GpoMaxGasPriceFlag,
 		utils.GpoIgnoreGasPriceFlag,
+		utils.GpoMinGasPriceFlag,
 		configFileFlag,
 		utils.VotingEnabledFlag,
 		utils.DisableVoteAttestationFlag,
@@ -153,6 +165,13 @@ var (
 		utils.BLSPasswordFileFlag,
 		utils.BLSWalletDirFlag,
 		utils.VoteJournalDirFlag,
+		utils.ValidatorMeshFlag,
+		utils.VoteProposersFirstFlag,
+		utils.JailWatcherOwnerFlag,
+		utils.JailWatcherDryRunFlag,
+		utils.StandbyLeaseFlag,
+		utils.SealGuardFlag,
+		utils.OasysShadowForkFlag,
 		utils.VoteKeyNameFlag,
 		utils.LogDebugFlag,
 		utils.LogBacktraceAtFlag,
@@ -186,6 +205,18 @@ var (
 		utils.RPCGlobalGasCapFlag,
 		utils.RPCGlobalEVMTimeoutFlag,
 		utils.RPCGlobalTxFeeCapFlag,
+		utils.FinalityConfirmationsFlag,
+		utils.FinalityStallLimitFlag,
+		utils.InclusionGasPriceFloorFlag,
+		utils.EpochWebhookFlag,
+		utils.FinalityHeadsFlag,
+		utils.OasysDebugScheduleFlag,
+		utils.OasysCliqueCompatFlag,
+		utils.OasysRewardAuditFlag,
+		utils.OasysConsensusTraceFlag,
+		utils.OasysConsensusTraceJsonConfigFlag,
+		utils.OasysReadOnlyFlag,
+		utils.OasysNetworkFlag,
 		utils.AllowUnprotectedTxs,
 		utils.BatchRequestLimit,
 		utils.BatchResponseMaxSize,
@@ -253,6 +284,8 @@ func init() {
 		// See verkle.go
 		verkleCommand,
 		blsCommand,
+		// See oasyscmd.go
+		oasysCommand,
 	}
 	if logTestCommand != nil {
 		app.Commands = append(app.Commands, logTestCommand)
@@ -372,6 +405,7 @@ func startNode(ctx *cli.Context, stack *node.Node, backend ethapi.Backend, isCon
 
 	// Start up the node itself
 	utils.StartNode(ctx, stack, isConsole)
+	watchEngineReload(ctx, stack)
 
 	// Unlock any account specifically requested
 	unlockAccounts(ctx, stack)
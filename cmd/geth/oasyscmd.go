@@ -0,0 +1,618 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	contracts "github.com/ethereum/go-ethereum/contracts/oasys"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vote"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	oasysCommand = &cli.Command{
+		Name:      "oasys",
+		Usage:     "Maintain the Oasys consensus state of the local node",
+		ArgsUsage: "",
+		Category:  "OASYS COMMANDS",
+		Description: `
+
+Offline maintenance commands for nodes running the Oasys proof-of-stake engine.
+The node must be stopped before running any of these commands.`,
+		Subcommands: []*cli.Command{
+			{
+				Name:      "setHead",
+				Usage:     "Rewind the local chain together with the Oasys consensus state",
+				ArgsUsage: "<number>",
+				Action:    oasysSetHead,
+				Category:  "OASYS COMMANDS",
+				Flags: flags.Merge([]cli.Flag{
+					utils.VoteJournalDirFlag,
+				}, utils.DatabaseFlags),
+				Description: `
+	geth oasys setHead <number>
+
+rewinds the chain head to the given block number, then deletes the consensus
+snapshots created after the block and truncates the vote journal so that no
+stale consensus data remains when the node is restarted.
+
+Unlike debug.setHead, it is safe to run on a validator node. Note that votes
+removed from the journal will be cast again once the chain progresses.`,
+			},
+			{
+				Name:      "index-slashes",
+				Usage:     "Index the historical slashes and jails of the validators",
+				ArgsUsage: "[<from> [<to>]]",
+				Action:    oasysIndexSlashes,
+				Category:  "OASYS COMMANDS",
+				Flags:     utils.DatabaseFlags,
+				Description: `
+	geth oasys index-slashes [<from> [<to>]]
+
+scans the canonical blocks in the given range, and indexes the slash transactions
+injected by the engine and the ValidatorSlashed/ValidatorJailed events of the
+StakeManager into the local database. The indexed records can be queried by the
+oasys_getSlashHistory RPC once the node is restarted.
+
+The range defaults to the blocks after the last indexed block up to the head.`,
+			},
+			{
+				Name:      "verify-seal",
+				Usage:     "Audit the seal of a header against the local consensus state",
+				ArgsUsage: "<header RLP | file | hash | number>",
+				Action:    oasysVerifySeal,
+				Category:  "OASYS COMMANDS",
+				Flags:     utils.DatabaseFlags,
+				Description: `
+	geth oasys verify-seal <header RLP | file | hash | number>
+
+recomputes the values the seal of the given header is verified against, and
+prints the recovered sealer, the in-turn proposer, the expected difficulty,
+the backoff time of the coinbase and whether the seal passes the verification.
+It helps investigating the blocks rejected with errWrongDifficulty or
+errUnauthorizedValidator.
+
+The header is given either as a hex encoded RLP, a file containing the RLP in
+binary or hex, or the hash or number of a block in the local chain. The parent
+of the header must be in the local chain.`,
+			},
+			{
+				Name:      "build-genesis",
+				Usage:     "Add the built-in Oasys contracts to a genesis spec",
+				ArgsUsage: "<genesis.json>",
+				Action:    oasysBuildGenesis,
+				Category:  "OASYS COMMANDS",
+				Flags:     []cli.Flag{buildGenesisContractsFlag},
+				Description: `
+	geth oasys build-genesis [--contracts <name>,...] <genesis.json>
+
+reads the given genesis spec, adds the accounts of the built-in contracts with
+their code and storage to its alloc, and prints the resulting spec. The built-in
+contracts can be limited to the given comma separated names. If the spec carries
+the oasys chain config, the system contracts of the engine are added as well.
+The spec must not allocate the addresses of the added contracts.
+
+Unlike the networks deploying the built-in contracts at the early blocks, the
+contracts are present from the genesis, which suits the verse chains. The oasys
+networks built this way keep the embedded contracts at the deployment block.`,
+			},
+			{
+				Name:     "report",
+				Usage:    "Generate the per-epoch activity report of the validators",
+				Action:   oasysReport,
+				Category: "OASYS COMMANDS",
+				Flags: flags.Merge([]cli.Flag{
+					reportFromEpochFlag,
+					reportToEpochFlag,
+					reportFormatFlag,
+				}, utils.DatabaseFlags),
+				Description: `
+	geth oasys report --from-epoch <N> --to-epoch <M> [--format csv|json]
+
+computes the activity of the validators in each epoch of the given range from
+the local chain, and prints it as CSV (one row per epoch and validator) or JSON.
+The report covers the stake, the in-turn blocks scheduled and sealed (uptime),
+the blocks sealed in total, the slash transactions, the commission rate and
+whether it changed from the previous epoch, and the attestation participation.
+
+The ongoing epoch is reported up to the chain head.`,
+			},
+			{
+				Name:     "simulate-reorg",
+				Usage:    "Simulate a reorg between two competing branches",
+				Action:   oasysSimulateReorg,
+				Category: "OASYS COMMANDS",
+				Flags: []cli.Flag{
+					reorgValidatorsFlag,
+					reorgEpochFlag,
+					reorgCommonFlag,
+					reorgDepthAFlag,
+					reorgDepthBFlag,
+					reorgOnlineAFlag,
+					reorgOnlineBFlag,
+					reorgAttestationForkChoiceFlag,
+				},
+				Description: `
+	geth oasys simulate-reorg [--depth.a <N>] [--depth.b <M>] [--online.a <indexes>] [--online.b <indexes>]
+
+builds a chain sealed and voted by all the validators, then two competing
+branches on top of it, each sealed by the online validator with the shortest
+backoff time and voted by the online validators of the branch. The branches
+are fed one after the other to a header-only node in memory, which reports the
+branch it follows, and whether the block justified on branch A was reverted.
+As the node only verifies and inserts the headers, neither the execution of
+the blocks nor the delivery of the blocks and the votes over the network is
+covered.
+
+The validators are given by their comma separated indexes or ranges such as
+"0,2-3", defaulting to all. It helps validating the fork choice and difficulty
+rules under adversarial scenarios, and runs without any data directory.`,
+			},
+			oasysDevnetCommand,
+		},
+	}
+
+	buildGenesisContractsFlag = &cli.StringFlag{
+		Name:  "contracts",
+		Usage: "Comma separated names of the built-in contracts to add (default = all)",
+	}
+	reportFromEpochFlag = &cli.Uint64Flag{
+		Name:     "from-epoch",
+		Usage:    "First epoch of the report",
+		Required: true,
+	}
+	reportToEpochFlag = &cli.Uint64Flag{
+		Name:  "to-epoch",
+		Usage: "Last epoch of the report (default = from-epoch)",
+	}
+	reportFormatFlag = &cli.StringFlag{
+		Name:  "format",
+		Usage: "Output format of the report (csv, json)",
+		Value: "csv",
+	}
+	reorgValidatorsFlag = &cli.IntFlag{
+		Name:  "validators",
+		Usage: "Number of the validators with the same stake",
+		Value: 4,
+	}
+	reorgEpochFlag = &cli.Uint64Flag{
+		Name:  "epoch",
+		Usage: "Number of blocks in an epoch",
+		Value: 100,
+	}
+	reorgCommonFlag = &cli.Uint64Flag{
+		Name:  "common",
+		Usage: "Number of blocks before the fork point",
+		Value: 8,
+	}
+	reorgDepthAFlag = &cli.Uint64Flag{
+		Name:  "depth.a",
+		Usage: "Number of blocks of branch A after the fork point",
+		Value: 4,
+	}
+	reorgDepthBFlag = &cli.Uint64Flag{
+		Name:  "depth.b",
+		Usage: "Number of blocks of branch B after the fork point",
+		Value: 4,
+	}
+	reorgOnlineAFlag = &cli.StringFlag{
+		Name:  "online.a",
+		Usage: "Indexes of the validators online on branch A (default = all)",
+	}
+	reorgOnlineBFlag = &cli.StringFlag{
+		Name:  "online.b",
+		Usage: "Indexes of the validators online on branch B (default = all)",
+	}
+	reorgAttestationForkChoiceFlag = &cli.BoolFlag{
+		Name:  "forkchoice.attestation",
+		Usage: "Activate the attestation weighted fork choice",
+	}
+)
+
+func oasysSetHead(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	number, err := strconv.ParseUint(ctx.Args().First(), 0, 64)
+	if err != nil {
+		return fmt.Errorf("invalid block number: %v", err)
+	}
+
+	stack, cfg := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack, &cfg.Eth, false)
+	defer db.Close()
+	defer chain.Stop()
+
+	engine, ok := chain.Engine().(*oasys.Oasys)
+	if !ok {
+		return errors.New("the chain is not running the oasys consensus engine")
+	}
+	if current := chain.CurrentBlock().Number.Uint64(); number >= current {
+		return fmt.Errorf("the block number must be lower than the current head, number: %d, head: %d", number, current)
+	}
+
+	// Rewind the chain first, the consensus state is rebuilt from the chain.
+	if err := chain.SetHead(number); err != nil {
+		return fmt.Errorf("failed to rewind the chain: %v", err)
+	}
+	if err := engine.Rewind(number); err != nil {
+		return err
+	}
+
+	journalPath := stack.ResolvePath(cfg.Node.VoteJournalDir)
+	if common.FileExist(journalPath) {
+		journal, err := vote.NewVoteJournal(journalPath)
+		if err != nil {
+			return fmt.Errorf("failed to open vote journal: %v", err)
+		}
+		defer journal.Close()
+		if err := journal.TruncateAfter(number); err != nil {
+			return fmt.Errorf("failed to truncate vote journal: %v", err)
+		}
+	}
+
+	head := chain.CurrentBlock()
+	log.Info("Rewound the chain", "number", head.Number, "hash", head.Hash())
+	return nil
+}
+
+func oasysIndexSlashes(ctx *cli.Context) error {
+	if ctx.Args().Len() > 2 {
+		utils.Fatalf("This command accepts at most two arguments.")
+	}
+	stack, cfg := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack, &cfg.Eth, false)
+	defer db.Close()
+	defer chain.Stop()
+
+	engine, ok := chain.Engine().(*oasys.Oasys)
+	if !ok {
+		return errors.New("the chain is not running the oasys consensus engine")
+	}
+	from, to := engine.SlashIndexHead()+1, chain.CurrentBlock().Number.Uint64()
+	for i, arg := range ctx.Args().Slice() {
+		number, err := strconv.ParseUint(arg, 0, 64)
+		if err != nil {
+			return fmt.Errorf("invalid block number: %v", err)
+		}
+		if i == 0 {
+			from = number
+		} else {
+			to = number
+		}
+	}
+	if from > to {
+		log.Info("No blocks to index", "from", from, "to", to)
+		return nil
+	}
+
+	var (
+		start  = time.Now()
+		logged = time.Now()
+	)
+	indexed, err := engine.IndexSlashes(chain, from, to, func(number uint64, records int) {
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Indexing slashes", "number", number, "to", to, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index slashes: %v", err)
+	}
+	log.Info("Indexed slashes", "from", from, "to", to, "records", indexed, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+func oasysVerifySeal(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack, cfg := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack, &cfg.Eth, false)
+	defer db.Close()
+	defer chain.Stop()
+
+	engine, ok := chain.Engine().(*oasys.Oasys)
+	if !ok {
+		return errors.New("the chain is not running the oasys consensus engine")
+	}
+	header, err := parseAuditHeader(chain, ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	audit, err := engine.AuditSeal(chain, header)
+	if err != nil {
+		return fmt.Errorf("failed to audit the seal: %v", err)
+	}
+	out, err := json.MarshalIndent(audit, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// parseAuditHeader resolves the header given to the verify-seal command, which
+// is either a block number or hash of the local chain, or an RLP encoded header
+// given directly or in a file.
+func parseAuditHeader(chain *core.BlockChain, arg string) (*types.Header, error) {
+	if number, err := strconv.ParseUint(arg, 10, 64); err == nil {
+		header := chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, fmt.Errorf("block %d not found", number)
+		}
+		return header, nil
+	}
+	if len(arg) == 2*common.HashLength+2 && strings.HasPrefix(arg, "0x") {
+		header := chain.GetHeaderByHash(common.HexToHash(arg))
+		if header == nil {
+			return nil, fmt.Errorf("block %s not found", arg)
+		}
+		return header, nil
+	}
+	var blob []byte
+	if common.FileExist(arg) {
+		content, err := os.ReadFile(arg)
+		if err != nil {
+			return nil, err
+		}
+		if decoded, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(content)), "0x")); err == nil {
+			blob = decoded
+		} else {
+			blob = content
+		}
+	} else {
+		decoded, err := hex.DecodeString(strings.TrimPrefix(arg, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid header RLP: %v", err)
+		}
+		blob = decoded
+	}
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(blob, header); err != nil {
+		return nil, fmt.Errorf("invalid header RLP: %v", err)
+	}
+	return header, nil
+}
+
+func oasysBuildGenesis(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	file, err := os.Open(ctx.Args().First())
+	if err != nil {
+		return fmt.Errorf("failed to read genesis file: %v", err)
+	}
+	defer file.Close()
+
+	genesis := new(core.Genesis)
+	if err := json.NewDecoder(file).Decode(genesis); err != nil {
+		return fmt.Errorf("invalid genesis file: %v", err)
+	}
+	var names []string
+	if ctx.IsSet(buildGenesisContractsFlag.Name) {
+		for _, name := range strings.Split(ctx.String(buildGenesisContractsFlag.Name), ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	if err := addBuiltinContracts(genesis, names); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(genesis, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// addBuiltinContracts adds the accounts of the built-in contracts of the given
+// names, or all of them, to the genesis alloc. The system contracts of the
+// engine are added only if the genesis is configured for the engine.
+func addBuiltinContracts(genesis *core.Genesis, names []string) error {
+	if genesis.Config == nil {
+		return errors.New("the genesis has no chain config")
+	}
+	builtins, err := contracts.BuildGenesisAlloc(genesis.Config, names...)
+	if err != nil {
+		return err
+	}
+	// The system contracts of the engine, overridden by their upgrades
+	alloc := make(types.GenesisAlloc)
+	if genesis.Config.Oasys != nil {
+		alloc = oasys.GenesisAlloc()
+	}
+	for addr, account := range builtins {
+		alloc[addr] = account
+	}
+	if genesis.Alloc == nil {
+		genesis.Alloc = make(types.GenesisAlloc)
+	}
+	for addr, account := range alloc {
+		if _, ok := genesis.Alloc[addr]; ok {
+			return fmt.Errorf("address of the built-in contract already allocated: %s", addr)
+		}
+		genesis.Alloc[addr] = account
+	}
+	return nil
+}
+
+func oasysReport(ctx *cli.Context) error {
+	from := ctx.Uint64(reportFromEpochFlag.Name)
+	to := from
+	if ctx.IsSet(reportToEpochFlag.Name) {
+		to = ctx.Uint64(reportToEpochFlag.Name)
+	}
+	if from > to {
+		return fmt.Errorf("invalid epoch range, from: %d, to: %d", from, to)
+	}
+	format := ctx.String(reportFormatFlag.Name)
+	if format != "csv" && format != "json" {
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+
+	stack, cfg := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack, &cfg.Eth, false)
+	defer db.Close()
+	defer chain.Stop()
+
+	engine, ok := chain.Engine().(*oasys.Oasys)
+	if !ok {
+		return errors.New("the chain is not running the oasys consensus engine")
+	}
+
+	var (
+		reports = make([]*oasys.EpochReport, 0, to-from+1)
+		start   = time.Now()
+		logged  = time.Now()
+	)
+	for epoch := from; epoch <= to; epoch++ {
+		report, err := engine.EpochReport(chain, epoch)
+		if err != nil {
+			return fmt.Errorf("failed to report epoch %d: %v", epoch, err)
+		}
+		reports = append(reports, report)
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Generating epoch report", "epoch", epoch, "to", to, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if format == "json" {
+		out, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	return writeReportCSV(os.Stdout, reports)
+}
+
+// writeReportCSV writes the epoch reports as CSV, one row per epoch and validator.
+func writeReportCSV(w io.Writer, reports []*oasys.EpochReport) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{
+		"epoch", "first_block", "last_block", "validator", "stake",
+		"scheduled", "in_turn", "sealed", "uptime", "slashes",
+		"commission_rate", "commission_changed",
+		"attestations_expected", "attestations_included", "participation",
+	})
+	for _, report := range reports {
+		for _, v := range report.Validators {
+			out.Write([]string{
+				strconv.FormatUint(report.Epoch, 10),
+				strconv.FormatUint(report.FirstBlock, 10),
+				strconv.FormatUint(report.LastBlock, 10),
+				v.Operator.Hex(),
+				v.Stake.String(),
+				strconv.FormatUint(v.Scheduled, 10),
+				strconv.FormatUint(v.InTurn, 10),
+				strconv.FormatUint(v.Sealed, 10),
+				strconv.FormatFloat(v.Uptime(), 'f', 4, 64),
+				strconv.FormatUint(v.Slashes, 10),
+				report.CommissionRate.String(),
+				strconv.FormatBool(report.CommissionChanged),
+				strconv.FormatUint(v.AttestationsExpected, 10),
+				strconv.FormatUint(v.AttestationsIncluded, 10),
+				strconv.FormatFloat(v.Participation(), 'f', 4, 64),
+			})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func oasysSimulateReorg(ctx *cli.Context) error {
+	scenario := &oasys.ReorgScenario{
+		Validators:            ctx.Int(reorgValidatorsFlag.Name),
+		Epoch:                 ctx.Uint64(reorgEpochFlag.Name),
+		Common:                ctx.Uint64(reorgCommonFlag.Name),
+		AttestationForkChoice: ctx.Bool(reorgAttestationForkChoiceFlag.Name),
+	}
+	for i, flags := range []struct{ depth, online string }{
+		{reorgDepthAFlag.Name, reorgOnlineAFlag.Name},
+		{reorgDepthBFlag.Name, reorgOnlineBFlag.Name},
+	} {
+		online, err := parseValidatorIndexes(ctx.String(flags.online), scenario.Validators)
+		if err != nil {
+			return fmt.Errorf("invalid --%s: %v", flags.online, err)
+		}
+		scenario.Branches[i] = oasys.ReorgBranch{Depth: ctx.Uint64(flags.depth), Online: online}
+	}
+
+	result, err := oasys.SimulateReorg(scenario)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Fork point:  %d\n", result.ForkPoint)
+	for i, b := range result.Branches {
+		fmt.Printf("Branch %c:    head=%d hash=%s td=%v attested=%d justified=%d finalized=%d\n",
+			'A'+i, b.Head, b.Hash.TerminalString(), b.Td, b.Attested, b.Justified, b.Finalized)
+	}
+	fmt.Printf("Winner:      branch %c\n", 'A'+result.Winner)
+	fmt.Printf("Reverted justified block: %v\n", result.RevertedJustified)
+	return nil
+}
+
+// parseValidatorIndexes parses the comma separated indexes or ranges of the
+// validators, defaulting to all of them.
+func parseValidatorIndexes(arg string, count int) ([]int, error) {
+	if arg == "" {
+		indexes := make([]int, count)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes, nil
+	}
+	var (
+		indexes []int
+		seen    = make(map[int]bool)
+	)
+	for _, part := range strings.Split(arg, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		from, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(last); err != nil {
+				return nil, err
+			}
+		}
+		if from < 0 || to < from || to >= count {
+			return nil, fmt.Errorf("validator index out of range: %s", part)
+		}
+		for i := from; i <= to; i++ {
+			if !seen[i] {
+				seen[i] = true
+				indexes = append(indexes, i)
+			}
+		}
+	}
+	return indexes, nil
+}
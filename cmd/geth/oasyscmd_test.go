@@ -0,0 +1,131 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	contracts "github.com/ethereum/go-ethereum/contracts/oasys"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestWriteReportCSV(t *testing.T) {
+	reports := []*oasys.EpochReport{{
+		Epoch:             3,
+		FirstBlock:        11,
+		LastBlock:         15,
+		CommissionRate:    big.NewInt(10),
+		CommissionChanged: true,
+		Attestations:      4,
+		Validators: []*oasys.ValidatorReport{{
+			Operator:             common.HexToAddress("0x01"),
+			Stake:                big.NewInt(1000),
+			Scheduled:            4,
+			InTurn:               3,
+			Sealed:               5,
+			Slashes:              1,
+			AttestationsExpected: 4,
+			AttestationsIncluded: 2,
+		}},
+	}}
+	var buf bytes.Buffer
+	if err := writeReportCSV(&buf, reports); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("wrong number of lines: have %d, want 2", len(lines))
+	}
+	want := "3,11,15,0x0000000000000000000000000000000000000001,1000,4,3,5,0.7500,1,10,true,4,2,0.5000"
+	if lines[1] != want {
+		t.Fatalf("wrong row:\nhave %s\nwant %s", lines[1], want)
+	}
+}
+
+func TestParseValidatorIndexes(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want []int
+		fail bool
+	}{
+		{arg: "", want: []int{0, 1, 2, 3}},
+		{arg: "2", want: []int{2}},
+		{arg: "0,2-3", want: []int{0, 2, 3}},
+		{arg: "1-2, 2", want: []int{1, 2}},
+		{arg: "4", fail: true},
+		{arg: "3-1", fail: true},
+		{arg: "a", fail: true},
+	}
+	for _, tt := range tests {
+		got, err := parseValidatorIndexes(tt.arg, 4)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("%q: expected error, got %v", tt.arg, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.arg, err)
+		} else if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestAddBuiltinContracts(t *testing.T) {
+	var (
+		stakeManager = common.HexToAddress(contracts.StakeManagerAddress)
+		wallet       = common.Address{0x01}
+	)
+	// The verse chains are not configured for the engine
+	verse := &core.Genesis{
+		Config: &params.ChainConfig{ChainID: big.NewInt(12345)},
+		Alloc:  types.GenesisAlloc{wallet: {Balance: big.NewInt(1)}},
+	}
+	if err := addBuiltinContracts(verse, []string{"WOAS"}); err != nil {
+		t.Fatalf("failed to add contracts to verse genesis: %v", err)
+	}
+	if len(verse.Alloc) != 2 {
+		t.Fatalf("accounts mismatch: have %d, want 2", len(verse.Alloc))
+	}
+	if _, ok := verse.Alloc[stakeManager]; ok {
+		t.Fatal("engine contract added to verse genesis")
+	}
+
+	// The oasys networks get the system contracts of the engine as well
+	network := &core.Genesis{Config: &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: &params.OasysConfig{Period: 15, Epoch: 5760}}}
+	if err := addBuiltinContracts(network, []string{"WOAS"}); err != nil {
+		t.Fatalf("failed to add contracts to oasys genesis: %v", err)
+	}
+	if _, ok := network.Alloc[stakeManager]; !ok {
+		t.Fatal("missing engine contract")
+	}
+	if err := addBuiltinContracts(network, []string{"WOAS"}); err == nil {
+		t.Fatal("allocated address overwritten")
+	}
+	if err := addBuiltinContracts(&core.Genesis{}, nil); err == nil {
+		t.Fatal("genesis without chain config accepted")
+	}
+}
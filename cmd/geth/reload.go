@@ -0,0 +1,78 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/urfave/cli/v2"
+)
+
+// watchEngineReload reloads the reloadable engine configuration from the config
+// file on SIGHUP, the same as the admin_reloadEngineConfig RPC.
+func watchEngineReload(ctx *cli.Context, stack *node.Node) {
+	file := ctx.String(configFileFlag.Name)
+	if file == "" {
+		return
+	}
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	go func() {
+		client := stack.Attach()
+		defer client.Close()
+
+		for range sighup {
+			cfg := gethConfig{Eth: ethconfig.Defaults, Node: defaultNodeConfig(), Metrics: metrics.DefaultConfig}
+			if err := loadConfig(file, &cfg); err != nil {
+				log.Error("Failed to reload config file", "file", file, "err", err)
+				continue
+			}
+			var applied []string
+			if err := client.Call(&applied, "admin_reloadEngineConfig", engineReloadConfig(ctx, &cfg.Eth)); err != nil {
+				log.Error("Failed to reload engine config", "file", file, "err", err)
+			}
+		}
+	}()
+}
+
+// engineReloadConfig returns the reloadable engine configuration of the config
+// file. The fields set by the flags are left unchanged, as the flags take
+// precedence over the file.
+func engineReloadConfig(ctx *cli.Context, cfg *ethconfig.Config) *oasys.ReloadConfig {
+	reload := new(oasys.ReloadConfig)
+	if !ctx.IsSet(utils.MinerAttestationWaitFlag.Name) {
+		wait := cfg.Miner.AttestationWait.String()
+		reload.AttestationWait = &wait
+	}
+	if !ctx.IsSet(utils.OasysConsensusTraceFlag.Name) && !ctx.IsSet(utils.OasysConsensusTraceJsonConfigFlag.Name) {
+		reload.Tracer = &cfg.OasysConsensusTrace
+		if cfg.OasysConsensusTraceJsonConfig != "" {
+			reload.TracerConfig = json.RawMessage(cfg.OasysConsensusTraceJsonConfig)
+		}
+	}
+	return reload
+}
@@ -26,12 +26,14 @@ import (
 
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/state/pruner"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -52,6 +54,7 @@ var (
 				Action:    pruneState,
 				Flags: flags.Merge([]cli.Flag{
 					utils.BloomFilterSizeFlag,
+					utils.OasysPruneRetainEpochsFlag,
 				}, utils.NetworkFlags, utils.DatabaseFlags),
 				Description: `
 geth snapshot prune-state <state-root>
@@ -62,6 +65,10 @@ two version states are available: genesis and the specific one.
 
 The default pruning target is the HEAD-127 state.
 
+On an Oasys chain, the states at the last blocks of the recent epochs given
+by --oasys.retain-epochs are retained as well, since the validators of the
+epochs are read from them when verifying the blocks again.
+
 WARNING: it's only supported in hash mode(--state.scheme=hash)".
 `,
 			},
@@ -181,6 +188,14 @@ func pruneState(ctx *cli.Context) error {
 		Datadir:   stack.ResolvePath(""),
 		BloomSize: ctx.Uint64(utils.BloomFilterSizeFlag.Name),
 	}
+	if retain := ctx.Int(utils.OasysPruneRetainEpochsFlag.Name); retain > 0 {
+		roots, err := oasysEpochStateRoots(chaindb, retain)
+		if err != nil {
+			log.Error("Failed to resolve epoch boundary states", "err", err)
+			return err
+		}
+		prunerconfig.Retain = roots
+	}
 	pruner, err := pruner.NewPruner(chaindb, prunerconfig)
 	if err != nil {
 		log.Error("Failed to open snapshot tree", "err", err)
@@ -205,6 +220,20 @@ func pruneState(ctx *cli.Context) error {
 	return nil
 }
 
+// oasysEpochStateRoots returns the state roots of the given number of recent
+// epoch boundaries, or nothing if the chain is not an Oasys chain.
+func oasysEpochStateRoots(chaindb ethdb.Database, epochs int) ([]common.Hash, error) {
+	config := rawdb.ReadChainConfig(chaindb, rawdb.ReadCanonicalHash(chaindb, 0))
+	if config == nil || config.Oasys == nil {
+		return nil, nil
+	}
+	head := rawdb.ReadHeadHeader(chaindb)
+	if head == nil {
+		return nil, errors.New("head header is missing")
+	}
+	return oasys.EpochStateRoots(chaindb, config, head, epochs)
+}
+
 func verifyState(ctx *cli.Context) error {
 	stack, _ := makeConfigNode(ctx)
 	defer stack.Close()
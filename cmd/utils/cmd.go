@@ -34,6 +34,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
@@ -188,18 +189,34 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 	blocks := make(types.Blocks, importBatchSize)
 	n := 0
 	for batch := 0; ; batch++ {
-		// Load a batch of RLP blocks.
+		// Load a batch of RLP blocks. The batch is cut short by the consensus
+		// snapshot records, which must be imported right after their blocks.
 		if checkInterrupt() {
 			return errors.New("interrupted")
 		}
-		i := 0
+		var (
+			i        = 0
+			eof      bool
+			snapshot []byte
+		)
 		for ; i < importBatchSize; i++ {
-			var b types.Block
-			if err := stream.Decode(&b); err == io.EOF {
+			kind, _, err := stream.Kind()
+			if err == io.EOF {
+				eof = true
 				break
 			} else if err != nil {
 				return fmt.Errorf("at block %d: %v", n, err)
 			}
+			if kind == rlp.String {
+				if snapshot, err = stream.Bytes(); err != nil {
+					return fmt.Errorf("at snapshot after block %d: %v", n, err)
+				}
+				break
+			}
+			var b types.Block
+			if err := stream.Decode(&b); err != nil {
+				return fmt.Errorf("at block %d: %v", n, err)
+			}
 			// don't import first block
 			if b.NumberU64() == 0 {
 				i--
@@ -208,19 +225,18 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 			blocks[i] = &b
 			n++
 		}
-		if i == 0 {
+		if i == 0 && eof {
 			break
 		}
 		// Import the batch.
 		if checkInterrupt() {
 			return errors.New("interrupted")
 		}
-		missing := missingBlocks(chain, blocks[:i])
-		if len(missing) == 0 {
-			log.Info("Skipping batch as all blocks present", "batch", batch, "first", blocks[0].Hash(), "last", blocks[i-1].Hash())
-			continue
-		}
-		if failindex, err := chain.InsertChain(missing); err != nil {
+		if missing := missingBlocks(chain, blocks[:i]); len(missing) == 0 {
+			if i > 0 {
+				log.Info("Skipping batch as all blocks present", "batch", batch, "first", blocks[0].Hash(), "last", blocks[i-1].Hash())
+			}
+		} else if failindex, err := chain.InsertChain(missing); err != nil {
 			var failnumber uint64
 			if failindex > 0 && failindex < len(missing) {
 				failnumber = missing[failindex].NumberU64()
@@ -229,10 +245,35 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 			}
 			return fmt.Errorf("invalid block %d: %v", failnumber, err)
 		}
+		// Restore the snapshot before the following blocks are verified, which
+		// would derive it again by the contract calls otherwise.
+		if snapshot != nil {
+			importSnapshot(chain, snapshot)
+		}
 	}
 	return nil
 }
 
+// importSnapshot restores the consensus snapshot read from the chain file, it's
+// replaced by the tests.
+var importSnapshot = importOasysSnapshot
+
+// importOasysSnapshot persists the consensus snapshot exported along with the
+// blocks, so that it does not have to be re-derived by the contract calls. The
+// snapshot is verified against the imported chain, and is skipped on failure.
+func importOasysSnapshot(chain *core.BlockChain, blob []byte) {
+	engine, ok := chain.Engine().(*oasys.Oasys)
+	if !ok {
+		log.Warn("Skipping consensus snapshot of non-oasys chain")
+		return
+	}
+	if snap, err := engine.ImportSnapshot(chain, blob); err != nil {
+		log.Warn("Failed to import consensus snapshot", "err", err)
+	} else {
+		log.Debug("Imported consensus snapshot", "number", snap.Number, "hash", snap.Hash)
+	}
+}
+
 func readList(filename string) ([]string, error) {
 	b, err := os.ReadFile(filename)
 	if err != nil {
@@ -351,8 +392,9 @@ func missingBlocks(chain *core.BlockChain, blocks []*types.Block) []*types.Block
 }
 
 // ExportChain exports a blockchain into the specified file, truncating any data
-// already present in the file.
-func ExportChain(blockchain *core.BlockChain, fn string) error {
+// already present in the file. If snapshots is set, the Oasys consensus snapshots
+// persisted at the checkpoint blocks are written right after their blocks.
+func ExportChain(blockchain *core.BlockChain, fn string, snapshots bool) error {
 	log.Info("Exporting blockchain", "file", fn)
 
 	// Open the file handle and potentially wrap with a gzip stream
@@ -368,7 +410,7 @@ func ExportChain(blockchain *core.BlockChain, fn string) error {
 		defer writer.(*gzip.Writer).Close()
 	}
 	// Iterate over the blocks and export them
-	if err := blockchain.Export(writer); err != nil {
+	if err := blockchain.ExportNCallback(writer, 0, blockchain.CurrentBlock().Number.Uint64(), exportCallback(blockchain, snapshots)); err != nil {
 		return err
 	}
 	log.Info("Exported blockchain", "file", fn)
@@ -378,7 +420,7 @@ func ExportChain(blockchain *core.BlockChain, fn string) error {
 
 // ExportAppendChain exports a blockchain into the specified file, appending to
 // the file if data already exists in it.
-func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, last uint64) error {
+func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, last uint64, snapshots bool) error {
 	log.Info("Exporting blockchain", "file", fn)
 
 	// Open the file handle and potentially wrap with a gzip stream
@@ -394,13 +436,38 @@ func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, las
 		defer writer.(*gzip.Writer).Close()
 	}
 	// Iterate over the blocks and export them
-	if err := blockchain.ExportN(writer, first, last); err != nil {
+	if err := blockchain.ExportNCallback(writer, first, last, exportCallback(blockchain, snapshots)); err != nil {
 		return err
 	}
 	log.Info("Exported blockchain to", "file", fn)
 	return nil
 }
 
+// exportCallback returns the callback writing the Oasys consensus snapshot of
+// each exported checkpoint block as an RLP string, which is told apart from the
+// blocks encoded as RLP lists on import.
+func exportCallback(blockchain *core.BlockChain, snapshots bool) func(io.Writer, *types.Block) error {
+	if !snapshots {
+		return nil
+	}
+	engine, ok := blockchain.Engine().(*oasys.Oasys)
+	if !ok {
+		log.Warn("Skipping consensus snapshots of non-oasys chain")
+		return nil
+	}
+	return func(w io.Writer, block *types.Block) error {
+		number := block.NumberU64()
+		if number == 0 || engine.LatestCheckpoint(number) != number || !engine.HasSnapshot(block.Hash()) {
+			return nil
+		}
+		blob, err := engine.EncodedSnapshot(block.Hash())
+		if err != nil {
+			return err
+		}
+		return rlp.Encode(w, blob)
+	}
+}
+
 // ExportHistory exports blockchain history into the specified directory,
 // following the Era format.
 func ExportHistory(bc *core.BlockChain, dir string, first, last, step uint64) error {
@@ -19,11 +19,16 @@ package utils
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -197,3 +202,50 @@ func TestImportFutureFormat(t *testing.T) {
 		t.Fatalf("wrong error: %v", err)
 	}
 }
+
+// TestImportChainSnapshots checks that the consensus snapshots exported along
+// with the blocks are restored right after their blocks, before the following
+// blocks are verified.
+func TestImportChainSnapshots(t *testing.T) {
+	genesis := &core.Genesis{Config: params.TestChainConfig}
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 10, nil)
+
+	f := filepath.Join(t.TempDir(), "chain.rlp")
+	fh, err := os.Create(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range blocks {
+		if err := rlp.Encode(fh, block); err != nil {
+			t.Fatal(err)
+		}
+		if number := block.NumberU64(); number%4 == 0 {
+			if err := rlp.Encode(fh, []byte(fmt.Sprintf("snapshot-%d", number))); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	fh.Close()
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+
+	var restored []string
+	defer func(fn func(*core.BlockChain, []byte)) { importSnapshot = fn }(importSnapshot)
+	importSnapshot = func(chain *core.BlockChain, blob []byte) {
+		restored = append(restored, fmt.Sprintf("%s@%d", blob, chain.CurrentBlock().Number))
+	}
+	if err := ImportChain(chain, f); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 10 {
+		t.Fatalf("head mismatch: have %d, want 10", head)
+	}
+	want := []string{"snapshot-4@4", "snapshot-8@8"}
+	if fmt.Sprint(restored) != fmt.Sprint(want) {
+		t.Fatalf("restored snapshots mismatch: have %v, want %v", restored, want)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -109,6 +110,11 @@ var (
 		Usage:    "Root directory for ancient data (default = inside chaindata)",
 		Category: flags.EthCategory,
 	}
+	ConsensusDataDirFlag = &flags.DirectoryFlag{
+		Name:     "datadir.consensus",
+		Usage:    "Root directory for the Oasys consensus snapshots (default = inside chaindata)",
+		Category: flags.EthCategory,
+	}
 	MinFreeDiskSpaceFlag = &flags.DirectoryFlag{
 		Name:     "datadir.minfreedisk",
 		Usage:    "Minimum free disk space in MB, once reached triggers auto shut down (default = --cache.gc converted to MB, 0 = disabled)",
@@ -156,6 +162,11 @@ var (
 		Usage:    "Holesky network: pre-configured proof-of-stake test network",
 		Category: flags.EthCategory,
 	}
+	OasysNetworkFlag = &cli.StringFlag{
+		Name:     "oasys.network",
+		Usage:    "Oasys network preset: mainnet, testnet, private or the path of a preset JSON file",
+		Category: flags.EthCategory,
+	}
 	// Dev mode
 	DeveloperFlag = &cli.BoolFlag{
 		Name:     "dev",
@@ -243,6 +254,12 @@ var (
 		Value:    2048,
 		Category: flags.EthCategory,
 	}
+	OasysPruneRetainEpochsFlag = &cli.IntFlag{
+		Name:     "oasys.retain-epochs",
+		Usage:    "Number of recent epoch boundary states retained on pruning, which the validators are read from on a deep verification",
+		Value:    2,
+		Category: flags.EthCategory,
+	}
 	OverrideCancun = &cli.Uint64Flag{
 		Name:     "override.cancun",
 		Usage:    "Manually specify the Cancun fork timestamp, overriding the bundled setting",
@@ -300,6 +317,11 @@ var (
 		Value:    ethconfig.Defaults.TransactionHistory,
 		Category: flags.StateCategory,
 	}
+	TransactionHistoryFinalizedFlag = &cli.BoolFlag{
+		Name:     "history.transactions.finalized",
+		Usage:    "Count the transactions index history back from the finalized block instead of head, never pruning non-finalized blocks",
+		Category: flags.StateCategory,
+	}
 	// Transaction pool settings
 	TxPoolLocalsFlag = &cli.StringFlag{
 		Name:     "txpool.locals",
@@ -365,6 +387,16 @@ var (
 		Value:    ethconfig.Defaults.TxPool.Lifetime,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolPoliciesFlag = &cli.StringFlag{
+		Name:     "txpool.policies",
+		Usage:    "Comma separated admission policies applied to the transactions entering the pool (allowlist, calldata, ratelimit)",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolPolicyConfigFlag = &cli.StringFlag{
+		Name:     "txpool.policies.config",
+		Usage:    "Admission policy configurations keyed by the policy names (JSON)",
+		Category: flags.TxPoolCategory,
+	}
 	// Blob transaction pool settings
 	BlobPoolDataDirFlag = &cli.StringFlag{
 		Name:     "blobpool.datadir",
@@ -397,6 +429,12 @@ var (
 		Value:    50,
 		Category: flags.PerfCategory,
 	}
+	CacheConsensusFlag = &cli.IntFlag{
+		Name:     "cache.consensus",
+		Usage:    "Megabytes of memory allocated to the consensus database (only used with --datadir.consensus)",
+		Value:    ethconfig.Defaults.ConsensusDatabaseCache,
+		Category: flags.PerfCategory,
+	}
 	CacheTrieFlag = &cli.IntFlag{
 		Name:     "cache.trie",
 		Usage:    "Percentage of cache memory allowance to use for trie caching (default = 15% full mode, 30% archive mode)",
@@ -420,6 +458,11 @@ var (
 		Usage:    "Disable heuristic state prefetch during block import (less CPU and disk IO, more time waiting for data)",
 		Category: flags.PerfCategory,
 	}
+	ParallelTxFlag = &cli.BoolFlag{
+		Name:     "parallel.tx",
+		Usage:    "Execute the transactions of the imported blocks in parallel, falling back to the serial execution on conflicts",
+		Category: flags.PerfCategory,
+	}
 	CachePreimagesFlag = &cli.BoolFlag{
 		Name:     "cache.preimages",
 		Usage:    "Enable recording the SHA3/keccak preimages of trie keys",
@@ -466,6 +509,11 @@ var (
 		Usage:    "0x prefixed public address for block mining rewards",
 		Category: flags.MinerCategory,
 	}
+	MinerSignersFlag = &cli.StringFlag{
+		Name:     "miner.signers",
+		Usage:    "Comma separated list of additional unlocked validator accounts to seal the blocks for (test networks only)",
+		Category: flags.MinerCategory,
+	}
 	MinerExtraDataFlag = &cli.StringFlag{
 		Name:     "miner.extradata",
 		Usage:    "Block extra data set by the miner (default = client version)",
@@ -477,6 +525,29 @@ var (
 		Value:    ethconfig.Defaults.Miner.Recommit,
 		Category: flags.MinerCategory,
 	}
+	MinerMinPeersFlag = &cli.IntFlag{
+		Name:     "miner.minpeers",
+		Usage:    "Minimum number of connected peers required to seal blocks (0 = no check)",
+		Value:    ethconfig.Defaults.Miner.MinPeers,
+		Category: flags.MinerCategory,
+	}
+	MinerHealthBeaconFlag = &cli.BoolFlag{
+		Name:     "miner.healthbeacon",
+		Usage:    "Embed the client version and the vote status in the extra data of the sealed blocks",
+		Category: flags.MinerCategory,
+	}
+	MinerSystemGasReserveFlag = &cli.Uint64Flag{
+		Name:     "miner.systemgasreserve",
+		Usage:    "Gas of each block kept out of the transaction selection for the Oasys system transactions",
+		Value:    ethconfig.Defaults.Miner.SystemGasReserve,
+		Category: flags.MinerCategory,
+	}
+	MinerAttestationWaitFlag = &cli.DurationFlag{
+		Name:     "miner.attestationwait",
+		Usage:    "Maximum time to postpone sealing for the votes close to the quorum of the vote attestation, at most a quarter of the block period (0 = no wait)",
+		Value:    ethconfig.Defaults.Miner.AttestationWait,
+		Category: flags.MinerCategory,
+	}
 	MinerNewPayloadTimeout = &cli.DurationFlag{
 		Name:     "miner.newpayload-timeout",
 		Usage:    "Specify the maximum time allowance for creating a new payload",
@@ -535,6 +606,61 @@ var (
 		Value:    ethconfig.Defaults.RPCTxFeeCap,
 		Category: flags.APICategory,
 	}
+	FinalityConfirmationsFlag = &cli.Uint64Flag{
+		Name:     "oasys.finality-confirmations",
+		Usage:    "Serve the justified block or the block with the given confirmations, whichever is newer, as the latest block over RPC (0 = disabled)",
+		Category: flags.APICategory,
+	}
+	FinalityStallLimitFlag = &cli.Uint64Flag{
+		Name:     "oasys.finality-breaker",
+		Usage:    "Freeze the latest and safe blocks served over RPC once no block has been justified for the given number of blocks, until the finality recovers (0 = disabled)",
+		Category: flags.APICategory,
+	}
+	InclusionGasPriceFloorFlag = &flags.BigFlag{
+		Name:     "oasys.inclusion-floor",
+		Usage:    "Track per validator whether the proposers include the pending transactions paying at least the given gas price (unset = disabled)",
+		Category: flags.MetricsCategory,
+	}
+	EpochWebhookFlag = &cli.StringFlag{
+		Name:     "oasys.epoch-webhook",
+		Usage:    "URL to post the start of the epochs with the new validator set and environment to, retried until delivered (empty = disabled)",
+		Category: flags.APICategory,
+	}
+	FinalityHeadsFlag = &cli.BoolFlag{
+		Name:     "oasys.finality-heads",
+		Usage:    "Include the justified and finalized block numbers in the newHeads subscription payloads",
+		Category: flags.APICategory,
+	}
+	OasysReadOnlyFlag = &cli.BoolFlag{
+		Name:     "oasys.readonly",
+		Usage:    "Run the Oasys engine without the signer and the vote machinery, for RPC and archive nodes (mining and voting are refused)",
+		Category: flags.APICategory,
+	}
+	OasysDebugScheduleFlag = &cli.BoolFlag{
+		Name:     "oasys.debug-schedule",
+		Usage:    "Enable the oasys_debugSchedule API computing proposer schedules (testing only)",
+		Category: flags.APICategory,
+	}
+	OasysCliqueCompatFlag = &cli.BoolFlag{
+		Name:     "oasys.clique-compat",
+		Usage:    "Serve the active validators under the clique API namespace (clique_getSigners, clique_getSnapshot) for legacy tooling",
+		Category: flags.APICategory,
+	}
+	OasysRewardAuditFlag = &cli.BoolFlag{
+		Name:     "oasys.reward-audit",
+		Usage:    "Verify the rewards minted to the StakeManager against the emission formula on startup (archive mode only)",
+		Category: flags.EthCategory,
+	}
+	OasysConsensusTraceFlag = &cli.StringFlag{
+		Name:     "oasys.consensustrace",
+		Usage:    "Name of the tracer receiving the consensus events of the Oasys engine, such as the snapshots, epochs and slashes (the VM is not traced)",
+		Category: flags.EthCategory,
+	}
+	OasysConsensusTraceJsonConfigFlag = &cli.StringFlag{
+		Name:     "oasys.consensustrace.jsonconfig",
+		Usage:    "Consensus tracer configuration (JSON)",
+		Category: flags.EthCategory,
+	}
 	// Authenticated RPC HTTP settings
 	AuthListenFlag = &cli.StringFlag{
 		Name:     "authrpc.addr",
@@ -826,6 +952,11 @@ var (
 		Value:    ethconfig.Defaults.GPO.MaxPrice.Int64(),
 		Category: flags.GasPriceCategory,
 	}
+	GpoMinGasPriceFlag = &cli.Int64Flag{
+		Name:     "gpo.minprice",
+		Usage:    "Minimum transaction priority fee accepted by the Oasys validators, below which gpo never recommends (default = miner gas price)",
+		Category: flags.GasPriceCategory,
+	}
 	GpoIgnoreGasPriceFlag = &cli.Int64Flag{
 		Name:     "gpo.ignoreprice",
 		Usage:    "Gas price below which gpo will ignore transactions",
@@ -963,6 +1094,46 @@ Please note that --` + MetricsHTTPFlag.Name + ` must be set to start the server.
 		Usage:    "Path for the voteJournal dir in fast finality feature (default = inside the datadir)",
 		Category: flags.FastFinalityCategory,
 	}
+	ValidatorMeshFlag = &cli.StringFlag{
+		Name:     "validator-mesh",
+		Usage:    "Comma separated enode://, enr: or enrtree:// URLs of the validator nodes to keep connected to, trusting the ones signed by the current validators",
+		Category: flags.FastFinalityCategory,
+	}
+	VoteProposersFirstFlag = &cli.BoolFlag{
+		Name:     "vote.proposers-first",
+		Usage:    "Send the votes to the peers delivering the blocks of the upcoming proposers first",
+		Category: flags.FastFinalityCategory,
+	}
+	JailWatcherOwnerFlag = &cli.StringFlag{
+		Name:     "oasys.jail-watcher.owner",
+		Usage:    "Owner address of the local validator to activate from once its jail period has elapsed (the account must be unlocked)",
+		Category: flags.MinerCategory,
+	}
+	JailWatcherDryRunFlag = &cli.BoolFlag{
+		Name:     "oasys.jail-watcher.dryrun",
+		Usage:    "Only log the validator activation instead of sending the transaction",
+		Category: flags.MinerCategory,
+	}
+	StandbyLeaseFlag = &cli.DurationFlag{
+		Name:     "oasys.standby.lease",
+		Usage:    "Run as a hot standby of the primary validator node sharing its keys, sealing and voting only once no block or vote of the validator has been seen for the lease (0 = disabled)",
+		Category: flags.MinerCategory,
+	}
+	SealGuardFlag = &cli.BoolFlag{
+		Name:     "oasys.sealguard",
+		Usage:    "Record the block seals in a slashing protection database to refuse sealing twice at the same height (always enabled with --oasys.standby.lease)",
+		Category: flags.MinerCategory,
+	}
+	OasysShadowForkFlag = &flags.DirectoryFlag{
+		Name:     "oasys.shadowfork",
+		Usage:    "Data directory of a stopped node to replay the canonical blocks from against the local consensus parameters and contracts on a scratch copy of the local chain, reporting the first divergence",
+		Category: flags.EthCategory,
+	}
+	ExportOasysSnapshotsFlag = &cli.BoolFlag{
+		Name:     "oasys.snapshots",
+		Usage:    "Include the Oasys consensus snapshots and their attestations in the exported chain file",
+		Category: flags.EthCategory,
+	}
 	VoteKeyNameFlag = &cli.StringFlag{
 		Name:     "vote-key-name",
 		Usage:    "Name of the BLS public key used for voting (default = first found key)",
@@ -1011,6 +1182,7 @@ var (
 	DatabaseFlags = []cli.Flag{
 		DataDirFlag,
 		AncientFlag,
+		ConsensusDataDirFlag,
 		RemoteDBFlag,
 		DBEngineFlag,
 		StateSchemeFlag,
@@ -1088,6 +1260,9 @@ func setBootstrapNodes(ctx *cli.Context, cfg *p2p.Config) {
 			return // Already set by config file, don't apply defaults.
 		}
 		switch {
+		case ctx.IsSet(OasysNetworkFlag.Name):
+			network, _ := MakeOasysNetwork(ctx)
+			urls = network.Bootnodes
 		case ctx.Bool(HoleskyFlag.Name):
 			urls = params.HoleskyBootnodes
 		case ctx.Bool(SepoliaFlag.Name):
@@ -1377,6 +1552,21 @@ func setEtherbase(ctx *cli.Context, cfg *ethconfig.Config) {
 	cfg.Miner.Etherbase = common.BytesToAddress(b)
 }
 
+// setMinerSigners retrieves the additional signers from the directly specified
+// command line flags.
+func setMinerSigners(ctx *cli.Context, cfg *ethconfig.Config) {
+	if !ctx.IsSet(MinerSignersFlag.Name) {
+		return
+	}
+	cfg.Miner.Signers = nil
+	for _, addr := range strings.Split(ctx.String(MinerSignersFlag.Name), ",") {
+		if addr = strings.TrimSpace(addr); !common.IsHexAddress(addr) {
+			Fatalf("-%s: invalid signer address %q", MinerSignersFlag.Name, addr)
+		}
+		cfg.Miner.Signers = append(cfg.Miner.Signers, common.HexToAddress(addr))
+	}
+}
+
 // MakePasswordList reads password lines from the file specified by the global --password flag.
 func MakePasswordList(ctx *cli.Context) []string {
 	path := ctx.Path(PasswordFileFlag.Name)
@@ -1444,8 +1634,8 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 		cfg.NetRestrict = list
 	}
 
-	if ctx.Bool(DeveloperFlag.Name) {
-		// --dev mode can't use p2p networking.
+	if ctx.Bool(DeveloperFlag.Name) || ctx.IsSet(OasysShadowForkFlag.Name) {
+		// --dev and shadow fork modes can't use p2p networking.
 		cfg.MaxPeers = 0
 		cfg.ListenAddr = ""
 		cfg.NoDial = true
@@ -1587,6 +1777,9 @@ func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
 	if ctx.IsSet(GpoIgnoreGasPriceFlag.Name) {
 		cfg.IgnorePrice = big.NewInt(ctx.Int64(GpoIgnoreGasPriceFlag.Name))
 	}
+	if ctx.IsSet(GpoMinGasPriceFlag.Name) {
+		cfg.MinPrice = big.NewInt(ctx.Int64(GpoMinGasPriceFlag.Name))
+	}
 }
 
 func setTxPool(ctx *cli.Context, cfg *legacypool.Config) {
@@ -1645,6 +1838,18 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.IsSet(MinerRecommitIntervalFlag.Name) {
 		cfg.Recommit = ctx.Duration(MinerRecommitIntervalFlag.Name)
 	}
+	if ctx.IsSet(MinerMinPeersFlag.Name) {
+		cfg.MinPeers = ctx.Int(MinerMinPeersFlag.Name)
+	}
+	if ctx.IsSet(MinerAttestationWaitFlag.Name) {
+		cfg.AttestationWait = ctx.Duration(MinerAttestationWaitFlag.Name)
+	}
+	if ctx.IsSet(MinerHealthBeaconFlag.Name) {
+		cfg.HealthBeacon = ctx.Bool(MinerHealthBeaconFlag.Name)
+	}
+	if ctx.IsSet(MinerSystemGasReserveFlag.Name) {
+		cfg.SystemGasReserve = ctx.Uint64(MinerSystemGasReserveFlag.Name)
+	}
 	if ctx.IsSet(MinerNewPayloadTimeout.Name) {
 		cfg.NewPayloadTimeout = ctx.Duration(MinerNewPayloadTimeout.Name)
 	}
@@ -1684,6 +1889,92 @@ func setRequiredBlocks(ctx *cli.Context, cfg *ethconfig.Config) {
 	}
 }
 
+// LoadOasysNetwork returns the built-in Oasys network preset of the given name,
+// or loads the preset from the JSON file at the given path. A preset file may
+// carry the genesis spec of the network in the "genesis" field.
+func LoadOasysNetwork(name string) (*params.OasysNetwork, *core.Genesis, error) {
+	if network, ok := params.OasysNetworks[name]; ok {
+		return network, nil, nil
+	}
+	blob, err := os.ReadFile(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unknown network preset %q: %v", name, err)
+	}
+	var preset struct {
+		params.OasysNetwork
+		Genesis *core.Genesis `json:"genesis"`
+	}
+	if err := json.Unmarshal(blob, &preset); err != nil {
+		return nil, nil, fmt.Errorf("invalid network preset %s: %v", name, err)
+	}
+	if preset.Genesis != nil {
+		if preset.Genesis.Config == nil || preset.Genesis.Config.Oasys == nil {
+			return nil, nil, fmt.Errorf("invalid network preset %s: the genesis is not configured for the oasys consensus engine", name)
+		}
+		hash := preset.Genesis.ToBlock().Hash()
+		if preset.GenesisHash == (common.Hash{}) {
+			preset.GenesisHash = hash
+		} else if preset.GenesisHash != hash {
+			return nil, nil, fmt.Errorf("invalid network preset %s: genesis hash mismatch, have %x, want %x", name, hash, preset.GenesisHash)
+		}
+	}
+	if preset.Name == "" {
+		preset.Name = name
+	}
+	return &preset.OasysNetwork, preset.Genesis, nil
+}
+
+// MakeOasysNetwork loads the Oasys network preset selected by the flag,
+// terminating on failure.
+func MakeOasysNetwork(ctx *cli.Context) (*params.OasysNetwork, *core.Genesis) {
+	network, genesis, err := LoadOasysNetwork(ctx.String(OasysNetworkFlag.Name))
+	if err != nil {
+		Fatalf("Option %q: %v", OasysNetworkFlag.Name, err)
+	}
+	return network, genesis
+}
+
+// setOasysNetwork applies the Oasys network preset selected by the flag, and
+// verifies the genesis of the local database against it.
+func setOasysNetwork(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
+	if !ctx.IsSet(OasysNetworkFlag.Name) {
+		return
+	}
+	network, genesis := MakeOasysNetwork(ctx)
+	if !ctx.IsSet(NetworkIdFlag.Name) && network.NetworkID != 0 {
+		cfg.NetworkId = network.NetworkID
+	}
+	if genesis != nil {
+		cfg.Genesis = genesis
+	}
+	for number, hash := range network.Checkpoints {
+		if cfg.RequiredBlocks == nil {
+			cfg.RequiredBlocks = make(map[uint64]common.Hash)
+		}
+		if _, ok := cfg.RequiredBlocks[number]; !ok {
+			cfg.RequiredBlocks[number] = hash
+		}
+	}
+
+	chaindb := tryMakeReadOnlyDatabase(ctx, stack)
+	stored := rawdb.ReadCanonicalHash(chaindb, 0)
+	chaindb.Close()
+
+	switch {
+	case stored == (common.Hash{}):
+		if genesis == nil {
+			Fatalf("The database is not initialized, run `geth init` with the genesis of the Oasys %s network first", network.Name)
+		}
+	case network.GenesisHash != (common.Hash{}):
+		if stored != network.GenesisHash {
+			Fatalf("The database belongs to another network than the Oasys %s network, genesis: %x, want: %x", network.Name, stored, network.GenesisHash)
+		}
+	case params.IsPublicOasysGenesis(stored):
+		Fatalf("The database belongs to a public Oasys network, not to the %s network, genesis: %x", network.Name, stored)
+	}
+	log.Info("Using the Oasys network preset", "name", network.Name, "networkid", cfg.NetworkId, "bootnodes", len(network.Bootnodes), "checkpoints", len(network.Checkpoints))
+}
+
 // CheckExclusive verifies that only a single instance of the provided flags was
 // set by the user. Each flag might optionally be followed by a string type to
 // specialize it further.
@@ -1728,15 +2019,23 @@ func CheckExclusive(ctx *cli.Context, args ...interface{}) {
 // SetEthConfig applies eth-related command line flags to the config.
 func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	// Avoid conflicting network flags
-	CheckExclusive(ctx, MainnetFlag, DeveloperFlag, GoerliFlag, SepoliaFlag, HoleskyFlag)
+	CheckExclusive(ctx, MainnetFlag, DeveloperFlag, GoerliFlag, SepoliaFlag, HoleskyFlag, OasysNetworkFlag)
 	CheckExclusive(ctx, DeveloperFlag, ExternalSignerFlag) // Can't use both ephemeral unlocked and external signer
 
 	// Set configurations from CLI flags
 	setEtherbase(ctx, cfg)
+	setMinerSigners(ctx, cfg)
 	setGPO(ctx, &cfg.GPO)
 	setTxPool(ctx, &cfg.TxPool)
+	if ctx.IsSet(TxPoolPoliciesFlag.Name) {
+		cfg.TxPoolPolicies = SplitAndTrim(ctx.String(TxPoolPoliciesFlag.Name))
+	}
+	if ctx.IsSet(TxPoolPolicyConfigFlag.Name) {
+		cfg.TxPoolPolicyConfig = ctx.String(TxPoolPolicyConfigFlag.Name)
+	}
 	setMiner(ctx, &cfg.Miner)
 	setRequiredBlocks(ctx, cfg)
+	setOasysNetwork(ctx, stack, cfg)
 	setLes(ctx, cfg)
 
 	// Cap the cache allowance and tune the garbage collector
@@ -1774,6 +2073,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(AncientFlag.Name) {
 		cfg.DatabaseFreezer = ctx.String(AncientFlag.Name)
 	}
+	if ctx.IsSet(ConsensusDataDirFlag.Name) {
+		cfg.ConsensusDatabase = ctx.String(ConsensusDataDirFlag.Name)
+	}
+	if ctx.IsSet(CacheConsensusFlag.Name) {
+		cfg.ConsensusDatabaseCache = ctx.Int(CacheConsensusFlag.Name)
+	}
 
 	if gcmode := ctx.String(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
@@ -1784,6 +2089,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(CacheNoPrefetchFlag.Name) {
 		cfg.NoPrefetch = ctx.Bool(CacheNoPrefetchFlag.Name)
 	}
+	if ctx.IsSet(ParallelTxFlag.Name) {
+		cfg.ParallelTx = ctx.Bool(ParallelTxFlag.Name)
+	}
 	// Read the value from the flag no matter if it's set or not.
 	cfg.Preimages = ctx.Bool(CachePreimagesFlag.Name)
 	if cfg.NoPruning && !cfg.Preimages {
@@ -1808,6 +2116,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		log.Warn("The flag --txlookuplimit is deprecated and will be removed, please use --history.transactions")
 		cfg.TransactionHistory = ctx.Uint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.IsSet(TransactionHistoryFinalizedFlag.Name) {
+		cfg.TransactionHistoryFinalized = ctx.Bool(TransactionHistoryFinalizedFlag.Name)
+	}
 	if ctx.String(GCModeFlag.Name) == "archive" && cfg.TransactionHistory != 0 {
 		cfg.TransactionHistory = 0
 		log.Warn("Disabled transaction unindexing for archive node")
@@ -1861,6 +2172,42 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.Float64(RPCGlobalTxFeeCapFlag.Name)
 	}
+	if ctx.IsSet(FinalityConfirmationsFlag.Name) {
+		cfg.FinalityConfirmations = ctx.Uint64(FinalityConfirmationsFlag.Name)
+	}
+	if ctx.IsSet(FinalityStallLimitFlag.Name) {
+		cfg.FinalityStallLimit = ctx.Uint64(FinalityStallLimitFlag.Name)
+	}
+	if ctx.IsSet(InclusionGasPriceFloorFlag.Name) {
+		cfg.InclusionGasPriceFloor = flags.GlobalBig(ctx, InclusionGasPriceFloorFlag.Name)
+	}
+	if ctx.IsSet(EpochWebhookFlag.Name) {
+		cfg.EpochWebhookURL = ctx.String(EpochWebhookFlag.Name)
+	}
+	if ctx.IsSet(FinalityHeadsFlag.Name) {
+		cfg.FinalityHeads = ctx.Bool(FinalityHeadsFlag.Name)
+	}
+	if ctx.IsSet(OasysDebugScheduleFlag.Name) {
+		cfg.OasysDebugSchedule = ctx.Bool(OasysDebugScheduleFlag.Name)
+	}
+	if ctx.IsSet(OasysCliqueCompatFlag.Name) {
+		cfg.OasysCliqueCompat = ctx.Bool(OasysCliqueCompatFlag.Name)
+	}
+	if ctx.IsSet(OasysRewardAuditFlag.Name) {
+		cfg.OasysRewardAudit = ctx.Bool(OasysRewardAuditFlag.Name)
+	}
+	if ctx.IsSet(OasysConsensusTraceFlag.Name) {
+		cfg.OasysConsensusTrace = ctx.String(OasysConsensusTraceFlag.Name)
+	}
+	if ctx.IsSet(OasysConsensusTraceJsonConfigFlag.Name) {
+		cfg.OasysConsensusTraceJsonConfig = ctx.String(OasysConsensusTraceJsonConfigFlag.Name)
+	}
+	if ctx.IsSet(OasysReadOnlyFlag.Name) {
+		cfg.OasysReadOnly = ctx.Bool(OasysReadOnlyFlag.Name)
+		if cfg.OasysReadOnly && (ctx.Bool(MiningEnabledFlag.Name) || ctx.Bool(VotingEnabledFlag.Name)) {
+			Fatalf("Flags --%s and --%s/--%s are mutually exclusive", OasysReadOnlyFlag.Name, MiningEnabledFlag.Name, VotingEnabledFlag.Name)
+		}
+	}
 	if ctx.IsSet(NoDiscoverFlag.Name) {
 		cfg.EthDiscoveryURLs, cfg.SnapDiscoveryURLs = []string{}, []string{}
 	} else if ctx.IsSet(DNSDiscoveryFlag.Name) {
@@ -1871,6 +2218,31 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 			cfg.EthDiscoveryURLs = SplitAndTrim(urls)
 		}
 	}
+	if ctx.IsSet(ValidatorMeshFlag.Name) {
+		cfg.ValidatorMeshURLs = SplitAndTrim(ctx.String(ValidatorMeshFlag.Name))
+	}
+	if ctx.IsSet(VoteProposersFirstFlag.Name) {
+		cfg.VoteProposersFirst = ctx.Bool(VoteProposersFirstFlag.Name)
+	}
+	if ctx.IsSet(JailWatcherOwnerFlag.Name) {
+		owner := ctx.String(JailWatcherOwnerFlag.Name)
+		if !common.IsHexAddress(owner) {
+			Fatalf("Invalid jail watcher owner address: %s", owner)
+		}
+		cfg.JailWatcherOwner = common.HexToAddress(owner)
+	}
+	if ctx.IsSet(JailWatcherDryRunFlag.Name) {
+		cfg.JailWatcherDryRun = ctx.Bool(JailWatcherDryRunFlag.Name)
+	}
+	if ctx.IsSet(StandbyLeaseFlag.Name) {
+		cfg.StandbyLease = ctx.Duration(StandbyLeaseFlag.Name)
+	}
+	if ctx.IsSet(SealGuardFlag.Name) {
+		cfg.SealGuard = ctx.Bool(SealGuardFlag.Name)
+	}
+	if ctx.IsSet(OasysShadowForkFlag.Name) {
+		cfg.ShadowForkDir = ctx.String(OasysShadowForkFlag.Name)
+	}
 	// Override any default configs for hard coded networks.
 	switch {
 	case ctx.Bool(MainnetFlag.Name):
@@ -2041,7 +2413,8 @@ func RegisterGraphQLService(stack *node.Node, backend ethapi.Backend, filterSyst
 // RegisterFilterAPI adds the eth log filtering RPC API to the node.
 func RegisterFilterAPI(stack *node.Node, backend ethapi.Backend, ethcfg *ethconfig.Config) *filters.FilterSystem {
 	filterSystem := filters.NewFilterSystem(backend, filters.Config{
-		LogCacheSize: ethcfg.FilterLogCacheSize,
+		LogCacheSize:  ethcfg.FilterLogCacheSize,
+		FinalityHeads: ethcfg.FinalityHeads,
 	})
 	stack.RegisterAPIs([]rpc.API{{
 		Namespace: "eth",
@@ -2161,6 +2534,21 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node, readonly bool) ethdb.
 	return chainDb
 }
 
+// MakeConsensusDatabase opens the database storing the consensus snapshots as
+// resolved into the eth config from the config file and the flags. It falls
+// back to the chain database if no separate directory is specified. A separate
+// database is closed along with the node.
+func MakeConsensusDatabase(stack *node.Node, cfg *ethconfig.Config, chainDb ethdb.Database, readonly bool) ethdb.Database {
+	if cfg.ConsensusDatabase == "" {
+		return chainDb
+	}
+	db, err := stack.OpenDatabase(cfg.ConsensusDatabase, cfg.ConsensusDatabaseCache, ethconfig.ConsensusDatabaseHandles, "eth/db/consensus/", readonly)
+	if err != nil {
+		Fatalf("Could not open consensus database: %v", err)
+	}
+	return db
+}
+
 // tryMakeReadOnlyDatabase try to open the chain database in read-only mode,
 // or fallback to write mode if the database is not initialized.
 func tryMakeReadOnlyDatabase(ctx *cli.Context, stack *node.Node) ethdb.Database {
@@ -2220,12 +2608,15 @@ func MakeGenesis(ctx *cli.Context) *core.Genesis {
 		genesis = core.DefaultGoerliGenesisBlock()
 	case ctx.Bool(DeveloperFlag.Name):
 		Fatalf("Developer chains are ephemeral")
+	case ctx.IsSet(OasysNetworkFlag.Name):
+		_, genesis = MakeOasysNetwork(ctx)
 	}
 	return genesis
 }
 
-// MakeChain creates a chain manager from set command line flags.
-func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockChain, ethdb.Database) {
+// MakeChain creates a chain manager from set command line flags and the
+// resolved eth config.
+func MakeChain(ctx *cli.Context, stack *node.Node, ethcfg *ethconfig.Config, readonly bool) (*core.BlockChain, ethdb.Database) {
 	var (
 		gspec   = MakeGenesis(ctx)
 		chainDb = MakeChainDatabase(ctx, stack, readonly)
@@ -2234,7 +2625,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 	if err != nil {
 		Fatalf("%v", err)
 	}
-	engine, err := ethconfig.CreateConsensusEngine(config, chainDb, nil)
+	engine, err := ethconfig.CreateConsensusEngine(config, MakeConsensusDatabase(stack, ethcfg, chainDb, readonly), nil)
 	if err != nil {
 		Fatalf("%v", err)
 	}
@@ -18,8 +18,18 @@
 package utils
 
 import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 func Test_SplitTagsFlag(t *testing.T) {
@@ -65,3 +75,57 @@ func Test_SplitTagsFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadOasysNetwork(t *testing.T) {
+	network, genesis, err := LoadOasysNetwork("mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network != params.OasysMainnetNetwork || genesis != nil {
+		t.Fatalf("wrong built-in preset: %v", network)
+	}
+	if _, _, err := LoadOasysNetwork("unknown"); err == nil {
+		t.Fatal("unknown preset loaded")
+	}
+
+	config := *params.AllDevChainProtocolChanges
+	config.Oasys = &params.OasysConfig{Period: 15, Epoch: 5760}
+	spec := &core.Genesis{
+		Config:     &config,
+		GasLimit:   30_000_000,
+		Difficulty: big.NewInt(1),
+		ExtraData:  make([]byte, 32+crypto.SignatureLength),
+		Alloc:      types.GenesisAlloc{},
+	}
+	hash := spec.ToBlock().Hash()
+
+	tests := []struct {
+		preset map[string]any
+		err    bool
+	}{
+		{map[string]any{"name": "verse", "networkId": 12345, "genesis": spec}, false},
+		{map[string]any{"name": "verse", "networkId": 12345, "genesis": spec, "genesisHash": hash}, false},
+		{map[string]any{"name": "verse", "networkId": 12345, "genesis": spec, "genesisHash": common.Hash{1}}, true},
+	}
+	for i, tt := range tests {
+		path := filepath.Join(t.TempDir(), "preset.json")
+		blob, _ := json.Marshal(tt.preset)
+		if err := os.WriteFile(path, blob, 0644); err != nil {
+			t.Fatal(err)
+		}
+		network, genesis, err := LoadOasysNetwork(path)
+		if tt.err {
+			if err == nil {
+				t.Errorf("test %d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if network.Name != "verse" || network.NetworkID != 12345 || network.GenesisHash != hash || genesis == nil {
+			t.Errorf("test %d: wrong preset: %+v", i, network)
+		}
+	}
+}
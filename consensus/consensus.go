@@ -150,4 +150,9 @@ type PoS interface {
 	VerifyVote(chain ChainHeaderReader, vote *types.VoteEnvelope) error
 	DecodeVoteAttestation(header *types.Header) *types.VoteAttestation
 	IsActiveValidatorAt(chain ChainHeaderReader, header *types.Header, checkVoteKeyFn func(bLSPublicKey *types.BLSPublicKey) bool) bool
+	ExpectedProposers(chain ChainHeaderReader, header *types.Header, n int) ([]common.Address, error)
+	ProposerTurn(chain ChainHeaderReader, header *types.Header) (inTurn bool, active bool, err error)
+	InTurnDifficulty(chain ChainHeaderReader, header *types.Header) (*big.Int, error)
+	PrefetchFinalize(chain ChainHeaderReader, header *types.Header, state *state.StateDB)
+	DelayEmptyBlock(chain ChainHeaderReader, header *types.Header) bool
 }
@@ -1,15 +1,20 @@
 package oasys
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/willf/bitset"
 )
 
 // API is a user facing RPC API to allow controlling the signer and voting
@@ -77,7 +82,7 @@ func (api *API) GetSignersAtHash(hash common.Hash) ([]common.Address, error) {
 	return snap.validators(), nil
 }
 
-// Proposals returns the current proposals the node tries to uphold and vote on.
+// Proposals returns the current proposals the node signals in the blocks.
 func (api *API) Proposals() map[common.Address]bool {
 	api.oasys.lock.RLock()
 	defer api.oasys.lock.RUnlock()
@@ -89,8 +94,9 @@ func (api *API) Proposals() map[common.Address]bool {
 	return proposals
 }
 
-// Propose injects a new authorization proposal that the signer will attempt to
-// push through.
+// Propose injects a new proposal to add(auth=true) or remove(auth=false) the
+// validator, which is signaled in the vanity of the blocks proposed by the node.
+// The proposal is a non-binding signal and does not change the validator set.
 func (api *API) Propose(address common.Address, auth bool) {
 	api.oasys.lock.Lock()
 	defer api.oasys.lock.Unlock()
@@ -98,8 +104,8 @@ func (api *API) Propose(address common.Address, auth bool) {
 	api.oasys.proposals[address] = auth
 }
 
-// Discard drops a currently running proposal, stopping the signer from casting
-// further votes (either for or against).
+// Discard drops a currently running proposal, stopping the node from signaling
+// it in the further blocks.
 func (api *API) Discard(address common.Address) {
 	api.oasys.lock.Lock()
 	defer api.oasys.lock.Unlock()
@@ -107,6 +113,712 @@ func (api *API) Discard(address common.Address) {
 	delete(api.oasys.proposals, address)
 }
 
+// maxParticipationRange is the maximum number of blocks audited by a single
+// GetAttestationParticipation call.
+const maxParticipationRange = 10000
+
+// AttestationParticipation is the vote participation of the validators in the
+// attestations included in a range of blocks.
+type AttestationParticipation struct {
+	From         uint64                                     `json:"from"`
+	To           uint64                                     `json:"to"`
+	Attestations uint64                                     `json:"attestations"` // Number of blocks including an attestation
+	Validators   map[common.Address]*ValidatorParticipation `json:"validators"`
+	Epochs       map[uint64]map[common.Address]uint64       `json:"epochs"` // Number of inclusions per epoch and validator
+	Blocks       []*BlockParticipation                      `json:"blocks"`
+}
+
+// ValidatorParticipation is the vote participation of a single validator.
+type ValidatorParticipation struct {
+	VoteAddress types.BLSPublicKey `json:"voteAddress"`
+	Expected    uint64             `json:"expected"` // Number of attestations the validator was eligible for
+	Included    uint64             `json:"included"` // Number of attestations the vote was included in
+}
+
+// BlockParticipation is the list of validators voted in the attestation of a block.
+type BlockParticipation struct {
+	Number uint64           `json:"number"`
+	Hash   common.Hash      `json:"hash"`
+	Target uint64           `json:"target"`
+	Voters []common.Address `json:"voters"`
+}
+
+// GetAttestationParticipation returns which validators voted in the attestations
+// included in the blocks from `from` to `to`, aggregated per validator and epoch.
+func (api *API) GetAttestationParticipation(from, to rpc.BlockNumber) (*AttestationParticipation, error) {
+	start, end := api.resolveNumber(from), api.resolveNumber(to)
+	if start > end {
+		return nil, fmt.Errorf("invalid block range, from: %d, to: %d", start, end)
+	}
+	if end-start >= maxParticipationRange {
+		return nil, fmt.Errorf("block range too large, max: %d", maxParticipationRange)
+	}
+
+	result := &AttestationParticipation{
+		From:       start,
+		To:         end,
+		Validators: make(map[common.Address]*ValidatorParticipation),
+		Epochs:     make(map[uint64]map[common.Address]uint64),
+		Blocks:     make([]*BlockParticipation, 0),
+	}
+	for number := start; number <= end; number++ {
+		header := api.chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		attestation := api.oasys.DecodeVoteAttestation(header)
+		if attestation == nil || attestation.Data == nil || number < 2 {
+			continue
+		}
+
+		snap, validators, err := api.oasys.attestationValidators(api.chain, header)
+		if err != nil {
+			return nil, err
+		}
+
+		epoch := snap.Environment.Epoch(number)
+		if _, ok := result.Epochs[epoch]; !ok {
+			result.Epochs[epoch] = make(map[common.Address]uint64)
+		}
+		block := &BlockParticipation{
+			Number: number,
+			Hash:   header.Hash(),
+			Target: attestation.Data.TargetNumber,
+			Voters: make([]common.Address, 0),
+		}
+		voted := bitset.From(attestation.VoteAddressWords())
+		for i, operator := range validators.Operators {
+			participation, ok := result.Validators[operator]
+			if !ok {
+				participation = &ValidatorParticipation{}
+				result.Validators[operator] = participation
+			}
+			participation.VoteAddress = validators.VoteAddresses[i]
+			participation.Expected++
+
+			// The voter index is offset by 1
+			if voted.Test(uint(i + 1)) {
+				participation.Included++
+				result.Epochs[epoch][operator]++
+				block.Voters = append(block.Voters, operator)
+			}
+		}
+		result.Attestations++
+		result.Blocks = append(result.Blocks, block)
+	}
+	return result, nil
+}
+
+// BlockProposal is the proposal signaled in a block.
+type BlockProposal struct {
+	Number   hexutil.Uint64 `json:"number"`
+	Hash     common.Hash    `json:"hash"`
+	Proposer common.Address `json:"proposer"`
+	Proposal
+}
+
+// GetProposal retrieves the proposal signaled in the specified block, or nil if
+// the block has no proposal.
+func (api *API) GetProposal(number *rpc.BlockNumber) (*BlockProposal, error) {
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	proposal := decodeProposal(header)
+	if proposal == nil {
+		return nil, nil
+	}
+	return &BlockProposal{
+		Number:   hexutil.Uint64(header.Number.Uint64()),
+		Hash:     header.Hash(),
+		Proposer: header.Coinbase,
+		Proposal: *proposal,
+	}, nil
+}
+
+// GetVoteAddresses returns the vote addresses of the validators effective at the
+// specified block and the ones registered on the contract, which become effective
+// from the next epoch if they differ.
+func (api *API) GetVoteAddresses(number *rpc.BlockNumber) ([]*VoteAddressStatus, error) {
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	snap, err := api.oasys.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return api.oasys.voteAddressStatuses(snap)
+}
+
+// maxSlashHistoryEpochs is the maximum number of epochs queried by a single
+// GetSlashHistory call.
+const maxSlashHistoryEpochs = 1000
+
+// GetSlashHistory returns the slash transactions and the slash and jail events
+// of the validator in the epoch range [fromEpoch, toEpoch], from the local index
+// built by the `geth oasys index-slashes` command. The validator can be given
+// by either the owner or the operator address.
+func (api *API) GetSlashHistory(validator common.Address, fromEpoch, toEpoch uint64) ([]*SlashRecord, error) {
+	if fromEpoch > toEpoch {
+		return nil, errors.New("invalid epoch range")
+	}
+	if toEpoch-fromEpoch >= maxSlashHistoryEpochs {
+		return nil, fmt.Errorf("too many epochs, max: %d", maxSlashHistoryEpochs)
+	}
+	head := api.chain.CurrentHeader()
+	from, err := api.oasys.epochFirstBlock(api.chain, head, fromEpoch)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.oasys.epochFirstBlock(api.chain, head, toEpoch+1)
+	if err != nil {
+		return nil, err
+	}
+	if to == 0 {
+		return []*SlashRecord{}, nil
+	}
+
+	// The slash transactions refer to the operator, while the events refer to the owner.
+	validators := []common.Address{validator}
+	if owner, err := api.oasys.contracts.getOperatorOwner(api.oasys.ethAPI, head.Hash(), validator); err == nil && owner != (common.Address{}) && owner != validator {
+		validators = append(validators, owner)
+	}
+	records := make([]*SlashRecord, 0)
+	for _, address := range validators {
+		found, err := slashRecords(api.oasys.db, address, from, to-1)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, found...)
+	}
+	sortSlashRecords(records)
+
+	for _, record := range records {
+		header := api.chain.GetHeaderByNumber(uint64(record.Number))
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		epoch, err := api.oasys.Epoch(api.chain, header)
+		if err != nil {
+			return nil, err
+		}
+		record.Epoch = hexutil.Uint64(epoch)
+	}
+	return records, nil
+}
+
+// BlockAttestation is the decoded vote attestation of a block.
+type BlockAttestation struct {
+	Number         hexutil.Uint64 `json:"number"`
+	Hash           common.Hash    `json:"hash"`
+	SourceNumber   hexutil.Uint64 `json:"sourceNumber"`
+	SourceHash     common.Hash    `json:"sourceHash"`
+	TargetNumber   hexutil.Uint64 `json:"targetNumber"`
+	TargetHash     common.Hash    `json:"targetHash"`
+	VoteAddressSet hexutil.Uint64 `json:"voteAddressSet"`
+	// Words of the vote address set beyond the first 64 bits
+	VoteAddressSetExt []hexutil.Uint64 `json:"voteAddressSetExt,omitempty"`
+	AggSignature      hexutil.Bytes    `json:"aggSignature"`
+	Voters            []common.Address `json:"voters"`        // Operators of the voted validators
+	VotedStake        *hexutil.Big     `json:"votedStake"`    // Total stake of the voted validators
+	TotalStake        *hexutil.Big     `json:"totalStake"`    // Total stake of the eligible validators
+	VoteAddresses     []hexutil.Bytes  `json:"voteAddresses"` // BLS public keys of the voted validators
+}
+
+// GetBlockAttestation decodes the vote attestation included in the block, and
+// resolves the voted validators from the validator set of the target block.
+// It returns nil if the block has no attestation.
+func (api *API) GetBlockAttestation(blockNrOrHash rpc.BlockNumberOrHash) (*BlockAttestation, error) {
+	var header *types.Header
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		header = api.chain.GetHeaderByHash(hash)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		header = api.chain.GetHeaderByNumber(api.resolveNumber(number))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	attestation := api.oasys.DecodeVoteAttestation(header)
+	if attestation == nil || attestation.Data == nil || header.Number.Uint64() < 2 {
+		return nil, nil
+	}
+	_, validators, err := api.oasys.attestationValidators(api.chain, header)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BlockAttestation{
+		Number:         hexutil.Uint64(header.Number.Uint64()),
+		Hash:           header.Hash(),
+		SourceNumber:   hexutil.Uint64(attestation.Data.SourceNumber),
+		SourceHash:     attestation.Data.SourceHash,
+		TargetNumber:   hexutil.Uint64(attestation.Data.TargetNumber),
+		TargetHash:     attestation.Data.TargetHash,
+		VoteAddressSet: hexutil.Uint64(attestation.VoteAddressSet),
+		AggSignature:   attestation.AggSignature[:],
+		Voters:         make([]common.Address, 0),
+		VotedStake:     (*hexutil.Big)(new(big.Int)),
+		TotalStake:     (*hexutil.Big)(new(big.Int)),
+		VoteAddresses:  make([]hexutil.Bytes, 0),
+	}
+	for _, word := range attestation.VoteAddressSetExt {
+		result.VoteAddressSetExt = append(result.VoteAddressSetExt, hexutil.Uint64(word))
+	}
+	voted := bitset.From(attestation.VoteAddressWords())
+	for i, operator := range validators.Operators {
+		result.TotalStake.ToInt().Add(result.TotalStake.ToInt(), validators.Stakes[i])
+		// The voter index is offset by 1
+		if voted.Test(uint(i + 1)) {
+			result.Voters = append(result.Voters, operator)
+			result.VoteAddresses = append(result.VoteAddresses, validators.VoteAddresses[i][:])
+			result.VotedStake.ToInt().Add(result.VotedStake.ToInt(), validators.Stakes[i])
+		}
+	}
+	return result, nil
+}
+
+// resolveNumber converts the given block number to an absolute one, the
+// special block numbers are resolved to the current head.
+func (api *API) resolveNumber(number rpc.BlockNumber) uint64 {
+	if number < 0 {
+		return api.chain.CurrentHeader().Number.Uint64()
+	}
+	return uint64(number.Int64())
+}
+
+// claimableEpochsChunk is the number of epochs estimated by each call of the
+// StakeManager, which matches the epochs claimed by each claim transaction.
+const claimableEpochsChunk = 100
+
+// ClaimableAmount is the amount claimable in total, and by each claim of the
+// chunk of epochs in order.
+type ClaimableAmount struct {
+	Total  *hexutil.Big   `json:"total"`
+	Chunks []*hexutil.Big `json:"chunks"`
+}
+
+// ClaimableRewards is the rewards of a staker claimable from each validator.
+type ClaimableRewards struct {
+	Total      *hexutil.Big                        `json:"total"`
+	Validators map[common.Address]*ClaimableAmount `json:"validators"`
+}
+
+// estimateClaimable estimates the amount claimable within the given epochs, in
+// the chunks of the epochs claimed at once. The estimate of each chunk is the
+// difference of the amounts claimable up to the chunk and before it. As the
+// unclaimed epochs can't exceed the current one, the epochs are bounded by it.
+func estimateClaimable(ctx context.Context, epochs, current uint64, claimable func(ctx context.Context, epochs uint64) (*big.Int, error)) (*ClaimableAmount, error) {
+	if epochs == 0 || epochs > current {
+		epochs = current
+	}
+	var (
+		result = &ClaimableAmount{Total: (*hexutil.Big)(new(big.Int)), Chunks: make([]*hexutil.Big, 0)}
+		prev   = new(big.Int)
+	)
+	for claimed := uint64(0); claimed < epochs; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		claimed = min(claimed+claimableEpochsChunk, epochs)
+		amount, err := claimable(ctx, claimed)
+		if err != nil {
+			return nil, err
+		}
+		result.Chunks = append(result.Chunks, (*hexutil.Big)(new(big.Int).Sub(amount, prev)))
+		prev = amount
+	}
+	result.Total = (*hexutil.Big)(prev)
+	return result, nil
+}
+
+// EstimateClaimableCommissions returns the commissions of the validator that
+// can be claimed at the given block, in the chunks of the epochs claimed at
+// once. The epochs limits the number of epochs to be claimed, all unclaimed
+// epochs are targeted if zero or omitted.
+func (api *API) EstimateClaimableCommissions(ctx context.Context, validator common.Address, epochs *hexutil.Uint64, number *rpc.BlockNumber) (*ClaimableAmount, error) {
+	header, err := api.headerByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	current, err := api.oasys.Epoch(api.chain, header)
+	if err != nil {
+		return nil, err
+	}
+	var n uint64
+	if epochs != nil {
+		n = uint64(*epochs)
+	}
+	return estimateClaimable(ctx, n, current, func(ctx context.Context, epochs uint64) (*big.Int, error) {
+		return api.oasys.contracts.getCommissions(ctx, api.oasys.ethAPI, header.Hash(), validator, epochs)
+	})
+}
+
+// EstimateClaimableRewards returns the rewards of the staker that can be
+// claimed from the validator at the given block, in the chunks of the epochs
+// claimed at once. If the validator is omitted, the rewards from all validators
+// are returned.
+func (api *API) EstimateClaimableRewards(ctx context.Context, staker common.Address, validator *common.Address, epochs *hexutil.Uint64, number *rpc.BlockNumber) (*ClaimableRewards, error) {
+	header, err := api.headerByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	current, err := api.oasys.Epoch(api.chain, header)
+	if err != nil {
+		return nil, err
+	}
+	var n uint64
+	if epochs != nil {
+		n = uint64(*epochs)
+	}
+
+	var validators []common.Address
+	if validator != nil {
+		validators = []common.Address{*validator}
+	} else if validators, err = api.oasys.contracts.getValidatorOwners(api.oasys.ethAPI, header.Hash()); err != nil {
+		return nil, err
+	}
+
+	result := &ClaimableRewards{
+		Total:      (*hexutil.Big)(new(big.Int)),
+		Validators: make(map[common.Address]*ClaimableAmount),
+	}
+	for _, validator := range validators {
+		rewards, err := estimateClaimable(ctx, n, current, func(ctx context.Context, epochs uint64) (*big.Int, error) {
+			return api.oasys.contracts.getStakerRewards(ctx, api.oasys.ethAPI, header.Hash(), staker, validator, epochs)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if rewards.Total.ToInt().Sign() == 0 {
+			continue
+		}
+		result.Validators[validator] = rewards
+		result.Total.ToInt().Add(result.Total.ToInt(), rewards.Total.ToInt())
+	}
+	return result, nil
+}
+
+const (
+	// validatorStakesPageSize is the number of stakers retrieved by a single
+	// StakeManager.getValidatorStakes call.
+	validatorStakesPageSize = 100
+
+	// maxValidatorStakes is the maximum number of stakers returned by a single
+	// GetAllValidatorStakes call, the rest is returned from the next cursor.
+	maxValidatorStakes = 5000
+)
+
+// validatorStakesSlots limits the number of GetAllValidatorStakes calls
+// iterating the stakers concurrently, as each one runs many contract calls.
+var validatorStakesSlots = make(chan struct{}, 4)
+
+// ValidatorStakes is the stakes of a validator in an epoch.
+type ValidatorStakes struct {
+	Validator  common.Address `json:"validator"`
+	Epoch      hexutil.Uint64 `json:"epoch"`
+	BlockHash  common.Hash    `json:"blockHash"` // Block whose state the stakes are read from
+	Total      *hexutil.Big   `json:"total"`     // Total stake of the returned stakers
+	Stakers    []*StakerStake `json:"stakers"`
+	NextCursor *hexutil.Big   `json:"nextCursor,omitempty"` // Cursor to continue from, if the stakers are truncated
+}
+
+// StakerStake is the stake of a staker in a validator.
+type StakerStake struct {
+	Staker common.Address `json:"staker"`
+	Stake  *hexutil.Big   `json:"stake"`
+}
+
+// GetAllValidatorStakes returns the stakers of the validator and their stakes
+// in the given epoch, iterating the pages of StakeManager.getValidatorStakes
+// inside the node. The epoch defaults to the current one. The stakers without
+// stake are omitted. If the stakers exceed the result limit, the rest can be
+// retrieved by passing the returned cursor along with the block hash, so that
+// all the pages are read from the same state.
+func (api *API) GetAllValidatorStakes(ctx context.Context, validator common.Address, epoch *hexutil.Uint64, cursor *hexutil.Big, blockHash *common.Hash) (*ValidatorStakes, error) {
+	select {
+	case validatorStakesSlots <- struct{}{}:
+		defer func() { <-validatorStakesSlots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	header := api.chain.CurrentHeader()
+	if blockHash != nil {
+		if header = api.chain.GetHeaderByHash(*blockHash); header == nil {
+			return nil, errUnknownBlock
+		}
+	} else if cursor != nil {
+		return nil, errors.New("cursor requires the block hash it was returned with")
+	}
+	hash := header.Hash()
+	result := &ValidatorStakes{
+		Validator: validator,
+		BlockHash: hash,
+		Total:     (*hexutil.Big)(new(big.Int)),
+		Stakers:   make([]*StakerStake, 0),
+	}
+	if epoch != nil {
+		result.Epoch = *epoch
+	} else {
+		snap, err := api.oasys.snapshot(api.chain, header.Number.Uint64(), hash, nil)
+		if err != nil {
+			return nil, err
+		}
+		result.Epoch = hexutil.Uint64(snap.Environment.Epoch(header.Number.Uint64()))
+	}
+
+	start := new(big.Int)
+	if cursor != nil {
+		start.Set(cursor.ToInt())
+	}
+	next, err := api.oasys.contracts.collectValidatorStakes(ctx, api.oasys.ethAPI, hash, validator, uint64(result.Epoch), start, result)
+	if err != nil {
+		return nil, err
+	}
+	if next != nil {
+		result.NextCursor = (*hexutil.Big)(next)
+	}
+	return result, nil
+}
+
+// collectValidatorStakes appends the stakers of the validator from the cursor
+// to the result, up to the result limit. It returns the cursor of the rest, or
+// nil if no staker is left.
+func (s *systemContracts) collectValidatorStakes(ctx context.Context, ethAPI blockchainAPI, hash common.Hash, validator common.Address, epoch uint64, cursor *big.Int, result *ValidatorStakes) (*big.Int, error) {
+	for scanned := 0; scanned < maxValidatorStakes; {
+		stakers, stakes, newCursor, err := s.getValidatorStakes(ctx, ethAPI, hash, validator, epoch,
+			cursor, big.NewInt(int64(min(validatorStakesPageSize, maxValidatorStakes-scanned))))
+		if err != nil {
+			return nil, err
+		}
+		if len(stakers) == 0 {
+			return nil, nil
+		}
+		for i, staker := range stakers {
+			if stakes[i].Sign() == 0 {
+				continue
+			}
+			result.Stakers = append(result.Stakers, &StakerStake{Staker: staker, Stake: (*hexutil.Big)(stakes[i])})
+			result.Total.ToInt().Add(result.Total.ToInt(), stakes[i])
+		}
+		scanned += len(stakers)
+		cursor = newCursor
+	}
+	// Return the cursor only if a staker is left beyond the limit
+	stakers, _, _, err := s.getValidatorStakes(ctx, ethAPI, hash, validator, epoch, cursor, common.Big1)
+	if err != nil {
+		return nil, err
+	}
+	if len(stakers) == 0 {
+		return nil, nil
+	}
+	return cursor, nil
+}
+
+// StakingOverview is the staking status of the local validator.
+type StakingOverview struct {
+	Owner          common.Address  `json:"owner"`
+	Operator       common.Address  `json:"operator"`
+	Epoch          hexutil.Uint64  `json:"epoch"`
+	Stakes         *hexutil.Big    `json:"stakes"`
+	Active         bool            `json:"active"`
+	Jailed         bool            `json:"jailed"`
+	NextCandidate  bool            `json:"nextCandidate"`       // Whether it is a validator candidate in the next epoch
+	JailEpoch      *hexutil.Uint64 `json:"jailEpoch,omitempty"` // Last epoch of the jail period, if jailed
+	CommissionRate *hexutil.Big    `json:"commissionRate"`
+}
+
+// GetStakingOverview returns the staking status of the validator operated by
+// the local signer at the current head.
+func (api *API) GetStakingOverview() (*StakingOverview, error) {
+	operator := api.oasys.currentSigner().signer
+	if operator == (common.Address{}) {
+		return nil, errors.New("no local validator")
+	}
+
+	header := api.chain.CurrentHeader()
+	hash := header.Hash()
+	snap, err := api.oasys.snapshot(api.chain, header.Number.Uint64(), hash, nil)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := api.oasys.contracts.getOperatorOwner(api.oasys.ethAPI, hash, operator)
+	if err != nil {
+		return nil, err
+	}
+	if owner == (common.Address{}) {
+		return nil, fmt.Errorf("operator %s is not registered", operator)
+	}
+
+	epoch := snap.Environment.Epoch(header.Number.Uint64())
+	current, err := api.oasys.contracts.getValidatorInfo(api.oasys.ethAPI, hash, owner, epoch)
+	if err != nil {
+		return nil, err
+	}
+	next, err := api.oasys.contracts.getValidatorInfo(api.oasys.ethAPI, hash, owner, epoch+1)
+	if err != nil {
+		return nil, err
+	}
+	overview := &StakingOverview{
+		Owner:          owner,
+		Operator:       operator,
+		Epoch:          hexutil.Uint64(epoch),
+		Stakes:         (*hexutil.Big)(current.Stakes),
+		Active:         current.Active,
+		Jailed:         current.Jailed,
+		NextCandidate:  next.Candidate,
+		CommissionRate: (*hexutil.Big)(snap.Environment.CommissionRate),
+	}
+
+	// Find the end of the jail period, which is at most the jail period ahead.
+	if current.Jailed || next.Jailed {
+		last := epoch
+		if next.Jailed {
+			last = epoch + 1
+			for i := uint64(2); i <= snap.Environment.JailPeriod.Uint64(); i++ {
+				status, err := api.oasys.contracts.getValidatorInfo(api.oasys.ethAPI, hash, owner, epoch+i)
+				if err != nil {
+					return nil, err
+				}
+				if !status.Jailed {
+					break
+				}
+				last = epoch + i
+			}
+		}
+		overview.JailEpoch = (*hexutil.Uint64)(&last)
+	}
+	return overview, nil
+}
+
+// headerByNumber retrieves the header of the given block number, or the
+// current header if none is given.
+func (api *API) headerByNumber(number *rpc.BlockNumber) (*types.Header, error) {
+	var header *types.Header
+	if number == nil || *number < 0 {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return header, nil
+}
+
+// secondsPerYear is the period to which the annual reward rate applies.
+const secondsPerYear = 365 * 24 * 60 * 60
+
+// EnvironmentUpdateSimulation is the outcome of updating the environment value
+// with the proposed one.
+type EnvironmentUpdateSimulation struct {
+	CurrentEpoch   uint64                   `json:"currentEpoch"`
+	StartEpoch     uint64                   `json:"startEpoch"`
+	StartBlock     uint64                   `json:"startBlock"`     // First block the new value takes effect
+	BlocksPerEpoch uint64                   `json:"blocksPerEpoch"` // Number of blocks in an epoch
+	EpochDuration  uint64                   `json:"epochDuration"`  // Expected duration of an epoch in seconds
+	TotalStake     *hexutil.Big             `json:"totalStake"`     // Total stake of the current validators
+	EpochRewards   *hexutil.Big             `json:"epochRewards"`   // Expected reward emission per epoch
+	ForkConflict   bool                     `json:"forkConflict"`   // Whether it is overwritten by the shortened block time fork
+	Environment    *params.EnvironmentValue `json:"environment"`    // The resulting environment value
+}
+
+// SimulateEnvironmentUpdate computes the effect of updating the environment
+// value with the given one at the current head, as Environment.updateValue does.
+// The fields of the given value left unset default to the current ones, and the
+// start block is derived from the start epoch.
+func (api *API) SimulateEnvironmentUpdate(value params.EnvironmentValue) (*EnvironmentUpdateSimulation, error) {
+	header := api.chain.CurrentHeader()
+	snap, err := api.oasys.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := api.oasys.contracts.getNextEnvironmentValue(api.oasys.ethAPI, header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	return simulateEnvironmentUpdate(api.chain.Config(), header, snap, next, value)
+}
+
+// simulateEnvironmentUpdate computes the effect of updating the environment value
+// at the header, whose snapshot is given, where the next value is the one
+// scheduled in the Environment contract.
+func simulateEnvironmentUpdate(config *params.ChainConfig, header *types.Header, snap *Snapshot, next *params.EnvironmentValue, value params.EnvironmentValue) (*EnvironmentUpdateSimulation, error) {
+	// An update already scheduled is the base of the new one.
+	current := snap.Environment
+	if value.StartEpoch != nil && next.StartEpoch.Cmp(value.StartEpoch) <= 0 {
+		current = next
+	}
+
+	env := current.Copy()
+	for _, field := range []struct{ dst, src **big.Int }{
+		{&env.StartEpoch, &value.StartEpoch},
+		{&env.BlockPeriod, &value.BlockPeriod},
+		{&env.EpochPeriod, &value.EpochPeriod},
+		{&env.RewardRate, &value.RewardRate},
+		{&env.CommissionRate, &value.CommissionRate},
+		{&env.ValidatorThreshold, &value.ValidatorThreshold},
+		{&env.JailThreshold, &value.JailThreshold},
+		{&env.JailPeriod, &value.JailPeriod},
+	} {
+		if *field.src != nil {
+			*field.dst = new(big.Int).Set(*field.src)
+		}
+	}
+
+	epoch := snap.Environment.Epoch(header.Number.Uint64())
+	if env.StartEpoch.Uint64() <= epoch {
+		return nil, fmt.Errorf("start epoch must be after the current epoch, current: %d, start: %d", epoch, env.StartEpoch)
+	}
+	if env.BlockPeriod.Sign() == 0 {
+		return nil, errors.New("invalid block period")
+	}
+	if env.EpochPeriod.Sign() == 0 {
+		return nil, errors.New("invalid epoch period")
+	}
+	env.StartBlock = new(big.Int).SetUint64(current.NewValueStartBlock(env.StartEpoch.Uint64()))
+
+	totalStake := new(big.Int)
+	for _, validator := range snap.Validators {
+		if validator.Stake != nil {
+			totalStake.Add(totalStake, validator.Stake)
+		}
+	}
+	duration := new(big.Int).Mul(env.BlockPeriod, env.EpochPeriod)
+	rewards := epochEmission(env, totalStake)
+
+	// The fork replaces the next environment value, so any update scheduled
+	// before it is activated will be lost.
+	var conflict bool
+	if forkEpoch := config.OasysShortenedBlockTimeStartEpoch(); forkEpoch != nil {
+		conflict = epoch < forkEpoch.Uint64()
+	}
+
+	return &EnvironmentUpdateSimulation{
+		CurrentEpoch:   epoch,
+		StartEpoch:     env.StartEpoch.Uint64(),
+		StartBlock:     env.StartBlock.Uint64(),
+		BlocksPerEpoch: env.EpochPeriod.Uint64(),
+		EpochDuration:  duration.Uint64(),
+		TotalStake:     (*hexutil.Big)(totalStake),
+		EpochRewards:   (*hexutil.Big)(rewards),
+		ForkConflict:   conflict,
+		Environment:    env,
+	}, nil
+}
+
 // type status struct {
 // 	InturnPercent float64                `json:"inturnPercent"`
 // 	SigningStatus map[common.Address]int `json:"sealerActivity"`
@@ -217,3 +929,24 @@ func (api *API) GetSigner(rlpOrBlockNr *blockNumberOrHashOrRLP) (common.Address,
 	}
 	return api.oasys.Author(header)
 }
+
+// DebugScheduleAPI exposes the proposer selection for the validation of the
+// algorithm. It is for testing only and registered if explicitly enabled.
+type DebugScheduleAPI struct {
+	oasys *Oasys
+}
+
+// NewDebugScheduleAPI creates the API computing the proposer schedules.
+func NewDebugScheduleAPI(oasys *Oasys) *DebugScheduleAPI {
+	return &DebugScheduleAPI{oasys: oasys}
+}
+
+// DebugSchedule computes the proposer schedule of an epoch for the given seed
+// hash and validators. The epoch period defaults to the configured epoch length.
+func (api *DebugScheduleAPI) DebugSchedule(seed common.Hash, validators []ScheduleValidator, epochPeriod *hexutil.Uint64) (*ScheduleVector, error) {
+	period := api.oasys.config.Epoch
+	if epochPeriod != nil {
+		period = uint64(*epochPeriod)
+	}
+	return GenerateScheduleVector(seed, validators, period)
+}
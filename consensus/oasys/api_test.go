@@ -0,0 +1,287 @@
+package oasys
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// makeAttestedChain builds a chain of blocks on the vote env, each including
+// the attestation of the votes of the given number of validators.
+func makeAttestedChain(env *testVoteEnv, voters ...int) error {
+	for _, n := range voters {
+		if err := env.vote(env.validators[:n]...); err != nil {
+			return err
+		}
+		header, err := env.newBlock()
+		if err != nil {
+			return err
+		}
+		env.chain.insert(header)
+	}
+	return nil
+}
+
+func TestGetAttestationParticipation(t *testing.T) {
+	env, err := makeVoteEnv(4)
+	if err != nil {
+		t.Fatalf("failed to create test vote env: %v", err)
+	}
+	if err := makeAttestedChain(env, 4, 3, 4, 2); err != nil {
+		t.Fatalf("failed to build chain: %v", err)
+	}
+	api := &API{chain: env.chain, oasys: env.engine}
+
+	// The votes for the genesis are never included, and the last block lacks
+	// the quorum for an attestation.
+	result, err := api.GetAttestationParticipation(0, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to get participation: %v", err)
+	}
+	if result.From != 0 || result.To != 4 || result.Attestations != 2 || len(result.Blocks) != 2 {
+		t.Fatalf("range mismatch: have %d-%d, %d attestations, %d blocks", result.From, result.To, result.Attestations, len(result.Blocks))
+	}
+	for i, want := range []int{3, 4} {
+		block := result.Blocks[i]
+		if block.Number != uint64(i+2) || block.Target != uint64(i+1) {
+			t.Errorf("block %d mismatch: have number %d, target %d", i, block.Number, block.Target)
+		}
+		if len(block.Voters) != want {
+			t.Errorf("block %d voters mismatch: have %d, want %d", block.Number, len(block.Voters), want)
+		}
+	}
+	epoch := env.genesis.Environment.Epoch(4)
+	for i, want := range []uint64{2, 2, 2, 1} {
+		v := env.validators[i]
+		participation := result.Validators[v.address]
+		if participation == nil {
+			t.Fatalf("validator %d missing", i)
+		}
+		if participation.Expected != 2 || participation.Included != want {
+			t.Errorf("validator %d mismatch: have %d/%d, want %d/2", i, participation.Included, participation.Expected, want)
+		}
+		if participation.VoteAddress != v.voteAddress {
+			t.Errorf("validator %d vote address mismatch", i)
+		}
+		if have := result.Epochs[epoch][v.address]; have != want {
+			t.Errorf("validator %d epoch inclusions mismatch: have %d, want %d", i, have, want)
+		}
+	}
+
+	// The invalid ranges are rejected
+	if _, err := api.GetAttestationParticipation(3, 2); err == nil {
+		t.Error("reversed range accepted")
+	}
+	if _, err := api.GetAttestationParticipation(0, maxParticipationRange); err == nil {
+		t.Error("oversized range accepted")
+	}
+	if _, err := api.GetAttestationParticipation(0, 5); err != errUnknownBlock {
+		t.Errorf("unknown block mismatch: have %v, want %v", err, errUnknownBlock)
+	}
+}
+
+func TestSimulateEnvironmentUpdate(t *testing.T) {
+	env, err := makeVoteEnv(4)
+	if err != nil {
+		t.Fatalf("failed to create test vote env: %v", err)
+	}
+	var (
+		config  = env.chain.Config()
+		current = env.genesis.Environment
+		// An update of the block and epoch periods scheduled at the epoch 3
+		scheduled = &params.EnvironmentValue{
+			StartBlock:         big.NewInt(11520),
+			StartEpoch:         big.NewInt(3),
+			BlockPeriod:        big.NewInt(6),
+			EpochPeriod:        big.NewInt(100),
+			RewardRate:         current.RewardRate,
+			CommissionRate:     current.CommissionRate,
+			ValidatorThreshold: current.ValidatorThreshold,
+			JailThreshold:      current.JailThreshold,
+			JailPeriod:         current.JailPeriod,
+		}
+		// The first block of the epoch 11, after the shortened block time fork
+		forked = &types.Header{Number: big.NewInt(10 * 5760)}
+	)
+	tests := []struct {
+		name     string
+		header   *types.Header
+		next     *params.EnvironmentValue
+		value    params.EnvironmentValue
+		start    uint64 // Expected start block
+		period   uint64 // Expected block period
+		epoch    uint64 // Expected epoch period
+		rate     int64  // Expected reward rate
+		conflict bool
+	}{
+		{"unscheduled", env.chain.CurrentHeader(), current, params.EnvironmentValue{StartEpoch: big.NewInt(3), RewardRate: big.NewInt(20)}, 11520, 15, 5760, 20, true},
+		{"after scheduled", env.chain.CurrentHeader(), scheduled, params.EnvironmentValue{StartEpoch: big.NewInt(5)}, 11720, 6, 100, 10, true},
+		{"before scheduled", env.chain.CurrentHeader(), scheduled, params.EnvironmentValue{StartEpoch: big.NewInt(2), BlockPeriod: big.NewInt(3)}, 5760, 3, 5760, 10, true},
+		{"after fork", forked, current, params.EnvironmentValue{StartEpoch: big.NewInt(12)}, 63360, 15, 5760, 10, false},
+	}
+	totalStake := newEth(40_000_000)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := simulateEnvironmentUpdate(config, tt.header, env.genesis, tt.next, tt.value)
+			if err != nil {
+				t.Fatalf("failed to simulate: %v", err)
+			}
+			if result.StartBlock != tt.start || result.Environment.StartBlock.Uint64() != tt.start {
+				t.Errorf("start block mismatch: have %d (%v), want %d", result.StartBlock, result.Environment.StartBlock, tt.start)
+			}
+			if result.Environment.BlockPeriod.Uint64() != tt.period || result.BlocksPerEpoch != tt.epoch || result.EpochDuration != tt.period*tt.epoch {
+				t.Errorf("periods mismatch: have %v/%d (%ds), want %d/%d", result.Environment.BlockPeriod, result.BlocksPerEpoch, result.EpochDuration, tt.period, tt.epoch)
+			}
+			if result.TotalStake.ToInt().Cmp(totalStake) != 0 {
+				t.Errorf("total stake mismatch: have %v, want %v", result.TotalStake, totalStake)
+			}
+			rewards := new(big.Int).Mul(totalStake, big.NewInt(tt.rate*int64(tt.period*tt.epoch)))
+			rewards.Div(rewards, big.NewInt(100*365*24*60*60))
+			if result.EpochRewards.ToInt().Cmp(rewards) != 0 {
+				t.Errorf("epoch rewards mismatch: have %v, want %v", result.EpochRewards, rewards)
+			}
+			if result.ForkConflict != tt.conflict {
+				t.Errorf("fork conflict mismatch: have %v, want %v", result.ForkConflict, tt.conflict)
+			}
+		})
+	}
+
+	// The updates not taking effect in a future epoch, or with zero periods
+	// are rejected
+	for _, value := range []params.EnvironmentValue{
+		{StartEpoch: common.Big1},
+		{StartEpoch: big.NewInt(3), BlockPeriod: common.Big0},
+		{StartEpoch: big.NewInt(3), EpochPeriod: common.Big0},
+	} {
+		if _, err := simulateEnvironmentUpdate(config, env.chain.CurrentHeader(), env.genesis, current, value); err == nil {
+			t.Errorf("invalid update accepted: %+v", value)
+		}
+	}
+}
+
+func TestGetBlockAttestation(t *testing.T) {
+	env, err := makeVoteEnv(4)
+	if err != nil {
+		t.Fatalf("failed to create test vote env: %v", err)
+	}
+	if err := makeAttestedChain(env, 4, 3, 2); err != nil {
+		t.Fatalf("failed to build chain: %v", err)
+	}
+	api := &API{chain: env.chain, oasys: env.engine}
+
+	// Resolves the voters of the attestation by the number and the hash alike
+	header := env.chain.GetHeaderByNumber(2)
+	for _, blockNrOrHash := range []rpc.BlockNumberOrHash{
+		rpc.BlockNumberOrHashWithNumber(2),
+		rpc.BlockNumberOrHashWithHash(header.Hash(), false),
+	} {
+		result, err := api.GetBlockAttestation(blockNrOrHash)
+		if err != nil {
+			t.Fatalf("failed to get attestation: %v", err)
+		}
+		if result.Number != 2 || result.Hash != header.Hash() {
+			t.Fatalf("block mismatch: have %d %x, want 2 %x", result.Number, result.Hash, header.Hash())
+		}
+		parent := env.chain.GetHeaderByNumber(1)
+		if result.TargetNumber != 1 || result.TargetHash != parent.Hash() || result.SourceNumber != 0 || result.SourceHash != env.chain.GetHeaderByNumber(0).Hash() {
+			t.Errorf("vote data mismatch: have %d %x <- %d %x", result.TargetNumber, result.TargetHash, result.SourceNumber, result.SourceHash)
+		}
+		if result.VoteAddressSet != 0b1110 {
+			t.Errorf("vote address set mismatch: have %b, want %b", result.VoteAddressSet, 0b1110)
+		}
+		if len(result.Voters) != 3 || len(result.VoteAddresses) != 3 {
+			t.Fatalf("voters mismatch: have %d, %d vote addresses", len(result.Voters), len(result.VoteAddresses))
+		}
+		for i, v := range env.validators[:3] {
+			if result.Voters[i] != v.address || types.BLSPublicKey(result.VoteAddresses[i]) != v.voteAddress {
+				t.Errorf("voter %d mismatch: have %v", i, result.Voters[i])
+			}
+		}
+		if want := newEth(30_000_000); result.VotedStake.ToInt().Cmp(want) != 0 {
+			t.Errorf("voted stake mismatch: have %v, want %v", result.VotedStake, want)
+		}
+		if want := newEth(40_000_000); result.TotalStake.ToInt().Cmp(want) != 0 {
+			t.Errorf("total stake mismatch: have %v, want %v", result.TotalStake, want)
+		}
+	}
+
+	// The blocks without an attestation return nothing
+	for _, number := range []rpc.BlockNumber{1, rpc.LatestBlockNumber} {
+		if result, err := api.GetBlockAttestation(rpc.BlockNumberOrHashWithNumber(number)); err != nil || result != nil {
+			t.Errorf("block %d: unexpected attestation: %v, %v", number, result, err)
+		}
+	}
+	if _, err := api.GetBlockAttestation(rpc.BlockNumberOrHashWithNumber(4)); err != errUnknownBlock {
+		t.Errorf("unknown block mismatch: have %v, want %v", err, errUnknownBlock)
+	}
+}
+
+func TestCollectValidatorStakes(t *testing.T) {
+	// page packs the result of StakeManager.getValidatorStakes with the given
+	// number of stakers from the cursor, every tenth one without stake.
+	page := func(cursor, count int) []byte {
+		stakers, stakes := make([]common.Address, count), make([]*big.Int, count)
+		for i := range stakers {
+			stakers[i] = common.BigToAddress(big.NewInt(int64(cursor + i + 1)))
+			stakes[i] = big.NewInt(int64((cursor + i) % 10))
+		}
+		out, err := stakeManager.abi.Methods["getValidatorStakes"].Outputs.Pack(stakers, stakes, big.NewInt(int64(cursor+count)))
+		if err != nil {
+			t.Fatalf("failed to pack: %v", err)
+		}
+		return out
+	}
+	// pages returns the responses of iterating the given number of stakers from
+	// the cursor, including the probe beyond the limit.
+	pages := func(cursor, total int) [][]byte {
+		var out [][]byte
+		for scanned := 0; ; {
+			count := min(validatorStakesPageSize, maxValidatorStakes-scanned, total-cursor)
+			if scanned == maxValidatorStakes {
+				count = min(1, total-cursor)
+			}
+			out = append(out, page(cursor, count))
+			if count == 0 || scanned == maxValidatorStakes {
+				return out
+			}
+			cursor, scanned = cursor+count, scanned+count
+		}
+	}
+	tests := []struct {
+		cursor, total int
+		stakers       int // Number of the stakers with stake returned
+		next          *big.Int
+	}{
+		{0, 0, 0, nil},
+		{0, 150, 135, nil},
+		{100, 150, 45, nil},
+		{0, maxValidatorStakes, maxValidatorStakes * 9 / 10, nil}, // No next page on the limit
+		{0, maxValidatorStakes + 1, maxValidatorStakes * 9 / 10, big.NewInt(maxValidatorStakes)},
+		{50, maxValidatorStakes + 100, maxValidatorStakes * 9 / 10, big.NewInt(maxValidatorStakes + 50)},
+	}
+	for i, tt := range tests {
+		var (
+			ethAPI = &testBlockchainAPI{rbytes: map[common.Address][][]byte{stakeManager.address: pages(tt.cursor, tt.total)}}
+			result = &ValidatorStakes{Total: new(hexutil.Big)}
+		)
+		next, err := newSystemContracts(nil).collectValidatorStakes(context.Background(), ethAPI, common.Hash{}, common.Address{}, 1, big.NewInt(int64(tt.cursor)), result)
+		if err != nil {
+			t.Fatalf("test %d: failed to collect: %v", i, err)
+		}
+		if len(result.Stakers) != tt.stakers {
+			t.Errorf("test %d: stakers mismatch: have %d, want %d", i, len(result.Stakers), tt.stakers)
+		}
+		if (next == nil) != (tt.next == nil) || (next != nil && next.Cmp(tt.next) != 0) {
+			t.Errorf("test %d: next cursor mismatch: have %v, want %v", i, next, tt.next)
+		}
+		if have, want := ethAPI.count[stakeManager.address], len(ethAPI.rbytes[stakeManager.address]); have != want {
+			t.Errorf("test %d: calls mismatch: have %d, want %d", i, have, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package oasys
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/prysmaticlabs/prysm/v5/crypto/bls"
+)
+
+// attestationBatchSize is the maximum number of headers whose attestation
+// signatures are verified at once by VerifyHeaders.
+const attestationBatchSize = 128
+
+// attestationBatch defers the verification of the aggregated signatures of the
+// attestations in a batch of headers, so that they are verified with a single
+// multi-pairing instead of a pairing per header.
+type attestationBatch struct {
+	pending []*pendingAttestation
+}
+
+// pendingAttestation is an attestation whose other checks passed, waiting for
+// its aggregated signature to be verified.
+type pendingAttestation struct {
+	index       int // Index of the header given to VerifyHeaders
+	epoch       uint64
+	header      *types.Header
+	attestation *types.VoteAttestation
+	signature   bls.Signature
+	pubKey      bls.PublicKey // Aggregated public key of the voted validators
+}
+
+func (b *attestationBatch) add(index int, epoch uint64, header *types.Header, attestation *types.VoteAttestation, signature bls.Signature, pubKeys []bls.PublicKey) {
+	b.pending = append(b.pending, &pendingAttestation{
+		index:       index,
+		epoch:       epoch,
+		header:      header,
+		attestation: attestation,
+		signature:   signature,
+		pubKey:      bls.AggregateMultiplePubkeys(pubKeys),
+	})
+}
+
+// verify verifies the deferred signatures, and returns the errors of the headers
+// failing the verification by their index. If the batch as a whole fails, the
+// signatures are verified one by one to find the invalid ones.
+func (b *attestationBatch) verify(c *Oasys) map[int]error {
+	defer func() { b.pending = b.pending[:0] }()
+	if len(b.pending) == 0 {
+		return nil
+	}
+	var (
+		sigs    = make([][]byte, len(b.pending))
+		msgs    = make([][32]byte, len(b.pending))
+		pubKeys = make([]bls.PublicKey, len(b.pending))
+	)
+	for i, p := range b.pending {
+		sigs[i] = p.attestation.AggSignature[:]
+		msgs[i] = p.attestation.Data.Hash()
+		pubKeys[i] = p.pubKey
+	}
+	valid, err := bls.VerifyMultipleSignatures(sigs, msgs, pubKeys)
+	if err != nil {
+		log.Debug("Failed to batch verify attestations", "headers", len(b.pending), "err", err)
+	}
+
+	failures := make(map[int]error)
+	for _, p := range b.pending {
+		if !valid && !p.verify() {
+			if err := c.attestationError(p.header, p.epoch, errors.New("invalid attestation, signature verify failed")); err != nil {
+				failures[p.index] = err
+			}
+			continue
+		}
+		if hooks := c.hooks.Load(); hooks != nil && hooks.OnAttestationVerified != nil {
+			hooks.OnAttestationVerified(p.header, p.attestation)
+		}
+	}
+	return failures
+}
+
+// verify verifies the signature of the attestation alone.
+func (p *pendingAttestation) verify() bool {
+	hash := p.attestation.Data.Hash()
+	return p.signature.Verify(p.pubKey, hash[:])
+}
@@ -0,0 +1,194 @@
+package oasys
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/prysmaticlabs/prysm/v5/crypto/bls"
+)
+
+// makeBatchAttestations creates the attestations of the given number of headers
+// signed by the given number of validators.
+func makeBatchAttestations(b testing.TB, headers, voters int) *attestationBatch {
+	keys := make([]bls.SecretKey, voters)
+	pubKeys := make([]bls.PublicKey, voters)
+	for i := range keys {
+		key, err := bls.RandKey()
+		if err != nil {
+			b.Fatal(err)
+		}
+		keys[i], pubKeys[i] = key, key.PublicKey()
+	}
+	batch := new(attestationBatch)
+	for i := 0; i < headers; i++ {
+		attestation := &types.VoteAttestation{
+			Data: &types.VoteData{SourceNumber: uint64(i), TargetNumber: uint64(i + 1), TargetHash: common.BigToHash(big.NewInt(int64(i + 1)))},
+		}
+		hash := attestation.Data.Hash()
+		sigs := make([]bls.Signature, voters)
+		for j, key := range keys {
+			sigs[j] = key.Sign(hash[:])
+		}
+		sig := bls.AggregateSignatures(sigs)
+		copy(attestation.AggSignature[:], sig.Marshal())
+		batch.add(i, 0, &types.Header{Number: big.NewInt(int64(i + 2))}, attestation, sig, pubKeys)
+	}
+	return batch
+}
+
+func BenchmarkAttestationBatch(b *testing.B) {
+	engine := &Oasys{}
+	for _, headers := range []int{1, 16, attestationBatchSize} {
+		b.Run(fmt.Sprintf("single/%d", headers), func(b *testing.B) {
+			batch := makeBatchAttestations(b, headers, 21)
+			pending := batch.pending
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, p := range pending {
+					if !p.verify() {
+						b.Fatal("invalid signature")
+					}
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("batch/%d", headers), func(b *testing.B) {
+			batch := makeBatchAttestations(b, headers, 21)
+			pending := batch.pending
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				batch.pending = append(batch.pending[:0], pending...)
+				if failures := batch.verify(engine); len(failures) != 0 {
+					b.Fatal("invalid signatures", failures)
+				}
+			}
+		})
+	}
+}
+
+func TestAttestationBatchFallback(t *testing.T) {
+	env, err := makeVoteEnv(1)
+	if err != nil {
+		t.Fatalf("failed to create test vote env: %v", err)
+	}
+	var verified []uint64
+	env.engine.SetHooks(&tracing.ConsensusHooks{
+		OnAttestationVerified: func(header *types.Header, attestation *types.VoteAttestation) {
+			verified = append(verified, header.Number.Uint64())
+		},
+	})
+
+	// The batch failing as a whole falls back to the signatures one by one,
+	// reporting the invalid one by the index of its header
+	batch := makeBatchAttestations(t, 5, 4)
+	invalid := batch.pending[2]
+	invalid.signature = batch.pending[3].signature
+	copy(invalid.attestation.AggSignature[:], invalid.signature.Marshal())
+
+	failures := batch.verify(env.engine)
+	if len(failures) != 1 || !errors.Is(failures[2], ErrInvalidAttestation) {
+		t.Fatalf("failures mismatch: %v", failures)
+	}
+	if want := []uint64{2, 3, 5, 6}; fmt.Sprint(verified) != fmt.Sprint(want) {
+		t.Fatalf("verified headers mismatch: have %v, want %v", verified, want)
+	}
+	if len(batch.pending) != 0 {
+		t.Fatalf("batch not drained: %d pending", len(batch.pending))
+	}
+}
+
+func TestVerifyHeadersBatchFailures(t *testing.T) {
+	defer uncommittedHashes.Purge()
+	defer lastBlockHashes.Purge()
+
+	env, err := makeVoteEnv(4)
+	if err != nil {
+		t.Fatalf("failed to create test vote env: %v", err)
+	}
+	// The attestations of a header in the first batch and of a header in the
+	// second batch are signed by a single voter instead of the quorum
+	var (
+		count    = attestationBatchSize + 12
+		tampered = map[int]bool{5: true, attestationBatchSize + 5: true}
+		genesis  = env.chain.CurrentHeader()
+	)
+	for i := 0; i < count; i++ {
+		if err := env.vote(env.validators[:3]...); err != nil {
+			t.Fatalf("block %d: failed to vote: %v", i+1, err)
+		}
+		header, err := env.newBlock()
+		if err != nil {
+			t.Fatalf("block %d: failed to build: %v", i+1, err)
+		}
+		if tampered[i] {
+			attestation := env.engine.DecodeVoteAttestation(header)
+			if attestation == nil {
+				t.Fatalf("block %d: no attestation", i+1)
+			}
+			hash := attestation.Data.Hash()
+			copy(attestation.AggSignature[:], env.validators[0].voteKey.Sign(hash[:]).Marshal())
+			buf, err := rlp.EncodeToBytes(attestation)
+			if err != nil {
+				t.Fatalf("block %d: failed to encode: %v", i+1, err)
+			}
+			header.Extra = append(append(header.Extra[:extraVanity:extraVanity], buf...), make([]byte, extraSeal)...)
+			if err := env.seal(header); err != nil {
+				t.Fatalf("block %d: failed to seal: %v", i+1, err)
+			}
+		}
+		env.chain.insert(header)
+	}
+
+	headers := env.chain.headers[1:]
+	_, results := env.engine.VerifyHeaders(newTestHeaderChain(env.chain.config, genesis), headers)
+	for i := range headers {
+		err := <-results
+		if tampered[i] {
+			if !errors.Is(err, ErrInvalidAttestation) {
+				t.Errorf("header %d: error mismatch, have %v, want %v", i, err, ErrInvalidAttestation)
+			}
+		} else if err != nil {
+			t.Errorf("header %d: failed to verify: %v", i, err)
+		}
+	}
+}
+
+// BenchmarkVerifyHeadersReplay replays a million headers through VerifyHeaders
+// in the chunks of the downloader, on an engine without any cache warmed up.
+// Generating and replaying the chain take tens of minutes, so run it with
+// -benchtime=1x -timeout=0.
+func BenchmarkVerifyHeadersReplay(b *testing.B) {
+	const (
+		count = 1_000_000
+		chunk = 2048 // Headers imported at once by the downloader
+	)
+	defer uncommittedHashes.Purge()
+	defer lastBlockHashes.Purge()
+
+	env := makeBenchChain(b, 1, count, true)
+	genesis, headers := env.chain.headers[0], env.chain.headers[1:]
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var (
+			engine = New(env.chain.config, env.engine.config, env.engine.db, nil)
+			chain  = newTestHeaderChain(env.chain.config, genesis)
+		)
+		for start := 0; start < len(headers); start += chunk {
+			batch := headers[start:min(start+chunk, len(headers))]
+			_, results := engine.VerifyHeaders(chain, batch)
+			for _, header := range batch {
+				if err := <-results; err != nil {
+					b.Fatalf("failed to verify block %d: %v", header.Number, err)
+				}
+				chain.insert(header)
+			}
+		}
+	}
+}
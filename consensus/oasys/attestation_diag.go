@@ -0,0 +1,152 @@
+package oasys
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/willf/bitset"
+)
+
+// maxBadAttestations is the number of the recent attestations rejected for the
+// vote address set kept for the debug API.
+const maxBadAttestations = 16
+
+// AttestationVoter is a validator of an attestation, with the bit marking it in
+// the vote address set (the validator index offset by one).
+type AttestationVoter struct {
+	Bit         uint               `json:"bit"`
+	Operator    common.Address     `json:"operator"`
+	VoteAddress types.BLSPublicKey `json:"voteAddress"`
+	Stake       *hexutil.Big       `json:"stake"`
+}
+
+// AttestationDiagnostics explains the vote address set of an attestation which
+// failed the verification: the bits decoded, the validators they map to, and the
+// stakes compared against the quorum threshold.
+type AttestationDiagnostics struct {
+	Number       hexutil.Uint64 `json:"number"`
+	Hash         common.Hash    `json:"hash"`
+	Coinbase     common.Address `json:"coinbase"`
+	SourceNumber hexutil.Uint64 `json:"sourceNumber"`
+	TargetNumber hexutil.Uint64 `json:"targetNumber"`
+	Reason       string         `json:"reason"`
+
+	VoteAddressSet    hexutil.Uint64   `json:"voteAddressSet"`
+	VoteAddressSetExt []hexutil.Uint64 `json:"voteAddressSetExt,omitempty"`
+	Bits              []uint           `json:"bits"`        // Bits set in the vote address set
+	UnknownBits       []uint           `json:"unknownBits"` // Bits set without a validator, including the bit zero
+
+	Voters     []*AttestationVoter `json:"voters"`
+	Absentees  []*AttestationVoter `json:"absentees"`
+	VotedStake *hexutil.Big        `json:"votedStake"`
+	TotalStake *hexutil.Big        `json:"totalStake"`
+	Threshold  *hexutil.Big        `json:"threshold"` // 2/3 of the total stake, rounded down
+}
+
+// newAttestationDiagnostics decodes the vote address set of the attestation in
+// the header against the validators eligible to vote.
+func newAttestationDiagnostics(reason string, header *types.Header, attestation *types.VoteAttestation, validators *nextValidators) *AttestationDiagnostics {
+	d := &AttestationDiagnostics{
+		Number:         hexutil.Uint64(header.Number.Uint64()),
+		Hash:           header.Hash(),
+		Coinbase:       header.Coinbase,
+		SourceNumber:   hexutil.Uint64(attestation.Data.SourceNumber),
+		TargetNumber:   hexutil.Uint64(attestation.Data.TargetNumber),
+		Reason:         reason,
+		VoteAddressSet: hexutil.Uint64(attestation.VoteAddressSet),
+		Bits:           make([]uint, 0),
+		UnknownBits:    make([]uint, 0),
+		Voters:         make([]*AttestationVoter, 0),
+		Absentees:      make([]*AttestationVoter, 0),
+	}
+	for _, word := range attestation.VoteAddressSetExt {
+		d.VoteAddressSetExt = append(d.VoteAddressSetExt, hexutil.Uint64(word))
+	}
+	voted := bitset.From(attestation.VoteAddressWords())
+	for bit, ok := voted.NextSet(0); ok; bit, ok = voted.NextSet(bit + 1) {
+		d.Bits = append(d.Bits, bit)
+		if bit == 0 || bit > uint(len(validators.Operators)) {
+			d.UnknownBits = append(d.UnknownBits, bit)
+		}
+	}
+	votedAddrs := make([]types.BLSPublicKey, 0, voted.Count())
+	for i, operator := range validators.Operators {
+		voter := &AttestationVoter{
+			Bit:         uint(i + 1),
+			Operator:    operator,
+			VoteAddress: validators.VoteAddresses[i],
+			Stake:       (*hexutil.Big)(new(big.Int).Set(validators.Stakes[i])),
+		}
+		if voted.Test(voter.Bit) {
+			d.Voters = append(d.Voters, voter)
+			votedAddrs = append(votedAddrs, validators.VoteAddresses[i])
+		} else {
+			d.Absentees = append(d.Absentees, voter)
+		}
+	}
+	votedStake, totalStake := votingPower(votedAddrs, validators)
+	threshold := new(big.Int).Mul(totalStake, big.NewInt(2))
+	threshold.Div(threshold, big.NewInt(3))
+
+	d.VotedStake = (*hexutil.Big)(votedStake)
+	d.TotalStake = (*hexutil.Big)(totalStake)
+	d.Threshold = (*hexutil.Big)(threshold)
+	return d
+}
+
+// String summarizes the diagnostics in a single line for the error messages.
+func (d *AttestationDiagnostics) String() string {
+	return fmt.Sprintf("voters: %d/%d, bits: %v, unknown bits: %v, voted stake: %v, threshold: %v (2/3 of total stake %v)",
+		len(d.Voters), len(d.Voters)+len(d.Absentees), d.Bits, d.UnknownBits,
+		d.VotedStake.ToInt(), d.Threshold.ToInt(), d.TotalStake.ToInt())
+}
+
+// badAttestationError is the failed verification of the vote address set of an
+// attestation, carrying the diagnostics of the set.
+type badAttestationError struct {
+	diag *AttestationDiagnostics
+}
+
+func (e *badAttestationError) Error() string {
+	return fmt.Sprintf("invalid attestation, %s, %s", e.diag.Reason, e.diag)
+}
+
+// badAttestations keeps the diagnostics of the recent attestations rejected for
+// the vote address set.
+type badAttestations struct {
+	lock  sync.Mutex
+	diags []*AttestationDiagnostics // Oldest first
+}
+
+func (b *badAttestations) add(diag *AttestationDiagnostics) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for _, d := range b.diags {
+		if d.Hash == diag.Hash {
+			return
+		}
+	}
+	b.diags = append(b.diags, diag)
+	if len(b.diags) > maxBadAttestations {
+		b.diags = b.diags[len(b.diags)-maxBadAttestations:]
+	}
+}
+
+func (b *badAttestations) list() []*AttestationDiagnostics {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return append([]*AttestationDiagnostics{}, b.diags...)
+}
+
+// BadAttestations returns the diagnostics of the recent attestations rejected
+// for the vote address set, since fast finality makes them invalidate the block.
+// The blocks are not imported, so the diagnostics are kept as of the rejection.
+func (api *DebugAPI) BadAttestations() []*AttestationDiagnostics {
+	return api.oasys.badAttestations.list()
+}
@@ -0,0 +1,132 @@
+package oasys
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestBadAttestationDiagnostics(t *testing.T) {
+	env, err := makeVoteEnv(4)
+	if err != nil {
+		t.Fatalf("failed to create test vote env: %v", err)
+	}
+	for _, voters := range []int{4, 3} {
+		if err := env.vote(env.validators[:voters]...); err != nil {
+			t.Fatalf("failed to vote: %v", err)
+		}
+		header, err := env.newBlock()
+		if err != nil {
+			t.Fatalf("failed to build: %v", err)
+		}
+		env.chain.insert(header)
+	}
+	if err := env.vote(env.validators[:3]...); err != nil {
+		t.Fatalf("failed to vote: %v", err)
+	}
+	header, err := env.newBlock()
+	if err != nil {
+		t.Fatalf("failed to build: %v", err)
+	}
+
+	// Drop the first voter and mark a bit beyond the validators
+	attestation := env.engine.DecodeVoteAttestation(header)
+	attestation.VoteAddressSet &^= 1 << 1
+	attestation.SetVoter(6)
+	buf, err := rlp.EncodeToBytes(attestation)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	header.Extra = append(append(header.Extra[:extraVanity:extraVanity], buf...), make([]byte, extraSeal)...)
+	if err := env.seal(header); err != nil {
+		t.Fatalf("failed to seal: %v", err)
+	}
+
+	var bad *badAttestationError
+	if err := env.verify(header); !errors.As(err, &bad) {
+		t.Fatalf("expected bad attestation error, got: %v", err)
+	}
+	diag := bad.diag
+	if !reflect.DeepEqual(diag.Bits, []uint{2, 3, 6}) || !reflect.DeepEqual(diag.UnknownBits, []uint{6}) {
+		t.Errorf("bits mismatch: have %v, unknown %v", diag.Bits, diag.UnknownBits)
+	}
+	if len(diag.Voters) != 2 || len(diag.Absentees) != 2 {
+		t.Errorf("voters mismatch: have %d voters, %d absentees", len(diag.Voters), len(diag.Absentees))
+	}
+	if diag.Voters[0].Operator != env.validators[1].address && diag.Voters[0].Operator != env.validators[2].address {
+		t.Errorf("unexpected voter: %v", diag.Voters[0].Operator)
+	}
+	if want := newEth(20_000_000); diag.VotedStake.ToInt().Cmp(want) != 0 {
+		t.Errorf("voted stake mismatch: have %v, want %v", diag.VotedStake, want)
+	}
+	if want := newEth(40_000_000); diag.TotalStake.ToInt().Cmp(want) != 0 {
+		t.Errorf("total stake mismatch: have %v, want %v", diag.TotalStake, want)
+	}
+	if diag.VotedStake.ToInt().Cmp(diag.Threshold.ToInt()) >= 0 {
+		t.Errorf("voted stake %v not below threshold %v", diag.VotedStake, diag.Threshold)
+	}
+
+	// The fatal failure is kept for the debug API and carried in the error data
+	err = env.engine.attestationError(header, 1, bad)
+	var oerr *Error
+	if !errors.As(err, &oerr) {
+		t.Fatalf("expected consensus error, got: %v", err)
+	}
+	if data := oerr.ErrorData().(map[string]interface{}); data["attestation"] != diag {
+		t.Errorf("diagnostics missing in error data: %v", data)
+	}
+	api := &DebugAPI{chain: env.chain, oasys: env.engine}
+	if got := api.BadAttestations(); len(got) != 1 || got[0] != diag {
+		t.Errorf("bad attestations mismatch: have %v", got)
+	}
+}
+
+func TestVoterBeyondValidators(t *testing.T) {
+	env, err := makeVoteEnv(4)
+	if err != nil {
+		t.Fatalf("failed to create test vote env: %v", err)
+	}
+	env.engine.chainConfig.Oasys.LargeVoteSetBlock = common.Big0
+
+	// Leave a validator out, so that a bit beyond the validators would keep the
+	// vote number within the validators number.
+	for i, voters := range []int{4, 3} {
+		if err := env.vote(env.validators[:voters]...); err != nil {
+			t.Fatalf("failed to vote: %v", err)
+		}
+		header, err := env.newBlock()
+		if err != nil {
+			t.Fatalf("failed to build: %v", err)
+		}
+		if i == 0 {
+			env.chain.insert(header)
+			continue
+		}
+		if err := env.verify(header); err != nil {
+			t.Fatalf("failed to verify the untouched attestation: %v", err)
+		}
+
+		// Mark a bit beyond the validators in either the base or the extended word
+		for _, index := range []int{5, 63, 64, 130} {
+			attestation := env.engine.DecodeVoteAttestation(header)
+			attestation.SetVoter(index)
+			buf, err := rlp.EncodeToBytes(attestation)
+			if err != nil {
+				t.Fatalf("failed to encode: %v", err)
+			}
+			tampered := types.CopyHeader(header)
+			tampered.Extra = append(append(header.Extra[:extraVanity:extraVanity], buf...), make([]byte, extraSeal)...)
+			if err := env.seal(tampered); err != nil {
+				t.Fatalf("failed to seal: %v", err)
+			}
+			var bad *badAttestationError
+			if err := env.verify(tampered); !errors.As(err, &bad) {
+				t.Errorf("voter %d: expected bad attestation error, got: %v", index, err)
+			}
+		}
+	}
+}
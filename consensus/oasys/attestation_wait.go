@@ -0,0 +1,125 @@
+package oasys
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// attestationWaitInterval is the interval to poll the vote pool while waiting
+// for the votes to reach the quorum.
+const attestationWaitInterval = 50 * time.Millisecond
+
+// attestationWaitPeriodDivisor bounds the wait for the votes to the fraction of
+// the block period from the slot of the block, leaving the rest of the slot to
+// propagate the block before the next one is sealed on top of it.
+const attestationWaitPeriodDivisor = 4
+
+var (
+	// Outcomes of the waits for the votes before sealing
+	attestationWaitReachedCounter = metrics.NewRegisteredCounter("oasys/attestation/wait/reached", nil)
+	attestationWaitTimeoutCounter = metrics.NewRegisteredCounter("oasys/attestation/wait/timeout", nil)
+	attestationWaitTimer          = metrics.NewRegisteredTimer("oasys/attestation/wait", nil)
+)
+
+// AttestationWait returns the maximum time to postpone sealing for the votes
+// close to the quorum to reach it, zero if the wait is disabled.
+func (c *Oasys) AttestationWait() time.Duration {
+	return time.Duration(c.attestationWait.Load())
+}
+
+// SetAttestationWait sets the maximum time to postpone sealing for the votes
+// close to the quorum to reach it, zero disables the wait.
+func (c *Oasys) SetAttestationWait(wait time.Duration) {
+	c.attestationWait.Store(int64(wait))
+}
+
+// isCloseToQuorum returns whether the voted stake is at least the half of the
+// total stake, so that the quorum is likely to be reached shortly.
+func isCloseToQuorum(voted, total *big.Int) bool {
+	return new(big.Int).Mul(voted, big.NewInt(2)).Cmp(total) >= 0
+}
+
+// attestationWaitDeadline returns the time to stop waiting for the votes, up to
+// the configured wait from now and within the first fraction of the slot.
+func attestationWaitDeadline(now, slot time.Time, wait, period time.Duration) time.Time {
+	deadline := now.Add(wait)
+	if limit := slot.Add(period / attestationWaitPeriodDivisor); deadline.After(limit) {
+		deadline = limit
+	}
+	return deadline
+}
+
+// waitForVotes postpones sealing the header while the votes for its parent are
+// close to the quorum of the vote attestation but not yet sufficient, up to the
+// configured wait and within the first fraction of the slot of the header.
+func (c *Oasys) waitForVotes(chain consensus.ChainHeaderReader, pool consensus.VotePool, header *types.Header, env *params.EnvironmentValue, stop <-chan struct{}) {
+	if c.AttestationWait() <= 0 || !c.chainConfig.IsFastFinalityEnabled(header.Number) || header.Number.Uint64() < 2 {
+		return
+	}
+	parent := chain.GetHeaderByHash(header.ParentHash)
+	if parent == nil {
+		return
+	}
+	snap, err := c.snapshot(chain, parent.Number.Uint64()-1, parent.ParentHash, nil)
+	if err != nil {
+		return
+	}
+	validators, err := c.getNextValidators(chain, header, snap, true)
+	if err != nil {
+		return
+	}
+	power := func() (*big.Int, *big.Int) {
+		votes := pool.FetchVoteByBlockHash(parent.Hash())
+		votedAddrs := make([]types.BLSPublicKey, 0, len(votes))
+		for _, vote := range votes {
+			votedAddrs = append(votedAddrs, vote.VoteAddress)
+		}
+		return votingPower(votedAddrs, validators)
+	}
+	voted, total := power()
+	if hasQuorum(voted, total) || !isCloseToQuorum(voted, total) {
+		return
+	}
+
+	var (
+		start    = time.Now()
+		slot     = time.UnixMilli(int64(headerMilliTime(header)))
+		period   = time.Duration(c.blockMilliPeriod(header.Number, env)) * time.Millisecond
+		deadline = attestationWaitDeadline(start, slot, c.AttestationWait(), period)
+		wait     = deadline.Sub(start)
+	)
+	if wait <= 0 {
+		return
+	}
+	var (
+		timeout = time.NewTimer(wait)
+		ticker  = time.NewTicker(attestationWaitInterval)
+	)
+	defer timeout.Stop()
+	defer ticker.Stop()
+	defer func() { attestationWaitTimer.UpdateSince(start) }()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timeout.C:
+			attestationWaitTimeoutCounter.Inc(1)
+			log.Debug("Votes did not reach the quorum within the wait", "number", header.Number, "voted", voted, "total", total, "wait", common.PrettyDuration(wait))
+			return
+		case <-ticker.C:
+			if voted, total = power(); hasQuorum(voted, total) {
+				attestationWaitReachedCounter.Inc(1)
+				log.Debug("Votes reached the quorum within the wait", "number", header.Number, "elapsed", common.PrettyDuration(time.Since(start)))
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package oasys
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// The benchmarks of the consensus hot paths, run by `make bench-consensus`. The
+// validator keys, the stakes and the scheduler seeds are fixed, so the results
+// are comparable across releases with benchstat.
+
+// makeBenchChain produces a chain of the given number of blocks sealed by the
+// given number of validators. If vote is set, every block is voted for by all
+// the validators.
+func makeBenchChain(b testing.TB, validators, blocks int, vote bool) *testVoteEnv {
+	env, err := makeVoteEnv(validators)
+	if err != nil {
+		b.Fatalf("failed to create test vote env: %v", err)
+	}
+	for i := 0; i < blocks; i++ {
+		if vote {
+			if err := env.vote(env.validators...); err != nil {
+				b.Fatalf("failed to vote: %v", err)
+			}
+		}
+		header, err := env.newBlock()
+		if err != nil {
+			b.Fatalf("failed to build block %d: %v", i+1, err)
+		}
+		env.chain.insert(header)
+	}
+	return env
+}
+
+func BenchmarkVerifyHeader(b *testing.B) {
+	env := makeBenchChain(b, 21, 256, true)
+	headers := env.chain.headers[1:]
+
+	// Warm up the snapshots, so that the verification itself is measured
+	for _, header := range headers {
+		if err := env.engine.verifyHeader(env.chain, header, nil, nil); err != nil {
+			b.Fatalf("failed to verify block %d: %v", header.Number, err)
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := env.engine.verifyHeader(env.chain, headers[i%len(headers)], nil, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSnapshotApply(b *testing.B) {
+	const blocks = 30_000 // Across the 5760-block epochs
+
+	env := makeBenchChain(b, 21, blocks, false)
+	snap := env.genesis.copy()
+	headers := env.chain.headers[1:]
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Recover the signers on every run, as a node syncing the blocks does
+		b.StopTimer()
+		snap.sigcache, _ = newSigCache(inmemorySignatures)
+		b.StartTimer()
+
+		if _, err := snap.apply(headers, env.chain, env.engine.config, env.engine.contracts); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*blocks), "ns/block")
+}
+
+func BenchmarkNewScheduler(b *testing.B) {
+	const size = 1000
+
+	var (
+		env = &params.EnvironmentValue{
+			StartBlock:         common.Big0,
+			StartEpoch:         common.Big1,
+			EpochPeriod:        big.NewInt(5760),
+			ValidatorThreshold: newEth(10_000_000),
+		}
+		operators = make([]common.Address, size)
+		stakes    = make([]*big.Int, size)
+		seed      = scheduleSeed(common.HexToHash("0x01"), true)
+	)
+	for i := 0; i < size; i++ {
+		operators[i] = common.BigToAddress(big.NewInt(int64(i + 1)))
+		stakes[i] = newEth(int64(10_000_000 + i*1000))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		newScheduler(env, 0, newWeightedChooser(operators, stakes, seed))
+	}
+}
+
+func BenchmarkVerifyVoteAttestation(b *testing.B) {
+	for _, validators := range []int{21, 63} {
+		b.Run(fmt.Sprintf("validators/%d", validators), func(b *testing.B) {
+			env := makeBenchChain(b, validators, 3, true)
+			header := env.chain.CurrentHeader()
+			if env.engine.DecodeVoteAttestation(header) == nil {
+				b.Fatal("missing attestation")
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := env.verify(header); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// benchEngine skips the finalization of the blocks, which calls the system
+// contracts through the RPC backend unavailable to the benchmarks, so that the
+// transactions of the blocks are measured only.
+type benchEngine struct {
+	*Oasys
+}
+
+func (e *benchEngine) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs *[]*types.Transaction,
+	uncles []*types.Header, withdrawals []*types.Withdrawal, receipts *[]*types.Receipt, systemTxs *[]*types.Transaction, usedGas *uint64) error {
+	return nil
+}
+
+// BenchmarkProcessMainnet replays the recent blocks of the mainnet with and
+// without the parallel transaction execution. It requires the chaindata of a
+// mainnet archive node, given by OASYS_MAINNET_CHAINDATA and opened read-only:
+//
+//	OASYS_MAINNET_CHAINDATA=/path/to/geth/chaindata go test -run '^$' -bench ProcessMainnet ./consensus/oasys
+func BenchmarkProcessMainnet(b *testing.B) {
+	b.Run("serial", func(b *testing.B) { benchmarkProcessMainnet(b, false) })
+	b.Run("parallel", func(b *testing.B) { benchmarkProcessMainnet(b, true) })
+}
+
+func benchmarkProcessMainnet(b *testing.B, parallel bool) {
+	const blocks = 256 // Recent blocks replayed, whose parent states are available
+
+	dir := os.Getenv("OASYS_MAINNET_CHAINDATA")
+	if dir == "" {
+		b.Skip("OASYS_MAINNET_CHAINDATA is not set")
+	}
+	db, err := rawdb.Open(rawdb.OpenOptions{
+		Directory:         dir,
+		AncientsDirectory: filepath.Join(dir, "ancient"),
+		Cache:             1024,
+		Handles:           512,
+		ReadOnly:          true,
+	})
+	if err != nil {
+		b.Fatalf("failed to open the chaindata: %v", err)
+	}
+	defer db.Close()
+
+	config := rawdb.ReadChainConfig(db, rawdb.ReadCanonicalHash(db, 0))
+	if config == nil || config.ChainID.Cmp(params.OasysMainnetChainConfig.ChainID) != 0 {
+		b.Fatalf("not the chaindata of the mainnet")
+	}
+	scheme := rawdb.ReadStateScheme(db)
+	if scheme == "" {
+		scheme = rawdb.HashScheme
+	}
+	cacheConfig := core.DefaultCacheConfigWithScheme(scheme)
+	cacheConfig.ParallelTx = parallel
+	// Neither the tries nor the snapshot are written to the read-only database
+	cacheConfig.TrieDirtyDisabled = true
+	cacheConfig.SnapshotLimit = 0
+	chain, err := core.NewBlockChain(db, cacheConfig, nil, nil, &benchEngine{New(config, config.Oasys, db, nil)}, vm.Config{}, nil, nil)
+	if err != nil {
+		b.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	var (
+		replayed []*types.Block
+		txs      int
+	)
+	for number := chain.CurrentBlock().Number.Uint64(); number > 0 && len(replayed) < blocks; number-- {
+		block := chain.GetBlockByNumber(number)
+		if block == nil || !chain.HasState(chain.GetHeaderByHash(block.ParentHash()).Root) {
+			break
+		}
+		replayed = append(replayed, block)
+		txs += len(block.Transactions())
+	}
+	if len(replayed) == 0 {
+		b.Fatalf("no state of the recent blocks, which requires an archive node")
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, block := range replayed {
+			b.StopTimer()
+			statedb, err := state.New(chain.GetHeaderByHash(block.ParentHash()).Root, chain.StateCache(), nil)
+			if err != nil {
+				b.Fatalf("failed to open the state of block %d: %v", block.NumberU64()-1, err)
+			}
+			b.StartTimer()
+			if _, _, _, err := chain.Processor().Process(block, statedb, vm.Config{}); err != nil {
+				b.Fatalf("failed to process block %d: %v", block.NumberU64(), err)
+			}
+		}
+	}
+	b.ReportMetric(float64(txs), "txs/op")
+}
@@ -0,0 +1,105 @@
+package oasys
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// maxLatencyGauges bounds the number of the per-proposer latency gauges, in case
+// the blocks of an epoch come from more proposers than expected.
+const maxLatencyGauges = 1000
+
+var (
+	// blockIntervalHistogram is the distribution of the seconds between the
+	// timestamps of the received blocks and their parents.
+	blockIntervalHistogram = metrics.NewRegisteredHistogram("oasys/block/interval", nil, metrics.NewExpDecaySample(1028, 0.015))
+	// blockDelayHistogram is the distribution of the milliseconds from the
+	// timestamp of the received blocks to their local receipt.
+	blockDelayHistogram = metrics.NewRegisteredHistogram("oasys/block/delay", nil, metrics.NewExpDecaySample(1028, 0.015))
+)
+
+// latencyMetrics averages the delay of the blocks received per proposer in the
+// current epoch. As the vote gauges, the per-proposer gauges only live through
+// an epoch.
+type latencyMetrics struct {
+	lock   sync.Mutex
+	epoch  uint64
+	sums   map[common.Address]int64
+	counts map[common.Address]int64
+}
+
+var proposerLatencyMetrics = &latencyMetrics{
+	sums:   make(map[common.Address]int64),
+	counts: make(map[common.Address]int64),
+}
+
+// latencyGaugeName returns the name of the latency gauge of the proposer.
+func latencyGaugeName(proposer common.Address) string {
+	return fmt.Sprintf("oasys/block/latency/%s", proposer.String())
+}
+
+// mark adds the delay in milliseconds of a block of the proposer in the given
+// epoch. The blocks of the past epochs are not counted.
+func (m *latencyMetrics) mark(epoch uint64, proposer common.Address, delay int64) {
+	if !metrics.Enabled {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if epoch < m.epoch {
+		return
+	}
+	if epoch > m.epoch {
+		m.reset(epoch)
+	}
+	count, ok := m.counts[proposer]
+	if !ok && len(m.counts) >= maxLatencyGauges {
+		return
+	}
+	count++
+	m.counts[proposer] = count
+	m.sums[proposer] += delay
+	metrics.GetOrRegisterGauge(latencyGaugeName(proposer), nil).Update(m.sums[proposer] / count)
+}
+
+// reset drops the gauges of the ended epoch.
+func (m *latencyMetrics) reset(epoch uint64) {
+	for proposer := range m.counts {
+		metrics.DefaultRegistry.Unregister(latencyGaugeName(proposer))
+	}
+	m.epoch = epoch
+	m.sums = make(map[common.Address]int64)
+	m.counts = make(map[common.Address]int64)
+}
+
+// MarkBlockReceived updates the block interval and latency metrics with a block
+// propagated by the peers, received locally at the given time. It's meant to be
+// called once the block is imported, to measure the readiness of the network
+// for a shorter block time.
+func (c *Oasys) MarkBlockReceived(chain consensus.ChainHeaderReader, header *types.Header, receivedAt time.Time) {
+	if !metrics.Enabled || receivedAt.IsZero() {
+		return
+	}
+	number := header.Number.Uint64()
+	if number == 0 {
+		return
+	}
+	if parent := chain.GetHeader(header.ParentHash, number-1); parent != nil {
+		blockIntervalHistogram.Update(int64(header.Time - parent.Time))
+	}
+	delay := receivedAt.Sub(time.UnixMilli(int64(headerMilliTime(header)))).Milliseconds()
+	blockDelayHistogram.Update(delay)
+
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return
+	}
+	proposerLatencyMetrics.mark(snap.Environment.Epoch(number), header.Coinbase, delay)
+}
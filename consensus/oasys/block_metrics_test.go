@@ -0,0 +1,46 @@
+package oasys
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+func TestLatencyMetricsReset(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	var (
+		m = &latencyMetrics{
+			sums:   make(map[common.Address]int64),
+			counts: make(map[common.Address]int64),
+		}
+		alice   = common.HexToAddress("0x01")
+		bob     = common.HexToAddress("0x02")
+		gaugeOf = func(addr common.Address) metrics.Gauge {
+			g, _ := metrics.DefaultRegistry.Get(latencyGaugeName(addr)).(metrics.Gauge)
+			return g
+		}
+		avgOf = func(addr common.Address) int64 { return gaugeOf(addr).Snapshot().Value() }
+	)
+	m.mark(1, alice, 100)
+	m.mark(1, alice, 300)
+	m.mark(1, bob, 50)
+	if have := avgOf(alice); have != 200 {
+		t.Fatalf("alice latency mismatch, have %d, want 200", have)
+	}
+
+	// Blocks of the past epochs are ignored, and the gauges of the proposers
+	// not proposing in the new epoch are dropped.
+	m.mark(2, bob, 70)
+	m.mark(1, alice, 100)
+	if gaugeOf(alice) != nil {
+		t.Fatal("gauge of the previous epoch not dropped")
+	}
+	if have := avgOf(bob); have != 70 {
+		t.Fatalf("bob latency mismatch, have %d, want 70", have)
+	}
+	m.reset(3)
+}
@@ -0,0 +1,93 @@
+package oasys
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// CliqueCompatAPI serves the active validators under the method names of the
+// clique API, for the tooling built for the clique based networks. It is only
+// registered if explicitly enabled.
+type CliqueCompatAPI struct {
+	api *API
+}
+
+// NewCliqueCompatAPI creates the API serving the validators as clique signers.
+func NewCliqueCompatAPI(chain consensus.ChainHeaderReader, oasys *Oasys) *CliqueCompatAPI {
+	return &CliqueCompatAPI{api: &API{chain: chain, oasys: oasys}}
+}
+
+// CliqueSnapshot is the validator set at a block in the layout of the clique
+// snapshot. There are no votes in the proof-of-stake scheme, so the votes and
+// the tally are always empty.
+type CliqueSnapshot struct {
+	Number  uint64                      `json:"number"`
+	Hash    common.Hash                 `json:"hash"`
+	Signers map[common.Address]struct{} `json:"signers"`
+	Recents map[uint64]common.Address   `json:"recents"`
+	Votes   []struct{}                  `json:"votes"`
+	Tally   map[common.Address]struct{} `json:"tally"`
+}
+
+// GetSnapshot retrieves the validator set at a given block as a clique snapshot.
+func (api *CliqueCompatAPI) GetSnapshot(number *rpc.BlockNumber) (*CliqueSnapshot, error) {
+	header, err := api.api.headerByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	return api.snapshot(header)
+}
+
+// GetSnapshotAtHash retrieves the validator set at a given block as a clique
+// snapshot.
+func (api *CliqueCompatAPI) GetSnapshotAtHash(hash common.Hash) (*CliqueSnapshot, error) {
+	header := api.api.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.snapshot(header)
+}
+
+// GetSigners retrieves the operators of the active validators at the specified
+// block.
+func (api *CliqueCompatAPI) GetSigners(number *rpc.BlockNumber) ([]common.Address, error) {
+	return api.api.GetSigners(number)
+}
+
+// GetSignersAtHash retrieves the operators of the active validators at the
+// specified block.
+func (api *CliqueCompatAPI) GetSignersAtHash(hash common.Hash) ([]common.Address, error) {
+	return api.api.GetSignersAtHash(hash)
+}
+
+// GetSigner returns the signer of the specified block.
+func (api *CliqueCompatAPI) GetSigner(rlpOrBlockNr *blockNumberOrHashOrRLP) (common.Address, error) {
+	return api.api.GetSigner(rlpOrBlockNr)
+}
+
+// snapshot converts the snapshot at the header to the clique layout. The
+// recents are the signers of the last blocks, as many as clique keeps.
+func (api *CliqueCompatAPI) snapshot(header *types.Header) (*CliqueSnapshot, error) {
+	snap, err := api.api.oasys.snapshot(api.api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &CliqueSnapshot{
+		Number:  snap.Number,
+		Hash:    snap.Hash,
+		Signers: make(map[common.Address]struct{}, len(snap.Validators)),
+		Recents: make(map[uint64]common.Address),
+		Votes:   make([]struct{}, 0),
+		Tally:   make(map[common.Address]struct{}),
+	}
+	for validator := range snap.Validators {
+		result.Signers[validator] = struct{}{}
+	}
+	for i := 0; i < len(snap.Validators)/2+1 && header != nil && header.Number.Sign() > 0; i++ {
+		result.Recents[header.Number.Uint64()] = header.Coinbase
+		header = api.api.chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	return result, nil
+}
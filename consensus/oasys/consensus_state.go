@@ -0,0 +1,86 @@
+package oasys
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ConsensusState is the dump of the engine-internal state for remote debugging.
+type ConsensusState struct {
+	Signer          common.Address          `json:"signer"`
+	ReadOnly        bool                    `json:"readOnly"`
+	VerifyOnly      bool                    `json:"verifyOnly"` // Whether the vote attestations are only verified, not assembled
+	Proposals       map[common.Address]bool `json:"proposals"`
+	AttestationWait string                  `json:"attestationWait"`
+	Config          *params.OasysConfig     `json:"config"`
+
+	// Block hashes of the snapshots cached in memory
+	Recents []common.Hash `json:"recents"`
+
+	// Proposer schedules cached in memory, keyed by the seed hash
+	Schedulers []*SchedulerState `json:"schedulers"`
+
+	// Number of the block hashes cached to compute the seed hashes
+	UncommittedHashes int `json:"uncommittedHashes"`
+	LastBlockHashes   int `json:"lastBlockHashes"`
+
+	// Number of the votes in the vote pool, filled by the backend
+	VotePool *VotePoolState `json:"votePool,omitempty"`
+}
+
+// SchedulerState is a proposer schedule cached in memory.
+type SchedulerState struct {
+	SeedHash    common.Hash      `json:"seedHash"`
+	StartEpoch  hexutil.Uint64   `json:"startEpoch"`
+	EpochPeriod hexutil.Uint64   `json:"epochPeriod"`
+	Validators  []common.Address `json:"validators"`
+}
+
+// VotePoolState is the number of the votes in the vote pool.
+type VotePoolState struct {
+	Current int `json:"current"`
+	Future  int `json:"future"`
+}
+
+// ConsensusState returns the dump of the engine-internal state. The signer
+// fields are read at once under the lock, while the caches are read as is.
+func (c *Oasys) ConsensusState() *ConsensusState {
+	c.lock.RLock()
+	state := &ConsensusState{
+		ReadOnly:        c.readOnly,
+		VerifyOnly:      c.votePool == nil,
+		Proposals:       make(map[common.Address]bool, len(c.proposals)),
+		AttestationWait: c.AttestationWait().String(),
+		Config:          c.config,
+	}
+	if c.signerState != nil {
+		state.Signer = c.signerState.signer
+	}
+	for address, authorize := range c.proposals {
+		state.Proposals[address] = authorize
+	}
+	c.lock.RUnlock()
+
+	for _, key := range c.recents.Load().Keys() {
+		if hash, ok := key.(common.Hash); ok {
+			state.Recents = append(state.Recents, hash)
+		}
+	}
+	for _, key := range schedulerCache.Keys() {
+		value, ok := schedulerCache.Peek(key)
+		if !ok {
+			continue
+		}
+		s := value.(*scheduler)
+		state.Schedulers = append(state.Schedulers, &SchedulerState{
+			SeedHash:    key.(common.Hash),
+			StartEpoch:  hexutil.Uint64(s.env.StartEpoch.Uint64()),
+			EpochPeriod: hexutil.Uint64(s.env.EpochPeriod.Uint64()),
+			Validators:  s.chooser.validators,
+		})
+	}
+	state.UncommittedHashes = uncommittedHashes.Len()
+	state.LastBlockHashes = lastBlockHashes.Len()
+	return state
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
@@ -46,7 +47,8 @@ var (
 	//go:embed oasys-genesis-contract-5675779/artifacts/contracts/CandidateValidatorManager.sol/CandidateValidatorManager.json
 	artifacts embed.FS
 
-	// Oasys genesis contracts
+	// Oasys genesis contracts, the addresses of which are the defaults of the
+	// system contracts of an engine, see newSystemContracts.
 	environment = &genesisContract{
 		address: common.HexToAddress(environmentAddress),
 		artifact: &artifact{
@@ -82,6 +84,56 @@ var (
 	}
 )
 
+// systemContracts is the system contracts of a network, which are the ones of
+// the public networks unless the genesis configures others.
+type systemContracts struct {
+	environment       *genesisContract
+	stakeManager      *genesisContract
+	candidateManager  *builtinContract
+	candidateManager2 *builtinContract
+	allowList         common.Address // Only passed to the StakeManager
+
+	systemMethods map[*genesisContract]map[string]int
+}
+
+// newSystemContracts returns the system contracts at the addresses and with the
+// expected code hashes configured in the genesis.
+func newSystemContracts(cfg *params.OasysContracts) *systemContracts {
+	var (
+		env, sm = *environment, *stakeManager
+		cm, cm2 = *candidateManager, *candidateManager2
+	)
+	s := &systemContracts{
+		environment:       &env,
+		stakeManager:      &sm,
+		candidateManager:  &cm,
+		candidateManager2: &cm2,
+		allowList:         common.HexToAddress(allowListAddress),
+	}
+	if cfg != nil {
+		s.environment.codeHash = cfg.EnvironmentCodeHash
+		s.stakeManager.codeHash = cfg.StakeManagerCodeHash
+		if cfg.Environment != (common.Address{}) {
+			s.environment.address = cfg.Environment
+		}
+		if cfg.StakeManager != (common.Address{}) {
+			s.stakeManager.address = cfg.StakeManager
+		}
+		if cfg.AllowList != (common.Address{}) {
+			s.allowList = cfg.AllowList
+		}
+		if cfg.CandidateValidatorManager != (common.Address{}) {
+			s.candidateManager.address = cfg.CandidateValidatorManager
+			s.candidateManager2.address = cfg.CandidateValidatorManager
+		}
+	}
+	s.systemMethods = map[*genesisContract]map[string]int{
+		s.environment:  systemMethods[environment],
+		s.stakeManager: systemMethods[stakeManager],
+	}
+	return s
+}
+
 func init() {
 	// Parse the system contract ABI
 	if err := environment.parseABI(); err != nil {
@@ -119,6 +171,7 @@ type contract struct {
 	address  common.Address
 	abi      *abi.ABI
 	artifact *artifact
+	codeHash common.Hash // Expected code hash, zero for the hash of the artifact
 }
 
 func (b *contract) parseABI() error {
@@ -142,10 +195,14 @@ func (b *contract) parseABI() error {
 // Contracts deployed in the genesis block
 type genesisContract = contract
 
+// verifyCode checks the hash of the deployed code against the one configured,
+// or the hash of the artifact by default.
 func (g *genesisContract) verifyCode(state *state.StateDB) bool {
-	deployed := state.GetCode(g.address)
-	expect := common.FromHex(g.artifact.DeployedBytecode)
-	return bytes.Equal(deployed, expect)
+	expect := g.codeHash
+	if expect == (common.Hash{}) {
+		expect = crypto.Keccak256Hash(common.FromHex(g.artifact.DeployedBytecode))
+	}
+	return crypto.Keccak256Hash(state.GetCode(g.address)) == expect
 }
 
 // Contracts deployed in a hard fork.
@@ -259,7 +316,7 @@ func (c *Oasys) IsSystemTransaction(tx *types.Transaction, header *types.Header)
 		return false, nil
 	}
 
-	for contract, methods := range systemMethods {
+	for contract, methods := range c.contracts.systemMethods {
 		if contract.address != *tx.To() {
 			continue
 		}
@@ -290,28 +347,28 @@ func (c *Oasys) initializeSystemContracts(
 	mining bool,
 ) error {
 	// Initialize Environment contract
-	if !environment.verifyCode(state) {
+	if !c.contracts.environment.verifyCode(state) {
 		return errors.New("invalid contract code: Environment")
 	}
-	data, err := environment.abi.Pack("initialize", params.InitialEnvironmentValue(c.config))
+	data, err := c.contracts.environment.abi.Pack("initialize", params.InitialEnvironmentValue(c.config))
 	if err != nil {
 		return err
 	}
-	msg := getMessage(header.Coinbase, environment.address, data, common.Big0)
+	msg := getMessage(header.Coinbase, c.contracts.environment.address, data, common.Big0)
 	err = c.applyTransaction(msg, state, header, cx, txs, receipts, systemTxs, usedGas, mining)
 	if err != nil {
 		return err
 	}
 
 	// Initialize StakeManager contract
-	if !stakeManager.verifyCode(state) {
+	if !c.contracts.stakeManager.verifyCode(state) {
 		return errors.New("invalid contract code: StakeManager")
 	}
-	data, err = stakeManager.abi.Pack("initialize", environment.address, common.HexToAddress(allowListAddress))
+	data, err = c.contracts.stakeManager.abi.Pack("initialize", c.contracts.environment.address, c.contracts.allowList)
 	if err != nil {
 		return err
 	}
-	msg = getMessage(header.Coinbase, stakeManager.address, data, common.Big0)
+	msg = getMessage(header.Coinbase, c.contracts.stakeManager.address, data, common.Big0)
 	err = c.applyTransaction(msg, state, header, cx, txs, receipts, systemTxs, usedGas, mining)
 	if err != nil {
 		return err
@@ -333,18 +390,33 @@ func (c *Oasys) slash(
 	usedGas *uint64,
 	mining bool,
 ) error {
+	msg, blocks, err := c.contracts.slashMessage(validator, schedules, header)
+	if err != nil {
+		return err
+	}
+	if err := c.applySystemTransaction(msg, state, header, cx, txs, receipts, systemTxs, usedGas, mining); err != nil {
+		return err
+	}
+	if hooks := c.hooks.Load(); hooks != nil && hooks.OnSlash != nil {
+		hooks.OnSlash(header, validator, blocks)
+	}
+	return nil
+}
+
+// slashMessage returns the message slashing the validator, and the number of
+// the blocks scheduled to the validator.
+func (s *systemContracts) slashMessage(validator common.Address, schedules []*common.Address, header *types.Header) (callmsg, int64, error) {
 	blocks := int64(0)
 	for _, address := range schedules {
 		if *address == validator {
 			blocks++
 		}
 	}
-	data, err := stakeManager.abi.Pack("slash", validator, big.NewInt(blocks))
+	data, err := s.stakeManager.abi.Pack("slash", validator, big.NewInt(blocks))
 	if err != nil {
-		return err
+		return callmsg{}, 0, err
 	}
-	msg := getMessage(header.Coinbase, stakeManager.address, data, common.Big0)
-	return c.applyTransaction(msg, state, header, cx, txs, receipts, systemTxs, usedGas, mining)
+	return getMessage(header.Coinbase, s.stakeManager.address, data, common.Big0), blocks, nil
 }
 
 type blockchainAPI interface {
@@ -352,7 +424,7 @@ type blockchainAPI interface {
 }
 
 // view functions
-func getNextValidators(
+func (s *systemContracts) getNextValidators(
 	config *params.ChainConfig,
 	ethAPI blockchainAPI,
 	hash common.Hash,
@@ -360,21 +432,28 @@ func getNextValidators(
 	block uint64,
 ) (*nextValidators, error) {
 	if config.IsFastFinalityEnabled(new(big.Int).SetUint64(block)) {
-		validators, err := callGetHighStakes2(ethAPI, hash, epoch)
+		validators, err := s.callGetHighStakes2(ethAPI, hash, epoch)
 		if err != nil {
 			return nil, err
 		}
 		validators.SortByOwner() // sort by owner for fast finality
+		if config.IsOasysValidatorCap(new(big.Int).SetUint64(block)) {
+			max, err := s.getMaxValidators(ethAPI, hash)
+			if err != nil {
+				return nil, err
+			}
+			validators, _ = capValidators(validators, max)
+		}
 		return validators, nil
 	}
 	if config.IsForkedOasysPublication(new(big.Int).SetUint64(block)) {
-		return callGetHighStakes(ethAPI, hash, epoch)
+		return s.callGetHighStakes(ethAPI, hash, epoch)
 	}
-	return callGetValidators(ethAPI, hash, epoch)
+	return s.callGetValidators(ethAPI, hash, epoch)
 }
 
 // Call the `StakeManager.getValidators` method.
-func callGetValidators(ethAPI blockchainAPI, hash common.Hash, epoch uint64) (*nextValidators, error) {
+func (s *systemContracts) callGetValidators(ethAPI blockchainAPI, hash common.Hash, epoch uint64) (*nextValidators, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -386,7 +465,7 @@ func callGetValidators(ethAPI blockchainAPI, hash common.Hash, epoch uint64) (*n
 		howMany = big.NewInt(100)
 	)
 	for {
-		data, err := stakeManager.abi.Pack(method, bepoch, cursor, howMany)
+		data, err := s.stakeManager.abi.Pack(method, bepoch, cursor, howMany)
 		if err != nil {
 			return nil, err
 		}
@@ -396,7 +475,7 @@ func callGetValidators(ethAPI blockchainAPI, hash common.Hash, epoch uint64) (*n
 		rbytes, err := ethAPI.Call(
 			ctx,
 			ethapi.TransactionArgs{
-				To:   &stakeManager.address,
+				To:   &s.stakeManager.address,
 				Data: &hexData,
 			},
 			&blockNrOrHash,
@@ -414,7 +493,7 @@ func callGetValidators(ethAPI blockchainAPI, hash common.Hash, epoch uint64) (*n
 			Candidates []bool
 			NewCursor  *big.Int
 		}
-		if err := stakeManager.abi.UnpackIntoInterface(&recv, method, rbytes); err != nil {
+		if err := s.stakeManager.abi.UnpackIntoInterface(&recv, method, rbytes); err != nil {
 			return nil, err
 		} else if len(recv.Owners) == 0 {
 			break
@@ -436,7 +515,7 @@ func callGetValidators(ethAPI blockchainAPI, hash common.Hash, epoch uint64) (*n
 }
 
 // Call the `CandidateValidatorManager.getHighStakes` method.
-func callGetHighStakes(ethAPI blockchainAPI, hash common.Hash, epoch uint64) (*nextValidators, error) {
+func (s *systemContracts) callGetHighStakes(ethAPI blockchainAPI, hash common.Hash, epoch uint64) (*nextValidators, error) {
 	var (
 		recv struct {
 			Owners          []common.Address
@@ -459,7 +538,7 @@ func callGetHighStakes(ethAPI blockchainAPI, hash common.Hash, epoch uint64) (*n
 			}
 		}
 	)
-	if err := callGetHighStakesCommon(ethAPI, hash, epoch, candidateManager, &recv, processCallResult); err != nil {
+	if err := callGetHighStakesCommon(ethAPI, hash, epoch, s.candidateManager, &recv, processCallResult); err != nil {
 		return nil, err
 	}
 
@@ -468,7 +547,7 @@ func callGetHighStakes(ethAPI blockchainAPI, hash common.Hash, epoch uint64) (*n
 
 // Call the `CandidateValidatorManager.getHighStakes` method.
 // This function is for the v1.6.0 contract.
-func callGetHighStakes2(ethAPI blockchainAPI, hash common.Hash, epoch uint64) (*nextValidators, error) {
+func (s *systemContracts) callGetHighStakes2(ethAPI blockchainAPI, hash common.Hash, epoch uint64) (*nextValidators, error) {
 	var (
 		recv struct {
 			Owners          []common.Address
@@ -496,7 +575,7 @@ func callGetHighStakes2(ethAPI blockchainAPI, hash common.Hash, epoch uint64) (*
 			}
 		}
 	)
-	if err := callGetHighStakesCommon(ethAPI, hash, epoch, candidateManager2, &recv, processCallResult); err != nil {
+	if err := callGetHighStakesCommon(ethAPI, hash, epoch, s.candidateManager2, &recv, processCallResult); err != nil {
 		return nil, err
 	}
 
@@ -558,7 +637,7 @@ func callGetHighStakesCommon(ethAPI blockchainAPI, hash common.Hash, epoch uint6
 }
 
 // Call the `StakeManager.getValidatorOwners` method.
-func getValidatorOwners(ethAPI blockchainAPI, hash common.Hash) ([]common.Address, error) {
+func (s *systemContracts) getValidatorOwners(ethAPI blockchainAPI, hash common.Hash) ([]common.Address, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -569,7 +648,7 @@ func getValidatorOwners(ethAPI blockchainAPI, hash common.Hash) ([]common.Addres
 		howMany = big.NewInt(100)
 	)
 	for {
-		data, err := stakeManager.abi.Pack(method, cursor, howMany)
+		data, err := s.stakeManager.abi.Pack(method, cursor, howMany)
 		if err != nil {
 			return nil, err
 		}
@@ -579,7 +658,7 @@ func getValidatorOwners(ethAPI blockchainAPI, hash common.Hash) ([]common.Addres
 		rbytes, err := ethAPI.Call(
 			ctx,
 			ethapi.TransactionArgs{
-				To:   &stakeManager.address,
+				To:   &s.stakeManager.address,
 				Data: &hexData,
 			},
 			&blockNrOrHash,
@@ -594,7 +673,7 @@ func getValidatorOwners(ethAPI blockchainAPI, hash common.Hash) ([]common.Addres
 			Owners    []common.Address
 			NewCursor *big.Int
 		}
-		if err := stakeManager.abi.UnpackIntoInterface(&recv, method, rbytes); err != nil {
+		if err := s.stakeManager.abi.UnpackIntoInterface(&recv, method, rbytes); err != nil {
 			return nil, err
 		} else if len(recv.Owners) == 0 {
 			break
@@ -607,12 +686,52 @@ func getValidatorOwners(ethAPI blockchainAPI, hash common.Hash) ([]common.Addres
 	return result, nil
 }
 
+// Call the `StakeManager.getValidatorStakes` method, returning a page of the
+// stakers of the validator and the cursor of the next page.
+func (s *systemContracts) getValidatorStakes(ctx context.Context, ethAPI blockchainAPI, hash common.Hash, validator common.Address, epoch uint64, cursor, howMany *big.Int) ([]common.Address, []*big.Int, *big.Int, error) {
+	method := "getValidatorStakes"
+
+	data, err := s.stakeManager.abi.Pack(method, validator, new(big.Int).SetUint64(epoch), cursor, howMany)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	hexData := (hexutil.Bytes)(data)
+	blockNrOrHash := rpc.BlockNumberOrHashWithHash(hash, false)
+	rbytes, err := ethAPI.Call(
+		ctx,
+		ethapi.TransactionArgs{
+			To:   &s.stakeManager.address,
+			Data: &hexData,
+		},
+		&blockNrOrHash,
+		nil,
+		nil,
+	)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var recv struct {
+		Stakers   []common.Address
+		Stakes    []*big.Int
+		NewCursor *big.Int
+	}
+	if err := s.stakeManager.abi.UnpackIntoInterface(&recv, method, rbytes); err != nil {
+		return nil, nil, nil, err
+	}
+	if len(recv.Stakers) != len(recv.Stakes) {
+		return nil, nil, nil, fmt.Errorf("mismatching stakes length, stakers: %d, stakes: %d", len(recv.Stakers), len(recv.Stakes))
+	}
+	return recv.Stakers, recv.Stakes, recv.NewCursor, nil
+}
+
 // Call the `StakeManager.getTotalRewards` method.
-func getRewards(ethAPI blockchainAPI, hash common.Hash) (*big.Int, error) {
+func (s *systemContracts) getRewards(ethAPI blockchainAPI, hash common.Hash) (*big.Int, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	validators, err := getValidatorOwners(ethAPI, hash)
+	validators, err := s.getValidatorOwners(ethAPI, hash)
 	if err != nil {
 		return nil, err
 	}
@@ -637,7 +756,7 @@ func getRewards(ethAPI blockchainAPI, hash common.Hash) (*big.Int, error) {
 		result = new(big.Int)
 	)
 	for _, chunk := range chunks {
-		data, err := stakeManager.abi.Pack(method, chunk, common.Big1)
+		data, err := s.stakeManager.abi.Pack(method, chunk, common.Big1)
 		if err != nil {
 			return nil, err
 		}
@@ -647,7 +766,7 @@ func getRewards(ethAPI blockchainAPI, hash common.Hash) (*big.Int, error) {
 		rbytes, err := ethAPI.Call(
 			ctx,
 			ethapi.TransactionArgs{
-				To:   &stakeManager.address,
+				To:   &s.stakeManager.address,
 				Data: &hexData,
 			},
 			&blockNrOrHash,
@@ -659,7 +778,7 @@ func getRewards(ethAPI blockchainAPI, hash common.Hash) (*big.Int, error) {
 		}
 
 		var recv *big.Int
-		if err := stakeManager.abi.UnpackIntoInterface(&recv, method, rbytes); err != nil {
+		if err := s.stakeManager.abi.UnpackIntoInterface(&recv, method, rbytes); err != nil {
 			return nil, err
 		}
 
@@ -669,14 +788,194 @@ func getRewards(ethAPI blockchainAPI, hash common.Hash) (*big.Int, error) {
 	return result, nil
 }
 
+// Call the `StakeManager.getCommissions` method.
+func (s *systemContracts) getCommissions(ctx context.Context, ethAPI blockchainAPI, hash common.Hash, validator common.Address, epochs uint64) (*big.Int, error) {
+	return s.callStakeManagerUint256(ctx, ethAPI, hash, "getCommissions", validator, new(big.Int).SetUint64(epochs))
+}
+
+// Call the `StakeManager.getRewards` method.
+func (s *systemContracts) getStakerRewards(ctx context.Context, ethAPI blockchainAPI, hash common.Hash, staker, validator common.Address, epochs uint64) (*big.Int, error) {
+	return s.callStakeManagerUint256(ctx, ethAPI, hash, "getRewards", staker, validator, new(big.Int).SetUint64(epochs))
+}
+
+func (s *systemContracts) callStakeManagerUint256(ctx context.Context, ethAPI blockchainAPI, hash common.Hash, method string, args ...interface{}) (*big.Int, error) {
+	data, err := s.stakeManager.abi.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	hexData := (hexutil.Bytes)(data)
+	blockNrOrHash := rpc.BlockNumberOrHashWithHash(hash, false)
+	rbytes, err := ethAPI.Call(
+		ctx,
+		ethapi.TransactionArgs{
+			To:   &s.stakeManager.address,
+			Data: &hexData,
+		},
+		&blockNrOrHash,
+		nil,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var recv *big.Int
+	if err := s.stakeManager.abi.UnpackIntoInterface(&recv, method, rbytes); err != nil {
+		return nil, err
+	}
+	return recv, nil
+}
+
+// ValidatorStatus is the status of a validator in an epoch.
+type ValidatorStatus struct {
+	Operator  common.Address
+	Active    bool
+	Jailed    bool
+	Candidate bool
+	Stakes    *big.Int
+}
+
+// Call the `StakeManager.getValidatorInfo` method.
+func (s *systemContracts) getValidatorInfo(ethAPI blockchainAPI, hash common.Hash, owner common.Address, epoch uint64) (*ValidatorStatus, error) {
+	method := "getValidatorInfo"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	data, err := s.stakeManager.abi.Pack(method, owner, new(big.Int).SetUint64(epoch))
+	if err != nil {
+		return nil, err
+	}
+
+	hexData := (hexutil.Bytes)(data)
+	blockNrOrHash := rpc.BlockNumberOrHashWithHash(hash, false)
+	rbytes, err := ethAPI.Call(
+		ctx,
+		ethapi.TransactionArgs{
+			To:   &s.stakeManager.address,
+			Data: &hexData,
+		},
+		&blockNrOrHash,
+		nil,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The returned values differ between the contract versions,
+	// so pick the required ones by name.
+	values, err := s.stakeManager.abi.Unpack(method, rbytes)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		result  ValidatorStatus
+		outputs = s.stakeManager.abi.Methods[method].Outputs
+		ok      = true
+	)
+	for i, output := range outputs {
+		switch output.Name {
+		case "operator":
+			result.Operator, ok = values[i].(common.Address)
+		case "active":
+			result.Active, ok = values[i].(bool)
+		case "jailed":
+			result.Jailed, ok = values[i].(bool)
+		case "candidate":
+			result.Candidate, ok = values[i].(bool)
+		case "stakes":
+			result.Stakes, ok = values[i].(*big.Int)
+		}
+		if !ok {
+			return nil, fmt.Errorf("unexpected type of %s: %T", output.Name, values[i])
+		}
+	}
+	return &result, nil
+}
+
+// Call the `StakeManager.operatorToOwner` method.
+func (s *systemContracts) getOperatorOwner(ethAPI blockchainAPI, hash common.Hash, operator common.Address) (common.Address, error) {
+	method := "operatorToOwner"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	data, err := s.stakeManager.abi.Pack(method, operator)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	hexData := (hexutil.Bytes)(data)
+	blockNrOrHash := rpc.BlockNumberOrHashWithHash(hash, false)
+	rbytes, err := ethAPI.Call(
+		ctx,
+		ethapi.TransactionArgs{
+			To:   &s.stakeManager.address,
+			Data: &hexData,
+		},
+		&blockNrOrHash,
+		nil,
+		nil,
+	)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	var owner common.Address
+	if err := s.stakeManager.abi.UnpackIntoInterface(&owner, method, rbytes); err != nil {
+		return common.Address{}, err
+	}
+	return owner, nil
+}
+
+// PackActivateValidator returns the address of the StakeManager contract of the
+// network and the call data of the `StakeManager.activateValidator` method,
+// which is sent by the owner or the operator of the validator.
+func (c *Oasys) PackActivateValidator(owner common.Address, epochs []uint64) (common.Address, []byte, error) {
+	args := make([]*big.Int, len(epochs))
+	for i, epoch := range epochs {
+		args[i] = new(big.Int).SetUint64(epoch)
+	}
+	data, err := c.contracts.stakeManager.abi.Pack("activateValidator", owner, args)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return c.contracts.stakeManager.address, data, nil
+}
+
+// PackStakeManager returns the default address of the StakeManager contract,
+// which GenesisAlloc deploys, and the call data of the given method.
+func PackStakeManager(method string, args ...interface{}) (common.Address, []byte, error) {
+	data, err := stakeManager.abi.Pack(method, args...)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return stakeManager.address, data, nil
+}
+
+// GenesisAlloc returns the genesis accounts of the system contracts, which are
+// initialized by the engine in the first block.
+func GenesisAlloc() types.GenesisAlloc {
+	alloc := make(types.GenesisAlloc)
+	for _, contract := range []*genesisContract{environment, stakeManager} {
+		alloc[contract.address] = types.Account{
+			Code:    common.FromHex(contract.artifact.DeployedBytecode),
+			Balance: new(big.Int),
+		}
+	}
+	return alloc
+}
+
 // Call the `Environment.nextValue` method.
-func getNextEnvironmentValue(ethAPI blockchainAPI, hash common.Hash) (*params.EnvironmentValue, error) {
+func (s *systemContracts) getNextEnvironmentValue(ethAPI blockchainAPI, hash common.Hash) (*params.EnvironmentValue, error) {
 	method := "nextValue"
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	data, err := environment.abi.Pack(method)
+	data, err := s.environment.abi.Pack(method)
 	if err != nil {
 		return nil, err
 	}
@@ -686,7 +985,7 @@ func getNextEnvironmentValue(ethAPI blockchainAPI, hash common.Hash) (*params.En
 	rbytes, err := ethAPI.Call(
 		ctx,
 		ethapi.TransactionArgs{
-			To:   &environment.address,
+			To:   &s.environment.address,
 			Data: &hexData,
 		},
 		&blockNrOrHash,
@@ -698,7 +997,7 @@ func getNextEnvironmentValue(ethAPI blockchainAPI, hash common.Hash) (*params.En
 	}
 
 	var recv struct{ Result params.EnvironmentValue }
-	if err := environment.abi.UnpackIntoInterface(&recv, method, rbytes); err != nil {
+	if err := s.environment.abi.UnpackIntoInterface(&recv, method, rbytes); err != nil {
 		return nil, err
 	}
 
@@ -720,10 +1019,10 @@ func (c *Oasys) applyTransaction(
 	expectedTx := types.NewTransaction(nonce, *msg.To(), msg.Value(), msg.Gas(), msg.GasPrice(), msg.Data())
 	expectedHash := c.txSigner.Hash(expectedTx)
 
-	if msg.From() == c.signer && mining {
-		expectedTx, err = c.txSignFn(accounts.Account{Address: msg.From()}, expectedTx, c.chainConfig.ChainID)
+	if signer := c.currentSigner().lookup(msg.From()); signer != nil && mining {
+		expectedTx, err = signer.txSignFn(accounts.Account{Address: msg.From()}, expectedTx, c.chainConfig.ChainID)
 		if err != nil {
-			return err
+			return fmt.Errorf("%w: %v", errSignSystemTx, err)
 		}
 	} else {
 		if systemTxs == nil || len(*systemTxs) == 0 || (*systemTxs)[0] == nil {
@@ -746,7 +1045,7 @@ func (c *Oasys) applyTransaction(
 	state.SetTxContext(expectedTx.Hash(), len(*txs))
 	gasUsed, err := applyMessage(msg, state, header, c.chainConfig, cx)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %v, tx: %s", errExecuteSystemTx, err, expectedTx.Hash())
 	}
 	*txs = append(*txs, expectedTx)
 	var root []byte
@@ -767,6 +1066,9 @@ func (c *Oasys) applyTransaction(
 	receipt.TransactionIndex = uint(state.TxIndex())
 	*receipts = append(*receipts, receipt)
 	state.SetNonce(msg.From(), nonce+1)
+	if hooks := c.hooks.Load(); hooks != nil && hooks.OnSystemTx != nil {
+		hooks.OnSystemTx(header, expectedTx)
+	}
 	return nil
 }
 
@@ -19,8 +19,10 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/tests"
 )
@@ -90,8 +92,63 @@ func TestInitializeSystemContracts(t *testing.T) {
 			t.Errorf("receipt.BlockNumber, got %v, want 50", receipt.BlockNumber)
 		}
 	}
-	if env.statedb.GetNonce(env.engine.signer) != 2 {
-		t.Errorf("account nonce value, got %v, want 2", env.statedb.GetNonce(env.engine.signer))
+	if env.statedb.GetNonce(env.engine.currentSigner().signer) != 2 {
+		t.Errorf("account nonce value, got %v, want 2", env.statedb.GetNonce(env.engine.currentSigner().signer))
+	}
+}
+
+func TestVerifyCode(t *testing.T) {
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	contract := &genesisContract{
+		address:  common.HexToAddress("0x1234"),
+		artifact: &artifact{DeployedBytecode: "0x6001"},
+	}
+
+	statedb.SetCode(contract.address, common.FromHex("0x6001"))
+	if !contract.verifyCode(statedb) {
+		t.Error("the code of the artifact is rejected")
+	}
+
+	patched := common.FromHex("0x6002")
+	statedb.SetCode(contract.address, patched)
+	if contract.verifyCode(statedb) {
+		t.Error("the patched code is accepted without the code hash")
+	}
+	contract.codeHash = crypto.Keccak256Hash(patched)
+	if !contract.verifyCode(statedb) {
+		t.Error("the patched code is rejected with the code hash")
+	}
+}
+
+func TestSystemContracts(t *testing.T) {
+	var (
+		configured = newSystemContracts(&params.OasysContracts{
+			Environment:               common.HexToAddress("0x01"),
+			StakeManager:              common.HexToAddress("0x02"),
+			AllowList:                 common.HexToAddress("0x03"),
+			CandidateValidatorManager: common.HexToAddress("0x04"),
+			StakeManagerCodeHash:      common.HexToHash("0x05"),
+		})
+		defaults = newSystemContracts(nil)
+	)
+	if configured.stakeManager.address != common.HexToAddress("0x02") || configured.stakeManager.codeHash != common.HexToHash("0x05") {
+		t.Errorf("stake manager, got %v %v", configured.stakeManager.address, configured.stakeManager.codeHash)
+	}
+	if configured.candidateManager2.address != common.HexToAddress("0x04") {
+		t.Errorf("candidate manager, got %v", configured.candidateManager2.address)
+	}
+	if _, ok := configured.systemMethods[configured.environment]; !ok {
+		t.Error("the system methods of the configured environment are missing")
+	}
+	// The contracts of the other engines keep the defaults
+	if defaults.environment.address != common.HexToAddress(environmentAddress) {
+		t.Errorf("environment, got %v, want %v", defaults.environment.address, environmentAddress)
+	}
+	if defaults.stakeManager.address != common.HexToAddress(stakeManagerAddress) || defaults.stakeManager.codeHash != (common.Hash{}) {
+		t.Errorf("stake manager, got %v %v", defaults.stakeManager.address, defaults.stakeManager.codeHash)
+	}
+	if defaults.allowList != common.HexToAddress(allowListAddress) {
+		t.Errorf("allow list, got %v, want %v", defaults.allowList, allowListAddress)
 	}
 }
 
@@ -152,8 +209,34 @@ func TestSlash(t *testing.T) {
 	if receipt.BlockNumber.Uint64() != 50 {
 		t.Errorf("receipt.BlockNumber, got %v, want 50", receipt.BlockNumber)
 	}
-	if env.statedb.GetNonce(env.engine.signer) != 1 {
-		t.Errorf("account nonce value, got %v, want 1", env.statedb.GetNonce(env.engine.signer))
+	if env.statedb.GetNonce(env.engine.currentSigner().signer) != 1 {
+		t.Errorf("account nonce value, got %v, want 1", env.statedb.GetNonce(env.engine.currentSigner().signer))
+	}
+}
+
+func TestSlashMessage(t *testing.T) {
+	var (
+		validator = common.HexToAddress("0x01")
+		other     = common.HexToAddress("0x02")
+		header    = &types.Header{Number: big.NewInt(50), Coinbase: common.HexToAddress("0x03")}
+		schedules = []*common.Address{&validator, &other, &validator}
+	)
+	msg, blocks, err := newSystemContracts(nil).slashMessage(validator, schedules, header)
+	if err != nil {
+		t.Fatalf("failed to create slash message: %v", err)
+	}
+	if blocks != 2 {
+		t.Errorf("blocks, got %v, want 2", blocks)
+	}
+	if msg.From() != header.Coinbase {
+		t.Errorf("from, got %v, want %v", msg.From(), header.Coinbase)
+	}
+	if *msg.To() != stakeManager.address {
+		t.Errorf("to, got %v, want %v", *msg.To(), stakeManager.address)
+	}
+	want, _ := stakeManager.abi.Pack("slash", validator, big.NewInt(2))
+	if !reflect.DeepEqual(msg.Data(), want) {
+		t.Errorf("data, got %x, want %x", msg.Data(), want)
 	}
 }
 
@@ -268,7 +351,7 @@ func TestGetNextValidators(t *testing.T) {
 	ethapi := &testBlockchainAPI{rbytes: rbytes}
 
 	for _, block := range []uint64{1, 10} {
-		got, err := getNextValidators(config, ethapi, common.Hash{}, 1, block)
+		got, err := newSystemContracts(nil).getNextValidators(config, ethapi, common.Hash{}, 1, block)
 		if err != nil {
 			t.Fatalf("failed to call getNextValidators method: %v", err)
 		}
@@ -320,7 +403,7 @@ func TestGetRewards(t *testing.T) {
 	rbytes[1] = rbyte
 
 	ethapi := &testBlockchainAPI{rbytes: map[common.Address][][]byte{stakeManager.address: rbytes}}
-	got, _ := getRewards(ethapi, common.Hash{})
+	got, _ := newSystemContracts(nil).getRewards(ethapi, common.Hash{})
 	if got.Cmp(want) != 0 {
 		t.Errorf("got %v, want: %v", got, want)
 	}
@@ -365,7 +448,7 @@ func TestGetNextEnvironmentValue(t *testing.T) {
 	)
 
 	ethapi := &testBlockchainAPI{rbytes: map[common.Address][][]byte{environment.address: {rbyte}}}
-	got, _ := getNextEnvironmentValue(ethapi, common.Hash{})
+	got, _ := newSystemContracts(nil).getNextEnvironmentValue(ethapi, common.Hash{})
 
 	if got.StartBlock.Cmp(want.StartBlock) != 0 {
 		t.Errorf("StartBlock, got %v, want: %v", got.StartBlock, want.StartBlock)
@@ -445,8 +528,170 @@ func TestSortByOwner(t *testing.T) {
 	}
 }
 
+func TestCapValidators(t *testing.T) {
+	var validators nextValidators
+	for i, stake := range []int64{30, 10, 20, 20, 40} {
+		validators.Owners = append(validators.Owners, common.BigToAddress(big.NewInt(int64(i+1))))
+		validators.Operators = append(validators.Operators, common.BigToAddress(big.NewInt(int64(i+101))))
+		validators.Stakes = append(validators.Stakes, big.NewInt(stake))
+		validators.VoteAddresses = append(validators.VoteAddresses, types.BLSPublicKey{byte(i + 1)})
+	}
+	owners := func(v *nextValidators) (ids []int64) {
+		for _, owner := range v.Owners {
+			ids = append(ids, owner.Big().Int64())
+		}
+		return ids
+	}
+	tests := []struct {
+		max        uint64
+		kept, cuts []int64
+	}{
+		{0, []int64{1, 2, 3, 4, 5}, nil},
+		{5, []int64{1, 2, 3, 4, 5}, nil},
+		{4, []int64{1, 3, 4, 5}, []int64{2}},
+		// The tie of the owners 3 and 4 is broken by the owner address
+		{3, []int64{1, 3, 5}, []int64{4, 2}},
+		{1, []int64{5}, []int64{1, 3, 4, 2}},
+	}
+	for i, tt := range tests {
+		kept, cut := capValidators(validators.Copy(), tt.max)
+		if have := owners(kept); !reflect.DeepEqual(have, tt.kept) {
+			t.Errorf("test %d: kept mismatch, have %v, want %v", i, have, tt.kept)
+		}
+		if have := owners(cut); !reflect.DeepEqual(have, tt.cuts) {
+			t.Errorf("test %d: cut mismatch, have %v, want %v", i, have, tt.cuts)
+		}
+		for j, owner := range kept.Owners {
+			k := owner.Big().Int64() - 1
+			if kept.Operators[j] != validators.Operators[k] || kept.Stakes[j].Cmp(validators.Stakes[k]) != 0 || kept.VoteAddresses[j] != validators.VoteAddresses[k] {
+				t.Errorf("test %d: validator %d is not kept as a whole", i, k+1)
+			}
+		}
+	}
+}
+
 var _ blockchainAPI = (*testBlockchainAPI)(nil)
 
+func TestGetCommissionsAndStakerRewards(t *testing.T) {
+	uint256Ty, _ := abi.NewType("uint256", "", nil)
+	arguments := abi.Arguments{{Type: uint256Ty}}
+	commissions, _ := arguments.Pack(big.NewInt(100))
+	rewards, _ := arguments.Pack(big.NewInt(200))
+
+	ethapi := &testBlockchainAPI{rbytes: map[common.Address][][]byte{stakeManager.address: {commissions, rewards}}}
+	validator, staker := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+
+	if got, err := newSystemContracts(nil).getCommissions(context.Background(), ethapi, common.Hash{}, validator, 0); err != nil {
+		t.Fatalf("failed to get commissions: %v", err)
+	} else if got.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("commissions, got %v, want: %v", got, 100)
+	}
+	if got, err := newSystemContracts(nil).getStakerRewards(context.Background(), ethapi, common.Hash{}, staker, validator, 0); err != nil {
+		t.Fatalf("failed to get rewards: %v", err)
+	} else if got.Cmp(big.NewInt(200)) != 0 {
+		t.Errorf("rewards, got %v, want: %v", got, 200)
+	}
+}
+
+func TestEstimateClaimable(t *testing.T) {
+	// Claimable 1 per epoch, recording the epochs of each call
+	var calls []uint64
+	claimable := func(ctx context.Context, epochs uint64) (*big.Int, error) {
+		calls = append(calls, epochs)
+		return new(big.Int).SetUint64(epochs), nil
+	}
+	tests := []struct {
+		epochs, current uint64
+		calls           []uint64
+		chunks          []int64
+		total           int64
+	}{
+		{0, 0, nil, []int64{}, 0},
+		{0, 50, []uint64{50}, []int64{50}, 50},
+		{0, 250, []uint64{100, 200, 250}, []int64{100, 100, 50}, 250},
+		{150, 250, []uint64{100, 150}, []int64{100, 50}, 150},
+		{300, 200, []uint64{100, 200}, []int64{100, 100}, 200},
+	}
+	for i, tt := range tests {
+		calls = nil
+		result, err := estimateClaimable(context.Background(), tt.epochs, tt.current, claimable)
+		if err != nil {
+			t.Fatalf("test %d: failed to estimate: %v", i, err)
+		}
+		if !reflect.DeepEqual(calls, tt.calls) {
+			t.Errorf("test %d: calls mismatch, got %v, want %v", i, calls, tt.calls)
+		}
+		chunks := make([]int64, 0)
+		for _, chunk := range result.Chunks {
+			chunks = append(chunks, chunk.ToInt().Int64())
+		}
+		if !reflect.DeepEqual(chunks, tt.chunks) {
+			t.Errorf("test %d: chunks mismatch, got %v, want %v", i, chunks, tt.chunks)
+		}
+		if result.Total.ToInt().Int64() != tt.total {
+			t.Errorf("test %d: total mismatch, got %v, want %v", i, result.Total, tt.total)
+		}
+	}
+
+	// Stops at the cancellation between the chunks
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = nil
+	_, err := estimateClaimable(ctx, 0, 1000, func(ctx context.Context, epochs uint64) (*big.Int, error) {
+		cancel()
+		return claimable(ctx, epochs)
+	})
+	if err != context.Canceled || len(calls) != 1 {
+		t.Errorf("cancellation mismatch, err %v, calls %v", err, calls)
+	}
+}
+
+func TestGetValidatorInfo(t *testing.T) {
+	want := &ValidatorStatus{
+		Operator:  common.HexToAddress("0x01"),
+		Active:    false,
+		Jailed:    true,
+		Candidate: true,
+		Stakes:    big.NewInt(1),
+	}
+
+	addressTy, _ := abi.NewType("address", "", nil)
+	boolTy, _ := abi.NewType("bool", "", nil)
+	uint256Ty, _ := abi.NewType("uint256", "", nil)
+	arguments := abi.Arguments{
+		{Type: addressTy}, // operator
+		{Type: boolTy},    // active
+		{Type: boolTy},    // jailed
+		{Type: boolTy},    // candidate
+		{Type: uint256Ty}, // stakes
+	}
+	rbyte, _ := arguments.Pack(want.Operator, want.Active, want.Jailed, want.Candidate, want.Stakes)
+
+	ethapi := &testBlockchainAPI{rbytes: map[common.Address][][]byte{stakeManager.address: {rbyte}}}
+	got, err := newSystemContracts(nil).getValidatorInfo(ethapi, common.Hash{}, common.HexToAddress("0x02"), 10)
+	if err != nil {
+		t.Fatalf("failed to get validator info: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want: %v", got, want)
+	}
+}
+
+func TestGetOperatorOwner(t *testing.T) {
+	want := common.HexToAddress("0x02")
+
+	addressTy, _ := abi.NewType("address", "", nil)
+	rbyte, _ := abi.Arguments{{Type: addressTy}}.Pack(want)
+
+	ethapi := &testBlockchainAPI{rbytes: map[common.Address][][]byte{stakeManager.address: {rbyte}}}
+	got, err := newSystemContracts(nil).getOperatorOwner(ethapi, common.Hash{}, common.HexToAddress("0x01"))
+	if err != nil {
+		t.Fatalf("failed to get owner: %v", err)
+	}
+	if got != want {
+		t.Errorf("got %v, want: %v", got, want)
+	}
+}
+
 type testBlockchainAPI struct {
 	rbytes map[common.Address][][]byte
 	count  map[common.Address]int
@@ -519,8 +764,9 @@ func makeEnv(wallet accounts.Wallet, account accounts.Account) (*testEnv, error)
 			BerlinBlock:         common.Big0,
 			LondonBlock:         common.Big0,
 			Oasys: &params.OasysConfig{
-				Period: 0,
-				Epoch:  100,
+				Period:      1,
+				Epoch:       100,
+				Environment: &params.OasysEnvironment{JailThreshold: big.NewInt(100)},
 			},
 		}
 		genspec = &core.Genesis{
@@ -609,3 +855,256 @@ func makeEnv(wallet accounts.Wallet, account accounts.Account) (*testEnv, error)
 
 	return &testEnv{engine, chain, stateTestState.StateDB}, nil
 }
+
+// testHeaderChain is an in-memory canonical chain of headers, which is enough
+// for the engine to take the snapshots and to verify the attestations.
+type testHeaderChain struct {
+	config  *params.ChainConfig
+	headers []*types.Header // Indexed by the block number
+	numbers map[common.Hash]uint64
+}
+
+func newTestHeaderChain(config *params.ChainConfig, genesis *types.Header) *testHeaderChain {
+	chain := &testHeaderChain{config: config, numbers: make(map[common.Hash]uint64)}
+	chain.insert(genesis)
+	return chain
+}
+
+// insert appends the header to the head of the chain.
+func (c *testHeaderChain) insert(header *types.Header) {
+	c.headers = append(c.headers, header)
+	c.numbers[header.Hash()] = header.Number.Uint64()
+}
+
+func (c *testHeaderChain) Config() *params.ChainConfig  { return c.config }
+func (c *testHeaderChain) CurrentHeader() *types.Header { return c.headers[len(c.headers)-1] }
+func (c *testHeaderChain) ChasingHead() *types.Header   { return nil }
+
+func (c *testHeaderChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := c.GetHeaderByNumber(number); header != nil && header.Hash() == hash {
+		return header
+	}
+	return nil
+}
+
+func (c *testHeaderChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(c.headers)) {
+		return nil
+	}
+	return c.headers[number]
+}
+
+func (c *testHeaderChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	if number, ok := c.numbers[hash]; ok {
+		return c.headers[number]
+	}
+	return nil
+}
+
+func (c *testHeaderChain) GetTd(hash common.Hash, number uint64) *big.Int {
+	if c.GetHeader(hash, number) == nil {
+		return nil
+	}
+	return new(big.Int).SetUint64(number + 1)
+}
+
+func (c *testHeaderChain) GetCanonicalHash(number uint64) common.Hash {
+	if header := c.GetHeaderByNumber(number); header != nil {
+		return header.Hash()
+	}
+	return common.Hash{}
+}
+
+func (c *testHeaderChain) GetVerifiedBlockByHash(hash common.Hash) *types.Header {
+	return c.GetHeaderByHash(hash)
+}
+
+// testVotePool is a vote pool keyed by the hash of the voted block.
+type testVotePool map[common.Hash][]*types.VoteEnvelope
+
+func (p testVotePool) FetchVoteByBlockHash(hash common.Hash) []*types.VoteEnvelope {
+	return p[hash]
+}
+
+// testVoteEnv is a chain of blocks sealed by multiple in-memory validators,
+// which vote for the blocks with their BLS keys.
+type testVoteEnv struct {
+	engine     *Oasys
+	chain      *testHeaderChain
+	genesis    *Snapshot
+	pool       testVotePool
+	validators []*simValidator
+}
+
+func makeVoteEnv(count int) (*testVoteEnv, error) {
+	var (
+		config      = &params.OasysConfig{Period: 15, Epoch: 5760}
+		chainConfig = &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: config}
+		operators   = make([]common.Address, count)
+	)
+	validators, err := newSimValidators(count)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range validators {
+		operators[i] = v.address
+	}
+
+	genesis := &types.Header{
+		UncleHash:  types.EmptyUncleHash,
+		Number:     common.Big0,
+		GasLimit:   params.GenesisGasLimit,
+		Difficulty: common.Big1,
+		Extra:      make([]byte, extraVanity+count*common.AddressLength+extraSeal),
+	}
+	for i, operator := range operators {
+		copy(genesis.Extra[extraVanity+i*common.AddressLength:], operator[:])
+	}
+
+	// The genesis snapshot would be taken from the extra data without the stakes
+	// and the vote addresses, so store the complete one as the checkpoint.
+	engine := New(chainConfig, config, rawdb.NewMemoryDatabase(), nil)
+	snap := newSnapshot(chainConfig, engine.signatures, nil, 0, genesis.Hash(), operators, params.InitialEnvironmentValue(config))
+	for _, v := range validators {
+		snap.Validators[v.address].Stake = newEth(10_000_000)
+		snap.Validators[v.address].VoteAddress = v.voteAddress
+	}
+	if err := snap.store(engine.db); err != nil {
+		return nil, err
+	}
+
+	return &testVoteEnv{
+		engine:     engine,
+		chain:      newTestHeaderChain(chainConfig, genesis),
+		genesis:    snap,
+		pool:       make(testVotePool),
+		validators: validators,
+	}, nil
+}
+
+// vote casts the votes of the given validators for the head, whose source is
+// the highest justified block.
+func (e *testVoteEnv) vote(voters ...*simValidator) error {
+	head := e.chain.CurrentHeader()
+	number, hash, err := e.engine.GetJustifiedNumberAndHash(e.chain, []*types.Header{head})
+	if err != nil {
+		return err
+	}
+	data := &types.VoteData{
+		SourceNumber: number,
+		SourceHash:   hash,
+		TargetNumber: head.Number.Uint64(),
+		TargetHash:   head.Hash(),
+	}
+	for _, v := range voters {
+		e.pool[head.Hash()] = append(e.pool[head.Hash()], v.vote(data))
+	}
+	return nil
+}
+
+// newBlock builds the next block on top of the head, assembling the attestation
+// of the votes for the head, and seals it by the in-turn validator.
+func (e *testVoteEnv) newBlock() (*types.Header, error) {
+	return newSimBlock(e.engine, e.chain, e.pool, e.chain.CurrentHeader(), e.validators)
+}
+
+// seal signs the header by the validator of the coinbase.
+func (e *testVoteEnv) seal(header *types.Header) error {
+	for _, v := range e.validators {
+		if v.address == header.Coinbase {
+			return v.seal(header)
+		}
+	}
+	return errUnauthorizedValidator
+}
+
+// verify verifies the attestation of the header against the head.
+func (e *testVoteEnv) verify(header *types.Header) error {
+	env := params.InitialEnvironmentValue(e.engine.config)
+	return e.engine.verifyVoteAttestation(e.chain, header, nil, env, nil)
+}
+
+func TestVoteAttestation(t *testing.T) {
+	env, err := makeVoteEnv(4)
+	if err != nil {
+		t.Fatalf("failed to create test vote env: %v", err)
+	}
+	vals := env.validators
+
+	// produce verifies and appends the next block after the votes of the given
+	// number of validators, and checks the justified and finalized blocks.
+	produce := func(voters int, attested bool, justified, finalized uint64) {
+		t.Helper()
+
+		number := env.chain.CurrentHeader().Number.Uint64() + 1
+		if err := env.vote(vals[:voters]...); err != nil {
+			t.Fatalf("block %d: failed to vote: %v", number, err)
+		}
+		header, err := env.newBlock()
+		if err != nil {
+			t.Fatalf("block %d: failed to build: %v", number, err)
+		}
+		if got := env.engine.DecodeVoteAttestation(header) != nil; got != attested {
+			t.Fatalf("block %d: attested mismatch, got: %v, want: %v", number, got, attested)
+		}
+		if err := env.verify(header); err != nil {
+			t.Fatalf("block %d: failed to verify attestation: %v", number, err)
+		}
+		env.chain.insert(header)
+
+		gotJustified, _, err := env.engine.GetJustifiedNumberAndHash(env.chain, []*types.Header{header})
+		if err != nil {
+			t.Fatalf("block %d: failed to get justified block: %v", number, err)
+		}
+		if gotJustified != justified {
+			t.Errorf("block %d: justified mismatch, got: %d, want: %d", number, gotJustified, justified)
+		}
+		if got := env.engine.GetFinalizedHeader(env.chain, header); got == nil || got.Number.Uint64() != finalized {
+			t.Errorf("block %d: finalized mismatch, got: %v, want: %d", number, got, finalized)
+		}
+	}
+
+	produce(4, false, 0, 0) // fast finality disabled
+	produce(3, true, 1, 0)
+	produce(4, true, 2, 1)
+	produce(2, false, 2, 1) // less than 2/3 voting power
+	produce(3, true, 4, 1)  // non-consecutive, only justified
+	produce(3, true, 5, 4)
+
+	// The tampered attestations are rejected
+	if err := env.vote(vals[:3]...); err != nil {
+		t.Fatalf("failed to vote: %v", err)
+	}
+	tests := []struct {
+		name   string
+		tamper func(*types.VoteAttestation)
+	}{
+		{"extra voter", func(a *types.VoteAttestation) { a.SetVoter(4) }},
+		{"missing voter", func(a *types.VoteAttestation) { a.VoteAddressSet &^= 1 << 1 }},
+		{"wrong source", func(a *types.VoteAttestation) { a.Data.SourceNumber-- }},
+		{"wrong target", func(a *types.VoteAttestation) { a.Data.TargetHash = common.Hash{0x1} }},
+		{"wrong signature", func(a *types.VoteAttestation) { a.AggSignature[0] ^= 0xff }},
+	}
+	for _, tt := range tests {
+		header, err := env.newBlock()
+		if err != nil {
+			t.Fatalf("%s: failed to build: %v", tt.name, err)
+		}
+		if err := env.verify(header); err != nil {
+			t.Fatalf("%s: failed to verify untampered attestation: %v", tt.name, err)
+		}
+		attestation := env.engine.DecodeVoteAttestation(header)
+		tt.tamper(attestation)
+		buf, err := rlp.EncodeToBytes(attestation)
+		if err != nil {
+			t.Fatalf("%s: failed to encode: %v", tt.name, err)
+		}
+		header.Extra = append(append(header.Extra[:extraVanity:extraVanity], buf...), make([]byte, extraSeal)...)
+		if err := env.seal(header); err != nil {
+			t.Fatalf("%s: failed to seal: %v", tt.name, err)
+		}
+		if err := env.verify(header); err == nil {
+			t.Errorf("%s: tampered attestation accepted", tt.name)
+		}
+	}
+}
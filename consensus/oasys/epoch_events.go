@@ -0,0 +1,242 @@
+package oasys
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Types of the epoch lifecycle events.
+const (
+	EpochStarted               = "epochStarted"
+	ValidatorJoined            = "validatorJoined"
+	ValidatorLeft              = "validatorLeft"
+	EnvironmentUpdateScheduled = "environmentUpdateScheduled"
+	FinalityStalled            = "finalityStalled"
+	FinalityRecovered          = "finalityRecovered"
+)
+
+const (
+	// finalityStallBlocks is the number of blocks without a newer justified
+	// block after which the finality is reported as stalled.
+	finalityStallBlocks = 20
+
+	// maxEpochEventBlocks is the maximum number of blocks inspected for the
+	// events at a single chain head, older ones are skipped on a large jump.
+	maxEpochEventBlocks = 1024
+)
+
+// EpochEvent is a lifecycle event of the epochs and the validators on the
+// canonical chain. The fields set depend on the type of the event.
+type EpochEvent struct {
+	Type   string      `json:"type"`
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+	Epoch  uint64      `json:"epoch"`
+
+	Environment     *params.EnvironmentValue `json:"environment,omitempty"`     // epochStarted, environmentUpdateScheduled
+	Validators      []common.Address         `json:"validators,omitempty"`      // epochStarted
+	Validator       *common.Address          `json:"validator,omitempty"`       // validatorJoined, validatorLeft
+	JustifiedNumber *uint64                  `json:"justifiedNumber,omitempty"` // finalityStalled, finalityRecovered
+}
+
+// epochEvents tracks the canonical chain to derive the epoch lifecycle events
+// from the consecutive chain heads.
+type epochEvents struct {
+	feed  event.Feed
+	scope event.SubscriptionScope
+
+	lock       sync.Mutex
+	number     uint64                   // Last block inspected, zero if not tracking
+	validators []common.Address         // Validators of the epoch at the last block
+	nextEnv    *params.EnvironmentValue // Environment value scheduled at the last block
+	stalled    bool                     // Whether the finality is reported as stalled
+}
+
+// SubscribeEpochEvents subscribes to the epoch lifecycle events of the canonical
+// chain, which are derived by EmitEpochEvents.
+func (c *Oasys) SubscribeEpochEvents(ch chan<- *EpochEvent) event.Subscription {
+	return c.epochEvents.scope.Track(c.epochEvents.feed.Subscribe(ch))
+}
+
+// EmitEpochEvents inspects the blocks up to the new chain head and sends the
+// epoch lifecycle events to the subscribers. The events are only derived while
+// there are subscribers, the tracking restarts from the head otherwise.
+func (c *Oasys) EmitEpochEvents(chain consensus.ChainHeaderReader, head *types.Header) {
+	e := c.epochEvents
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.scope.Count() == 0 {
+		e.number, e.validators, e.nextEnv, e.stalled = 0, nil, nil, false
+		return
+	}
+	number := head.Number.Uint64()
+	from := e.number + 1
+	if e.number == 0 || from > number || number-from >= maxEpochEventBlocks {
+		// Start tracking, or restart on a reorg or a large jump of the head.
+		from = number
+	}
+
+	var events []*EpochEvent
+	for n := from; n <= number; n++ {
+		header := head
+		if n != number {
+			if header = chain.GetHeaderByNumber(n); header == nil {
+				break
+			}
+		}
+		snap, err := c.snapshot(chain, n, header.Hash(), nil)
+		if err != nil {
+			log.Debug("Failed to retrieve snapshot for epoch events", "number", n, "err", err)
+			return
+		}
+		validators := snap.validators()
+		if snap.Environment.IsEpoch(n) && e.number != 0 {
+			epoch := snap.Environment.Epoch(n)
+			events = append(events, &EpochEvent{
+				Type:        EpochStarted,
+				Number:      n,
+				Hash:        header.Hash(),
+				Epoch:       epoch,
+				Environment: snap.Environment.Copy(),
+				Validators:  validators,
+			})
+			for _, validator := range validators {
+				validator := validator
+				if !slices.Contains(e.validators, validator) {
+					events = append(events, &EpochEvent{Type: ValidatorJoined, Number: n, Hash: header.Hash(), Epoch: epoch, Validator: &validator})
+				}
+			}
+			for _, validator := range e.validators {
+				validator := validator
+				if !slices.Contains(validators, validator) {
+					events = append(events, &EpochEvent{Type: ValidatorLeft, Number: n, Hash: header.Hash(), Epoch: epoch, Validator: &validator})
+				}
+			}
+		}
+		e.number, e.validators = n, validators
+
+		if n == number {
+			events = append(events, c.headEpochEvents(chain, head, snap)...)
+		}
+	}
+	for _, ev := range events {
+		e.feed.Send(ev)
+	}
+}
+
+// EpochStartedEvent returns the epochStarted event of the given epoch on the
+// canonical chain up to the given head, for the consumers catching up with the
+// events missed while they were not subscribed.
+func (c *Oasys) EpochStartedEvent(chain consensus.ChainHeaderReader, head *types.Header, epoch uint64) (*EpochEvent, error) {
+	snap, err := c.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// Walk back to the environment the epoch started with
+	env := snap.Environment
+	for env.StartEpoch.Uint64() > epoch && env.StartBlock.Uint64() > 0 {
+		header := chain.GetHeaderByNumber(env.StartBlock.Uint64() - 1)
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		if snap, err = c.snapshot(chain, header.Number.Uint64(), header.Hash(), nil); err != nil {
+			return nil, err
+		}
+		env = snap.Environment
+	}
+	if env.StartEpoch.Uint64() > epoch || env.EpochStartBlock(epoch) > head.Number.Uint64() {
+		return nil, errUnknownBlock
+	}
+	header := chain.GetHeaderByNumber(env.EpochStartBlock(epoch))
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	if snap, err = c.snapshot(chain, header.Number.Uint64(), header.Hash(), nil); err != nil {
+		return nil, err
+	}
+	return &EpochEvent{
+		Type:        EpochStarted,
+		Number:      header.Number.Uint64(),
+		Hash:        header.Hash(),
+		Epoch:       epoch,
+		Environment: snap.Environment.Copy(),
+		Validators:  snap.validators(),
+	}, nil
+}
+
+// headEpochEvents derives the events observed at the chain head rather than at
+// every block: the scheduled environment update and the finality stall.
+func (c *Oasys) headEpochEvents(chain consensus.ChainHeaderReader, head *types.Header, snap *Snapshot) []*EpochEvent {
+	var (
+		e      = c.epochEvents
+		number = head.Number.Uint64()
+		epoch  = snap.Environment.Epoch(number)
+		events []*EpochEvent
+	)
+	if next, err := c.contracts.getNextEnvironmentValue(c.ethAPI, head.Hash()); err != nil {
+		log.Debug("Failed to retrieve next environment value for epoch events", "number", number, "err", err)
+	} else if next.StartEpoch != nil && next.StartEpoch.Uint64() > epoch {
+		if e.nextEnv != nil && e.nextEnv.Equal(next) != nil {
+			events = append(events, &EpochEvent{Type: EnvironmentUpdateScheduled, Number: number, Hash: head.Hash(), Epoch: epoch, Environment: next})
+		}
+		e.nextEnv = next
+	} else {
+		e.nextEnv = snap.Environment
+	}
+
+	if c.chainConfig.IsFastFinalityEnabled(head.Number) {
+		justified, _, err := c.GetJustifiedNumberAndHash(chain, []*types.Header{head})
+		if err != nil {
+			return events
+		}
+		if stalled := number-justified >= finalityStallBlocks; stalled != e.stalled {
+			typ := FinalityStalled
+			if !stalled {
+				typ = FinalityRecovered
+			}
+			events = append(events, &EpochEvent{Type: typ, Number: number, Hash: head.Hash(), Epoch: epoch, JustifiedNumber: &justified})
+			e.stalled = stalled
+		}
+	}
+	return events
+}
+
+// EpochEvents creates a subscription to the epoch lifecycle events of the
+// canonical chain: the start of the epochs, the validators joining and leaving
+// the validator set, the environment updates scheduled, and the finality
+// stalling and recovering.
+func (api *API) EpochEvents(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan *EpochEvent, 64)
+		sub := api.oasys.SubscribeEpochEvents(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, ev)
+			case <-rpcSub.Err():
+				return
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
@@ -0,0 +1,190 @@
+package oasys
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/willf/bitset"
+)
+
+// EpochReport is the activity of the validators in an epoch, computed from the
+// blocks and receipts of the local chain.
+type EpochReport struct {
+	Epoch      uint64 `json:"epoch"`
+	FirstBlock uint64 `json:"firstBlock"`
+	LastBlock  uint64 `json:"lastBlock"` // Capped to the chain head for the ongoing epoch
+
+	CommissionRate    *big.Int `json:"commissionRate"`
+	CommissionChanged bool     `json:"commissionChanged"` // Whether the rate differs from the previous epoch
+
+	Attestations uint64             `json:"attestations"` // Number of blocks including an attestation
+	Validators   []*ValidatorReport `json:"validators"`   // Sorted by the operator address
+}
+
+// ValidatorReport is the activity of a single validator in an epoch.
+type ValidatorReport struct {
+	Operator  common.Address `json:"operator"`
+	Stake     *big.Int       `json:"stake"`
+	Scheduled uint64         `json:"scheduled"` // Blocks the validator was in-turn for
+	InTurn    uint64         `json:"inTurn"`    // Blocks sealed while in-turn
+	Sealed    uint64         `json:"sealed"`    // Blocks sealed in total
+	Slashes   uint64         `json:"slashes"`   // Slash transactions injected against the validator
+
+	AttestationsExpected uint64 `json:"attestationsExpected"`
+	AttestationsIncluded uint64 `json:"attestationsIncluded"`
+}
+
+// Uptime returns the ratio of the in-turn blocks sealed by the validator, or
+// one if the validator was never in-turn.
+func (r *ValidatorReport) Uptime() float64 {
+	if r.Scheduled == 0 {
+		return 1
+	}
+	return float64(r.InTurn) / float64(r.Scheduled)
+}
+
+// Participation returns the ratio of the attestations the vote of the validator
+// was included in, or zero if the validator was not eligible for any.
+func (r *ValidatorReport) Participation() float64 {
+	if r.AttestationsExpected == 0 {
+		return 0
+	}
+	return float64(r.AttestationsIncluded) / float64(r.AttestationsExpected)
+}
+
+// EpochReport computes the report of the given epoch from the canonical chain.
+// The slashes are counted from the slash transactions of the blocks, which refer
+// to the operators, so the report needs neither the slash index nor the state.
+func (c *Oasys) EpochReport(chain SlashIndexChain, epoch uint64) (*EpochReport, error) {
+	if epoch == 0 {
+		return nil, errors.New("unknown epoch")
+	}
+	head := chain.CurrentHeader()
+	first, err := c.epochFirstBlock(chain, head, epoch)
+	if err != nil {
+		return nil, err
+	}
+	// The genesis block opening the first epoch is sealed by no validator
+	if first == 0 {
+		first = 1
+	}
+	if first > head.Number.Uint64() {
+		return nil, errors.New("epoch not reached by the local chain")
+	}
+	header := chain.GetHeaderByNumber(first)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	snap, err := c.snapshot(chain, first, header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	last := first + snap.Environment.EpochPeriod.Uint64() - 1
+	if number := head.Number.Uint64(); last > number {
+		last = number
+	}
+
+	report := &EpochReport{
+		Epoch:          epoch,
+		FirstBlock:     first,
+		LastBlock:      last,
+		CommissionRate: new(big.Int).Set(snap.Environment.CommissionRate),
+	}
+	if prev, err := c.snapshot(chain, first-1, header.ParentHash, nil); err == nil {
+		report.CommissionChanged = prev.Environment.CommissionRate.Cmp(snap.Environment.CommissionRate) != 0
+	}
+
+	validators := snap.ToNextValidators()
+	reports := make(map[common.Address]*ValidatorReport, len(validators.Operators))
+	for i, operator := range validators.Operators {
+		reports[operator] = &ValidatorReport{Operator: operator, Stake: validators.Stakes[i]}
+	}
+	lookup := func(operator common.Address) *ValidatorReport {
+		if r, ok := reports[operator]; ok {
+			return r
+		}
+		r := &ValidatorReport{Operator: operator, Stake: new(big.Int)}
+		reports[operator] = r
+		return r
+	}
+	scheduler, err := c.scheduler(chain, header, snap.Environment, validators.Operators, validators.Stakes)
+	if err != nil {
+		return nil, err
+	}
+
+	for number := first; number <= last; number++ {
+		if number != first {
+			if header = chain.GetHeaderByNumber(number); header == nil {
+				return nil, errUnknownBlock
+			}
+		}
+		inturn := *scheduler.expect(number)
+		lookup(inturn).Scheduled++
+		sealer := lookup(header.Coinbase)
+		sealer.Sealed++
+		if header.Coinbase == inturn {
+			sealer.InTurn++
+		}
+
+		block := chain.GetBlock(header.Hash(), number)
+		if block == nil {
+			return nil, errUnknownBlock
+		}
+		for _, record := range c.contracts.findSlashRecords(block, chain.GetReceiptsByHash(header.Hash())) {
+			if record.Kind == SlashKindSlashTx {
+				lookup(record.Validator).Slashes++
+			}
+		}
+
+		attestation := c.DecodeVoteAttestation(header)
+		if attestation == nil || attestation.Data == nil || number < 2 {
+			continue
+		}
+		_, eligible, err := c.attestationValidators(chain, header)
+		if err != nil {
+			return nil, err
+		}
+		voted := bitset.From(attestation.VoteAddressWords())
+		for i, operator := range eligible.Operators {
+			r := lookup(operator)
+			r.AttestationsExpected++
+
+			// The voter index is offset by 1
+			if voted.Test(uint(i + 1)) {
+				r.AttestationsIncluded++
+			}
+		}
+		report.Attestations++
+	}
+
+	for _, r := range reports {
+		report.Validators = append(report.Validators, r)
+	}
+	sort.Slice(report.Validators, func(i, j int) bool {
+		return bytes.Compare(report.Validators[i].Operator[:], report.Validators[j].Operator[:]) < 0
+	})
+	return report, nil
+}
+
+// attestationValidators returns the validators eligible to vote in the
+// attestation of the header, which are the ones of the target block(=parent block).
+func (c *Oasys) attestationValidators(chain consensus.ChainHeaderReader, header *types.Header) (*Snapshot, *nextValidators, error) {
+	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return nil, nil, errUnknownBlock
+	}
+	snap, err := c.snapshot(chain, parent.Number.Uint64()-1, parent.ParentHash, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	validators, err := c.getNextValidators(chain, header, snap, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return snap, validators, nil
+}
@@ -0,0 +1,91 @@
+package oasys
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// testBlockChain serves the bodies of the headers without any transaction.
+type testBlockChain struct {
+	*testHeaderChain
+}
+
+func (c *testBlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	if header := c.GetHeader(hash, number); header != nil {
+		return types.NewBlockWithHeader(header)
+	}
+	return nil
+}
+
+func (c *testBlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts { return nil }
+
+func TestEpochReport(t *testing.T) {
+	env, err := makeVoteEnv(4)
+	if err != nil {
+		t.Fatalf("failed to create test vote env: %v", err)
+	}
+	if err := makeAttestedChain(env, 4, 3, 4, 2); err != nil {
+		t.Fatalf("failed to build chain: %v", err)
+	}
+	chain := &testBlockChain{env.chain}
+
+	if _, err := env.engine.EpochReport(chain, 0); err == nil {
+		t.Error("expected error for the epoch 0")
+	}
+	if _, err := env.engine.EpochReport(chain, 2); err == nil {
+		t.Error("expected error for the epoch not reached")
+	}
+
+	// The ongoing first epoch is reported from the block 1 up to the head, and
+	// the votes for the genesis and the last block lack an attestation.
+	report, err := env.engine.EpochReport(chain, 1)
+	if err != nil {
+		t.Fatalf("failed to report: %v", err)
+	}
+	if report.Epoch != 1 || report.FirstBlock != 1 || report.LastBlock != 4 {
+		t.Errorf("range mismatch: have epoch %d, blocks %d-%d", report.Epoch, report.FirstBlock, report.LastBlock)
+	}
+	if report.CommissionRate.Cmp(env.genesis.Environment.CommissionRate) != 0 || report.CommissionChanged {
+		t.Errorf("commission mismatch: have %v, changed %v", report.CommissionRate, report.CommissionChanged)
+	}
+	if report.Attestations != 2 {
+		t.Errorf("attestations mismatch: have %d, want 2", report.Attestations)
+	}
+	if len(report.Validators) != len(env.validators) {
+		t.Fatalf("validators mismatch: have %d, want %d", len(report.Validators), len(env.validators))
+	}
+
+	var scheduled, sealed uint64
+	for i, r := range report.Validators {
+		if i > 0 && bytes.Compare(report.Validators[i-1].Operator[:], r.Operator[:]) >= 0 {
+			t.Errorf("validators not sorted: %v before %v", report.Validators[i-1].Operator, r.Operator)
+		}
+		if r.Stake.Cmp(newEth(10_000_000)) != 0 {
+			t.Errorf("%v: stake mismatch: have %v", r.Operator, r.Stake)
+		}
+		// The blocks are sealed by the in-turn validators only
+		if r.InTurn != r.Scheduled || r.Sealed != r.Scheduled || r.Uptime() != 1 {
+			t.Errorf("%v: blocks mismatch: scheduled %d, in-turn %d, sealed %d", r.Operator, r.Scheduled, r.InTurn, r.Sealed)
+		}
+		if r.Slashes != 0 {
+			t.Errorf("%v: slashes mismatch: have %d", r.Operator, r.Slashes)
+		}
+		scheduled += r.Scheduled
+		sealed += r.Sealed
+
+		// The last validator is included in the attestation of the block 3 only
+		want := uint64(2)
+		if r.Operator == env.validators[3].address {
+			want = 1
+		}
+		if r.AttestationsExpected != 2 || r.AttestationsIncluded != want {
+			t.Errorf("%v: attestations mismatch: have %d/%d, want %d/2", r.Operator, r.AttestationsIncluded, r.AttestationsExpected, want)
+		}
+	}
+	if scheduled != 4 || sealed != 4 {
+		t.Errorf("blocks mismatch: scheduled %d, sealed %d, want 4", scheduled, sealed)
+	}
+}
@@ -0,0 +1,64 @@
+package oasys
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// maxEnvironmentCheckpoints is the number of the checkpoints walked back to find
+// a persisted snapshot carrying the environment value.
+const maxEnvironmentCheckpoints = 16
+
+// EpochStateRoots returns the state roots of the given number of recent epochs
+// up to the head, which the validators of the epochs are read from on a deep
+// verification. They are the roots of the last blocks of the previous epochs.
+// The epochs are resolved with the environment value of the latest persisted
+// snapshot, so no roots before the environment value took effect are returned.
+func EpochStateRoots(db ethdb.Database, config *params.ChainConfig, head *types.Header, count int) ([]common.Hash, error) {
+	if config.Oasys == nil {
+		return nil, errors.New("not an oasys chain")
+	}
+	env, err := persistedEnvironment(db, config, head.Number.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	var roots []common.Hash
+	for epoch := env.Epoch(head.Number.Uint64()); len(roots) < count && epoch >= env.StartEpoch.Uint64(); epoch-- {
+		first := env.EpochStartBlock(epoch)
+		if first == 0 {
+			break
+		}
+		hash := rawdb.ReadCanonicalHash(db, first-1)
+		header := rawdb.ReadHeader(db, hash, first-1)
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		roots = append(roots, header.Root)
+		if epoch == env.StartEpoch.Uint64() {
+			break
+		}
+	}
+	return roots, nil
+}
+
+// persistedEnvironment returns the environment value of the latest snapshot
+// persisted at or before the given block.
+func persistedEnvironment(db ethdb.Database, config *params.ChainConfig, number uint64) (*params.EnvironmentValue, error) {
+	checkpoint := number - number%checkpointInterval
+	for i := 0; i < maxEnvironmentCheckpoints; i++ {
+		if checkpoint == 0 {
+			return params.InitialEnvironmentValue(config.Oasys), nil
+		}
+		hash := rawdb.ReadCanonicalHash(db, checkpoint)
+		if snap, err := loadSnapshot(config, nil, nil, db, hash); err == nil && snap.Environment != nil {
+			return snap.Environment, nil
+		}
+		checkpoint -= checkpointInterval
+	}
+	return nil, errNoEnvironmentValue
+}
@@ -0,0 +1,47 @@
+package oasys
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestEpochStateRoots(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		config = &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: &params.OasysConfig{Period: 15, Epoch: 10}}
+		head   *types.Header
+	)
+	for i := int64(0); i <= 35; i++ {
+		head = &types.Header{Number: big.NewInt(i), Root: common.BigToHash(big.NewInt(1000 + i))}
+		rawdb.WriteHeader(db, head)
+		rawdb.WriteCanonicalHash(db, head.Hash(), head.Number.Uint64())
+	}
+	root := func(number int64) common.Hash { return common.BigToHash(big.NewInt(1000 + number)) }
+
+	for _, tt := range []struct {
+		count int
+		want  []common.Hash
+	}{
+		{0, nil},
+		{1, []common.Hash{root(29)}},
+		{2, []common.Hash{root(29), root(19)}},
+		{10, []common.Hash{root(29), root(19), root(9)}}, // Capped to the genesis epoch
+	} {
+		roots, err := EpochStateRoots(db, config, head, tt.count)
+		if err != nil {
+			t.Fatalf("count %d: failed to resolve roots: %v", tt.count, err)
+		}
+		if !reflect.DeepEqual(roots, tt.want) {
+			t.Errorf("count %d: roots mismatch: have %v, want %v", tt.count, roots, tt.want)
+		}
+	}
+	if _, err := EpochStateRoots(db, params.AllEthashProtocolChanges, head, 2); err == nil {
+		t.Error("expected an error for a non-oasys chain")
+	}
+}
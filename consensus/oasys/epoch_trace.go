@@ -0,0 +1,228 @@
+package oasys
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// epochTraceProposers is the number of the proposer slots reported by the
+// epoch transition trace.
+const epochTraceProposers = 100
+
+// Sources of the values decided in the epoch transition.
+const (
+	epochSourceInitial  = "initial"
+	epochSourceHeader   = "header"
+	epochSourceContract = "contract"
+)
+
+// DebugAPI is the RPC API to debug the proof-of-stake scheme, served under the
+// debug namespace.
+type DebugAPI struct {
+	chain consensus.ChainHeaderReader
+	oasys *Oasys
+}
+
+// EpochValidator is a validator of the epoch in the order it was retrieved.
+type EpochValidator struct {
+	Owner       common.Address     `json:"owner"`
+	Operator    common.Address     `json:"operator"`
+	Stake       *hexutil.Big       `json:"stake"`
+	VoteAddress types.BLSPublicKey `json:"voteAddress"`
+}
+
+// EpochTransitionTrace is the decision trail of the epoch transition at an
+// epoch block, covering every value the nodes must agree on.
+type EpochTransitionTrace struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+	Epoch  uint64      `json:"epoch"`
+
+	// Environment value of the epoch, the one in the header and the one of the
+	// contract are both reported if available
+	EnvironmentSource   string                   `json:"environmentSource"`
+	Environment         *params.EnvironmentValue `json:"environment"`
+	HeaderEnvironment   *params.EnvironmentValue `json:"headerEnvironment,omitempty"`
+	ContractEnvironment *params.EnvironmentValue `json:"contractEnvironment,omitempty"`
+	EnvironmentError    string                   `json:"environmentError,omitempty"`
+
+	// Validators of the epoch in the order retrieved, and the ones of the contract
+	// if they differ from the header
+	ValidatorsSource    string            `json:"validatorsSource"`
+	Validators          []*EpochValidator `json:"validators"`
+	ContractValidators  []*EpochValidator `json:"contractValidators,omitempty"`
+	ValidatorsError     string            `json:"validatorsError,omitempty"`
+	ExtraOrder          string            `json:"extraOrder"`          // Order of the validators in the extra-data
+	SchedulerValidators []common.Address  `json:"schedulerValidators"` // Order of the weighted chooser
+
+	// Extra-data between the vanity and the seal, and the one derived locally
+	HeaderExtra   hexutil.Bytes `json:"headerExtra"`
+	ExpectedExtra hexutil.Bytes `json:"expectedExtra"`
+	ExtraMatch    bool          `json:"extraMatch"`           // Whether the import accepts the extra-data against the contracts
+	ExtraError    string        `json:"extraError,omitempty"` // Why the import rejects it
+
+	SeedHash  common.Hash      `json:"seedHash"`
+	Seed      int64            `json:"seed"`
+	Proposers []common.Address `json:"proposers"` // Proposers of the first slots of the epoch
+}
+
+// TraceEpochTransition reports the decision trail of the epoch transition at the
+// given epoch block: the environment value and where it was sourced from, the
+// validators retrieved and their order, the resulting extra-data, and the seed
+// and the first proposer slots of the scheduler. Two nodes disagreeing on the
+// epoch block can diff their traces to find the diverging decision.
+func (api *DebugAPI) TraceEpochTransition(number rpc.BlockNumber) (*EpochTransitionTrace, error) {
+	var header *types.Header
+	if number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	var (
+		c      = api.oasys
+		config = c.chainConfig
+		num    = header.Number.Uint64()
+	)
+	if num == 0 {
+		return nil, fmt.Errorf("block %d is not an epoch block", num)
+	}
+	snap, err := c.snapshot(api.chain, num-1, header.ParentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !snap.Environment.IsEpoch(num) {
+		return nil, fmt.Errorf("block %d is not an epoch block", num)
+	}
+	trace := &EpochTransitionTrace{
+		Number: num,
+		Hash:   header.Hash(),
+		Epoch:  snap.Environment.Epoch(num),
+	}
+
+	// The values verified from the header, and the ones of the contracts
+	// checked against them on the import, both through the engine
+	env, err := c.environment(api.chain, header, snap, true)
+	if err != nil {
+		return nil, err
+	}
+	trace.Environment = env
+	contractEnv := env
+	switch {
+	case num < c.config.Epoch:
+		trace.EnvironmentSource = epochSourceInitial
+	case fromHeader(config, header, func() error { _, err := getEnvironmentFromHeader(header); return err }):
+		trace.EnvironmentSource, trace.HeaderEnvironment = epochSourceHeader, env
+		if contractEnv, err = c.environment(api.chain, header, snap, false); err != nil {
+			trace.EnvironmentError = err.Error()
+		} else {
+			trace.ContractEnvironment = contractEnv
+		}
+	default:
+		trace.EnvironmentSource, trace.ContractEnvironment = epochSourceContract, env
+	}
+
+	validators, err := c.getNextValidators(api.chain, header, snap, true)
+	if err != nil {
+		return nil, err
+	}
+	trace.Validators = traceValidators(validators)
+	contract := validators
+	if fromHeader(config, header, func() error { _, err := getValidatorsFromHeader(header, config); return err }) {
+		trace.ValidatorsSource = epochSourceHeader
+		if contract, err = c.getNextValidators(api.chain, header, snap, false); err != nil {
+			trace.ValidatorsError = err.Error()
+		} else if !equalValidators(contract, validators) {
+			trace.ContractValidators = traceValidators(contract)
+		}
+	} else {
+		trace.ValidatorsSource = epochSourceContract
+	}
+
+	// The extra-data assembled by Oasys.Prepare and verified by Oasys.Finalize
+	switch {
+	case config.IsFastFinalityEnabled(header.Number):
+		trace.ExtraOrder = "contract"
+	case config.IsForkedOasysPublication(header.Number):
+		trace.ExtraOrder = "contract, hashed"
+	default:
+		trace.ExtraOrder = "address ascending"
+	}
+	trace.ExpectedExtra = c.epochExtra(header.Number, env, validators)
+	if len(header.Extra) >= extraVanity+extraSeal {
+		trace.HeaderExtra = header.Extra[extraVanity : len(header.Extra)-extraSeal]
+	}
+	if trace.EnvironmentError == "" && trace.ValidatorsError == "" {
+		if err := c.verifyExtraHeaderValueInEpoch(header, trace.HeaderExtra, contractEnv, contract); err != nil {
+			trace.ExtraError = err.Error()
+		} else {
+			trace.ExtraMatch = true
+		}
+	}
+
+	// The scheduler of the epoch
+	if num >= c.config.Epoch {
+		if trace.SeedHash, trace.Seed, err = c.epochSeed(api.chain, header, env); err != nil {
+			return nil, err
+		}
+	}
+	scheduler, err := c.scheduler(api.chain, header, env, validators.Operators, validators.Stakes)
+	if err != nil {
+		return nil, err
+	}
+	trace.SchedulerValidators = scheduler.chooser.validators
+	schedules := scheduler.schedules()
+	if len(schedules) > epochTraceProposers {
+		schedules = schedules[:epochTraceProposers]
+	}
+	trace.Proposers = make([]common.Address, len(schedules))
+	for i, proposer := range schedules {
+		trace.Proposers[i] = *proposer
+	}
+	return trace, nil
+}
+
+// fromHeader reports whether the engine takes the value of the epoch block from
+// its header, which is the case after the fast finality if it can be decoded.
+func fromHeader(config *params.ChainConfig, header *types.Header, decode func() error) bool {
+	return config.IsFastFinalityEnabled(header.Number) && decode() == nil
+}
+
+// equalValidators reports whether the validators are the same in the same order,
+// ignoring the owners which are missing from the compact extra-data.
+func equalValidators(a, b *nextValidators) bool {
+	if len(a.Operators) != len(b.Operators) {
+		return false
+	}
+	for i := range a.Operators {
+		if a.Operators[i] != b.Operators[i] || a.Stakes[i].Cmp(b.Stakes[i]) != 0 || a.VoteAddresses[i] != b.VoteAddresses[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func traceValidators(validators *nextValidators) []*EpochValidator {
+	traced := make([]*EpochValidator, len(validators.Operators))
+	for i := range traced {
+		traced[i] = &EpochValidator{
+			Operator: validators.Operators[i],
+			Stake:    (*hexutil.Big)(validators.Stakes[i]),
+		}
+		if i < len(validators.Owners) {
+			traced[i].Owner = validators.Owners[i]
+		}
+		if i < len(validators.VoteAddresses) {
+			traced[i].VoteAddress = validators.VoteAddresses[i]
+		}
+	}
+	return traced
+}
@@ -0,0 +1,141 @@
+package oasys
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/oasys/valcache"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// makeEpochTraceAPI returns the debug API over a chain whose block 10 starts the
+// second epoch with the given validators in its extra-data, while the contracts
+// return the contract validators.
+func makeEpochTraceAPI(validators, contract *nextValidators) (*DebugAPI, *types.Header) {
+	var (
+		config      = &params.OasysConfig{Period: 15, Epoch: 10}
+		chainConfig = &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: config}
+		env         = params.InitialEnvironmentValue(config)
+		engine      = New(chainConfig, config, rawdb.NewMemoryDatabase(), nil)
+		chain       = newTestHeaderChain(chainConfig, &types.Header{Number: big.NewInt(0)})
+	)
+	for i := 1; i < 10; i++ {
+		chain.insert(&types.Header{ParentHash: chain.CurrentHeader().Hash(), Number: big.NewInt(int64(i))})
+	}
+	parent := chain.CurrentHeader()
+	snap := newSnapshot(chainConfig, engine.signatures, nil, parent.Number.Uint64(), parent.Hash(), validators.Operators, env)
+	for i, operator := range validators.Operators {
+		snap.Validators[operator].Stake = validators.Stakes[i]
+		snap.Validators[operator].VoteAddress = validators.VoteAddresses[i]
+	}
+	engine.recents.Load().Add(snap.Hash, snap)
+
+	number := big.NewInt(10)
+	extra := append(make([]byte, extraVanity), engine.epochExtra(number, env, validators)...)
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     number,
+		Extra:      append(extra, make([]byte, extraSeal)...),
+	}
+	chain.insert(header)
+
+	epoch := env.Epoch(number.Uint64())
+	engine.valcache.StoreEnvironment(epoch, parent.Hash(), env)
+	engine.valcache.StoreValidators(epoch, parent.Hash(), (*valcache.Validators)(contract))
+	return &DebugAPI{chain: chain, oasys: engine}, header
+}
+
+func TestTraceEpochTransition(t *testing.T) {
+	validators := &nextValidators{
+		Owners:        []common.Address{{0x11}, {0x12}, {0x13}},
+		Operators:     []common.Address{{0x21}, {0x22}, {0x23}},
+		Stakes:        []*big.Int{newEth(30_000_000), newEth(20_000_000), newEth(10_000_000)},
+		VoteAddresses: []types.BLSPublicKey{randomBLSPublicKey(), randomBLSPublicKey(), randomBLSPublicKey()},
+	}
+	api, header := makeEpochTraceAPI(validators, validators)
+
+	trace, err := api.TraceEpochTransition(rpc.BlockNumber(10))
+	if err != nil {
+		t.Fatalf("failed to trace epoch transition: %v", err)
+	}
+	if trace.Hash != header.Hash() || trace.Epoch != 2 {
+		t.Errorf("block mismatch: have %s epoch %d, want %s epoch 2", trace.Hash, trace.Epoch, header.Hash())
+	}
+	if trace.EnvironmentSource != epochSourceHeader || trace.ValidatorsSource != epochSourceHeader {
+		t.Errorf("source mismatch: have environment %s validators %s, want header", trace.EnvironmentSource, trace.ValidatorsSource)
+	}
+	if trace.ContractEnvironment == nil || trace.ContractValidators != nil {
+		t.Errorf("contract values mismatch: environment %v, validators %v", trace.ContractEnvironment, trace.ContractValidators)
+	}
+	if !bytes.Equal(trace.ExpectedExtra, trace.HeaderExtra) || !trace.ExtraMatch || trace.ExtraError != "" {
+		t.Errorf("extra mismatch: match %v, err %q", trace.ExtraMatch, trace.ExtraError)
+	}
+	if len(trace.Validators) != len(validators.Operators) {
+		t.Fatalf("validators length mismatch: have %d, want %d", len(trace.Validators), len(validators.Operators))
+	}
+	for i, v := range trace.Validators {
+		if v.Operator != validators.Operators[i] || v.Stake.ToInt().Cmp(validators.Stakes[i]) != 0 {
+			t.Errorf("validator %d mismatch: have %s %v", i, v.Operator, v.Stake)
+		}
+	}
+
+	// The seed and the proposers are the ones of the scheduler of the engine
+	seedHash, seed, err := api.oasys.epochSeed(api.chain, header, trace.Environment)
+	if err != nil {
+		t.Fatalf("failed to get seed: %v", err)
+	}
+	if trace.SeedHash != header.ParentHash || trace.SeedHash != seedHash || trace.Seed != seed {
+		t.Errorf("seed mismatch: have %s %d, want %s %d", trace.SeedHash, trace.Seed, seedHash, seed)
+	}
+	scheduler, err := api.oasys.scheduler(api.chain, header, trace.Environment, validators.Operators, validators.Stakes)
+	if err != nil {
+		t.Fatalf("failed to create scheduler: %v", err)
+	}
+	schedules := scheduler.schedules()
+	if len(trace.Proposers) == 0 || len(trace.Proposers) > epochTraceProposers {
+		t.Fatalf("unexpected number of proposers: %d", len(trace.Proposers))
+	}
+	for i, proposer := range trace.Proposers {
+		if proposer != *schedules[i] {
+			t.Errorf("proposer %d mismatch: have %s, want %s", i, proposer, *schedules[i])
+		}
+	}
+
+	if _, err := api.TraceEpochTransition(rpc.BlockNumber(0)); err == nil {
+		t.Error("traced genesis block")
+	}
+	if _, err := api.TraceEpochTransition(rpc.BlockNumber(11)); err != errUnknownBlock {
+		t.Errorf("unknown block error mismatch: have %v, want %v", err, errUnknownBlock)
+	}
+}
+
+func TestTraceEpochTransitionMismatch(t *testing.T) {
+	validators := &nextValidators{
+		Owners:        []common.Address{{0x11}, {0x12}},
+		Operators:     []common.Address{{0x21}, {0x22}},
+		Stakes:        []*big.Int{newEth(20_000_000), newEth(10_000_000)},
+		VoteAddresses: []types.BLSPublicKey{randomBLSPublicKey(), randomBLSPublicKey()},
+	}
+	contract := validators.Copy()
+	contract.Stakes[1] = newEth(15_000_000)
+	api, _ := makeEpochTraceAPI(validators, contract)
+
+	trace, err := api.TraceEpochTransition(rpc.BlockNumber(10))
+	if err != nil {
+		t.Fatalf("failed to trace epoch transition: %v", err)
+	}
+	if trace.ExtraMatch || trace.ExtraError == "" {
+		t.Errorf("extra accepted against mismatching contract validators")
+	}
+	if len(trace.ContractValidators) != 2 || trace.ContractValidators[1].Stake.ToInt().Cmp(contract.Stakes[1]) != 0 {
+		t.Errorf("contract validators mismatch: %v", trace.ContractValidators)
+	}
+	if !bytes.Equal(trace.ExpectedExtra, trace.HeaderExtra) {
+		t.Error("expected extra differs from the header")
+	}
+}
@@ -0,0 +1,121 @@
+package oasys
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Classes of the consensus failures, which the errors returned while verifying
+// and finalizing the blocks can be matched against with errors.Is.
+var (
+	ErrSnapshotUnavailable    = errors.New("failed to retrieve snapshot")
+	ErrEnvironmentUnavailable = errors.New("failed to get environment value")
+	ErrValidatorsUnavailable  = errors.New("failed to get validators")
+	ErrSchedulerUnavailable   = errors.New("failed to get scheduler")
+	ErrSystemContract         = errors.New("failed to call system contract")
+	ErrInvalidAttestation     = errors.New("invalid vote attestation")
+	ErrSealMismatch           = errors.New("seal mismatch")
+	ErrSystemTxReverted       = errors.New("system transaction reverted locally")
+)
+
+// errorCodes maps the classes of the consensus failures to the JSON-RPC error
+// codes, which are stable across releases for the monitoring.
+var errorCodes = map[error]int{
+	ErrSnapshotUnavailable:    -39001,
+	ErrEnvironmentUnavailable: -39002,
+	ErrValidatorsUnavailable:  -39003,
+	ErrSchedulerUnavailable:   -39004,
+	ErrSystemContract:         -39005,
+	ErrInvalidAttestation:     -39006,
+	ErrSealMismatch:           -39007,
+	ErrSystemTxReverted:       -39008,
+}
+
+// Error is a consensus failure carrying its class and the block it occurred at.
+// It implements rpc.Error and rpc.DataError, so that the failures returned over
+// JSON-RPC are classified by the code and the structured data.
+type Error struct {
+	Kind      error           // One of the classes of the consensus failures
+	Stage     string          // Engine method the failure occurred in
+	Number    uint64          // Block number
+	Epoch     *uint64         // Epoch of the block, if known
+	Validator *common.Address // Validator of the block, if relevant
+	Err       error           // Underlying error
+}
+
+func newError(kind error, stage string, number uint64, err error) *Error {
+	return &Error{Kind: kind, Stage: stage, Number: number, Err: err}
+}
+
+// withEpoch sets the epoch of the block.
+func (e *Error) withEpoch(epoch uint64) *Error {
+	e.Epoch = &epoch
+	return e
+}
+
+// withValidator sets the validator of the block.
+func (e *Error) withValidator(validator common.Address) *Error {
+	e.Validator = &validator
+	return e
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%v, in: %s, blockNumber: %d", e.Kind, e.Stage, e.Number)
+	if e.Epoch != nil {
+		msg += fmt.Sprintf(", epoch: %d", *e.Epoch)
+	}
+	if e.Validator != nil {
+		msg += fmt.Sprintf(", validator: %s", e.Validator.Hex())
+	}
+	if e.Err != nil {
+		msg += fmt.Sprintf(", err: %v", e.Err)
+	}
+	return msg
+}
+
+// Unwrap returns both the class and the underlying error for errors.Is.
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// ErrorCode implements rpc.Error.
+func (e *Error) ErrorCode() int {
+	return errorCodes[e.Kind]
+}
+
+// ErrorData implements rpc.DataError.
+func (e *Error) ErrorData() interface{} {
+	data := map[string]interface{}{
+		"kind":   e.Kind.Error(),
+		"stage":  e.Stage,
+		"number": hexutil.Uint64(e.Number),
+	}
+	if e.Epoch != nil {
+		data["epoch"] = hexutil.Uint64(*e.Epoch)
+	}
+	if e.Validator != nil {
+		data["validator"] = *e.Validator
+	}
+	var bad *badAttestationError
+	if errors.As(e.Err, &bad) {
+		data["attestation"] = bad.diag
+	}
+	return data
+}
+
+// ErrorCode returns the JSON-RPC error code of the consensus failure, or false
+// if the error is not one of the classified failures.
+func ErrorCode(err error) (int, bool) {
+	var e *Error
+	if !errors.As(err, &e) {
+		return 0, false
+	}
+	code, ok := errorCodes[e.Kind]
+	return code, ok
+}
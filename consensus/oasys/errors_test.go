@@ -0,0 +1,46 @@
+package oasys
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestError(t *testing.T) {
+	validator := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	err := error(newError(ErrSealMismatch, "verifySeal", 100, errWrongDifficulty).withEpoch(2).withValidator(validator))
+
+	require.ErrorIs(t, err, ErrSealMismatch)
+	require.ErrorIs(t, err, errWrongDifficulty)
+	require.NotErrorIs(t, err, ErrInvalidAttestation)
+	require.Equal(t, "seal mismatch, in: verifySeal, blockNumber: 100, epoch: 2, validator: 0x1000000000000000000000000000000000000001, err: wrong difficulty", err.Error())
+
+	var rpcErr rpc.Error
+	require.True(t, errors.As(err, &rpcErr))
+	require.Equal(t, -39007, rpcErr.ErrorCode())
+	var dataErr rpc.DataError
+	require.True(t, errors.As(err, &dataErr))
+	require.Equal(t, validator, dataErr.ErrorData().(map[string]interface{})["validator"])
+
+	// The underlying consensus errors are kept matchable through the wrapping.
+	wrapped := newError(ErrInvalidAttestation, "verifyCascadingFields", 100, consensus.ErrUnknownAncestor)
+	require.ErrorIs(t, wrapped, consensus.ErrUnknownAncestor)
+	code, ok := ErrorCode(wrapped)
+	require.True(t, ok)
+	require.Equal(t, -39006, code)
+
+	// The system transaction reverted locally is classified apart from the
+	// other failures of the system transactions.
+	reverted := newError(ErrSystemTxReverted, "Finalize", 100, fmt.Errorf("%w: execution reverted", errExecuteSystemTx))
+	require.ErrorIs(t, reverted, errExecuteSystemTx)
+	code, _ = ErrorCode(reverted)
+	require.Equal(t, -39008, code)
+
+	_, ok = ErrorCode(errWrongDifficulty)
+	require.False(t, ok)
+}
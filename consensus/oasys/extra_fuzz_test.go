@@ -0,0 +1,98 @@
+package oasys
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// fuzzExtraConfig returns the chain config of the fuzzed headers, with the
+// compact extra fork enabled or not.
+func fuzzExtraConfig(compact bool) *params.ChainConfig {
+	config := &params.OasysConfig{Period: 15, Epoch: 5760}
+	if compact {
+		config.CompactExtraBlock = common.Big0
+	}
+	return &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: config}
+}
+
+// addExtraSeeds adds the extra data of the epoch and non-epoch blocks in both
+// layouts, and their truncations, to the seed corpus.
+func addExtraSeeds(f *testing.F) {
+	var (
+		env        = params.InitialEnvironmentValue(&params.OasysConfig{Period: 15, Epoch: 5760})
+		validators = &nextValidators{}
+	)
+	for i := 0; i < 3; i++ {
+		validators.Owners = append(validators.Owners, common.BigToAddress(big.NewInt(int64(i+1))))
+		validators.Operators = append(validators.Operators, common.BigToAddress(big.NewInt(int64(i+10))))
+		validators.Stakes = append(validators.Stakes, newEth(int64(i+1)))
+		validators.VoteAddresses = append(validators.VoteAddresses, types.BLSPublicKey{byte(i + 1)})
+	}
+	attestation, err := rlp.EncodeToBytes(&types.VoteAttestation{
+		VoteAddressSet: 3,
+		Data:           &types.VoteData{SourceNumber: 98, TargetNumber: 99},
+	})
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, compact := range []bool{false, true} {
+		extra := make([]byte, extraVanity)
+		extra = append(extra, assembleEnvironmentValue(env)...)
+		extra = append(extra, assembleValidators(validators, compact)...)
+		extra = append(extra, attestation...)
+		extra = append(extra, make([]byte, extraSeal)...)
+		for _, n := range []int{len(extra), len(extra) - extraSeal, extraVanity + envValuesLen + 1, extraVanity + extraSeal + 1} {
+			f.Add(extra[:n], compact)
+		}
+	}
+	f.Add(append(append(make([]byte, extraVanity), attestation...), make([]byte, extraSeal)...), false)
+	f.Add([]byte{}, false)
+}
+
+func FuzzGetValidatorsFromHeader(f *testing.F) {
+	addExtraSeeds(f)
+	f.Fuzz(func(t *testing.T, extra []byte, compact bool) {
+		header := &types.Header{Number: big.NewInt(5760), Extra: extra}
+		validators, err := getValidatorsFromHeader(header, fuzzExtraConfig(compact))
+		if err != nil {
+			if !errors.Is(err, errMissingValidators) && !errors.Is(err, errInvalidValidators) {
+				t.Fatalf("untyped error: %v", err)
+			}
+			return
+		}
+		if n := len(validators.Owners); n == 0 || len(validators.Operators) != n || len(validators.Stakes) != n || len(validators.VoteAddresses) != n {
+			t.Fatalf("inconsistent validators: %d owners, %d operators, %d stakes, %d vote addresses",
+				n, len(validators.Operators), len(validators.Stakes), len(validators.VoteAddresses))
+		}
+	})
+}
+
+func FuzzGetEnvironmentFromHeader(f *testing.F) {
+	addExtraSeeds(f)
+	f.Fuzz(func(t *testing.T, extra []byte, _ bool) {
+		header := &types.Header{Number: big.NewInt(5760), Extra: extra}
+		env, err := getEnvironmentFromHeader(header)
+		if err == nil && env.EpochPeriod == nil {
+			t.Fatal("environment value decoded without the epoch period")
+		}
+	})
+}
+
+func FuzzGetVoteAttestationFromHeader(f *testing.F) {
+	addExtraSeeds(f)
+	f.Fuzz(func(t *testing.T, extra []byte, compact bool) {
+		config := fuzzExtraConfig(compact)
+		header := &types.Header{Number: big.NewInt(5760), Extra: extra}
+		for _, isEpoch := range []bool{false, true} {
+			if _, err := getVoteAttestationFromHeader(header, config, config.Oasys, isEpoch); err != nil && !errors.Is(err, errInvalidAttestationData) {
+				t.Fatalf("untyped error: %v", err)
+			}
+		}
+	})
+}
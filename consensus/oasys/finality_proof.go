@@ -0,0 +1,137 @@
+package oasys
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxFinalityProofSearch is the maximum number of blocks after the finalized
+// block searched for the attestation finalizing it.
+const maxFinalityProofSearch = 64
+
+// FinalityValidator is a validator of the set committed in the epoch header,
+// in the order of the bits of the vote address set offset by one.
+type FinalityValidator struct {
+	Operator    common.Address `json:"operator"`
+	VoteAddress hexutil.Bytes  `json:"voteAddress"`
+	Stake       *hexutil.Big   `json:"stake"`
+}
+
+// FinalityProof is the data for an external verifier to check the finality of a
+// block: the attestation of the block and its child, signed by the validators
+// committed in the epoch header, and the headers linking the epoch header to the
+// header carrying the attestation.
+type FinalityProof struct {
+	Number            hexutil.Uint64       `json:"number"`
+	Hash              common.Hash          `json:"hash"`
+	EpochNumber       hexutil.Uint64       `json:"epochNumber"` // Epoch header committing the validators
+	Validators        []*FinalityValidator `json:"validators"`
+	AttestationNumber hexutil.Uint64       `json:"attestationNumber"` // Header carrying the attestation
+	Attestation       hexutil.Bytes        `json:"attestation"`       // RLP of the attestation
+	Headers           []hexutil.Bytes      `json:"headers"`           // RLP of the consecutive headers covering the epoch header, the block and the one carrying the attestation
+}
+
+// GetFinalityProof returns the proof that the specified block is finalized, or
+// the latest finalized block if none is given. A block is finalized once an
+// attestation justifies its child with the block as the source.
+func (api *API) GetFinalityProof(number *rpc.BlockNumber) (*FinalityProof, error) {
+	var header *types.Header
+	if number == nil {
+		header = api.oasys.GetFinalizedHeader(api.chain, api.chain.CurrentHeader())
+	} else {
+		header = api.chain.GetHeaderByNumber(api.resolveNumber(*number))
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	if !api.oasys.chainConfig.IsFastFinalityEnabled(header.Number) {
+		return nil, errors.New("fast finality is not enabled at the block")
+	}
+
+	// Find the attestation with the block as the source and its child as the target.
+	var (
+		finalized   = header.Number.Uint64()
+		head        = api.chain.CurrentHeader().Number.Uint64()
+		carrier     *types.Header
+		attestation *types.VoteAttestation
+	)
+	for n := finalized + 2; n <= head && n <= finalized+maxFinalityProofSearch; n++ {
+		h := api.chain.GetHeaderByNumber(n)
+		if h == nil {
+			break
+		}
+		a := api.oasys.DecodeVoteAttestation(h)
+		if a == nil || a.Data == nil || a.Data.SourceNumber < finalized {
+			continue
+		}
+		if a.Data.SourceNumber == finalized && a.Data.SourceHash == header.Hash() && a.Data.TargetNumber == finalized+1 {
+			carrier, attestation = h, a
+		}
+		break
+	}
+	if carrier == nil {
+		return nil, errors.New("block is not finalized")
+	}
+
+	// The attestation is signed by the validators of the epoch of the parent of
+	// the target, or the ones of the carrying header if it starts an epoch.
+	snap, err := api.oasys.snapshot(api.chain, carrier.Number.Uint64()-1, carrier.ParentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	epochNumber := carrier.Number.Uint64()
+	if !snap.Environment.IsEpoch(epochNumber) {
+		if snap, err = api.oasys.snapshot(api.chain, finalized, header.Hash(), nil); err != nil {
+			return nil, err
+		}
+		epochNumber = snap.Environment.GetFirstBlock(finalized)
+	}
+	epochHeader := api.chain.GetHeaderByNumber(epochNumber)
+	if epochHeader == nil {
+		return nil, errUnknownBlock
+	}
+	validators, err := getValidatorsFromHeader(epochHeader, api.oasys.chainConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &FinalityProof{
+		Number:            hexutil.Uint64(finalized),
+		Hash:              header.Hash(),
+		EpochNumber:       hexutil.Uint64(epochNumber),
+		Validators:        make([]*FinalityValidator, 0, len(validators.Operators)),
+		AttestationNumber: hexutil.Uint64(carrier.Number.Uint64()),
+	}
+	for i, operator := range validators.Operators {
+		proof.Validators = append(proof.Validators, &FinalityValidator{
+			Operator:    operator,
+			VoteAddress: validators.VoteAddresses[i][:],
+			Stake:       (*hexutil.Big)(new(big.Int).Set(validators.Stakes[i])),
+		})
+	}
+	if proof.Attestation, err = rlp.EncodeToBytes(attestation); err != nil {
+		return nil, err
+	}
+	start := epochNumber
+	if finalized < start {
+		start = finalized
+	}
+	for n := start; n <= carrier.Number.Uint64(); n++ {
+		h := api.chain.GetHeaderByNumber(n)
+		if h == nil {
+			return nil, errUnknownBlock
+		}
+		enc, err := rlp.EncodeToBytes(h)
+		if err != nil {
+			return nil, err
+		}
+		proof.Headers = append(proof.Headers, enc)
+	}
+	return proof, nil
+}
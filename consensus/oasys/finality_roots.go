@@ -0,0 +1,283 @@
+package oasys
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxFinalityRootsBatch is the maximum number of blocks covered by a batch of
+// the finality roots.
+const maxFinalityRootsBatch = 1024
+
+// FinalityRoot is a finalized block with the attestation finalizing it, in the
+// form consumed by the verse nodes as the finality source of the hub layer. The
+// attestation justifies the child of the block with the block as the source, so
+// it is carried by the grandchild of the block.
+type FinalityRoot struct {
+	Number      hexutil.Uint64 `json:"number"`
+	Hash        common.Hash    `json:"hash"`
+	ParentHash  common.Hash    `json:"parentHash"`
+	Time        hexutil.Uint64 `json:"timestamp"`
+	Epoch       hexutil.Uint64 `json:"epoch"`       // Epoch of the validators signing the attestation
+	Attestation hexutil.Bytes  `json:"attestation"` // RLP of the attestation
+}
+
+// FinalityRoots is a batch of the finality roots found in a range of blocks. The
+// validators signing the attestations are listed once per epoch. If compressed,
+// the roots and the validators are carried as the gzipped JSON instead.
+type FinalityRoots struct {
+	From       hexutil.Uint64                          `json:"from"`
+	Next       hexutil.Uint64                          `json:"next"` // First block not covered by the batch
+	Roots      []*FinalityRoot                         `json:"roots,omitempty"`
+	Validators map[hexutil.Uint64][]*FinalityValidator `json:"validators,omitempty"`
+	Compressed hexutil.Bytes                           `json:"compressed,omitempty"`
+}
+
+// finalityRootsContent is the compressed content of the finality roots.
+type finalityRootsContent struct {
+	Roots      []*FinalityRoot                         `json:"roots"`
+	Validators map[hexutil.Uint64][]*FinalityValidator `json:"validators"`
+}
+
+// compress replaces the roots and the validators with their gzipped JSON.
+func (r *FinalityRoots) compress() error {
+	enc, err := json.Marshal(&finalityRootsContent{Roots: r.Roots, Validators: r.Validators})
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(enc); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	r.Roots, r.Validators, r.Compressed = nil, nil, buf.Bytes()
+	return nil
+}
+
+// Decompress restores the roots and the validators of a compressed batch.
+func (r *FinalityRoots) Decompress() error {
+	if len(r.Compressed) == 0 {
+		return nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(r.Compressed))
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	enc, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	var content finalityRootsContent
+	if err := json.Unmarshal(enc, &content); err != nil {
+		return err
+	}
+	r.Roots, r.Validators, r.Compressed = content.Roots, content.Validators, nil
+	return nil
+}
+
+// finalityRoots collects the finality roots of the canonical blocks from the
+// given one, covering at most the given number of blocks up to the latest
+// finalized block.
+func (c *Oasys) finalityRoots(chain consensus.ChainHeaderReader, from, count uint64) (*FinalityRoots, error) {
+	batch := &FinalityRoots{From: hexutil.Uint64(from), Next: hexutil.Uint64(from)}
+
+	finalized := c.GetFinalizedHeader(chain, chain.CurrentHeader())
+	if finalized == nil {
+		return nil, errUnknownBlock
+	}
+	if from > finalized.Number.Uint64() {
+		return batch, nil
+	}
+	to := finalized.Number.Uint64()
+	if count > 0 && to-from >= count {
+		to = from + count - 1
+	}
+	batch.Next = hexutil.Uint64(to + 1)
+
+	for number := from; number <= to; number++ {
+		carrier := chain.GetHeaderByNumber(number + 2)
+		if carrier == nil {
+			return nil, errUnknownBlock
+		}
+		attestation := c.DecodeVoteAttestation(carrier)
+		if attestation == nil || attestation.Data == nil ||
+			attestation.Data.SourceNumber != number || attestation.Data.TargetNumber != number+1 {
+			continue
+		}
+		header := chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		if attestation.Data.SourceHash != header.Hash() {
+			continue
+		}
+		snap, validators, err := c.attestationValidators(chain, carrier)
+		if err != nil {
+			return nil, err
+		}
+		epoch := hexutil.Uint64(snap.Environment.Epoch(carrier.Number.Uint64()))
+		if _, ok := batch.Validators[epoch]; !ok {
+			if batch.Validators == nil {
+				batch.Validators = make(map[hexutil.Uint64][]*FinalityValidator)
+			}
+			list := make([]*FinalityValidator, 0, len(validators.Operators))
+			for i, operator := range validators.Operators {
+				list = append(list, &FinalityValidator{
+					Operator:    operator,
+					VoteAddress: validators.VoteAddresses[i][:],
+					Stake:       (*hexutil.Big)(new(big.Int).Set(validators.Stakes[i])),
+				})
+			}
+			batch.Validators[epoch] = list
+		}
+		enc, err := rlp.EncodeToBytes(attestation)
+		if err != nil {
+			return nil, err
+		}
+		batch.Roots = append(batch.Roots, &FinalityRoot{
+			Number:      hexutil.Uint64(number),
+			Hash:        header.Hash(),
+			ParentHash:  header.ParentHash,
+			Time:        hexutil.Uint64(header.Time),
+			Epoch:       epoch,
+			Attestation: enc,
+		})
+	}
+	return batch, nil
+}
+
+// GetFinalityRoots returns the finality roots of the blocks from the given one,
+// covering at most the given number of blocks (default and maximum 1024) up to
+// the latest finalized block. The batch is meant for the verse nodes to catch up
+// with the hub finality, resuming from the next block of the batch.
+func (api *API) GetFinalityRoots(from hexutil.Uint64, count *hexutil.Uint64, compress *bool) (*FinalityRoots, error) {
+	n := uint64(maxFinalityRootsBatch)
+	if count != nil && uint64(*count) > 0 && uint64(*count) < n {
+		n = uint64(*count)
+	}
+	batch, err := api.oasys.finalityRoots(api.chain, uint64(from), n)
+	if err != nil {
+		return nil, err
+	}
+	if compress != nil && *compress {
+		if err := batch.compress(); err != nil {
+			return nil, err
+		}
+	}
+	return batch, nil
+}
+
+// finalityHeads tracks the latest finalized block of the canonical chain to
+// notify the subscribers of the finality roots.
+type finalityHeads struct {
+	feed  event.Feed
+	scope event.SubscriptionScope
+
+	lock   sync.Mutex
+	number uint64 // Latest finalized block sent, zero if not tracking
+}
+
+// EmitFinalityHead sends the finalized block of the new chain head to the
+// subscribers of the finality roots, if the finality advanced.
+func (c *Oasys) EmitFinalityHead(chain consensus.ChainHeaderReader, head *types.Header) {
+	f := c.finalityHeads
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.scope.Count() == 0 {
+		f.number = 0
+		return
+	}
+	finalized := c.GetFinalizedHeader(chain, head)
+	if finalized == nil || finalized.Number.Uint64() <= f.number {
+		return
+	}
+	f.number = finalized.Number.Uint64()
+	f.feed.Send(finalized)
+}
+
+// FinalityRoots creates a subscription to the finality roots of the canonical
+// chain, starting from the given block or from the next finalized block if none
+// is given. The roots are sent in batches as the finality advances, the ones of
+// the past blocks first.
+func (api *API) FinalityRoots(ctx context.Context, from *hexutil.Uint64) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	var next uint64
+	if from != nil {
+		next = uint64(*from)
+	} else {
+		finalized := api.oasys.GetFinalizedHeader(api.chain, api.chain.CurrentHeader())
+		if finalized == nil {
+			return nil, errUnknownBlock
+		}
+		next = finalized.Number.Uint64() + 1
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		heads := make(chan *types.Header, 16)
+		sub := api.oasys.finalityHeads.scope.Track(api.oasys.finalityHeads.feed.Subscribe(heads))
+		defer sub.Unsubscribe()
+
+		// Send the roots up to the latest finalized block, in batches
+		deliver := func(finalized uint64) bool {
+			for next <= finalized {
+				batch, err := api.oasys.finalityRoots(api.chain, next, maxFinalityRootsBatch)
+				if err != nil {
+					log.Debug("Failed to collect finality roots", "from", next, "err", err)
+					return true
+				}
+				if uint64(batch.Next) == next {
+					return true
+				}
+				if len(batch.Roots) > 0 {
+					if err := notifier.Notify(rpcSub.ID, batch); err != nil {
+						return false
+					}
+				}
+				next = uint64(batch.Next)
+			}
+			return true
+		}
+		if finalized := api.oasys.GetFinalizedHeader(api.chain, api.chain.CurrentHeader()); finalized != nil {
+			if !deliver(finalized.Number.Uint64()) {
+				return
+			}
+		}
+		for {
+			select {
+			case head := <-heads:
+				if !deliver(head.Number.Uint64()) {
+					return
+				}
+			case <-rpcSub.Err():
+				return
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
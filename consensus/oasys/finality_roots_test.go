@@ -0,0 +1,87 @@
+package oasys
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestGetFinalityRoots(t *testing.T) {
+	env, err := makeVoteEnv(4)
+	if err != nil {
+		t.Fatalf("failed to create test vote env: %v", err)
+	}
+	// Every block from the second one finalizes its grandparent
+	for i := 0; i < 8; i++ {
+		if err := env.vote(env.validators...); err != nil {
+			t.Fatalf("failed to vote: %v", err)
+		}
+		header, err := env.newBlock()
+		if err != nil {
+			t.Fatalf("failed to build block %d: %v", i+1, err)
+		}
+		env.chain.insert(header)
+	}
+	api := &API{chain: env.chain, oasys: env.engine}
+
+	batch, err := api.GetFinalityRoots(0, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to get finality roots: %v", err)
+	}
+	if batch.Next != 7 || len(batch.Roots) != 7 {
+		t.Fatalf("batch mismatch: next %d, roots %d, want next 7, roots 7", batch.Next, len(batch.Roots))
+	}
+	for i, root := range batch.Roots {
+		header := env.chain.GetHeaderByNumber(uint64(i))
+		if uint64(root.Number) != uint64(i) || root.Hash != header.Hash() || root.ParentHash != header.ParentHash {
+			t.Errorf("root %d: block mismatch: have %d %x", i, root.Number, root.Hash)
+		}
+		var attestation types.VoteAttestation
+		if err := rlp.DecodeBytes(root.Attestation, &attestation); err != nil {
+			t.Fatalf("root %d: failed to decode attestation: %v", i, err)
+		}
+		if attestation.Data.SourceHash != root.Hash || attestation.Data.TargetNumber != uint64(i+1) {
+			t.Errorf("root %d: attestation mismatch: %+v", i, attestation.Data)
+		}
+		if validators := batch.Validators[root.Epoch]; len(validators) != len(env.validators) {
+			t.Errorf("root %d: validators mismatch: have %d, want %d", i, len(validators), len(env.validators))
+		}
+	}
+
+	// Resume from the middle with a limited batch
+	count := hexutil.Uint64(3)
+	partial, err := api.GetFinalityRoots(2, &count, nil)
+	if err != nil {
+		t.Fatalf("failed to get partial finality roots: %v", err)
+	}
+	if partial.Next != 5 || !reflect.DeepEqual(partial.Roots, batch.Roots[2:5]) {
+		t.Errorf("partial batch mismatch: next %d, roots %d", partial.Next, len(partial.Roots))
+	}
+
+	// Nothing beyond the finalized block
+	ahead, err := api.GetFinalityRoots(7, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to get finality roots ahead: %v", err)
+	}
+	if ahead.Next != 7 || len(ahead.Roots) != 0 {
+		t.Errorf("batch ahead mismatch: next %d, roots %d", ahead.Next, len(ahead.Roots))
+	}
+
+	compress := true
+	compressed, err := api.GetFinalityRoots(0, nil, &compress)
+	if err != nil {
+		t.Fatalf("failed to get compressed finality roots: %v", err)
+	}
+	if len(compressed.Roots) != 0 || len(compressed.Compressed) == 0 {
+		t.Fatalf("batch not compressed")
+	}
+	if err := compressed.Decompress(); err != nil {
+		t.Fatalf("failed to decompress: %v", err)
+	}
+	if !reflect.DeepEqual(compressed, batch) {
+		t.Errorf("decompressed batch mismatch")
+	}
+}
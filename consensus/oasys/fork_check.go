@@ -0,0 +1,124 @@
+package oasys
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+//go:generate go run ./internal/forkcapture -rpc $OASYS_MAINNET_RPC -start 4089600 -out forkvectors/shortened_block_time_mainnet.json
+
+// shortenedBlockTimeVector is captured from the mainnet blocks at the start of
+// the shortened block time fork, against which the binary is checked before the
+// fork starts.
+//
+//go:embed forkvectors/shortened_block_time_mainnet.json
+var shortenedBlockTimeVector []byte
+
+// errNoForkVector is returned if no vector has been captured for the fork.
+var errNoForkVector = errors.New("no fork vector captured")
+
+// ForkVector is the validators and the blocks captured from the beginning of an
+// epoch of a live network, so that the proposer selection and the timestamp math
+// of a binary can be checked against the real chain.
+type ForkVector struct {
+	Environment *params.EnvironmentValue `json:"environment"`
+	SeedHash    common.Hash              `json:"seedHash"`   // Hash of the last block of the previous epoch
+	Validators  []ScheduleValidator      `json:"validators"` // In the order given to the proposer selection
+	Blocks      []*ForkVectorBlock       `json:"blocks"`     // Consecutive blocks from the epoch block
+}
+
+// ForkVectorBlock is the part of a captured block decided by the proposer
+// selection.
+type ForkVectorBlock struct {
+	Number     uint64         `json:"number"`
+	ParentTime uint64         `json:"parentTime"`
+	Time       uint64         `json:"time"`
+	Coinbase   common.Address `json:"coinbase"`
+}
+
+// CheckShortenedBlockTime verifies the scheduler seeds and the timestamp math of
+// the binary under the periods of the shortened block time fork against the
+// blocks captured from the mainnet. The check is skipped once the fork has
+// started at the given header, as the binary has proven to follow the chain,
+// and whether the check was run is returned.
+func (c *Oasys) CheckShortenedBlockTime(chain consensus.ChainHeaderReader, header *types.Header) (bool, error) {
+	snap, err := c.snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return false, err
+	}
+	fork := c.chainConfig.OasysShortenedBlockTimeStartEpoch()
+	if fork == nil || snap.Environment.Epoch(header.Number.Uint64()) >= fork.Uint64() {
+		return false, nil
+	}
+	vector, err := decodeForkVector(shortenedBlockTimeVector)
+	if err != nil {
+		return false, err
+	}
+	return true, checkForkVector(vector)
+}
+
+// decodeForkVector decodes the captured vector, errNoForkVector if none.
+func decodeForkVector(blob []byte) (*ForkVector, error) {
+	var vector *ForkVector
+	if err := json.Unmarshal(blob, &vector); err != nil {
+		return nil, err
+	}
+	if vector == nil {
+		return nil, errNoForkVector
+	}
+	if env := vector.Environment; env == nil || env.StartBlock == nil || env.StartEpoch == nil || env.BlockPeriod == nil || env.EpochPeriod == nil {
+		return nil, errors.New("incomplete environment in fork vector")
+	}
+	if len(vector.Blocks) == 0 {
+		return nil, errors.New("no blocks in fork vector")
+	}
+	return vector, nil
+}
+
+// checkForkVector checks that the captured blocks were sealed no earlier than
+// the proposer selection of the binary allows their sealers to, which fails for
+// the blocks sealed in time if the binary selects another proposer or computes
+// other back-off times.
+func checkForkVector(v *ForkVector) error {
+	var (
+		env   = v.Environment
+		start = env.GetFirstBlock(v.Blocks[0].Number)
+		index = make(map[common.Address]int, len(v.Validators))
+	)
+	if start != v.Blocks[0].Number {
+		return fmt.Errorf("fork vector starts at block %d, not the epoch block %d", v.Blocks[0].Number, start)
+	}
+	schedule, err := GenerateScheduleVector(v.SeedHash, v.Validators, env.EpochPeriod.Uint64())
+	if err != nil {
+		return err
+	}
+	for i, validator := range v.Validators {
+		index[validator.Address] = i
+	}
+	for i, block := range v.Blocks {
+		if block.Number != start+uint64(i) {
+			return fmt.Errorf("non-consecutive block %d in fork vector", block.Number)
+		}
+		if block.Number-start >= uint64(len(schedule.Schedule)) {
+			return fmt.Errorf("block %d beyond the epoch of the fork vector", block.Number)
+		}
+		validator, ok := index[block.Coinbase]
+		if !ok {
+			return fmt.Errorf("block %d sealed by unknown validator %s", block.Number, block.Coinbase)
+		}
+		slot := schedule.Schedule[block.Number-start]
+		earliest := block.ParentTime + env.BlockPeriod.Uint64() + slot.BackOffTimes[validator]
+		if block.Time < earliest {
+			return fmt.Errorf("mismatching timestamp of block %d sealed by %s, expected at least: %d, real: %d (proposer: %s)",
+				block.Number, block.Coinbase, earliest, block.Time, slot.Proposer)
+		}
+	}
+	return nil
+}
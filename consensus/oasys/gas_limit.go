@@ -0,0 +1,131 @@
+package oasys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/oasys/valcache"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// environmentGasLimitABI is the ABI of the block gas limit of the Environment
+// contract, which is applied from the next epoch like the other values. The
+// contracts predating it revert without data, leaving the blocks bounded by
+// params.MaxGasLimit only.
+const environmentGasLimitABI = `[{"type":"function","name":"gasLimit","stateMutability":"view","inputs":[],"outputs":[{"name":"target","type":"uint256"},{"name":"max","type":"uint256"}]}]`
+
+var environmentGasLimit abi.ABI
+
+func init() {
+	var err error
+	if environmentGasLimit, err = abi.JSON(strings.NewReader(environmentGasLimitABI)); err != nil {
+		panic(err)
+	}
+}
+
+// Call the `Environment.gasLimit` method, returning the zero gas limit if the
+// contract does not implement it.
+func (s *systemContracts) getGasLimit(ethAPI blockchainAPI, hash common.Hash) (valcache.GasLimit, error) {
+	method := "gasLimit"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	data, err := environmentGasLimit.Pack(method)
+	if err != nil {
+		return valcache.GasLimit{}, err
+	}
+
+	hexData := (hexutil.Bytes)(data)
+	blockNrOrHash := rpc.BlockNumberOrHashWithHash(hash, false)
+	rbytes, err := ethAPI.Call(
+		ctx,
+		ethapi.TransactionArgs{
+			To:   &s.environment.address,
+			Data: &hexData,
+		},
+		&blockNrOrHash,
+		nil,
+		nil,
+	)
+	if errors.Is(err, vm.ErrExecutionReverted) {
+		return valcache.GasLimit{}, nil
+	}
+	if err != nil {
+		return valcache.GasLimit{}, err
+	}
+	if len(rbytes) == 0 {
+		return valcache.GasLimit{}, nil
+	}
+
+	var recv struct {
+		Target *big.Int
+		Max    *big.Int
+	}
+	if err := environmentGasLimit.UnpackIntoInterface(&recv, method, rbytes); err != nil {
+		return valcache.GasLimit{}, err
+	}
+	if !recv.Target.IsUint64() || !recv.Max.IsUint64() {
+		return valcache.GasLimit{}, fmt.Errorf("gas limit out of range, target: %v, max: %v", recv.Target, recv.Max)
+	}
+	return valcache.GasLimit{Target: recv.Target.Uint64(), Max: recv.Max.Uint64()}, nil
+}
+
+// gasLimit returns the block gas limit of the epoch of the header, read from the
+// Environment contract at the parent of the epoch block unless cached already.
+// If the state of the epoch block is no longer available, it is read at the
+// parent of the header instead, as the values of the contract don't change
+// within an epoch.
+func (c *Oasys) gasLimit(chain consensus.ChainHeaderReader, header *types.Header, env *params.EnvironmentValue) (valcache.GasLimit, error) {
+	number := header.Number.Uint64()
+	if number < c.config.Epoch {
+		return valcache.GasLimit{}, nil
+	}
+	epoch := env.Epoch(number)
+	parent, err := getPrevEpochLastBlockHash(c.config, chain, env, header)
+	if err != nil {
+		return valcache.GasLimit{}, err
+	}
+	if limit, ok := c.valcache.GasLimit(epoch, parent); ok {
+		return limit, nil
+	}
+	limit, err := c.contracts.getGasLimit(c.ethAPI, parent)
+	if err != nil && parent != header.ParentHash {
+		log.Debug("Reading gas limit at the parent", "number", number, "epoch", epoch, "err", err)
+		limit, err = c.contracts.getGasLimit(c.ethAPI, header.ParentHash)
+	}
+	if err != nil {
+		return valcache.GasLimit{}, err
+	}
+	c.valcache.StoreGasLimit(epoch, parent, limit)
+	return limit, nil
+}
+
+// verifyGasLimit checks that the gas limit of the header is within the maximum
+// of the network, allowing the blocks to move toward it if it has been lowered.
+func verifyGasLimit(header, parent *types.Header, limit valcache.GasLimit) error {
+	if limit.Max == 0 {
+		return nil
+	}
+	ceil := limit.Max
+	if parent.GasLimit > ceil {
+		ceil = core.CalcGasLimit(parent.GasLimit, limit.Max)
+	}
+	if header.GasLimit > ceil {
+		return fmt.Errorf("invalid gasLimit: have %d, max %d", header.GasLimit, ceil)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package oasys
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/oasys/valcache"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestGetGasLimit(t *testing.T) {
+	uint256Ty, _ := abi.NewType("uint256", "", nil)
+	arguments := abi.Arguments{{Type: uint256Ty}, {Type: uint256Ty}}
+	configured, _ := arguments.Pack(big.NewInt(30_000_000), big.NewInt(40_000_000))
+
+	// The contracts predating the gas limit return nothing
+	ethapi := &testBlockchainAPI{rbytes: map[common.Address][][]byte{environment.address: {configured, nil}}}
+	if have, err := newSystemContracts(nil).getGasLimit(ethapi, common.Hash{}); err != nil {
+		t.Fatalf("failed to get gas limit: %v", err)
+	} else if want := (valcache.GasLimit{Target: 30_000_000, Max: 40_000_000}); have != want {
+		t.Errorf("gas limit mismatch: have %+v, want %+v", have, want)
+	}
+	if have, err := newSystemContracts(nil).getGasLimit(ethapi, common.Hash{}); err != nil {
+		t.Fatalf("failed to get unset gas limit: %v", err)
+	} else if have != (valcache.GasLimit{}) {
+		t.Errorf("unset gas limit mismatch: have %+v", have)
+	}
+}
+
+func TestGasLimitOfEpoch(t *testing.T) {
+	var (
+		config      = &params.OasysConfig{Period: 15, Epoch: 10}
+		chainConfig = &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: config}
+		env         = params.InitialEnvironmentValue(config)
+		engine      = New(chainConfig, config, nil, nil)
+		chain       = newTestHeaderChain(chainConfig, &types.Header{Number: big.NewInt(0)})
+	)
+	for i := 1; i <= 25; i++ {
+		chain.insert(&types.Header{ParentHash: chain.CurrentHeader().Hash(), Number: big.NewInt(int64(i))})
+	}
+	// The limits of the epochs started by the blocks 10 and 20
+	var (
+		second = valcache.GasLimit{Target: 30_000_000, Max: 40_000_000}
+		third  = valcache.GasLimit{Target: 20_000_000, Max: 25_000_000}
+	)
+	engine.valcache.StoreGasLimit(2, chain.headers[9].Hash(), second)
+	engine.valcache.StoreGasLimit(3, chain.headers[19].Hash(), third)
+
+	tests := []struct {
+		number uint64
+		want   valcache.GasLimit
+	}{
+		{5, valcache.GasLimit{}}, // Before the first epoch block
+		{10, second},
+		{15, second},
+		{19, second},
+		{20, third},
+		{25, third},
+	}
+	for _, tt := range tests {
+		have, err := engine.gasLimit(chain, chain.headers[tt.number], env)
+		if err != nil {
+			t.Fatalf("block %d: failed to get gas limit: %v", tt.number, err)
+		}
+		if have != tt.want {
+			t.Errorf("block %d: gas limit mismatch: have %+v, want %+v", tt.number, have, tt.want)
+		}
+	}
+}
+
+func TestVerifyGasLimit(t *testing.T) {
+	limit := valcache.GasLimit{Target: 30_000_000, Max: 40_000_000}
+	tests := []struct {
+		parent, header uint64
+		limit          valcache.GasLimit
+		valid          bool
+	}{
+		{50_000_000, 60_000_000, valcache.GasLimit{}, true}, // No limit configured
+		{30_000_000, 40_000_000, limit, true},
+		{40_000_000, 40_000_001, limit, false},
+		{50_000_000, 49_951_172, limit, true}, // Moving toward the lowered maximum
+		{50_000_000, 50_000_000, limit, false},
+	}
+	for i, tt := range tests {
+		var (
+			parent = &types.Header{GasLimit: tt.parent}
+			header = &types.Header{GasLimit: tt.header}
+		)
+		if err := verifyGasLimit(header, parent, tt.limit); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch, err %v, want valid %v", i, err, tt.valid)
+		}
+	}
+}
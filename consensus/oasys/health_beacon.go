@@ -0,0 +1,150 @@
+package oasys
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	healthBeaconVersion = 0x01 // Version of the layout of the health beacon
+
+	healthVoteEnabled = 0x01 // Flag of the health beacon set if the validator votes
+)
+
+var (
+	// healthBeaconMagic prefixes the health beacon in the vanity of the extra data.
+	healthBeaconMagic = []byte("hb")
+
+	// healthBeaconLen is the length of the health beacon at the start of the
+	// vanity, which fits next to the proposal at its end.
+	healthBeaconLen = len(healthBeaconMagic) + 1 + 3 + 1
+)
+
+// maxFleetStatusRange is the maximum number of blocks scanned by a single
+// GetFleetStatus call.
+const maxFleetStatusRange = 10000
+
+// HealthStatus is the status of the node of the block proposer, embedded at the
+// start of the vanity of the extra data. The status is covered by the seal, so
+// it's signed by the validator.
+type HealthStatus struct {
+	Version     string `json:"version"` // Client version as major.minor.patch
+	VoteEnabled bool   `json:"voteEnabled"`
+}
+
+// encodeHealthBeacon writes the status at the start of the vanity of the header,
+// overwriting the head of the configured vanity.
+func encodeHealthBeacon(header *types.Header, voteEnabled bool) {
+	if len(header.Extra) < extraVanity {
+		return
+	}
+	b := header.Extra[:healthBeaconLen]
+	copy(b, healthBeaconMagic)
+	b[len(healthBeaconMagic)] = healthBeaconVersion
+	b[len(healthBeaconMagic)+1] = params.VersionMajor
+	b[len(healthBeaconMagic)+2] = params.VersionMinor
+	b[len(healthBeaconMagic)+3] = params.VersionPatch
+	b[healthBeaconLen-1] = 0
+	if voteEnabled {
+		b[healthBeaconLen-1] |= healthVoteEnabled
+	}
+}
+
+// decodeHealthBeacon returns the status embedded in the vanity of the header, or
+// nil if there is none or its layout is unknown.
+func decodeHealthBeacon(header *types.Header) *HealthStatus {
+	if len(header.Extra) < extraVanity {
+		return nil
+	}
+	b := header.Extra[:healthBeaconLen]
+	if !bytes.HasPrefix(b, healthBeaconMagic) || b[len(healthBeaconMagic)] != healthBeaconVersion {
+		return nil
+	}
+	return &HealthStatus{
+		Version:     fmt.Sprintf("%d.%d.%d", b[len(healthBeaconMagic)+1], b[len(healthBeaconMagic)+2], b[len(healthBeaconMagic)+3]),
+		VoteEnabled: b[healthBeaconLen-1]&healthVoteEnabled != 0,
+	}
+}
+
+// SetHealthBeacon enables or disables the health beacon in the blocks sealed by
+// the engine.
+func (c *Oasys) SetHealthBeacon(enabled, voteEnabled bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.healthBeacon, c.healthVoteEnabled = enabled, voteEnabled
+}
+
+// signalHealth embeds the health beacon into the header if enabled.
+func (c *Oasys) signalHealth(header *types.Header) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.healthBeacon {
+		encodeHealthBeacon(header, c.healthVoteEnabled)
+	}
+}
+
+// ValidatorHealth is the latest health beacon of a block proposer.
+type ValidatorHealth struct {
+	Number uint64 `json:"number"` // Latest block of the proposer carrying a beacon
+	HealthStatus
+}
+
+// FleetStatus is the health beacons of the block proposers in a range of blocks.
+type FleetStatus struct {
+	From       uint64                              `json:"from"`
+	To         uint64                              `json:"to"`
+	Validators map[common.Address]*ValidatorHealth `json:"validators"`
+	Versions   map[string]int                      `json:"versions"` // Number of proposers by client version
+	Unknown    []common.Address                    `json:"unknown"`  // Proposers without any beacon in the range
+}
+
+// GetFleetStatus decodes the health beacons of the block proposers in the blocks
+// from `from` to `to`, keeping the latest one of every proposer.
+func (api *API) GetFleetStatus(from, to rpc.BlockNumber) (*FleetStatus, error) {
+	start, end := api.resolveNumber(from), api.resolveNumber(to)
+	if start > end {
+		return nil, fmt.Errorf("invalid block range, from: %d, to: %d", start, end)
+	}
+	if end-start >= maxFleetStatusRange {
+		return nil, fmt.Errorf("block range too large, max: %d", maxFleetStatusRange)
+	}
+
+	result := &FleetStatus{
+		From:       start,
+		To:         end,
+		Validators: make(map[common.Address]*ValidatorHealth),
+		Versions:   make(map[string]int),
+		Unknown:    make([]common.Address, 0),
+	}
+	// Walk the range backwards to decode the latest block of every proposer
+	proposers := make(map[common.Address]bool)
+	for number := end; number >= start && number > 0; number-- {
+		header := api.chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		if proposers[header.Coinbase] {
+			continue
+		}
+		proposers[header.Coinbase] = true
+		if status := decodeHealthBeacon(header); status != nil {
+			result.Validators[header.Coinbase] = &ValidatorHealth{Number: number, HealthStatus: *status}
+			result.Versions[status.Version]++
+		}
+	}
+	for proposer := range proposers {
+		if _, ok := result.Validators[proposer]; !ok {
+			result.Unknown = append(result.Unknown, proposer)
+		}
+	}
+	slices.SortFunc(result.Unknown, func(a, b common.Address) int { return a.Cmp(b) })
+	return result, nil
+}
@@ -0,0 +1,11 @@
+package oasys
+
+import (
+	"github.com/ethereum/go-ethereum/core/tracing"
+)
+
+// SetHooks sets the consensus tracer hooks invoked on the consensus events, replacing
+// the ones set before. The nil hooks disable the tracing.
+func (c *Oasys) SetHooks(hooks *tracing.ConsensusHooks) {
+	c.hooks.Store(hooks)
+}
@@ -0,0 +1,49 @@
+package oasys
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceSnapshot(t *testing.T) {
+	var (
+		env = &params.EnvironmentValue{
+			StartBlock:  common.Big0,
+			StartEpoch:  common.Big1,
+			EpochPeriod: big.NewInt(10),
+		}
+		addr1 = common.HexToAddress("0x01")
+		addr2 = common.HexToAddress("0x02")
+		prev  = &Snapshot{Number: 8, Environment: env, Validators: map[common.Address]*ValidatorInfo{addr1: {}}}
+		next  = &Snapshot{Number: 10, Environment: env, Validators: map[common.Address]*ValidatorInfo{addr1: {}, addr2: {}}}
+	)
+
+	var applied, epochs, changes int
+	engine := &Oasys{}
+	engine.SetHooks(&tracing.ConsensusHooks{
+		OnSnapshotApplied: func(number uint64, hash common.Hash, validators []common.Address) {
+			applied++
+		},
+		OnEpochTransition: func(epoch uint64, number uint64, env *params.EnvironmentValue) {
+			epochs++
+			require.Equal(t, uint64(2), epoch)
+		},
+		OnValidatorSetChange: func(number uint64, prev, next []common.Address) {
+			changes++
+			require.Equal(t, []common.Address{addr1}, prev)
+			require.Equal(t, []common.Address{addr1, addr2}, next)
+		},
+	})
+
+	engine.traceSnapshot(prev, next)
+	require.Equal(t, []int{1, 1, 1}, []int{applied, epochs, changes})
+
+	// No transition within the same epoch and validators
+	engine.traceSnapshot(next, &Snapshot{Number: 11, Environment: env, Validators: next.Validators})
+	require.Equal(t, []int{2, 1, 1}, []int{applied, epochs, changes})
+}
@@ -0,0 +1,101 @@
+// forkcapture captures the validators and the first blocks of an epoch from a
+// node running on a live network, as the vector the binaries are checked
+// against before they follow a fork. The node must serve the oasys namespace
+// and keep the state of the parent of the epoch block.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func main() {
+	var (
+		endpoint = flag.String("rpc", "", "RPC endpoint of the node on the network")
+		start    = flag.Uint64("start", 0, "epoch block to capture from")
+		blocks   = flag.Uint64("blocks", 64, "number of the blocks to capture")
+		out      = flag.String("out", "", "output file (default stdout)")
+	)
+	flag.Parse()
+	if *endpoint == "" || *start == 0 || *blocks == 0 {
+		fatalf("usage: forkcapture -rpc <endpoint> -start <epoch block> [-blocks <count>] [-out <file>]")
+	}
+
+	ctx := context.Background()
+	client, err := rpc.DialContext(ctx, *endpoint)
+	if err != nil {
+		fatalf("failed to dial %s: %v", *endpoint, err)
+	}
+	defer client.Close()
+	eth := ethclient.NewClient(client)
+
+	number := rpc.BlockNumber(*start)
+	var snap struct {
+		Environment *params.EnvironmentValue `json:"environment"`
+	}
+	if err := client.CallContext(ctx, &snap, "oasys_getSnapshot", number); err != nil {
+		fatalf("failed to get snapshot at %d: %v", *start, err)
+	}
+	if snap.Environment == nil || snap.Environment.GetFirstBlock(*start) != *start {
+		fatalf("block %d is not an epoch block", *start)
+	}
+	var schedule oasys.ValidatorSchedule
+	if err := client.CallContext(ctx, &schedule, "oasys_getValidatorSchedule", number); err != nil {
+		fatalf("failed to get validators at %d: %v", *start, err)
+	}
+	parent, err := eth.HeaderByNumber(ctx, new(big.Int).SetUint64(*start-1))
+	if err != nil {
+		fatalf("failed to get header %d: %v", *start-1, err)
+	}
+
+	vector := &oasys.ForkVector{
+		Environment: snap.Environment,
+		SeedHash:    parent.Hash(),
+	}
+	for _, v := range schedule.Validators {
+		vector.Validators = append(vector.Validators, oasys.ScheduleValidator{Address: v.Operator, Stake: v.Stake})
+	}
+	for n := *start; n < *start+*blocks; n++ {
+		header, err := eth.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			fatalf("failed to get header %d: %v", n, err)
+		}
+		if header.ParentHash != parent.Hash() {
+			fatalf("header %d does not follow its parent, reorged while capturing", n)
+		}
+		vector.Blocks = append(vector.Blocks, &oasys.ForkVectorBlock{
+			Number:     n,
+			ParentTime: parent.Time,
+			Time:       header.Time,
+			Coinbase:   header.Coinbase,
+		})
+		parent = header
+	}
+
+	data, err := json.MarshalIndent(vector, "", "  ")
+	if err != nil {
+		fatalf("failed to encode vector: %v", err)
+	}
+	data = append(data, '\n')
+	if *out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		fatalf("failed to write vector: %v", err)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
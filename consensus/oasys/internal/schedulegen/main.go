@@ -0,0 +1,72 @@
+// schedulegen generates the canonical test vectors of the Oasys proposer
+// selection. The vectors are checked by the tests of the consensus engine, so
+// they must be regenerated whenever the selection algorithm changes.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var ether = big.NewInt(1e18)
+
+type testCase struct {
+	stakes []int64 // Stakes of the validators in ether
+	period uint64
+}
+
+var cases = []testCase{
+	{[]int64{10_000_000}, 40},
+	{[]int64{10_000_000, 10_000_000, 10_000_000, 10_000_000}, 40},
+	{[]int64{50_000_000, 20_000_000, 10_000_000, 1_000_000}, 40},
+	{[]int64{10_000_000, 10_000_000, 12_345_678, 30_000_000, 10_000_001, 99_999_999, 10_000_000}, 100},
+	{[]int64{1, 10_000_000, 10_000_000}, 60},
+}
+
+func main() {
+	out := flag.String("out", "", "output file (default stdout)")
+	flag.Parse()
+
+	var vectors []*oasys.ScheduleVector
+	for i, c := range cases {
+		validators := make([]oasys.ScheduleValidator, len(c.stakes))
+		for j, stake := range c.stakes {
+			validators[j] = oasys.ScheduleValidator{
+				Address: common.BytesToAddress(crypto.Keccak256([]byte(fmt.Sprintf("validator-%d-%d", i, j)))),
+				Stake:   (*hexutil.Big)(new(big.Int).Mul(big.NewInt(stake), ether)),
+			}
+		}
+		seedHash := crypto.Keccak256Hash([]byte(fmt.Sprintf("seed-%d", i)))
+		vector, err := oasys.GenerateScheduleVector(seedHash, validators, c.period)
+		if err != nil {
+			fatalf("failed to generate vector %d: %v", i, err)
+		}
+		vectors = append(vectors, vector)
+	}
+
+	data, err := json.MarshalIndent(vectors, "", "  ")
+	if err != nil {
+		fatalf("failed to encode vectors: %v", err)
+	}
+	data = append(data, '\n')
+	if *out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		fatalf("failed to write vectors: %v", err)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,84 @@
+package oasys
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// NextEpochValidators is the validator set of the next epoch projected from the
+// stakes at the current head, compared with the validators of the current epoch.
+// The projection holds unless the stakes change before the last block of the
+// current epoch.
+type NextEpochValidators struct {
+	*ValidatorSchedule
+
+	Number             uint64                `json:"number"`             // Head block the projection is made at
+	ValidatorThreshold *hexutil.Big          `json:"validatorThreshold"` // Stake required to become a validator in the next epoch
+	Joining            []*ScheduledValidator `json:"joining"`            // Validators not in the current epoch
+	Leaving            []*ScheduledValidator `json:"leaving"`            // Validators of the current epoch not in the next one, with the current stakes
+}
+
+// GetNextEpochValidators projects the validator set of the next epoch by running
+// the contract calls of the engine at the next epoch block against the state of
+// the current head, so that the validators know in advance whether they will be
+// scheduled.
+func (api *API) GetNextEpochValidators() (*NextEpochValidators, error) {
+	header := api.chain.CurrentHeader()
+	number := header.Number.Uint64()
+	snap, err := api.oasys.snapshot(api.chain, number, header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The environment value scheduled on the contract may take effect at the
+	// next epoch, changing the validator threshold.
+	var (
+		epoch = snap.Environment.Epoch(number) + 1
+		start = snap.Environment.EpochStartBlock(epoch)
+	)
+	next, err := api.oasys.contracts.getNextEnvironmentValue(api.oasys.ethAPI, header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	schedule, kept, err := api.oasys.validatorSchedule(header.Hash(), epoch, start)
+	if err != nil {
+		return nil, err
+	}
+	return projectNextEpochValidators(snap, number, next, schedule, kept), nil
+}
+
+// projectNextEpochValidators compares the validators kept for the next epoch
+// with the ones of the snapshot at the given head, taking the validator threshold
+// from the next environment value if it takes effect at the next epoch.
+func projectNextEpochValidators(snap *Snapshot, number uint64, next *params.EnvironmentValue, schedule *ValidatorSchedule, kept *nextValidators) *NextEpochValidators {
+	env := snap.Environment
+	if epoch := env.Epoch(number) + 1; env.ShouldUpdate(epoch, next) {
+		env = next
+	}
+	result := &NextEpochValidators{
+		ValidatorSchedule:  schedule,
+		Number:             number,
+		ValidatorThreshold: (*hexutil.Big)(new(big.Int).Set(env.ValidatorThreshold)),
+		Joining:            make([]*ScheduledValidator, 0),
+		Leaving:            make([]*ScheduledValidator, 0),
+	}
+	scheduled := make(map[common.Address]bool, len(kept.Operators))
+	for i, operator := range kept.Operators {
+		scheduled[operator] = true
+		if !snap.exists(operator) {
+			result.Joining = append(result.Joining, schedule.Validators[i])
+		}
+	}
+	for _, operator := range snap.validators() {
+		if !scheduled[operator] {
+			result.Leaving = append(result.Leaving, &ScheduledValidator{
+				Operator: operator,
+				Stake:    (*hexutil.Big)(new(big.Int).Set(snap.Validators[operator].Stake)),
+			})
+		}
+	}
+	return result
+}
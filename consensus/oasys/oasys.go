@@ -7,8 +7,10 @@ import (
 	"io"
 	"math"
 	"math/big"
+	"slices"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
@@ -17,7 +19,10 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/consensus/oasys/valcache"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -49,6 +54,15 @@ const (
 	validatorInfoBytesLen = addressBytesLen*2 + stakeBytesLen + types.BLSPublicKeyLength
 	validatorNumberSize   = 1 // Fixed number of extra prefix bytes reserved for validator number
 
+	// The extra data of the headers is capped before parsing it, by the size of
+	// the epoch extra data with the maximum validators in the larger compact
+	// layout and the largest attestation. The RLP of a compact validator is the
+	// list header and the four prefixed items.
+	maxExtraValidators      = 1000
+	compactValidatorLen     = 3 + 2*(1+addressBytesLen) + (1 + stakeBytesLen) + (1 + types.BLSPublicKeyLength)
+	maxExtraAttestationSize = 1024
+	defaultMaxExtraSize     = extraVanity + envValuesLen + 9 + maxExtraValidators*compactValidatorLen + maxExtraAttestationSize + extraSeal
+
 	backoffWiggleTime = uint64(1) // second
 )
 
@@ -68,6 +82,17 @@ var (
 	verifyVoteAttestationErrorCounter = metrics.NewRegisteredCounter("oasys/verifyVoteAttestation/error", nil)
 	updateAttestationErrorCounter     = metrics.NewRegisteredCounter("oasys/updateAttestation/error", nil)
 	validVotesfromSelfCounter         = metrics.NewRegisteredCounter("oasys/VerifyVote/self", nil)
+
+	// Time from the target block timestamp to the inclusion of its votes in an attestation
+	attestationDelayTimer = metrics.NewRegisteredTimer("oasys/attestation/delay", nil)
+
+	// State of the latest snapshot
+	epochGauge            = metrics.NewRegisteredGauge("oasys/epoch", nil)
+	justifiedLagGauge     = metrics.NewRegisteredGauge("oasys/finality/justifiedlag", nil)
+	finalizedLagGauge     = metrics.NewRegisteredGauge("oasys/finality/finalizedlag", nil)
+	attestationPowerGauge = metrics.NewRegisteredGauge("oasys/attestation/power", nil) // Percentage of the stake voted
+	validatorsGauge       = metrics.NewRegisteredGauge("oasys/validators", nil)
+	stakeRankGauge        = metrics.NewRegisteredGauge("oasys/validators/rank", nil) // Zero if not a validator
 )
 
 // Various error messages to mark blocks invalid. These should be private to
@@ -87,6 +112,10 @@ var (
 	// to contain a 65 byte secp256k1 signature.
 	errMissingSignature = errors.New("extra-data 65 byte signature suffix missing")
 
+	// errExtraTooLarge is returned if a block's extra-data section exceeds the
+	// maximum size of the chain.
+	errExtraTooLarge = errors.New("extra-data too large")
+
 	// errExtraSigners is returned if non-checkpoint block contain signer data in
 	// their extra-data fields.
 	errExtraSigners = errors.New("non-checkpoint block contains extra signer list")
@@ -131,6 +160,30 @@ var (
 
 	// errNoEnvironmentValue is returned if the extra data does not contain the environment value
 	errNoEnvironmentValue = errors.New("no environment value in the extra data")
+
+	// errMissingValidators is returned if the extra data of an epoch block is too
+	// short to contain the validators it declares.
+	errMissingValidators = errors.New("missing validator info in the extra data")
+
+	// errInvalidValidators is returned if the validators in the extra data of an
+	// epoch block are malformed.
+	errInvalidValidators = errors.New("invalid validator info in the extra data")
+
+	// errInvalidAttestationData is returned if the vote attestation in the extra
+	// data can't be decoded.
+	errInvalidAttestationData = errors.New("invalid vote attestation in the extra data")
+
+	// errUnexpectedVoteAttestation is returned if the vote attestation header
+	// field is set before the attestation header fork or without Cancun fields.
+	errUnexpectedVoteAttestation = errors.New("unexpected vote attestation in the header")
+
+	// errReadOnly is returned if a block is attempted to be sealed by the
+	// read-only engine.
+	errReadOnly = errors.New("read-only engine")
+
+	// errSignerChanged is returned if a block is attempted to be sealed by the
+	// signer other than the one the block was prepared for.
+	errSignerChanged = errors.New("signer changed since the block was prepared")
 )
 
 var (
@@ -149,11 +202,11 @@ func init() {
 }
 
 // ecrecover extracts the Ethereum account address from a signed header.
-func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, error) {
+func ecrecover(header *types.Header, sigcache *sigCache) (common.Address, error) {
 	// If the signature's already cached, return that
 	hash := header.Hash()
-	if address, known := sigcache.Get(hash); known {
-		return address.(common.Address), nil
+	if address, known := sigcache.get(hash); known {
+		return address, nil
 	}
 	// Retrieve the signature from the header extra-data
 	if len(header.Extra) < extraSeal {
@@ -169,7 +222,7 @@ func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, er
 	var signer common.Address
 	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
 
-	sigcache.Add(hash, signer)
+	sigcache.add(hash, signer)
 	return signer, nil
 }
 
@@ -179,22 +232,60 @@ type Oasys struct {
 	config      *params.OasysConfig // Consensus engine configuration parameters
 	db          ethdb.Database      // Database to store and retrieve snapshot checkpoints
 
-	recents    *lru.ARCCache // Snapshots for recent block to speed up reorgs
-	signatures *lru.ARCCache // Signatures of recent blocks to speed up mining
+	recents    atomic.Pointer[lru.ARCCache] // Snapshots for recent block to speed up reorgs
+	signatures *sigCache                    // Signatures of recent blocks to speed up mining
+
+	proposals map[common.Address]bool // Current list of proposals we are signaling
+
+	healthBeacon      bool // Whether the health beacon is embedded in the sealed blocks, protected by lock
+	healthVoteEnabled bool // Vote-enabled flag of the health beacon, protected by lock
 
-	proposals map[common.Address]bool // Current list of proposals we are pushing
+	signerState *signerState // Signing identity of the engine, protected by lock
+	lock        sync.RWMutex // Protects the signer fields
 
-	signer common.Address // Ethereum address of the signing key
-	signFn SignerFn       // Signer function to authorize hashes with
-	lock   sync.RWMutex   // Protects the signer fields
+	ethAPI    *ethapi.BlockChainAPI
+	contracts *systemContracts   // System contracts of the network, at the addresses configured in the genesis
+	votePool  consensus.VotePool // Votes assembled into the sealed blocks, nil if verification-only, protected by lock
+	txSigner  types.Signer
 
-	ethAPI   *ethapi.BlockChainAPI
-	VotePool consensus.VotePool
-	txSigner types.Signer
-	txSignFn TxSignerFn
+	wiggleTime    uint64                                 // Seconds added to the back-off time of out-of-turn validators
+	maxExtraSize  uint64                                 // Maximum size of the extra data of the headers
+	hooks         atomic.Pointer[tracing.ConsensusHooks] // Callbacks invoked on the consensus events, nil if not traced
+	voteAddresses *pendingVoteAddresses                  // Vote address statuses at the latest block checked, protected by lock
+	readOnly      bool                                   // Whether the signer and the vote machinery are disabled, protected by lock
+
+	systemTxFailures *systemTxFailures // System transaction failures of the latest block assembled, protected by lock
+	epochEvents      *epochEvents      // Epoch lifecycle events sent to the subscribers
+	valcache         *valcache.Cache   // Validators, environment values and gas limits read from the contracts at the epoch blocks
+	finalityHeads    *finalityHeads    // Finalized blocks sent to the subscribers of the finality roots
+	badAttestations  *badAttestations  // Diagnostics of the attestations recently rejected for the vote address set
+	voteArrivals     *voteArrivals     // Local arrival times of the votes entering the vote pool
+	systemNonces     systemNonces      // Nonces of the validator accounts used by the blocks sealed last
+
+	// Maximum time to postpone sealing for the votes close to the quorum to
+	// reach it, zero disables the wait
+	attestationWait atomic.Int64
+
+	syncUnverified atomic.Bool // Whether the state downloaded by the snap sync is yet to pass the verification
+
+	sealGuard     ethdb.KeyValueStore // Slashing protection of the block seals, nil if disabled, protected by lock
+	sealGuardLock sync.Mutex          // Serializes the checks and the records of the seal guard
 
 	// The fields below are for testing only
-	fakeDiff bool // Skip difficulty verifications
+	fakeDiff atomic.Bool // Skip difficulty verifications
+}
+
+// signerState is the signing identity of the engine. Authorize replaces it as a
+// whole, so that the address and the signing functions are always read from the
+// same authorization, and the sealing in progress notices the replacement by the
+// version.
+type signerState struct {
+	version  uint64
+	signer   common.Address // Ethereum address of the signing key
+	signFn   SignerFn       // Signer function to authorize hashes with
+	txSignFn TxSignerFn     // Signer function to authorize system transactions with
+
+	extra []*signerState // Additional validators sealed for on the test networks, see AddSigner
 }
 
 // New creates a Oasys proof-of-stake consensus engine with the initial
@@ -205,20 +296,38 @@ func New(chainConfig *params.ChainConfig, config *params.OasysConfig, db ethdb.D
 	if conf.Epoch == 0 {
 		conf.Epoch = epochLength
 	}
+	wiggleTime := backoffWiggleTime
+	if conf.BackoffWiggleTime != nil {
+		wiggleTime = *conf.BackoffWiggleTime
+	}
+	maxExtraSize := uint64(defaultMaxExtraSize)
+	if conf.MaxExtraSize != nil {
+		maxExtraSize = *conf.MaxExtraSize
+	}
 	// Allocate the snapshot caches and create the engine
 	recents, _ := lru.NewARC(inmemorySnapshots)
-	signatures, _ := lru.NewARC(inmemorySignatures)
-
-	return &Oasys{
-		chainConfig: chainConfig,
-		config:      &conf,
-		db:          db,
-		recents:     recents,
-		signatures:  signatures,
-		proposals:   make(map[common.Address]bool),
-		ethAPI:      ethAPI,
-		txSigner:    types.LatestSigner(chainConfig),
-	}
+	signatures, _ := newSigCache(inmemorySignatures)
+
+	c := &Oasys{
+		chainConfig:     chainConfig,
+		config:          &conf,
+		db:              db,
+		proposals:       make(map[common.Address]bool),
+		signerState:     new(signerState),
+		signatures:      signatures,
+		ethAPI:          ethAPI,
+		contracts:       newSystemContracts(conf.Contracts),
+		txSigner:        types.LatestSigner(chainConfig),
+		wiggleTime:      wiggleTime,
+		maxExtraSize:    maxExtraSize,
+		epochEvents:     new(epochEvents),
+		finalityHeads:   new(finalityHeads),
+		badAttestations: new(badAttestations),
+		voteArrivals:    newVoteArrivals(),
+		valcache:        valcache.New(db),
+	}
+	c.recents.Store(recents)
+	return c
 }
 
 // Author implements consensus.Engine, returning the Ethereum address recovered
@@ -229,7 +338,7 @@ func (c *Oasys) Author(header *types.Header) (common.Address, error) {
 
 // VerifyHeader checks whether a header conforms to the consensus rules.
 func (c *Oasys) VerifyHeader(chain consensus.ChainHeaderReader, header *types.Header) error {
-	return c.verifyHeader(chain, header, nil)
+	return c.verifyHeader(chain, header, nil, nil)
 }
 
 // VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers. The
@@ -239,16 +348,42 @@ func (c *Oasys) VerifyHeaders(chain consensus.ChainHeaderReader, headers []*type
 	abort := make(chan struct{})
 	results := make(chan error, len(headers))
 
+	// Keep the signers recovered from the batch until the snapshots are applied
+	c.signatures.fit(len(headers))
+
 	go func() {
+		// The attestation signatures of multiple headers are verified at once,
+		// holding back the results until the signatures of the batch are verified.
+		var (
+			batch *attestationBatch
+			held  []error
+		)
+		if len(headers) > 1 {
+			batch = new(attestationBatch)
+		}
 		for i, header := range headers {
-			uncommittedHashes.Add(header.Hash(), header.ParentHash)
-			err := c.verifyHeader(chain, header, headers[:i])
+			uncommittedHashes.Add(header.Hash(), numberedHash{header.ParentHash, header.Number.Uint64()})
+			held = append(held, c.verifyHeader(chain, header, headers[:i], batch))
 
-			select {
-			case <-abort:
-				return
-			case results <- err:
+			if batch != nil && len(held) < attestationBatchSize && i < len(headers)-1 {
+				continue
+			}
+			if batch != nil {
+				offset := i + 1 - len(held)
+				for index, err := range batch.verify(c) {
+					if held[index-offset] == nil {
+						held[index-offset] = err
+					}
+				}
 			}
+			for _, err := range held {
+				select {
+				case <-abort:
+					return
+				case results <- err:
+				}
+			}
+			held = held[:0]
 		}
 	}()
 	return abort, results
@@ -258,14 +393,17 @@ func (c *Oasys) VerifyHeaders(chain consensus.ChainHeaderReader, headers []*type
 // caller may optionally pass in a batch of parents (ascending order) to avoid
 // looking those up from the database. This is useful for concurrently verifying
 // a batch of new headers.
-func (c *Oasys) verifyHeader(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header) error {
+//
+// If a batch is given, the signature of the vote attestation is deferred to be
+// verified together with the other headers of the batch.
+func (c *Oasys) verifyHeader(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, batch *attestationBatch) error {
 	if header.Number == nil {
 		return errUnknownBlock
 	}
 	number := header.Number.Uint64()
 
 	// Don't waste time checking blocks from the future
-	if header.Time > uint64(time.Now().Unix()) {
+	if headerMilliTime(header) > uint64(time.Now().UnixMilli()) {
 		return consensus.ErrFutureBlock
 	}
 	// Check that the extra-data contains both the validators and signature
@@ -275,15 +413,26 @@ func (c *Oasys) verifyHeader(chain consensus.ChainHeaderReader, header *types.He
 	if len(header.Extra) < extraVanity+extraSeal {
 		return errMissingSignature
 	}
+	// Reject the oversized extra-data before retrieving the snapshot and parsing it.
+	// The default size is above any extra-data of the legacy layouts, which hold at
+	// most 255 validators, so it bounds the whole chain, and the configured one only
+	// applies since the fork.
+	maxExtraSize := uint64(defaultMaxExtraSize)
+	if c.chainConfig.IsOasysExtraSizeLimit(header.Number) {
+		maxExtraSize = c.maxExtraSize
+	}
+	if uint64(len(header.Extra)) > maxExtraSize {
+		return fmt.Errorf("%w: have %d, max %d", errExtraTooLarge, len(header.Extra), maxExtraSize)
+	}
 	// Apply parent headers to the snapshot, the snapshot updates is only processed here.
 	snap, err := c.snapshot(chain, number-1, header.ParentHash, parents)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve snapshot, in: verifyHeader, blockNumber: %d, parentHash: %x, err: %v", number, header.ParentHash, err)
+		return newError(ErrSnapshotUnavailable, "verifyHeader", number, err)
 	}
 	// Get the environment value from snapshot except the block is epoch block
 	env, err := c.environment(chain, header, snap, true)
 	if err != nil {
-		return fmt.Errorf("failed to get environment value, in: verifyHeader, err: %v", err)
+		return newError(ErrEnvironmentUnavailable, "verifyHeader", number, err)
 	}
 	// Ensure that the extra-data contains extra data aside from the vanity and seal
 	extraLenExceptVanityAndSeal := len(header.Extra) - extraVanity - extraSeal
@@ -293,10 +442,17 @@ func (c *Oasys) verifyHeader(chain consensus.ChainHeaderReader, header *types.He
 		}
 	} else if !c.chainConfig.IsFastFinalityEnabled(header.Number) && extraLenExceptVanityAndSeal != 0 {
 		return errExtraSigners
+	} else if c.chainConfig.IsOasysAttestationHeader(header.Number) && extraLenExceptVanityAndSeal != 0 {
+		// The attestation is no longer packed in the extra-data
+		return errExtraSigners
 	}
-	// Ensure that the mix digest is zero as we don't have fork protection currently
-	if header.MixDigest != (common.Hash{}) {
-		return errInvalidMixDigest
+	// Ensure that the vote attestation header field is only set since the fork
+	if header.VoteAttestation != nil && (!c.chainConfig.IsOasysAttestationHeader(header.Number) || header.ParentBeaconRoot == nil) {
+		return errUnexpectedVoteAttestation
+	}
+	// Ensure that the mix digest is zero, or only carries the millisecond timestamp
+	if err := c.verifyMixDigest(header); err != nil {
+		return err
 	}
 	// Ensure that the block doesn't contain any uncles which are meaningless in PoS
 	if header.UncleHash != uncleHash {
@@ -356,14 +512,14 @@ func (c *Oasys) verifyHeader(chain consensus.ChainHeaderReader, header *types.He
 	}
 
 	// All basic checks passed, verify cascading fields
-	return c.verifyCascadingFields(chain, header, parents, snap, env)
+	return c.verifyCascadingFields(chain, header, parents, snap, env, batch)
 }
 
 // verifyCascadingFields verifies all the header fields that are not standalone,
 // rather depend on a batch of previous headers. The caller may optionally pass
 // in a batch of parents (ascending order) to avoid looking those up from the
 // database. This is useful for concurrently verifying a batch of new headers.
-func (c *Oasys) verifyCascadingFields(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, snap *Snapshot, env *params.EnvironmentValue) error {
+func (c *Oasys) verifyCascadingFields(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, snap *Snapshot, env *params.EnvironmentValue, batch *attestationBatch) error {
 	// The genesis block is the always valid dead-end
 	number := header.Number.Uint64()
 	if number == 0 {
@@ -384,16 +540,22 @@ func (c *Oasys) verifyCascadingFields(chain consensus.ChainHeaderReader, header
 	// Get the validators from snapshot except the block is epoch block
 	validators, err := c.getNextValidators(chain, header, snap, true)
 	if err != nil {
-		return fmt.Errorf("failed to get validators, in: verifyCascadingFields, err: %v", err)
+		return newError(ErrValidatorsUnavailable, "verifyCascadingFields", number, err).withEpoch(env.Epoch(number))
 	}
 	// Ensure that the block's timestamp is older than the scheduled validator backoff time
 	scheduler, err := c.scheduler(chain, header, env, validators.Operators, validators.Stakes)
 	if err != nil {
-		return fmt.Errorf("failed to get scheduler. blockNumber: %d, in: verifyCascadingFields, err: %v", number, err)
+		return newError(ErrSchedulerUnavailable, "verifyCascadingFields", number, err).withEpoch(env.Epoch(number))
 	}
-	if header.Time < parent.Time+env.BlockPeriod.Uint64()+scheduler.backOffTime(number, header.Coinbase) {
+	if headerMilliTime(header) < headerMilliTime(parent)+c.blockMilliPeriod(header.Number, env)+c.backOffMilliTime(header.Number, env, scheduler, header.Coinbase) {
 		return consensus.ErrFutureBlock
 	}
+	// The blocks with the slash transaction only are checked along with the body
+	if header.TxHash == types.EmptyTxsHash {
+		if err := c.verifyHeartbeat(header, parent, env, scheduler); err != nil {
+			return err
+		}
+	}
 
 	// Verify that the gasUsed is <= gasLimit
 	if header.GasUsed > header.GasLimit {
@@ -401,17 +563,41 @@ func (c *Oasys) verifyCascadingFields(chain consensus.ChainHeaderReader, header
 	}
 
 	// Verify vote attestation for fast finality.
-	if err := c.verifyVoteAttestation(chain, header, parents, env); err != nil {
-		log.Warn("Verify vote attestation failed", "error", err, "hash", header.Hash(), "number", header.Number,
-			"parent", header.ParentHash, "coinbase", header.Coinbase, "extra", common.Bytes2Hex(header.Extra))
-		verifyVoteAttestationErrorCounter.Inc(1)
-		if chain.Config().IsFastFinalityEnabled(header.Number) {
+	if err := c.verifyVoteAttestation(chain, header, parents, env, batch); err != nil {
+		if err := c.attestationError(header, env.Epoch(number), err); err != nil {
 			return err
 		}
 	}
 
 	// All basic checks passed, verify the seal and return
-	return c.verifySeal(chain, header, parents, scheduler)
+	if err := c.verifySeal(chain, header, parents, scheduler); err != nil {
+		return newError(ErrSealMismatch, "verifySeal", number, err).withEpoch(env.Epoch(number)).withValidator(header.Coinbase)
+	}
+	return nil
+}
+
+// attestationError reports the failed verification of the vote attestation in
+// the header, which only invalidates the header since fast finality.
+func (c *Oasys) attestationError(header *types.Header, epoch uint64, err error) error {
+	verifyVoteAttestationErrorCounter.Inc(1)
+	if c.chainConfig.IsFastFinalityEnabled(header.Number) {
+		log.Warn("Verify vote attestation failed", "error", err, "hash", header.Hash(), "number", header.Number,
+			"parent", header.ParentHash, "coinbase", header.Coinbase, "extra", common.Bytes2Hex(header.Extra))
+		var bad *badAttestationError
+		if errors.As(err, &bad) {
+			c.badAttestations.add(bad.diag)
+		}
+		return newError(ErrInvalidAttestation, "verifyCascadingFields", header.Number.Uint64(), err).withEpoch(epoch).withValidator(header.Coinbase)
+	}
+	// The legacy attestations are tolerated, not worth a warning on the nodes
+	// which don't take part in the votes
+	logFn := log.Warn
+	if c.VerifyOnly() {
+		logFn = log.Debug
+	}
+	logFn("Verify vote attestation failed", "error", err, "hash", header.Hash(), "number", header.Number,
+		"parent", header.ParentHash, "coinbase", header.Coinbase, "extra", common.Bytes2Hex(header.Extra))
+	return nil
 }
 
 // getParent returns the parent of a given block.
@@ -431,7 +617,9 @@ func (o *Oasys) getParent(chain consensus.ChainHeaderReader, header *types.Heade
 }
 
 // verifyVoteAttestation checks whether the vote attestation in the header is valid.
-func (o *Oasys) verifyVoteAttestation(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, env *params.EnvironmentValue) error {
+// If a batch is given, the aggregated signature is added to it to be verified
+// later, with the header indexed by the number of the parents given.
+func (o *Oasys) verifyVoteAttestation(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header, env *params.EnvironmentValue, batch *attestationBatch) error {
 	attestation, err := getVoteAttestationFromHeader(header, o.chainConfig, o.config, env.IsEpoch(header.Number.Uint64()))
 	if err != nil {
 		return err
@@ -486,10 +674,27 @@ func (o *Oasys) verifyVoteAttestation(chain consensus.ChainHeaderReader, header
 		return fmt.Errorf("failed to get validators, in: verifyVoteAttestation, err: %v", err)
 	}
 
+	// The voted validators beyond the first 64 bits are marked since the large vote
+	// set fork only, and the extended bitset must be encoded canonically, without
+	// any voter beyond the validators.
+	if ext := attestation.VoteAddressSetExt; len(ext) > 0 {
+		if !o.chainConfig.IsOasysLargeVoteSet(header.Number) {
+			return errors.New("invalid attestation, extended vote address set before the large vote set fork")
+		}
+		if ext[len(ext)-1] == 0 {
+			return errors.New("invalid attestation, extended vote address set with trailing zero word")
+		}
+	}
+	if o.chainConfig.IsOasysLargeVoteSet(header.Number) && hasVoterBeyond(attestation, len(validators.Operators)) {
+		reason := fmt.Sprintf("voter beyond validators number(=%d)", len(validators.Operators))
+		return &badAttestationError{newAttestationDiagnostics(reason, header, attestation, validators)}
+	}
+
 	// Filter out valid validator from attestation.
-	validatorsBitSet := bitset.From([]uint64{uint64(attestation.VoteAddressSet)})
+	validatorsBitSet := bitset.From(attestation.VoteAddressWords())
 	if validatorsBitSet.Count() > uint(len(validators.Operators)) {
-		return fmt.Errorf("invalid attestation, vote number(=%d) larger than validators number(=%d)", validatorsBitSet.Count(), len(validators.Operators))
+		reason := fmt.Sprintf("vote number(=%d) larger than validators number(=%d)", validatorsBitSet.Count(), len(validators.Operators))
+		return &badAttestationError{newAttestationDiagnostics(reason, header, attestation, validators)}
 	}
 	votedAddrs := make([]types.BLSPublicKey, 0, validatorsBitSet.Count())
 	votedPubKeys := make([]bls.PublicKey, 0, validatorsBitSet.Count())
@@ -508,7 +713,7 @@ func (o *Oasys) verifyVoteAttestation(chain consensus.ChainHeaderReader, header
 
 	// The valid voted validators should be no less than 2/3 voting power.
 	if !isSufficientVotes(votedAddrs, validators) {
-		return errors.New("invalid attestation, not enough voting power voted")
+		return &badAttestationError{newAttestationDiagnostics("not enough voting power voted", header, attestation, validators)}
 	}
 
 	// Verify the aggregated signature.
@@ -516,14 +721,50 @@ func (o *Oasys) verifyVoteAttestation(chain consensus.ChainHeaderReader, header
 	if err != nil {
 		return fmt.Errorf("BLS signature converts failed: %v", err)
 	}
+	if batch != nil {
+		batch.add(len(parents), env.Epoch(header.Number.Uint64()), header, attestation, aggSig, votedPubKeys)
+		return nil
+	}
 	if !aggSig.FastAggregateVerify(votedPubKeys, attestation.Data.Hash()) {
 		return errors.New("invalid attestation, signature verify failed")
 	}
+	if hooks := o.hooks.Load(); hooks != nil && hooks.OnAttestationVerified != nil {
+		hooks.OnAttestationVerified(header, attestation)
+	}
 
 	return nil
 }
 
+// hasVoterBeyond returns whether the attestation marks a voter at an index above
+// the given number of validators, the voter indexes being offset by 1.
+func hasVoterBeyond(attestation *types.VoteAttestation, validators int) bool {
+	for i, word := range attestation.VoteAddressWords() {
+		// The lowest index of the word above the validators
+		if first := validators + 1 - i*64; first <= 0 {
+			if word != 0 {
+				return true
+			}
+		} else if first < 64 && word>>first != 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func isSufficientVotes(votedAddrs []types.BLSPublicKey, validators *nextValidators) bool {
+	return hasQuorum(votingPower(votedAddrs, validators))
+}
+
+// hasQuorum returns whether the voter's total stake is enough for the attestation.
+func hasQuorum(voterTotalStake, totalStake *big.Int) bool {
+	// the voter's total stake should be greater than 2/3 of the total stake
+	threshold := new(big.Int).Mul(totalStake, big.NewInt(2))
+	threshold.Div(threshold, big.NewInt(3))
+	return voterTotalStake.Cmp(threshold) >= 0
+}
+
+// votingPower returns the total stake of the voters and the validators.
+func votingPower(votedAddrs []types.BLSPublicKey, validators *nextValidators) (*big.Int, *big.Int) {
 	totalStake := big.NewInt(0)
 	voterTotalStake := big.NewInt(0)
 	for i, stake := range validators.Stakes {
@@ -535,24 +776,29 @@ func isSufficientVotes(votedAddrs []types.BLSPublicKey, validators *nextValidato
 			}
 		}
 	}
-	// the voter's total stake should be greater than 2/3 of the total stake
-	threshold := new(big.Int).Mul(totalStake, big.NewInt(2))
-	threshold.Div(threshold, big.NewInt(3))
-	return voterTotalStake.Cmp(threshold) >= 0
+	return voterTotalStake, totalStake
 }
 
 // getValidatorsFromHeader returns the next validators extracted from the header's extra field if exists.
 // The validators bytes would be contained only in the epoch block's header, and its each validator bytes length is fixed.
 // Layout: |--Extra Vanity--|--EnvironmentValue--| --Validator Number--|--Owner(or Empty)--|--Operator(or Empty)--|---Stake(or Empty)--|--Vote Address(or Empty)--|--Vote Attestation(or Empty)--|--Extra Seal--|
-func getValidatorsFromHeader(header *types.Header) (*nextValidators, error) {
+// Since the compact extra fork, the validators are encoded as an RLP list of the validator tuples.
+// Layout: |--Extra Vanity--|--EnvironmentValue--|--RLP([Owner, Operator, Stake, Vote Address]...)--|--Vote Attestation(or Empty)--|--Extra Seal--|
+func getValidatorsFromHeader(header *types.Header, chainConfig *params.ChainConfig) (*nextValidators, error) {
 	if len(header.Extra) <= extraVanity+extraSeal {
-		return nil, fmt.Errorf("no validators in the extra data, extra length: %d", len(header.Extra))
+		return nil, fmt.Errorf("%w, extra length: %d", errMissingValidators, len(header.Extra))
+	}
+	if chainConfig.IsOasysCompactExtra(header.Number) {
+		return getCompactValidatorsFromHeader(header)
 	}
 
+	if len(header.Extra) < extraVanity+envValuesLen+validatorNumberSize {
+		return nil, fmt.Errorf("%w, extra length: %d", errMissingValidators, len(header.Extra))
+	}
 	num := int(header.Extra[extraVanity+envValuesLen])
 	lenNoAttestation := extraVanity + envValuesLen + validatorNumberSize + num*validatorInfoBytesLen
 	if num == 0 || len(header.Extra) < lenNoAttestation {
-		return nil, fmt.Errorf("missing validator info in the extra data, extra length: %d", len(header.Extra))
+		return nil, fmt.Errorf("%w, extra length: %d", errMissingValidators, len(header.Extra))
 	}
 
 	vals := &nextValidators{
@@ -576,6 +822,61 @@ func getValidatorsFromHeader(header *types.Header) (*nextValidators, error) {
 	return vals, nil
 }
 
+// compactValidator is the validator tuple of the compact extra-data layout, in
+// which the stake is encoded in the minimum bytes.
+type compactValidator struct {
+	Owner       common.Address
+	Operator    common.Address
+	Stake       *big.Int
+	VoteAddress types.BLSPublicKey
+}
+
+// compactValidatorsLen returns the length of the RLP encoded validators at the
+// beginning of the given bytes.
+func compactValidatorsLen(b []byte) (int, error) {
+	kind, _, rest, err := rlp.Split(b)
+	if err != nil {
+		return 0, err
+	}
+	if kind != rlp.List {
+		return 0, errors.New("validators are not an RLP list")
+	}
+	return len(b) - len(rest), nil
+}
+
+func getCompactValidatorsFromHeader(header *types.Header) (*nextValidators, error) {
+	start := extraVanity + envValuesLen
+	if len(header.Extra) <= start+extraSeal {
+		return nil, fmt.Errorf("%w, extra length: %d", errMissingValidators, len(header.Extra))
+	}
+	b := header.Extra[start : len(header.Extra)-extraSeal]
+	size, err := compactValidatorsLen(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidValidators, err)
+	}
+	var list []compactValidator
+	if err := rlp.DecodeBytes(b[:size], &list); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidValidators, err)
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("%w, extra length: %d", errMissingValidators, len(header.Extra))
+	}
+
+	vals := &nextValidators{
+		Owners:        make([]common.Address, len(list)),
+		Operators:     make([]common.Address, len(list)),
+		Stakes:        make([]*big.Int, len(list)),
+		VoteAddresses: make([]types.BLSPublicKey, len(list)),
+	}
+	for i, v := range list {
+		vals.Owners[i] = v.Owner
+		vals.Operators[i] = v.Operator
+		vals.Stakes[i] = v.Stake
+		vals.VoteAddresses[i] = v.VoteAddress
+	}
+	return vals, nil
+}
+
 func getEnvironmentFromHeader(header *types.Header) (*params.EnvironmentValue, error) {
 	// As the vote attestation length is not determistically fixed, we omit the vote attestation info
 	// even if the vote attestations are included, the length is enough smaller than the environment value
@@ -594,6 +895,9 @@ func getEnvironmentFromHeader(header *types.Header) (*params.EnvironmentValue, e
 
 // getVoteAttestationFromHeader returns the vote attestation extracted from the header's extra field if exists.
 func getVoteAttestationFromHeader(header *types.Header, chainConfig *params.ChainConfig, oasysConfig *params.OasysConfig, isEpoch bool) (*types.VoteAttestation, error) {
+	if chainConfig.IsOasysAttestationHeader(header.Number) {
+		return header.VoteAttestation, nil
+	}
 	if len(header.Extra) <= extraVanity+extraSeal {
 		return nil, nil
 	}
@@ -603,18 +907,30 @@ func getVoteAttestationFromHeader(header *types.Header, chainConfig *params.Chai
 	}
 
 	var attestationBytes []byte
-	if isEpoch {
+	if isEpoch && chainConfig.IsOasysCompactExtra(header.Number) {
+		start := extraVanity + envValuesLen
+		end := len(header.Extra) - extraSeal
+		if end <= start {
+			return nil, nil
+		}
+		size, err := compactValidatorsLen(header.Extra[start:end])
+		if err != nil {
+			// Not an epoch block, as it may be called from the `DecodeVoteAttestation(...)`.
+			return nil, nil
+		}
+		attestationBytes = header.Extra[start+size : end]
+	} else if isEpoch {
 		// Strictly check the length because it might be called from the
 		// `DecodeVoteAttestation(...)` even though it is not actually an epoch block.
 		var num int
-		if len(header.Extra) >= extraVanity+envValuesLen {
+		if len(header.Extra) > extraVanity+envValuesLen {
 			num = int(header.Extra[extraVanity+envValuesLen])
 		}
-		if len(header.Extra) <= extraVanity+extraSeal+validatorNumberSize+num*validatorInfoBytesLen {
-			return nil, nil
-		}
 		start := extraVanity + envValuesLen + validatorNumberSize + num*validatorInfoBytesLen
 		end := len(header.Extra) - extraSeal
+		if end <= start {
+			return nil, nil
+		}
 		attestationBytes = header.Extra[start:end]
 	} else {
 		attestationBytes = header.Extra[extraVanity : len(header.Extra)-extraSeal]
@@ -627,7 +943,7 @@ func getVoteAttestationFromHeader(header *types.Header, chainConfig *params.Chai
 
 	var attestation types.VoteAttestation
 	if err := rlp.Decode(bytes.NewReader(attestationBytes), &attestation); err != nil {
-		return nil, fmt.Errorf("block %d has vote attestation info, decode err: %s", header.Number.Uint64(), err)
+		return nil, fmt.Errorf("%w, block: %d, err: %v", errInvalidAttestationData, header.Number.Uint64(), err)
 	}
 	return &attestation, nil
 }
@@ -656,7 +972,7 @@ func (c *Oasys) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 	)
 	for snap == nil {
 		// If an in-memory snapshot was found, use that
-		if s, ok := c.recents.Get(hash); ok {
+		if s, ok := c.recents.Load().Get(hash); ok {
 			snap = s.(*Snapshot)
 			break
 		}
@@ -719,11 +1035,18 @@ func (c *Oasys) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 	for i := 0; i < len(headers)/2; i++ {
 		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
 	}
-	snap, err := snap.apply(headers, chain, c.config)
+	prev := snap
+	snap, err := snap.apply(headers, chain, c.config, c.contracts)
 	if err != nil {
 		return nil, err
 	}
-	c.recents.Add(snap.Hash, snap)
+	c.recents.Load().Add(snap.Hash, snap)
+	if len(headers) > 0 {
+		c.traceSnapshot(prev, snap)
+		if metrics.Enabled {
+			c.updateMetrics(snap, headers[len(headers)-1])
+		}
+	}
 
 	// If we've generated a new checkpoint snapshot, save to disk
 	if snap.Number%checkpointInterval == 0 && len(headers) > 0 {
@@ -735,6 +1058,74 @@ func (c *Oasys) snapshot(chain consensus.ChainHeaderReader, number uint64, hash
 	return snap, err
 }
 
+// traceSnapshot invokes the hooks for the headers applied to the snapshot.
+func (c *Oasys) traceSnapshot(prev, snap *Snapshot) {
+	hooks := c.hooks.Load()
+	if hooks == nil {
+		return
+	}
+	validators := snap.validators()
+	if hooks.OnSnapshotApplied != nil {
+		hooks.OnSnapshotApplied(snap.Number, snap.Hash, validators)
+	}
+	if epoch := snap.Environment.Epoch(snap.Number); epoch != prev.Environment.Epoch(prev.Number) && hooks.OnEpochTransition != nil {
+		hooks.OnEpochTransition(epoch, snap.Number, snap.Environment)
+	}
+	if old := prev.validators(); !slices.Equal(old, validators) && hooks.OnValidatorSetChange != nil {
+		hooks.OnValidatorSetChange(snap.Number, old, validators)
+	}
+}
+
+// updateMetrics updates the gauges of the epoch and the finality with the
+// snapshot of the latest header.
+func (c *Oasys) updateMetrics(snap *Snapshot, header *types.Header) {
+	epochGauge.Update(int64(snap.Environment.Epoch(snap.Number)))
+	validatorsGauge.Update(int64(len(snap.Validators)))
+	if snap.Attestation != nil {
+		justifiedLagGauge.Update(int64(snap.Number - snap.Attestation.TargetNumber))
+		finalizedLagGauge.Update(int64(snap.Number - snap.Attestation.SourceNumber))
+	}
+
+	// Stakes in the descending order to rank the local validator
+	signer := c.currentSigner().signer
+	var (
+		total       = new(big.Int)
+		voted       = new(big.Int)
+		stakes      = make([]*big.Int, 0, len(snap.Validators))
+		local       *big.Int
+		attestation *types.VoteAttestation
+	)
+	if c.chainConfig.IsFastFinalityEnabled(header.Number) {
+		attestation, _ = getVoteAttestationFromHeader(header, c.chainConfig, c.config, snap.Environment.IsEpoch(header.Number.Uint64()))
+	}
+	for address, info := range snap.Validators {
+		if info.Stake == nil {
+			continue
+		}
+		total.Add(total, info.Stake)
+		stakes = append(stakes, info.Stake)
+		if address == signer {
+			local = info.Stake
+		}
+		if attestation != nil && attestation.HasVoter(info.Index) {
+			voted.Add(voted, info.Stake)
+		}
+	}
+	if attestation != nil && total.Sign() > 0 {
+		attestationPowerGauge.Update(new(big.Int).Div(new(big.Int).Mul(voted, big.NewInt(100)), total).Int64())
+	}
+	rank := 0
+	if local != nil {
+		rank = 1
+		for _, stake := range stakes {
+			if stake.Cmp(local) > 0 {
+				rank++
+			}
+		}
+	}
+	stakeRankGauge.Update(int64(rank))
+}
+
 // VerifyUncles implements consensus.Engine, always returning an error for any
 // uncles as this consensus mechanism doesn't permit uncles.
 func (c *Oasys) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
@@ -768,7 +1159,7 @@ func (c *Oasys) verifySeal(chain consensus.ChainHeaderReader, header *types.Head
 	}
 
 	// Ensure that the difficulty corresponds to the turn-ness of the validator
-	if !c.fakeDiff {
+	if !c.fakeDiff.Load() {
 		difficulty := scheduler.difficulty(number, validator, c.chainConfig.IsForkedOasysExtendDifficulty(header.Number))
 		if header.Difficulty.Cmp(difficulty) != 0 {
 			return errWrongDifficulty
@@ -777,7 +1168,24 @@ func (c *Oasys) verifySeal(chain consensus.ChainHeaderReader, header *types.Head
 	return nil
 }
 
-func assembleValidators(validators *nextValidators) []byte {
+func assembleValidators(validators *nextValidators, compact bool) []byte {
+	if compact {
+		list := make([]compactValidator, len(validators.Operators))
+		for i := range list {
+			list[i] = compactValidator{
+				Owner:       validators.Owners[i],
+				Operator:    validators.Operators[i],
+				Stake:       validators.Stakes[i],
+				VoteAddress: validators.VoteAddresses[i],
+			}
+		}
+		extra, err := rlp.EncodeToBytes(list)
+		if err != nil {
+			panic(err) // Encoding the fixed types never fails
+		}
+		return extra
+	}
+
 	extra := make([]byte, 0, validatorNumberSize+len(validators.Operators)*common.AddressLength)
 	// add validator number
 	extra = append(extra, byte(len(validators.Operators)))
@@ -812,34 +1220,32 @@ func assembleEnvironmentValue(env *params.EnvironmentValue) []byte {
 	return extra
 }
 
-func (c *Oasys) assembleVoteAttestation(chain consensus.ChainHeaderReader, header *types.Header) error {
+// buildVoteAttestation aggregates the votes for the parent of the header into
+// an attestation. It returns nil if the votes are not sufficient.
+func (c *Oasys) buildVoteAttestation(chain consensus.ChainHeaderReader, pool consensus.VotePool, header *types.Header) (*types.VoteAttestation, *types.Header, error) {
 	if !c.chainConfig.IsFastFinalityEnabled(header.Number) || header.Number.Uint64() < 2 {
-		return nil
-	}
-
-	if c.VotePool == nil {
-		return nil
+		return nil, nil, nil
 	}
 
 	// Fetch direct parent's votes
 	parent := chain.GetHeaderByHash(header.ParentHash)
 	if parent == nil {
-		return errors.New("parent not found")
+		return nil, nil, errors.New("parent not found")
 	}
-	votes := c.VotePool.FetchVoteByBlockHash(parent.Hash())
+	votes := pool.FetchVoteByBlockHash(parent.Hash())
 	if len(votes) == 0 {
 		log.Debug("no votes found, skip assemble vote attestation", "header", header.Hash(), "number", header.Number, "parent", parent.Hash())
-		return nil
+		return nil, nil, nil
 	}
 
 	// Get validators of target block(=parent block)
 	snap, err := c.snapshot(chain, parent.Number.Uint64()-1, parent.ParentHash, nil)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	validators, err := c.getNextValidators(chain, header, snap, true)
 	if err != nil {
-		return fmt.Errorf("failed to get validators, in: assembleVoteAttestation, err: %v", err)
+		return nil, nil, fmt.Errorf("failed to get validators, in: assembleVoteAttestation, err: %v", err)
 	}
 
 	// Check if the number of votes is sufficient
@@ -849,14 +1255,14 @@ func (c *Oasys) assembleVoteAttestation(chain consensus.ChainHeaderReader, heade
 	}
 	if !isSufficientVotes(votedAddrs, validators) {
 		log.Debug("vote number less than 2/3 voting power, skip assemble vote attestation", "header", header.Hash(), "number", header.Number, "parent", parent.Hash(), "votes", len(votes))
-		return nil
+		return nil, nil, nil
 	}
 
 	// Prepare vote attestation
 	// Prepare vote data
 	justifiedBlockNumber, justifiedBlockHash, err := c.GetJustifiedNumberAndHash(chain, []*types.Header{parent})
 	if err != nil {
-		return errors.New("unexpected error when getting the highest justified number and hash")
+		return nil, nil, errors.New("unexpected error when getting the highest justified number and hash")
 	}
 	attestation := &types.VoteAttestation{
 		Data: &types.VoteData{
@@ -869,7 +1275,7 @@ func (c *Oasys) assembleVoteAttestation(chain consensus.ChainHeaderReader, heade
 	// Check vote data from votes
 	for _, vote := range votes {
 		if vote.Data.Hash() != attestation.Data.Hash() {
-			return fmt.Errorf("vote check error, expected: %v, real: %v", attestation.Data, vote)
+			return nil, nil, fmt.Errorf("vote check error, expected: %v, real: %v", attestation.Data, vote)
 		}
 	}
 	// Prepare aggregated vote signature
@@ -881,45 +1287,76 @@ func (c *Oasys) assembleVoteAttestation(chain consensus.ChainHeaderReader, heade
 	}
 	sigs, err := bls.MultipleSignaturesFromBytes(signatures)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	copy(attestation.AggSignature[:], bls.AggregateSignatures(sigs).Marshal())
 	// Prepare vote address bitset.
+	largeVoteSet := c.chainConfig.IsOasysLargeVoteSet(header.Number)
 	for i, voteAddr := range validators.VoteAddresses {
 		if _, ok := voteAddrSet[voteAddr]; ok {
 			voterIndex := i + 1
 			// sanity check
-			if 64 <= voterIndex {
-				// As the bitset is uint64, it should be less than 64
-				return errors.New("too many validators")
+			if 64 <= voterIndex && !largeVoteSet {
+				// As the bitset is uint64 before the fork, it should be less than 64
+				return nil, nil, errors.New("too many validators")
 			}
-			attestation.VoteAddressSet |= 1 << voterIndex
+			attestation.SetVoter(voterIndex)
 		}
 	}
-	validatorsBitSet := bitset.From([]uint64{uint64(attestation.VoteAddressSet)})
+	validatorsBitSet := bitset.From(attestation.VoteAddressWords())
 	if validatorsBitSet.Count() < uint(len(signatures)) {
 		log.Warn(fmt.Sprintf("assembleVoteAttestation, check VoteAddress Set failed, expected:%d, real:%d", len(signatures), validatorsBitSet.Count()))
-		return errors.New("invalid attestation, check VoteAddress Set failed")
+		return nil, nil, errors.New("invalid attestation, check VoteAddress Set failed")
 	}
+	return attestation, parent, nil
+}
 
-	// Append attestation to header extra field.
-	buf := new(bytes.Buffer)
-	err = rlp.Encode(buf, attestation)
-	if err != nil {
+// assembleVoteAttestation embeds the attestation of the votes in the pool for
+// the parent into the header being sealed.
+func (c *Oasys) assembleVoteAttestation(chain consensus.ChainHeaderReader, pool consensus.VotePool, header *types.Header) error {
+	attestation, parent, err := c.buildVoteAttestation(chain, pool, header)
+	if attestation == nil || err != nil {
 		return err
 	}
 
-	// Insert vote attestation into header extra ahead extra seal.
-	extraSealStart := len(header.Extra) - extraSeal
-	extraSealBytes := header.Extra[extraSealStart:]
-	header.Extra = append(header.Extra[0:extraSealStart], buf.Bytes()...)
-	header.Extra = append(header.Extra, extraSealBytes...)
+	if c.chainConfig.IsOasysAttestationHeader(header.Number) {
+		header.VoteAttestation = attestation
+	} else {
+		// Append attestation to header extra field.
+		buf := new(bytes.Buffer)
+		err = rlp.Encode(buf, attestation)
+		if err != nil {
+			return err
+		}
 
+		// Insert vote attestation into header extra ahead extra seal.
+		extraSealStart := len(header.Extra) - extraSeal
+		extraSealBytes := header.Extra[extraSealStart:]
+		header.Extra = append(header.Extra[0:extraSealStart], buf.Bytes()...)
+		header.Extra = append(header.Extra, extraSealBytes...)
+	}
+
+	attestationDelayTimer.Update(time.Since(time.UnixMilli(int64(headerMilliTime(parent)))))
+	if hooks := c.hooks.Load(); hooks != nil && hooks.OnAttestationAssembled != nil {
+		hooks.OnAttestationAssembled(header, attestation)
+	}
 	log.Debug("successfully assemble vote attestation", "header", header.Hash(), "number", header.Number, "justifiedBlockNumber", attestation.Data.TargetNumber, "finalizeBlockNumber", attestation.Data.SourceNumber)
 
 	return nil
 }
 
+// PreviewVoteAttestation returns the attestation which would be assembled into
+// the header if it was sealed now, without modifying the header. No attestation
+// is assembled if the engine is verification-only.
+func (c *Oasys) PreviewVoteAttestation(chain consensus.ChainHeaderReader, header *types.Header) (*types.VoteAttestation, error) {
+	pool := c.currentVotePool()
+	if pool == nil {
+		return nil, nil
+	}
+	attestation, _, err := c.buildVoteAttestation(chain, pool, header)
+	return attestation, err
+}
+
 // Prepare implements consensus.Engine, preparing all the consensus fields of the
 // header for running the transactions on top.
 func (c *Oasys) Prepare(chain consensus.ChainHeaderReader, header *types.Header) error {
@@ -927,7 +1364,7 @@ func (c *Oasys) Prepare(chain consensus.ChainHeaderReader, header *types.Header)
 	// header.Coinbase = c.signer
 	header.Nonce = types.BlockNonce{}
 
-	// Mix digest is reserved for now, set to empty
+	// Mix digest only carries the millisecond timestamp, set below
 	header.MixDigest = common.Hash{}
 
 	// Ensure the extra data has all its components
@@ -948,20 +1385,39 @@ func (c *Oasys) Prepare(chain consensus.ChainHeaderReader, header *types.Header)
 	if err != nil {
 		return fmt.Errorf("failed to get validators, in: Prepare, err: %v", err)
 	}
+	c.signalProposal(header, snap)
+	c.signalHealth(header)
+
+	// Move the gas limit toward the target of the Environment contract, if any.
+	limit, err := c.gasLimit(chain, header, env)
+	if err != nil {
+		return fmt.Errorf("failed to get gas limit, in: Prepare, blockNumber: %d, err: %v", number, err)
+	}
+	if limit.Target != 0 {
+		parent := chain.GetHeader(header.ParentHash, number-1)
+		if parent == nil {
+			return consensus.ErrUnknownAncestor
+		}
+		header.GasLimit = core.CalcGasLimit(parent.GasLimit, limit.Target)
+	}
 
 	// Add the difficulty
 	scheduler, err := c.scheduler(chain, header, env, validators.Operators, validators.Stakes)
 	if err != nil {
 		return fmt.Errorf("failed to get scheduler, in: Prepare, blockNumber: %d, err: %v", number, err)
 	}
-	header.Difficulty = scheduler.difficulty(number, c.signer, c.chainConfig.IsForkedOasysExtendDifficulty(header.Number))
+	// Seal for the authorized validator proposing the earliest, if several
+	signer := c.currentSigner()
+	proposer := signer.signer
+	if len(signer.extra) > 0 && signer.lookup(header.Coinbase) != nil {
+		proposer = signer.proposer(scheduler, number)
+		header.Coinbase = proposer
+	}
+	header.Difficulty = scheduler.difficulty(number, proposer, c.chainConfig.IsForkedOasysExtendDifficulty(header.Number))
 
 	// Add validators to the extra data
 	if env.IsEpoch(number) {
-		if c.chainConfig.IsFastFinalityEnabled(header.Number) {
-			header.Extra = append(header.Extra, assembleEnvironmentValue(env)...)
-		}
-		header.Extra = append(header.Extra, c.getExtraHeaderValueInEpoch(header.Number, validators)...)
+		header.Extra = append(header.Extra, c.epochExtra(header.Number, env, validators)...)
 	}
 
 	// Add extra seal
@@ -972,10 +1428,28 @@ func (c *Oasys) Prepare(chain consensus.ChainHeaderReader, header *types.Header)
 	if parent == nil {
 		return consensus.ErrUnknownAncestor
 	}
-	header.Time = parent.Time + env.BlockPeriod.Uint64() + scheduler.backOffTime(number, c.signer)
-	if header.Time < uint64(time.Now().Unix()) {
-		header.Time = uint64(time.Now().Unix())
+	if !c.chainConfig.IsOasysMillisecondTimestamp(header.Number) {
+		backOffTime := scheduler.backOffTime(number, proposer)
+		header.Time = parent.Time + env.BlockPeriod.Uint64() + backOffTime
+		if header.Time < uint64(time.Now().Unix()) {
+			header.Time = uint64(time.Now().Unix())
+			// The slots of all the validators have passed after an idle period, keep
+			// the back-off time so that the in-turn validator still proposes first.
+			if c.chainConfig.IsOasysHeartbeat(header.Number) {
+				header.Time += backOffTime
+			}
+		}
+		return nil
 	}
+	backOffTime := c.backOffMilliTime(header.Number, env, scheduler, proposer)
+	ms := headerMilliTime(parent) + c.blockMilliPeriod(header.Number, env) + backOffTime
+	if now := uint64(time.Now().UnixMilli()); ms < now {
+		ms = now
+		if c.chainConfig.IsOasysHeartbeat(header.Number) {
+			ms += backOffTime
+		}
+	}
+	c.setHeaderMilliTime(header, ms)
 
 	return nil
 }
@@ -995,24 +1469,24 @@ func (c *Oasys) Finalize(chain consensus.ChainHeaderReader, header *types.Header
 	if number == 1 {
 		err := c.initializeSystemContracts(state, header, cx, txs, receipts, systemTxs, usedGas, false)
 		if err != nil {
-			return fmt.Errorf("failed to initialize system contracts, in: Finalize, blockNumber: %d, blockHash: %s, err: %v", number, hash, err)
+			return newError(ErrSystemContract, "Finalize", number, err)
 		}
 	}
 
 	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve snapshot, in: Finalize, blockNumber: %d, parentHash: %x, err: %v", number, header.ParentHash, err)
+		return newError(ErrSnapshotUnavailable, "Finalize", number, err)
 	}
 	// Get from contract, not from header.
 	// This value is made sure to be the same as the value from header in verifyExtraHeaderValueInEpoch.
 	fromHeader := false
 	env, err := c.environment(chain, header, snap, fromHeader)
 	if err != nil {
-		return fmt.Errorf("failed to get environment, in: Finalize, err: %v", err)
+		return newError(ErrEnvironmentUnavailable, "Finalize", number, err)
 	}
 	validators, err := c.getNextValidators(chain, header, snap, fromHeader)
 	if err != nil {
-		return fmt.Errorf("failed to get validators, in: Finalize, err: %v", err)
+		return newError(ErrValidatorsUnavailable, "Finalize", number, err).withEpoch(env.Epoch(number))
 	}
 
 	// If the block is a epoch block, verify the validator list or hash
@@ -1023,8 +1497,33 @@ func (c *Oasys) Finalize(chain consensus.ChainHeaderReader, header *types.Header
 		}
 	}
 
+	// Verify the gas limit against the maximum of the Environment contract, which
+	// is only available along with the state.
+	limit, err := c.gasLimit(chain, header, env)
+	if err != nil {
+		return newError(ErrEnvironmentUnavailable, "Finalize", number, err).withEpoch(env.Epoch(number))
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	if err := verifyGasLimit(header, parent, limit); err != nil {
+		return err
+	}
+	// Verify the heartbeat of the empty blocks, the slash transaction of the
+	// out-of-turn validators being added below.
+	if len(*txs) == 0 && c.heartbeatMilliTime(header.Number, env) > 0 {
+		scheduler, err := c.scheduler(chain, header, env, validators.Operators, validators.Stakes)
+		if err != nil {
+			return newError(ErrSchedulerUnavailable, "Finalize", number, err).withEpoch(env.Epoch(number))
+		}
+		if err := c.verifyHeartbeat(header, parent, env, scheduler); err != nil {
+			return err
+		}
+	}
+
 	if err := c.addBalanceToStakeManager(state, header.ParentHash, number, env); err != nil {
-		return fmt.Errorf("failed to add balance to staking contract, in: Finalize, blockNumber: %d, blockHash: %s, err: %v", number, hash, err)
+		return newError(ErrSystemContract, "Finalize", number, err).withEpoch(env.Epoch(number))
 	}
 
 	if number >= c.config.Epoch {
@@ -1034,10 +1533,15 @@ func (c *Oasys) Finalize(chain consensus.ChainHeaderReader, header *types.Header
 		}
 		scheduler, err := c.scheduler(chain, header, env, validators.Operators, validators.Stakes)
 		if err != nil {
-			return fmt.Errorf("failed to get scheduler, in: Finalize, blockNumber: %d, blockHash: %s, err: %v", number, hash, err)
+			return newError(ErrSchedulerUnavailable, "Finalize", number, err).withEpoch(env.Epoch(number))
 		}
 		if expected := *scheduler.expect(number); expected != validator {
 			if err := c.slash(expected, scheduler.schedules(), state, header, cx, txs, receipts, systemTxs, usedGas, false); err != nil {
+				// The proposer only includes the slash transaction succeeded on
+				// its state, so the local revert precedes a receipt root mismatch.
+				if errors.Is(err, errExecuteSystemTx) {
+					return newError(ErrSystemTxReverted, "Finalize", number, err).withEpoch(env.Epoch(number)).withValidator(expected)
+				}
 				log.Warn("failed to slash validator", "in", "Finalize", "hash", hash, "number", number, "address", expected, "err", err)
 			}
 		}
@@ -1072,40 +1576,44 @@ func (c *Oasys) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *t
 	if number == 1 {
 		err := c.initializeSystemContracts(state, header, cx, &txs, &receipts, nil, &header.GasUsed, true)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to initialize system contracts, in: FinalizeAndAssemble, blockNumber: %d, blockHash: %s, err: %v", number, hash, err)
+			return nil, nil, newError(ErrSystemContract, "FinalizeAndAssemble", number, err)
 		}
 	}
 
 	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to retrieve snapshot, in: FinalizeAndAssemble, blockNumber: %d, parentHash: %x, err: %v", number, header.ParentHash, err)
+		return nil, nil, newError(ErrSnapshotUnavailable, "FinalizeAndAssemble", number, err)
 	}
 	fromHeader := false // Not retrieve from header to be safe, even it's already in the header
 	env, err := c.environment(chain, header, snap, fromHeader)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get environment, in: FinalizeAndAssemble, err: %v", err)
+		return nil, nil, newError(ErrEnvironmentUnavailable, "FinalizeAndAssemble", number, err)
 	}
 	validators, err := c.getNextValidators(chain, header, snap, fromHeader)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get validators, in: FinalizeAndAssemble, err: %v", err)
+		return nil, nil, newError(ErrValidatorsUnavailable, "FinalizeAndAssemble", number, err).withEpoch(env.Epoch(number))
 	}
 
 	scheduler, err := c.scheduler(chain, header, env, validators.Operators, validators.Stakes)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get scheduler, in: FinalizeAndAssemble, blockNumber: %d, blockHash: %s, err: %v", number, hash, err)
+		return nil, nil, newError(ErrSchedulerUnavailable, "FinalizeAndAssemble", number, err).withEpoch(env.Epoch(number))
 	}
 
 	if err := c.addBalanceToStakeManager(state, header.ParentHash, number, env); err != nil {
-		return nil, nil, fmt.Errorf("failed to add balance to staking contract, in: FinalizeAndAssemble, blockNumber: %d, blockHash: %s, err: %v", number, hash, err)
+		return nil, nil, newError(ErrSystemContract, "FinalizeAndAssemble", number, err).withEpoch(env.Epoch(number))
 	}
 
+	var failures []*SystemTxFailure
 	if number >= c.config.Epoch {
 		if expected := *scheduler.expect(number); expected != header.Coinbase {
 			if err := c.slash(expected, scheduler.schedules(), state, header, cx, &txs, &receipts, nil, &header.GasUsed, true); err != nil {
-				log.Warn("failed to slash validator", "in", "FinalizeAndAssemble", "hash", hash, "number", number, "address", expected, "err", err)
+				failure := newSystemTxFailure("slash", expected, err)
+				failures = append(failures, failure)
+				log.Warn("failed to slash validator", "in", "FinalizeAndAssemble", "hash", hash, "number", number, "address", expected, "reason", failure.Reason, "err", err)
 			}
 		}
 	}
+	c.storeSystemTxFailures(header, failures)
 
 	if header.GasLimit < header.GasUsed {
 		return nil, nil, errors.New("gas consumption of system txs exceed the gas limit")
@@ -1128,8 +1636,9 @@ func (c *Oasys) IsActiveValidatorAt(chain consensus.ChainHeaderReader, header *t
 		log.Warn("failed to get validators", "in", "IsActiveValidatorAt", "err", err)
 		return false
 	}
+	signer := c.currentSigner().signer
 	for i, operator := range validators.Operators {
-		if operator == c.signer {
+		if operator == signer {
 			if checkVoteKeyFn == nil || checkVoteKeyFn(&validators.VoteAddresses[i]) {
 				return true
 			}
@@ -1154,6 +1663,177 @@ func (c *Oasys) Period(chain consensus.ChainHeaderReader, header *types.Header)
 	return env.EpochPeriod.Uint64()
 }
 
+// BlockPeriod returns the seconds between the blocks at the given header,
+// falling back to the configured period if the environment is unavailable.
+func (c *Oasys) BlockPeriod(chain consensus.ChainHeaderReader, header *types.Header) uint64 {
+	number := header.Number.Uint64()
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		log.Warn("failed to get snapshot", "in", "BlockPeriod", "parentHash", header.ParentHash, "number", number, "err", err)
+		return c.config.Period
+	}
+	env, err := c.environment(chain, header, snap, true)
+	if err != nil {
+		log.Warn("failed to get environment value", "in", "BlockPeriod", "parentHash", header.ParentHash, "number", number, "err", err)
+		return c.config.Period
+	}
+	return env.BlockPeriod.Uint64()
+}
+
+// BackOffTime returns the seconds added to the block period for the validator
+// to propose the given header, which must have been prepared. The milliseconds
+// since the millisecond timestamp fork are rounded down.
+func (c *Oasys) BackOffTime(chain consensus.ChainHeaderReader, header *types.Header, validator common.Address) (uint64, error) {
+	backOffTime, err := c.headerBackOffMilliTime(chain, header, validator)
+	return backOffTime / 1000, err
+}
+
+// headerBackOffMilliTime returns the milliseconds added to the block period for
+// the validator to propose the given header, which must have been prepared.
+func (c *Oasys) headerBackOffMilliTime(chain consensus.ChainHeaderReader, header *types.Header, validator common.Address) (uint64, error) {
+	number := header.Number.Uint64()
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return 0, err
+	}
+	env, err := c.environment(chain, header, snap, true)
+	if err != nil {
+		return 0, err
+	}
+	validators, err := c.getNextValidators(chain, header, snap, true)
+	if err != nil {
+		return 0, err
+	}
+	scheduler, err := c.scheduler(chain, header, env, validators.Operators, validators.Stakes)
+	if err != nil {
+		return 0, err
+	}
+	return c.backOffMilliTime(header.Number, env, scheduler, validator), nil
+}
+
+// DelayEmptyBlock postpones the timestamp of the prepared header of an empty
+// block to the heartbeat of the chain config after its parent, keeping the
+// back-off time of the validator, so that an idle chain only produces a block
+// every heartbeat. The sealing is replaced once a transaction arrives. It
+// returns whether the timestamp was postponed.
+func (c *Oasys) DelayEmptyBlock(chain consensus.ChainHeaderReader, header *types.Header) bool {
+	number := header.Number.Uint64()
+	if c.config.Heartbeat == nil || !c.chainConfig.IsOasysHeartbeat(header.Number) {
+		return false
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return false
+	}
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return false
+	}
+	env, err := c.environment(chain, header, snap, true)
+	if err != nil {
+		return false
+	}
+	heartbeat := c.heartbeatMilliTime(header.Number, env)
+	if heartbeat == 0 {
+		return false
+	}
+	backOffTime, err := c.headerBackOffMilliTime(chain, header, header.Coinbase)
+	if err != nil {
+		return false
+	}
+	if delayed := headerMilliTime(parent) + heartbeat + backOffTime; headerMilliTime(header) < delayed {
+		c.setHeaderMilliTime(header, delayed)
+		return true
+	}
+	return false
+}
+
+// ExpectedProposers returns the validators scheduled to propose the n blocks
+// following the given header. The schedule of the next epoch is not known yet,
+// so the returned list stops at the epoch boundary.
+func (c *Oasys) ExpectedProposers(chain consensus.ChainHeaderReader, header *types.Header, n int) ([]common.Address, error) {
+	number := header.Number.Uint64()
+	snap, err := c.snapshot(chain, number, header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	next := &types.Header{Number: new(big.Int).SetUint64(number + 1), ParentHash: header.Hash()}
+	if number+1 < c.config.Epoch || snap.Environment.IsEpoch(number+1) {
+		return nil, nil
+	}
+	validators := snap.ToNextValidators()
+	scheduler, err := c.scheduler(chain, next, snap.Environment, validators.Operators, validators.Stakes)
+	if err != nil {
+		return nil, err
+	}
+
+	var proposers []common.Address
+	for i := uint64(1); i <= uint64(n); i++ {
+		if snap.Environment.IsEpoch(number + i) {
+			break
+		}
+		proposers = append(proposers, *scheduler.expect(number + i))
+	}
+	return proposers, nil
+}
+
+// ProposerTurn returns whether the coinbase of the given header is the in-turn
+// validator of the local scheduler, and whether it is in the active validator
+// set. The parent of the header must be known. The proposer of the first block
+// of an epoch is reported as active and out of turn, as the validator set of
+// the epoch is not known without the state of the parent.
+func (c *Oasys) ProposerTurn(chain consensus.ChainHeaderReader, header *types.Header) (bool, bool, error) {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return false, false, errUnknownBlock
+	}
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return false, false, err
+	}
+	if number < c.config.Epoch || snap.Environment.IsEpoch(number) {
+		return false, true, nil
+	}
+	validators := snap.ToNextValidators()
+	scheduler, err := c.scheduler(chain, header, snap.Environment, validators.Operators, validators.Stakes)
+	if err != nil {
+		return false, false, err
+	}
+	return *scheduler.expect(number) == header.Coinbase, snap.exists(header.Coinbase), nil
+}
+
+// InTurnDifficulty returns the difficulty of an in-turn block on top of the
+// given header, by which the total difficulty grows with each in-turn block.
+func (c *Oasys) InTurnDifficulty(chain consensus.ChainHeaderReader, header *types.Header) (*big.Int, error) {
+	number := header.Number.Uint64()
+	snap, err := c.snapshot(chain, number, header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if !c.chainConfig.IsForkedOasysExtendDifficulty(new(big.Int).SetUint64(number + 1)) {
+		return new(big.Int).Set(diffInTurn), nil
+	}
+	// The validator in turn has the highest priority, the number of validators.
+	minDiff := new(big.Int).Div(totalSupply, snap.Environment.ValidatorThreshold)
+	return minDiff.Mul(minDiff, big.NewInt(int64(len(snap.Validators)))), nil
+}
+
+// Epoch returns the epoch number of the given header.
+func (c *Oasys) Epoch(chain consensus.ChainHeaderReader, header *types.Header) (uint64, error) {
+	number := header.Number.Uint64()
+	snap, err := c.snapshot(chain, number, header.Hash(), nil)
+	if err != nil {
+		return 0, err
+	}
+	return snap.Environment.Epoch(number), nil
+}
+
+// ValidatorStatus returns the status of the validator owned by the given
+// address in the epoch, as seen at the state of the given header.
+func (c *Oasys) ValidatorStatus(header *types.Header, owner common.Address, epoch uint64) (*ValidatorStatus, error) {
+	return c.contracts.getValidatorInfo(c.ethAPI, header.Hash(), owner, epoch)
+}
+
 // VerifyVote will verify: 1. If the vote comes from valid validators 2. If the vote's sourceNumber and sourceHash are correct
 func (c *Oasys) VerifyVote(chain consensus.ChainHeaderReader, vote *types.VoteEnvelope) error {
 	targetNumber := vote.Data.TargetNumber
@@ -1188,13 +1868,14 @@ func (c *Oasys) VerifyVote(chain consensus.ChainHeaderReader, vote *types.VoteEn
 	}
 	for i := range validators.Operators {
 		if validators.VoteAddresses[i] == vote.VoteAddress {
-			if validators.Operators[i] == c.signer {
+			if validators.Operators[i] == c.currentSigner().signer {
 				validVotesfromSelfCounter.Inc(1)
 			}
-			metrics.GetOrRegisterCounter(fmt.Sprintf("oasys/VerifyVote/%s", validators.Operators[i].String()), nil).Inc(1)
+			validatorVoteMetrics.mark(snap.Environment.Epoch(number), validators.Operators[i])
 			return nil
 		}
 	}
+	c.announcePendingVoteAddress(snap, vote)
 
 	return errors.New("vote verification failed")
 }
@@ -1205,9 +1886,101 @@ func (c *Oasys) Authorize(signer common.Address, signFn SignerFn, txSignFn TxSig
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	c.signer = signer
-	c.signFn = signFn
-	c.txSignFn = txSignFn
+	if c.readOnly {
+		log.Error("Refused to authorize the read-only engine", "signer", signer)
+		return
+	}
+	c.signerState = &signerState{
+		version:  c.signerVersion() + 1,
+		signer:   signer,
+		signFn:   signFn,
+		txSignFn: txSignFn,
+	}
+}
+
+// SignData signs the Keccak256 hash of the data with the key of the validator
+// the engine is authorized with, returning the validator along with the
+// signature. The zero address is returned if the engine isn't authorized.
+func (c *Oasys) SignData(data []byte) (common.Address, []byte, error) {
+	key := c.currentSigner()
+	if key.signFn == nil {
+		return common.Address{}, nil, nil
+	}
+	sig, err := key.signFn(accounts.Account{Address: key.signer}, accounts.MimetypeDataWithValidator, data)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return key.signer, sig, nil
+}
+
+// signerVersion returns the version of the current signer, the caller must hold
+// the lock.
+func (c *Oasys) signerVersion() uint64 {
+	if c.signerState == nil {
+		return 0
+	}
+	return c.signerState.version
+}
+
+// currentSigner returns the current signing identity of the engine.
+func (c *Oasys) currentSigner() *signerState {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.signerState == nil {
+		return new(signerState)
+	}
+	return c.signerState
+}
+
+// SetReadOnly disables the signer and the vote machinery of the engine for the
+// nodes only serving RPC. It must be called before the engine is authorized.
+func (c *Oasys) SetReadOnly() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.readOnly = true
+	c.signerState = &signerState{version: c.signerVersion() + 1}
+	c.votePool = nil
+}
+
+// SetVotePool enables the vote production of the engine, assembling the votes
+// in the pool into the attestations of the sealed blocks. Without a pool, the
+// engine is verification-only: the attestations of the imported blocks are
+// verified, but none are assembled.
+func (c *Oasys) SetVotePool(pool consensus.VotePool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.readOnly {
+		log.Error("Refused to set the vote pool of the read-only engine")
+		return
+	}
+	c.votePool = pool
+	if feed, ok := pool.(voteFeed); ok {
+		c.voteArrivals.track(feed)
+	}
+}
+
+// VerifyOnly returns whether the engine only verifies the vote attestations,
+// without any vote pool to assemble them from.
+func (c *Oasys) VerifyOnly() bool {
+	return c.currentVotePool() == nil
+}
+
+// currentVotePool returns the vote pool of the engine, or nil if the engine is
+// verification-only.
+func (c *Oasys) currentVotePool() consensus.VotePool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.votePool
+}
+
+// ReadOnly returns whether the signer and the vote machinery are disabled.
+func (c *Oasys) ReadOnly() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.readOnly
 }
 
 // Seal implements consensus.Engine, attempting to create a sealed block using
@@ -1226,8 +1999,20 @@ func (c *Oasys) Seal(chain consensus.ChainHeaderReader, block *types.Block, resu
 	}
 	// Don't hold the signer fields for the entire sealing procedure
 	c.lock.RLock()
-	validator, signFn := c.signer, c.signFn
+	readOnly := c.readOnly
 	c.lock.RUnlock()
+	if readOnly {
+		return errReadOnly
+	}
+	signer := c.currentSigner()
+
+	// The difficulty and the back-off time were prepared for the coinbase, the
+	// block can't be sealed by another signer authorized in the meantime
+	key := signer.lookup(header.Coinbase)
+	if key == nil {
+		return fmt.Errorf("%w: prepared for %s, authorized %s", errSignerChanged, header.Coinbase, signer.signer)
+	}
+	validator, signFn := key.signer, key.signFn
 
 	// Bail out if we're unauthorized to sign a block
 	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
@@ -1244,7 +2029,7 @@ func (c *Oasys) Seal(chain consensus.ChainHeaderReader, block *types.Block, resu
 	}
 
 	// Sweet, the protocol permits us to sign the block, wait for our time
-	delay := time.Until(time.Unix(int64(header.Time), 0))
+	delay := time.Until(time.UnixMilli(int64(headerMilliTime(header))))
 
 	// Wait until sealing is terminated or delay timeout.
 	log.Trace("Waiting for slot to sign and propagate", "delay", common.PrettyDuration(delay))
@@ -1255,13 +2040,28 @@ func (c *Oasys) Seal(chain consensus.ChainHeaderReader, block *types.Block, resu
 		case <-time.After(delay):
 		}
 
-		err := c.assembleVoteAttestation(chain, header)
-		if err != nil {
-			/* If the vote attestation can't be assembled successfully, the blockchain won't get
-			   fast finalized, but it can be tolerated, so just report this error here. */
-			log.Error("Assemble vote attestation failed when sealing", "err", err)
+		// The verification-only engine seals the blocks without attestation
+		if pool := c.currentVotePool(); pool != nil {
+			c.waitForVotes(chain, pool, header, snap.Environment, stop)
+
+			if err := c.assembleVoteAttestation(chain, pool, header); err != nil {
+				/* If the vote attestation can't be assembled successfully, the blockchain won't get
+				   fast finalized, but it can be tolerated, so just report this error here. */
+				log.Error("Assemble vote attestation failed when sealing", "err", err)
+			}
+			c.markVoteArrivals(snap, header, pool.FetchVoteByBlockHash(header.ParentHash), time.Now())
 		}
 
+		// Discard the block if the signer was replaced while waiting
+		if version := c.currentSigner().version; version != signer.version {
+			log.Warn("Signer changed during sealing, discarding block", "number", number, "signer", validator)
+			return
+		}
+		// Refuse to sign anything conflicting with a previous seal of the signer
+		if err := c.guardSeal(chain, validator, header); err != nil {
+			log.Error("Refused to seal the block", "number", number, "signer", validator, "err", err)
+			return
+		}
 		// Sign all the things!
 		sighash, err := signFn(accounts.Account{Address: validator}, accounts.MimetypeOasys, OasysRLP(header))
 		if err != nil {
@@ -1270,8 +2070,11 @@ func (c *Oasys) Seal(chain consensus.ChainHeaderReader, block *types.Block, resu
 		}
 		copy(header.Extra[len(header.Extra)-extraSeal:], sighash)
 
+		sealed := block.WithSeal(header)
+		c.systemNonces.reserve(sealed, c.txSigner)
+
 		select {
-		case results <- block.WithSeal(header):
+		case results <- sealed:
 		default:
 			log.Warn("Sealing result is not read by miner", "sealhash", types.SealHash(header))
 		}
@@ -1306,7 +2109,7 @@ func (c *Oasys) CalcDifficulty(chain consensus.ChainHeaderReader, time uint64, p
 		return nil
 	}
 
-	return scheduler.difficulty(number, c.signer, c.chainConfig.IsForkedOasysExtendDifficulty(parent.Number))
+	return scheduler.difficulty(number, c.currentSigner().signer, c.chainConfig.IsForkedOasysExtendDifficulty(parent.Number))
 }
 
 func encodeSigHeaderWithoutVoteAttestation(w io.Writer, header *types.Header) {
@@ -1343,6 +2146,54 @@ func (c *Oasys) SealHash(header *types.Header) (hash common.Hash) {
 
 // Close implements consensus.Engine. It's a noop for oasys as there are no background threads.
 func (c *Oasys) Close() error {
+	c.voteArrivals.stop()
+	return nil
+}
+
+// Rewind discards the consensus state built on top of the given block number,
+// so that the engine does not refer to rolled-back blocks after the chain head
+// has been rewound. It must be called after the chain head has been reset.
+func (c *Oasys) Rewind(number uint64) error {
+	c.recents.Load().Purge()
+	schedulerCache.Purge()
+	lastBlockHashes.Purge()
+	uncommittedHashes.Purge()
+
+	deleted, err := deleteSnapshots(c.db, number)
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshots, number: %d, err: %v", number, err)
+	}
+	if err := deleteSlashRecords(c.db, number); err != nil {
+		return fmt.Errorf("failed to delete slash records, number: %d, err: %v", number, err)
+	}
+	log.Info("Rewound oasys consensus state", "number", number, "snapshots", deleted)
+	return nil
+}
+
+// PruneCaches drops the cached block hashes used to compute the proposer
+// schedule of the blocks at or below the finalized block.
+func (c *Oasys) PruneCaches(finalized uint64) {
+	if pruned := pruneSchedulerCaches(finalized); pruned > 0 {
+		log.Debug("Pruned scheduler caches", "finalized", finalized, "pruned", pruned)
+	}
+}
+
+// CheckEnvironment compares the block and epoch periods of the environment value
+// at the given header with the ones expected from the chain config, to surface
+// a genesis which disagrees with the chain before syncing on top of it.
+func (c *Oasys) CheckEnvironment(chain consensus.ChainHeaderReader, header *types.Header) error {
+	snap, err := c.snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return err
+	}
+	env := snap.Environment
+	blockPeriod, epochPeriod := c.chainConfig.OasysPeriods(env.Epoch(header.Number.Uint64()))
+	if env.BlockPeriod.Uint64() != blockPeriod {
+		return fmt.Errorf("mismatching block period, expected: %d, real: %v", blockPeriod, env.BlockPeriod)
+	}
+	if epochPeriod != 0 && env.EpochPeriod.Uint64() != epochPeriod {
+		return fmt.Errorf("mismatching epoch period, expected: %d, real: %v", epochPeriod, env.EpochPeriod)
+	}
 	return nil
 }
 
@@ -1354,6 +2205,11 @@ func (c *Oasys) APIs(chain consensus.ChainHeaderReader) []rpc.API {
 		Version:   "1.0",
 		Service:   &API{chain: chain, oasys: c},
 		Public:    false,
+	}, {
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   &DebugAPI{chain: chain, oasys: c},
+		Public:    false,
 	}}
 }
 
@@ -1406,13 +2262,13 @@ func (c *Oasys) getNextValidators(chain consensus.ChainHeaderReader, header *typ
 	number := header.Number.Uint64()
 	if snap.Environment.IsEpoch(number) {
 		if fromHeader && c.chainConfig.IsFastFinalityEnabled(header.Number) {
-			if validators, err = getValidatorsFromHeader(header); err != nil {
+			if validators, err = getValidatorsFromHeader(header, c.chainConfig); err != nil {
 				log.Warn("failed to get validators from header", "in", "getNextValidators", "hash", header.Hash(), "number", number, "err", err)
 			}
 		}
 		// If not fast finality or failed to get validators from header
 		if validators == nil {
-			if validators, err = getNextValidators(c.chainConfig, c.ethAPI, header.ParentHash, snap.Environment.Epoch(number), number); err != nil {
+			if validators, err = c.contractValidators(header, snap.Environment.Epoch(number)); err != nil {
 				err = fmt.Errorf("failed to get next validators, blockNumber: %d, parentHash: %s, error: %v", number, header.ParentHash, err)
 				return
 			}
@@ -1424,6 +2280,16 @@ func (c *Oasys) getNextValidators(chain consensus.ChainHeaderReader, header *typ
 	return
 }
 
+// epochExtra returns the values put in the extra-data of the epoch block between
+// the vanity and the seal.
+func (c *Oasys) epochExtra(number *big.Int, env *params.EnvironmentValue, validators *nextValidators) []byte {
+	var extra []byte
+	if c.chainConfig.IsFastFinalityEnabled(number) {
+		extra = append(extra, assembleEnvironmentValue(env)...)
+	}
+	return append(extra, c.getExtraHeaderValueInEpoch(number, validators)...)
+}
+
 // Converting the validator list for the extra header field.
 func (c *Oasys) getExtraHeaderValueInEpoch(number *big.Int, validators *nextValidators) []byte {
 	if !c.chainConfig.IsFastFinalityEnabled(number) {
@@ -1447,7 +2313,7 @@ func (c *Oasys) getExtraHeaderValueInEpoch(number *big.Int, validators *nextVali
 		return extra
 	}
 
-	return assembleValidators(validators)
+	return assembleValidators(validators, c.chainConfig.IsOasysCompactExtra(number))
 }
 
 // Verify the length of the Extra header field.
@@ -1469,6 +2335,12 @@ func (c *Oasys) verifyExtraHeaderLengthInEpoch(number *big.Int, length int) erro
 	}
 	// at least one validator info in extra header
 	// The exact extra header validation will be done in verifyExtraHeaderValueInEpoch during Finalize
+	if c.chainConfig.IsOasysCompactExtra(number) {
+		if length <= envValuesLen {
+			return fmt.Errorf("missing validator info in extra header, length: %d", length)
+		}
+		return nil
+	}
 	if length < envValuesLen+validatorNumberSize+validatorInfoBytesLen {
 		return fmt.Errorf("missing validator info in extra header, length: %d", length)
 	}
@@ -1497,7 +2369,7 @@ func (c *Oasys) verifyExtraHeaderValueInEpoch(header *types.Header, actual []byt
 		return err
 	}
 
-	validators, err := getValidatorsFromHeader(header)
+	validators, err := getValidatorsFromHeader(header, c.chainConfig)
 	if err != nil {
 		return err
 	}
@@ -1530,7 +2402,7 @@ func OasysRLP(header *types.Header) []byte {
 }
 
 func (c *Oasys) addBalanceToStakeManager(state *state.StateDB, hash common.Hash, number uint64, env *params.EnvironmentValue) error {
-	if !env.IsEpoch(number) || env.Epoch(number) < 3 || env.Epoch(number) > 60 {
+	if !env.IsEpoch(number) || !isRewardEpoch(env.Epoch(number)) {
 		return nil
 	}
 
@@ -1538,14 +2410,14 @@ func (c *Oasys) addBalanceToStakeManager(state *state.StateDB, hash common.Hash,
 		rewards *big.Int
 		err     error
 	)
-	if rewards, err = getRewards(c.ethAPI, hash); err != nil {
+	if rewards, err = c.contracts.getRewards(c.ethAPI, hash); err != nil {
 		return fmt.Errorf("failed to get rewards, blockNumber: %d, blockHash: %s, error: %v", number, hash, err)
 	}
 	if rewards.Cmp(common.Big0) == 0 {
 		return nil
 	}
 
-	state.AddBalance(stakeManager.address, uint256.MustFromBig(rewards))
+	state.AddBalance(c.contracts.stakeManager.address, uint256.MustFromBig(rewards))
 	log.Info("Balance added to stake manager", "hash", hash, "amount", rewards.String())
 	return nil
 }
@@ -1564,7 +2436,7 @@ func (c *Oasys) environment(chain consensus.ChainHeaderReader, header *types.Hea
 		}
 		// If not fast finality or failed to get environment from header
 		if env == nil {
-			if env, err = getNextEnvironmentValue(c.ethAPI, header.ParentHash); err != nil {
+			if env, err = c.contractEnvironment(header, snap.Environment.Epoch(number)); err != nil {
 				return nil, fmt.Errorf("failed to get environment value, blockNumber: %d, parentHash: %s, error: %v", number, header.ParentHash, err)
 			}
 		}
@@ -1591,32 +2463,37 @@ func (c *Oasys) scheduler(chain consensus.ChainHeaderReader, header *types.Heade
 
 	// Previous epoch does not exists.
 	if number < c.config.Epoch {
-		return newScheduler(env, 0, newWeightedChooser(validators, stakes, 0)), nil
+		created := newScheduler(env, 0, newWeightedChooser(validators, stakes, 0))
+		created.wiggleTime = c.wiggleTime
+		return created, nil
 	}
 
-	// After the second epoch, the hash of the last block
-	// of the previous epoch is used as the random seed.
-	seedHash, err := getPrevEpochLastBlockHash(c.config, chain, env, header)
+	seedHash, seed, err := c.epochSeed(chain, header, env)
 	if err != nil {
 		return nil, err
-	} else if seedHash == emptyHash {
-		return nil, errors.New("invalid seed hash")
 	}
-
 	if cache, ok := schedulerCache.Get(seedHash); ok {
 		return cache.(*scheduler), nil
 	}
 
-	var seed int64
-	if env.Epoch(number) >= c.chainConfig.OasysShortenedBlockTimeStartEpoch().Uint64() {
-		// This has nothing to do with reducing block time, but it has been fixed for possible overflow.
-		seed = new(big.Int).Mod(seedHash.Big(), bigMaxInt64).Int64()
-	} else {
-		seed = seedHash.Big().Int64()
-	}
-
 	created := newScheduler(env, env.GetFirstBlock(number),
 		newWeightedChooser(validators, stakes, seed))
+	created.wiggleTime = c.wiggleTime
 	schedulerCache.Add(seedHash, created)
 	return created, nil
 }
+
+// epochSeed returns the hash of the last block of the previous epoch and the
+// random seed of the scheduler derived from it, for the epochs after the first.
+func (c *Oasys) epochSeed(chain consensus.ChainHeaderReader, header *types.Header, env *params.EnvironmentValue) (common.Hash, int64, error) {
+	// After the second epoch, the hash of the last block
+	// of the previous epoch is used as the random seed.
+	seedHash, err := getPrevEpochLastBlockHash(c.config, chain, env, header)
+	if err != nil {
+		return emptyHash, 0, err
+	} else if seedHash == emptyHash {
+		return emptyHash, 0, errors.New("invalid seed hash")
+	}
+	// This has nothing to do with reducing block time, but it has been fixed for possible overflow.
+	return seedHash, scheduleSeed(seedHash, env.Epoch(header.Number.Uint64()) >= c.chainConfig.OasysShortenedBlockTimeStartEpoch().Uint64()), nil
+}
@@ -1,13 +1,22 @@
 package oasys
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/consensus"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie/testutil"
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,13 +44,13 @@ func TestAssembleEnvAndValidators(t *testing.T) {
 	// assemble
 	header.Extra = append(header.Extra, assembleEnvironmentValue(env)...)
 	require.Len(t, header.Extra, extraVanity+envValuesLen)
-	header.Extra = append(header.Extra, assembleValidators(validators)...)
+	header.Extra = append(header.Extra, assembleValidators(validators, false)...)
 	require.Len(t, header.Extra, extraVanity+envValuesLen+validatorNumberSize+size*validatorInfoBytesLen)
 
 	// disassemble
 	acturalEnv, err := getEnvironmentFromHeader(header)
 	require.NoError(t, err)
-	actualVals, err := getValidatorsFromHeader(header)
+	actualVals, err := getValidatorsFromHeader(header, &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: config})
 	require.NoError(t, err)
 
 	// assert env
@@ -64,6 +73,95 @@ func TestAssembleEnvAndValidators(t *testing.T) {
 	}
 }
 
+func TestAssembleCompactValidators(t *testing.T) {
+	var (
+		config      = &params.OasysConfig{Period: 15, Epoch: 5760, CompactExtraBlock: big.NewInt(100)}
+		chainConfig = &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: config}
+		env         = params.InitialEnvironmentValue(config)
+		size        = 20
+		validators  = &nextValidators{
+			Owners:        make([]common.Address, size),
+			Operators:     make([]common.Address, size),
+			Stakes:        make([]*big.Int, size),
+			VoteAddresses: make([]types.BLSPublicKey, size),
+		}
+		attestation = &types.VoteAttestation{
+			VoteAddressSet: 3,
+			Data:           &types.VoteData{SourceNumber: 98, TargetNumber: 99},
+			Extra:          []byte{},
+		}
+	)
+	for i := 0; i < size; i++ {
+		validators.Owners[i] = testutil.RandomAddress()
+		validators.Operators[i] = testutil.RandomAddress()
+		validators.Stakes[i] = new(big.Int).Mul(newEth(10_000_000), big.NewInt(int64(i+1)))
+		validators.VoteAddresses[i] = randomBLSPublicKey()
+	}
+	attestationBytes, err := rlp.EncodeToBytes(attestation)
+	require.NoError(t, err)
+
+	assemble := func(number int64) *types.Header {
+		header := &types.Header{Number: big.NewInt(number), Extra: make([]byte, extraVanity)}
+		header.Extra = append(header.Extra, assembleEnvironmentValue(env)...)
+		header.Extra = append(header.Extra, assembleValidators(validators, chainConfig.IsOasysCompactExtra(header.Number))...)
+		header.Extra = append(header.Extra, attestationBytes...)
+		header.Extra = append(header.Extra, make([]byte, extraSeal)...)
+		return header
+	}
+	legacy, compact := assemble(99), assemble(100)
+	require.Less(t, len(compact.Extra), len(legacy.Extra))
+
+	for _, header := range []*types.Header{legacy, compact} {
+		actualVals, err := getValidatorsFromHeader(header, chainConfig)
+		require.NoError(t, err)
+		require.Equal(t, validators.Owners, actualVals.Owners)
+		require.Equal(t, validators.Operators, actualVals.Operators)
+		require.Equal(t, validators.Stakes, actualVals.Stakes)
+		require.Equal(t, validators.VoteAddresses, actualVals.VoteAddresses)
+
+		actualAttestation, err := getVoteAttestationFromHeader(header, chainConfig, config, true)
+		require.NoError(t, err)
+		require.Equal(t, attestation, actualAttestation)
+	}
+}
+
+func TestGetVoteAttestationFromHeaderField(t *testing.T) {
+	var (
+		config      = &params.OasysConfig{Period: 15, Epoch: 5760, AttestationHeaderBlock: big.NewInt(100)}
+		chainConfig = &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: config}
+		attestation = &types.VoteAttestation{
+			VoteAddressSet: 3,
+			Data:           &types.VoteData{SourceNumber: 98, TargetNumber: 99},
+		}
+	)
+	attestationBytes, err := rlp.EncodeToBytes(attestation)
+	require.NoError(t, err)
+
+	// Before the fork, the attestation is packed in the extra-data
+	legacy := &types.Header{
+		Number:          big.NewInt(99),
+		Extra:           append(append(make([]byte, extraVanity), attestationBytes...), make([]byte, extraSeal)...),
+		VoteAttestation: attestation,
+	}
+	actual, err := getVoteAttestationFromHeader(legacy, chainConfig, config, false)
+	require.NoError(t, err)
+	require.Equal(t, attestation.Data, actual.Data)
+
+	// Since the fork, the extra-data is ignored
+	header := &types.Header{
+		Number: big.NewInt(100),
+		Extra:  legacy.Extra,
+	}
+	actual, err = getVoteAttestationFromHeader(header, chainConfig, config, false)
+	require.NoError(t, err)
+	require.Nil(t, actual)
+
+	header.VoteAttestation = attestation
+	actual, err = getVoteAttestationFromHeader(header, chainConfig, config, true)
+	require.NoError(t, err)
+	require.Equal(t, attestation, actual)
+}
+
 func TestIsSufficientVotes(t *testing.T) {
 	var (
 		size       = 5
@@ -111,3 +209,410 @@ func newEth(n int64) *big.Int {
 func randomBLSPublicKey() types.BLSPublicKey {
 	return types.BLSPublicKey(testutil.RandBytes(types.BLSPublicKeyLength))
 }
+
+func TestEncodeProposal(t *testing.T) {
+	vanity := bytes.Repeat([]byte{0xff}, extraVanity)
+	header := &types.Header{Extra: append(append([]byte{}, vanity...), make([]byte, extraSeal)...)}
+	require.Nil(t, decodeProposal(header))
+
+	for _, proposal := range []*Proposal{
+		{Address: testutil.RandomAddress(), Authorize: true},
+		{Address: testutil.RandomAddress(), Authorize: false},
+	} {
+		encodeProposal(header, proposal)
+		require.Equal(t, proposal, decodeProposal(header))
+		require.Equal(t, vanity[:extraVanity-proposalLen], header.Extra[:extraVanity-proposalLen])
+		require.Len(t, header.Extra, extraVanity+extraSeal)
+	}
+}
+
+func TestEncodeHealthBeacon(t *testing.T) {
+	vanity := bytes.Repeat([]byte{0xff}, extraVanity)
+	header := &types.Header{Extra: append(append([]byte{}, vanity...), make([]byte, extraSeal)...)}
+	require.Nil(t, decodeHealthBeacon(header))
+
+	version := fmt.Sprintf("%d.%d.%d", params.VersionMajor, params.VersionMinor, params.VersionPatch)
+	for _, voteEnabled := range []bool{true, false} {
+		encodeHealthBeacon(header, voteEnabled)
+		require.Equal(t, &HealthStatus{Version: version, VoteEnabled: voteEnabled}, decodeHealthBeacon(header))
+		require.Equal(t, vanity[healthBeaconLen:], header.Extra[healthBeaconLen:extraVanity])
+	}
+
+	// The beacon and the proposal fit together in the vanity
+	proposal := &Proposal{Address: testutil.RandomAddress(), Authorize: true}
+	encodeProposal(header, proposal)
+	require.Equal(t, proposal, decodeProposal(header))
+	require.NotNil(t, decodeHealthBeacon(header))
+	require.Len(t, header.Extra, extraVanity+extraSeal)
+}
+
+func TestVotingPowerQuorum(t *testing.T) {
+	validators := &nextValidators{
+		Stakes:        []*big.Int{big.NewInt(10), big.NewInt(20), big.NewInt(30), big.NewInt(40)},
+		VoteAddresses: []types.BLSPublicKey{{0x01}, {0x02}, {0x03}, {0x04}},
+	}
+	tests := []struct {
+		voted  []types.BLSPublicKey
+		stake  int64
+		close  bool
+		quorum bool
+	}{
+		{nil, 0, false, false},
+		{[]types.BLSPublicKey{{0x04}}, 40, false, false},
+		{[]types.BLSPublicKey{{0x01}, {0x04}}, 50, true, false},
+		{[]types.BLSPublicKey{{0x03}, {0x04}}, 70, true, true},
+		{[]types.BLSPublicKey{{0x03}, {0x04}, {0x05}}, 70, true, true},
+	}
+	for i, tt := range tests {
+		voted, total := votingPower(tt.voted, validators)
+		require.Equal(t, tt.stake, voted.Int64(), "test %d", i)
+		require.Equal(t, int64(100), total.Int64(), "test %d", i)
+		require.Equal(t, tt.close, isCloseToQuorum(voted, total), "test %d", i)
+		require.Equal(t, tt.quorum, hasQuorum(voted, total), "test %d", i)
+		require.Equal(t, tt.quorum, isSufficientVotes(tt.voted, validators), "test %d", i)
+	}
+}
+
+func TestAttestationWaitDeadline(t *testing.T) {
+	var (
+		slot   = time.Unix(1000, 0)
+		period = 6 * time.Second
+	)
+	tests := []struct {
+		now  time.Duration // Since the slot
+		wait time.Duration
+		want time.Duration // Since the slot
+	}{
+		{0, time.Second, time.Second},                           // Configured wait
+		{0, 5 * time.Second, 1500 * time.Millisecond},           // Capped at the fraction of the period
+		{time.Second, time.Second, 1500 * time.Millisecond},     // Late start, capped within the slot
+		{2 * time.Second, time.Second, 1500 * time.Millisecond}, // Beyond the fraction, no wait
+		{-time.Second, time.Second, 0},                          // Early start
+	}
+	for i, tt := range tests {
+		have := attestationWaitDeadline(slot.Add(tt.now), slot, tt.wait, period)
+		require.Equal(t, slot.Add(tt.want), have, "test %d", i)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	engine := &Oasys{config: &params.OasysConfig{Period: 15, Epoch: 5760}}
+	engine.Authorize(common.Address{0x01}, nil, nil)
+	engine.SetReadOnly()
+	require.True(t, engine.ReadOnly())
+	require.Equal(t, common.Address{}, engine.currentSigner().signer)
+
+	engine.Authorize(common.Address{0x02}, nil, nil)
+	require.Equal(t, common.Address{}, engine.currentSigner().signer)
+
+	header := &types.Header{Number: big.NewInt(1)}
+	err := engine.Seal(nil, types.NewBlockWithHeader(header), nil, nil)
+	require.ErrorIs(t, err, errReadOnly)
+}
+
+// emptyVotePool is a vote pool without any vote.
+type emptyVotePool struct{}
+
+func (emptyVotePool) FetchVoteByBlockHash(common.Hash) []*types.VoteEnvelope { return nil }
+
+func TestVerifyOnly(t *testing.T) {
+	engine := &Oasys{config: &params.OasysConfig{Period: 15, Epoch: 5760}}
+	require.True(t, engine.VerifyOnly())
+
+	engine.SetVotePool(emptyVotePool{})
+	require.False(t, engine.VerifyOnly())
+
+	// The read-only engine drops the vote pool and refuses a new one
+	engine.SetReadOnly()
+	require.True(t, engine.VerifyOnly())
+	engine.SetVotePool(emptyVotePool{})
+	require.True(t, engine.VerifyOnly())
+}
+
+func TestVerifyHeaderExtraSize(t *testing.T) {
+	var (
+		max         = uint64(extraVanity + extraSeal + 100)
+		config      = &params.OasysConfig{Period: 15, Epoch: 5760, ExtraSizeLimitBlock: big.NewInt(2)}
+		chainConfig = &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: config}
+	)
+	for _, c := range []struct {
+		maxExtraSize *uint64
+		size         int
+	}{
+		{nil, defaultMaxExtraSize + 1},
+		{&max, int(max) + 1},
+	} {
+		config.MaxExtraSize = c.maxExtraSize
+		engine := New(chainConfig, config, rawdb.NewMemoryDatabase(), nil)
+		chain := newTestHeaderChain(chainConfig, &types.Header{Number: common.Big0, Extra: make([]byte, extraVanity+extraSeal)})
+
+		// Rejected before looking up the unknown parent since the fork
+		header := &types.Header{Number: big.NewInt(2), Extra: make([]byte, c.size)}
+		require.ErrorIs(t, engine.VerifyHeader(chain, header), errExtraTooLarge)
+
+		// Only bounded by the default size before the fork
+		header = &types.Header{Number: big.NewInt(1), Extra: make([]byte, c.size)}
+		if c.size > defaultMaxExtraSize {
+			require.ErrorIs(t, engine.VerifyHeader(chain, header), errExtraTooLarge)
+		} else {
+			require.NotErrorIs(t, engine.VerifyHeader(chain, header), errExtraTooLarge)
+		}
+	}
+	// The default size is above the extra-data of the legacy layouts
+	require.Greater(t, defaultMaxExtraSize, extraVanity+envValuesLen+validatorNumberSize+255*validatorInfoBytesLen+maxExtraAttestationSize+extraSeal)
+}
+
+func TestAuthorizeVersion(t *testing.T) {
+	engine := &Oasys{config: &params.OasysConfig{Period: 15, Epoch: 5760}}
+	require.Equal(t, uint64(0), engine.currentSigner().version)
+
+	engine.Authorize(common.Address{0x01}, nil, nil)
+	prev := engine.currentSigner()
+	require.Equal(t, common.Address{0x01}, prev.signer)
+
+	engine.Authorize(common.Address{0x02}, nil, nil)
+	require.Equal(t, prev.version+1, engine.currentSigner().version)
+	require.Equal(t, common.Address{0x01}, prev.signer, "previous state mutated")
+
+	// The block prepared for the previous signer can't be sealed
+	header := &types.Header{Number: big.NewInt(1), Coinbase: common.Address{0x01}}
+	err := engine.Seal(nil, types.NewBlockWithHeader(header), nil, nil)
+	require.ErrorIs(t, err, errSignerChanged)
+}
+
+func TestAddSigner(t *testing.T) {
+	config := &params.OasysConfig{Period: 15, Epoch: 5760}
+	engine := New(params.TestChainConfig, config, nil, nil)
+	require.Error(t, engine.AddSigner(common.Address{0x02}, nil, nil), "added before authorized")
+
+	engine.Authorize(common.Address{0x01}, nil, nil)
+	require.NoError(t, engine.AddSigner(common.Address{0x02}, nil, nil))
+	require.NoError(t, engine.AddSigner(common.Address{0x02}, nil, nil))
+
+	signer := engine.currentSigner()
+	require.Len(t, signer.extra, 1)
+	require.Equal(t, common.Address{0x01}, signer.lookup(common.Address{0x01}).signer)
+	require.Equal(t, common.Address{0x02}, signer.lookup(common.Address{0x02}).signer)
+	require.Nil(t, signer.lookup(common.Address{0x03}))
+
+	// Sealing for the validator of the earliest turn, in turn whenever any is
+	var (
+		env        = params.InitialEnvironmentValue(config)
+		validators = []common.Address{{0x01}, {0x02}, {0x03}}
+		scheduler  = newScheduler(env, 0, newWeightedChooser(validators, []*big.Int{newEth(10), newEth(20), newEth(30)}, 1))
+	)
+	for number := uint64(1); number < env.EpochPeriod.Uint64(); number++ {
+		proposer := signer.proposer(scheduler, number)
+		if expected := *scheduler.expect(number); expected != (common.Address{0x03}) {
+			require.Equal(t, expected, proposer, "block %d", number)
+		}
+		for _, validator := range validators[:2] {
+			turn, _ := scheduler.turn(number, validator)
+			want, _ := scheduler.turn(number, proposer)
+			require.LessOrEqual(t, want, turn, "block %d", number)
+		}
+	}
+
+	// Authorize drops the additional signers
+	engine.Authorize(common.Address{0x01}, nil, nil)
+	require.Empty(t, engine.currentSigner().extra)
+
+	mainnet := New(params.OasysMainnetChainConfig, config, nil, nil)
+	mainnet.Authorize(common.Address{0x01}, nil, nil)
+	require.ErrorIs(t, mainnet.AddSigner(common.Address{0x02}, nil, nil), errExtraSignerMainnet)
+}
+
+func TestReload(t *testing.T) {
+	var (
+		engine = New(params.TestChainConfig, &params.OasysConfig{Period: 15, Epoch: 5760}, nil, nil)
+		on     = true
+		off    = false
+		wait   = "300ms"
+		bad    = "1x"
+		tracer = "logger"
+		none   = ""
+		size   = 16
+	)
+	engine.fakeDiff.Store(true)
+
+	_, err := engine.Reload(&ReloadConfig{FakeDiff: &on})
+	require.Error(t, err)
+
+	// The invalid config changes nothing
+	_, err = engine.Reload(&ReloadConfig{FakeDiff: &off, AttestationWait: &bad})
+	require.Error(t, err)
+	require.True(t, engine.fakeDiff.Load())
+
+	recents := engine.recents.Load()
+	applied, err := engine.Reload(&ReloadConfig{FakeDiff: &off, AttestationWait: &wait, Tracer: &tracer, SnapshotCache: &size})
+	require.NoError(t, err)
+	require.Equal(t, []string{"fakeDiff", "attestationWait", "tracer", "snapshotCache"}, applied)
+	require.False(t, engine.fakeDiff.Load())
+	require.Equal(t, 300*time.Millisecond, engine.AttestationWait())
+	require.NotNil(t, engine.hooks.Load())
+	require.NotSame(t, recents, engine.recents.Load())
+
+	_, err = engine.Reload(&ReloadConfig{Tracer: &none})
+	require.NoError(t, err)
+	require.Nil(t, engine.hooks.Load())
+}
+
+func TestConsensusState(t *testing.T) {
+	recents, _ := lru.NewARC(inmemorySnapshots)
+	engine := &Oasys{
+		config:    &params.OasysConfig{Period: 15, Epoch: 5760},
+		proposals: map[common.Address]bool{{0x02}: true},
+	}
+	engine.recents.Store(recents)
+	engine.Authorize(common.Address{0x01}, nil, nil)
+	recents.Add(common.Hash{0x03}, &Snapshot{})
+
+	var (
+		env        = params.InitialEnvironmentValue(engine.config)
+		validators = []common.Address{{0x04}, {0x05}}
+		seedHash   = common.Hash{0x06}
+	)
+	schedulerCache.Add(seedHash, newScheduler(env, 0, newWeightedChooser(validators, []*big.Int{newEth(10), newEth(20)}, 1)))
+	defer schedulerCache.Remove(seedHash)
+
+	state := engine.ConsensusState()
+	require.Equal(t, common.Address{0x01}, state.Signer)
+	require.Equal(t, map[common.Address]bool{{0x02}: true}, state.Proposals)
+	require.Equal(t, []common.Hash{{0x03}}, state.Recents)
+	require.Contains(t, state.Schedulers, &SchedulerState{
+		SeedHash:    seedHash,
+		StartEpoch:  hexutil.Uint64(env.StartEpoch.Uint64()),
+		EpochPeriod: 5760,
+		Validators:  validators,
+	})
+}
+
+func TestSystemTxFailures(t *testing.T) {
+	var (
+		engine    = &Oasys{}
+		validator = common.Address{0x01}
+		header    = &types.Header{ParentHash: common.Hash{0x02}, Number: big.NewInt(10)}
+		failures  = []*SystemTxFailure{
+			newSystemTxFailure("slash", validator, fmt.Errorf("%w: %v", errSignSystemTx, errors.New("timeout"))),
+			newSystemTxFailure("slash", validator, fmt.Errorf("%w: %v", errExecuteSystemTx, errors.New("reverted"))),
+			newSystemTxFailure("slash", validator, errors.New("supposed to get a actual transaction, but get none")),
+		}
+	)
+	require.Equal(t, SystemTxFailureSign, failures[0].Reason)
+	require.Equal(t, SystemTxFailureExecution, failures[1].Reason)
+	require.Equal(t, SystemTxFailureOther, failures[2].Reason)
+
+	engine.storeSystemTxFailures(header, failures)
+	require.Equal(t, failures, engine.SystemTxFailures(header))
+	require.Nil(t, engine.SystemTxFailures(&types.Header{ParentHash: common.Hash{0x03}, Number: big.NewInt(10)}))
+
+	engine.storeSystemTxFailures(header, nil)
+	require.Nil(t, engine.SystemTxFailures(header))
+}
+
+func TestMilliTimestamp(t *testing.T) {
+	chainConfig := &params.ChainConfig{
+		ChainID: big.NewInt(999999),
+		Oasys:   &params.OasysConfig{Period: 15, Epoch: 5760, MillisecondTimestampBlock: big.NewInt(10)},
+	}
+	engine := &Oasys{chainConfig: chainConfig, config: chainConfig.Oasys}
+
+	// Before the fork, the timestamp is rounded up to the seconds
+	header := &types.Header{Number: big.NewInt(9)}
+	engine.setHeaderMilliTime(header, 1_700_000_000_250)
+	require.Equal(t, uint64(1_700_000_001), header.Time)
+	require.Equal(t, common.Hash{}, header.MixDigest)
+	require.Equal(t, uint64(1_700_000_001_000), headerMilliTime(header))
+	require.NoError(t, engine.verifyMixDigest(header))
+
+	header.MixDigest[31] = 1
+	require.ErrorIs(t, engine.verifyMixDigest(header), errInvalidMixDigest)
+
+	// After the fork, the millisecond part is carried in the mix digest
+	header = &types.Header{Number: big.NewInt(10)}
+	engine.setHeaderMilliTime(header, 1_700_000_000_250)
+	require.Equal(t, uint64(1_700_000_000), header.Time)
+	require.Equal(t, uint64(1_700_000_000_250), headerMilliTime(header))
+	require.NoError(t, engine.verifyMixDigest(header))
+
+	header.MixDigest = common.BigToHash(big.NewInt(1000))
+	require.ErrorIs(t, engine.verifyMixDigest(header), errInvalidMilliTimestamp)
+
+	header.MixDigest = common.Hash{0: 1}
+	require.ErrorIs(t, engine.verifyMixDigest(header), errInvalidMilliTimestamp)
+}
+
+func TestMilliBlockPeriod(t *testing.T) {
+	milliPeriod := uint64(400)
+	chainConfig := &params.ChainConfig{
+		ChainID: big.NewInt(999999),
+		Oasys:   &params.OasysConfig{Period: 1, Epoch: 5760, MillisecondTimestampBlock: big.NewInt(10), MilliPeriod: &milliPeriod},
+	}
+	var (
+		engine     = &Oasys{chainConfig: chainConfig, config: chainConfig.Oasys}
+		env        = params.InitialEnvironmentValue(chainConfig.Oasys)
+		validators = []common.Address{{0x01}, {0x02}, {0x03}}
+		scheduler  = newScheduler(env, 0, newWeightedChooser(validators, []*big.Int{newEth(10), newEth(20), newEth(30)}, 1))
+	)
+	for number := uint64(1); number < 20; number++ {
+		for _, validator := range validators {
+			turn, err := scheduler.turn(number, validator)
+			require.NoError(t, err)
+
+			// The whole seconds of the environment before the fork, and the
+			// configured milliseconds after it, capping each turn to the period
+			period, backOffTime := uint64(1000), uint64(0)
+			if turn > 0 {
+				backOffTime = turn*1000 + backoffWiggleTime*1000
+			}
+			if number >= 10 {
+				period = milliPeriod
+				if turn > 0 {
+					backOffTime = turn*milliPeriod + backoffWiggleTime*1000
+				}
+			}
+			require.Equal(t, period, engine.blockMilliPeriod(new(big.Int).SetUint64(number), env), "block %d", number)
+			require.Equal(t, backOffTime, engine.backOffMilliTime(new(big.Int).SetUint64(number), env, scheduler, validator), "block %d", number)
+		}
+	}
+}
+
+func TestVerifyHeartbeat(t *testing.T) {
+	heartbeat := uint64(60)
+	chainConfig := &params.ChainConfig{
+		ChainID: big.NewInt(999999),
+		Oasys:   &params.OasysConfig{Period: 15, Epoch: 5760, HeartbeatBlock: big.NewInt(10), Heartbeat: &heartbeat},
+	}
+	var (
+		engine     = &Oasys{chainConfig: chainConfig, config: chainConfig.Oasys}
+		env        = params.InitialEnvironmentValue(chainConfig.Oasys)
+		validators = []common.Address{{0x01}, {0x02}, {0x03}}
+		scheduler  = newScheduler(env, 0, newWeightedChooser(validators, []*big.Int{newEth(10), newEth(20), newEth(30)}, 1))
+		epoch      = env.EpochPeriod.Uint64()
+	)
+	for _, number := range []uint64{9, 10, 11, epoch, epoch + 1} {
+		parent := &types.Header{Number: new(big.Int).SetUint64(number - 1), Time: 1_000_000}
+		for _, validator := range validators {
+			header := &types.Header{Number: new(big.Int).SetUint64(number), Coinbase: validator}
+			backOffTime := engine.backOffMilliTime(header.Number, env, scheduler, validator)
+
+			// An empty block each block period, until the heartbeat fork except
+			// for the epoch blocks
+			engine.setHeaderMilliTime(header, headerMilliTime(parent)+env.BlockPeriod.Uint64()*1000+backOffTime)
+			err := engine.verifyHeartbeat(header, parent, env, scheduler)
+			if number < 10 || number == epoch {
+				require.NoError(t, err, "block %d", number)
+			} else {
+				require.ErrorIs(t, err, errEarlyEmptyBlock, "block %d", number)
+			}
+
+			// An empty block each heartbeat, keeping the back-off time
+			engine.setHeaderMilliTime(header, headerMilliTime(parent)+heartbeat*1000+backOffTime)
+			require.NoError(t, engine.verifyHeartbeat(header, parent, env, scheduler), "block %d", number)
+			engine.setHeaderMilliTime(header, headerMilliTime(parent)+heartbeat*1000+backOffTime-1000)
+			if number >= 10 && number != epoch {
+				require.ErrorIs(t, engine.verifyHeartbeat(header, parent, env, scheduler), errEarlyEmptyBlock, "block %d", number)
+			}
+		}
+	}
+}
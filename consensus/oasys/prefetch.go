@@ -0,0 +1,60 @@
+package oasys
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var finalizePrefetchTimer = metrics.NewRegisteredTimer("oasys/prefetch/finalize", nil)
+
+// PrefetchFinalize warms the state read by the finalization of the block being
+// assembled, in parallel with the transaction execution. The contract calls of
+// the finalization are executed on the given state, which must be a copy to be
+// discarded, so that the storage slots of the StakeManager and Environment and
+// their trie nodes are cached before the finalization hits the disk.
+func (c *Oasys) PrefetchFinalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) {
+	defer finalizePrefetchTimer.UpdateSince(time.Now())
+
+	number := header.Number.Uint64()
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return
+	}
+	env, err := c.environment(chain, header, snap, false)
+	if err != nil {
+		return
+	}
+	validators, err := c.getNextValidators(chain, header, snap, false)
+	if err != nil {
+		return
+	}
+	state.GetBalance(c.contracts.environment.address)
+	state.GetBalance(c.contracts.stakeManager.address)
+
+	if err := c.addBalanceToStakeManager(state, header.ParentHash, number, env); err != nil {
+		log.Debug("Failed to prefetch rewards", "number", number, "err", err)
+	}
+	if number >= c.config.Epoch {
+		scheduler, err := c.scheduler(chain, header, env, validators.Operators, validators.Stakes)
+		if err != nil {
+			return
+		}
+		if expected := *scheduler.expect(number); expected != header.Coinbase {
+			msg, _, err := c.contracts.slashMessage(expected, scheduler.schedules(), header)
+			if err != nil {
+				return
+			}
+			cx := chainContext{Chain: chain, oasys: c}
+			if _, err := applyMessage(msg, state, header, c.chainConfig, cx); err != nil {
+				log.Debug("Failed to prefetch slash", "number", number, "validator", expected, "err", err)
+			}
+		}
+	}
+	// Hash the state to load the trie nodes on the paths of the changed slots
+	state.IntermediateRoot(c.chainConfig.IsEIP158(header.Number))
+}
@@ -0,0 +1,84 @@
+package oasys
+
+import (
+	"bytes"
+	"math/rand"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	proposalAuthorize   = 0x01 // Flag of the proposal to add a validator
+	proposalDeauthorize = 0x02 // Flag of the proposal to remove a validator
+)
+
+var (
+	// proposalMagic prefixes the proposal in the vanity of the extra data.
+	proposalMagic = []byte("vote")
+
+	// proposalLen is the length of the proposal at the end of the vanity.
+	proposalLen = len(proposalMagic) + 1 + common.AddressLength
+)
+
+// Proposal is a non-binding signal of the block proposer for the validator set
+// of the upcoming epochs, embedded at the end of the vanity of the extra data.
+type Proposal struct {
+	Address   common.Address `json:"address"`
+	Authorize bool           `json:"authorize"`
+}
+
+// encodeProposal writes the proposal at the end of the vanity of the header,
+// overwriting the tail of the configured vanity.
+func encodeProposal(header *types.Header, proposal *Proposal) {
+	if len(header.Extra) < extraVanity {
+		return
+	}
+	b := header.Extra[extraVanity-proposalLen : extraVanity]
+	copy(b, proposalMagic)
+	if proposal.Authorize {
+		b[len(proposalMagic)] = proposalAuthorize
+	} else {
+		b[len(proposalMagic)] = proposalDeauthorize
+	}
+	copy(b[len(proposalMagic)+1:], proposal.Address[:])
+}
+
+// decodeProposal returns the proposal embedded in the vanity of the header, or
+// nil if there is none.
+func decodeProposal(header *types.Header) *Proposal {
+	if len(header.Extra) < extraVanity {
+		return nil
+	}
+	b := header.Extra[extraVanity-proposalLen : extraVanity]
+	if !bytes.HasPrefix(b, proposalMagic) {
+		return nil
+	}
+	proposal := &Proposal{Address: common.BytesToAddress(b[len(proposalMagic)+1:])}
+	switch b[len(proposalMagic)] {
+	case proposalAuthorize:
+		proposal.Authorize = true
+	case proposalDeauthorize:
+		proposal.Authorize = false
+	default:
+		return nil
+	}
+	return proposal
+}
+
+// signalProposal embeds one of the pending proposals into the header. Only the
+// proposals which would change the validator set of the snapshot are signaled.
+func (c *Oasys) signalProposal(header *types.Header, snap *Snapshot) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	proposals := make([]*Proposal, 0, len(c.proposals))
+	for address, authorize := range c.proposals {
+		if snap.exists(address) != authorize {
+			proposals = append(proposals, &Proposal{Address: address, Authorize: authorize})
+		}
+	}
+	if len(proposals) > 0 {
+		encodeProposal(header, proposals[rand.Intn(len(proposals))])
+	}
+}
@@ -0,0 +1,95 @@
+package oasys
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/log"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// ReloadConfig is the engine configuration reloadable without restart. The
+// fields left nil are unchanged.
+type ReloadConfig struct {
+	// FakeDiff can only be turned off, as turning it on skips the difficulty
+	// verification of the live chain
+	FakeDiff *bool `json:"fakeDiff,omitempty"`
+
+	// AttestationWait is the maximum time to postpone sealing for the votes,
+	// in the format of time.ParseDuration
+	AttestationWait *string `json:"attestationWait,omitempty"`
+
+	// Tracer is the consensus tracer invoked on the consensus events, such as the
+	// one posting the alerts to a webhook, empty to disable the tracing
+	Tracer       *string         `json:"tracer,omitempty"`
+	TracerConfig json.RawMessage `json:"tracerConfig,omitempty"`
+
+	SnapshotCache  *int `json:"snapshotCache,omitempty"`  // Number of the snapshots cached in memory
+	SignatureCache *int `json:"signatureCache,omitempty"` // Number of the block signatures cached in memory
+}
+
+// Reload applies the reloadable configuration to the running engine, and returns
+// the names of the fields applied. The configuration is validated as a whole
+// before any field is applied, so an invalid one changes nothing.
+func (c *Oasys) Reload(cfg *ReloadConfig) ([]string, error) {
+	var (
+		wait    time.Duration
+		hooks   *tracing.ConsensusHooks
+		recents *lru.ARCCache
+		err     error
+	)
+	if cfg.FakeDiff != nil && *cfg.FakeDiff {
+		return nil, errors.New("fakeDiff can only be turned off")
+	}
+	if cfg.AttestationWait != nil {
+		if wait, err = time.ParseDuration(*cfg.AttestationWait); err != nil {
+			return nil, fmt.Errorf("invalid attestationWait: %v", err)
+		}
+		if wait < 0 {
+			return nil, errors.New("attestationWait must not be negative")
+		}
+	}
+	if cfg.Tracer != nil && *cfg.Tracer != "" {
+		if hooks, err = tracers.ConsensusDirectory.New(*cfg.Tracer, cfg.TracerConfig); err != nil {
+			return nil, fmt.Errorf("failed to create consensus tracer: %v", err)
+		}
+	}
+	if cfg.SnapshotCache != nil {
+		if recents, err = lru.NewARC(*cfg.SnapshotCache); err != nil {
+			return nil, fmt.Errorf("invalid snapshotCache: %v", err)
+		}
+	}
+	if cfg.SignatureCache != nil && *cfg.SignatureCache <= 0 {
+		return nil, errors.New("invalid signatureCache: must provide a positive size")
+	}
+
+	var applied []string
+	if cfg.FakeDiff != nil {
+		c.fakeDiff.Store(false)
+		applied = append(applied, "fakeDiff")
+	}
+	if cfg.AttestationWait != nil {
+		c.SetAttestationWait(wait)
+		applied = append(applied, "attestationWait")
+	}
+	if cfg.Tracer != nil {
+		c.SetHooks(hooks)
+		applied = append(applied, "tracer")
+	}
+	// The caches are replaced rather than resized, the entries are reloaded
+	// from the database on demand
+	if recents != nil {
+		c.recents.Store(recents)
+		applied = append(applied, "snapshotCache")
+	}
+	if cfg.SignatureCache != nil {
+		c.signatures.reset(*cfg.SignatureCache)
+		applied = append(applied, "signatureCache")
+	}
+	log.Info("Reloaded consensus engine config", "applied", applied)
+	return applied, nil
+}
@@ -0,0 +1,404 @@
+package oasys
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/prysmaticlabs/prysm/v5/crypto/bls"
+)
+
+const (
+	reorgSimChainID = 20241
+	reorgSimPeriod  = 15
+)
+
+// ReorgBranch configures a branch of the reorg simulation.
+type ReorgBranch struct {
+	Depth  uint64 // Number of blocks after the fork point
+	Online []int  // Indexes of the validators sealing and voting on the branch
+}
+
+// ReorgScenario configures the reorg simulation. The validators have the same
+// stake, and seal and vote for the blocks of the common chain all together.
+type ReorgScenario struct {
+	Validators            int
+	Epoch                 uint64
+	Common                uint64 // Number of blocks before the fork point
+	AttestationForkChoice bool   // Whether the attestation weighted fork choice is activated
+	Branches              [2]ReorgBranch
+}
+
+// ReorgBranchResult is the state of a branch on the target node.
+type ReorgBranchResult struct {
+	Head      uint64      `json:"head"`
+	Hash      common.Hash `json:"hash"`
+	Td        *big.Int    `json:"td"`
+	Attested  uint64      `json:"attested"` // Blocks including an attestation after the fork point
+	Justified uint64      `json:"justified"`
+	Finalized uint64      `json:"finalized"`
+}
+
+// ReorgResult is the outcome of the reorg simulation.
+type ReorgResult struct {
+	ForkPoint         uint64                `json:"forkPoint"`
+	Branches          [2]*ReorgBranchResult `json:"branches"`
+	Winner            int                   `json:"winner"`            // Index of the branch followed by the target node
+	RevertedJustified bool                  `json:"revertedJustified"` // Whether the block justified on the first branch was reverted
+}
+
+// simValidator is an in-memory validator, sealing the blocks with the ECDSA key
+// and voting with the BLS key.
+type simValidator struct {
+	key         *ecdsa.PrivateKey
+	address     common.Address
+	voteKey     bls.SecretKey
+	voteAddress types.BLSPublicKey
+}
+
+// newSimValidators creates the validators whose keys are derived from their
+// indexes, so that the simulated chains are reproducible.
+func newSimValidators(count int) ([]*simValidator, error) {
+	validators := make([]*simValidator, count)
+	for i := range validators {
+		seed := crypto.Keccak256(big.NewInt(int64(i)).Bytes())
+		key, err := crypto.ToECDSA(seed)
+		if err != nil {
+			return nil, err
+		}
+		seed = crypto.Keccak256(seed)
+		seed[0] &= 0x3f // keep the BLS secret key below the curve order
+		voteKey, err := bls.SecretKeyFromBytes(seed)
+		if err != nil {
+			return nil, err
+		}
+		v := &simValidator{key: key, address: crypto.PubkeyToAddress(key.PublicKey), voteKey: voteKey}
+		copy(v.voteAddress[:], voteKey.PublicKey().Marshal())
+		validators[i] = v
+	}
+	return validators, nil
+}
+
+// vote signs the vote for the data.
+func (v *simValidator) vote(data *types.VoteData) *types.VoteEnvelope {
+	vote := &types.VoteEnvelope{VoteAddress: v.voteAddress, Data: data}
+	digest := data.Hash()
+	copy(vote.Signature[:], v.voteKey.Sign(digest[:]).Marshal())
+	return vote
+}
+
+// seal signs the header.
+func (v *simValidator) seal(header *types.Header) error {
+	sig, err := crypto.Sign(types.SealHash(header).Bytes(), v.key)
+	if err != nil {
+		return err
+	}
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+	return nil
+}
+
+// newSimBlock builds the next block on top of the parent, assembling the
+// attestation of the votes in the pool, and seals it by the given validator
+// with the shortest back-off time. The validators stay the same across the
+// epochs.
+func newSimBlock(engine *Oasys, chain consensus.ChainHeaderReader, pool consensus.VotePool, parent *types.Header, sealers []*simValidator) (*types.Header, error) {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  types.EmptyUncleHash,
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Extra:      make([]byte, extraVanity),
+	}
+	number := header.Number.Uint64()
+	snap, err := engine.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	validators := snap.ToNextValidators()
+	scheduler, err := engine.scheduler(chain, header, snap.Environment, validators.Operators, validators.Stakes)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		sealer  *simValidator
+		backoff uint64
+	)
+	for _, v := range sealers {
+		if b := scheduler.backOffTime(number, v.address); sealer == nil || b < backoff {
+			sealer, backoff = v, b
+		}
+	}
+	header.Coinbase = sealer.address
+	header.Difficulty = scheduler.difficulty(number, sealer.address, chain.Config().IsForkedOasysExtendDifficulty(header.Number))
+	header.Time = parent.Time + snap.Environment.BlockPeriod.Uint64() + backoff
+
+	if snap.Environment.IsEpoch(number) {
+		validators.Owners = validators.Operators
+		header.Extra = append(header.Extra, assembleEnvironmentValue(snap.Environment)...)
+		header.Extra = append(header.Extra, engine.getExtraHeaderValueInEpoch(header.Number, validators)...)
+	}
+	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
+	if err := engine.assembleVoteAttestation(chain, pool, header); err != nil {
+		return nil, err
+	}
+	return header, sealer.seal(header)
+}
+
+// reorgSimulator builds the branches with an engine of its own, so that the
+// caches of the target node are not warmed up by the block production.
+type reorgSimulator struct {
+	config     *params.ChainConfig
+	genesis    *core.Genesis
+	validators []*simValidator
+	snap       *Snapshot // Snapshot of the genesis, carrying the stakes and the vote addresses
+
+	engine  *Oasys
+	builder *core.HeaderChain
+	votes   map[common.Hash][]*types.VoteEnvelope
+}
+
+// SimulateReorg builds two competing branches of the given scenario, feeds the
+// first and then the second one to a header-only node in memory, and reports
+// the branch chosen by the fork choice of the node.
+//
+// The node only verifies and inserts the headers, so the simulation covers the
+// header verification, the attestations and the fork choice, but neither the
+// block execution, the system transactions nor the delivery of the blocks and
+// the votes over the network. The stakes and the validators are taken from the
+// genesis snapshot rather than from the StakeManager.
+func SimulateReorg(scenario *ReorgScenario) (*ReorgResult, error) {
+	if scenario.Validators < 1 {
+		return nil, fmt.Errorf("invalid number of validators: %d", scenario.Validators)
+	}
+	if scenario.Epoch < 2 {
+		return nil, fmt.Errorf("invalid epoch period: %d", scenario.Epoch)
+	}
+	for i, branch := range scenario.Branches {
+		if branch.Depth == 0 {
+			return nil, fmt.Errorf("branch %d: zero depth", i)
+		}
+		if len(branch.Online) == 0 {
+			return nil, fmt.Errorf("branch %d: no online validators", i)
+		}
+		for _, index := range branch.Online {
+			if index < 0 || index >= scenario.Validators {
+				return nil, fmt.Errorf("branch %d: unknown validator %d", i, index)
+			}
+		}
+	}
+	sim, err := newReorgSimulator(scenario)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make([]int, scenario.Validators)
+	for i := range all {
+		all[i] = i
+	}
+	shared, err := sim.extend(sim.builder.GetHeaderByNumber(0), scenario.Common, all)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build common chain: %v", err)
+	}
+	fork := sim.builder.GetHeaderByNumber(0)
+	if len(shared) > 0 {
+		fork = shared[len(shared)-1]
+	}
+	var branches [2][]*types.Header
+	for i, branch := range scenario.Branches {
+		if branches[i], err = sim.extend(fork, branch.Depth, branch.Online); err != nil {
+			return nil, fmt.Errorf("failed to build branch %d: %v", i, err)
+		}
+	}
+
+	// Feed the branches to the target node one after the other
+	target, engine, err := sim.newNode()
+	if err != nil {
+		return nil, err
+	}
+	forker := core.NewForkChoice(target, nil)
+	feed := func(headers []*types.Header) error {
+		if len(headers) == 0 {
+			return nil
+		}
+		if _, err := target.ValidateHeaderChain(headers); err != nil {
+			return err
+		}
+		_, err := target.InsertHeaderChain(headers, time.Now(), forker)
+		return err
+	}
+	if err := feed(append(shared, branches[0]...)); err != nil {
+		return nil, fmt.Errorf("failed to feed branch 0: %v", err)
+	}
+	justified, justifiedHash, err := engine.GetJustifiedNumberAndHash(target, []*types.Header{target.CurrentHeader()})
+	if err != nil {
+		return nil, err
+	}
+	if err := feed(branches[1]); err != nil {
+		return nil, fmt.Errorf("failed to feed branch 1: %v", err)
+	}
+
+	result := &ReorgResult{
+		ForkPoint:         fork.Number.Uint64(),
+		RevertedJustified: justified > 0 && target.GetCanonicalHash(justified) != justifiedHash,
+	}
+	head := target.CurrentHeader()
+	for i, headers := range branches {
+		last := headers[len(headers)-1]
+		r := &ReorgBranchResult{
+			Head:      last.Number.Uint64(),
+			Hash:      last.Hash(),
+			Td:        target.GetTd(last.Hash(), last.Number.Uint64()),
+			Justified: target.GetJustifiedNumber(last),
+		}
+		if finalized := target.GetFinalizedHeader(last); finalized != nil {
+			r.Finalized = finalized.Number.Uint64()
+		}
+		for _, header := range headers {
+			if engine.DecodeVoteAttestation(header) != nil {
+				r.Attested++
+			}
+		}
+		if last.Hash() == head.Hash() {
+			result.Winner = i
+		}
+		result.Branches[i] = r
+	}
+	return result, nil
+}
+
+func newReorgSimulator(scenario *ReorgScenario) (*reorgSimulator, error) {
+	config := &params.OasysConfig{
+		Period:      reorgSimPeriod,
+		Epoch:       scenario.Epoch,
+		Environment: &params.OasysEnvironment{JailThreshold: new(big.Int).SetUint64(scenario.Epoch)},
+	}
+	if scenario.AttestationForkChoice {
+		config.AttestationForkChoiceBlock = common.Big0
+	}
+	validators, err := newSimValidators(scenario.Validators)
+	if err != nil {
+		return nil, err
+	}
+	sim := &reorgSimulator{
+		config:     &params.ChainConfig{ChainID: big.NewInt(reorgSimChainID), Oasys: config},
+		validators: validators,
+		votes:      make(map[common.Hash][]*types.VoteEnvelope),
+	}
+	operators := make([]common.Address, len(validators))
+	for i, v := range validators {
+		operators[i] = v.address
+	}
+
+	extra := make([]byte, extraVanity, extraVanity+len(operators)*common.AddressLength+extraSeal)
+	for _, operator := range operators {
+		extra = append(extra, operator.Bytes()...)
+	}
+	sim.genesis = &core.Genesis{
+		Config:     sim.config,
+		ExtraData:  append(extra, make([]byte, extraSeal)...),
+		GasLimit:   params.GenesisGasLimit,
+		Difficulty: common.Big1,
+		Alloc:      types.GenesisAlloc{},
+	}
+
+	// The genesis snapshot would be taken from the extra data without the stakes
+	// and the vote addresses, so the complete one is stored as the checkpoint.
+	sim.snap = newSnapshot(sim.config, nil, nil, 0, sim.genesis.ToBlock().Hash(), operators, params.InitialEnvironmentValue(config))
+	for _, v := range sim.validators {
+		sim.snap.Validators[v.address].Stake = new(big.Int).Set(sim.snap.Environment.ValidatorThreshold)
+		sim.snap.Validators[v.address].VoteAddress = v.voteAddress
+	}
+
+	if sim.builder, sim.engine, err = sim.newNode(); err != nil {
+		return nil, err
+	}
+	return sim, nil
+}
+
+// newNode creates a header-only node in memory on top of the genesis.
+func (s *reorgSimulator) newNode() (*core.HeaderChain, *Oasys, error) {
+	db := rawdb.NewMemoryDatabase()
+	if _, err := s.genesis.Commit(db, triedb.NewDatabase(db, triedb.HashDefaults)); err != nil {
+		return nil, nil, err
+	}
+	if err := s.snap.store(db); err != nil {
+		return nil, nil, err
+	}
+	engine := New(s.config, s.config.Oasys, db, nil)
+	chain, err := core.NewHeaderChain(db, s.config, engine, func() bool { return false })
+	if err != nil {
+		return nil, nil, err
+	}
+	return chain, engine, nil
+}
+
+// FetchVoteByBlockHash implements consensus.VotePool.
+func (s *reorgSimulator) FetchVoteByBlockHash(hash common.Hash) []*types.VoteEnvelope {
+	return s.votes[hash]
+}
+
+// extend builds the given number of blocks on top of the parent. The blocks are
+// sealed by the online validator with the shortest back-off time, and voted for
+// by all the online validators.
+func (s *reorgSimulator) extend(parent *types.Header, count uint64, online []int) ([]*types.Header, error) {
+	headers := make([]*types.Header, 0, count)
+	for i := uint64(0); i < count; i++ {
+		if err := s.vote(parent, online); err != nil {
+			return nil, err
+		}
+		sealers := make([]*simValidator, len(online))
+		for i, index := range online {
+			sealers[i] = s.validators[index]
+		}
+		header, err := newSimBlock(s.engine, s.builder, s, parent, sealers)
+		if err != nil {
+			return nil, err
+		}
+		if header.Time > uint64(time.Now().Unix()) {
+			return nil, errors.New("simulated chain reached the current time")
+		}
+		if _, err := s.builder.WriteHeaders([]*types.Header{header}); err != nil {
+			return nil, err
+		}
+		headers = append(headers, header)
+		parent = header
+	}
+	return headers, nil
+}
+
+// vote casts the votes of the online validators for the block, unless already
+// voted for it on the other branch.
+func (s *reorgSimulator) vote(block *types.Header, online []int) error {
+	number, hash, err := s.engine.GetJustifiedNumberAndHash(s.builder, []*types.Header{block})
+	if err != nil {
+		return err
+	}
+	data := &types.VoteData{
+		SourceNumber: number,
+		SourceHash:   hash,
+		TargetNumber: block.Number.Uint64(),
+		TargetHash:   block.Hash(),
+	}
+	voted := make(map[types.BLSPublicKey]bool)
+	for _, vote := range s.votes[block.Hash()] {
+		voted[vote.VoteAddress] = true
+	}
+	for _, index := range online {
+		v := s.validators[index]
+		if voted[v.voteAddress] {
+			continue
+		}
+		s.votes[block.Hash()] = append(s.votes[block.Hash()], v.vote(data))
+	}
+	return nil
+}
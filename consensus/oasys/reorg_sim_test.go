@@ -0,0 +1,53 @@
+package oasys
+
+import "testing"
+
+func TestSimulateReorg(t *testing.T) {
+	defer uncommittedHashes.Purge()
+	defer lastBlockHashes.Purge()
+
+	tests := []struct {
+		name              string
+		branches          [2]ReorgBranch
+		winner            int
+		revertedJustified bool
+	}{
+		{
+			name: "heavier branch",
+			branches: [2]ReorgBranch{
+				{Depth: 4, Online: []int{0}},
+				{Depth: 4, Online: []int{1, 2, 3}},
+			},
+			winner: 1,
+		},
+		{
+			name: "higher justified branch",
+			branches: [2]ReorgBranch{
+				{Depth: 3, Online: []int{0, 1, 2, 3}},
+				{Depth: 8, Online: []int{0}},
+			},
+			winner: 0,
+		},
+		{
+			name: "justified block reverted",
+			branches: [2]ReorgBranch{
+				{Depth: 2, Online: []int{0, 1, 2}},
+				{Depth: 5, Online: []int{1, 2, 3}},
+			},
+			winner:            1,
+			revertedJustified: true,
+		},
+	}
+	for _, tt := range tests {
+		result, err := SimulateReorg(&ReorgScenario{Validators: 4, Epoch: 100, Common: 4, Branches: tt.branches})
+		if err != nil {
+			t.Fatalf("%s: failed to simulate: %v", tt.name, err)
+		}
+		if result.Winner != tt.winner {
+			t.Errorf("%s: winner mismatch, got: %d, want: %d", tt.name, result.Winner, tt.winner)
+		}
+		if result.RevertedJustified != tt.revertedJustified {
+			t.Errorf("%s: reverted justified mismatch, got: %v, want: %v", tt.name, result.RevertedJustified, tt.revertedJustified)
+		}
+	}
+}
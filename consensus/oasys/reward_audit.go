@@ -0,0 +1,237 @@
+package oasys
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	contracts "github.com/ethereum/go-ethereum/contracts/oasys"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// The staking rewards are minted to the StakeManager at the epoch blocks of these
+// epochs only, later rewards are paid from the balance of the contract.
+const (
+	firstRewardEpoch = 3
+	lastRewardEpoch  = 60
+)
+
+// errNoArchiveChain is returned if the chain can't replay the epoch blocks.
+var errNoArchiveChain = errors.New("the chain can't replay the epoch blocks")
+
+// archiveChain is the chain of an archive node, which replays the epoch blocks on
+// the historical states to measure the rewards minted by the engine.
+type archiveChain interface {
+	consensus.ChainHeaderReader
+	Engine() consensus.Engine
+	GetBlock(hash common.Hash, number uint64) *types.Block
+	StateAt(root common.Hash) (*state.StateDB, error)
+}
+
+// isRewardEpoch returns whether the rewards are minted at the given epoch.
+func isRewardEpoch(epoch uint64) bool {
+	return epoch >= firstRewardEpoch && epoch <= lastRewardEpoch
+}
+
+// epochEmission computes the rewards emitted for an epoch of the environment by
+// the annual reward rate on the given total stake.
+func epochEmission(env *params.EnvironmentValue, totalStake *big.Int) *big.Int {
+	duration := new(big.Int).Mul(env.BlockPeriod, env.EpochPeriod)
+	rewards := new(big.Int).Mul(totalStake, env.RewardRate)
+	rewards.Mul(rewards, duration)
+	return rewards.Div(rewards, big.NewInt(100*secondsPerYear))
+}
+
+// RewardEmission is the rewards minted to the StakeManager at an epoch block, the
+// ones accounted by the StakeManager for the ended epoch, which must be minted
+// exactly, and the ones of the emission formula on the stakes of the ended epoch,
+// which differ by the rounding of the rewards of every staker by the contract.
+type RewardEmission struct {
+	Epoch    uint64       `json:"epoch"`
+	Number   uint64       `json:"number"`
+	Hash     common.Hash  `json:"hash"`
+	Minted   *hexutil.Big `json:"minted"`
+	Expected *hexutil.Big `json:"expected"`
+	Formula  *hexutil.Big `json:"formula"`
+	Deviates bool         `json:"deviates"` // Whether the minted rewards differ from the accounted ones
+}
+
+// rewardEmission measures the rewards minted at the epoch block with the given
+// number. The states of the block and its parent must be available.
+func (c *Oasys) rewardEmission(chain archiveChain, number uint64) (*RewardEmission, error) {
+	block := chain.GetBlock(chain.GetCanonicalHash(number), number)
+	if block == nil {
+		return nil, errUnknownBlock
+	}
+	header := block.Header()
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	minted, err := c.mintedRewards(chain, block)
+	if err != nil {
+		return nil, err
+	}
+	expected, err := c.contracts.getRewards(c.ethAPI, header.ParentHash)
+	if err != nil {
+		return nil, err
+	}
+	totalStake := new(big.Int)
+	for _, validator := range snap.Validators {
+		if validator.Stake != nil {
+			totalStake.Add(totalStake, validator.Stake)
+		}
+	}
+
+	return &RewardEmission{
+		Epoch:    snap.Environment.Epoch(number),
+		Number:   number,
+		Hash:     header.Hash(),
+		Minted:   (*hexutil.Big)(minted),
+		Expected: (*hexutil.Big)(expected),
+		Formula:  (*hexutil.Big)(epochEmission(snap.Environment, totalStake)),
+		Deviates: minted.Cmp(expected) != 0,
+	}, nil
+}
+
+// mintedRewards measures the balance added to the StakeManager by the engine at
+// the given block, from the state after the general transactions of the block
+// replayed on the parent state to the state of the block. The system transactions
+// following the minting don't transfer the balance of the StakeManager.
+func (c *Oasys) mintedRewards(chain archiveChain, block *types.Block) (*big.Int, error) {
+	header := block.Header()
+	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	statedb, err := chain.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	contracts.Deploy(c.chainConfig, statedb, header.Number.Uint64())
+
+	var (
+		gp      = new(core.GasPool).AddGas(header.GasLimit)
+		usedGas uint64
+	)
+	for i, tx := range block.Transactions() {
+		if isSystemTx, err := c.IsSystemTransaction(tx, header); err != nil {
+			return nil, err
+		} else if isSystemTx {
+			continue
+		}
+		statedb.SetTxContext(tx.Hash(), i)
+		if _, err := core.ApplyTransaction(c.chainConfig, chain, nil, gp, statedb, header, tx, &usedGas, vm.Config{}); err != nil {
+			return nil, err
+		}
+	}
+	before := statedb.GetBalance(c.contracts.stakeManager.address).ToBig()
+
+	final, err := chain.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Sub(final.GetBalance(c.contracts.stakeManager.address).ToBig(), before), nil
+}
+
+// rewardEmissions recomputes the rewards minted at the epoch blocks up to the
+// given epoch, which requires the historical states of an archive node.
+func (c *Oasys) rewardEmissions(chain consensus.ChainHeaderReader, toEpoch uint64) ([]*RewardEmission, error) {
+	archive, ok := chain.(archiveChain)
+	if !ok {
+		return nil, errNoArchiveChain
+	}
+	head := chain.CurrentHeader()
+	snap, err := c.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if current := snap.Environment.Epoch(head.Number.Uint64()); toEpoch > current {
+		toEpoch = current
+	}
+	if toEpoch > lastRewardEpoch {
+		toEpoch = lastRewardEpoch
+	}
+	var emissions []*RewardEmission
+	for epoch := uint64(firstRewardEpoch); epoch <= toEpoch; epoch++ {
+		number, err := c.epochFirstBlock(chain, head, epoch)
+		if err != nil {
+			return nil, err
+		}
+		emission, err := c.rewardEmission(archive, number)
+		if err != nil {
+			return nil, err
+		}
+		emissions = append(emissions, emission)
+	}
+	return emissions, nil
+}
+
+// AuditRewardEmission verifies the rewards minted to the StakeManager over the
+// reward epochs passed against the ones accounted by the StakeManager, logging
+// the deviating epochs. It requires the historical states of an archive node.
+func (c *Oasys) AuditRewardEmission(chain consensus.ChainHeaderReader) error {
+	emissions, err := c.rewardEmissions(chain, lastRewardEpoch)
+	if err != nil {
+		return err
+	}
+	var (
+		minted    = new(big.Int)
+		expected  = new(big.Int)
+		deviating int
+	)
+	for _, emission := range emissions {
+		minted.Add(minted, emission.Minted.ToInt())
+		expected.Add(expected, emission.Expected.ToInt())
+		if emission.Deviates {
+			log.Warn("Minted rewards deviate from the accounted rewards", "epoch", emission.Epoch, "number", emission.Number,
+				"minted", emission.Minted.ToInt(), "expected", emission.Expected.ToInt(), "formula", emission.Formula.ToInt())
+			deviating++
+		}
+	}
+	log.Info("Audited the reward emission", "epochs", len(emissions), "minted", minted, "expected", expected, "deviating", deviating)
+	if deviating > 0 {
+		return errors.New("minted rewards deviate from the accounted rewards")
+	}
+	return nil
+}
+
+// MintedRewards is the cumulative rewards minted to the StakeManager.
+type MintedRewards struct {
+	Minted    *hexutil.Big      `json:"minted"`
+	Expected  *hexutil.Big      `json:"expected"`
+	Emissions []*RewardEmission `json:"emissions"`
+}
+
+// GetMintedRewards returns the rewards minted to the StakeManager at the epoch
+// blocks up to the given epoch, or all of them if none is given, together with
+// the ones accounted by the StakeManager. It requires the historical states
+// of an archive node.
+func (api *API) GetMintedRewards(toEpoch *hexutil.Uint64) (*MintedRewards, error) {
+	to := uint64(lastRewardEpoch)
+	if toEpoch != nil {
+		to = uint64(*toEpoch)
+	}
+	emissions, err := api.oasys.rewardEmissions(api.chain, to)
+	if err != nil {
+		return nil, err
+	}
+	result := &MintedRewards{
+		Minted:    (*hexutil.Big)(new(big.Int)),
+		Expected:  (*hexutil.Big)(new(big.Int)),
+		Emissions: make([]*RewardEmission, 0, len(emissions)),
+	}
+	for _, emission := range emissions {
+		result.Minted.ToInt().Add(result.Minted.ToInt(), emission.Minted.ToInt())
+		result.Expected.ToInt().Add(result.Expected.ToInt(), emission.Expected.ToInt())
+		result.Emissions = append(result.Emissions, emission)
+	}
+	return result, nil
+}
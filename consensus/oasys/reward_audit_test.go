@@ -0,0 +1,114 @@
+package oasys
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func TestEpochEmission(t *testing.T) {
+	env := &params.EnvironmentValue{
+		BlockPeriod: big.NewInt(15),
+		EpochPeriod: big.NewInt(5760),
+		RewardRate:  big.NewInt(10),
+	}
+	// 10% a year of 365 ether staked for a day
+	stake := new(big.Int).Mul(big.NewInt(365), big.NewInt(params.Ether))
+	if have, want := epochEmission(env, stake), big.NewInt(params.Ether/10); have.Cmp(want) != 0 {
+		t.Errorf("emission mismatch: have %v, want %v", have, want)
+	}
+	for epoch, want := range map[uint64]bool{2: false, 3: true, 60: true, 61: false} {
+		if have := isRewardEpoch(epoch); have != want {
+			t.Errorf("epoch %d: reward epoch mismatch, have %v, want %v", epoch, have, want)
+		}
+	}
+}
+
+// testArchiveChain replays the blocks on the states committed to the database.
+type testArchiveChain struct {
+	*testHeaderChain
+	engine consensus.Engine
+	db     state.Database
+	blocks map[common.Hash]*types.Block
+}
+
+func (c *testArchiveChain) Engine() consensus.Engine { return c.engine }
+
+func (c *testArchiveChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return c.blocks[hash]
+}
+
+func (c *testArchiveChain) StateAt(root common.Hash) (*state.StateDB, error) {
+	return state.New(root, c.db, nil)
+}
+
+func TestMintedRewards(t *testing.T) {
+	env, err := makeVoteEnv(1)
+	if err != nil {
+		t.Fatalf("failed to create test vote env: %v", err)
+	}
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	chain := &testArchiveChain{
+		testHeaderChain: env.chain,
+		engine:          env.engine,
+		db:              state.NewDatabase(rawdb.NewMemoryDatabase()),
+		blocks:          make(map[common.Hash]*types.Block),
+	}
+
+	// The sender funds the StakeManager along with the rewards minted at the block
+	statedb, _ := state.New(types.EmptyRootHash, chain.db, nil)
+	statedb.AddBalance(sender, uint256.NewInt(params.Ether))
+	parentRoot, err := statedb.Commit(0, false)
+	if err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	parent := env.chain.CurrentHeader()
+	parent.Root = parentRoot
+	env.chain.numbers[parent.Hash()] = 0
+
+	tx, err := types.SignTx(types.NewTransaction(0, stakeManager.address, big.NewInt(params.GWei), params.TxGas, common.Big0, nil), types.HomesteadSigner{}, key)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     common.Big1,
+		GasLimit:   params.GenesisGasLimit,
+		Difficulty: common.Big2,
+		Coinbase:   env.validators[0].address,
+	}
+	var (
+		gp      = new(core.GasPool).AddGas(header.GasLimit)
+		usedGas uint64
+	)
+	statedb, _ = state.New(parentRoot, chain.db, nil)
+	if _, err := core.ApplyTransaction(chain.config, chain, nil, gp, statedb, header, tx, &usedGas, vm.Config{}); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	statedb.AddBalance(stakeManager.address, uint256.NewInt(5*params.GWei))
+	if header.Root, err = statedb.Commit(1, false); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	block := types.NewBlockWithHeader(header).WithBody([]*types.Transaction{tx}, nil)
+	env.chain.insert(header)
+	chain.blocks[header.Hash()] = block
+
+	minted, err := env.engine.mintedRewards(chain, block)
+	if err != nil {
+		t.Fatalf("failed to measure the minted rewards: %v", err)
+	}
+	if want := big.NewInt(5 * params.GWei); minted.Cmp(want) != 0 {
+		t.Errorf("minted rewards mismatch: have %v, want %v", minted, want)
+	}
+}
@@ -0,0 +1,107 @@
+package oasys
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+//go:generate go run ./internal/schedulegen -out testdata/schedule_vectors.json
+
+const (
+	// maxScheduleEpochPeriod and maxScheduleValidators bound the size of the
+	// schedule vector, which is computed on the requests of the remote callers.
+	// The period is twice the epoch of the public networks.
+	maxScheduleEpochPeriod = 2 * params.SHORT_BLOCK_TIME_EPOCH_PERIOD
+	maxScheduleValidators  = 1000
+)
+
+// ScheduleValidator is a validator and its stake given to the proposer selection.
+type ScheduleValidator struct {
+	Address common.Address `json:"address"`
+	Stake   *hexutil.Big   `json:"stake"`
+}
+
+// ScheduleBlock is the proposer and the back-off times of the validators at a
+// block position in the epoch. The back-off times are in the same order as the
+// validators of the vector.
+type ScheduleBlock struct {
+	Position     uint64         `json:"position"`
+	Proposer     common.Address `json:"proposer"`
+	BackOffTimes []uint64       `json:"backOffTimes"`
+}
+
+// ScheduleVector is a canonical test vector of the proposer selection, which
+// allows alternative client implementations and auditors to validate the
+// algorithm without running the chain.
+type ScheduleVector struct {
+	SeedHash    common.Hash         `json:"seedHash"`
+	Seed        int64               `json:"seed"`
+	EpochPeriod uint64              `json:"epochPeriod"`
+	Validators  []ScheduleValidator `json:"validators"`
+	Schedule    []ScheduleBlock     `json:"schedule"`
+}
+
+// GenerateScheduleVector computes the proposer schedule of an epoch for the
+// given seed hash and validators. The seed is derived from the hash in the same
+// way as the epochs since the shortened block time fork.
+func GenerateScheduleVector(seedHash common.Hash, validators []ScheduleValidator, epochPeriod uint64) (*ScheduleVector, error) {
+	if len(validators) == 0 {
+		return nil, errors.New("no validators")
+	}
+	if len(validators) > maxScheduleValidators {
+		return nil, fmt.Errorf("too many validators, max: %d", maxScheduleValidators)
+	}
+	if epochPeriod == 0 || epochPeriod > maxScheduleEpochPeriod {
+		return nil, fmt.Errorf("invalid epoch period, max: %d", maxScheduleEpochPeriod)
+	}
+	var (
+		addresses = make([]common.Address, len(validators))
+		stakes    = make([]*big.Int, len(validators))
+		seen      = make(map[common.Address]bool)
+	)
+	for i, v := range validators {
+		if seen[v.Address] {
+			return nil, errors.New("duplicate validator")
+		}
+		seen[v.Address] = true
+		// The validators staking less than 1 ether are never chosen, and the
+		// chooser falls back to the unseeded random source if none is chosen.
+		if v.Stake == nil || v.Stake.ToInt().Cmp(ether) < 0 {
+			return nil, errors.New("stake must be at least 1 ether")
+		}
+		addresses[i], stakes[i] = v.Address, v.Stake.ToInt()
+	}
+
+	env := &params.EnvironmentValue{
+		StartBlock:  common.Big0,
+		StartEpoch:  common.Big1,
+		EpochPeriod: new(big.Int).SetUint64(epochPeriod),
+	}
+	seed := scheduleSeed(seedHash, true)
+	s := newScheduler(env, 0, newWeightedChooser(addresses, stakes, seed))
+
+	vector := &ScheduleVector{
+		SeedHash:    seedHash,
+		Seed:        seed,
+		EpochPeriod: epochPeriod,
+		Validators:  validators,
+		Schedule:    make([]ScheduleBlock, epochPeriod),
+	}
+	for bpos := uint64(0); bpos < epochPeriod; bpos++ {
+		block := ScheduleBlock{
+			Position:     bpos,
+			Proposer:     *s.expect(bpos),
+			BackOffTimes: make([]uint64, len(validators)),
+		}
+		for i, v := range validators {
+			block.BackOffTimes[i] = s.backOffTime(bpos, v.Address)
+		}
+		vector.Schedule[bpos] = block
+	}
+	return vector, nil
+}
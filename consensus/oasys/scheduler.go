@@ -12,6 +12,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params"
 	lru "github.com/hashicorp/golang-lru"
 )
@@ -26,8 +27,18 @@ var (
 	// Cache the hash value of the final block of the
 	// previous epoch for a block with a certain ParentHash.
 	lastBlockHashes *lru.Cache
+
+	uncommittedHashesGauge = metrics.NewRegisteredGauge("oasys/cache/uncommitted", nil)
+	lastBlockHashesGauge   = metrics.NewRegisteredGauge("oasys/cache/lastblock", nil)
 )
 
+// numberedHash is a cached hash together with the number of the block of the
+// cache key, so that the entries of the finalized blocks can be pruned.
+type numberedHash struct {
+	hash   common.Hash
+	number uint64
+}
+
 func init() {
 	// Set the capacity equal to the "blockCacheMaxItems" in the "eth/downloader" package.
 	// WARNING: The capacity must not be smaller than the maximum size of the batch verification.
@@ -39,7 +50,6 @@ func init() {
 }
 
 type scheduler struct {
-	mu  sync.Mutex
 	env *params.EnvironmentValue
 
 	// WARNING: Consensus engine should not use this value directly.
@@ -52,9 +62,17 @@ type scheduler struct {
 	// WARNING: Consensus engine should not use this value directly.
 	choices []*common.Address
 
-	// Validator order indexed by block position in the epoch.
+	// Mapping the real address to the index of "chooser.validators".
+	indexes map[common.Address]int
+
+	// Seconds added to the back-off time of the out-of-turn validators.
+	wiggleTime uint64
+
+	// Validator order indexed by block position in the epoch and validator
+	// index, which is computed once on first use and read without locking.
 	// WARNING: Consensus engine should not use this value directly.
-	turns *lru.Cache
+	turns     []uint32
+	turnsOnce sync.Once
 
 	// Example: period=1000, epoch=2, validators=[A,B,C]
 	//   Index 0: block=1000 choices[0]=A turns[0]=[A,B,C]
@@ -67,15 +85,17 @@ type scheduler struct {
 func newScheduler(env *params.EnvironmentValue, epochStart uint64, chooser *weightedChooser) *scheduler {
 	period := env.EpochPeriod.Uint64()
 	s := &scheduler{
-		env:     env,
-		chooser: chooser,
-		ptrmap:  map[common.Address]*common.Address{},
-		choices: make([]*common.Address, period),
+		env:        env,
+		chooser:    chooser,
+		ptrmap:     map[common.Address]*common.Address{},
+		indexes:    map[common.Address]int{},
+		choices:    make([]*common.Address, period),
+		wiggleTime: backoffWiggleTime,
 	}
-	s.turns, _ = lru.New(32)
 
 	for i, addr := range s.chooser.validators {
 		s.ptrmap[addr] = &s.chooser.validators[i]
+		s.indexes[addr] = i
 	}
 
 	for bpos := uint64(0); bpos < period; bpos++ {
@@ -123,36 +143,86 @@ func (s *scheduler) turn(number uint64, validator common.Address) (uint64, error
 		return 0, errUnauthorizedValidator
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.turnsOnce.Do(s.computeTurns)
 
-	turns := map[*common.Address]uint64{}
-	if cache, ok := s.turns.Get(number); ok {
-		turns = cache.(map[*common.Address]uint64)
-	} else {
-		s.turns.Add(number, turns)
-	}
+	bpos := number - s.env.GetFirstBlock(number)
+	return uint64(s.turns[bpos*uint64(len(s.chooser.validators))+uint64(s.indexes[validator])]), nil
+}
 
-	for bpos := number - s.env.GetFirstBlock(number); ; bpos++ {
-		if turn, ok := turns[s.ptrmap[validator]]; ok {
-			return turn, nil
+// computeTurns computes the validator order of all blocks in the epoch. The
+// order of a block is the order in which the validators first appear in the
+// schedule from the block on, so the schedule is extended beyond the epoch
+// until all validators appear after its last block. The validators without any
+// weight never appear, so they are not waited for and come last in the order.
+func (s *scheduler) computeTurns() {
+	var (
+		period     = s.env.EpochPeriod.Uint64()
+		size       = len(s.chooser.validators)
+		choices    = make([]int, period)
+		found      = make([]bool, size)
+		seen       = 0
+		weighted   = 0
+		unweighted []int
+	)
+	for i := 0; i < size; i++ {
+		if s.chooser.weighted(i) {
+			weighted++
+		} else {
+			unweighted = append(unweighted, i)
 		}
-		if bpos >= uint64(len(s.choices)) {
+	}
+	for bpos, choice := range s.choices[:period] {
+		choices[bpos] = s.indexes[*choice]
+	}
+	for bpos := period - 1; seen < weighted; bpos++ {
+		if bpos >= uint64(len(choices)) {
 			// Out of the first calculated schedule.
-			s.choices = append(s.choices, s.ptrmap[s.chooser.random()])
+			choices = append(choices, s.indexes[s.chooser.random()])
+		}
+		if !found[choices[bpos]] {
+			found[choices[bpos]] = true
+			seen++
+		}
+	}
+
+	// Walking the schedule backward, the validator of a block always comes
+	// first in the order of the block, followed by the order of the next block.
+	s.turns = make([]uint32, period*uint64(size))
+	order := make([]int, 0, size)
+	for bpos := len(choices) - 1; bpos >= 0; bpos-- {
+		choice, i := choices[bpos], 0
+		for ; i < len(order) && order[i] != choice; i++ {
+		}
+		if i == len(order) {
+			order = append(order, choice)
 		}
-		if _, ok := turns[s.choices[bpos]]; !ok {
-			turns[s.choices[bpos]] = uint64(len(turns))
+		copy(order[1:i+1], order[:i])
+		order[0] = choice
+
+		if uint64(bpos) < period {
+			row := s.turns[uint64(bpos)*uint64(size):]
+			for turn, index := range order {
+				row[index] = uint32(turn)
+			}
+			for turn, index := range unweighted {
+				row[index] = uint32(len(order) + turn)
+			}
 		}
 	}
 }
 
 func (s *scheduler) backOffTime(number uint64, validator common.Address) uint64 {
+	return s.backOffMilliTime(number, validator, 1000) / 1000
+}
+
+// backOffMilliTime returns the back-off time of the validator in milliseconds,
+// each turn after the in-turn validator adding the given step in milliseconds.
+func (s *scheduler) backOffMilliTime(number uint64, validator common.Address, step uint64) uint64 {
 	turn, err := s.turn(number, validator)
 	if errors.Is(err, errUnauthorizedValidator) || turn == 0 {
 		return 0
 	}
-	return turn + backoffWiggleTime
+	return turn*step + s.wiggleTime*1000
 }
 
 type validatorAndStake struct {
@@ -226,6 +296,19 @@ func (c *weightedChooser) random() common.Address {
 	return c.validators[i]
 }
 
+// weighted returns whether the validator at the index may be chosen. The
+// validators staking less than 1 ether have no weight and are never chosen,
+// unless none of the validators have any weight.
+func (c *weightedChooser) weighted(index int) bool {
+	if c.max == 0 {
+		return true
+	}
+	if index == 0 {
+		return c.totals[0] > 0
+	}
+	return c.totals[index] > c.totals[index-1]
+}
+
 func (c *weightedChooser) randInt() int {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -258,6 +341,15 @@ func newWeightedChooser(
 	return chooser
 }
 
+// scheduleSeed converts the seed hash into the seed of the weighted chooser.
+// The hash is reduced modulo MaxInt64 if fixOverflow is set.
+func scheduleSeed(seedHash common.Hash, fixOverflow bool) int64 {
+	if fixOverflow {
+		return new(big.Int).Mod(seedHash.Big(), bigMaxInt64).Int64()
+	}
+	return seedHash.Big().Int64()
+}
+
 func getPrevEpochLastBlockHash(
 	config *params.OasysConfig,
 	chain consensus.ChainHeaderReader,
@@ -271,7 +363,7 @@ func getPrevEpochLastBlockHash(
 	)
 	for ; number > epochStart; number-- {
 		if cache, ok := lastBlockHashes.Get(parent); ok {
-			parent = cache.(common.Hash)
+			parent = cache.(numberedHash).hash
 			break
 		}
 
@@ -289,7 +381,7 @@ func getPrevEpochLastBlockHash(
 			parent = h.ParentHash
 		} else if uncommitted, ok := uncommittedHashes.Get(parent); ok {
 			// Not committed to the database.
-			parent = uncommitted.(common.Hash)
+			parent = uncommitted.(numberedHash).hash
 		} else {
 			// Something is wrong.
 			return emptyHash, fmt.Errorf(
@@ -298,6 +390,26 @@ func getPrevEpochLastBlockHash(
 		}
 	}
 
-	lastBlockHashes.ContainsOrAdd(header.ParentHash, parent)
+	lastBlockHashes.ContainsOrAdd(header.ParentHash, numberedHash{parent, header.Number.Uint64() - 1})
 	return parent, nil
 }
+
+// pruneSchedulerCaches drops the cached hashes of the blocks at or below the
+// finalized block. These are never looked up again, as the new blocks are built
+// on the finalized chain, and the hashes of the stale forks left after reorgs
+// would otherwise stay until evicted by the LRU. It returns the number of the
+// pruned entries.
+func pruneSchedulerCaches(finalized uint64) int {
+	var pruned int
+	for _, cache := range []*lru.Cache{uncommittedHashes, lastBlockHashes} {
+		for _, key := range cache.Keys() {
+			if value, ok := cache.Peek(key); ok && value.(numberedHash).number <= finalized {
+				cache.Remove(key)
+				pruned++
+			}
+		}
+	}
+	uncommittedHashesGauge.Update(int64(uncommittedHashes.Len()))
+	lastBlockHashesGauge.Update(int64(lastBlockHashes.Len()))
+	return pruned
+}
@@ -1,10 +1,15 @@
 package oasys
 
 import (
+	"encoding/json"
+	"errors"
 	"math/big"
+	"os"
+	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/params"
 )
 
@@ -197,3 +202,322 @@ func TestDifficulty(t *testing.T) {
 		}
 	}
 }
+
+func TestTurnsWithUnequalStakes(t *testing.T) {
+	env := &params.EnvironmentValue{
+		StartBlock:  common.Big0,
+		StartEpoch:  common.Big1,
+		EpochPeriod: epochPeriod,
+	}
+	unequalStakes := []*big.Int{
+		new(big.Int).Mul(big.NewInt(50_000_000), ether),
+		new(big.Int).Mul(big.NewInt(20_000_000), ether),
+		new(big.Int).Mul(big.NewInt(10_000_000), ether),
+		new(big.Int).Mul(big.NewInt(1_000_000), ether),
+	}
+
+	for seed := int64(0); seed < 20; seed++ {
+		scheduler := newScheduler(env, 0, newWeightedChooser(validators, unequalStakes, seed))
+
+		// Replay the same schedule and compute the turns by scanning it forward.
+		chooser := newWeightedChooser(validators, unequalStakes, seed)
+		var choices []common.Address
+		for bpos := uint64(0); bpos < epochPeriod.Uint64(); bpos++ {
+			choices = append(choices, chooser.random())
+		}
+		for number := uint64(0); number < epochPeriod.Uint64(); number++ {
+			turns := map[common.Address]uint64{}
+			for bpos := number; len(turns) < len(validators); bpos++ {
+				if bpos >= uint64(len(choices)) {
+					choices = append(choices, chooser.random())
+				}
+				if _, ok := turns[choices[bpos]]; !ok {
+					turns[choices[bpos]] = uint64(len(turns))
+				}
+			}
+			for _, validator := range validators {
+				got, err := scheduler.turn(number, validator)
+				if err != nil {
+					t.Fatalf("failed to get turn: %v", err)
+				}
+				if got != turns[validator] {
+					t.Errorf("turn mismatch, seed %v, block %v, validator %v, got %v, want %v", seed, number, names[validator], got, turns[validator])
+				}
+			}
+		}
+	}
+}
+
+func TestTurnsWithSubEtherStakes(t *testing.T) {
+	env := &params.EnvironmentValue{
+		StartBlock:         common.Big0,
+		StartEpoch:         common.Big1,
+		EpochPeriod:        epochPeriod,
+		ValidatorThreshold: new(big.Int).Mul(ether, big.NewInt(10_000_000)),
+	}
+	subEtherStakes := []*big.Int{
+		new(big.Int).Mul(big.NewInt(10_000_000), ether),
+		new(big.Int).Div(ether, big.NewInt(2)),
+		new(big.Int).Mul(big.NewInt(20_000_000), ether),
+		common.Big0,
+	}
+	unweighted := map[common.Address]bool{validators[1]: true, validators[3]: true}
+
+	for seed := int64(0); seed < 20; seed++ {
+		scheduler := newScheduler(env, 0, newWeightedChooser(validators, subEtherStakes, seed))
+
+		for number := uint64(0); number < epochPeriod.Uint64(); number++ {
+			turns := make(map[uint64]bool)
+			for _, validator := range validators {
+				turn, err := scheduler.turn(number, validator)
+				if err != nil {
+					t.Fatalf("failed to get turn: %v", err)
+				}
+				if unweighted[validator] && turn < 2 {
+					t.Errorf("unweighted validator ahead, seed %v, block %v, validator %v, turn %v", seed, number, names[validator], turn)
+				}
+				turns[turn] = true
+			}
+			if len(turns) != len(validators) {
+				t.Errorf("duplicate turns, seed %v, block %v", seed, number)
+			}
+			if unweighted[*scheduler.expect(number)] {
+				t.Errorf("unweighted validator scheduled, seed %v, block %v", seed, number)
+			}
+			scheduler.difficulty(number, validators[3], true)
+		}
+	}
+}
+
+func BenchmarkDifficulty(b *testing.B) {
+	env := &params.EnvironmentValue{
+		StartBlock:         common.Big0,
+		StartEpoch:         common.Big1,
+		EpochPeriod:        big.NewInt(5760),
+		ValidatorThreshold: new(big.Int).Mul(ether, big.NewInt(10_000_000)),
+	}
+	scheduler := newScheduler(env, 0, newWeightedChooser(validators, stakes, 0))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		number := uint64(i) % env.EpochPeriod.Uint64()
+		scheduler.difficulty(number, validators[i%len(validators)], true)
+		scheduler.backOffTime(number, validators[i%len(validators)])
+	}
+}
+
+func TestScheduleVectors(t *testing.T) {
+	data, err := os.ReadFile("testdata/schedule_vectors.json")
+	if err != nil {
+		t.Fatalf("failed to read vectors: %v", err)
+	}
+	var vectors []*ScheduleVector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatalf("failed to decode vectors: %v", err)
+	}
+	for i, want := range vectors {
+		got, err := GenerateScheduleVector(want.SeedHash, want.Validators, want.EpochPeriod)
+		if err != nil {
+			t.Fatalf("vector %d: failed to generate: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("vector %d: schedule mismatch, run go generate if the algorithm has been changed", i)
+		}
+	}
+}
+
+func TestGenerateScheduleVectorLimits(t *testing.T) {
+	valid := []ScheduleValidator{{Address: validators[0], Stake: (*hexutil.Big)(stakes[0])}}
+	if _, err := GenerateScheduleVector(common.Hash{}, valid, maxScheduleEpochPeriod); err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	for i, tt := range []struct {
+		validators []ScheduleValidator
+		period     uint64
+	}{
+		{nil, 40},
+		{valid, 0},
+		{valid, maxScheduleEpochPeriod + 1},
+		{make([]ScheduleValidator, maxScheduleValidators+1), 40},
+		{[]ScheduleValidator{valid[0], {Address: validators[1], Stake: (*hexutil.Big)(common.Big0)}}, 40},
+		{[]ScheduleValidator{valid[0], {Address: validators[1], Stake: (*hexutil.Big)(new(big.Int).Sub(ether, common.Big1))}}, 40},
+		{[]ScheduleValidator{valid[0], {Address: validators[1]}}, 40},
+		{[]ScheduleValidator{valid[0], valid[0]}, 40},
+	} {
+		if _, err := GenerateScheduleVector(common.Hash{}, tt.validators, tt.period); err == nil {
+			t.Errorf("case %d: invalid input accepted", i)
+		}
+	}
+}
+
+func TestPruneSchedulerCaches(t *testing.T) {
+	defer uncommittedHashes.Purge()
+	defer lastBlockHashes.Purge()
+
+	for i := uint64(1); i <= 10; i++ {
+		hash := common.BigToHash(new(big.Int).SetUint64(i))
+		uncommittedHashes.Add(hash, numberedHash{common.Hash{}, i})
+		lastBlockHashes.Add(hash, numberedHash{common.Hash{}, i})
+	}
+	if pruned := pruneSchedulerCaches(6); pruned != 12 {
+		t.Errorf("pruned entries mismatch, want: 12, got: %d", pruned)
+	}
+	for i := uint64(1); i <= 10; i++ {
+		hash := common.BigToHash(new(big.Int).SetUint64(i))
+		if want := i > 6; uncommittedHashes.Contains(hash) != want || lastBlockHashes.Contains(hash) != want {
+			t.Errorf("cached entry mismatch, number: %d, want: %v", i, want)
+		}
+	}
+}
+
+func TestShortenedBlockTimeVector(t *testing.T) {
+	vector, err := decodeForkVector(shortenedBlockTimeVector)
+	if errors.Is(err, errNoForkVector) {
+		t.Skip("no mainnet vector captured, run go generate with OASYS_MAINNET_RPC")
+	}
+	if err != nil {
+		t.Fatalf("failed to decode vector: %v", err)
+	}
+	if err := checkForkVector(vector); err != nil {
+		t.Errorf("the binary is not ready for the shortened block time fork: %v", err)
+	}
+}
+
+func TestCheckForkVector(t *testing.T) {
+	var (
+		env = &params.EnvironmentValue{
+			StartBlock:  big.NewInt(100),
+			StartEpoch:  big.NewInt(5),
+			BlockPeriod: big.NewInt(params.SHORT_BLOCK_TIME_SECONDS),
+			EpochPeriod: big.NewInt(20),
+		}
+		validators = []ScheduleValidator{
+			{Address: common.Address{0x01}, Stake: (*hexutil.Big)(new(big.Int).Mul(ether, big.NewInt(10_000_000)))},
+			{Address: common.Address{0x02}, Stake: (*hexutil.Big)(new(big.Int).Mul(ether, big.NewInt(20_000_000)))},
+			{Address: common.Address{0x03}, Stake: (*hexutil.Big)(new(big.Int).Mul(ether, big.NewInt(30_000_000)))},
+		}
+		seedHash = common.HexToHash("0x01")
+	)
+	schedule, err := GenerateScheduleVector(seedHash, validators, env.EpochPeriod.Uint64())
+	if err != nil {
+		t.Fatalf("failed to generate schedule: %v", err)
+	}
+	// Blocks sealed in time by the proposers, and one by a validator in its back-off
+	capture := func() *ForkVector {
+		v := &ForkVector{Environment: env, SeedHash: seedHash, Validators: validators}
+		parentTime := uint64(1700000000)
+		for i := uint64(0); i < 10; i++ {
+			block := &ForkVectorBlock{Number: 100 + i, ParentTime: parentTime, Time: parentTime + 6, Coinbase: schedule.Schedule[i].Proposer}
+			if i == 5 {
+				for j, validator := range validators {
+					if validator.Address != block.Coinbase {
+						block.Coinbase, block.Time = validator.Address, parentTime+6+schedule.Schedule[i].BackOffTimes[j]
+						break
+					}
+				}
+			}
+			v.Blocks = append(v.Blocks, block)
+			parentTime = block.Time
+		}
+		return v
+	}
+	if err := checkForkVector(capture()); err != nil {
+		t.Fatalf("captured blocks rejected: %v", err)
+	}
+
+	// Rejected if the proposer or the back-off time differs from the binary
+	tests := map[string]func(v *ForkVector){
+		"early":       func(v *ForkVector) { v.Blocks[5].Time-- },
+		"other seed":  func(v *ForkVector) { v.SeedHash = common.HexToHash("0x02") },
+		"unknown":     func(v *ForkVector) { v.Blocks[3].Coinbase = common.Address{0x04} },
+		"not epoch":   func(v *ForkVector) { v.Blocks = v.Blocks[1:] },
+		"gap":         func(v *ForkVector) { v.Blocks = append(v.Blocks[:3], v.Blocks[4:]...) },
+		"other stake": func(v *ForkVector) { v.Validators = append([]ScheduleValidator{}, validators[1:]...) },
+	}
+	for name, tamper := range tests {
+		v := capture()
+		tamper(v)
+		if err := checkForkVector(v); err == nil {
+			t.Errorf("%s: tampered blocks accepted", name)
+		}
+	}
+}
+
+func TestProjectNextEpochValidators(t *testing.T) {
+	var (
+		env  = params.InitialEnvironmentValue(&params.OasysConfig{Period: 15, Epoch: 5760})
+		snap = newSnapshot(nil, nil, nil, 100, common.Hash{}, validators[:3], env)
+		kept = &nextValidators{Owners: validators[1:], Operators: validators[1:], Stakes: stakes[1:]}
+	)
+	for i, validator := range validators[:3] {
+		snap.Validators[validator].Stake = stakes[i]
+	}
+	schedule := &ValidatorSchedule{Epoch: 2, StartBlock: env.EpochStartBlock(2), Validators: scheduledValidators(kept)}
+
+	// The validator threshold switches to the next environment value only if
+	// it takes effect at the next epoch
+	threshold := new(big.Int).Mul(big.NewInt(20_000_000), ether)
+	for startEpoch, switched := range map[int64]bool{1: false, 2: true, 3: false} {
+		next := env.Copy()
+		next.StartEpoch = big.NewInt(startEpoch)
+		next.ValidatorThreshold = threshold
+
+		result := projectNextEpochValidators(snap, 100, next, schedule, kept)
+		want := env.ValidatorThreshold
+		if switched {
+			want = threshold
+		}
+		if result.ValidatorThreshold.ToInt().Cmp(want) != 0 {
+			t.Errorf("start epoch %d: threshold mismatch: have %v, want %v", startEpoch, result.ValidatorThreshold, want)
+		}
+		if result.Number != 100 || result.ValidatorSchedule != schedule {
+			t.Errorf("start epoch %d: schedule mismatch: have number %d", startEpoch, result.Number)
+		}
+
+		// The last validator joins and the first one leaves with its current stake
+		if len(result.Joining) != 1 || result.Joining[0].Operator != validators[3] {
+			t.Errorf("start epoch %d: joining mismatch: have %v", startEpoch, result.Joining)
+		}
+		if len(result.Leaving) != 1 || result.Leaving[0].Operator != validators[0] || result.Leaving[0].Stake.ToInt().Cmp(stakes[0]) != 0 {
+			t.Errorf("start epoch %d: leaving mismatch: have %v", startEpoch, result.Leaving)
+		}
+	}
+}
+
+func TestInTurnDifficulty(t *testing.T) {
+	env, err := makeVoteEnv(4)
+	if err != nil {
+		t.Fatalf("failed to create test vote env: %v", err)
+	}
+
+	// The block 1 is still before the extended difficulty fork
+	diff, err := env.engine.InTurnDifficulty(env.chain, env.chain.CurrentHeader())
+	if err != nil {
+		t.Fatalf("failed to get the in-turn difficulty: %v", err)
+	}
+	if diff.Cmp(diffInTurn) != 0 {
+		t.Errorf("in-turn difficulty mismatch before the fork, got: %v, want: %v", diff, diffInTurn)
+	}
+
+	header, err := env.newBlock()
+	if err != nil {
+		t.Fatalf("failed to build: %v", err)
+	}
+	env.chain.insert(header)
+
+	diff, err = env.engine.InTurnDifficulty(env.chain, header)
+	if err != nil {
+		t.Fatalf("failed to get the in-turn difficulty: %v", err)
+	}
+	want := new(big.Int).Div(totalSupply, env.genesis.Environment.ValidatorThreshold)
+	want.Mul(want, big.NewInt(4))
+	if diff.Cmp(want) != 0 {
+		t.Errorf("in-turn difficulty mismatch after the fork, got: %v, want: %v", diff, want)
+	}
+	if header, err = env.newBlock(); err != nil {
+		t.Fatalf("failed to build: %v", err)
+	}
+	if header.Difficulty.Cmp(want) != 0 {
+		t.Errorf("in-turn block difficulty mismatch, got: %v, want: %v", header.Difficulty, want)
+	}
+}
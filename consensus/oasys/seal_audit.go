@@ -0,0 +1,90 @@
+package oasys
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SealAudit is the breakdown of the seal verification of a header, for
+// investigating the blocks rejected by verifySeal.
+type SealAudit struct {
+	Number   uint64         `json:"number"`
+	Hash     common.Hash    `json:"hash"`
+	Coinbase common.Address `json:"coinbase"`
+
+	// Sealer recovered from the signature, zero if it cannot be recovered
+	Sealer       common.Address `json:"sealer"`
+	SealerError  string         `json:"sealerError,omitempty"`
+	Validator    bool           `json:"validator"` // Whether the coinbase is in the validator set
+	InTurn       common.Address `json:"inTurn"`    // In-turn proposer of the block
+	Turn         uint64         `json:"turn"`      // Turn of the coinbase, 0 being in-turn
+	BackOffTime  uint64         `json:"backOffTime"`
+	EarliestTime uint64         `json:"earliestTime"` // Earliest timestamp the coinbase may seal at
+
+	Difficulty         *big.Int `json:"difficulty"`
+	ExpectedDifficulty *big.Int `json:"expectedDifficulty"`
+
+	// Result of verifySeal, the error is empty if the seal passes
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// AuditSeal recomputes the values verifySeal checks the given header against:
+// the recovered sealer, the in-turn proposer, the expected difficulty and the
+// backoff time of the coinbase. The parent of the header must be known locally,
+// but the header itself does not have to be.
+func (c *Oasys) AuditSeal(chain consensus.ChainHeaderReader, header *types.Header) (*SealAudit, error) {
+	if header.Number == nil || header.Number.Sign() == 0 {
+		return nil, errUnknownBlock
+	}
+	number := header.Number.Uint64()
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	env, err := c.environment(chain, header, snap, true)
+	if err != nil {
+		return nil, err
+	}
+	validators, err := c.getNextValidators(chain, header, snap, true)
+	if err != nil {
+		return nil, err
+	}
+	scheduler, err := c.scheduler(chain, header, env, validators.Operators, validators.Stakes)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := &SealAudit{
+		Number:             number,
+		Hash:               header.Hash(),
+		Coinbase:           header.Coinbase,
+		Validator:          scheduler.exists(header.Coinbase),
+		InTurn:             *scheduler.expect(number),
+		BackOffTime:        scheduler.backOffTime(number, header.Coinbase),
+		Difficulty:         header.Difficulty,
+		ExpectedDifficulty: scheduler.difficulty(number, header.Coinbase, c.chainConfig.IsForkedOasysExtendDifficulty(header.Number)),
+	}
+	audit.EarliestTime = parent.Time + env.BlockPeriod.Uint64() + audit.BackOffTime
+	if sealer, err := ecrecover(header, c.signatures); err != nil {
+		audit.SealerError = err.Error()
+	} else {
+		audit.Sealer = sealer
+	}
+	if audit.Validator {
+		audit.Turn, _ = scheduler.turn(number, header.Coinbase)
+	}
+	if err := c.verifySeal(chain, header, nil, scheduler); err != nil {
+		audit.Error = err.Error()
+	} else {
+		audit.Valid = true
+	}
+	return audit, nil
+}
@@ -0,0 +1,111 @@
+package oasys
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	// sealGuardPrefix is the database key prefix of the sealed headers recorded
+	// by the seal guard, followed by the signer and the block number.
+	sealGuardPrefix = []byte("oasys-sealed-")
+
+	// sealGuardHighestPrefix is the database key prefix of the highest block
+	// number sealed by the signer.
+	sealGuardHighestPrefix = []byte("oasys-sealed-highest-")
+
+	// errDoubleSign is returned if a header is refused to be signed, as another
+	// header of the same or a higher number has been signed by the same signer.
+	errDoubleSign = errors.New("refused to double sign")
+
+	doubleSignRefusedCounter = metrics.NewRegisteredCounter("oasys/sealguard/refused", nil)
+)
+
+func sealGuardKey(signer common.Address, number uint64) []byte {
+	key := append(append(append([]byte{}, sealGuardPrefix...), signer.Bytes()...), make([]byte, 8)...)
+	binary.BigEndian.PutUint64(key[len(key)-8:], number)
+	return key
+}
+
+func sealGuardHighestKey(signer common.Address) []byte {
+	return append(append([]byte{}, sealGuardHighestPrefix...), signer.Bytes()...)
+}
+
+// SetSealGuard enables the slashing protection of the block seals, recording
+// every header signed by the engine into the given database before the signature
+// is released. The database is kept apart from the chain data, so that the
+// records survive a resync of the chain.
+func (c *Oasys) SetSealGuard(db ethdb.KeyValueStore) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.sealGuard = db
+}
+
+// currentSealGuard returns the database of the seal guard, or nil if disabled.
+func (c *Oasys) currentSealGuard() ethdb.KeyValueStore {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.sealGuard
+}
+
+// guardSeal checks that signing the header can't be a double sign of the signer
+// and records it. The header is refused if the signer has already signed another
+// header of the same number, or any of a higher number, or if the canonical chain
+// already contains a block of the signer at the number, which was sealed by
+// another node sharing the key.
+func (c *Oasys) guardSeal(chain consensus.ChainHeaderReader, signer common.Address, header *types.Header) error {
+	db := c.currentSealGuard()
+	if db == nil {
+		return nil
+	}
+	var (
+		number   = header.Number.Uint64()
+		sealHash = types.SealHash(header)
+	)
+	c.sealGuardLock.Lock()
+	defer c.sealGuardLock.Unlock()
+
+	if blob, _ := db.Get(sealGuardKey(signer, number)); len(blob) > 0 {
+		if common.BytesToHash(blob) == sealHash {
+			return nil // Signing the same header again is harmless
+		}
+		doubleSignRefusedCounter.Inc(1)
+		return errDoubleSign
+	}
+	if blob, _ := db.Get(sealGuardHighestKey(signer)); len(blob) == 8 {
+		if highest := binary.BigEndian.Uint64(blob); highest >= number {
+			doubleSignRefusedCounter.Inc(1)
+			log.Warn("Refused to seal below the highest sealed block", "number", number, "highest", highest, "signer", signer)
+			return errDoubleSign
+		}
+	}
+	if canonical := chain.GetHeaderByNumber(number); canonical != nil && canonical.Coinbase == signer && types.SealHash(canonical) != sealHash {
+		doubleSignRefusedCounter.Inc(1)
+		log.Warn("Refused to seal over a canonical block of the signer", "number", number, "hash", canonical.Hash(), "signer", signer)
+		return errDoubleSign
+	}
+
+	batch := db.NewBatch()
+	batch.Put(sealGuardKey(signer, number), sealHash.Bytes())
+	batch.Put(sealGuardHighestKey(signer), binary.BigEndian.AppendUint64(nil, number))
+	return batch.Write()
+}
+
+// HasSealed returns whether the header was signed by the engine, as recorded by
+// the seal guard. It's always false if the seal guard is disabled.
+func (c *Oasys) HasSealed(header *types.Header) bool {
+	db := c.currentSealGuard()
+	if db == nil {
+		return false
+	}
+	blob, _ := db.Get(sealGuardKey(header.Coinbase, header.Number.Uint64()))
+	return len(blob) > 0 && common.BytesToHash(blob) == types.SealHash(header)
+}
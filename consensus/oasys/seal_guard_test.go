@@ -0,0 +1,56 @@
+package oasys
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// canonicalHeaders is a chain reader serving the canonical headers by number.
+type canonicalHeaders struct {
+	consensus.ChainHeaderReader
+	headers map[uint64]*types.Header
+}
+
+func (c *canonicalHeaders) GetHeaderByNumber(number uint64) *types.Header {
+	return c.headers[number]
+}
+
+func TestGuardSeal(t *testing.T) {
+	var (
+		signer = common.Address{0x01}
+		engine = &Oasys{}
+		chain  = &canonicalHeaders{headers: make(map[uint64]*types.Header)}
+		header = func(number int64, gasLimit uint64) *types.Header {
+			return &types.Header{Number: big.NewInt(number), Coinbase: signer, Extra: append(make([]byte, extraVanity), make([]byte, extraSeal)...), GasLimit: gasLimit}
+		}
+	)
+	// Disabled without the database
+	require.NoError(t, engine.guardSeal(chain, signer, header(10, 0)))
+	require.False(t, engine.HasSealed(header(10, 0)))
+
+	engine.SetSealGuard(rawdb.NewMemoryDatabase())
+	require.NoError(t, engine.guardSeal(chain, signer, header(10, 0)))
+	require.True(t, engine.HasSealed(header(10, 0)))
+
+	// The same header can be signed again, but not another one of the same number
+	require.NoError(t, engine.guardSeal(chain, signer, header(10, 0)))
+	require.ErrorIs(t, engine.guardSeal(chain, signer, header(10, 1)), errDoubleSign)
+	require.False(t, engine.HasSealed(header(10, 1)))
+
+	// Nor a lower one
+	require.ErrorIs(t, engine.guardSeal(chain, signer, header(9, 0)), errDoubleSign)
+
+	// Nor over a canonical block of the signer sealed elsewhere
+	chain.headers[11] = header(11, 2)
+	require.ErrorIs(t, engine.guardSeal(chain, signer, header(11, 0)), errDoubleSign)
+	require.NoError(t, engine.guardSeal(chain, signer, header(12, 0)))
+
+	// Other signers are guarded apart
+	require.NoError(t, engine.guardSeal(chain, common.Address{0x02}, header(10, 1)))
+}
@@ -0,0 +1,122 @@
+package oasys
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// maxInmemorySignatures is the capacity the signature cache grows up to with
+	// the peer count and the sizes of the header batches verified.
+	maxInmemorySignatures = 32768
+
+	// headersPerPeer is the number of the headers fetched from a peer at once,
+	// the same as downloader.MaxHeaderFetch.
+	headersPerPeer = 192
+)
+
+var (
+	sigcacheHitMeter  = metrics.NewRegisteredMeter("oasys/sigcache/hit", nil)
+	sigcacheMissMeter = metrics.NewRegisteredMeter("oasys/sigcache/miss", nil)
+	sigcacheSizeGauge = metrics.NewRegisteredGauge("oasys/sigcache/size", nil)
+)
+
+// sigCache is the cache of the signers recovered from the block seals, shared by
+// the seal verification and the snapshots applying the headers. It is sized by
+// the peer count and grows with the header batches verified at once, so that
+// the signers recovered from a batch are still cached when the snapshots are
+// applied on it.
+type sigCache struct {
+	cache atomic.Pointer[lru.ARCCache]
+
+	lock sync.Mutex // Serializes the replacements of the cache
+	size int        // Capacity of the current cache, protected by lock
+}
+
+// newSigCache creates a signature cache of the given capacity.
+func newSigCache(size int) (*sigCache, error) {
+	s := new(sigCache)
+	if err := s.reset(size); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// get returns the signer of the given block, if cached.
+func (s *sigCache) get(hash common.Hash) (common.Address, bool) {
+	if signer, ok := s.cache.Load().Get(hash); ok {
+		sigcacheHitMeter.Mark(1)
+		return signer.(common.Address), true
+	}
+	sigcacheMissMeter.Mark(1)
+	return common.Address{}, false
+}
+
+// add caches the signer of the given block.
+func (s *sigCache) add(hash common.Hash, signer common.Address) {
+	s.cache.Load().Add(hash, signer)
+}
+
+// reset replaces the cache with an empty one of the given capacity.
+func (s *sigCache) reset(size int) error {
+	cache, err := lru.NewARC(size)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.cache.Store(cache)
+	s.size = size
+	sigcacheSizeGauge.Update(int64(size))
+	return nil
+}
+
+// fitPeers grows the cache to hold the signers of a header batch fetched from
+// each of the given number of peers.
+func (s *sigCache) fitPeers(peers int) {
+	s.grow(peers * headersPerPeer)
+}
+
+// fit grows the cache to hold the signers of a batch of the given number of
+// headers twice.
+func (s *sigCache) fit(headers int) {
+	s.grow(2 * headers)
+}
+
+// grow grows the cache up to the given capacity, keeping the cached signers.
+// The cache never shrinks, except by a reset.
+func (s *sigCache) grow(size int) {
+	if size > maxInmemorySignatures {
+		size = maxInmemorySignatures
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if size <= s.size {
+		return
+	}
+	cache, err := lru.NewARC(size)
+	if err != nil {
+		return
+	}
+	old := s.cache.Load()
+	for _, key := range old.Keys() {
+		if signer, ok := old.Peek(key); ok {
+			cache.Add(key, signer)
+		}
+	}
+	s.cache.Store(cache)
+	s.size = size
+	sigcacheSizeGauge.Update(int64(size))
+}
+
+// SetMaxPeers sizes the signature cache to hold the header batches fetched from
+// the given number of peers at once.
+func (c *Oasys) SetMaxPeers(peers int) {
+	c.signatures.fitPeers(peers)
+}
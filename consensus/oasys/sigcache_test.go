@@ -0,0 +1,63 @@
+package oasys
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestSigCacheFit(t *testing.T) {
+	cache, err := newSigCache(4)
+	if err != nil {
+		t.Fatalf("failed to create signature cache: %v", err)
+	}
+	hash, signer := common.HexToHash("0x01"), common.HexToAddress("0x02")
+	cache.add(hash, signer)
+
+	// Growing keeps the cached signers
+	cache.fit(100)
+	if cache.size != 200 {
+		t.Errorf("size mismatch after growing: have %d, want %d", cache.size, 200)
+	}
+	if got, ok := cache.get(hash); !ok || got != signer {
+		t.Errorf("signer lost after growing: have %v %x", ok, got)
+	}
+	for i := 0; i < 200; i++ {
+		cache.add(common.BigToHash(big.NewInt(int64(i+2))), signer)
+	}
+	if got := cache.cache.Load().Len(); got != 200 {
+		t.Errorf("entries mismatch: have %d, want %d", got, 200)
+	}
+
+	// A smaller batch never shrinks it, and the growth is capped
+	cache.fit(10)
+	if cache.size != 200 {
+		t.Errorf("size mismatch after smaller batch: have %d, want %d", cache.size, 200)
+	}
+	cache.fit(maxInmemorySignatures)
+	if cache.size != maxInmemorySignatures {
+		t.Errorf("size mismatch after huge batch: have %d, want %d", cache.size, maxInmemorySignatures)
+	}
+
+	// The peer count grows it the same way
+	if err := cache.reset(8); err != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+	cache.fitPeers(50)
+	if want := 50 * headersPerPeer; cache.size != want {
+		t.Errorf("size mismatch after peers: have %d, want %d", cache.size, want)
+	}
+
+	// A reset empties the cache shared with the existing snapshots
+	snap := &Snapshot{sigcache: cache}
+	if err := cache.reset(8); err != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+	if _, ok := snap.sigcache.get(hash); ok {
+		t.Error("signer cached after reset")
+	}
+	if snap.sigcache.size != 8 {
+		t.Errorf("size mismatch after reset: have %d, want %d", snap.sigcache.size, 8)
+	}
+}
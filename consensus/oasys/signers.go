@@ -0,0 +1,81 @@
+package oasys
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// errExtraSignerMainnet is returned if an additional signer is authorized on the
+// mainnet, where a node must seal for a single validator.
+var errExtraSignerMainnet = errors.New("additional signers are not allowed on the mainnet")
+
+// AddSigner authorizes the engine to seal the blocks for another validator on
+// top of the one authorized by Authorize, so that a single node can run several
+// small validators of a test network. The engine seals whenever any of them is
+// scheduled, for the one proposing the earliest. The votes are still cast for
+// the validator authorized by Authorize only. Authorize drops the additional
+// signers, so they must be added after it.
+func (c *Oasys) AddSigner(signer common.Address, signFn SignerFn, txSignFn TxSignerFn) error {
+	if c.chainConfig.ChainID != nil && c.chainConfig.ChainID.Cmp(params.OasysMainnetChainConfig.ChainID) == 0 {
+		return errExtraSignerMainnet
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.readOnly {
+		return errReadOnly
+	}
+	if c.signerState == nil || c.signerState.signer == (common.Address{}) {
+		return errors.New("no signer authorized")
+	}
+	if c.signerState.lookup(signer) != nil {
+		return nil
+	}
+	state := *c.signerState
+	state.version++
+	state.extra = append(append([]*signerState{}, state.extra...), &signerState{
+		signer:   signer,
+		signFn:   signFn,
+		txSignFn: txSignFn,
+	})
+	c.signerState = &state
+
+	log.Warn("Authorized additional signer, for test networks only", "signer", signer, "signers", len(state.extra)+1)
+	return nil
+}
+
+// lookup returns the signing identity of the given validator, either the one
+// authorized or an additional signer, or nil if the validator isn't authorized.
+func (s *signerState) lookup(validator common.Address) *signerState {
+	if validator == s.signer {
+		return s
+	}
+	for _, extra := range s.extra {
+		if extra.signer == validator {
+			return extra
+		}
+	}
+	return nil
+}
+
+// proposer returns the authorized validator of the earliest turn at the given
+// block, or the one authorized by Authorize if none of them is scheduled.
+func (s *signerState) proposer(scheduler *scheduler, number uint64) common.Address {
+	var (
+		proposer = s.signer
+		earliest = ^uint64(0)
+	)
+	for _, key := range append([]*signerState{s}, s.extra...) {
+		turn, err := scheduler.turn(number, key.signer)
+		if err != nil {
+			continue
+		}
+		if turn < earliest {
+			proposer, earliest = key.signer, turn
+		}
+	}
+	return proposer
+}
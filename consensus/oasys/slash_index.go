@@ -0,0 +1,248 @@
+package oasys
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	// slashIndexPrefix + validator + number + sequence -> SlashRecord
+	slashIndexPrefix = []byte("oasys-slashindex-")
+
+	// slashIndexHeadKey tracks the latest block number indexed.
+	slashIndexHeadKey = []byte("oasys-slashindexhead")
+
+	// Events emitted by the StakeManager contract, the validator is the owner.
+	validatorSlashedTopic = crypto.Keccak256Hash([]byte("ValidatorSlashed(address)"))
+	validatorJailedTopic  = crypto.Keccak256Hash([]byte("ValidatorJailed(address,uint256)"))
+)
+
+// Kinds of the slash records.
+const (
+	SlashKindSlashTx = "slashTx" // Slash transaction injected by the engine, the validator is the operator
+	SlashKindSlashed = "slashed" // ValidatorSlashed event, the validator is the owner
+	SlashKindJailed  = "jailed"  // ValidatorJailed event, the validator is the owner
+)
+
+// SlashRecord is a slash or jail of a validator found in a block.
+type SlashRecord struct {
+	Kind      string         `json:"kind"`
+	Validator common.Address `json:"validator"`
+	Number    hexutil.Uint64 `json:"number"`
+	TxHash    common.Hash    `json:"txHash"`
+	Blocks    hexutil.Uint64 `json:"blocks,omitempty"` // Blocks scheduled to the validator, for the slash transactions
+	Until     hexutil.Uint64 `json:"until,omitempty"`  // Epoch the jail period ends, for the jail events
+	Epoch     hexutil.Uint64 `json:"epoch" rlp:"-"`    // Resolved when queried
+}
+
+// SlashIndexChain defines the small collection of methods needed to index the
+// slash records from the local chain.
+type SlashIndexChain interface {
+	consensus.ChainHeaderReader
+	GetBlock(hash common.Hash, number uint64) *types.Block
+	GetReceiptsByHash(hash common.Hash) types.Receipts
+}
+
+func slashRecordKey(validator common.Address, number uint64, seq uint16) []byte {
+	key := make([]byte, 0, len(slashIndexPrefix)+common.AddressLength+10)
+	key = append(key, slashIndexPrefix...)
+	key = append(key, validator[:]...)
+	key = binary.BigEndian.AppendUint64(key, number)
+	return binary.BigEndian.AppendUint16(key, seq)
+}
+
+// findSlashRecords extracts the slash transactions and the slash and jail
+// events of the StakeManager from the block.
+func (s *systemContracts) findSlashRecords(block *types.Block, receipts types.Receipts) []*SlashRecord {
+	var (
+		records []*SlashRecord
+		slash   = s.stakeManager.abi.Methods["slash"]
+		number  = hexutil.Uint64(block.NumberU64())
+	)
+	for i, tx := range block.Transactions() {
+		var receipt *types.Receipt
+		if i < len(receipts) {
+			receipt = receipts[i]
+		}
+		if receipt == nil || receipt.Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+		if to := tx.To(); to != nil && *to == s.stakeManager.address && bytes.HasPrefix(tx.Data(), slash.ID) {
+			if args, err := slash.Inputs.Unpack(tx.Data()[len(slash.ID):]); err == nil && len(args) == 2 {
+				operator, _ := args[0].(common.Address)
+				blocks, _ := args[1].(*big.Int)
+				record := &SlashRecord{Kind: SlashKindSlashTx, Validator: operator, Number: number, TxHash: tx.Hash()}
+				if blocks != nil {
+					record.Blocks = hexutil.Uint64(blocks.Uint64())
+				}
+				records = append(records, record)
+			}
+		}
+		for _, l := range receipt.Logs {
+			if l.Address != s.stakeManager.address || len(l.Topics) < 2 {
+				continue
+			}
+			record := &SlashRecord{Validator: common.BytesToAddress(l.Topics[1][:]), Number: number, TxHash: tx.Hash()}
+			switch l.Topics[0] {
+			case validatorSlashedTopic:
+				record.Kind = SlashKindSlashed
+			case validatorJailedTopic:
+				record.Kind = SlashKindJailed
+				if len(l.Data) >= common.HashLength {
+					record.Until = hexutil.Uint64(new(big.Int).SetBytes(l.Data[:common.HashLength]).Uint64())
+				}
+			default:
+				continue
+			}
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// SlashIndexHead returns the latest block number indexed, or zero if none.
+func (c *Oasys) SlashIndexHead() uint64 {
+	blob, err := c.db.Get(slashIndexHeadKey)
+	if err != nil || len(blob) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(blob)
+}
+
+// IndexSlashes scans the canonical blocks in the range [from, to] and indexes
+// the slash records found into the consensus database. The progress callback
+// is invoked after each block is indexed, if given.
+func (c *Oasys) IndexSlashes(chain SlashIndexChain, from, to uint64, progress func(number uint64, records int)) (int, error) {
+	var (
+		batch   = c.db.NewBatch()
+		indexed int
+	)
+	for number := from; number <= to; number++ {
+		header := chain.GetHeaderByNumber(number)
+		if header == nil {
+			return indexed, errUnknownBlock
+		}
+		block := chain.GetBlock(header.Hash(), number)
+		if block == nil {
+			return indexed, errUnknownBlock
+		}
+		records := c.contracts.findSlashRecords(block, chain.GetReceiptsByHash(header.Hash()))
+		for i, record := range records {
+			blob, err := rlp.EncodeToBytes(record)
+			if err != nil {
+				return indexed, err
+			}
+			if err := batch.Put(slashRecordKey(record.Validator, number, uint16(i)), blob); err != nil {
+				return indexed, err
+			}
+		}
+		indexed += len(records)
+
+		if batch.ValueSize() > ethdb.IdealBatchSize || number == to {
+			if err := batch.Put(slashIndexHeadKey, binary.BigEndian.AppendUint64(nil, number)); err != nil {
+				return indexed, err
+			}
+			if err := batch.Write(); err != nil {
+				return indexed, err
+			}
+			batch.Reset()
+		}
+		if progress != nil {
+			progress(number, len(records))
+		}
+	}
+	return indexed, nil
+}
+
+// slashRecords returns the indexed slash records of the validator in the block
+// range [from, to], in ascending order of the block number.
+func slashRecords(db ethdb.Database, validator common.Address, from, to uint64) ([]*SlashRecord, error) {
+	var (
+		prefix  = append(append([]byte{}, slashIndexPrefix...), validator[:]...)
+		it      = db.NewIterator(prefix, binary.BigEndian.AppendUint64(nil, from))
+		records []*SlashRecord
+	)
+	defer it.Release()
+
+	for it.Next() {
+		if len(it.Key()) != len(prefix)+10 {
+			continue
+		}
+		if binary.BigEndian.Uint64(it.Key()[len(prefix):]) > to {
+			break
+		}
+		record := new(SlashRecord)
+		if err := rlp.DecodeBytes(it.Value(), record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, it.Error()
+}
+
+// deleteSlashRecords removes the indexed slash records created after the given
+// block number, and rewinds the index head.
+func deleteSlashRecords(db ethdb.Database, number uint64) error {
+	it := db.NewIterator(slashIndexPrefix, nil)
+	defer it.Release()
+
+	batch := db.NewBatch()
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(slashIndexPrefix)+common.AddressLength+10 {
+			continue
+		}
+		if binary.BigEndian.Uint64(key[len(slashIndexPrefix)+common.AddressLength:]) > number {
+			if err := batch.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if blob, err := db.Get(slashIndexHeadKey); err == nil && len(blob) == 8 && binary.BigEndian.Uint64(blob) > number {
+		if err := batch.Put(slashIndexHeadKey, binary.BigEndian.AppendUint64(nil, number)); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// epochFirstBlock returns the first block of the epoch, following the history
+// of the environment values backward from the given header.
+func (c *Oasys) epochFirstBlock(chain consensus.ChainHeaderReader, header *types.Header, epoch uint64) (uint64, error) {
+	for {
+		snap, err := c.snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
+		if err != nil {
+			return 0, err
+		}
+		env := snap.Environment
+		if epoch >= env.StartEpoch.Uint64() || env.StartBlock.Sign() == 0 {
+			if epoch < env.StartEpoch.Uint64() {
+				return 0, nil
+			}
+			return env.EpochStartBlock(epoch), nil
+		}
+		if header = chain.GetHeaderByNumber(env.StartBlock.Uint64() - 1); header == nil {
+			return 0, errors.New("unknown environment history")
+		}
+	}
+}
+
+// sortSlashRecords sorts the records in ascending order of the block number,
+// keeping the order within the block.
+func sortSlashRecords(records []*SlashRecord) {
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Number < records[j].Number })
+}
@@ -0,0 +1,58 @@
+package oasys
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlashRecords(t *testing.T) {
+	var (
+		db       = rawdb.NewMemoryDatabase()
+		operator = common.HexToAddress("0x01")
+		owner    = common.HexToAddress("0x02")
+	)
+	data, err := stakeManager.abi.Pack("slash", operator, big.NewInt(10))
+	require.NoError(t, err)
+	tx := types.NewTx(&types.LegacyTx{To: &stakeManager.address, Data: data})
+	receipt := &types.Receipt{
+		Status: types.ReceiptStatusSuccessful,
+		Logs: []*types.Log{
+			{Address: stakeManager.address, Topics: []common.Hash{validatorSlashedTopic, common.BytesToHash(owner[:])}},
+			{Address: stakeManager.address, Topics: []common.Hash{validatorJailedTopic, common.BytesToHash(owner[:])}, Data: common.BigToHash(big.NewInt(5)).Bytes()},
+		},
+	}
+	for _, number := range []uint64{100, 200} {
+		block := types.NewBlockWithHeader(&types.Header{Number: new(big.Int).SetUint64(number)}).WithBody([]*types.Transaction{tx}, nil)
+		records := newSystemContracts(nil).findSlashRecords(block, types.Receipts{receipt})
+		require.Len(t, records, 3)
+		require.Equal(t, &SlashRecord{Kind: SlashKindSlashTx, Validator: operator, Number: hexutil.Uint64(number), TxHash: tx.Hash(), Blocks: 10}, records[0])
+		require.Equal(t, SlashKindSlashed, records[1].Kind)
+		require.Equal(t, &SlashRecord{Kind: SlashKindJailed, Validator: owner, Number: hexutil.Uint64(number), TxHash: tx.Hash(), Until: 5}, records[2])
+
+		for i, record := range records {
+			blob, err := rlp.EncodeToBytes(record)
+			require.NoError(t, err)
+			require.NoError(t, db.Put(slashRecordKey(record.Validator, number, uint16(i)), blob))
+		}
+	}
+
+	records, err := slashRecords(db, owner, 0, 150)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	records, err = slashRecords(db, operator, 100, 200)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	require.NoError(t, deleteSlashRecords(db, 150))
+	records, err = slashRecords(db, operator, 0, 1000)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, hexutil.Uint64(100), records[0].Number)
+}
@@ -15,13 +15,30 @@ import (
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
-	lru "github.com/hashicorp/golang-lru"
+)
+
+// snapshotPrefix is the database key prefix of the persisted snapshots.
+var snapshotPrefix = []byte("oasys-")
+
+// snapshotVersion is the version of the persisted snapshot encoding, stored as
+// the first byte of the blob. The snapshots persisted before the versioning are
+// bare JSON objects, distinguished by their leading '{', and are regarded as
+// version 0.
+const snapshotVersion = 1
+
+var (
+	// errEmptySnapshot is returned if the persisted snapshot blob is empty.
+	errEmptySnapshot = errors.New("empty snapshot")
+
+	// errUnknownSnapshotVersion is returned if the persisted snapshot is encoded
+	// with a version unknown to this node, most likely by a newer release.
+	errUnknownSnapshotVersion = errors.New("unknown snapshot version")
 )
 
 // Snapshot is the state of the authorization voting at a given point in time.
 type Snapshot struct {
 	config   *params.ChainConfig // Consensus engine parameters to fine tune behavior
-	sigcache *lru.ARCCache       // Cache of recent block signatures to speed up ecrecover
+	sigcache *sigCache           // Cache of recent block signatures to speed up ecrecover
 	ethAPI   *ethapi.BlockChainAPI
 
 	Number      uint64                            `json:"number"`                // Block number where the snapshot was created
@@ -71,7 +88,7 @@ func (s validatorsAscending) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 // newSnapshot creates a new snapshot with the specified startup parameters. This
 // method does not initialize the set of recent validators, so only ever use if for
 // the genesis block.
-func newSnapshot(config *params.ChainConfig, sigcache *lru.ARCCache, ethAPI *ethapi.BlockChainAPI,
+func newSnapshot(config *params.ChainConfig, sigcache *sigCache, ethAPI *ethapi.BlockChainAPI,
 	number uint64, hash common.Hash, validators []common.Address, environment *params.EnvironmentValue) *Snapshot {
 	snap := &Snapshot{
 		config:      config,
@@ -92,14 +109,14 @@ func newSnapshot(config *params.ChainConfig, sigcache *lru.ARCCache, ethAPI *eth
 }
 
 // loadSnapshot loads an existing snapshot from the database.
-func loadSnapshot(config *params.ChainConfig, sigcache *lru.ARCCache, ethAPI *ethapi.BlockChainAPI,
+func loadSnapshot(config *params.ChainConfig, sigcache *sigCache, ethAPI *ethapi.BlockChainAPI,
 	db ethdb.Database, hash common.Hash) (*Snapshot, error) {
-	blob, err := db.Get(append([]byte("oasys-"), hash[:]...))
+	blob, err := db.Get(append(snapshotPrefix, hash[:]...))
 	if err != nil {
 		return nil, err
 	}
-	snap := new(Snapshot)
-	if err := json.Unmarshal(blob, snap); err != nil {
+	snap, err := decodeSnapshot(blob)
+	if err != nil {
 		return nil, err
 	}
 	snap.config = config
@@ -111,11 +128,41 @@ func loadSnapshot(config *params.ChainConfig, sigcache *lru.ARCCache, ethAPI *et
 
 // store inserts the snapshot into the database.
 func (s *Snapshot) store(db ethdb.Database) error {
-	blob, err := json.Marshal(s)
+	blob, err := encodeSnapshot(s)
 	if err != nil {
 		return err
 	}
-	return db.Put(append([]byte("oasys-"), s.Hash[:]...), blob)
+	return db.Put(append(snapshotPrefix, s.Hash[:]...), blob)
+}
+
+// deleteSnapshots removes the persisted snapshots created after the given block
+// number, regardless of whether they belong to the canonical chain or not.
+func deleteSnapshots(db ethdb.Database, number uint64) (int, error) {
+	it := db.NewIterator(snapshotPrefix, nil)
+	defer it.Release()
+
+	var (
+		batch   = db.NewBatch()
+		deleted int
+	)
+	for it.Next() {
+		if len(it.Key()) != len(snapshotPrefix)+common.HashLength {
+			continue
+		}
+		snap, err := decodeSnapshot(it.Value())
+		if err != nil {
+			log.Warn("Failed to decode snapshot", "key", common.Bytes2Hex(it.Key()), "err", err)
+			continue
+		}
+		if snap.Number > number {
+			batch.Delete(common.CopyBytes(it.Key()))
+			deleted++
+		}
+	}
+	if err := it.Error(); err != nil {
+		return 0, err
+	}
+	return deleted, batch.Write()
 }
 
 // copy creates a deep copy of the snapshot, though not the individual votes.
@@ -184,8 +231,8 @@ func (s *Snapshot) updateAttestation(header *types.Header, chainConfig *params.C
 }
 
 // apply creates a new authorization snapshot by applying the given headers to
-// the original one.
-func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderReader, oasysConfig *params.OasysConfig) (*Snapshot, error) {
+// the original one, reading the validators of the epochs from the contracts.
+func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderReader, oasysConfig *params.OasysConfig, contracts *systemContracts) (*Snapshot, error) {
 	// Allow passing in no headers for cleaner code
 	if len(headers) == 0 {
 		return s, nil
@@ -214,13 +261,13 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 		if number > 0 && snap.Environment.IsEpoch(number) {
 			var nextValidator *nextValidators
 			if s.config.IsFastFinalityEnabled(header.Number) {
-				if nextValidator, err = getValidatorsFromHeader(header); err != nil {
+				if nextValidator, err = getValidatorsFromHeader(header, s.config); err != nil {
 					log.Warn("failed to get validators from header", "in", "Snapshot.apply", "hash", header.Hash(), "number", number, "err", err)
 				}
 			}
 			// If not fast finality or failed to get validators from header
 			if nextValidator == nil {
-				if nextValidator, err = getNextValidators(s.config, s.ethAPI, header.ParentHash, snap.Environment.Epoch(number), number); err != nil {
+				if nextValidator, err = contracts.getNextValidators(s.config, s.ethAPI, header.ParentHash, snap.Environment.Epoch(number), number); err != nil {
 					return nil, fmt.Errorf("failed to get validators, in Snapshot.apply, err: %w", err)
 				}
 			}
@@ -228,7 +275,7 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 			if s.config.IsFastFinalityEnabled(header.Number) {
 				nextEnv, err = getEnvironmentFromHeader(header)
 			} else {
-				nextEnv, err = getNextEnvironmentValue(s.ethAPI, header.ParentHash)
+				nextEnv, err = contracts.getNextEnvironmentValue(s.ethAPI, header.ParentHash)
 			}
 			if err != nil {
 				log.Error("Failed to get environment value", "in", "Snapshot.apply", "hash", header.ParentHash, "number", number, "err", err)
@@ -0,0 +1,181 @@
+package oasys
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// snapshotV1 is the persisted form of the snapshot in version 1. It is kept
+// apart from the Snapshot, whose JSON is also served by the APIs, so that the
+// database schema only changes along with the version. None of the fields may
+// refer to a live type, which could change the schema on its own.
+type snapshotV1 struct {
+	Number      uint64                              `json:"number"`
+	Hash        common.Hash                         `json:"hash"`
+	Validators  map[common.Address]*validatorInfoV1 `json:"validators"`
+	Attestation *voteDataV1                         `json:"attestation,omitempty"`
+	Environment *environmentV1                      `json:"environment"`
+}
+
+// validatorInfoV1 is the persisted form of the ValidatorInfo in version 1,
+// without the fallback to the stake-only encoding of v1.5.0.
+type validatorInfoV1 struct {
+	Stake       *big.Int           `json:"stake,omitempty"`
+	Index       int                `json:"index,omitempty"`
+	VoteAddress types.BLSPublicKey `json:"vote_address,omitempty"`
+}
+
+// voteDataV1 is the persisted form of the VoteData in version 1.
+type voteDataV1 struct {
+	SourceNumber uint64      `json:"SourceNumber"`
+	SourceHash   common.Hash `json:"SourceHash"`
+	TargetNumber uint64      `json:"TargetNumber"`
+	TargetHash   common.Hash `json:"TargetHash"`
+}
+
+// environmentV1 is the persisted form of the EnvironmentValue in version 1.
+type environmentV1 struct {
+	StartBlock         *big.Int `json:"StartBlock"`
+	StartEpoch         *big.Int `json:"StartEpoch"`
+	BlockPeriod        *big.Int `json:"BlockPeriod"`
+	EpochPeriod        *big.Int `json:"EpochPeriod"`
+	RewardRate         *big.Int `json:"RewardRate"`
+	CommissionRate     *big.Int `json:"CommissionRate"`
+	ValidatorThreshold *big.Int `json:"ValidatorThreshold"`
+	JailThreshold      *big.Int `json:"JailThreshold"`
+	JailPeriod         *big.Int `json:"JailPeriod"`
+}
+
+// encodeSnapshot encodes the snapshot in the current version. The encoding is
+// deterministic, as the validators are written in the order of the addresses.
+func encodeSnapshot(s *Snapshot) ([]byte, error) {
+	enc := &snapshotV1{
+		Number:     s.Number,
+		Hash:       s.Hash,
+		Validators: make(map[common.Address]*validatorInfoV1, len(s.Validators)),
+	}
+	for address, info := range s.Validators {
+		enc.Validators[address] = &validatorInfoV1{
+			Stake:       info.Stake,
+			Index:       info.Index,
+			VoteAddress: info.VoteAddress,
+		}
+	}
+	if a := s.Attestation; a != nil {
+		enc.Attestation = &voteDataV1{
+			SourceNumber: a.SourceNumber,
+			SourceHash:   a.SourceHash,
+			TargetNumber: a.TargetNumber,
+			TargetHash:   a.TargetHash,
+		}
+	}
+	if e := s.Environment; e != nil {
+		enc.Environment = &environmentV1{
+			StartBlock:         e.StartBlock,
+			StartEpoch:         e.StartEpoch,
+			BlockPeriod:        e.BlockPeriod,
+			EpochPeriod:        e.EpochPeriod,
+			RewardRate:         e.RewardRate,
+			CommissionRate:     e.CommissionRate,
+			ValidatorThreshold: e.ValidatorThreshold,
+			JailThreshold:      e.JailThreshold,
+			JailPeriod:         e.JailPeriod,
+		}
+	}
+	blob, err := json.Marshal(enc)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{snapshotVersion}, blob...), nil
+}
+
+// decodeSnapshot decodes the persisted snapshot of any known version, migrating
+// the older versions to the current one.
+func decodeSnapshot(blob []byte) (*Snapshot, error) {
+	if len(blob) == 0 {
+		return nil, errEmptySnapshot
+	}
+	switch blob[0] {
+	case '{':
+		return decodeSnapshotV0(blob)
+	case 1:
+		return decodeSnapshotV1(blob[1:])
+	default:
+		return nil, fmt.Errorf("%w: %d", errUnknownSnapshotVersion, blob[0])
+	}
+}
+
+// decodeSnapshotV0 decodes the bare JSON snapshot persisted before the
+// versioning. The validators may still be in the stake-only encoding of v1.5.0.
+func decodeSnapshotV0(blob []byte) (*Snapshot, error) {
+	snap := new(Snapshot)
+	if err := json.Unmarshal(blob, snap); err != nil {
+		return nil, err
+	}
+	if snap.Validators == nil {
+		snap.Validators = make(map[common.Address]*ValidatorInfo)
+	}
+	return snap, nil
+}
+
+// decodeSnapshotV1 decodes the version 1 snapshot, rejecting the unknown fields
+// and the trailing data rather than silently dropping them.
+func decodeSnapshotV1(blob []byte) (*Snapshot, error) {
+	dec := json.NewDecoder(bytes.NewReader(blob))
+	dec.DisallowUnknownFields()
+
+	var enc snapshotV1
+	if err := dec.Decode(&enc); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("trailing data after snapshot")
+	}
+	if enc.Validators == nil || enc.Environment == nil {
+		return nil, errors.New("incomplete snapshot")
+	}
+	e := enc.Environment
+	snap := &Snapshot{
+		Number:     enc.Number,
+		Hash:       enc.Hash,
+		Validators: make(map[common.Address]*ValidatorInfo, len(enc.Validators)),
+		Environment: &params.EnvironmentValue{
+			StartBlock:         e.StartBlock,
+			StartEpoch:         e.StartEpoch,
+			BlockPeriod:        e.BlockPeriod,
+			EpochPeriod:        e.EpochPeriod,
+			RewardRate:         e.RewardRate,
+			CommissionRate:     e.CommissionRate,
+			ValidatorThreshold: e.ValidatorThreshold,
+			JailThreshold:      e.JailThreshold,
+			JailPeriod:         e.JailPeriod,
+		},
+	}
+	if a := enc.Attestation; a != nil {
+		snap.Attestation = &types.VoteData{
+			SourceNumber: a.SourceNumber,
+			SourceHash:   a.SourceHash,
+			TargetNumber: a.TargetNumber,
+			TargetHash:   a.TargetHash,
+		}
+	}
+	for address, info := range enc.Validators {
+		if info == nil || info.Stake == nil {
+			return nil, fmt.Errorf("incomplete validator %s in snapshot", address)
+		}
+		snap.Validators[address] = &ValidatorInfo{
+			Stake:       info.Stake,
+			Index:       info.Index,
+			VoteAddress: info.VoteAddress,
+		}
+	}
+	return snap, nil
+}
@@ -0,0 +1,175 @@
+package oasys
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// errNotCheckpoint is returned if the snapshot received from a remote
+	// peer is not created at a checkpoint block.
+	errNotCheckpoint = errors.New("snapshot is not at a checkpoint")
+
+	// errUnverifiableSnapshot is returned if the snapshot cannot be verified
+	// against the epoch header, as the header does not contain the validators.
+	errUnverifiableSnapshot = errors.New("snapshot cannot be verified by the epoch header")
+)
+
+// LatestCheckpoint returns the latest checkpoint block number at or before the
+// given block, at which the snapshots are persisted.
+func (c *Oasys) LatestCheckpoint(number uint64) uint64 {
+	return number - number%checkpointInterval
+}
+
+// HasSnapshot returns whether the snapshot of the given checkpoint block has
+// been persisted.
+func (c *Oasys) HasSnapshot(hash common.Hash) bool {
+	has, _ := c.db.Has(append(snapshotPrefix, hash[:]...))
+	return has
+}
+
+// EncodedSnapshot returns the persisted snapshot of the given checkpoint block
+// in its database encoding, to be served to the remote peers.
+func (c *Oasys) EncodedSnapshot(hash common.Hash) ([]byte, error) {
+	return c.db.Get(append(snapshotPrefix, hash[:]...))
+}
+
+// ImportSnapshot verifies the snapshot received from a remote peer and persists
+// it, so that the snapshots after the checkpoint are applied on top of it rather
+// than reconstructed from the genesis. The checkpoint header and its epoch header
+// must already be in the local, verified header chain, as the snapshot is only
+// verified against the validators and the environment embedded in the latter.
+func (c *Oasys) ImportSnapshot(chain consensus.ChainHeaderReader, blob []byte) (*Snapshot, error) {
+	snap, err := decodeSnapshot(blob)
+	if err != nil {
+		return nil, err
+	}
+	if snap.Number == 0 || snap.Number%checkpointInterval != 0 {
+		return nil, errNotCheckpoint
+	}
+	if snap.Environment == nil || snap.Environment.EpochPeriod == nil || snap.Environment.EpochPeriod.Sign() == 0 {
+		return nil, errNoEnvironmentValue
+	}
+	header := chain.GetHeader(snap.Hash, snap.Number)
+	if header == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	// Walk back to the epoch header on the same branch as the checkpoint.
+	var (
+		headers     = []*types.Header{header}
+		epochHeader = header
+	)
+	for first := snap.Environment.GetFirstBlock(snap.Number); epochHeader.Number.Uint64() > first; {
+		if epochHeader = chain.GetHeader(epochHeader.ParentHash, epochHeader.Number.Uint64()-1); epochHeader == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+		headers = append(headers, epochHeader)
+	}
+	if err := verifyCheckpointSnapshot(snap, epochHeader, c.chainConfig); err != nil {
+		return nil, err
+	}
+	// The attestation is not trusted from the peer, but derived from the vote
+	// attestations in the verified headers of the epoch.
+	attestation, err := checkpointAttestation(headers, snap.Environment, c.chainConfig, c.config)
+	if err != nil {
+		return nil, err
+	}
+	if !equalVoteData(snap.Attestation, attestation) {
+		return nil, fmt.Errorf("mismatching attestation, expected: %v, real: %v", attestation, snap.Attestation)
+	}
+
+	snap.config = c.chainConfig
+	snap.sigcache = c.signatures
+	snap.ethAPI = c.ethAPI
+	if err := snap.store(c.db); err != nil {
+		return nil, err
+	}
+	c.recents.Load().Add(snap.Hash, snap)
+	log.Info("Imported checkpoint snapshot", "number", snap.Number, "hash", snap.Hash)
+	return snap, nil
+}
+
+// verifyCheckpointSnapshot checks that the validators and the environment of
+// the snapshot are the ones embedded in the epoch header.
+func verifyCheckpointSnapshot(snap *Snapshot, epochHeader *types.Header, chainConfig *params.ChainConfig) error {
+	if !chainConfig.IsFastFinalityEnabled(epochHeader.Number) {
+		return errUnverifiableSnapshot
+	}
+	env, err := getEnvironmentFromHeader(epochHeader)
+	if err != nil {
+		return err
+	}
+	if err := snap.Environment.Equal(env); err != nil {
+		return err
+	}
+	validators, err := getValidatorsFromHeader(epochHeader, chainConfig)
+	if err != nil {
+		return err
+	}
+	if len(snap.Validators) != len(validators.Operators) {
+		return fmt.Errorf("mismatching validator count, expected: %d, real: %d", len(validators.Operators), len(snap.Validators))
+	}
+	for i, operator := range validators.Operators {
+		info, ok := snap.Validators[operator]
+		if !ok {
+			return fmt.Errorf("missing validator: %s", operator)
+		}
+		if info.Index != i+1 || info.Stake == nil || info.Stake.Cmp(validators.Stakes[i]) != 0 || info.VoteAddress != validators.VoteAddresses[i] {
+			return fmt.Errorf("mismatching validator info: %s", operator)
+		}
+	}
+	return nil
+}
+
+// checkpointAttestation derives the attestation of the snapshot at the first of
+// the given headers, which are the headers of the epoch in descending order. It
+// replays the vote attestations from the latest one justifying the direct child
+// of its source, which sets all of the attestation, as the others only move the
+// target on. The attestation is nil if none of the headers has any attestation,
+// as the one inherited from the previous epochs cannot be verified.
+func checkpointAttestation(headers []*types.Header, env *params.EnvironmentValue, chainConfig *params.ChainConfig, oasysConfig *params.OasysConfig) (*types.VoteData, error) {
+	var (
+		start = -1
+		found bool
+	)
+	for i, header := range headers {
+		number := header.Number.Uint64()
+		if !chainConfig.IsFastFinalityEnabled(header.Number) {
+			break
+		}
+		attestation, _ := getVoteAttestationFromHeader(header, chainConfig, oasysConfig, env.IsEpoch(number))
+		if attestation == nil || attestation.Data.TargetHash != header.ParentHash || attestation.Data.TargetNumber+1 != number {
+			continue
+		}
+		found = true
+		if attestation.Data.SourceNumber+1 == attestation.Data.TargetNumber {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		if found {
+			return nil, errUnverifiableSnapshot
+		}
+		return nil, nil
+	}
+	replay := &Snapshot{Environment: env}
+	for i := start; i >= 0; i-- {
+		replay.updateAttestation(headers[i], chainConfig, oasysConfig)
+	}
+	return replay.Attestation, nil
+}
+
+// equalVoteData returns whether the vote data are the same, including nil.
+func equalVoteData(a, b *types.VoteData) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,111 @@
+package oasys
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyCheckpointSnapshot(t *testing.T) {
+	var (
+		config      = &params.OasysConfig{Period: 15, Epoch: 5760}
+		chainConfig = &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: config}
+		env         = params.InitialEnvironmentValue(config)
+		size        = 3
+		validators  = &nextValidators{
+			Owners:        make([]common.Address, size),
+			Operators:     make([]common.Address, size),
+			Stakes:        make([]*big.Int, size),
+			VoteAddresses: make([]types.BLSPublicKey, size),
+		}
+	)
+	for i := 0; i < size; i++ {
+		validators.Owners[i] = common.BigToAddress(big.NewInt(int64(i + 1)))
+		validators.Operators[i] = common.BigToAddress(big.NewInt(int64(i + 100)))
+		validators.Stakes[i] = newEth(int64(i + 1))
+		validators.VoteAddresses[i] = randomBLSPublicKey()
+	}
+	epochHeader := &types.Header{Number: big.NewInt(5760), Extra: make([]byte, extraVanity)}
+	epochHeader.Extra = append(epochHeader.Extra, assembleEnvironmentValue(env)...)
+	epochHeader.Extra = append(epochHeader.Extra, assembleValidators(validators, false)...)
+	epochHeader.Extra = append(epochHeader.Extra, make([]byte, extraSeal)...)
+
+	newSnap := func() *Snapshot {
+		snap := newSnapshot(chainConfig, nil, nil, 6144, common.Hash{0x1}, validators.Operators, env)
+		for i, operator := range validators.Operators {
+			snap.Validators[operator].Stake = validators.Stakes[i]
+			snap.Validators[operator].VoteAddress = validators.VoteAddresses[i]
+		}
+		return snap
+	}
+	require.NoError(t, verifyCheckpointSnapshot(newSnap(), epochHeader, chainConfig))
+
+	// mismatching stake
+	snap := newSnap()
+	snap.Validators[validators.Operators[1]].Stake = newEth(100)
+	require.Error(t, verifyCheckpointSnapshot(snap, epochHeader, chainConfig))
+
+	// unknown validator
+	snap = newSnap()
+	snap.Validators[common.Address{0xff}] = &ValidatorInfo{Index: size + 1, Stake: newEth(1)}
+	require.Error(t, verifyCheckpointSnapshot(snap, epochHeader, chainConfig))
+
+	// mismatching environment
+	snap = newSnap()
+	snap.Environment.EpochPeriod = big.NewInt(100)
+	require.Error(t, verifyCheckpointSnapshot(snap, epochHeader, chainConfig))
+
+	// epoch header before fast finality
+	require.ErrorIs(t, verifyCheckpointSnapshot(newSnap(), &types.Header{Number: big.NewInt(1)}, chainConfig), errUnverifiableSnapshot)
+}
+
+func TestCheckpointAttestation(t *testing.T) {
+	var (
+		config      = &params.OasysConfig{Period: 15, Epoch: 5760, AttestationHeaderBlock: common.Big0}
+		chainConfig = &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: config}
+		env         = params.InitialEnvironmentValue(config)
+	)
+	// Headers 100 to 106 with the vote attestations justifying the given targets
+	// from the given sources, the first and last of which are not the parents
+	newHeaders := func(attestations map[uint64][2]uint64) []*types.Header {
+		var headers []*types.Header
+		parent := &types.Header{Number: big.NewInt(99)}
+		hashes := map[uint64]common.Hash{99: parent.Hash()}
+		for number := uint64(100); number <= 106; number++ {
+			header := &types.Header{Number: new(big.Int).SetUint64(number), ParentHash: parent.Hash()}
+			if votes, ok := attestations[number]; ok {
+				header.VoteAttestation = &types.VoteAttestation{Data: &types.VoteData{
+					SourceNumber: votes[0], SourceHash: hashes[votes[0]],
+					TargetNumber: votes[1], TargetHash: hashes[votes[1]],
+				}}
+			}
+			hashes[number] = header.Hash()
+			headers = append([]*types.Header{header}, headers...)
+			parent = header
+		}
+		return headers
+	}
+
+	// The latest attestation justifying the direct child of its source sets it,
+	// and the following ones move the target on
+	headers := newHeaders(map[uint64][2]uint64{101: {99, 100}, 102: {100, 101}, 104: {101, 103}, 106: {99, 100}})
+	attestation, err := checkpointAttestation(headers, env, chainConfig, config)
+	require.NoError(t, err)
+	require.Equal(t, &types.VoteData{
+		SourceNumber: 100, SourceHash: headers[6].Hash(),
+		TargetNumber: 103, TargetHash: headers[3].Hash(),
+	}, attestation)
+
+	// No attestation in the epoch
+	attestation, err = checkpointAttestation(newHeaders(nil), env, chainConfig, config)
+	require.NoError(t, err)
+	require.Nil(t, attestation)
+
+	// The source of the attestation is out of the headers
+	_, err = checkpointAttestation(newHeaders(map[uint64][2]uint64{104: {101, 103}}), env, chainConfig, config)
+	require.ErrorIs(t, err, errUnverifiableSnapshot)
+}
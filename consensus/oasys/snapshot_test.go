@@ -5,7 +5,10 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/stretchr/testify/require"
 )
 
@@ -42,3 +45,155 @@ func TestUnmarshalValidatorInfo(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expects, actuals)
 }
+
+func TestDeleteSnapshots(t *testing.T) {
+	var (
+		db  = rawdb.NewMemoryDatabase()
+		env = params.InitialEnvironmentValue(&params.OasysConfig{Period: 15, Epoch: 5760})
+	)
+	for _, number := range []uint64{0, 1024, 2048, 3072} {
+		hash := common.BigToHash(new(big.Int).SetUint64(number + 1))
+		snap := newSnapshot(nil, nil, nil, number, hash, nil, env)
+		require.NoError(t, snap.store(db))
+	}
+	// unrelated key sharing the prefix
+	require.NoError(t, db.Put(append(snapshotPrefix, 0x1), []byte{0x1}))
+
+	deleted, err := deleteSnapshots(db, 1024)
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted)
+
+	for _, number := range []uint64{0, 1024, 2048, 3072} {
+		hash := common.BigToHash(new(big.Int).SetUint64(number + 1))
+		_, err := loadSnapshot(nil, nil, nil, db, hash)
+		if number <= 1024 {
+			require.NoError(t, err)
+		} else {
+			require.Error(t, err)
+		}
+	}
+	has, err := db.Has(append(snapshotPrefix, 0x1))
+	require.NoError(t, err)
+	require.True(t, has)
+}
+
+func TestCompareVoteAddresses(t *testing.T) {
+	var (
+		op1, op2, op3 = common.Address{0x01}, common.Address{0x02}, common.Address{0x03}
+		key1, key2    = types.BLSPublicKey{0x11}, types.BLSPublicKey{0x12}
+		rotated       = types.BLSPublicKey{0x22}
+	)
+	snap := &Snapshot{Validators: map[common.Address]*ValidatorInfo{
+		op1: {Stake: big.NewInt(1), Index: 1, VoteAddress: key1},
+		op2: {Stake: big.NewInt(1), Index: 2, VoteAddress: key2},
+		op3: {Stake: big.NewInt(1), Index: 3},
+	}}
+	next := &nextValidators{
+		Operators:     []common.Address{op1, op2},
+		VoteAddresses: []types.BLSPublicKey{key1, rotated},
+	}
+	statuses, pending := compareVoteAddresses(snap, next)
+	require.Equal(t, 1, pending)
+	require.Equal(t, []*VoteAddressStatus{
+		{Operator: op1, Effective: key1, Registered: key1},
+		{Operator: op2, Effective: key2, Registered: rotated, Pending: true},
+		{Operator: op3}, // Not a candidate of the next epoch
+	}, statuses)
+}
+
+func TestSnapshotEncoding(t *testing.T) {
+	env := params.InitialEnvironmentValue(&params.OasysConfig{Period: 15, Epoch: 5760})
+	snap := newSnapshot(nil, nil, nil, 1024, common.Hash{0x01},
+		[]common.Address{{0x03}, {0x01}, {0x02}}, env)
+	snap.Validators[common.Address{0x02}].Stake = big.NewInt(100)
+	snap.Validators[common.Address{0x02}].VoteAddress = types.BLSPublicKey{0x04}
+	snap.Attestation = &types.VoteData{SourceNumber: 1000, SourceHash: common.Hash{0x05}, TargetNumber: 1023, TargetHash: common.Hash{0x06}}
+
+	blob, err := encodeSnapshot(snap)
+	require.NoError(t, err)
+	require.Equal(t, byte(snapshotVersion), blob[0])
+
+	// The encoding is deterministic and survives the round trip
+	for i := 0; i < 10; i++ {
+		again, err := encodeSnapshot(snap.copy())
+		require.NoError(t, err)
+		require.Equal(t, blob, again)
+	}
+	dec, err := decodeSnapshot(blob)
+	require.NoError(t, err)
+	require.Equal(t, snap.Number, dec.Number)
+	require.Equal(t, snap.Hash, dec.Hash)
+	require.Equal(t, snap.Validators, dec.Validators)
+	require.Equal(t, snap.Attestation, dec.Attestation)
+	require.Equal(t, snap.Environment, dec.Environment)
+
+	// The unknown fields, the trailing data and the unknown versions are rejected
+	_, err = decodeSnapshot(append([]byte{snapshotVersion}, `{"number":1,"hash":"0x0000000000000000000000000000000000000000000000000000000000000001","validators":{},"environment":{},"unknown":1}`...))
+	require.Error(t, err)
+	_, err = decodeSnapshot(append(common.CopyBytes(blob), `{}`...))
+	require.Error(t, err)
+	_, err = decodeSnapshot(append([]byte{snapshotVersion + 1}, blob[1:]...))
+	require.ErrorIs(t, err, errUnknownSnapshotVersion)
+	_, err = decodeSnapshot(nil)
+	require.ErrorIs(t, err, errEmptySnapshot)
+}
+
+func TestSnapshotEncodingV1Schema(t *testing.T) {
+	// The version 1 encoding is frozen, any change requires a new version
+	const v1 = `{"number":1024,"hash":"0x0100000000000000000000000000000000000000000000000000000000000000","validators":{"0x0100000000000000000000000000000000000000":{"stake":0,"index":1,"vote_address":"0x000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"},"0x0200000000000000000000000000000000000000":{"stake":100,"index":2,"vote_address":"0x040000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"}},"attestation":{"SourceNumber":1000,"SourceHash":"0x0500000000000000000000000000000000000000000000000000000000000000","TargetNumber":1023,"TargetHash":"0x0600000000000000000000000000000000000000000000000000000000000000"},"environment":{"StartBlock":0,"StartEpoch":1,"BlockPeriod":15,"EpochPeriod":5760,"RewardRate":10,"CommissionRate":10,"ValidatorThreshold":10000000000000000000000000,"JailThreshold":500,"JailPeriod":2}}`
+
+	env := params.InitialEnvironmentValue(&params.OasysConfig{Period: 15, Epoch: 5760})
+	snap := newSnapshot(nil, nil, nil, 1024, common.Hash{0x01}, []common.Address{{0x01}, {0x02}}, env)
+	snap.Validators[common.Address{0x02}].Stake = big.NewInt(100)
+	snap.Validators[common.Address{0x02}].VoteAddress = types.BLSPublicKey{0x04}
+	snap.Attestation = &types.VoteData{SourceNumber: 1000, SourceHash: common.Hash{0x05}, TargetNumber: 1023, TargetHash: common.Hash{0x06}}
+
+	blob, err := encodeSnapshot(snap)
+	require.NoError(t, err)
+	require.Equal(t, append([]byte{1}, v1...), blob)
+
+	dec, err := decodeSnapshot(append([]byte{1}, v1...))
+	require.NoError(t, err)
+	require.Equal(t, snap.Validators, dec.Validators)
+	require.Equal(t, snap.Attestation, dec.Attestation)
+	require.Equal(t, snap.Environment, dec.Environment)
+}
+
+func TestSnapshotEncodingMigration(t *testing.T) {
+	// Snapshots persisted before the versioning, including the validators in
+	// the stake-only encoding of v1.5.0
+	legacy := []byte(`{
+		"number": 2048,
+		"hash": "0x0000000000000000000000000000000000000000000000000000000000000002",
+		"validators": {
+			"0x0000000000000000000000000000000000000001": 10,
+			"0x0000000000000000000000000000000000000002": {"stake": 20, "index": 2}
+		},
+		"environment": {"StartBlock": 0, "StartEpoch": 1, "EpochPeriod": 5760}
+	}`)
+	db := rawdb.NewMemoryDatabase()
+	hash := common.HexToHash("0x02")
+	require.NoError(t, db.Put(append(snapshotPrefix, hash[:]...), legacy))
+
+	snap, err := loadSnapshot(nil, nil, nil, db, hash)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2048), snap.Number)
+	require.Equal(t, big.NewInt(10), snap.Validators[common.HexToAddress("0x01")].Stake)
+	require.Equal(t, 2, snap.Validators[common.HexToAddress("0x02")].Index)
+	require.Equal(t, big.NewInt(5760), snap.Environment.EpochPeriod)
+
+	// Storing it again migrates it to the current version
+	require.NoError(t, snap.store(db))
+	blob, err := db.Get(append(snapshotPrefix, hash[:]...))
+	require.NoError(t, err)
+	require.Equal(t, byte(snapshotVersion), blob[0])
+
+	migrated, err := loadSnapshot(nil, nil, nil, db, hash)
+	require.NoError(t, err)
+	require.Equal(t, snap.Validators, migrated.Validators)
+	require.Equal(t, snap.Environment, migrated.Environment)
+
+	deleted, err := deleteSnapshots(db, 1024)
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+}
@@ -0,0 +1,78 @@
+package oasys
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// syncCheckEpochs is the number of the most recent epochs whose validators are
+// re-derived from the state downloaded by the snap sync.
+const syncCheckEpochs = 2
+
+// ErrCorruptedSyncState is returned if the validators re-derived from the state
+// downloaded by the snap sync mismatch the ones embedded in the epoch headers.
+var ErrCorruptedSyncState = errors.New("synced state mismatches the epoch headers")
+
+// HoldSyncedStateVotes marks the state downloaded by the snap sync as unverified,
+// holding the votes until VerifySyncedState succeeds.
+func (c *Oasys) HoldSyncedStateVotes() {
+	c.syncUnverified.Store(true)
+}
+
+// VerifySyncedState cross-checks the state downloaded by the snap sync against
+// the verified header chain: the validators of the most recent epochs are
+// re-derived from the StakeManager at the head state and compared with the ones
+// embedded in the epoch headers. On a mismatch, the state is likely corrupted or
+// maliciously served, so the votes are held until the node is resynced. Any
+// failure keeps the votes held, as the state is only trusted once verified.
+func (c *Oasys) VerifySyncedState(chain consensus.ChainHeaderReader) error {
+	head := chain.CurrentHeader()
+	snap, err := c.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return err
+	}
+	var (
+		current = snap.Environment.Epoch(head.Number.Uint64())
+		checked int
+	)
+	for epoch := current; epoch > 0 && current-epoch < syncCheckEpochs; epoch-- {
+		number, err := c.epochFirstBlock(chain, head, epoch)
+		if err != nil {
+			return err
+		}
+		header := chain.GetHeaderByNumber(number)
+		if header == nil {
+			return errUnknownBlock
+		}
+		// The validators are only embedded since the fast finality
+		if !c.chainConfig.IsFastFinalityEnabled(header.Number) {
+			break
+		}
+		expected, err := getValidatorsFromHeader(header, c.chainConfig)
+		if err != nil {
+			return err
+		}
+		actual, err := c.contracts.getNextValidators(c.chainConfig, c.ethAPI, head.Hash(), epoch, number)
+		if err != nil {
+			return fmt.Errorf("failed to get validators from the synced state, epoch: %d, error: %v", epoch, err)
+		}
+		if !equalValidators(actual, expected) {
+			log.Error("Synced state mismatches the epoch header, holding the votes", "epoch", epoch, "number", number,
+				"hash", header.Hash(), "head", head.Number, "expected", len(expected.Operators), "actual", len(actual.Operators))
+			return fmt.Errorf("%w, epoch: %d, number: %d", ErrCorruptedSyncState, epoch, number)
+		}
+		checked++
+	}
+	c.syncUnverified.Store(false)
+	log.Info("Verified the synced state against the epoch headers", "head", head.Number, "epochs", checked)
+	return nil
+}
+
+// SyncStateVerified returns whether the state downloaded by the snap sync passed
+// the verification, or no snap sync was run. The node must not vote otherwise.
+func (c *Oasys) SyncStateVerified() bool {
+	return !c.syncUnverified.Load()
+}
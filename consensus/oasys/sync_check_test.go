@@ -0,0 +1,49 @@
+package oasys
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySyncedStateHoldsVotes(t *testing.T) {
+	chainConfig := &params.ChainConfig{
+		ChainID: big.NewInt(999999),
+		Oasys:   &params.OasysConfig{Period: 15, Epoch: 5760},
+	}
+	recents, _ := lru.NewARC(inmemorySnapshots)
+	engine := &Oasys{chainConfig: chainConfig, config: chainConfig.Oasys, db: rawdb.NewMemoryDatabase()}
+	engine.recents.Store(recents)
+
+	// The votes are allowed unless a snap sync asks for the verification
+	require.True(t, engine.SyncStateVerified())
+	engine.HoldSyncedStateVotes()
+	require.False(t, engine.SyncStateVerified())
+
+	// A failing verification keeps the votes held
+	var (
+		genesis = &types.Header{Number: big.NewInt(0)}
+		head    = &types.Header{Number: big.NewInt(2), ParentHash: common.Hash{0x01}} // Unknown parent
+		chain   = newTestHeaderChain(chainConfig, genesis)
+	)
+	chain.insert(&types.Header{Number: big.NewInt(1), ParentHash: genesis.Hash()})
+	chain.insert(head)
+	require.Error(t, engine.VerifySyncedState(chain))
+	require.False(t, engine.SyncStateVerified())
+
+	// The votes are released once the verification succeeds
+	recents.Add(head.Hash(), &Snapshot{
+		Number:      2,
+		Hash:        head.Hash(),
+		Validators:  map[common.Address]*ValidatorInfo{},
+		Environment: params.InitialEnvironmentValue(chainConfig.Oasys),
+	})
+	require.NoError(t, engine.VerifySyncedState(chain))
+	require.True(t, engine.SyncStateVerified())
+}
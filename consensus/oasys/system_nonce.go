@@ -0,0 +1,97 @@
+package oasys
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// systemNonces reserves the nonces of the validator accounts consumed by the
+// blocks sealed locally, including the system transactions which the
+// transaction pool doesn't know of.
+//
+// Within a block, the system transactions take their nonces from the state
+// after the transactions of the pool in applyTransaction, so they never collide
+// with the transactions of the validator account in the same block. Between
+// the seal and the import of the block however, the pending nonce of the pool
+// still points at the nonces of the system transactions, so the transactions
+// sent from the validator account in the meantime would reuse them and be
+// dropped once the block is imported, leaving a gap in the nonces.
+//
+// The reservations are kept per validator account, as the node may seal for
+// several validators, see AddSigner.
+type systemNonces struct {
+	lock     sync.Mutex
+	accounts map[common.Address]systemNonce // Reservations of the validator accounts, protected by lock
+}
+
+// systemNonce is the reservation of the nonces of a validator account.
+type systemNonce struct {
+	number uint64 // Block sealed last for the account
+	next   uint64 // Next nonce of the account after the block
+}
+
+// reserve reserves the nonces of the validator account used by the given block,
+// and drops the reservations of the blocks below it, which are either imported
+// or superseded.
+func (n *systemNonces) reserve(block *types.Block, signer types.Signer) {
+	var (
+		sender = block.Coinbase()
+		next   uint64
+		found  bool
+	)
+	for _, tx := range block.Transactions() {
+		if from, err := types.Sender(signer, tx); err != nil || from != sender {
+			continue
+		}
+		if tx.Nonce() >= next {
+			next, found = tx.Nonce()+1, true
+		}
+	}
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	number := block.NumberU64()
+	for account, reserved := range n.accounts {
+		if reserved.number < number {
+			delete(n.accounts, account)
+		}
+	}
+	if !found {
+		return
+	}
+	if reserved, ok := n.accounts[sender]; ok && reserved.number > number {
+		return
+	}
+	if n.accounts == nil {
+		n.accounts = make(map[common.Address]systemNonce)
+	}
+	n.accounts[sender] = systemNonce{number: number, next: next}
+}
+
+// reserved returns the next nonce of the account after the block sealed last
+// for it, as long as the chain head is below the block. Once the head reaches
+// the block number, either the block is imported and the pool accounts for it,
+// or another block was adopted and the reserved nonces are still free.
+func (n *systemNonces) reserved(head *types.Header, account common.Address) (uint64, bool) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	reserved, ok := n.accounts[account]
+	if !ok || head.Number.Uint64() >= reserved.number {
+		return 0, false
+	}
+	return reserved.next, true
+}
+
+// PendingNonce returns the nonce to send the next transaction of the account
+// with, given the pending nonce of the transaction pool. For the local validator
+// account, it skips the nonces of the system transactions in the block sealed
+// but not imported yet.
+func (c *Oasys) PendingNonce(head *types.Header, account common.Address, poolNonce uint64) uint64 {
+	if next, ok := c.systemNonces.reserved(head, account); ok && next > poolNonce {
+		return next
+	}
+	return poolNonce
+}
@@ -0,0 +1,57 @@
+package oasys
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemNonces(t *testing.T) {
+	var (
+		key, _    = crypto.GenerateKey()
+		validator = crypto.PubkeyToAddress(key.PublicKey)
+		other, _  = crypto.GenerateKey()
+		signer    = types.LatestSigner(params.TestChainConfig)
+		engine    = &Oasys{}
+	)
+	sign := func(key *ecdsa.PrivateKey, nonce uint64) *types.Transaction {
+		tx := types.NewTransaction(nonce, common.Address{0x01}, common.Big0, 21000, common.Big0, nil)
+		signed, err := types.SignTx(tx, signer, key)
+		require.NoError(t, err)
+		return signed
+	}
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(10), Coinbase: validator}).
+		WithBody([]*types.Transaction{sign(key, 5), sign(other, 9), sign(key, 6), sign(key, 7)}, nil)
+
+	engine.systemNonces.reserve(block, signer)
+
+	// The nonces of the block are skipped until it is imported or superseded
+	parent := &types.Header{Number: big.NewInt(9)}
+	require.Equal(t, uint64(8), engine.PendingNonce(parent, validator, 5))
+	require.Equal(t, uint64(9), engine.PendingNonce(parent, validator, 9))
+	require.Equal(t, uint64(5), engine.PendingNonce(parent, crypto.PubkeyToAddress(other.PublicKey), 5))
+	require.Equal(t, uint64(5), engine.PendingNonce(block.Header(), validator, 5))
+
+	// An older block doesn't replace the reservation
+	engine.systemNonces.reserve(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(9), Coinbase: validator}).
+		WithBody([]*types.Transaction{sign(key, 5)}, nil), signer)
+	require.Equal(t, uint64(8), engine.PendingNonce(parent, validator, 5))
+
+	// The reservations of the validators sealed for are kept apart, and the ones
+	// below the block sealed last are dropped
+	extra := crypto.PubkeyToAddress(other.PublicKey)
+	engine.systemNonces.reserve(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(10), Coinbase: extra}).
+		WithBody([]*types.Transaction{sign(other, 9), sign(other, 10)}, nil), signer)
+	require.Equal(t, uint64(8), engine.PendingNonce(parent, validator, 5))
+	require.Equal(t, uint64(11), engine.PendingNonce(parent, extra, 9))
+
+	engine.systemNonces.reserve(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(11), Coinbase: extra}), signer)
+	require.Equal(t, uint64(5), engine.PendingNonce(parent, validator, 5))
+	require.Equal(t, uint64(9), engine.PendingNonce(parent, extra, 9))
+}
@@ -0,0 +1,110 @@
+package oasys
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// systemTxSignAttempts is the maximum attempts to sign a system transaction in
+// the block assembly.
+const systemTxSignAttempts = 3
+
+// Reasons of the failures of the system transactions.
+const (
+	SystemTxFailureSign      = "sign"      // The signer failed to sign the transaction
+	SystemTxFailureExecution = "execution" // The contract call failed
+	SystemTxFailureOther     = "other"     // The transaction could not be assembled
+)
+
+var (
+	// errSignSystemTx is returned if the signer fails to sign a system transaction.
+	errSignSystemTx = errors.New("failed to sign system transaction")
+
+	// errExecuteSystemTx is returned if the contract call of a system transaction fails.
+	errExecuteSystemTx = errors.New("failed to execute system transaction")
+
+	systemTxRetryCounter = metrics.NewRegisteredCounter("oasys/systemtx/retry", nil)
+	systemTxFailCounters = map[string]metrics.Counter{
+		SystemTxFailureSign:      metrics.NewRegisteredCounter("oasys/systemtx/failed/sign", nil),
+		SystemTxFailureExecution: metrics.NewRegisteredCounter("oasys/systemtx/failed/execution", nil),
+		SystemTxFailureOther:     metrics.NewRegisteredCounter("oasys/systemtx/failed/other", nil),
+	}
+)
+
+// SystemTxFailure is a system transaction failed in the block assembly.
+type SystemTxFailure struct {
+	Method    string         `json:"method"`
+	Validator common.Address `json:"validator"`
+	Reason    string         `json:"reason"`
+	Error     string         `json:"error"`
+}
+
+func newSystemTxFailure(method string, validator common.Address, err error) *SystemTxFailure {
+	reason := SystemTxFailureOther
+	switch {
+	case errors.Is(err, errSignSystemTx):
+		reason = SystemTxFailureSign
+	case errors.Is(err, errExecuteSystemTx):
+		reason = SystemTxFailureExecution
+	}
+	systemTxFailCounters[reason].Inc(1)
+	return &SystemTxFailure{Method: method, Validator: validator, Reason: reason, Error: err.Error()}
+}
+
+// systemTxFailures are the system transaction failures in the block assembled
+// on top of the parent.
+type systemTxFailures struct {
+	parentHash common.Hash
+	number     uint64
+	failures   []*SystemTxFailure
+}
+
+// applySystemTransaction applies the system transaction, retrying the signing
+// in the block assembly as a remote signer may fail transiently. The execution
+// is deterministic on the state and the gas is fixed by the consensus for the
+// validators to rebuild the same transaction, so neither is retried.
+func (c *Oasys) applySystemTransaction(
+	msg callmsg,
+	state *state.StateDB,
+	header *types.Header,
+	cx core.ChainContext,
+	txs *[]*types.Transaction,
+	receipts *[]*types.Receipt,
+	systemTxs *[]*types.Transaction,
+	usedGas *uint64,
+	mining bool,
+) (err error) {
+	for attempt := 1; ; attempt++ {
+		err = c.applyTransaction(msg, state, header, cx, txs, receipts, systemTxs, usedGas, mining)
+		if err == nil || !errors.Is(err, errSignSystemTx) || attempt >= systemTxSignAttempts {
+			return err
+		}
+		systemTxRetryCounter.Inc(1)
+	}
+}
+
+// storeSystemTxFailures keeps the system transaction failures of the block
+// assembled, replacing the ones of the previous assembly.
+func (c *Oasys) storeSystemTxFailures(header *types.Header, failures []*SystemTxFailure) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.systemTxFailures = &systemTxFailures{parentHash: header.ParentHash, number: header.Number.Uint64(), failures: failures}
+}
+
+// SystemTxFailures returns the system transaction failures in the latest block
+// assembled on top of the same parent as the given header.
+func (c *Oasys) SystemTxFailures(header *types.Header) []*SystemTxFailure {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if f := c.systemTxFailures; f != nil && f.parentHash == header.ParentHash && f.number == header.Number.Uint64() {
+		return f.failures
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package oasys
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// errInvalidMilliTimestamp is returned if the mix digest of a block after the
+// millisecond timestamp fork does not carry a valid millisecond part.
+var errInvalidMilliTimestamp = errors.New("invalid millisecond timestamp")
+
+// errEarlyEmptyBlock is returned if a block without transactions but the system
+// ones is sealed before the heartbeat after its parent.
+var errEarlyEmptyBlock = errors.New("empty block before the heartbeat")
+
+// headerMilliTime returns the timestamp of the header in milliseconds. Since the
+// millisecond timestamp fork, the sub-second part is carried in the last 8 bytes
+// of the mix digest, which is zero before the fork.
+func headerMilliTime(header *types.Header) uint64 {
+	return header.Time*1000 + binary.BigEndian.Uint64(header.MixDigest[common.HashLength-8:])
+}
+
+// setHeaderMilliTime sets the timestamp of the header in milliseconds, or in
+// seconds rounding the sub-second part up before the millisecond timestamp fork.
+func (c *Oasys) setHeaderMilliTime(header *types.Header, ms uint64) {
+	header.MixDigest = common.Hash{}
+	if !c.chainConfig.IsOasysMillisecondTimestamp(header.Number) {
+		header.Time = (ms + 999) / 1000
+		return
+	}
+	header.Time = ms / 1000
+	binary.BigEndian.PutUint64(header.MixDigest[common.HashLength-8:], ms%1000)
+}
+
+// blockMilliPeriod returns the milliseconds between the blocks at the given number.
+// Since the millisecond timestamp fork, the configured period replaces the block
+// period of the environment, which is in whole seconds, for the scheduling only.
+func (c *Oasys) blockMilliPeriod(number *big.Int, env *params.EnvironmentValue) uint64 {
+	if c.config.MilliPeriod != nil && c.chainConfig.IsOasysMillisecondTimestamp(number) {
+		return *c.config.MilliPeriod
+	}
+	return env.BlockPeriod.Uint64() * 1000
+}
+
+// heartbeatMilliTime returns the milliseconds since the parent before an empty
+// block may be sealed at the given number, or zero if the empty blocks are
+// sealed every block period. The epoch blocks are never postponed.
+func (c *Oasys) heartbeatMilliTime(number *big.Int, env *params.EnvironmentValue) uint64 {
+	if c.config.Heartbeat == nil || !c.chainConfig.IsOasysHeartbeat(number) || env.IsEpoch(number.Uint64()) {
+		return 0
+	}
+	if heartbeat := *c.config.Heartbeat * 1000; heartbeat > c.blockMilliPeriod(number, env) {
+		return heartbeat
+	}
+	return 0
+}
+
+// verifyHeartbeat checks that an empty block is not sealed before the heartbeat
+// after its parent plus the back-off time of the validator, so that the in-turn
+// validator still proposes first while the chain is idle.
+func (c *Oasys) verifyHeartbeat(header, parent *types.Header, env *params.EnvironmentValue, scheduler *scheduler) error {
+	heartbeat := c.heartbeatMilliTime(header.Number, env)
+	if heartbeat == 0 {
+		return nil
+	}
+	if headerMilliTime(header) < headerMilliTime(parent)+heartbeat+c.backOffMilliTime(header.Number, env, scheduler, header.Coinbase) {
+		return errEarlyEmptyBlock
+	}
+	return nil
+}
+
+// backOffMilliTime returns the back-off time of the validator at the given number
+// in milliseconds. Since the millisecond timestamp fork, each turn adds the block
+// period capped to a second instead of a whole second, so that the back-off
+// shrinks along with the sub-second block periods.
+func (c *Oasys) backOffMilliTime(number *big.Int, env *params.EnvironmentValue, scheduler *scheduler, validator common.Address) uint64 {
+	step := uint64(1000)
+	if c.chainConfig.IsOasysMillisecondTimestamp(number) {
+		step = min(step, c.blockMilliPeriod(number, env))
+	}
+	return scheduler.backOffMilliTime(number.Uint64(), validator, step)
+}
+
+// verifyMixDigest checks that the mix digest is zero before the millisecond
+// timestamp fork, and only carries the millisecond part after it.
+func (c *Oasys) verifyMixDigest(header *types.Header) error {
+	if !c.chainConfig.IsOasysMillisecondTimestamp(header.Number) {
+		if header.MixDigest != (common.Hash{}) {
+			return errInvalidMixDigest
+		}
+		return nil
+	}
+	if common.BytesToHash(header.MixDigest[common.HashLength-8:]) != header.MixDigest {
+		return errInvalidMilliTimestamp
+	}
+	if binary.BigEndian.Uint64(header.MixDigest[common.HashLength-8:]) >= 1000 {
+		return errInvalidMilliTimestamp
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+// Package valcache implements the persistent cache of the validator sets, the
+// environment values and the gas limits the Oasys engine reads from the
+// contracts at the epoch blocks, so that the contracts are called once per epoch rather than on every
+// verification, and the values survive the pruning of the state they were read
+// from.
+package valcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Version is the version of the layout of the entries, the entries of an older
+// layout are dropped by Migrate.
+const Version = 1
+
+// inmemoryEntries is the number of the entries kept in memory in front of the
+// database, per kind.
+const inmemoryEntries = 16
+
+var (
+	// The entries are keyed by the epoch and the hash of the parent of the epoch
+	// block, as the contracts are read at the parent, so that the branches forked
+	// before the epoch block never share an entry.
+	validatorsPrefix  = []byte("oasys-valcache-v") // validatorsPrefix + epoch (uint64 big endian) + parent hash -> RLP(Validators)
+	environmentPrefix = []byte("oasys-valcache-e") // environmentPrefix + epoch (uint64 big endian) + parent hash -> RLP(params.EnvironmentValue)
+	gasLimitPrefix    = []byte("oasys-valcache-g") // gasLimitPrefix + epoch (uint64 big endian) + parent hash -> RLP(GasLimit)
+	versionKey        = []byte("oasys-valcache-version")
+
+	// entryPrefix is the common prefix of all the keys of the cache.
+	entryPrefix = []byte("oasys-valcache-")
+)
+
+// Validators is the validator set of an epoch, in the order returned by the
+// contracts.
+type Validators struct {
+	Owners        []common.Address
+	Operators     []common.Address
+	Stakes        []*big.Int
+	VoteAddresses []types.BLSPublicKey
+}
+
+// GasLimit is the block gas limit of an epoch, zero if the network configures
+// none.
+type GasLimit struct {
+	Target uint64 // Gas limit the block producers move toward
+	Max    uint64 // Gas limit the blocks must not exceed
+}
+
+// key identifies an entry of the cache.
+type key struct {
+	epoch  uint64
+	parent common.Hash
+}
+
+func (k key) encode(prefix []byte) []byte {
+	enc := make([]byte, len(prefix)+8+common.HashLength)
+	copy(enc, prefix)
+	binary.BigEndian.PutUint64(enc[len(prefix):], k.epoch)
+	copy(enc[len(prefix)+8:], k.parent[:])
+	return enc
+}
+
+// Cache is the validator set, environment value and gas limit cache backed by
+// the chain database. A nil database keeps the entries in memory only.
+type Cache struct {
+	db           ethdb.KeyValueStore
+	validators   *lru.Cache[key, *Validators]
+	environments *lru.Cache[key, *params.EnvironmentValue]
+	gasLimits    *lru.Cache[key, GasLimit]
+}
+
+// New creates the cache on top of the given database.
+func New(db ethdb.KeyValueStore) *Cache {
+	return &Cache{
+		db:           db,
+		validators:   lru.NewCache[key, *Validators](inmemoryEntries),
+		environments: lru.NewCache[key, *params.EnvironmentValue](inmemoryEntries),
+		gasLimits:    lru.NewCache[key, GasLimit](inmemoryEntries),
+	}
+}
+
+// Validators returns the cached validator set of the epoch started by the block
+// with the given parent, or nil if not cached.
+func (c *Cache) Validators(epoch uint64, parent common.Hash) *Validators {
+	k := key{epoch, parent}
+	if v, ok := c.validators.Get(k); ok {
+		return v.copy()
+	}
+	if c.db == nil {
+		return nil
+	}
+	blob, err := c.db.Get(k.encode(validatorsPrefix))
+	if err != nil || len(blob) == 0 {
+		return nil
+	}
+	v := new(Validators)
+	if err := rlp.DecodeBytes(blob, v); err != nil {
+		log.Warn("Invalid cached validators", "epoch", epoch, "parent", parent, "err", err)
+		return nil
+	}
+	c.validators.Add(k, v)
+	return v.copy()
+}
+
+// StoreValidators caches the validator set of the epoch started by the block
+// with the given parent.
+func (c *Cache) StoreValidators(epoch uint64, parent common.Hash, v *Validators) {
+	k := key{epoch, parent}
+	v = v.copy()
+	c.validators.Add(k, v)
+	if c.db == nil {
+		return
+	}
+	blob, err := rlp.EncodeToBytes(v)
+	if err != nil {
+		log.Warn("Failed to encode validators", "epoch", epoch, "parent", parent, "err", err)
+		return
+	}
+	if err := c.db.Put(k.encode(validatorsPrefix), blob); err != nil {
+		log.Warn("Failed to store validators", "epoch", epoch, "parent", parent, "err", err)
+	}
+}
+
+// Environment returns the cached environment value of the epoch started by the
+// block with the given parent, or nil if not cached.
+func (c *Cache) Environment(epoch uint64, parent common.Hash) *params.EnvironmentValue {
+	k := key{epoch, parent}
+	if env, ok := c.environments.Get(k); ok {
+		return env.Copy()
+	}
+	if c.db == nil {
+		return nil
+	}
+	blob, err := c.db.Get(k.encode(environmentPrefix))
+	if err != nil || len(blob) == 0 {
+		return nil
+	}
+	env := new(params.EnvironmentValue)
+	if err := rlp.DecodeBytes(blob, env); err != nil {
+		log.Warn("Invalid cached environment value", "epoch", epoch, "parent", parent, "err", err)
+		return nil
+	}
+	c.environments.Add(k, env)
+	return env.Copy()
+}
+
+// StoreEnvironment caches the environment value of the epoch started by the
+// block with the given parent.
+func (c *Cache) StoreEnvironment(epoch uint64, parent common.Hash, env *params.EnvironmentValue) {
+	k := key{epoch, parent}
+	env = env.Copy()
+	c.environments.Add(k, env)
+	if c.db == nil {
+		return
+	}
+	blob, err := rlp.EncodeToBytes(env)
+	if err != nil {
+		log.Warn("Failed to encode environment value", "epoch", epoch, "parent", parent, "err", err)
+		return
+	}
+	if err := c.db.Put(k.encode(environmentPrefix), blob); err != nil {
+		log.Warn("Failed to store environment value", "epoch", epoch, "parent", parent, "err", err)
+	}
+}
+
+// GasLimit returns the cached gas limit of the epoch started by the block with
+// the given parent, and whether it is cached.
+func (c *Cache) GasLimit(epoch uint64, parent common.Hash) (GasLimit, bool) {
+	k := key{epoch, parent}
+	if limit, ok := c.gasLimits.Get(k); ok {
+		return limit, true
+	}
+	if c.db == nil {
+		return GasLimit{}, false
+	}
+	blob, err := c.db.Get(k.encode(gasLimitPrefix))
+	if err != nil || len(blob) == 0 {
+		return GasLimit{}, false
+	}
+	var limit GasLimit
+	if err := rlp.DecodeBytes(blob, &limit); err != nil {
+		log.Warn("Invalid cached gas limit", "epoch", epoch, "parent", parent, "err", err)
+		return GasLimit{}, false
+	}
+	c.gasLimits.Add(k, limit)
+	return limit, true
+}
+
+// StoreGasLimit caches the gas limit of the epoch started by the block with the
+// given parent.
+func (c *Cache) StoreGasLimit(epoch uint64, parent common.Hash, limit GasLimit) {
+	k := key{epoch, parent}
+	c.gasLimits.Add(k, limit)
+	if c.db == nil {
+		return
+	}
+	blob, err := rlp.EncodeToBytes(&limit)
+	if err != nil {
+		log.Warn("Failed to encode gas limit", "epoch", epoch, "parent", parent, "err", err)
+		return
+	}
+	if err := c.db.Put(k.encode(gasLimitPrefix), blob); err != nil {
+		log.Warn("Failed to store gas limit", "epoch", epoch, "parent", parent, "err", err)
+	}
+}
+
+// Migrate prepares the cache in the database for this version, dropping the
+// entries of an older layout. It returns whether the cache was migrated, which
+// is also the case for the nodes running it the first time.
+func Migrate(db ethdb.KeyValueStore) (bool, error) {
+	blob, _ := db.Get(versionKey)
+	if len(blob) == 8 && binary.BigEndian.Uint64(blob) == Version {
+		return false, nil
+	}
+	it := db.NewIterator(entryPrefix, nil)
+	defer it.Release()
+
+	batch := db.NewBatch()
+	for it.Next() {
+		if bytes.Equal(it.Key(), versionKey) {
+			continue
+		}
+		if err := batch.Delete(it.Key()); err != nil {
+			return false, err
+		}
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return false, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return false, err
+	}
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, Version)
+	if err := batch.Put(versionKey, enc); err != nil {
+		return false, err
+	}
+	return true, batch.Write()
+}
+
+func (v *Validators) copy() *Validators {
+	cpy := &Validators{
+		Owners:        make([]common.Address, len(v.Owners)),
+		Operators:     make([]common.Address, len(v.Operators)),
+		Stakes:        make([]*big.Int, len(v.Stakes)),
+		VoteAddresses: make([]types.BLSPublicKey, len(v.VoteAddresses)),
+	}
+	copy(cpy.Owners, v.Owners)
+	copy(cpy.Operators, v.Operators)
+	copy(cpy.VoteAddresses, v.VoteAddresses)
+	for i, stake := range v.Stakes {
+		cpy.Stakes[i] = new(big.Int).Set(stake)
+	}
+	return cpy
+}
@@ -0,0 +1,103 @@
+package valcache
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func testValidators() *Validators {
+	return &Validators{
+		Owners:        []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02")},
+		Operators:     []common.Address{common.HexToAddress("0x11"), common.HexToAddress("0x12")},
+		Stakes:        []*big.Int{big.NewInt(100), big.NewInt(200)},
+		VoteAddresses: []types.BLSPublicKey{{0x21}, {0x22}},
+	}
+}
+
+func TestCachePersistence(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		parent = common.HexToHash("0xaa")
+		vals   = testValidators()
+		env    = params.InitialEnvironmentValue(&params.OasysConfig{Period: 15, Epoch: 5760})
+		limit  = GasLimit{Target: 30_000_000, Max: 40_000_000}
+	)
+	cache := New(db)
+	if cache.Validators(2, parent) != nil || cache.Environment(2, parent) != nil {
+		t.Fatal("unexpected entries in empty cache")
+	}
+	if _, ok := cache.GasLimit(2, parent); ok {
+		t.Fatal("unexpected gas limit in empty cache")
+	}
+	cache.StoreValidators(2, parent, vals)
+	cache.StoreEnvironment(2, parent, env)
+	cache.StoreGasLimit(2, parent, limit)
+	cache.StoreGasLimit(3, parent, GasLimit{})
+
+	// The entries are read back from the database by a new cache
+	cache = New(db)
+	if got := cache.Validators(2, parent); !reflect.DeepEqual(got, vals) {
+		t.Errorf("validators mismatch: have %+v, want %+v", got, vals)
+	}
+	if got := cache.Environment(2, parent); got == nil || got.Equal(env) != nil {
+		t.Errorf("environment mismatch: have %+v, want %+v", got, env)
+	}
+	if got, ok := cache.GasLimit(2, parent); !ok || got != limit {
+		t.Errorf("gas limit mismatch: have %+v, want %+v", got, limit)
+	}
+	if got, ok := cache.GasLimit(3, parent); !ok || got != (GasLimit{}) {
+		t.Errorf("unset gas limit mismatch: have %+v, cached %v", got, ok)
+	}
+	// Another branch or epoch does not share the entries
+	if cache.Validators(2, common.HexToHash("0xbb")) != nil || cache.Validators(3, parent) != nil {
+		t.Error("unexpected validators of another branch or epoch")
+	}
+	if cache.Environment(2, common.HexToHash("0xbb")) != nil {
+		t.Error("unexpected environment of another branch")
+	}
+
+	// The returned entries are copies
+	got := cache.Validators(2, parent)
+	got.Stakes[0].SetInt64(0)
+	if again := cache.Validators(2, parent); again.Stakes[0].Int64() != 100 {
+		t.Error("cached validators modified through the returned copy")
+	}
+}
+
+func TestCacheMemoryOnly(t *testing.T) {
+	cache := New(nil)
+	parent := common.HexToHash("0xaa")
+	cache.StoreValidators(2, parent, testValidators())
+	if got := cache.Validators(2, parent); !reflect.DeepEqual(got, testValidators()) {
+		t.Errorf("validators mismatch: have %+v", got)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	parent := common.HexToHash("0xaa")
+
+	// The entries written before the cache was versioned are dropped
+	New(db).StoreValidators(2, parent, testValidators())
+	if migrated, err := Migrate(db); err != nil || !migrated {
+		t.Fatalf("first migration: migrated %v, err %v", migrated, err)
+	}
+	if New(db).Validators(2, parent) != nil {
+		t.Error("entry of older layout not dropped")
+	}
+
+	// The entries of the current version are kept
+	New(db).StoreValidators(2, parent, testValidators())
+	if migrated, err := Migrate(db); err != nil || migrated {
+		t.Fatalf("second migration: migrated %v, err %v", migrated, err)
+	}
+	if New(db).Validators(2, parent) == nil {
+		t.Error("entry of current layout dropped")
+	}
+}
@@ -0,0 +1,86 @@
+package oasys
+
+import (
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/oasys/valcache"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// migrateValidatorCacheEpochs is the number of the recent epochs filled into the
+// validator cache on the migration, if their state is still available.
+const migrateValidatorCacheEpochs = 4
+
+// contractValidators returns the validators of the given epoch started by the
+// header, read from the contracts at its parent unless cached already.
+func (c *Oasys) contractValidators(header *types.Header, epoch uint64) (*nextValidators, error) {
+	if cached := c.valcache.Validators(epoch, header.ParentHash); cached != nil {
+		return (*nextValidators)(cached), nil
+	}
+	validators, err := c.contracts.getNextValidators(c.chainConfig, c.ethAPI, header.ParentHash, epoch, header.Number.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	c.valcache.StoreValidators(epoch, header.ParentHash, (*valcache.Validators)(validators))
+	return validators, nil
+}
+
+// contractEnvironment returns the environment value of the given epoch started
+// by the header, read from the contract at its parent unless cached already.
+func (c *Oasys) contractEnvironment(header *types.Header, epoch uint64) (*params.EnvironmentValue, error) {
+	if cached := c.valcache.Environment(epoch, header.ParentHash); cached != nil {
+		return cached, nil
+	}
+	env, err := c.contracts.getNextEnvironmentValue(c.ethAPI, header.ParentHash)
+	if err != nil {
+		return nil, err
+	}
+	c.valcache.StoreEnvironment(epoch, header.ParentHash, env)
+	return env, nil
+}
+
+// MigrateValidatorCache prepares the validator cache on the first start of the
+// release introducing it, or of a release changing its layout. The validators
+// and the environment values of the recent epochs are read from the contracts
+// at once, skipping the epochs whose state was already pruned, while the older
+// epochs are left to be filled on demand.
+func (c *Oasys) MigrateValidatorCache(chain consensus.ChainHeaderReader, head *types.Header) error {
+	if c.db == nil {
+		return nil
+	}
+	migrated, err := valcache.Migrate(c.db)
+	if err != nil || !migrated || c.ethAPI == nil {
+		return err
+	}
+	snap, err := c.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return err
+	}
+	var (
+		env    = snap.Environment
+		filled int
+	)
+	for epoch, i := env.Epoch(head.Number.Uint64()), 0; i < migrateValidatorCacheEpochs; epoch, i = epoch-1, i+1 {
+		number := env.EpochStartBlock(epoch)
+		if number == 0 {
+			break
+		}
+		header := chain.GetHeaderByNumber(number)
+		if header == nil {
+			break
+		}
+		if _, err := c.contractValidators(header, epoch); err != nil {
+			log.Debug("Skipped validator cache migration", "epoch", epoch, "number", number, "err", err)
+		} else if _, err := c.contractEnvironment(header, epoch); err != nil {
+			log.Debug("Skipped validator cache migration", "epoch", epoch, "number", number, "err", err)
+		} else {
+			filled++
+		}
+		if epoch == env.StartEpoch.Uint64() {
+			break
+		}
+	}
+	log.Info("Migrated validator cache", "version", valcache.Version, "epochs", filled)
+	return nil
+}
@@ -0,0 +1,200 @@
+package oasys
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// validatorCapABI is the ABI of the `CandidateValidatorManager.maxValidators`
+// method, which is not included in the embedded artifacts of the contract.
+const validatorCapABI = `[{"inputs":[],"name":"maxValidators","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+
+var validatorCap = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(validatorCapABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// Call the `CandidateValidatorManager.maxValidators` method. It returns zero,
+// meaning no cap, if the deployed contract does not implement the method yet.
+func (s *systemContracts) getMaxValidators(ethAPI blockchainAPI, hash common.Hash) (uint64, error) {
+	method := "maxValidators"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	data, err := validatorCap.Pack(method)
+	if err != nil {
+		return 0, err
+	}
+
+	hexData := (hexutil.Bytes)(data)
+	blockNrOrHash := rpc.BlockNumberOrHashWithHash(hash, false)
+	rbytes, err := ethAPI.Call(
+		ctx,
+		ethapi.TransactionArgs{
+			To:   &s.candidateManager2.address,
+			Data: &hexData,
+		},
+		&blockNrOrHash,
+		nil,
+		nil,
+	)
+	if err != nil {
+		// The contract reverts without a reason on an unknown method.
+		if err.Error() == vm.ErrExecutionReverted.Error() {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if len(rbytes) == 0 {
+		return 0, nil
+	}
+
+	var recv *big.Int
+	if err := validatorCap.UnpackIntoInterface(&recv, method, rbytes); err != nil {
+		return 0, err
+	}
+	if !recv.IsUint64() {
+		return 0, nil
+	}
+	return recv.Uint64(), nil
+}
+
+// capValidators trims the validators to the given number of the highest stakes.
+// The validators with the same stake are ranked by the owner address so that
+// every node selects the same set. The kept validators are ordered by owner as
+// the validator set of fast finality, and the cut ones by rank.
+func capValidators(validators *nextValidators, max uint64) (kept, cut *nextValidators) {
+	if max == 0 || uint64(len(validators.Owners)) <= max {
+		return validators, &nextValidators{}
+	}
+	ranks := make([]int, len(validators.Owners))
+	for i := range ranks {
+		ranks[i] = i
+	}
+	sort.SliceStable(ranks, func(i, j int) bool {
+		a, b := ranks[i], ranks[j]
+		if cmp := validators.Stakes[a].Cmp(validators.Stakes[b]); cmp != 0 {
+			return cmp > 0
+		}
+		return bytes.Compare(validators.Owners[a][:], validators.Owners[b][:]) < 0
+	})
+	kept, cut = &nextValidators{}, &nextValidators{}
+	for rank, i := range ranks {
+		dst := kept
+		if uint64(rank) >= max {
+			dst = cut
+		}
+		dst.Owners = append(dst.Owners, validators.Owners[i])
+		dst.Operators = append(dst.Operators, validators.Operators[i])
+		dst.Stakes = append(dst.Stakes, validators.Stakes[i])
+		dst.VoteAddresses = append(dst.VoteAddresses, validators.VoteAddresses[i])
+	}
+	kept.SortByOwner()
+	return kept, cut
+}
+
+// ScheduledValidator is a validator eligible for an epoch.
+type ScheduledValidator struct {
+	Owner    common.Address `json:"owner"`
+	Operator common.Address `json:"operator"`
+	Stake    *hexutil.Big   `json:"stake"`
+}
+
+// ValidatorSchedule is the validator set of an epoch together with the cut-off
+// applied by the maximum validator count.
+type ValidatorSchedule struct {
+	Epoch         uint64                `json:"epoch"`
+	StartBlock    uint64                `json:"startBlock"`
+	MaxValidators uint64                `json:"maxValidators"`         // Zero if the validator set is not capped
+	CutOffStake   *hexutil.Big          `json:"cutOffStake,omitempty"` // Lowest stake kept, set if any validator is cut
+	Validators    []*ScheduledValidator `json:"validators"`            // Ordered by owner
+	Excluded      []*ScheduledValidator `json:"excluded"`              // Cut by the cap, ordered by rank
+}
+
+// GetValidatorSchedule returns the validator set of the epoch including the
+// specified block, and the candidates excluded by the maximum validator count
+// set on the contract.
+func (api *API) GetValidatorSchedule(number *rpc.BlockNumber) (*ValidatorSchedule, error) {
+	header, err := api.headerByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := api.oasys.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	start := snap.Environment.GetFirstBlock(header.Number.Uint64())
+	if start == 0 {
+		return nil, errUnknownBlock
+	}
+	parent := api.chain.GetHeaderByNumber(start - 1)
+	if parent == nil {
+		return nil, errUnknownBlock
+	}
+	schedule, _, err := api.oasys.validatorSchedule(parent.Hash(), snap.Environment.Epoch(start), start)
+	return schedule, err
+}
+
+// validatorSchedule computes the validator set of the given epoch starting at
+// the given block from the contracts at the given block hash, as the engine does
+// at the epoch block, and returns it with the validators kept.
+func (c *Oasys) validatorSchedule(hash common.Hash, epoch, start uint64) (*ValidatorSchedule, *nextValidators, error) {
+	var (
+		schedule  = &ValidatorSchedule{Epoch: epoch, StartBlock: start}
+		all, kept *nextValidators
+		cut       = &nextValidators{}
+		err       error
+	)
+	if !c.chainConfig.IsOasysValidatorCap(new(big.Int).SetUint64(start)) {
+		if kept, err = c.contracts.getNextValidators(c.chainConfig, c.ethAPI, hash, epoch, start); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		if all, err = c.contracts.callGetHighStakes2(c.ethAPI, hash, epoch); err != nil {
+			return nil, nil, err
+		}
+		if schedule.MaxValidators, err = c.contracts.getMaxValidators(c.ethAPI, hash); err != nil {
+			return nil, nil, err
+		}
+		all.SortByOwner()
+		kept, cut = capValidators(all, schedule.MaxValidators)
+	}
+	schedule.Validators = scheduledValidators(kept)
+	schedule.Excluded = scheduledValidators(cut)
+	if len(cut.Owners) > 0 {
+		lowest := kept.Stakes[0]
+		for _, stake := range kept.Stakes {
+			if stake.Cmp(lowest) < 0 {
+				lowest = stake
+			}
+		}
+		schedule.CutOffStake = (*hexutil.Big)(new(big.Int).Set(lowest))
+	}
+	return schedule, kept, nil
+}
+
+func scheduledValidators(validators *nextValidators) []*ScheduledValidator {
+	result := make([]*ScheduledValidator, 0, len(validators.Owners))
+	for i, owner := range validators.Owners {
+		result = append(result, &ScheduledValidator{
+			Owner:    owner,
+			Operator: validators.Operators[i],
+			Stake:    (*hexutil.Big)(new(big.Int).Set(validators.Stakes[i])),
+		})
+	}
+	return result
+}
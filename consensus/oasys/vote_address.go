@@ -0,0 +1,108 @@
+package oasys
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	// Number of validators whose vote address registered on the contract is not
+	// yet effective in the snapshot
+	pendingVoteAddressGauge = metrics.NewRegisteredGauge("oasys/validators/pendingvoteaddress", nil)
+
+	// Votes rejected as signed by a vote address pending until the next epoch
+	pendingVoteAddressRejectCounter = metrics.NewRegisteredCounter("oasys/VerifyVote/pendingvoteaddress", nil)
+)
+
+// VoteAddressStatus is the vote address of a validator effective in the snapshot
+// and the one registered on the contract, which becomes effective from the next
+// epoch if they differ.
+type VoteAddressStatus struct {
+	Operator   common.Address     `json:"operator"`
+	Effective  types.BLSPublicKey `json:"effective"`
+	Registered types.BLSPublicKey `json:"registered"`
+	Pending    bool               `json:"pending"`
+}
+
+// pendingVoteAddresses caches the vote address statuses computed at a block, as
+// the rejected votes are checked against them for every vote.
+type pendingVoteAddresses struct {
+	hash     common.Hash
+	statuses []*VoteAddressStatus
+}
+
+// voteAddressStatuses compares the vote addresses of the validators in the
+// snapshot with the ones registered on the contract for the next
+// epoch, and updates the metric of the pending changes.
+func (c *Oasys) voteAddressStatuses(snap *Snapshot) ([]*VoteAddressStatus, error) {
+	if !c.chainConfig.IsFastFinalityEnabled(new(big.Int).SetUint64(snap.Number)) {
+		return nil, errors.New("fast finality is not enabled")
+	}
+	c.lock.RLock()
+	cached := c.voteAddresses
+	c.lock.RUnlock()
+	if cached != nil && cached.hash == snap.Hash {
+		return cached.statuses, nil
+	}
+
+	next, err := c.contracts.callGetHighStakes2(c.ethAPI, snap.Hash, snap.Environment.Epoch(snap.Number)+1)
+	if err != nil {
+		return nil, err
+	}
+	statuses, pending := compareVoteAddresses(snap, next)
+	pendingVoteAddressGauge.Update(int64(pending))
+
+	c.lock.Lock()
+	c.voteAddresses = &pendingVoteAddresses{hash: snap.Hash, statuses: statuses}
+	c.lock.Unlock()
+	return statuses, nil
+}
+
+// compareVoteAddresses returns the vote address statuses of the validators in
+// the snapshot against the next validators, and the number of pending changes.
+func compareVoteAddresses(snap *Snapshot, next *nextValidators) ([]*VoteAddressStatus, int) {
+	registered := make(map[common.Address]types.BLSPublicKey, len(next.Operators))
+	for i, operator := range next.Operators {
+		registered[operator] = next.VoteAddresses[i]
+	}
+	var (
+		statuses = make([]*VoteAddressStatus, 0, len(snap.Validators))
+		pending  int
+	)
+	for _, operator := range snap.validators() {
+		status := &VoteAddressStatus{
+			Operator:   operator,
+			Effective:  snap.Validators[operator].VoteAddress,
+			Registered: registered[operator],
+		}
+		status.Pending = status.Registered != (types.BLSPublicKey{}) && status.Registered != status.Effective
+		if status.Pending {
+			pending++
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, pending
+}
+
+// announcePendingVoteAddress reports the vote rejected as signed by the vote
+// address registered on the contract, which is not effective until the next
+// epoch, so that the validator rotating its key mid-epoch is not left unnoticed.
+func (c *Oasys) announcePendingVoteAddress(snap *Snapshot, vote *types.VoteEnvelope) {
+	statuses, err := c.voteAddressStatuses(snap)
+	if err != nil {
+		return
+	}
+	for _, status := range statuses {
+		if status.Pending && status.Registered == vote.VoteAddress {
+			pendingVoteAddressRejectCounter.Inc(1)
+			log.Warn("Rejected vote signed by the vote address pending until the next epoch", "operator", status.Operator,
+				"effective", status.Effective, "registered", status.Registered, "target", vote.Data.TargetNumber)
+			return
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package oasys
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// maxVoteArrivalGauges bounds the number of the per-validator vote arrival
+	// gauges, in case the votes of an epoch come from more validators than expected.
+	maxVoteArrivalGauges = 1000
+
+	// voteArrivalSafetyMargin is the margin between the arrival of the votes for
+	// the parent and the seal, below which the votes risk missing the attestation.
+	voteArrivalSafetyMargin = 500 * time.Millisecond
+
+	// inmemoryVoteArrivals is the number of the votes whose local arrival is kept,
+	// enough for the votes of the recent blocks.
+	inmemoryVoteArrivals = 4096
+
+	// voteArrivalChanSize is the size of the channel receiving the votes entering
+	// the vote pool.
+	voteArrivalChanSize = 256
+)
+
+// voteMarginHistogram is the distribution of the milliseconds between the local
+// receipt of the votes for the parent and the seal of the block.
+var voteMarginHistogram = metrics.NewRegisteredHistogram("oasys/vote/margin", nil, metrics.NewExpDecaySample(1028, 0.015))
+
+// voteArrivalMetrics averages the margins of the votes per validator at the
+// blocks sealed locally in the current epoch. When the epoch ends, the validators
+// whose votes arrive too close to the seal are recommended to compensate their
+// broadcast delay. As the vote gauges, the per-validator gauges only live
+// through an epoch.
+type voteArrivalMetrics struct {
+	lock   sync.Mutex
+	epoch  uint64
+	sums   map[common.Address]int64
+	counts map[common.Address]int64
+}
+
+var validatorVoteArrivalMetrics = &voteArrivalMetrics{
+	sums:   make(map[common.Address]int64),
+	counts: make(map[common.Address]int64),
+}
+
+// voteMarginGaugeName returns the name of the vote margin gauge of the validator.
+func voteMarginGaugeName(validator common.Address) string {
+	return fmt.Sprintf("oasys/vote/margin/%s", validator.String())
+}
+
+// voteCompensationGaugeName returns the name of the gauge of the milliseconds
+// the validator is recommended to broadcast its votes earlier by.
+func voteCompensationGaugeName(validator common.Address) string {
+	return fmt.Sprintf("oasys/vote/compensation/%s", validator.String())
+}
+
+// voteCompensation returns the time the votes with the given average margin are
+// recommended to arrive earlier by, to keep the safety margin.
+func voteCompensation(margin int64) time.Duration {
+	if compensation := voteArrivalSafetyMargin - time.Duration(margin)*time.Millisecond; compensation > 0 {
+		return compensation
+	}
+	return 0
+}
+
+// mark adds the margin in milliseconds of a vote of the validator at a block of
+// the given epoch. The blocks of the past epochs are not counted.
+func (m *voteArrivalMetrics) mark(epoch uint64, validator common.Address, margin int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if epoch < m.epoch {
+		return
+	}
+	if epoch > m.epoch {
+		m.reset(epoch)
+	}
+	count, ok := m.counts[validator]
+	if !ok && len(m.counts) >= maxVoteArrivalGauges {
+		return
+	}
+	count++
+	m.counts[validator] = count
+	m.sums[validator] += margin
+	if metrics.Enabled {
+		average := m.sums[validator] / count
+		metrics.GetOrRegisterGauge(voteMarginGaugeName(validator), nil).Update(average)
+		metrics.GetOrRegisterGauge(voteCompensationGaugeName(validator), nil).Update(voteCompensation(average).Milliseconds())
+	}
+}
+
+// reset publishes the recommendations of the ended epoch and drops its gauges.
+func (m *voteArrivalMetrics) reset(epoch uint64) {
+	for validator, count := range m.counts {
+		average := m.sums[validator] / count
+		if compensation := voteCompensation(average); compensation > 0 {
+			log.Warn("Votes arrive close to the seal, recommend broadcasting earlier", "validator", validator, "epoch", m.epoch,
+				"votes", count, "margin", time.Duration(average)*time.Millisecond, "compensation", compensation)
+		}
+		metrics.DefaultRegistry.Unregister(voteMarginGaugeName(validator))
+		metrics.DefaultRegistry.Unregister(voteCompensationGaugeName(validator))
+	}
+	m.epoch = epoch
+	m.sums = make(map[common.Address]int64)
+	m.counts = make(map[common.Address]int64)
+}
+
+// voteFeed is the vote pool announcing the votes entering it.
+type voteFeed interface {
+	SubscribeNewVoteEvent(ch chan<- core.NewVoteEvent) event.Subscription
+}
+
+// voteArrivals records the local arrival time of the votes announced by the
+// vote pool, which are measured against the seal of the blocks.
+type voteArrivals struct {
+	times *lru.Cache // Vote hash -> time.Time of the first local arrival
+
+	lock sync.Mutex
+	sub  event.Subscription // Subscription to the vote pool, nil if not tracking, protected by lock
+}
+
+func newVoteArrivals() *voteArrivals {
+	times, _ := lru.New(inmemoryVoteArrivals)
+	return &voteArrivals{times: times}
+}
+
+// track records the arrivals of the votes announced by the feed, replacing the
+// feed tracked before.
+func (a *voteArrivals) track(feed voteFeed) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.sub != nil {
+		a.sub.Unsubscribe()
+	}
+	ch := make(chan core.NewVoteEvent, voteArrivalChanSize)
+	a.sub = feed.SubscribeNewVoteEvent(ch)
+	go a.loop(ch, a.sub)
+}
+
+func (a *voteArrivals) loop(ch <-chan core.NewVoteEvent, sub event.Subscription) {
+	for {
+		select {
+		case ev := <-ch:
+			a.record(ev.Vote, time.Now())
+		case <-sub.Err():
+			return
+		}
+	}
+}
+
+// stop stops tracking the vote pool.
+func (a *voteArrivals) stop() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.sub != nil {
+		a.sub.Unsubscribe()
+		a.sub = nil
+	}
+}
+
+// record records the arrival of the vote, unless it arrived earlier.
+func (a *voteArrivals) record(vote *types.VoteEnvelope, at time.Time) {
+	a.times.ContainsOrAdd(vote.Hash(), at)
+}
+
+// arrival returns the time of the first local arrival of the vote, or the zero
+// time if it's not recorded.
+func (a *voteArrivals) arrival(vote *types.VoteEnvelope) time.Time {
+	if at, ok := a.times.Get(vote.Hash()); ok {
+		return at.(time.Time)
+	}
+	return time.Time{}
+}
+
+// markVoteArrivals measures the margins between the local arrival of the votes
+// for the parent and the seal of the header at the given time.
+func (c *Oasys) markVoteArrivals(snap *Snapshot, header *types.Header, votes []*types.VoteEnvelope, sealedAt time.Time) {
+	if len(votes) == 0 {
+		return
+	}
+	operators := make(map[types.BLSPublicKey]common.Address, len(snap.Validators))
+	for operator, info := range snap.Validators {
+		operators[info.VoteAddress] = operator
+	}
+	epoch := snap.Environment.Epoch(header.Number.Uint64())
+	for _, vote := range votes {
+		operator, ok := operators[vote.VoteAddress]
+		if !ok {
+			continue
+		}
+		arrival := c.voteArrivals.arrival(vote)
+		if arrival.IsZero() {
+			continue
+		}
+		margin := sealedAt.Sub(arrival).Milliseconds()
+		voteMarginHistogram.Update(margin)
+		validatorVoteArrivalMetrics.mark(epoch, operator, margin)
+	}
+}
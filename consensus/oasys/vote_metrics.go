@@ -0,0 +1,68 @@
+package oasys
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// maxVoteGauges bounds the number of the per-validator vote gauges, in case
+// the votes of an epoch come from more validators than expected.
+const maxVoteGauges = 1000
+
+// votesPerValidatorHistogram is the distribution of the number of the votes
+// verified per validator over the ended epochs.
+var votesPerValidatorHistogram = metrics.NewRegisteredHistogram("oasys/VerifyVote/pervalidator", nil, metrics.NewExpDecaySample(1028, 0.015))
+
+// voteMetrics counts the votes verified per validator in the current epoch.
+// The per-validator gauges only live through an epoch, so that the metrics of
+// the validators leaving the validator set do not accumulate on long-running
+// nodes. The counts of an ended epoch are summarized in the histogram.
+type voteMetrics struct {
+	lock   sync.Mutex
+	epoch  uint64
+	counts map[common.Address]int64
+}
+
+var validatorVoteMetrics = &voteMetrics{counts: make(map[common.Address]int64)}
+
+// voteGaugeName returns the name of the vote gauge of the validator.
+func voteGaugeName(validator common.Address) string {
+	return fmt.Sprintf("oasys/VerifyVote/%s", validator.String())
+}
+
+// mark counts a vote of the validator for a block in the given epoch. The
+// votes for the blocks of the past epochs are not counted.
+func (m *voteMetrics) mark(epoch uint64, validator common.Address) {
+	if !metrics.Enabled {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if epoch < m.epoch {
+		return
+	}
+	if epoch > m.epoch {
+		m.reset(epoch)
+	}
+	count, ok := m.counts[validator]
+	if !ok && len(m.counts) >= maxVoteGauges {
+		return
+	}
+	count++
+	m.counts[validator] = count
+	metrics.GetOrRegisterGauge(voteGaugeName(validator), nil).Update(count)
+}
+
+// reset summarizes the counts of the ended epoch and drops its gauges.
+func (m *voteMetrics) reset(epoch uint64) {
+	for validator, count := range m.counts {
+		votesPerValidatorHistogram.Update(count)
+		metrics.DefaultRegistry.Unregister(voteGaugeName(validator))
+	}
+	m.epoch = epoch
+	m.counts = make(map[common.Address]int64)
+}
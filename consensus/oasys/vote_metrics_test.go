@@ -0,0 +1,158 @@
+package oasys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+func TestVoteMetricsReset(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	var (
+		m       = &voteMetrics{counts: make(map[common.Address]int64)}
+		alice   = common.HexToAddress("0x01")
+		bob     = common.HexToAddress("0x02")
+		gaugeOf = func(addr common.Address) metrics.Gauge {
+			g, _ := metrics.DefaultRegistry.Get(voteGaugeName(addr)).(metrics.Gauge)
+			return g
+		}
+		countOf = func(addr common.Address) int64 { return gaugeOf(addr).Snapshot().Value() }
+	)
+	m.mark(1, alice)
+	m.mark(1, alice)
+	m.mark(1, bob)
+	if have := countOf(alice); have != 2 {
+		t.Fatalf("alice votes mismatch, have %d, want 2", have)
+	}
+
+	// Votes of the past epochs are ignored, and the gauges of the validators
+	// not voting in the new epoch are dropped.
+	m.mark(2, bob)
+	m.mark(1, alice)
+	if gaugeOf(alice) != nil {
+		t.Fatal("gauge of the previous epoch not dropped")
+	}
+	if have := countOf(bob); have != 1 {
+		t.Fatalf("bob votes mismatch, have %d, want 1", have)
+	}
+	m.reset(3)
+}
+
+func TestVoteArrivalMetrics(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	var (
+		m = &voteArrivalMetrics{
+			sums:   make(map[common.Address]int64),
+			counts: make(map[common.Address]int64),
+		}
+		alice   = common.HexToAddress("0x01")
+		bob     = common.HexToAddress("0x02")
+		valueOf = func(name string) int64 {
+			g, _ := metrics.DefaultRegistry.Get(name).(metrics.Gauge)
+			if g == nil {
+				return -1
+			}
+			return g.Snapshot().Value()
+		}
+	)
+	m.mark(1, alice, 100)
+	m.mark(1, alice, 300)
+	m.mark(1, bob, 900)
+	if have := valueOf(voteMarginGaugeName(alice)); have != 200 {
+		t.Fatalf("alice margin mismatch, have %d, want 200", have)
+	}
+	if have := valueOf(voteCompensationGaugeName(alice)); have != 300 {
+		t.Fatalf("alice compensation mismatch, have %d, want 300", have)
+	}
+	if have := valueOf(voteCompensationGaugeName(bob)); have != 0 {
+		t.Fatalf("bob compensation mismatch, have %d, want 0", have)
+	}
+
+	m.mark(2, bob, 600)
+	if valueOf(voteMarginGaugeName(alice)) != -1 || valueOf(voteCompensationGaugeName(alice)) != -1 {
+		t.Fatal("gauges of the previous epoch not dropped")
+	}
+	m.reset(3)
+}
+
+// testVoteFeedPool is a vote pool announcing the votes put into it.
+type testVoteFeedPool struct {
+	testVotePool
+	feed event.Feed
+}
+
+func (p *testVoteFeedPool) SubscribeNewVoteEvent(ch chan<- core.NewVoteEvent) event.Subscription {
+	return p.feed.Subscribe(ch)
+}
+
+func (p *testVoteFeedPool) put(vote *types.VoteEnvelope) {
+	p.testVotePool[vote.Data.TargetHash] = append(p.testVotePool[vote.Data.TargetHash], vote)
+	p.feed.Send(core.NewVoteEvent{Vote: vote})
+}
+
+func TestVoteArrivalsFromPool(t *testing.T) {
+	defer func(m *voteArrivalMetrics) { validatorVoteArrivalMetrics = m }(validatorVoteArrivalMetrics)
+	validatorVoteArrivalMetrics = &voteArrivalMetrics{
+		sums:   make(map[common.Address]int64),
+		counts: make(map[common.Address]int64),
+	}
+	env, err := makeVoteEnv(4)
+	if err != nil {
+		t.Fatalf("failed to create test vote env: %v", err)
+	}
+	pool := &testVoteFeedPool{testVotePool: make(testVotePool)}
+	env.engine.SetVotePool(pool)
+	defer env.engine.Close()
+
+	genesis := env.chain.CurrentHeader()
+	data := &types.VoteData{TargetNumber: 0, TargetHash: genesis.Hash()}
+	votes := make([]*types.VoteEnvelope, len(env.validators))
+	for i, v := range env.validators {
+		votes[i] = v.vote(data)
+	}
+	// The arrivals are recorded from the votes announced by the pool, and the
+	// votes announced again keep the first arrival
+	pool.put(votes[0])
+	pool.put(votes[1])
+	pool.testVotePool[genesis.Hash()] = append(pool.testVotePool[genesis.Hash()], votes[2]) // Never announced
+
+	deadline := time.Now().Add(time.Second)
+	for env.engine.voteArrivals.arrival(votes[1]).IsZero() {
+		if time.Now().After(deadline) {
+			t.Fatal("vote arrival not recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	first := env.engine.voteArrivals.arrival(votes[0])
+	pool.feed.Send(core.NewVoteEvent{Vote: votes[0]})
+	time.Sleep(20 * time.Millisecond)
+	if have := env.engine.voteArrivals.arrival(votes[0]); !have.Equal(first) {
+		t.Fatalf("arrival overwritten, have %v, want %v", have, first)
+	}
+
+	header := &types.Header{Number: common.Big1, ParentHash: genesis.Hash()}
+	sealedAt := env.engine.voteArrivals.arrival(votes[1]).Add(200 * time.Millisecond)
+	env.engine.markVoteArrivals(env.genesis, header, pool.FetchVoteByBlockHash(genesis.Hash()), sealedAt)
+
+	m := validatorVoteArrivalMetrics
+	if m.counts[env.validators[0].address] != 1 || m.counts[env.validators[1].address] != 1 {
+		t.Fatalf("announced votes not measured: %v", m.counts)
+	}
+	if m.sums[env.validators[1].address] != 200 {
+		t.Fatalf("margin mismatch, have %d, want 200", m.sums[env.validators[1].address])
+	}
+	if _, ok := m.counts[env.validators[2].address]; ok {
+		t.Fatal("vote without arrival measured")
+	}
+}
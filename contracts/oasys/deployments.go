@@ -1,6 +1,7 @@
 package oasys
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"strings"
@@ -69,6 +70,56 @@ func mustDecodeCode(code string) []byte {
 
 type deploymentSet [][]*deployment
 
+// embedded returns the addresses of the contracts already holding the code they
+// are given last in the set.
+func (s deploymentSet) embedded(state StateDB) map[string]bool {
+	final := make(map[string][]byte)
+	for _, deployments := range s {
+		for _, d := range deployments {
+			if d.code != nil {
+				final[d.contract.address] = d.code
+			}
+		}
+	}
+	embedded := make(map[string]bool)
+	for address, code := range final {
+		if bytes.Equal(state.GetCode(common.HexToAddress(address)), code) {
+			embedded[address] = true
+		}
+	}
+	return embedded
+}
+
+// BuiltinContracts returns the names of the built-in contracts deployed by any of
+// the deployment sets, and of the system contracts at the addresses configured in
+// the genesis, keyed by address.
+func BuiltinContracts(config *params.ChainConfig) map[common.Address]string {
+	contracts := make(map[common.Address]string)
+	for _, deploymentMap := range deploymentSets {
+		for _, deploymentSet := range deploymentMap {
+			for _, deployments := range deploymentSet {
+				for _, d := range deployments {
+					contracts[common.HexToAddress(d.contract.address)] = d.contract.name
+				}
+			}
+		}
+	}
+	if config == nil || config.Oasys == nil || config.Oasys.Contracts == nil {
+		return contracts
+	}
+	for address, name := range map[common.Address]string{
+		config.Oasys.Contracts.Environment:               environment.name,
+		config.Oasys.Contracts.StakeManager:              stakeManager.name,
+		config.Oasys.Contracts.AllowList:                 l1BuildAllowList.name,
+		config.Oasys.Contracts.CandidateValidatorManager: candidateValidatorManager.name,
+	} {
+		if address != (common.Address{}) {
+			contracts[address] = name
+		}
+	}
+	return contracts
+}
+
 // Contract deployment definition.
 type deployment struct {
 	contract *contract
@@ -34,8 +34,10 @@ var deployments11 = []*deployment{
 						updated.StartEpoch = cfg.OasysShortenedBlockTimeStartEpoch()
 						updated.StartBlock = new(big.Int).SetUint64(
 							initial.NewValueStartBlock(updated.StartEpoch.Uint64()))
-						updated.BlockPeriod = big.NewInt(params.SHORT_BLOCK_TIME_SECONDS)
-						updated.EpochPeriod = big.NewInt(params.SHORT_BLOCK_TIME_EPOCH_PERIOD)
+						if !cfg.OasysKeepPeriods() {
+							updated.BlockPeriod = big.NewInt(params.SHORT_BLOCK_TIME_SECONDS)
+							updated.EpochPeriod = big.NewInt(params.SHORT_BLOCK_TIME_EPOCH_PERIOD)
+						}
 
 						return structvalue{
 							updated.StartBlock,
@@ -0,0 +1,148 @@
+package oasys
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// genesisState is a StateDB collecting the code and the storage of the deployed
+// contracts into genesis accounts.
+type genesisState types.GenesisAlloc
+
+func (s genesisState) GetCode(addr common.Address) []byte {
+	return s[addr].Code
+}
+
+func (s genesisState) SetCode(addr common.Address, code []byte) {
+	account := s.account(addr)
+	account.Code = code
+	s[addr] = account
+}
+
+func (s genesisState) SetState(addr common.Address, key common.Hash, value common.Hash) {
+	account := s.account(addr)
+	if account.Storage == nil {
+		account.Storage = make(map[common.Hash]common.Hash)
+	}
+	account.Storage[key] = value
+	s[addr] = account
+}
+
+func (s genesisState) account(addr common.Address) types.Account {
+	account := s[addr]
+	if account.Balance == nil {
+		account.Balance = new(big.Int)
+	}
+	return account
+}
+
+// ContractNames returns the sorted names of the built-in contracts deployed on
+// the new networks, which can be selected by BuildGenesisAlloc.
+func ContractNames() []string {
+	var names []string
+	for _, deployment := range defaultDeployments() {
+		names = append(names, deployment.contract.name)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// BuildGenesisAlloc returns the genesis accounts of the built-in contracts with
+// their code and storage, as deployed on the new networks by the engine. Unlike
+// the deployments made at the early blocks, the contracts are present from the
+// genesis, so that verse chains can embed them. If names are given, only the
+// contracts of the given names are included.
+func BuildGenesisAlloc(cfg *params.ChainConfig, names ...string) (types.GenesisAlloc, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("missing chain config")
+	}
+	selected := make(map[string]bool)
+	if len(names) > 0 {
+		known := make(map[string]bool)
+		for _, name := range ContractNames() {
+			known[name] = true
+		}
+		for _, name := range names {
+			if !known[name] {
+				return nil, fmt.Errorf("unknown contract: %s, available: %s", name, strings.Join(ContractNames(), ", "))
+			}
+			selected[name] = true
+		}
+	}
+	state := make(genesisState)
+	for _, d := range defaultDeployments() {
+		if len(selected) > 0 && !selected[d.contract.name] {
+			continue
+		}
+		d.deployCode(state)
+		storage, err := d.storage.build(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build %s contract storage map: %v", d.contract.name, err)
+		}
+		address := common.HexToAddress(d.contract.address)
+		for key, val := range storage {
+			state.SetState(address, key, val)
+		}
+	}
+	return types.GenesisAlloc(state), nil
+}
+
+// BuildAllowListAlloc returns the genesis account of the AllowList contract the
+// StakeManager is initialized with, owned by the given address and allowing the
+// given addresses to join as validators. It shares the implementation of the
+// L1BuildAllowList contract.
+func BuildAllowListAlloc(owner common.Address, allowed []common.Address) (common.Address, types.Account, error) {
+	var code []byte
+	for _, d := range defaultDeployments() {
+		if d.contract == l1BuildAllowList {
+			code = d.code
+		}
+	}
+	var (
+		values  = make(map[int64]interface{})
+		indexes = make(map[string]interface{})
+	)
+	for i, address := range allowed {
+		values[int64(i)] = address
+		indexes[address.Hex()] = big.NewInt(int64(i + 1))
+	}
+	storage, err := storage{
+		// address private _owner
+		"0x00": owner,
+		// EnumerableSet.AddressSet private _allowlist
+		"0x01": &array{values: values},
+		"0x02": &mapping{keyFn: addressKeyFn, values: indexes},
+	}.build(nil)
+	if err != nil {
+		return common.Address{}, types.Account{}, err
+	}
+	return common.HexToAddress(AllowListAddress), types.Account{Code: code, Storage: storage, Balance: new(big.Int)}, nil
+}
+
+// defaultDeployments returns the deployments of the new networks in the order of
+// the deployment, so that the upgrades override the earlier code and storage.
+func defaultDeployments() []*deployment {
+	var (
+		deploymentMap = deploymentSets[defaultGenesisHash]
+		blocks        = make([]uint64, 0, len(deploymentMap))
+		ordered       []*deployment
+	)
+	for block := range deploymentMap {
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i] < blocks[j] })
+
+	for _, block := range blocks {
+		for _, deployments := range deploymentMap[block] {
+			ordered = append(ordered, deployments...)
+		}
+	}
+	return ordered
+}
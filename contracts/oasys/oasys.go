@@ -47,8 +47,14 @@ func Deploy(chainConfig *params.ChainConfig, state StateDB, block uint64) {
 	}
 
 	if deploymentSet, ok := deploymentMap[block]; ok {
+		// The contracts embedded in the genesis by BuildGenesisAlloc already hold
+		// their final code, and are kept rather than reset to the initial storage.
+		embedded := deploymentSet.embedded(state)
 		for _, deployments := range deploymentSet {
 			for _, d := range deployments {
+				if embedded[d.contract.address] {
+					continue
+				}
 				d.deploy(chainConfig, state, block)
 			}
 		}
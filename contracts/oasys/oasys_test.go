@@ -1296,3 +1296,87 @@ func TestDeploy(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildGenesisAlloc(t *testing.T) {
+	config := params.OasysTestnetChainConfig
+
+	// The genesis accounts match the deployments of the new networks
+	deployed := MockStateDB{}
+	Deploy(config, deployed, 2)
+
+	alloc, err := BuildGenesisAlloc(config)
+	if err != nil {
+		t.Fatalf("failed to build genesis alloc: %v", err)
+	}
+	if len(alloc) != len(deployed) {
+		t.Fatalf("accounts mismatch, got: %d, want: %d", len(alloc), len(deployed))
+	}
+	for addr, contract := range deployed {
+		account, ok := alloc[addr]
+		if !ok {
+			t.Fatalf("missing account: %s", addr)
+		}
+		if !bytes.Equal(account.Code, contract.code) {
+			t.Errorf("code mismatch, address: %s", addr)
+		}
+		if len(account.Storage) != len(contract.storage) {
+			t.Errorf("storage mismatch, address: %s, got: %d, want: %d", addr, len(account.Storage), len(contract.storage))
+		}
+		for key, val := range contract.storage {
+			if account.Storage[key] != val {
+				t.Errorf("storage mismatch, address: %s, slot: %s", addr, key)
+			}
+		}
+	}
+
+	// Subset of the contracts
+	alloc, err = BuildGenesisAlloc(config, "StakeManager", "WOAS")
+	if err != nil {
+		t.Fatalf("failed to build genesis alloc: %v", err)
+	}
+	if len(alloc) != 2 {
+		t.Fatalf("accounts mismatch, got: %d, want: 2", len(alloc))
+	}
+	if _, ok := alloc[common.HexToAddress(StakeManagerAddress)]; !ok {
+		t.Error("missing StakeManager")
+	}
+
+	if _, err = BuildGenesisAlloc(config, "Unknown"); err == nil {
+		t.Error("expected error for unknown contract")
+	}
+}
+
+func TestDeployEmbeddedGenesis(t *testing.T) {
+	config := &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: &params.OasysConfig{Period: 15, Epoch: 5760}}
+	alloc, err := BuildGenesisAlloc(config)
+	if err != nil {
+		t.Fatalf("failed to build genesis alloc: %v", err)
+	}
+	var (
+		state   = make(MockStateDB)
+		manager = common.HexToAddress(StakeManagerAddress)
+		slot    common.Hash
+	)
+	for key := range alloc[manager].Storage {
+		slot = key
+	}
+	for addr, account := range alloc {
+		state.SetCode(addr, account.Code)
+		for key, val := range account.Storage {
+			state.SetState(addr, key, val)
+		}
+	}
+	// The storage changed since the genesis is kept at the deployment block
+	state.SetState(manager, slot, common.Hash{0x01})
+	Deploy(config, state, 2)
+	if got := state.getContract(manager).storage[slot]; got != (common.Hash{0x01}) {
+		t.Fatalf("embedded storage reset: %v", got)
+	}
+
+	// The contracts not embedded are still deployed
+	delete(state, manager)
+	Deploy(config, state, 2)
+	if !bytes.Equal(state.GetCode(manager), alloc[manager].Code) {
+		t.Fatal("missing contract not deployed")
+	}
+}
@@ -144,6 +144,8 @@ type CacheConfig struct {
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	Preimages           bool          // Whether to store preimage of trie key to the disk
 	StateHistory        uint64        // Number of blocks from head whose state histories are reserved.
+	TxIndexFinalized    bool          // Whether to reserve the transaction indexes back from the finalized block instead of head
+	ParallelTx          bool          // Whether to execute the transactions of the imported blocks in parallel
 	StateScheme         string        // Scheme used to store ethereum states and merkle tree nodes on top
 
 	SnapshotNoBuild bool // Whether the background generation is allowed
@@ -521,6 +523,14 @@ func (bc *BlockChain) GetJustifiedNumber(header *types.Header) uint64 {
 	return 0
 }
 
+// GetFinalizedHeader returns the highest finalized block header on the branch including and before `header`.
+func (bc *BlockChain) GetFinalizedHeader(header *types.Header) *types.Header {
+	if p, ok := bc.engine.(consensus.PoS); ok {
+		return p.GetFinalizedHeader(bc, header)
+	}
+	return nil
+}
+
 // loadLastState loads the last known chain state from the database. This method
 // assumes that the chain manager mutex is held.
 func (bc *BlockChain) loadLastState() error {
@@ -925,6 +935,13 @@ func (bc *BlockChain) Export(w io.Writer) error {
 
 // ExportN writes a subset of the active chain to the given writer.
 func (bc *BlockChain) ExportN(w io.Writer, first uint64, last uint64) error {
+	return bc.ExportNCallback(w, first, last, nil)
+}
+
+// ExportNCallback writes a subset of the active chain to the given writer, and
+// invokes the callback after each block is written so that the caller can
+// append additional records of the block to the stream.
+func (bc *BlockChain) ExportNCallback(w io.Writer, first uint64, last uint64, callback func(w io.Writer, block *types.Block) error) error {
 	if first > last {
 		return fmt.Errorf("export failed: first (%d) is greater than last (%d)", first, last)
 	}
@@ -947,6 +964,11 @@ func (bc *BlockChain) ExportN(w io.Writer, first uint64, last uint64) error {
 		if err := block.EncodeRLP(w); err != nil {
 			return err
 		}
+		if callback != nil {
+			if err := callback(w, block); err != nil {
+				return err
+			}
+		}
 		if time.Since(reported) >= statsReportLimit {
 			log.Info("Exporting blocks", "exported", block.NumberU64()-first, "elapsed", common.PrettyDuration(time.Since(start)))
 			reported = time.Now()
@@ -1534,19 +1556,24 @@ func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types
 	bc.futureBlocks.Remove(block.Hash())
 
 	if status == CanonStatTy {
-		bc.chainFeed.Send(ChainEvent{Block: block, Hash: block.Hash(), Logs: logs})
-		if len(logs) > 0 {
-			bc.logsFeed.Send(logs)
-		}
-		var finalizedHeader *types.Header
+		var (
+			finalizedHeader *types.Header
+			event           = ChainEvent{Block: block, Hash: block.Hash(), Logs: logs}
+		)
 		if pos, ok := bc.Engine().(consensus.PoS); ok {
 			if finalizedHeader = pos.GetFinalizedHeader(bc, block.Header()); finalizedHeader != nil {
 				bc.SetFinalized(finalizedHeader)
+				event.FinalizedNumber = finalizedHeader.Number.Uint64()
 			}
 			if justifiedBlockNumber, _, err := pos.GetJustifiedNumberAndHash(bc, []*types.Header{block.Header()}); err == nil {
 				justifiedBlockGauge.Update(int64(justifiedBlockNumber))
+				event.JustifiedNumber = justifiedBlockNumber
 			}
 		}
+		bc.chainFeed.Send(event)
+		if len(logs) > 0 {
+			bc.logsFeed.Send(logs)
+		}
 		// In theory, we should fire a ChainHeadEvent when we inject
 		// a canonical block, but sometimes we can insert a batch of
 		// canonical blocks. Avoid firing too many ChainHeadEvents,
@@ -2412,7 +2439,11 @@ func (bc *BlockChain) SetCanonical(head *types.Block) (common.Hash, error) {
 
 	// Emit events
 	logs := bc.collectLogs(head, false)
-	bc.chainFeed.Send(ChainEvent{Block: head, Hash: head.Hash(), Logs: logs})
+	event := ChainEvent{Block: head, Hash: head.Hash(), Logs: logs, JustifiedNumber: bc.GetJustifiedNumber(head.Header())}
+	if finalized := bc.GetFinalizedHeader(head.Header()); finalized != nil {
+		event.FinalizedNumber = finalized.Number.Uint64()
+	}
+	bc.chainFeed.Send(event)
 	if len(logs) > 0 {
 		bc.logsFeed.Send(logs)
 	}
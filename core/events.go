@@ -40,6 +40,11 @@ type ChainEvent struct {
 	Block *types.Block
 	Hash  common.Hash
 	Logs  []*types.Log
+
+	// Highest justified and finalized block numbers on the branch of the block,
+	// zero if the engine has no fast finality
+	JustifiedNumber uint64
+	FinalizedNumber uint64
 }
 
 type ChainSideEvent struct {
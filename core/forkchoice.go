@@ -30,6 +30,10 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 )
 
+// maxAttestationForkChoiceDepth is the maximum number of blocks to walk back on
+// the branches to compare their attested blocks.
+const maxAttestationForkChoiceDepth = 1024
+
 // ChainReader defines a small collection of methods needed to access the local
 // blockchain during header verification. It's implemented by both blockchain
 // and lightchain.
@@ -43,6 +47,15 @@ type ChainReader interface {
 	// GetJustifiedNumber returns the highest justified blockNumber on the branch including and before `header`
 	GetJustifiedNumber(header *types.Header) uint64
 
+	// GetFinalizedHeader returns the highest finalized block header on the branch including and before `header`
+	GetFinalizedHeader(header *types.Header) *types.Header
+
+	// GetHeader retrieves a block header by hash and number.
+	GetHeader(common.Hash, uint64) *types.Header
+
+	// GetCanonicalHash returns the canonical hash for a given block number.
+	GetCanonicalHash(number uint64) common.Hash
+
 	// GetTd returns the total difficulty of a local block.
 	GetTd(common.Hash, uint64) *big.Int
 }
@@ -110,6 +123,14 @@ func (f *ForkChoice) ReorgNeeded(current *types.Header, extern *types.Header) (b
 		return false, nil
 	}
 	// Local and external difficulty is identical.
+	// Prefer the branch with more attested blocks if the fork is activated.
+	if f.chain.Config().IsOasysAttestationForkChoice(extern.Number) {
+		if pos, ok := f.chain.Engine().(consensus.PoS); ok {
+			if local, remote, ok := f.countAttestations(pos, current, extern); ok && local != remote {
+				return remote > local, nil
+			}
+		}
+	}
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
 	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
 	reorg := false
@@ -126,6 +147,38 @@ func (f *ForkChoice) ReorgNeeded(current *types.Header, extern *types.Header) (b
 	return reorg, nil
 }
 
+// countAttestations returns the number of blocks including a vote attestation on
+// each branch since their common ancestor. It gives up if the branches are too
+// long or an ancestor is unknown.
+func (f *ForkChoice) countAttestations(pos consensus.PoS, current, extern *types.Header) (uint64, uint64, bool) {
+	var (
+		local, remote uint64
+		count         = func(header *types.Header) uint64 {
+			if pos.DecodeVoteAttestation(header) != nil {
+				return 1
+			}
+			return 0
+		}
+	)
+	for depth := 0; current.Hash() != extern.Hash(); depth++ {
+		if depth >= maxAttestationForkChoiceDepth {
+			return 0, 0, false
+		}
+		if current.Number.Cmp(extern.Number) >= 0 {
+			local += count(current)
+			if current = f.chain.GetHeader(current.ParentHash, current.Number.Uint64()-1); current == nil {
+				return 0, 0, false
+			}
+		} else {
+			remote += count(extern)
+			if extern = f.chain.GetHeader(extern.ParentHash, extern.Number.Uint64()-1); extern == nil {
+				return 0, 0, false
+			}
+		}
+	}
+	return local, remote, true
+}
+
 // ReorgNeededWithFastFinality compares justified block numbers firstly, backoff to compare tds when equal
 func (f *ForkChoice) ReorgNeededWithFastFinality(current *types.Header, header *types.Header) (bool, error) {
 	_, ok := f.chain.Engine().(consensus.PoS)
@@ -141,12 +194,48 @@ func (f *ForkChoice) ReorgNeededWithFastFinality(current *types.Header, header *
 		curJustifiedNumber = f.chain.GetJustifiedNumber(current)
 	}
 	if justifiedNumber == curJustifiedNumber {
-		return f.ReorgNeeded(current, header)
+		reorg, err := f.ReorgNeeded(current, header)
+		if err != nil || !reorg {
+			return reorg, err
+		}
+		return !f.revertsFinalized(current, header), nil
 	}
 
 	if justifiedNumber > curJustifiedNumber && header.Number.Cmp(current.Number) <= 0 {
 		log.Info("Chain find higher justifiedNumber", "fromHeight", current.Number, "fromHash", current.Hash(), "fromMiner", current.Coinbase, "fromJustified", curJustifiedNumber,
 			"toHeight", header.Number, "toHash", header.Hash(), "toMiner", header.Coinbase, "toJustified", justifiedNumber)
 	}
-	return justifiedNumber > curJustifiedNumber, nil
+	if justifiedNumber < curJustifiedNumber {
+		return false, nil
+	}
+	return !f.revertsFinalized(current, header), nil
+}
+
+// revertsFinalized returns whether switching the head from `current` to `header`
+// would revert the block finalized on the current chain. Such chains are never
+// accepted regardless of their difficulty.
+func (f *ForkChoice) revertsFinalized(current *types.Header, header *types.Header) bool {
+	finalized := f.chain.GetFinalizedHeader(current)
+	if finalized == nil || finalized.Number.Sign() == 0 {
+		return false
+	}
+	// Walk back the new chain to the fork point with the canonical chain,
+	// which the finalized block belongs to.
+	ancestor := header
+	for ancestor.Number.Cmp(finalized.Number) > 0 {
+		if f.chain.GetCanonicalHash(ancestor.Number.Uint64()) == ancestor.Hash() {
+			return false
+		}
+		ancestor = f.chain.GetHeader(ancestor.ParentHash, ancestor.Number.Uint64()-1)
+		if ancestor == nil {
+			// The fork point is unknown, leave the decision to the difficulty
+			return false
+		}
+	}
+	if ancestor.Number.Cmp(finalized.Number) == 0 && ancestor.Hash() == finalized.Hash() {
+		return false
+	}
+	log.Warn("Rejected chain reverting the finalized block", "finalized", finalized.Number, "finalizedHash", finalized.Hash(),
+		"height", header.Number, "hash", header.Hash(), "miner", header.Coinbase)
+	return true
 }
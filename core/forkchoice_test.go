@@ -0,0 +1,225 @@
+package core
+
+import (
+	"math/big"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testAttestationEngine is a fake PoS engine, for which the headers with the
+// extra-data starting with 1 include a vote attestation.
+type testAttestationEngine struct {
+	consensus.PoS
+}
+
+func (e *testAttestationEngine) DecodeVoteAttestation(header *types.Header) *types.VoteAttestation {
+	if len(header.Extra) > 0 && header.Extra[0] == 1 {
+		return new(types.VoteAttestation)
+	}
+	return nil
+}
+
+// testForkChoiceChain is a chain reader over the headers of the branches built
+// on a shared canonical chain.
+type testForkChoiceChain struct {
+	config    *params.ChainConfig
+	headers   map[common.Hash]*types.Header
+	canonical map[uint64]common.Hash
+	td        map[common.Hash]*big.Int
+	justified map[common.Hash]uint64
+	finalized map[common.Hash]*types.Header
+}
+
+func newTestForkChoiceChain(config *params.ChainConfig, length int) (*testForkChoiceChain, *types.Header) {
+	chain := &testForkChoiceChain{
+		config:    config,
+		headers:   make(map[common.Hash]*types.Header),
+		canonical: make(map[uint64]common.Hash),
+		td:        make(map[common.Hash]*big.Int),
+		justified: make(map[common.Hash]uint64),
+		finalized: make(map[common.Hash]*types.Header),
+	}
+	genesis := &types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}
+	chain.add(genesis, true)
+	diffs := make([]int64, length)
+	for i := range diffs {
+		diffs[i] = 2
+	}
+	head := chain.extend(genesis, diffs, nil, true)
+	return chain, head[len(head)-1]
+}
+
+func (c *testForkChoiceChain) add(header *types.Header, canonical bool) {
+	hash := header.Hash()
+	c.headers[hash] = header
+	c.td[hash] = new(big.Int).Set(header.Difficulty)
+	if parent, ok := c.td[header.ParentHash]; ok && header.Number.Sign() > 0 {
+		c.td[hash].Add(c.td[hash], parent)
+	}
+	if canonical {
+		c.canonical[header.Number.Uint64()] = hash
+	}
+}
+
+// extend builds a branch on the parent with the given difficulties, attesting
+// the blocks given.
+func (c *testForkChoiceChain) extend(parent *types.Header, diffs []int64, attested []bool, canonical bool) []*types.Header {
+	headers := make([]*types.Header, len(diffs))
+	for i, diff := range diffs {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, common.Big1),
+			Difficulty: big.NewInt(diff),
+			Extra:      []byte{0, byte(len(diffs))}, // Distinguish the branches
+		}
+		if i < len(attested) && attested[i] {
+			header.Extra[0] = 1
+		}
+		c.add(header, canonical)
+		headers[i], parent = header, header
+	}
+	return headers
+}
+
+func (c *testForkChoiceChain) Config() *params.ChainConfig { return c.config }
+func (c *testForkChoiceChain) Engine() consensus.Engine    { return &testAttestationEngine{} }
+func (c *testForkChoiceChain) GetJustifiedNumber(header *types.Header) uint64 {
+	return c.justified[header.Hash()]
+}
+func (c *testForkChoiceChain) GetFinalizedHeader(header *types.Header) *types.Header {
+	return c.finalized[header.Hash()]
+}
+func (c *testForkChoiceChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header, ok := c.headers[hash]; ok && header.Number.Uint64() == number {
+		return header
+	}
+	return nil
+}
+func (c *testForkChoiceChain) GetCanonicalHash(number uint64) common.Hash { return c.canonical[number] }
+func (c *testForkChoiceChain) GetTd(hash common.Hash, number uint64) *big.Int {
+	return c.td[hash]
+}
+
+func TestReorgNeededAttestations(t *testing.T) {
+	// The local branch of four blocks of the difficulty 2, and the external
+	// ones of the same total difficulty, which the tie-breaker reorgs to if
+	// shorter and not if longer
+	var (
+		local   = []int64{2, 2, 2, 2}
+		shorter = []int64{4, 2, 2}
+		longer  = []int64{2, 2, 2, 1, 1}
+		yes     = true
+		no      = false
+	)
+	tests := []struct {
+		name           string
+		fork           *big.Int
+		localAttested  []bool
+		extern         []int64
+		externAttested []bool
+		want           bool
+	}{
+		{"higher td", common.Big0, []bool{yes, yes, yes, yes}, []int64{3, 3, 3}, nil, true},
+		{"lower td", common.Big0, nil, []int64{2, 2, 2}, []bool{yes, yes, yes}, false},
+		{"more attested", common.Big0, []bool{yes, no, no, no}, longer, []bool{yes, no, no, no, yes}, true},
+		{"fewer attested", common.Big0, []bool{yes, yes, no, no}, shorter, []bool{yes, no, no}, false},
+		{"equally attested, shorter", common.Big0, []bool{yes, no, no, no}, shorter, []bool{no, yes, no}, true},
+		{"equally attested, longer", common.Big0, []bool{yes, no, no, no}, longer, []bool{no, no, no, no, yes}, false},
+		{"not forked", nil, []bool{yes, yes, no, no}, shorter, nil, true},
+		{"fork ahead", big.NewInt(100), nil, longer, []bool{yes, yes}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				config          = &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: &params.OasysConfig{AttestationForkChoiceBlock: tt.fork}}
+				chain, ancestor = newTestForkChoiceChain(config, 10)
+				current         = chain.extend(ancestor, local, tt.localAttested, true)
+				extern          = chain.extend(ancestor, tt.extern, tt.externAttested, false)
+				f               = &ForkChoice{chain: chain, rand: mrand.New(mrand.NewSource(0))}
+			)
+			reorg, err := f.ReorgNeeded(current[len(current)-1], extern[len(extern)-1])
+			if err != nil {
+				t.Fatalf("failed to choose fork: %v", err)
+			}
+			if reorg != tt.want {
+				t.Errorf("reorg mismatch: have %v, want %v", reorg, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountAttestationsDepth(t *testing.T) {
+	config := &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: &params.OasysConfig{AttestationForkChoiceBlock: common.Big0}}
+	chain, ancestor := newTestForkChoiceChain(config, 10)
+
+	// The branches too long to compare are left to the tie-breaker
+	var (
+		local  = make([]int64, maxAttestationForkChoiceDepth/2+1)
+		extern = make([]int64, maxAttestationForkChoiceDepth/2+1)
+		f      = &ForkChoice{chain: chain}
+	)
+	for i := range local {
+		local[i], extern[i] = 2, 2
+	}
+	current := chain.extend(ancestor, local, []bool{true}, true)
+	remote := chain.extend(ancestor, extern, nil, false)
+	if _, _, ok := f.countAttestations(&testAttestationEngine{}, current[len(current)-1], remote[len(remote)-1]); ok {
+		t.Fatal("compared branches beyond the maximum depth")
+	}
+	if local, remote, ok := f.countAttestations(&testAttestationEngine{}, current[len(current)-2], remote[len(remote)-2]); !ok || local != 1 || remote != 0 {
+		t.Fatalf("attestations mismatch: have %d, %d (%v), want 1, 0", local, remote, ok)
+	}
+}
+
+func TestReorgNeededWithFastFinality(t *testing.T) {
+	// The local branch of four blocks on the ancestor, finalized up to the
+	// given block of it (0 for the ancestor, -1 for none)
+	tests := []struct {
+		name            string
+		localJustified  uint64
+		extern          []int64
+		externJustified uint64
+		finalized       int
+		want            bool
+	}{
+		{"higher justified", 10, []int64{2, 2}, 11, -1, true},
+		{"lower justified", 11, []int64{3, 3, 3, 3}, 10, -1, false},
+		{"equal justified, higher td", 10, []int64{3, 3, 3}, 10, -1, true},
+		{"equal justified, lower td", 10, []int64{2, 2}, 10, -1, false},
+		{"equal justified, reverts finalized", 10, []int64{3, 3, 3}, 10, 2, false},
+		{"higher justified, keeps finalized", 10, []int64{2, 2}, 11, 0, true},
+		{"higher justified, reverts finalized", 10, []int64{2, 2}, 11, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				config          = &params.ChainConfig{ChainID: big.NewInt(12345), Oasys: &params.OasysConfig{}}
+				chain, ancestor = newTestForkChoiceChain(config, 10)
+				current         = chain.extend(ancestor, []int64{2, 2, 2, 2}, nil, true)
+				extern          = chain.extend(ancestor, tt.extern, nil, false)
+				head            = current[len(current)-1]
+				f               = &ForkChoice{chain: chain, rand: mrand.New(mrand.NewSource(0))}
+			)
+			chain.justified[head.Hash()] = tt.localJustified
+			chain.justified[extern[len(extern)-1].Hash()] = tt.externJustified
+			switch {
+			case tt.finalized == 0:
+				chain.finalized[head.Hash()] = ancestor
+			case tt.finalized > 0:
+				chain.finalized[head.Hash()] = current[tt.finalized-1]
+			}
+			reorg, err := f.ReorgNeededWithFastFinality(head, extern[len(extern)-1])
+			if err != nil {
+				t.Fatalf("failed to choose fork: %v", err)
+			}
+			if reorg != tt.want {
+				t.Errorf("reorg mismatch: have %v, want %v", reorg, tt.want)
+			}
+		})
+	}
+}
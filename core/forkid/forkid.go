@@ -269,6 +269,10 @@ func gatherForks(config *params.ChainConfig, genesis uint64) ([]uint64, []uint64
 			}
 		}
 	}
+	// Gather the Oasys forks, which are not the fields of the chain config
+	for _, rule := range config.OasysForkBlocks() {
+		forksByBlock = append(forksByBlock, rule.Uint64())
+	}
 	slices.Sort(forksByBlock)
 	slices.Sort(forksByTime)
 
@@ -21,6 +21,7 @@ import (
 	"hash/crc32"
 	"math"
 	"math/big"
+	"slices"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -439,3 +440,28 @@ func TestTimeBasedForkInGenesis(t *testing.T) {
 		}
 	}
 }
+
+// TestOasysForks tests that the Oasys forks are included in the fork ID of the
+// private networks, while the ones of the mainnet are kept as is.
+func TestOasysForks(t *testing.T) {
+	config := &params.ChainConfig{
+		ChainID:        big.NewInt(12345),
+		HomesteadBlock: big.NewInt(0),
+		Oasys: &params.OasysConfig{
+			Period:            1,
+			Epoch:             20,
+			CompactExtraBlock: big.NewInt(500),
+		},
+	}
+	forks, _ := gatherForks(config, 0)
+	if want := []uint64{2, 180, 500}; !slices.Equal(forks, want) {
+		t.Errorf("fork blocks mismatch: have %v, want %v", forks, want)
+	}
+
+	mainnet := *params.OasysMainnetChainConfig
+	forks, _ = gatherForks(&mainnet, 0)
+	mainnet.Oasys = nil
+	if want, _ := gatherForks(&mainnet, 0); !slices.Equal(forks, want) {
+		t.Errorf("mainnet fork blocks mismatch: have %v, want %v", forks, want)
+	}
+}
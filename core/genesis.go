@@ -467,6 +467,13 @@ func (g *Genesis) Commit(db ethdb.Database, triedb *triedb.Database) (*types.Blo
 	if config.Oasys != nil && len(block.Extra()) < 32+crypto.SignatureLength {
 		return nil, errors.New("can't start oasys chain without signers")
 	}
+	// The parameters are only enforced on the new chains, as the chains already
+	// running with them are merely warned about on startup.
+	if config.Oasys != nil {
+		if err := config.Oasys.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid oasys config: %v", err)
+		}
+	}
 	// All the checks has passed, flushAlloc the states derived from the genesis
 	// specification as well as the specification itself into the provided
 	// database.
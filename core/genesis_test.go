@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/triedb"
@@ -44,6 +45,29 @@ func TestInvalidCliqueConfig(t *testing.T) {
 	}
 }
 
+func TestInvalidOasysConfig(t *testing.T) {
+	// The default jail threshold exceeds the epoch period
+	config := *params.AllDevChainProtocolChanges
+	config.Oasys = &params.OasysConfig{Period: 15, Epoch: 100}
+	genesis := &Genesis{
+		Config:     &config,
+		ExtraData:  make([]byte, 32+crypto.SignatureLength),
+		GasLimit:   params.GenesisGasLimit,
+		Difficulty: big.NewInt(1),
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+	}
+	db := rawdb.NewMemoryDatabase()
+	if _, err := genesis.Commit(db, triedb.NewDatabase(db, nil)); err == nil {
+		t.Fatal("Expected error on invalid oasys config")
+	}
+
+	config.Oasys = &params.OasysConfig{Period: 15, Epoch: 100, Environment: &params.OasysEnvironment{JailThreshold: big.NewInt(100)}}
+	db = rawdb.NewMemoryDatabase()
+	if _, err := genesis.Commit(db, triedb.NewDatabase(db, nil)); err != nil {
+		t.Fatalf("Failed to commit valid oasys config: %v", err)
+	}
+}
+
 func TestSetupGenesis(t *testing.T) {
 	testSetupGenesis(t, rawdb.HashScheme)
 	testSetupGenesis(t, rawdb.PathScheme)
@@ -121,6 +121,14 @@ func (hc *HeaderChain) GetJustifiedNumber(header *types.Header) uint64 {
 	return 0
 }
 
+// GetFinalizedHeader returns the highest finalized block header on the branch including and before `header`.
+func (hc *HeaderChain) GetFinalizedHeader(header *types.Header) *types.Header {
+	if p, ok := hc.engine.(consensus.PoS); ok {
+		return p.GetFinalizedHeader(hc, header)
+	}
+	return nil
+}
+
 // GetBlockNumber retrieves the block number belonging to the given hash
 // from the cache or database
 func (hc *HeaderChain) GetBlockNumber(hash common.Hash) *uint64 {
@@ -0,0 +1,260 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	parallelReplayMeter    = metrics.NewRegisteredMeter("chain/parallel/replays", nil)
+	parallelReexecuteMeter = metrics.NewRegisteredMeter("chain/parallel/reexecutes", nil)
+)
+
+// speculation is the result of a transaction executed speculatively on top of
+// the state before the transactions of the block.
+type speculation struct {
+	done chan struct{}
+
+	err      error
+	result   *ExecutionResult
+	reads    map[accessKey]struct{}
+	writes   *accessWrites
+	logs     []*types.Log
+	replayed bool // Whether the effect can be replayed by the writes
+}
+
+// parallelExecutor executes the general transactions of a block in parallel
+// on top of the state before them, and commits them in order. A transaction
+// whose read states were not changed by the preceding transactions is
+// committed by replaying its writes, while the others are executed again
+// serially, so that the result is always the same as the serial execution.
+type parallelExecutor struct {
+	p         *StateProcessor
+	header    *types.Header
+	blockHash common.Hash
+	cfg       vm.Config
+	base      *state.StateDB
+	txs       []*types.Transaction
+	msgs      []*Message     // Messages of the general transactions, nil for the system ones
+	specs     []*speculation // Speculations of the general transactions, nil for the system ones
+	general   []int          // Indexes of the general transactions
+
+	next atomic.Int64 // Position of the next general transaction to execute speculatively
+	stop atomic.Bool  // Whether the speculative execution is aborted
+	wg   sync.WaitGroup
+
+	// Account states changed by the committed transactions, and the accounts
+	// created, destructed or deleted, whose all states are regarded as changed
+	written   map[accessKey]struct{}
+	destructs map[common.Address]struct{}
+}
+
+// newParallelExecutor starts the speculative execution of the general
+// transactions of the block on top of the state, or returns nil if the block
+// is processed serially. Blocks with any transaction failing to be classified
+// or converted are left to the serial execution, which reports the failure.
+func (p *StateProcessor) newParallelExecutor(block *types.Block, statedb *state.StateDB, cfg vm.Config, signer types.Signer, pos consensus.PoS) *parallelExecutor {
+	if p.bc == nil || !p.bc.cacheConfig.ParallelTx {
+		return nil
+	}
+	// Tracers and preimages observe the execution itself, which is not replayed
+	if cfg.Tracer != nil || cfg.EnablePreimageRecording || !p.config.IsByzantium(block.Number()) {
+		return nil
+	}
+	var (
+		header = block.Header()
+		txs    = block.Transactions()
+		msgs   = make([]*Message, len(txs))
+		specs  = make([]*speculation, len(txs))
+	)
+	var general []int
+	for i, tx := range txs {
+		if pos != nil {
+			isSystemTx, err := pos.IsSystemTransaction(tx, header)
+			if err != nil {
+				return nil
+			}
+			if isSystemTx {
+				continue
+			}
+		}
+		msg, err := TransactionToMessage(tx, signer, header.BaseFee)
+		if err != nil {
+			return nil
+		}
+		msgs[i], specs[i] = msg, &speculation{done: make(chan struct{})}
+		general = append(general, i)
+	}
+	if len(general) < 2 {
+		return nil
+	}
+	e := &parallelExecutor{
+		p:         p,
+		header:    header,
+		blockHash: block.Hash(),
+		cfg:       cfg,
+		base:      statedb.Copy(),
+		txs:       txs,
+		msgs:      msgs,
+		specs:     specs,
+		general:   general,
+		written:   make(map[accessKey]struct{}),
+		destructs: make(map[common.Address]struct{}),
+	}
+	workers := min(runtime.NumCPU(), len(general))
+	e.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go e.loop()
+	}
+	return e
+}
+
+// close aborts the speculative execution and waits for the workers to exit.
+func (e *parallelExecutor) close() {
+	e.stop.Store(true)
+	e.wg.Wait()
+}
+
+// isSystemTx reports whether the i-th transaction is a system transaction.
+func (e *parallelExecutor) isSystemTx(i int) bool {
+	return e.specs[i] == nil
+}
+
+func (e *parallelExecutor) loop() {
+	defer e.wg.Done()
+
+	// The block context is created per worker, as the block hashes are cached
+	// in the context.
+	context := NewEVMBlockContext(e.header, e.p.bc, nil)
+	for !e.stop.Load() {
+		n := int(e.next.Add(1) - 1)
+		if n >= len(e.general) {
+			return
+		}
+		e.speculate(context, e.general[n])
+	}
+}
+
+// speculate executes the transaction on a copy of the state before the
+// transactions, recording the states read and written.
+func (e *parallelExecutor) speculate(context vm.BlockContext, i int) {
+	var (
+		spec    = e.specs[i]
+		tx      = e.txs[i]
+		msg     = e.msgs[i]
+		statedb = e.base.Copy()
+		rec     = newAccessRecorder(statedb)
+		gp      = new(GasPool).AddGas(msg.GasLimit)
+	)
+	defer close(spec.done)
+
+	statedb.SetTxContext(tx.Hash(), i)
+	evm := vm.NewEVM(context, NewEVMTxContext(msg), rec, e.p.config, e.cfg)
+	spec.result, spec.err = ApplyMessage(evm, msg, gp)
+	if spec.err != nil {
+		return
+	}
+	spec.reads = rec.reads
+	spec.writes = rec.writes()
+	spec.logs = statedb.GetLogs(tx.Hash(), 0, common.Hash{})
+	spec.replayed = len(rec.destructs) == 0
+}
+
+// valid reports whether the states read by the speculative execution were not
+// changed by the transactions committed so far.
+func (e *parallelExecutor) valid(spec *speculation) bool {
+	if spec.err != nil || !spec.replayed {
+		return false
+	}
+	for key := range spec.reads {
+		if _, ok := e.written[key]; ok {
+			return false
+		}
+		if _, ok := e.destructs[key.addr]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// commit applies the i-th transaction, which must be a general one, on top of
+// the state, replaying the speculative execution if valid, or executing it
+// again otherwise.
+func (e *parallelExecutor) commit(i int, statedb *state.StateDB, gp *GasPool, usedGas *uint64, evm *vm.EVM) (*types.Receipt, error) {
+	var (
+		spec = e.specs[i]
+		tx   = e.txs[i]
+		msg  = e.msgs[i]
+	)
+	<-spec.done
+
+	statedb.SetTxContext(tx.Hash(), i)
+	if e.valid(spec) && gp.Gas() >= msg.GasLimit {
+		parallelReplayMeter.Mark(1)
+
+		spec.writes.apply(statedb)
+		for _, l := range spec.logs {
+			statedb.AddLog(&types.Log{Address: l.Address, Topics: l.Topics, Data: l.Data})
+		}
+		if err := gp.SubGas(spec.result.UsedGas); err != nil {
+			return nil, err
+		}
+		e.record(spec.writes, nil, statedb)
+		statedb.Finalise(true)
+		*usedGas += spec.result.UsedGas
+
+		return newReceipt(msg, tx, spec.result, nil, statedb, e.header.Number, e.blockHash, *usedGas, evm), nil
+	}
+	parallelReexecuteMeter.Mark(1)
+
+	rec := newAccessRecorder(statedb)
+	evm.Reset(NewEVMTxContext(msg), rec)
+	result, err := ApplyMessage(evm, msg, gp)
+	if err != nil {
+		return nil, err
+	}
+	e.record(rec.writes(), rec.destructs, statedb)
+	statedb.Finalise(true)
+	*usedGas += result.UsedGas
+
+	return newReceipt(msg, tx, result, nil, statedb, e.header.Number, e.blockHash, *usedGas, evm), nil
+}
+
+// record records the account states changed by the committed transaction, and
+// the accounts destructed or deleted. It must be called before the state is
+// finalised.
+func (e *parallelExecutor) record(writes *accessWrites, destructs map[common.Address]struct{}, statedb *state.StateDB) {
+	for _, key := range writes.keys() {
+		e.written[key] = struct{}{}
+	}
+	for addr := range destructs {
+		e.destructs[addr] = struct{}{}
+	}
+	for _, addr := range writes.emptied(statedb) {
+		e.destructs[addr] = struct{}{}
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// Increments the slot 0, conflicting with each other
+	parallelCounterCode = []byte{
+		byte(vm.PUSH1), 0, byte(vm.SLOAD), byte(vm.PUSH1), 1, byte(vm.ADD),
+		byte(vm.PUSH1), 0, byte(vm.SSTORE), byte(vm.STOP),
+	}
+	// Stores the value in the slot of the caller, independent of each other
+	parallelSlotCode = []byte{byte(vm.CALLVALUE), byte(vm.CALLER), byte(vm.SSTORE), byte(vm.STOP)}
+	// Emits a log of the caller
+	parallelLogCode = []byte{byte(vm.CALLER), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.LOG1), byte(vm.STOP)}
+	// Sends the balance to the caller
+	parallelDestructCode = []byte{byte(vm.CALLER), byte(vm.SELFDESTRUCT)}
+	// Deploys the slot writer
+	parallelDeployCode = append([]byte{
+		byte(vm.PUSH1), byte(len(parallelSlotCode)), byte(vm.DUP1), byte(vm.PUSH1), 12, byte(vm.PUSH1), 0, byte(vm.CODECOPY),
+		byte(vm.PUSH1), 0, byte(vm.RETURN),
+	}, append([]byte{byte(vm.INVALID)}, parallelSlotCode...)...)
+
+	parallelCounter  = common.HexToAddress("0x000000000000000000000000000000000000c001")
+	parallelSlot     = common.HexToAddress("0x000000000000000000000000000000000000c002")
+	parallelLog      = common.HexToAddress("0x000000000000000000000000000000000000c003")
+	parallelDestruct = common.HexToAddress("0x000000000000000000000000000000000000c004")
+	parallelEmpty    = common.HexToAddress("0x000000000000000000000000000000000000c005")
+)
+
+// newParallelChain generates the blocks of the transactions mixing the
+// independent ones and the conflicting ones.
+func newParallelChain(t testing.TB, blocks int, gen func(i int, b *BlockGen, keys []*ecdsa.PrivateKey)) (*Genesis, []*types.Block) {
+	var (
+		keys  = make([]*ecdsa.PrivateKey, 16)
+		funds = new(big.Int).Mul(big.NewInt(params.Ether), big.NewInt(1000))
+		gspec = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				parallelCounter:  {Code: parallelCounterCode, Balance: common.Big0},
+				parallelSlot:     {Code: parallelSlotCode, Balance: common.Big0},
+				parallelLog:      {Code: parallelLogCode, Balance: common.Big0},
+				parallelDestruct: {Code: parallelDestructCode, Balance: big.NewInt(params.Ether)},
+				parallelEmpty:    {Balance: common.Big0},
+			},
+		}
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		gspec.Alloc[crypto.PubkeyToAddress(keys[i].PublicKey)] = types.Account{Balance: funds}
+	}
+	_, chain, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), blocks, func(i int, b *BlockGen) {
+		gen(i, b, keys)
+	})
+	return gspec, chain
+}
+
+func newParallelTx(t testing.TB, b *BlockGen, key *ecdsa.PrivateKey, to *common.Address, value int64, data []byte) *types.Transaction {
+	signer := types.LatestSigner(params.TestChainConfig)
+	tx, err := types.SignNewTx(key, signer, &types.LegacyTx{
+		Nonce:    b.TxNonce(crypto.PubkeyToAddress(key.PublicKey)),
+		To:       to,
+		Value:    big.NewInt(value),
+		Gas:      200000,
+		GasPrice: b.header.BaseFee,
+		Data:     data,
+	})
+	if err != nil {
+		t.Fatalf("failed to sign tx: %v", err)
+	}
+	return tx
+}
+
+func TestParallelProcess(t *testing.T) {
+	gspec, blocks := newParallelChain(t, 4, func(i int, b *BlockGen, keys []*ecdsa.PrivateKey) {
+		for j, key := range keys {
+			recipient := common.BigToAddress(big.NewInt(int64(0x10000 + i*len(keys) + j)))
+			b.AddTx(newParallelTx(t, b, key, &recipient, 1000, nil))
+		}
+		// Transactions of the same sender
+		for j := 0; j < 3; j++ {
+			b.AddTx(newParallelTx(t, b, keys[0], &parallelSlot, int64(j+1), nil))
+		}
+		// Conflicting storage writes
+		for _, key := range keys[1:4] {
+			b.AddTx(newParallelTx(t, b, key, &parallelCounter, 0, nil))
+		}
+		// Independent storage writes and logs
+		for _, key := range keys[4:8] {
+			b.AddTx(newParallelTx(t, b, key, &parallelSlot, 1, nil))
+			b.AddTx(newParallelTx(t, b, key, &parallelLog, 0, nil))
+		}
+		switch i {
+		case 1:
+			// Contract creation and a call to the created contract
+			creator := crypto.PubkeyToAddress(keys[8].PublicKey)
+			created := crypto.CreateAddress(creator, b.TxNonce(creator))
+			b.AddTx(newParallelTx(t, b, keys[8], nil, 0, parallelDeployCode))
+			b.AddTx(newParallelTx(t, b, keys[9], &created, 5, nil))
+		case 2:
+			// Empty account touched and deleted, and its existence read again
+			b.AddTx(newParallelTx(t, b, keys[10], &parallelEmpty, 0, nil))
+			b.AddTx(newParallelTx(t, b, keys[11], &parallelEmpty, 0, nil))
+		case 3:
+			// Self-destruct sending the balance, and the balance read again
+			b.AddTx(newParallelTx(t, b, keys[12], &parallelDestruct, 0, nil))
+			b.AddTx(newParallelTx(t, b, keys[13], &parallelDestruct, 0, nil))
+		}
+		// Payment to the coinbase, whose balance is changed by every transaction
+		b.AddTx(newParallelTx(t, b, keys[14], &b.header.Coinbase, 1000, nil))
+	})
+
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.ParallelTx = true
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// The receipts must be identical to the serial execution, including the
+	// fields not covered by the receipt root
+	for _, block := range blocks {
+		parent := chain.GetHeaderByHash(block.ParentHash())
+		if parent == nil {
+			t.Fatalf("missing parent of block %d", block.NumberU64())
+		}
+		var results [2]string
+		for i, parallel := range []bool{false, true} {
+			chain.cacheConfig.ParallelTx = parallel
+			statedb, err := state.New(parent.Root, chain.stateCache, nil)
+			if err != nil {
+				t.Fatalf("failed to open state: %v", err)
+			}
+			receipts, _, _, err := chain.processor.Process(block, statedb, vm.Config{})
+			if err != nil {
+				t.Fatalf("failed to process block %d, parallel: %v, err: %v", block.NumberU64(), parallel, err)
+			}
+			if root := statedb.IntermediateRoot(true); root != block.Root() {
+				t.Fatalf("state root mismatch of block %d, parallel: %v, have: %x, want: %x", block.NumberU64(), parallel, root, block.Root())
+			}
+			enc, _ := json.Marshal(receipts)
+			results[i] = string(enc)
+		}
+		if results[0] != results[1] {
+			t.Fatalf("receipts mismatch of block %d\nserial:   %s\nparallel: %s", block.NumberU64(), results[0], results[1])
+		}
+		chain.cacheConfig.ParallelTx = true
+		if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatalf("failed to insert block %d: %v", block.NumberU64(), err)
+		}
+	}
+}
+
+func BenchmarkProcessSerial(b *testing.B)   { benchmarkProcess(b, false) }
+func BenchmarkProcessParallel(b *testing.B) { benchmarkProcess(b, true) }
+
+// benchmarkProcess processes a block of the transfers and the storage writes,
+// mostly independent of each other as the token transfers on the mainnet.
+func benchmarkProcess(b *testing.B, parallel bool) {
+	gspec, blocks := newParallelChain(b, 1, func(i int, gen *BlockGen, keys []*ecdsa.PrivateKey) {
+		for n := 0; n < 8; n++ {
+			for j, key := range keys {
+				if j%2 == 0 {
+					recipient := common.BigToAddress(big.NewInt(int64(0x10000 + n*len(keys) + j)))
+					gen.AddTx(newParallelTx(b, gen, key, &recipient, 1000, nil))
+				} else {
+					gen.AddTx(newParallelTx(b, gen, key, &parallelSlot, int64(n+1), nil))
+				}
+			}
+		}
+	})
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.ParallelTx = parallel
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		b.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	block := blocks[0]
+	parent := chain.GetHeaderByHash(block.ParentHash())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		statedb, _ := state.New(parent.Root, chain.stateCache, nil)
+		if _, _, _, err := chain.processor.Process(block, statedb, vm.Config{}); err != nil {
+			b.Fatalf("failed to process block: %v", err)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/holiman/uint256"
+)
+
+// accessKind is the kind of the account state accessed by a transaction.
+type accessKind uint8
+
+const (
+	accessBalance accessKind = iota
+	accessNonce
+	accessCode
+	accessStorage
+)
+
+// accessKey is an account state accessed by a transaction. The slot is only
+// set for the storage.
+type accessKey struct {
+	addr common.Address
+	kind accessKind
+	slot common.Hash
+}
+
+// accessRecorder is a vm.StateDB recording the account states read and written
+// by a transaction executed on top of the wrapped state. The writes are kept as
+// the values before the first change, so that the effect of the transaction can
+// be extracted once it is executed.
+type accessRecorder struct {
+	*state.StateDB
+
+	reads    map[accessKey]struct{}
+	balances map[common.Address]*uint256.Int // Balances before the first change
+	nonces   map[common.Address]uint64       // Nonces before the first change
+	storages map[accessKey]common.Hash       // Storage values before the first change
+
+	// Accounts overwritten, destructed or whose code is set, which cannot be
+	// replayed as plain writes
+	destructs map[common.Address]struct{}
+}
+
+func newAccessRecorder(statedb *state.StateDB) *accessRecorder {
+	return &accessRecorder{
+		StateDB:   statedb,
+		reads:     make(map[accessKey]struct{}),
+		balances:  make(map[common.Address]*uint256.Int),
+		nonces:    make(map[common.Address]uint64),
+		storages:  make(map[accessKey]common.Hash),
+		destructs: make(map[common.Address]struct{}),
+	}
+}
+
+func (r *accessRecorder) read(addr common.Address, kinds ...accessKind) {
+	for _, kind := range kinds {
+		r.reads[accessKey{addr: addr, kind: kind}] = struct{}{}
+	}
+}
+
+func (r *accessRecorder) writeBalance(addr common.Address) {
+	if _, ok := r.balances[addr]; !ok {
+		r.balances[addr] = r.StateDB.GetBalance(addr).Clone()
+	}
+}
+
+// CreateAccount marks the account destructed only if it exists, as the new
+// account created by the value transfer is replayed by the balance change.
+func (r *accessRecorder) CreateAccount(addr common.Address) {
+	if r.StateDB.Exist(addr) {
+		r.destructs[addr] = struct{}{}
+	}
+	r.StateDB.CreateAccount(addr)
+}
+
+func (r *accessRecorder) SubBalance(addr common.Address, amount *uint256.Int) {
+	r.writeBalance(addr)
+	r.StateDB.SubBalance(addr, amount)
+}
+
+func (r *accessRecorder) AddBalance(addr common.Address, amount *uint256.Int) {
+	r.writeBalance(addr)
+	r.StateDB.AddBalance(addr, amount)
+}
+
+func (r *accessRecorder) GetBalance(addr common.Address) *uint256.Int {
+	r.read(addr, accessBalance)
+	return r.StateDB.GetBalance(addr)
+}
+
+func (r *accessRecorder) GetNonce(addr common.Address) uint64 {
+	r.read(addr, accessNonce)
+	return r.StateDB.GetNonce(addr)
+}
+
+func (r *accessRecorder) SetNonce(addr common.Address, nonce uint64) {
+	if _, ok := r.nonces[addr]; !ok {
+		r.nonces[addr] = r.StateDB.GetNonce(addr)
+	}
+	r.StateDB.SetNonce(addr, nonce)
+}
+
+func (r *accessRecorder) GetCodeHash(addr common.Address) common.Hash {
+	r.read(addr, accessCode)
+	return r.StateDB.GetCodeHash(addr)
+}
+
+func (r *accessRecorder) GetCode(addr common.Address) []byte {
+	r.read(addr, accessCode)
+	return r.StateDB.GetCode(addr)
+}
+
+func (r *accessRecorder) SetCode(addr common.Address, code []byte) {
+	r.destructs[addr] = struct{}{}
+	r.StateDB.SetCode(addr, code)
+}
+
+func (r *accessRecorder) GetCodeSize(addr common.Address) int {
+	r.read(addr, accessCode)
+	return r.StateDB.GetCodeSize(addr)
+}
+
+func (r *accessRecorder) GetCommittedState(addr common.Address, slot common.Hash) common.Hash {
+	r.reads[accessKey{addr: addr, kind: accessStorage, slot: slot}] = struct{}{}
+	return r.StateDB.GetCommittedState(addr, slot)
+}
+
+func (r *accessRecorder) GetState(addr common.Address, slot common.Hash) common.Hash {
+	r.reads[accessKey{addr: addr, kind: accessStorage, slot: slot}] = struct{}{}
+	return r.StateDB.GetState(addr, slot)
+}
+
+func (r *accessRecorder) SetState(addr common.Address, slot common.Hash, value common.Hash) {
+	key := accessKey{addr: addr, kind: accessStorage, slot: slot}
+	if _, ok := r.storages[key]; !ok {
+		r.storages[key] = r.StateDB.GetState(addr, slot)
+	}
+	r.StateDB.SetState(addr, slot, value)
+}
+
+func (r *accessRecorder) SelfDestruct(addr common.Address) {
+	r.destructs[addr] = struct{}{}
+	r.StateDB.SelfDestruct(addr)
+}
+
+func (r *accessRecorder) HasSelfDestructed(addr common.Address) bool {
+	r.read(addr, accessCode)
+	return r.StateDB.HasSelfDestructed(addr)
+}
+
+func (r *accessRecorder) Selfdestruct6780(addr common.Address) {
+	r.destructs[addr] = struct{}{}
+	r.StateDB.Selfdestruct6780(addr)
+}
+
+func (r *accessRecorder) Exist(addr common.Address) bool {
+	r.read(addr, accessBalance, accessNonce, accessCode)
+	return r.StateDB.Exist(addr)
+}
+
+func (r *accessRecorder) Empty(addr common.Address) bool {
+	r.read(addr, accessBalance, accessNonce, accessCode)
+	return r.StateDB.Empty(addr)
+}
+
+// accessWrites is the effect of a transaction on the account states, applicable
+// on top of another state as long as the states read by the transaction are
+// the same in both.
+type accessWrites struct {
+	balances map[common.Address]*uint256.Int // Balance differences, the sign in negatives
+	negative map[common.Address]bool
+	touched  []common.Address // Accounts whose balances are touched without changes
+	nonces   map[common.Address]uint64
+	storages map[accessKey]common.Hash
+}
+
+// writes returns the effect of the transaction executed, dropping the writes
+// restored to the original values. It must be called before the state is
+// finalised, as the accounts emptied are deleted by the finalisation.
+func (r *accessRecorder) writes() *accessWrites {
+	w := &accessWrites{
+		balances: make(map[common.Address]*uint256.Int),
+		negative: make(map[common.Address]bool),
+		nonces:   make(map[common.Address]uint64),
+		storages: make(map[accessKey]common.Hash),
+	}
+	for addr, before := range r.balances {
+		after := r.StateDB.GetBalance(addr)
+		switch after.Cmp(before) {
+		case 1:
+			w.balances[addr] = new(uint256.Int).Sub(after, before)
+		case -1:
+			w.balances[addr] = new(uint256.Int).Sub(before, after)
+			w.negative[addr] = true
+		default:
+			w.touched = append(w.touched, addr)
+		}
+	}
+	for addr, before := range r.nonces {
+		if after := r.StateDB.GetNonce(addr); after != before {
+			w.nonces[addr] = after
+		}
+	}
+	for key, before := range r.storages {
+		if after := r.StateDB.GetState(key.addr, key.slot); after != before {
+			w.storages[key] = after
+		}
+	}
+	return w
+}
+
+// keys returns the account states changed by the transaction.
+func (w *accessWrites) keys() []accessKey {
+	keys := make([]accessKey, 0, len(w.balances)+len(w.nonces)+len(w.storages))
+	for addr := range w.balances {
+		keys = append(keys, accessKey{addr: addr, kind: accessBalance})
+	}
+	for addr := range w.nonces {
+		keys = append(keys, accessKey{addr: addr, kind: accessNonce})
+	}
+	for key := range w.storages {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// emptied returns the accounts touched by the transaction and left empty in the
+// state, which are deleted by the finalisation of the transaction.
+func (w *accessWrites) emptied(statedb *state.StateDB) []common.Address {
+	var addrs []common.Address
+	for _, addr := range w.touched {
+		if statedb.Empty(addr) {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// apply applies the effect of the transaction on top of the state. The touched
+// accounts are touched again, so that the empty ones are deleted the same.
+func (w *accessWrites) apply(statedb *state.StateDB) {
+	for addr, diff := range w.balances {
+		if w.negative[addr] {
+			statedb.SubBalance(addr, diff)
+		} else {
+			statedb.AddBalance(addr, diff)
+		}
+	}
+	for _, addr := range w.touched {
+		statedb.AddBalance(addr, new(uint256.Int))
+	}
+	for addr, nonce := range w.nonces {
+		statedb.SetNonce(addr, nonce)
+	}
+	for key, value := range w.storages {
+		statedb.SetState(key.addr, key.slot, value)
+	}
+}
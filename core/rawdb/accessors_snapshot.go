@@ -208,3 +208,18 @@ func WriteSnapshotSyncStatus(db ethdb.KeyValueWriter, status []byte) {
 		log.Crit("Failed to store snapshot sync status", "err", err)
 	}
 }
+
+// CountOasysSnapshots returns the number of the Oasys consensus snapshots stored
+// in the database.
+func CountOasysSnapshots(db ethdb.Iteratee) int {
+	it := db.NewIterator(OasysSnapshotPrefix, nil)
+	defer it.Release()
+
+	var count int
+	for it.Next() {
+		if len(it.Key()) == len(OasysSnapshotPrefix)+common.HashLength {
+			count++
+		}
+	}
+	return count
+}
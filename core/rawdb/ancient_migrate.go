@@ -0,0 +1,194 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// errNoLegacyFreezer is returned if the chain freezer is not in the legacy
+// location, so there's nothing to migrate.
+var errNoLegacyFreezer = errors.New("no legacy chain freezer found")
+
+// IsLegacyChainFreezer returns whether the chain freezer is located in the root
+// ancient folder, as laid out by the releases before the state freezer.
+func IsLegacyChainFreezer(ancient string) bool {
+	return common.FileExist(ancient) && !common.FileExist(filepath.Join(ancient, ChainFreezerName))
+}
+
+// legacyChainFreezerFiles returns the names of the chain freezer files in the
+// legacy location, leaving the other folders and files of the ancient root.
+func legacyChainFreezerFiles(ancient string) ([]string, error) {
+	entries, err := os.ReadDir(ancient)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if name == "FLOCK" {
+			files = append(files, name)
+			continue
+		}
+		for table := range chainFreezerNoSnappy {
+			if strings.HasPrefix(name, table+".") {
+				files = append(files, name)
+				break
+			}
+		}
+	}
+	return files, nil
+}
+
+// freezerRange returns the number of the items and the tail of the freezer.
+func freezerRange(dir string, readonly bool) (uint64, uint64, error) {
+	f, err := NewChainFreezer(dir, "", readonly)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	frozen, err := f.Ancients()
+	if err != nil {
+		return 0, 0, err
+	}
+	tail, err := f.Tail()
+	if err != nil {
+		return 0, 0, err
+	}
+	return frozen, tail, nil
+}
+
+// MigrateLegacyChainFreezer relocates the chain freezer from the legacy location
+// in the root ancient folder into its own sub folder, upgrading the table layout
+// (metadata files and the tables added since) on the way. The files are renamed
+// in place, so the migration takes no extra disk space.
+//
+// The relocated freezer is verified to hold the same items and, if the key-value
+// store is not empty, to match its genesis, before the migration is considered
+// done. Otherwise the files are moved back to the legacy location. The Oasys
+// snapshots live in the key-value store and are kept as they are.
+func MigrateLegacyChainFreezer(db ethdb.KeyValueStore, ancient string) error {
+	if !IsLegacyChainFreezer(ancient) {
+		return errNoLegacyFreezer
+	}
+	frozen, tail, err := freezerRange(ancient, true)
+	if err != nil {
+		return fmt.Errorf("failed to open legacy chain freezer: %v", err)
+	}
+	files, err := legacyChainFreezerFiles(ancient)
+	if err != nil {
+		return err
+	}
+	log.Info("Migrating legacy chain freezer", "location", ancient, "files", len(files), "items", frozen, "tail", tail)
+
+	var (
+		freezer = filepath.Join(ancient, ChainFreezerName)
+		start   = time.Now()
+		logged  = time.Now()
+		moved   []string
+	)
+	if err := os.Mkdir(freezer, 0755); err != nil {
+		return err
+	}
+	for _, name := range files {
+		if err := os.Rename(filepath.Join(ancient, name), filepath.Join(freezer, name)); err != nil {
+			return rollbackChainFreezer(ancient, fmt.Errorf("failed to relocate %s: %v", name, err))
+		}
+		moved = append(moved, name)
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Relocating chain freezer files", "moved", len(moved), "total", len(files), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	// Open the relocated freezer for writing once, which upgrades the tables
+	log.Info("Upgrading chain freezer tables", "location", freezer)
+	migrated, migratedTail, err := freezerRange(freezer, false)
+	if err != nil {
+		return rollbackChainFreezer(ancient, fmt.Errorf("failed to open relocated chain freezer: %v", err))
+	}
+	if migrated != frozen || migratedTail != tail {
+		return rollbackChainFreezer(ancient, fmt.Errorf("relocated chain freezer mismatch: items %d != %d, tail %d != %d", migrated, frozen, migratedTail, tail))
+	}
+	if err := verifyFreezerGenesis(db, freezer); err != nil {
+		return rollbackChainFreezer(ancient, err)
+	}
+	log.Info("Migrated legacy chain freezer", "location", freezer, "files", len(moved), "items", frozen,
+		"oasysSnapshots", CountOasysSnapshots(db), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// verifyFreezerGenesis checks that the genesis of the freezer matches the one
+// of the key-value store, if both are present.
+func verifyFreezerGenesis(db ethdb.KeyValueStore, freezer string) error {
+	kvgenesis, _ := db.Get(headerHashKey(0))
+	if len(kvgenesis) == 0 {
+		return nil
+	}
+	f, err := NewChainFreezer(freezer, "", true)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if frozen, _ := f.Ancients(); frozen == 0 {
+		return nil
+	}
+	frgenesis, err := f.Ancient(ChainFreezerHashTable, 0)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve genesis from ancient %v", err)
+	}
+	if !bytes.Equal(kvgenesis, frgenesis) {
+		return fmt.Errorf("genesis mismatch: %#x (leveldb) != %#x (ancients)", kvgenesis, frgenesis)
+	}
+	return nil
+}
+
+// rollbackChainFreezer moves the files of the chain freezer sub folder back to
+// the legacy location and returns the error causing the rollback.
+func rollbackChainFreezer(ancient string, cause error) error {
+	log.Error("Chain freezer migration failed, rolling back", "err", cause)
+
+	freezer := filepath.Join(ancient, ChainFreezerName)
+	entries, err := os.ReadDir(freezer)
+	if err != nil {
+		return fmt.Errorf("%v, rollback failed: %v", cause, err)
+	}
+	for _, entry := range entries {
+		if err := os.Rename(filepath.Join(freezer, entry.Name()), filepath.Join(ancient, entry.Name())); err != nil {
+			return fmt.Errorf("%v, rollback failed: %v", cause, err)
+		}
+	}
+	if err := os.Remove(freezer); err != nil {
+		return fmt.Errorf("%v, rollback failed: %v", cause, err)
+	}
+	log.Info("Rolled back chain freezer migration", "location", ancient)
+	return cause
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/require"
+)
+
+// newLegacyChainFreezer creates a chain freezer of the given items in the root
+// ancient folder, as laid out by the legacy releases.
+func newLegacyChainFreezer(t *testing.T, genesis common.Hash, items uint64) string {
+	ancient := t.TempDir()
+	f, err := NewChainFreezer(ancient, "", false)
+	require.NoError(t, err)
+	_, err = f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i := uint64(0); i < items; i++ {
+			hash := common.Hash{byte(i)}
+			if i == 0 {
+				hash = genesis
+			}
+			for table := range chainFreezerNoSnappy {
+				if table == ChainFreezerBlobSidecarTable {
+					continue
+				}
+				value := []byte{byte(i)}
+				if table == ChainFreezerHashTable {
+					value = hash.Bytes()
+				}
+				if err := op.AppendRaw(table, i, value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	// Other ancient stores live in sub folders and must be left alone
+	require.NoError(t, os.Mkdir(filepath.Join(ancient, StateFreezerName), 0755))
+	return ancient
+}
+
+func TestMigrateLegacyChainFreezer(t *testing.T) {
+	genesis := common.Hash{0xaa}
+	ancient := newLegacyChainFreezer(t, genesis, 3)
+
+	db := NewMemoryDatabase()
+	WriteCanonicalHash(db, genesis, 0)
+	require.True(t, IsLegacyChainFreezer(ancient))
+	require.NoError(t, MigrateLegacyChainFreezer(db, ancient))
+	require.False(t, IsLegacyChainFreezer(ancient))
+	require.ErrorIs(t, MigrateLegacyChainFreezer(db, ancient), errNoLegacyFreezer)
+
+	files, err := legacyChainFreezerFiles(ancient)
+	require.NoError(t, err)
+	require.Empty(t, files)
+	require.DirExists(t, filepath.Join(ancient, StateFreezerName))
+
+	f, err := NewChainFreezer(filepath.Join(ancient, ChainFreezerName), "", true)
+	require.NoError(t, err)
+	defer f.Close()
+	frozen, err := f.Ancients()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), frozen)
+}
+
+func TestMigrateLegacyChainFreezerRollback(t *testing.T) {
+	ancient := newLegacyChainFreezer(t, common.Hash{0xaa}, 3)
+	before, err := legacyChainFreezerFiles(ancient)
+	require.NoError(t, err)
+
+	// The genesis mismatch of the key-value store rolls the migration back
+	db := NewMemoryDatabase()
+	WriteCanonicalHash(db, common.Hash{0xbb}, 0)
+	require.ErrorContains(t, MigrateLegacyChainFreezer(db, ancient), "genesis mismatch")
+	require.True(t, IsLegacyChainFreezer(ancient))
+	require.NoDirExists(t, filepath.Join(ancient, ChainFreezerName))
+
+	after, err := legacyChainFreezerFiles(ancient)
+	require.NoError(t, err)
+	require.Subset(t, after, before)
+
+	frozen, _, err := freezerRange(ancient, true)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), frozen)
+}
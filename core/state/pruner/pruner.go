@@ -57,8 +57,9 @@ const (
 
 // Config includes all the configurations for pruning.
 type Config struct {
-	Datadir   string // The directory of the state database
-	BloomSize uint64 // The Megabytes of memory allocated to bloom-filter
+	Datadir   string        // The directory of the state database
+	BloomSize uint64        // The Megabytes of memory allocated to bloom-filter
+	Retain    []common.Hash // The state roots retained besides the target and the genesis
 }
 
 // Pruner is an offline tool to prune the stale state with the
@@ -323,6 +324,19 @@ func (p *Pruner) Prune(root common.Hash) error {
 	if err := extractGenesis(p.db, p.stateBloom); err != nil {
 		return err
 	}
+	// Traverse the retained states, such as the ones the Oasys engine reads the
+	// validators from, and put all their entries into the bloom filter too.
+	for _, retained := range p.config.Retain {
+		if !rawdb.HasLegacyTrieNode(p.db, retained) {
+			log.Warn("Retained state is not present", "root", retained)
+			continue
+		}
+		log.Info("Retaining state", "root", retained)
+		if err := extractState(p.db, retained, p.stateBloom); err != nil {
+			return err
+		}
+		delete(middleRoots, retained)
+	}
 	filterName := bloomFilterName(p.config.Datadir, root)
 
 	log.Info("Writing state bloom to disk", "name", filterName)
@@ -410,7 +424,13 @@ func extractGenesis(db ethdb.Database, stateBloom *stateBloom) error {
 	if genesis == nil {
 		return errors.New("missing genesis block")
 	}
-	t, err := trie.NewStateTrie(trie.StateTrieID(genesis.Root()), triedb.NewDatabase(db, triedb.HashDefaults))
+	return extractState(db, genesis.Root(), stateBloom)
+}
+
+// extractState loads the state of the given root and commits all the state
+// entries into the given bloomfilter.
+func extractState(db ethdb.Database, root common.Hash, stateBloom *stateBloom) error {
+	t, err := trie.NewStateTrie(trie.StateTrieID(root), triedb.NewDatabase(db, triedb.HashDefaults))
 	if err != nil {
 		return err
 	}
@@ -433,7 +453,7 @@ func extractGenesis(db ethdb.Database, stateBloom *stateBloom) error {
 				return err
 			}
 			if acc.Root != types.EmptyRootHash {
-				id := trie.StorageTrieID(genesis.Root(), common.BytesToHash(accIter.LeafKey()), acc.Root)
+				id := trie.StorageTrieID(root, common.BytesToHash(accIter.LeafKey()), acc.Root)
 				storageTrie, err := trie.NewStateTrie(id, triedb.NewDatabase(db, triedb.HashDefaults))
 				if err != nil {
 					return err
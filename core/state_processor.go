@@ -93,8 +93,27 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	if beaconRoot := block.BeaconRoot(); beaconRoot != nil {
 		ProcessBeaconBlockRoot(*beaconRoot, vmenv, statedb)
 	}
+	// Execute the general transactions speculatively in parallel if enabled,
+	// which are committed in order below
+	parallel := p.newParallelExecutor(block, statedb, cfg, signer, pos)
+	if parallel != nil {
+		defer parallel.close()
+	}
 	// Iterate over and process the individual transactions
 	for i, tx := range txs {
+		if parallel != nil {
+			if parallel.isSystemTx(i) {
+				systemTxs = append(systemTxs, tx)
+				continue
+			}
+			receipt, err := parallel.commit(i, statedb, gp, usedGas, vmenv)
+			if err != nil {
+				return nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			}
+			generalTxs = append(generalTxs, tx)
+			receipts = append(receipts, receipt)
+			continue
+		}
 		if isPoS {
 			isSystemTx, err := pos.IsSystemTransaction(tx, block.Header())
 			if err != nil {
@@ -155,9 +174,13 @@ func applyTransaction(msg *Message, config *params.ChainConfig, gp *GasPool, sta
 	}
 	*usedGas += result.UsedGas
 
-	// Create a new receipt for the transaction, storing the intermediate root and gas used
-	// by the tx.
-	receipt := &types.Receipt{Type: tx.Type(), PostState: root, CumulativeGasUsed: *usedGas}
+	return newReceipt(msg, tx, result, root, statedb, blockNumber, blockHash, *usedGas, evm), nil
+}
+
+// newReceipt creates a new receipt for the transaction, storing the intermediate
+// root and gas used by the tx.
+func newReceipt(msg *Message, tx *types.Transaction, result *ExecutionResult, root []byte, statedb *state.StateDB, blockNumber *big.Int, blockHash common.Hash, usedGas uint64, evm *vm.EVM) *types.Receipt {
+	receipt := &types.Receipt{Type: tx.Type(), PostState: root, CumulativeGasUsed: usedGas}
 	if result.Failed() {
 		receipt.Status = types.ReceiptStatusFailed
 	} else {
@@ -173,7 +196,7 @@ func applyTransaction(msg *Message, config *params.ChainConfig, gp *GasPool, sta
 
 	// If the transaction created a contract, store the creation address in the receipt.
 	if msg.To == nil {
-		receipt.ContractAddress = crypto.CreateAddress(evm.TxContext.Origin, tx.Nonce())
+		receipt.ContractAddress = crypto.CreateAddress(msg.From, tx.Nonce())
 	}
 
 	// Set the receipt logs and create the bloom filter.
@@ -182,7 +205,7 @@ func applyTransaction(msg *Message, config *params.ChainConfig, gp *GasPool, sta
 	receipt.BlockHash = blockHash
 	receipt.BlockNumber = blockNumber
 	receipt.TransactionIndex = uint(statedb.TxIndex())
-	return receipt, err
+	return receipt
 }
 
 // ApplyTransaction attempts to apply a transaction to the given state database
@@ -0,0 +1,47 @@
+// Package tracing defines the hooks of the consensus tracers, which are invoked
+// on the events of the Oasys engine while the blocks are imported and produced.
+package tracing
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type (
+	// SnapshotAppliedHook is called when new headers are applied to a snapshot
+	// of the consensus engine.
+	SnapshotAppliedHook = func(number uint64, hash common.Hash, validators []common.Address)
+
+	// EpochTransitionHook is called when a snapshot enters a new epoch.
+	EpochTransitionHook = func(epoch uint64, number uint64, env *params.EnvironmentValue)
+
+	// ValidatorSetChangeHook is called when the validators of a snapshot change.
+	ValidatorSetChangeHook = func(number uint64, prev, next []common.Address)
+
+	// SystemTxHook is called when a system transaction is applied to a block.
+	SystemTxHook = func(header *types.Header, tx *types.Transaction)
+
+	// SlashHook is called when a validator missing its turn is slashed.
+	SlashHook = func(header *types.Header, validator common.Address, blocks int64)
+
+	// AttestationAssembledHook is called when a vote attestation is added to a
+	// header being sealed.
+	AttestationAssembledHook = func(header *types.Header, attestation *types.VoteAttestation)
+
+	// AttestationVerifiedHook is called when the vote attestation of a header
+	// is successfully verified.
+	AttestationVerifiedHook = func(header *types.Header, attestation *types.VoteAttestation)
+)
+
+// ConsensusHooks are the callbacks of a consensus tracer. Any of the callbacks
+// may be nil. The callbacks are invoked synchronously, so they must not block.
+type ConsensusHooks struct {
+	OnSnapshotApplied      SnapshotAppliedHook
+	OnEpochTransition      EpochTransitionHook
+	OnValidatorSetChange   ValidatorSetChangeHook
+	OnSystemTx             SystemTxHook
+	OnSlash                SlashHook
+	OnAttestationAssembled AttestationAssembledHook
+	OnAttestationVerified  AttestationVerifiedHook
+}
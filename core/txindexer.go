@@ -44,7 +44,13 @@ type txIndexer struct {
 	//  * 0: means the entire chain should be indexed
 	//  * N: means the latest N blocks [HEAD-N+1, HEAD] should be indexed
 	//       and all others shouldn't.
-	limit    uint64
+	limit uint64
+
+	// finalized reserves the limit back from the finalized block instead of
+	// the head, [FINALIZED-N+1, HEAD], so that the indexes of the non-finalized
+	// blocks are never pruned.
+	finalized bool
+
 	db       ethdb.Database
 	progress chan chan TxIndexProgress
 	term     chan chan struct{}
@@ -54,17 +60,20 @@ type txIndexer struct {
 // newTxIndexer initializes the transaction indexer.
 func newTxIndexer(limit uint64, chain *BlockChain) *txIndexer {
 	indexer := &txIndexer{
-		limit:    limit,
-		db:       chain.db,
-		progress: make(chan chan TxIndexProgress),
-		term:     make(chan chan struct{}),
-		closed:   make(chan struct{}),
+		limit:     limit,
+		finalized: chain.cacheConfig.TxIndexFinalized,
+		db:        chain.db,
+		progress:  make(chan chan TxIndexProgress),
+		term:      make(chan chan struct{}),
+		closed:    make(chan struct{}),
 	}
 	go indexer.loop(chain)
 
 	var msg string
 	if limit == 0 {
 		msg = "entire chain"
+	} else if indexer.finalized {
+		msg = fmt.Sprintf("last %d finalized blocks", limit)
 	} else {
 		msg = fmt.Sprintf("last %d blocks", limit)
 	}
@@ -73,10 +82,27 @@ func newTxIndexer(limit uint64, chain *BlockChain) *txIndexer {
 	return indexer
 }
 
+// pivot returns the block number the limit is reserved back from, which is the
+// head, or the finalized block if configured. The current tail is kept until
+// the finalized block is known.
+func (indexer *txIndexer) pivot(chain *BlockChain, head uint64, tail *uint64) uint64 {
+	if !indexer.finalized || indexer.limit == 0 {
+		return head
+	}
+	if final := chain.CurrentFinalBlock(); final != nil {
+		return min(final.Number.Uint64(), head)
+	}
+	if tail != nil && *tail+indexer.limit-1 < head {
+		return *tail + indexer.limit - 1
+	}
+	return head
+}
+
 // run executes the scheduled indexing/unindexing task in a separate thread.
+// The indexes are reserved for the limit back from the pivot, up to the head.
 // If the stop channel is closed, the task should be terminated as soon as
 // possible, the done channel will be closed once the task is finished.
-func (indexer *txIndexer) run(tail *uint64, head uint64, stop chan struct{}, done chan struct{}) {
+func (indexer *txIndexer) run(tail *uint64, head uint64, pivot uint64, stop chan struct{}, done chan struct{}) {
 	defer func() { close(done) }()
 
 	// Short circuit if chain is empty and nothing to index.
@@ -88,15 +114,15 @@ func (indexer *txIndexer) run(tail *uint64, head uint64, stop chan struct{}, don
 	// not indexed yet, index the chain according to the configured limit.
 	if tail == nil {
 		from := uint64(0)
-		if indexer.limit != 0 && head >= indexer.limit {
-			from = head - indexer.limit + 1
+		if indexer.limit != 0 && pivot >= indexer.limit {
+			from = pivot - indexer.limit + 1
 		}
 		rawdb.IndexTransactions(indexer.db, from, head+1, stop, true)
 		return
 	}
 	// The tail flag is existent (which means indexes in [tail, head] should be
 	// present), while the whole chain are requested for indexing.
-	if indexer.limit == 0 || head < indexer.limit {
+	if indexer.limit == 0 || pivot < indexer.limit {
 		if *tail > 0 {
 			// It can happen when chain is rewound to a historical point which
 			// is even lower than the indexes tail, recap the indexing target
@@ -110,13 +136,13 @@ func (indexer *txIndexer) run(tail *uint64, head uint64, stop chan struct{}, don
 		return
 	}
 	// The tail flag is existent, adjust the index range according to configured
-	// limit and the latest chain head.
-	if head-indexer.limit+1 < *tail {
-		// Reindex a part of missing indices and rewind index tail to HEAD-limit
-		rawdb.IndexTransactions(indexer.db, head-indexer.limit+1, *tail, stop, true)
+	// limit and the latest pivot.
+	if pivot-indexer.limit+1 < *tail {
+		// Reindex a part of missing indices and rewind index tail to PIVOT-limit
+		rawdb.IndexTransactions(indexer.db, pivot-indexer.limit+1, *tail, stop, true)
 	} else {
-		// Unindex a part of stale indices and forward index tail to HEAD-limit
-		rawdb.UnindexTransactions(indexer.db, *tail, head-indexer.limit+1, stop, false)
+		// Unindex a part of stale indices and forward index tail to PIVOT-limit
+		rawdb.UnindexTransactions(indexer.db, *tail, pivot-indexer.limit+1, stop, false)
 	}
 }
 
@@ -127,10 +153,11 @@ func (indexer *txIndexer) loop(chain *BlockChain) {
 
 	// Listening to chain events and manipulate the transaction indexes.
 	var (
-		stop     chan struct{}                       // Non-nil if background routine is active.
-		done     chan struct{}                       // Non-nil if background routine is active.
-		lastHead uint64                              // The latest announced chain head (whose tx indexes are assumed created)
-		lastTail = rawdb.ReadTxIndexTail(indexer.db) // The oldest indexed block, nil means nothing indexed
+		stop      chan struct{}                       // Non-nil if background routine is active.
+		done      chan struct{}                       // Non-nil if background routine is active.
+		lastHead  uint64                              // The latest announced chain head (whose tx indexes are assumed created)
+		lastPivot uint64                              // The block number the limit is reserved back from
+		lastTail  = rawdb.ReadTxIndexTail(indexer.db) // The oldest indexed block, nil means nothing indexed
 
 		headCh = make(chan ChainHeadEvent)
 		sub    = chain.SubscribeChainHeadEvent(headCh)
@@ -143,23 +170,25 @@ func (indexer *txIndexer) loop(chain *BlockChain) {
 		stop = make(chan struct{})
 		done = make(chan struct{})
 		lastHead = head.Number().Uint64()
-		go indexer.run(rawdb.ReadTxIndexTail(indexer.db), head.NumberU64(), stop, done)
+		lastPivot = indexer.pivot(chain, lastHead, lastTail)
+		go indexer.run(lastTail, lastHead, lastPivot, stop, done)
 	}
 	for {
 		select {
 		case head := <-headCh:
+			lastHead = head.Block.NumberU64()
+			lastPivot = indexer.pivot(chain, lastHead, lastTail)
 			if done == nil {
 				stop = make(chan struct{})
 				done = make(chan struct{})
-				go indexer.run(rawdb.ReadTxIndexTail(indexer.db), head.Block.NumberU64(), stop, done)
+				go indexer.run(rawdb.ReadTxIndexTail(indexer.db), lastHead, lastPivot, stop, done)
 			}
-			lastHead = head.Block.NumberU64()
 		case <-done:
 			stop = nil
 			done = nil
 			lastTail = rawdb.ReadTxIndexTail(indexer.db)
 		case ch := <-indexer.progress:
-			ch <- indexer.report(lastHead, lastTail)
+			ch <- indexer.report(lastHead, lastPivot, lastTail)
 		case ch := <-indexer.term:
 			if stop != nil {
 				close(stop)
@@ -175,10 +204,10 @@ func (indexer *txIndexer) loop(chain *BlockChain) {
 }
 
 // report returns the tx indexing progress.
-func (indexer *txIndexer) report(head uint64, tail *uint64) TxIndexProgress {
-	total := indexer.limit
-	if indexer.limit == 0 || total > head {
-		total = head + 1 // genesis included
+func (indexer *txIndexer) report(head uint64, pivot uint64, tail *uint64) TxIndexProgress {
+	total := head + 1 // genesis included
+	if indexer.limit != 0 && pivot >= indexer.limit {
+		total = head - pivot + indexer.limit
 	}
 	var indexed uint64
 	if tail != nil {
@@ -85,7 +85,7 @@ func TestTxIndexer(t *testing.T) {
 		for number := *tail; number <= chainHead; number += 1 {
 			verifyIndexes(db, number, true)
 		}
-		progress := indexer.report(chainHead, tail)
+		progress := indexer.report(chainHead, chainHead, tail)
 		if !progress.Done() {
 			t.Fatalf("Expect fully indexed")
 		}
@@ -221,23 +221,60 @@ func TestTxIndexer(t *testing.T) {
 			db:       db,
 			progress: make(chan chan TxIndexProgress),
 		}
-		indexer.run(nil, 128, make(chan struct{}), make(chan struct{}))
+		indexer.run(nil, 128, 128, make(chan struct{}), make(chan struct{}))
 		verify(db, c.tailA, indexer)
 
 		indexer.limit = c.limitB
-		indexer.run(rawdb.ReadTxIndexTail(db), 128, make(chan struct{}), make(chan struct{}))
+		indexer.run(rawdb.ReadTxIndexTail(db), 128, 128, make(chan struct{}), make(chan struct{}))
 		verify(db, c.tailB, indexer)
 
 		indexer.limit = c.limitC
-		indexer.run(rawdb.ReadTxIndexTail(db), 128, make(chan struct{}), make(chan struct{}))
+		indexer.run(rawdb.ReadTxIndexTail(db), 128, 128, make(chan struct{}), make(chan struct{}))
 		verify(db, c.tailC, indexer)
 
 		// Recover all indexes
 		indexer.limit = 0
-		indexer.run(rawdb.ReadTxIndexTail(db), 128, make(chan struct{}), make(chan struct{}))
+		indexer.run(rawdb.ReadTxIndexTail(db), 128, 128, make(chan struct{}), make(chan struct{}))
 		verify(db, 0, indexer)
 
 		db.Close()
 		os.RemoveAll(frdir)
 	}
+
+	// Reserve the indexes back from the finalized block
+	frdir := t.TempDir()
+	db, _ := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), frdir, "", false)
+	rawdb.WriteAncientBlocks(db, append([]*types.Block{gspec.ToBlock()}, blocks...), append([]types.Receipts{{}}, receipts...), big.NewInt(0))
+	defer db.Close()
+
+	indexer := &txIndexer{
+		limit:     64,
+		finalized: true,
+		db:        db,
+		progress:  make(chan chan TxIndexProgress),
+	}
+	for _, c := range []struct {
+		pivot uint64
+		tail  uint64
+	}{
+		{128, 65}, // block [65, 128] are indexed
+		{100, 37}, // block [37, 128] are indexed, the non-finalized ones kept
+		{120, 57}, // block [57, 128] are indexed
+		{32, 0},   // all blocks are indexed
+	} {
+		indexer.run(rawdb.ReadTxIndexTail(db), 128, c.pivot, make(chan struct{}), make(chan struct{}))
+		tail := rawdb.ReadTxIndexTail(db)
+		if tail == nil || *tail != c.tail {
+			t.Fatalf("Unexpected tx index tail, want %v, got %v", c.tail, tail)
+		}
+		for number := uint64(0); number < *tail; number += 1 {
+			verifyIndexes(db, number, false)
+		}
+		for number := *tail; number <= chainHead; number += 1 {
+			verifyIndexes(db, number, true)
+		}
+		if progress := indexer.report(chainHead, c.pivot, tail); !progress.Done() {
+			t.Fatalf("Expect fully indexed")
+		}
+	}
 }
@@ -60,4 +60,8 @@ var (
 	// input transaction of non-blob type when a blob transaction from this sender
 	// remains pending (and vice-versa).
 	ErrAlreadyReserved = errors.New("address already reserved")
+
+	// ErrPolicyRejected is returned if a transaction is rejected by one of the
+	// admission policies installed by the operator.
+	ErrPolicyRejected = errors.New("rejected by txpool policy")
 )
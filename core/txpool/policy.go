@@ -0,0 +1,282 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"golang.org/x/time/rate"
+)
+
+// Policy is a custom admission policy of the transactions, which the operators
+// install to filter the transactions entering the pool without forking it,
+// such as the allowlists of the game contracts. The policies are consulted for
+// both the remote and the local transactions before they reach the subpools,
+// concurrently, so they must be safe for concurrent use.
+type Policy interface {
+	// Admit returns an error if the transaction of the sender is rejected.
+	Admit(tx *types.Transaction, sender common.Address, local bool) error
+}
+
+// AccountingPolicy is an admission policy keeping track of the transactions
+// accepted into the pool, such as the rate limiters, which must not charge the
+// transactions rejected by the subpools afterwards.
+type AccountingPolicy interface {
+	Policy
+
+	// Accepted is called once the transaction of the sender admitted by all the
+	// policies has been accepted by a subpool.
+	Accepted(tx *types.Transaction, sender common.Address, local bool)
+}
+
+// PolicyCtor creates an admission policy from its configuration.
+type PolicyCtor func(cfg json.RawMessage) (Policy, error)
+
+// PolicyDirectory is the collection of the admission policies, which are looked
+// up by name when the node starts. Custom policies are registered from the
+// init function of a package compiled into the node.
+var PolicyDirectory = policyDirectory{elems: make(map[string]PolicyCtor)}
+
+type policyDirectory struct {
+	elems map[string]PolicyCtor
+}
+
+// Register registers an admission policy under the given name.
+func (d *policyDirectory) Register(name string, f PolicyCtor) {
+	d.elems[name] = f
+}
+
+// New creates the admission policy registered under the name.
+func (d *policyDirectory) New(name string, cfg json.RawMessage) (Policy, error) {
+	if f, ok := d.elems[name]; ok {
+		return f(cfg)
+	}
+	return nil, fmt.Errorf("txpool policy %q not found", name)
+}
+
+func init() {
+	PolicyDirectory.Register("allowlist", newAllowlistPolicy)
+	PolicyDirectory.Register("calldata", newCalldataPolicy)
+	PolicyDirectory.Register("ratelimit", newRateLimitPolicy)
+}
+
+// installedPolicy is an admission policy installed on the pool.
+type installedPolicy struct {
+	name     string
+	policy   Policy
+	rejected metrics.Counter
+}
+
+// InstallPolicies creates the admission policies registered under the names
+// with their configurations keyed by the names, and installs them on the pool.
+// It must be called before the pool starts accepting transactions.
+func (p *TxPool) InstallPolicies(signer types.Signer, names []string, cfgs map[string]json.RawMessage) error {
+	policies := make([]*installedPolicy, 0, len(names))
+	for _, name := range names {
+		policy, err := PolicyDirectory.New(name, cfgs[name])
+		if err != nil {
+			return err
+		}
+		policies = append(policies, &installedPolicy{
+			name:     name,
+			policy:   policy,
+			rejected: metrics.NewRegisteredCounter("txpool/policy/"+name+"/rejected", nil),
+		})
+	}
+	p.signer, p.policies = signer, policies
+	return nil
+}
+
+// admit consults the installed admission policies on the transaction. The ones
+// with an invalid sender are left to the subpools to reject.
+func (p *TxPool) admit(tx *types.Transaction, local bool) error {
+	if len(p.policies) == 0 {
+		return nil
+	}
+	sender, err := types.Sender(p.signer, tx)
+	if err != nil {
+		return nil
+	}
+	for _, installed := range p.policies {
+		if err := installed.policy.Admit(tx, sender, local); err != nil {
+			installed.rejected.Inc(1)
+			return fmt.Errorf("%w: %s: %v", ErrPolicyRejected, installed.name, err)
+		}
+	}
+	return nil
+}
+
+// accepted accounts the transaction accepted by a subpool to the installed
+// admission policies keeping track of them.
+func (p *TxPool) accepted(tx *types.Transaction, local bool) {
+	if len(p.policies) == 0 {
+		return
+	}
+	sender, err := types.Sender(p.signer, tx)
+	if err != nil {
+		return
+	}
+	for _, installed := range p.policies {
+		if policy, ok := installed.policy.(AccountingPolicy); ok {
+			policy.Accepted(tx, sender, local)
+		}
+	}
+}
+
+// allowlistPolicy admits the transactions calling the listed contracts only.
+type allowlistPolicy struct {
+	contracts map[common.Address]struct{}
+	create    bool // Whether contract creations are admitted
+	transfer  bool // Whether plain value transfers to any account are admitted
+}
+
+func newAllowlistPolicy(cfg json.RawMessage) (Policy, error) {
+	var config struct {
+		Contracts      []common.Address `json:"contracts"`
+		AllowCreate    bool             `json:"allowCreate"`
+		AllowTransfers bool             `json:"allowTransfers"`
+	}
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	policy := &allowlistPolicy{
+		contracts: make(map[common.Address]struct{}, len(config.Contracts)),
+		create:    config.AllowCreate,
+		transfer:  config.AllowTransfers,
+	}
+	for _, contract := range config.Contracts {
+		policy.contracts[contract] = struct{}{}
+	}
+	return policy, nil
+}
+
+func (p *allowlistPolicy) Admit(tx *types.Transaction, sender common.Address, local bool) error {
+	if tx.To() == nil {
+		if !p.create {
+			return errors.New("contract creation not allowed")
+		}
+		return nil
+	}
+	if _, ok := p.contracts[*tx.To()]; ok {
+		return nil
+	}
+	if p.transfer && len(tx.Data()) == 0 {
+		return nil
+	}
+	return fmt.Errorf("recipient %s not allowed", tx.To())
+}
+
+// calldataPolicy admits the transactions whose calldata is within the limit.
+type calldataPolicy struct {
+	maxSize int
+}
+
+func newCalldataPolicy(cfg json.RawMessage) (Policy, error) {
+	var config struct {
+		MaxSize int `json:"maxSize"`
+	}
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	if config.MaxSize <= 0 {
+		return nil, errors.New("maxSize must be positive")
+	}
+	return &calldataPolicy{maxSize: config.MaxSize}, nil
+}
+
+func (p *calldataPolicy) Admit(tx *types.Transaction, sender common.Address, local bool) error {
+	if size := len(tx.Data()); size > p.maxSize {
+		return fmt.Errorf("calldata size %d, limit %d", size, p.maxSize)
+	}
+	return nil
+}
+
+// rateLimitSenders is the number of the senders whose rate limiters are kept.
+const rateLimitSenders = 65536
+
+// rateLimitPolicy admits the transactions of each sender up to the rate.
+type rateLimitPolicy struct {
+	rate  rate.Limit
+	burst int
+
+	limiters *lru.Cache[common.Address, *rate.Limiter]
+	lock     sync.Mutex // Lock protecting the creation of the limiters
+}
+
+func newRateLimitPolicy(cfg json.RawMessage) (Policy, error) {
+	var config struct {
+		Rate  float64 `json:"rate"`  // Transactions per second
+		Burst int     `json:"burst"` // Transactions admitted at once
+	}
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	if config.Rate <= 0 {
+		return nil, errors.New("rate must be positive")
+	}
+	if config.Burst <= 0 {
+		config.Burst = 1
+	}
+	return &rateLimitPolicy{
+		rate:     rate.Limit(config.Rate),
+		burst:    config.Burst,
+		limiters: lru.NewCache[common.Address, *rate.Limiter](rateLimitSenders),
+	}, nil
+}
+
+// limiter returns the rate limiter of the sender, creating it if unknown.
+func (p *rateLimitPolicy) limiter(sender common.Address) *rate.Limiter {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	limiter, ok := p.limiters.Get(sender)
+	if !ok {
+		limiter = rate.NewLimiter(p.rate, p.burst)
+		p.limiters.Add(sender, limiter)
+	}
+	return limiter
+}
+
+// Admit checks the sender has a token left, without spending it, as the
+// transaction may still be rejected by the subpools.
+func (p *rateLimitPolicy) Admit(tx *types.Transaction, sender common.Address, local bool) error {
+	if p.limiter(sender).Tokens() < 1 {
+		return fmt.Errorf("sender %s exceeds %v transactions per second", sender, float64(p.rate))
+	}
+	return nil
+}
+
+// Accepted spends a token of the sender for the transaction accepted into the
+// pool. The tokens may go negative if several transactions of the sender were
+// admitted at once, delaying the next ones accordingly.
+func (p *rateLimitPolicy) Accepted(tx *types.Transaction, sender common.Address, local bool) {
+	p.limiter(sender).ReserveN(time.Now(), 1)
+}
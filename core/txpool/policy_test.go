@@ -0,0 +1,117 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestPolicies(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		signer = types.LatestSigner(params.TestChainConfig)
+		game   = common.HexToAddress("0x01")
+		other  = common.HexToAddress("0x02")
+		nonce  uint64
+		newTx  = func(to *common.Address, data []byte) *types.Transaction {
+			nonce++
+			return types.MustSignNewTx(key, signer, &types.LegacyTx{Nonce: nonce, To: to, Gas: 100000, GasPrice: big.NewInt(1), Data: data})
+		}
+	)
+	pool := &TxPool{}
+	err := pool.InstallPolicies(signer, []string{"allowlist", "calldata", "ratelimit"}, map[string]json.RawMessage{
+		"allowlist": json.RawMessage(`{"contracts": ["0x0000000000000000000000000000000000000001"], "allowTransfers": true}`),
+		"calldata":  json.RawMessage(`{"maxSize": 4}`),
+		"ratelimit": json.RawMessage(`{"rate": 0.001, "burst": 3}`),
+	})
+	if err != nil {
+		t.Fatalf("failed to install policies: %v", err)
+	}
+	tests := []struct {
+		tx       *types.Transaction
+		rejected bool
+	}{
+		{newTx(&game, []byte{1, 2, 3, 4}), false},   // Allowed contract
+		{newTx(&other, nil), false},                 // Plain transfer
+		{newTx(&other, []byte{1}), true},            // Disallowed contract
+		{newTx(nil, nil), true},                     // Contract creation
+		{newTx(&game, []byte{1, 2, 3, 4, 5}), true}, // Oversized calldata
+		{newTx(&game, nil), false},                  // Last one within the burst
+		{newTx(&game, nil), true},                   // Rate limited
+	}
+	for i, tt := range tests {
+		err := pool.admit(tt.tx, false)
+		if tt.rejected != (err != nil) {
+			t.Errorf("test %d: rejected mismatch, want: %v, err: %v", i, tt.rejected, err)
+		}
+		if err != nil && !errors.Is(err, ErrPolicyRejected) {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if err == nil {
+			pool.accepted(tt.tx, false)
+		}
+	}
+	if err := pool.InstallPolicies(signer, []string{"unknown"}, nil); err == nil {
+		t.Error("unknown policy installed")
+	}
+	if err := pool.InstallPolicies(signer, []string{"calldata"}, nil); err == nil {
+		t.Error("calldata policy installed without the limit")
+	}
+}
+
+func TestRateLimitPolicyAccounting(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		signer = types.LatestSigner(params.TestChainConfig)
+		pool   = &TxPool{}
+	)
+	err := pool.InstallPolicies(signer, []string{"ratelimit"}, map[string]json.RawMessage{
+		"ratelimit": json.RawMessage(`{"rate": 0.001, "burst": 2}`),
+	})
+	if err != nil {
+		t.Fatalf("failed to install policies: %v", err)
+	}
+	txs := make([]*types.Transaction, 5)
+	for i := range txs {
+		txs[i] = types.MustSignNewTx(key, signer, &types.LegacyTx{Nonce: uint64(i), Gas: 21000, GasPrice: big.NewInt(1)})
+	}
+	// The transactions rejected by the subpools after the admission don't
+	// spend the budget of the sender
+	for i, tx := range txs[:3] {
+		if err := pool.admit(tx, false); err != nil {
+			t.Fatalf("tx %d: rejected without being accepted: %v", i, err)
+		}
+	}
+	// The accepted ones do
+	for i, tx := range txs[3:] {
+		if err := pool.admit(tx, false); err != nil {
+			t.Fatalf("tx %d: rejected within the burst: %v", i, err)
+		}
+		pool.accepted(tx, false)
+	}
+	if err := pool.admit(txs[0], false); !errors.Is(err, ErrPolicyRejected) {
+		t.Fatalf("admitted beyond the burst: %v", err)
+	}
+}
@@ -75,6 +75,9 @@ type TxPool struct {
 	term chan struct{}           // Termination channel to detect a closed pool
 
 	sync chan chan error // Testing / simulator channel to block until internal reset is done
+
+	signer   types.Signer       // Signer to recover the senders for the admission policies
+	policies []*installedPolicy // Admission policies installed by the operator
 }
 
 // New creates a new transaction pool to gather, sort and filter inbound
@@ -317,11 +320,16 @@ func (p *TxPool) Add(txs []*types.Transaction, local bool, sync bool) []error {
 	// so we can piece back the returned errors into the original order.
 	txsets := make([][]*types.Transaction, len(p.subpools))
 	splits := make([]int, len(txs))
+	rejects := make([]error, len(txs))
 
 	for i, tx := range txs {
 		// Mark this transaction belonging to no-subpool
 		splits[i] = -1
 
+		// Reject the transaction disallowed by the admission policies
+		if rejects[i] = p.admit(tx, local); rejects[i] != nil {
+			continue
+		}
 		// Try to find a subpool that accepts the transaction
 		for j, subpool := range p.subpools {
 			if subpool.Filter(tx) {
@@ -339,6 +347,11 @@ func (p *TxPool) Add(txs []*types.Transaction, local bool, sync bool) []error {
 	}
 	errs := make([]error, len(txs))
 	for i, split := range splits {
+		// If the transaction was rejected by the policies, report the reason
+		if rejects[i] != nil {
+			errs[i] = rejects[i]
+			continue
+		}
 		// If the transaction was rejected by all subpools, mark it unsupported
 		if split == -1 {
 			errs[i] = core.ErrTxTypeNotSupported
@@ -347,6 +360,11 @@ func (p *TxPool) Add(txs []*types.Transaction, local bool, sync bool) []error {
 		// Find which subpool handled it and pull in the corresponding error
 		errs[i] = errsets[split][0]
 		errsets[split] = errsets[split][1:]
+
+		// Account the transaction accepted by the subpool to the policies
+		if errs[i] == nil {
+			p.accepted(txs[i], local)
+		}
 	}
 	return errs
 }
@@ -94,6 +94,10 @@ type Header struct {
 
 	// ParentBeaconRoot was added by EIP-4788 and is ignored in legacy headers.
 	ParentBeaconRoot *common.Hash `json:"parentBeaconBlockRoot" rlp:"optional"`
+
+	// VoteAttestation was moved from the extra-data by the Oasys attestation
+	// header fork and is ignored in legacy headers.
+	VoteAttestation *VoteAttestation `json:"voteAttestation" rlp:"optional"`
 }
 
 // field type overrides for gencodec
@@ -149,6 +153,11 @@ func (h *Header) SanityCheck() error {
 			return fmt.Errorf("too large base fee: bitlen %d", bfLen)
 		}
 	}
+	if h.VoteAttestation != nil {
+		if eLen := len(h.VoteAttestation.Extra); eLen > MaxAttestationExtraLength {
+			return fmt.Errorf("too large vote attestation extra: size %d", eLen)
+		}
+	}
 	return nil
 }
 
@@ -313,6 +322,9 @@ func CopyHeader(h *Header) *Header {
 		cpy.ParentBeaconRoot = new(common.Hash)
 		*cpy.ParentBeaconRoot = *h.ParentBeaconRoot
 	}
+	if h.VoteAttestation != nil {
+		cpy.VoteAttestation = h.VoteAttestation.Copy()
+	}
 	return &cpy
 }
 
@@ -590,6 +602,9 @@ func EncodeSigHeader(w io.Writer, header *Header) {
 			header.ExcessBlobGas,
 			header.ParentBeaconRoot,
 		}
+		if header.VoteAttestation != nil {
+			enc = append(enc, header.VoteAttestation)
+		}
 	} else {
 		enc = []interface{}{
 			header.ParentHash,
@@ -623,6 +638,9 @@ func EncodeSigHeader(w io.Writer, header *Header) {
 		if header.ParentBeaconRoot != nil {
 			panic("unexpected parent beacon root value in oasys")
 		}
+		if header.VoteAttestation != nil {
+			panic("unexpected vote attestation value in oasys")
+		}
 	}
 	if err := rlp.Encode(w, enc); err != nil {
 		panic("can't encode: " + err.Error())
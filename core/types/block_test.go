@@ -317,3 +317,92 @@ func TestRlpDecodeParentHash(t *testing.T) {
 		}
 	}
 }
+
+func TestVoteAttestationHeaderEncoding(t *testing.T) {
+	var (
+		zero   uint64
+		root   common.Hash
+		header = &Header{
+			Difficulty:       big.NewInt(2),
+			Number:           big.NewInt(100),
+			Extra:            make([]byte, 32+65),
+			BaseFee:          big.NewInt(params.InitialBaseFee),
+			WithdrawalsHash:  &EmptyWithdrawalsHash,
+			BlobGasUsed:      &zero,
+			ExcessBlobGas:    &zero,
+			ParentBeaconRoot: &root,
+			VoteAttestation: &VoteAttestation{
+				VoteAddressSet: 6,
+				Data:           &VoteData{SourceNumber: 98, TargetNumber: 99, TargetHash: common.HexToHash("0x01")},
+				Extra:          []byte{},
+			},
+		}
+	)
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec Header
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dec.VoteAttestation, header.VoteAttestation) {
+		t.Fatalf("attestation mismatch: have %v, want %v", dec.VoteAttestation, header.VoteAttestation)
+	}
+	if dec.Hash() != header.Hash() {
+		t.Fatalf("hash mismatch: have %x, want %x", dec.Hash(), header.Hash())
+	}
+
+	// The attestation is covered by the seal
+	cpy := CopyHeader(header)
+	cpy.VoteAttestation.VoteAddressSet = 7
+	if SealHash(cpy) == SealHash(header) {
+		t.Fatal("seal hash does not cover the attestation")
+	}
+	if header.VoteAttestation.VoteAddressSet != 6 {
+		t.Fatal("attestation is not deep copied")
+	}
+}
+
+func TestVoteAttestationLargeVoteSet(t *testing.T) {
+	for _, index := range []int{1, 63, 64, 65, 127, 128, 200} {
+		attestation := &VoteAttestation{Data: &VoteData{TargetNumber: 99}, Extra: []byte{}}
+		attestation.SetVoter(index)
+		for _, other := range []int{1, 63, 64, 65, 127, 128, 200} {
+			if have, want := attestation.HasVoter(other), other == index; have != want {
+				t.Errorf("index %d: voter %d mismatch, have %v, want %v", index, other, have, want)
+			}
+		}
+		if index < 64 && attestation.VoteAddressSetExt != nil {
+			t.Errorf("index %d: unexpected extended set %v", index, attestation.VoteAddressSetExt)
+		}
+		if have, want := len(attestation.VoteAddressWords()), index/64+1; have != want {
+			t.Errorf("index %d: words mismatch, have %d, want %d", index, have, want)
+		}
+
+		// Without the extended set, the attestation encodes as before the fork
+		enc, err := rlp.EncodeToBytes(&Header{Difficulty: big.NewInt(2), Number: big.NewInt(100), BaseFee: big.NewInt(params.InitialBaseFee),
+			WithdrawalsHash: &EmptyWithdrawalsHash, BlobGasUsed: new(uint64), ExcessBlobGas: new(uint64), ParentBeaconRoot: new(common.Hash), VoteAttestation: attestation})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var dec Header
+		if err := rlp.DecodeBytes(enc, &dec); err != nil {
+			t.Fatalf("index %d: %v", index, err)
+		}
+		if !reflect.DeepEqual(dec.VoteAttestation, attestation) {
+			t.Errorf("index %d: attestation mismatch: have %v, want %v", index, dec.VoteAttestation, attestation)
+		}
+		legacy, err := rlp.EncodeToBytes(attestation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decLegacy VoteAttestation
+		if err := rlp.DecodeBytes(legacy, &decLegacy); err != nil {
+			t.Fatalf("index %d: %v", index, err)
+		}
+		if !reflect.DeepEqual(&decLegacy, attestation) {
+			t.Errorf("index %d: extra-data attestation mismatch: have %v, want %v", index, &decLegacy, attestation)
+		}
+	}
+}
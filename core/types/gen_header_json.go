@@ -16,27 +16,28 @@ var _ = (*headerMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (h Header) MarshalJSON() ([]byte, error) {
 	type Header struct {
-		ParentHash       common.Hash     `json:"parentHash"       gencodec:"required"`
-		UncleHash        common.Hash     `json:"sha3Uncles"       gencodec:"required"`
-		Coinbase         common.Address  `json:"miner"`
-		Root             common.Hash     `json:"stateRoot"        gencodec:"required"`
-		TxHash           common.Hash     `json:"transactionsRoot" gencodec:"required"`
-		ReceiptHash      common.Hash     `json:"receiptsRoot"     gencodec:"required"`
-		Bloom            Bloom           `json:"logsBloom"        gencodec:"required"`
-		Difficulty       *hexutil.Big    `json:"difficulty"       gencodec:"required"`
-		Number           *hexutil.Big    `json:"number"           gencodec:"required"`
-		GasLimit         hexutil.Uint64  `json:"gasLimit"         gencodec:"required"`
-		GasUsed          hexutil.Uint64  `json:"gasUsed"          gencodec:"required"`
-		Time             hexutil.Uint64  `json:"timestamp"        gencodec:"required"`
-		Extra            hexutil.Bytes   `json:"extraData"        gencodec:"required"`
-		MixDigest        common.Hash     `json:"mixHash"`
-		Nonce            BlockNonce      `json:"nonce"`
-		BaseFee          *hexutil.Big    `json:"baseFeePerGas" rlp:"optional"`
-		WithdrawalsHash  *common.Hash    `json:"withdrawalsRoot" rlp:"optional"`
-		BlobGasUsed      *hexutil.Uint64 `json:"blobGasUsed" rlp:"optional"`
-		ExcessBlobGas    *hexutil.Uint64 `json:"excessBlobGas" rlp:"optional"`
-		ParentBeaconRoot *common.Hash    `json:"parentBeaconBlockRoot" rlp:"optional"`
-		Hash             common.Hash     `json:"hash"`
+		ParentHash       common.Hash      `json:"parentHash"       gencodec:"required"`
+		UncleHash        common.Hash      `json:"sha3Uncles"       gencodec:"required"`
+		Coinbase         common.Address   `json:"miner"`
+		Root             common.Hash      `json:"stateRoot"        gencodec:"required"`
+		TxHash           common.Hash      `json:"transactionsRoot" gencodec:"required"`
+		ReceiptHash      common.Hash      `json:"receiptsRoot"     gencodec:"required"`
+		Bloom            Bloom            `json:"logsBloom"        gencodec:"required"`
+		Difficulty       *hexutil.Big     `json:"difficulty"       gencodec:"required"`
+		Number           *hexutil.Big     `json:"number"           gencodec:"required"`
+		GasLimit         hexutil.Uint64   `json:"gasLimit"         gencodec:"required"`
+		GasUsed          hexutil.Uint64   `json:"gasUsed"          gencodec:"required"`
+		Time             hexutil.Uint64   `json:"timestamp"        gencodec:"required"`
+		Extra            hexutil.Bytes    `json:"extraData"        gencodec:"required"`
+		MixDigest        common.Hash      `json:"mixHash"`
+		Nonce            BlockNonce       `json:"nonce"`
+		BaseFee          *hexutil.Big     `json:"baseFeePerGas" rlp:"optional"`
+		WithdrawalsHash  *common.Hash     `json:"withdrawalsRoot" rlp:"optional"`
+		BlobGasUsed      *hexutil.Uint64  `json:"blobGasUsed" rlp:"optional"`
+		ExcessBlobGas    *hexutil.Uint64  `json:"excessBlobGas" rlp:"optional"`
+		ParentBeaconRoot *common.Hash     `json:"parentBeaconBlockRoot" rlp:"optional"`
+		VoteAttestation  *VoteAttestation `json:"voteAttestation" rlp:"optional"`
+		Hash             common.Hash      `json:"hash"`
 	}
 	var enc Header
 	enc.ParentHash = h.ParentHash
@@ -59,6 +60,7 @@ func (h Header) MarshalJSON() ([]byte, error) {
 	enc.BlobGasUsed = (*hexutil.Uint64)(h.BlobGasUsed)
 	enc.ExcessBlobGas = (*hexutil.Uint64)(h.ExcessBlobGas)
 	enc.ParentBeaconRoot = h.ParentBeaconRoot
+	enc.VoteAttestation = h.VoteAttestation
 	enc.Hash = h.Hash()
 	return json.Marshal(&enc)
 }
@@ -66,26 +68,27 @@ func (h Header) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals from JSON.
 func (h *Header) UnmarshalJSON(input []byte) error {
 	type Header struct {
-		ParentHash       *common.Hash    `json:"parentHash"       gencodec:"required"`
-		UncleHash        *common.Hash    `json:"sha3Uncles"       gencodec:"required"`
-		Coinbase         *common.Address `json:"miner"`
-		Root             *common.Hash    `json:"stateRoot"        gencodec:"required"`
-		TxHash           *common.Hash    `json:"transactionsRoot" gencodec:"required"`
-		ReceiptHash      *common.Hash    `json:"receiptsRoot"     gencodec:"required"`
-		Bloom            *Bloom          `json:"logsBloom"        gencodec:"required"`
-		Difficulty       *hexutil.Big    `json:"difficulty"       gencodec:"required"`
-		Number           *hexutil.Big    `json:"number"           gencodec:"required"`
-		GasLimit         *hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
-		GasUsed          *hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
-		Time             *hexutil.Uint64 `json:"timestamp"        gencodec:"required"`
-		Extra            *hexutil.Bytes  `json:"extraData"        gencodec:"required"`
-		MixDigest        *common.Hash    `json:"mixHash"`
-		Nonce            *BlockNonce     `json:"nonce"`
-		BaseFee          *hexutil.Big    `json:"baseFeePerGas" rlp:"optional"`
-		WithdrawalsHash  *common.Hash    `json:"withdrawalsRoot" rlp:"optional"`
-		BlobGasUsed      *hexutil.Uint64 `json:"blobGasUsed" rlp:"optional"`
-		ExcessBlobGas    *hexutil.Uint64 `json:"excessBlobGas" rlp:"optional"`
-		ParentBeaconRoot *common.Hash    `json:"parentBeaconBlockRoot" rlp:"optional"`
+		ParentHash       *common.Hash     `json:"parentHash"       gencodec:"required"`
+		UncleHash        *common.Hash     `json:"sha3Uncles"       gencodec:"required"`
+		Coinbase         *common.Address  `json:"miner"`
+		Root             *common.Hash     `json:"stateRoot"        gencodec:"required"`
+		TxHash           *common.Hash     `json:"transactionsRoot" gencodec:"required"`
+		ReceiptHash      *common.Hash     `json:"receiptsRoot"     gencodec:"required"`
+		Bloom            *Bloom           `json:"logsBloom"        gencodec:"required"`
+		Difficulty       *hexutil.Big     `json:"difficulty"       gencodec:"required"`
+		Number           *hexutil.Big     `json:"number"           gencodec:"required"`
+		GasLimit         *hexutil.Uint64  `json:"gasLimit"         gencodec:"required"`
+		GasUsed          *hexutil.Uint64  `json:"gasUsed"          gencodec:"required"`
+		Time             *hexutil.Uint64  `json:"timestamp"        gencodec:"required"`
+		Extra            *hexutil.Bytes   `json:"extraData"        gencodec:"required"`
+		MixDigest        *common.Hash     `json:"mixHash"`
+		Nonce            *BlockNonce      `json:"nonce"`
+		BaseFee          *hexutil.Big     `json:"baseFeePerGas" rlp:"optional"`
+		WithdrawalsHash  *common.Hash     `json:"withdrawalsRoot" rlp:"optional"`
+		BlobGasUsed      *hexutil.Uint64  `json:"blobGasUsed" rlp:"optional"`
+		ExcessBlobGas    *hexutil.Uint64  `json:"excessBlobGas" rlp:"optional"`
+		ParentBeaconRoot *common.Hash     `json:"parentBeaconBlockRoot" rlp:"optional"`
+		VoteAttestation  *VoteAttestation `json:"voteAttestation" rlp:"optional"`
 	}
 	var dec Header
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -163,5 +166,8 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 	if dec.ParentBeaconRoot != nil {
 		h.ParentBeaconRoot = dec.ParentBeaconRoot
 	}
+	if dec.VoteAttestation != nil {
+		h.VoteAttestation = dec.VoteAttestation
+	}
 	return nil
 }
@@ -42,7 +42,8 @@ func (obj *Header) EncodeRLP(_w io.Writer) error {
 	_tmp3 := obj.BlobGasUsed != nil
 	_tmp4 := obj.ExcessBlobGas != nil
 	_tmp5 := obj.ParentBeaconRoot != nil
-	if _tmp1 || _tmp2 || _tmp3 || _tmp4 || _tmp5 {
+	_tmp6 := obj.VoteAttestation != nil
+	if _tmp1 || _tmp2 || _tmp3 || _tmp4 || _tmp5 || _tmp6 {
 		if obj.BaseFee == nil {
 			w.Write(rlp.EmptyString)
 		} else {
@@ -52,34 +53,63 @@ func (obj *Header) EncodeRLP(_w io.Writer) error {
 			w.WriteBigInt(obj.BaseFee)
 		}
 	}
-	if _tmp2 || _tmp3 || _tmp4 || _tmp5 {
+	if _tmp2 || _tmp3 || _tmp4 || _tmp5 || _tmp6 {
 		if obj.WithdrawalsHash == nil {
 			w.Write([]byte{0x80})
 		} else {
 			w.WriteBytes(obj.WithdrawalsHash[:])
 		}
 	}
-	if _tmp3 || _tmp4 || _tmp5 {
+	if _tmp3 || _tmp4 || _tmp5 || _tmp6 {
 		if obj.BlobGasUsed == nil {
 			w.Write([]byte{0x80})
 		} else {
 			w.WriteUint64((*obj.BlobGasUsed))
 		}
 	}
-	if _tmp4 || _tmp5 {
+	if _tmp4 || _tmp5 || _tmp6 {
 		if obj.ExcessBlobGas == nil {
 			w.Write([]byte{0x80})
 		} else {
 			w.WriteUint64((*obj.ExcessBlobGas))
 		}
 	}
-	if _tmp5 {
+	if _tmp5 || _tmp6 {
 		if obj.ParentBeaconRoot == nil {
 			w.Write([]byte{0x80})
 		} else {
 			w.WriteBytes(obj.ParentBeaconRoot[:])
 		}
 	}
+	if _tmp6 {
+		if obj.VoteAttestation == nil {
+			w.Write([]byte{0xC0})
+		} else {
+			_tmp7 := w.List()
+			w.WriteUint64(uint64(obj.VoteAttestation.VoteAddressSet))
+			w.WriteBytes(obj.VoteAttestation.AggSignature[:])
+			if obj.VoteAttestation.Data == nil {
+				w.Write([]byte{0xC0})
+			} else {
+				_tmp8 := w.List()
+				w.WriteUint64(obj.VoteAttestation.Data.SourceNumber)
+				w.WriteBytes(obj.VoteAttestation.Data.SourceHash[:])
+				w.WriteUint64(obj.VoteAttestation.Data.TargetNumber)
+				w.WriteBytes(obj.VoteAttestation.Data.TargetHash[:])
+				w.ListEnd(_tmp8)
+			}
+			w.WriteBytes(obj.VoteAttestation.Extra)
+			_tmp10 := len(obj.VoteAttestation.VoteAddressSetExt) > 0
+			if _tmp10 {
+				_tmp9 := w.List()
+				for _, _tmp11 := range obj.VoteAttestation.VoteAddressSetExt {
+					w.WriteUint64(_tmp11)
+				}
+				w.ListEnd(_tmp9)
+			}
+			w.ListEnd(_tmp7)
+		}
+	}
 	w.ListEnd(_tmp0)
 	return w.Flush()
 }
@@ -60,6 +60,9 @@ type Transaction struct {
 	hash atomic.Value
 	size atomic.Value
 	from atomic.Value
+
+	// preconditions of the local submission, never encoded nor propagated
+	conditional atomic.Value
 }
 
 // NewTx creates a new transaction.
@@ -459,6 +462,20 @@ func (tx *Transaction) Time() time.Time {
 	return tx.time
 }
 
+// Conditional returns the preconditions the transaction was submitted with
+// locally, or nil if it is unconditional.
+func (tx *Transaction) Conditional() *TransactionConditional {
+	if cond := tx.conditional.Load(); cond != nil {
+		return cond.(*TransactionConditional)
+	}
+	return nil
+}
+
+// SetConditional sets the preconditions of the transaction submitted locally.
+func (tx *Transaction) SetConditional(cond *TransactionConditional) {
+	tx.conditional.Store(cond)
+}
+
 // Hash returns the transaction hash.
 func (tx *Transaction) Hash() common.Hash {
 	if hash := tx.hash.Load(); hash != nil {
@@ -0,0 +1,214 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// MaxConditionalCost is the maximum number of the storage roots and the slots
+// referenced by the known accounts of a transaction conditional.
+const MaxConditionalCost = 1000
+
+var (
+	ErrConditionalCost        = errors.New("conditional cost exceeds the limit")
+	ErrConditionalBlockNumber = errors.New("block number out of the conditional range")
+	ErrConditionalTimestamp   = errors.New("timestamp out of the conditional range")
+	ErrConditionalFinalized   = errors.New("finalized block number out of the conditional range")
+	ErrConditionalStorage     = errors.New("storage mismatches the known accounts")
+)
+
+// ConditionalBlock is the block whose state the known accounts are checked
+// against.
+type ConditionalBlock string
+
+const (
+	ConditionalLatest    ConditionalBlock = "latest"    // State the transaction is executed on
+	ConditionalSafe      ConditionalBlock = "safe"      // State of the justified block
+	ConditionalFinalized ConditionalBlock = "finalized" // State of the finalized block
+)
+
+// KnownAccount is the expected storage of an account, either the storage root
+// or the values of the slots.
+type KnownAccount struct {
+	StorageRoot  *common.Hash
+	StorageSlots map[common.Hash]common.Hash
+}
+
+// UnmarshalJSON decodes either the storage root or the object of the slots.
+func (ka *KnownAccount) UnmarshalJSON(input []byte) error {
+	var root common.Hash
+	if err := json.Unmarshal(input, &root); err == nil {
+		ka.StorageRoot, ka.StorageSlots = &root, nil
+		return nil
+	}
+	var slots map[common.Hash]common.Hash
+	if err := json.Unmarshal(input, &slots); err != nil {
+		return fmt.Errorf("known account must be a storage root or slots: %v", err)
+	}
+	ka.StorageRoot, ka.StorageSlots = nil, slots
+	return nil
+}
+
+// MarshalJSON encodes either the storage root or the object of the slots.
+func (ka KnownAccount) MarshalJSON() ([]byte, error) {
+	if ka.StorageRoot != nil {
+		return json.Marshal(ka.StorageRoot)
+	}
+	return json.Marshal(ka.StorageSlots)
+}
+
+// ConditionalState is the state the known accounts are checked against.
+type ConditionalState interface {
+	GetStorageRoot(addr common.Address) common.Hash
+	GetState(addr common.Address, slot common.Hash) common.Hash
+}
+
+// TransactionConditional is the preconditions of a transaction submitted by
+// eth_sendRawTransactionConditional, which are checked on the submission and
+// again when the transaction is included in a block. Besides the block range,
+// the preconditions may require the finalized block, so that the relayers of
+// the bridges submit the transactions only valid while the finalized state
+// observed holds.
+type TransactionConditional struct {
+	KnownAccounts map[common.Address]KnownAccount `json:"knownAccounts,omitempty"`
+
+	// Block whose state the known accounts are checked against, the latest if empty
+	KnownAccountsBlock ConditionalBlock `json:"knownAccountsBlock,omitempty"`
+
+	BlockNumberMin     *hexutil.Uint64 `json:"blockNumberMin,omitempty"`
+	BlockNumberMax     *hexutil.Uint64 `json:"blockNumberMax,omitempty"`
+	TimestampMin       *hexutil.Uint64 `json:"timestampMin,omitempty"`
+	TimestampMax       *hexutil.Uint64 `json:"timestampMax,omitempty"`
+	FinalizedNumberMin *hexutil.Uint64 `json:"finalizedNumberMin,omitempty"`
+	FinalizedNumberMax *hexutil.Uint64 `json:"finalizedNumberMax,omitempty"`
+}
+
+// Cost returns the number of the storage roots and the slots referenced.
+func (c *TransactionConditional) Cost() int {
+	cost := 0
+	for _, account := range c.KnownAccounts {
+		if account.StorageRoot != nil {
+			cost++
+		} else {
+			cost += len(account.StorageSlots)
+		}
+	}
+	return cost
+}
+
+// Validate checks the sanity of the preconditions.
+func (c *TransactionConditional) Validate() error {
+	if cost := c.Cost(); cost > MaxConditionalCost {
+		return fmt.Errorf("%w: cost %d, limit %d", ErrConditionalCost, cost, MaxConditionalCost)
+	}
+	switch c.KnownAccountsBlock {
+	case "", ConditionalLatest, ConditionalSafe, ConditionalFinalized:
+	default:
+		return fmt.Errorf("invalid known accounts block %q", c.KnownAccountsBlock)
+	}
+	for _, r := range []struct {
+		name     string
+		min, max *hexutil.Uint64
+	}{
+		{"block number", c.BlockNumberMin, c.BlockNumberMax},
+		{"timestamp", c.TimestampMin, c.TimestampMax},
+		{"finalized block number", c.FinalizedNumberMin, c.FinalizedNumberMax},
+	} {
+		if r.min != nil && r.max != nil && *r.min > *r.max {
+			return fmt.Errorf("%s range inverted: min %d, max %d", r.name, *r.min, *r.max)
+		}
+	}
+	return nil
+}
+
+// Block returns the block whose state the known accounts are checked against.
+func (c *TransactionConditional) Block() ConditionalBlock {
+	if c.KnownAccountsBlock == "" {
+		return ConditionalLatest
+	}
+	return c.KnownAccountsBlock
+}
+
+// ConditionalChain is the source of the safe and the finalized blocks, which
+// the preconditions are resolved against on the submission and the inclusion
+// alike.
+type ConditionalChain interface {
+	CurrentSafeBlock() *Header
+	CurrentFinalBlock() *Header
+}
+
+// Resolve returns the number of the finalized block, and the header of the block
+// whose state the known accounts are checked against, nil for the latest state.
+func (c *TransactionConditional) Resolve(chain ConditionalChain) (uint64, *Header, error) {
+	var finalized uint64
+	final := chain.CurrentFinalBlock()
+	if final != nil {
+		finalized = final.Number.Uint64()
+	}
+	var header *Header
+	switch block := c.Block(); block {
+	case ConditionalLatest:
+		return finalized, nil, nil
+	case ConditionalSafe:
+		header = chain.CurrentSafeBlock()
+	case ConditionalFinalized:
+		header = final
+	}
+	if header == nil {
+		return 0, nil, fmt.Errorf("%s block not found", c.Block())
+	}
+	return finalized, header, nil
+}
+
+// Check checks the preconditions against the header of the block including the
+// transaction, the number of the finalized block, and the state selected by the
+// known accounts block.
+func (c *TransactionConditional) Check(header *Header, finalized uint64, state ConditionalState) error {
+	if !inRange(header.Number.Uint64(), c.BlockNumberMin, c.BlockNumberMax) {
+		return fmt.Errorf("%w: %d", ErrConditionalBlockNumber, header.Number)
+	}
+	if !inRange(header.Time, c.TimestampMin, c.TimestampMax) {
+		return fmt.Errorf("%w: %d", ErrConditionalTimestamp, header.Time)
+	}
+	if !inRange(finalized, c.FinalizedNumberMin, c.FinalizedNumberMax) {
+		return fmt.Errorf("%w: %d", ErrConditionalFinalized, finalized)
+	}
+	for addr, account := range c.KnownAccounts {
+		if account.StorageRoot != nil {
+			if root := state.GetStorageRoot(addr); root != *account.StorageRoot {
+				return fmt.Errorf("%w: account %s, root %s, want %s", ErrConditionalStorage, addr, root, account.StorageRoot)
+			}
+			continue
+		}
+		for slot, want := range account.StorageSlots {
+			if value := state.GetState(addr, slot); value != want {
+				return fmt.Errorf("%w: account %s, slot %s, value %s, want %s", ErrConditionalStorage, addr, slot, value, want)
+			}
+		}
+	}
+	return nil
+}
+
+func inRange(v uint64, min, max *hexutil.Uint64) bool {
+	return (min == nil || v >= uint64(*min)) && (max == nil || v <= uint64(*max))
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type testConditionalState struct {
+	roots map[common.Address]common.Hash
+	slots map[common.Address]map[common.Hash]common.Hash
+}
+
+func (s *testConditionalState) GetStorageRoot(addr common.Address) common.Hash {
+	return s.roots[addr]
+}
+
+func (s *testConditionalState) GetState(addr common.Address, slot common.Hash) common.Hash {
+	return s.slots[addr][slot]
+}
+
+func TestTransactionConditional(t *testing.T) {
+	var (
+		root  = common.HexToAddress("0x01")
+		slots = common.HexToAddress("0x02")
+		state = &testConditionalState{
+			roots: map[common.Address]common.Hash{root: common.HexToHash("0xaa")},
+			slots: map[common.Address]map[common.Hash]common.Hash{
+				slots: {common.HexToHash("0x01"): common.HexToHash("0xbb")},
+			},
+		}
+		header = &Header{Number: big.NewInt(100), Time: 1000}
+	)
+	tests := []struct {
+		input     string
+		finalized uint64
+		err       error
+	}{
+		{`{}`, 0, nil},
+		{`{"blockNumberMin": "0x64", "blockNumberMax": "0x64", "timestampMin": "0x3e8"}`, 0, nil},
+		{`{"blockNumberMin": "0x65"}`, 0, ErrConditionalBlockNumber},
+		{`{"timestampMax": "0x3e7"}`, 0, ErrConditionalTimestamp},
+		{`{"finalizedNumberMin": "0x60"}`, 96, nil},
+		{`{"finalizedNumberMin": "0x60"}`, 95, ErrConditionalFinalized},
+		{`{"finalizedNumberMax": "0x60"}`, 97, ErrConditionalFinalized},
+		{
+			`{"knownAccounts": {
+				"0x0000000000000000000000000000000000000001": "0x00000000000000000000000000000000000000000000000000000000000000aa",
+				"0x0000000000000000000000000000000000000002": {"0x0000000000000000000000000000000000000000000000000000000000000001": "0x00000000000000000000000000000000000000000000000000000000000000bb"}
+			}, "knownAccountsBlock": "finalized"}`,
+			0, nil,
+		},
+		{
+			`{"knownAccounts": {"0x0000000000000000000000000000000000000001": "0x00000000000000000000000000000000000000000000000000000000000000ab"}}`,
+			0, ErrConditionalStorage,
+		},
+		{
+			`{"knownAccounts": {"0x0000000000000000000000000000000000000002": {"0x0000000000000000000000000000000000000000000000000000000000000002": "0x00000000000000000000000000000000000000000000000000000000000000bb"}}}`,
+			0, ErrConditionalStorage,
+		},
+	}
+	for i, tt := range tests {
+		var cond TransactionConditional
+		if err := json.Unmarshal([]byte(tt.input), &cond); err != nil {
+			t.Fatalf("test %d: failed to decode: %v", i, err)
+		}
+		if err := cond.Validate(); err != nil {
+			t.Fatalf("test %d: invalid conditional: %v", i, err)
+		}
+		if err := cond.Check(header, tt.finalized, state); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch, have: %v, want: %v", i, err, tt.err)
+		}
+		// The known accounts must survive the round trip
+		enc, err := json.Marshal(&cond)
+		if err != nil {
+			t.Fatalf("test %d: failed to encode: %v", i, err)
+		}
+		var dec TransactionConditional
+		if err := json.Unmarshal(enc, &dec); err != nil {
+			t.Fatalf("test %d: failed to decode again: %v", i, err)
+		}
+		if dec.Cost() != cond.Cost() || dec.Block() != cond.Block() {
+			t.Errorf("test %d: round trip mismatch: %s", i, enc)
+		}
+	}
+	for i, input := range []string{
+		`{"blockNumberMin": "0x2", "blockNumberMax": "0x1"}`,
+		`{"finalizedNumberMin": "0x2", "finalizedNumberMax": "0x1"}`,
+		`{"knownAccountsBlock": "pending"}`,
+	} {
+		var cond TransactionConditional
+		if err := json.Unmarshal([]byte(input), &cond); err != nil {
+			t.Fatalf("invalid %d: failed to decode: %v", i, err)
+		}
+		if err := cond.Validate(); err == nil {
+			t.Errorf("invalid %d: conditional accepted", i)
+		}
+	}
+	tx := NewTx(&LegacyTx{})
+	if tx.Conditional() != nil {
+		t.Fatal("conditional set on new transaction")
+	}
+	cond := &TransactionConditional{}
+	tx.SetConditional(cond)
+	if tx.Conditional() != cond {
+		t.Fatal("conditional not set")
+	}
+}
+
+type testConditionalChain struct {
+	safe, final *Header
+}
+
+func (c *testConditionalChain) CurrentSafeBlock() *Header  { return c.safe }
+func (c *testConditionalChain) CurrentFinalBlock() *Header { return c.final }
+
+func TestTransactionConditionalResolve(t *testing.T) {
+	var (
+		safe  = &Header{Number: big.NewInt(90)}
+		final = &Header{Number: big.NewInt(80)}
+	)
+	tests := []struct {
+		block     ConditionalBlock
+		chain     *testConditionalChain
+		finalized uint64
+		header    *Header
+		fail      bool
+	}{
+		{"", &testConditionalChain{safe, final}, 80, nil, false},
+		{ConditionalLatest, &testConditionalChain{}, 0, nil, false},
+		{ConditionalSafe, &testConditionalChain{safe, final}, 80, safe, false},
+		{ConditionalSafe, &testConditionalChain{nil, final}, 0, nil, true},
+		{ConditionalFinalized, &testConditionalChain{safe, final}, 80, final, false},
+		{ConditionalFinalized, &testConditionalChain{safe, nil}, 0, nil, true},
+	}
+	for i, tt := range tests {
+		cond := &TransactionConditional{KnownAccountsBlock: tt.block}
+		finalized, header, err := cond.Resolve(tt.chain)
+		if tt.fail != (err != nil) {
+			t.Fatalf("test %d: error mismatch, have: %v, want failure: %v", i, err, tt.fail)
+		}
+		if finalized != tt.finalized || header != tt.header {
+			t.Errorf("test %d: resolved mismatch, have: %d %v, want: %d %v", i, finalized, header, tt.finalized, tt.header)
+		}
+	}
+}
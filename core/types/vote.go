@@ -55,6 +55,56 @@ type VoteAttestation struct {
 	AggSignature   BLSSignature     // The aggregated BLS signature of the voted validators' signatures.
 	Data           *VoteData        // The vote data for fast finality.
 	Extra          []byte           // Reserved for future usage.
+
+	// VoteAddressSetExt continues the bitset of the voted validators beyond the
+	// first 64 bits, allowed since the Oasys large vote set fork.
+	VoteAddressSetExt []uint64 `rlp:"optional"`
+}
+
+// Copy returns a deep copy of the attestation.
+func (a *VoteAttestation) Copy() *VoteAttestation {
+	cpy := *a
+	if a.Data != nil {
+		data := *a.Data
+		cpy.Data = &data
+	}
+	if a.Extra != nil {
+		cpy.Extra = common.CopyBytes(a.Extra)
+	}
+	if a.VoteAddressSetExt != nil {
+		cpy.VoteAddressSetExt = append([]uint64(nil), a.VoteAddressSetExt...)
+	}
+	return &cpy
+}
+
+// VoteAddressWords returns the bitset of the voted validators as 64-bit words,
+// the first of which is VoteAddressSet.
+func (a *VoteAttestation) VoteAddressWords() []uint64 {
+	return append([]uint64{uint64(a.VoteAddressSet)}, a.VoteAddressSetExt...)
+}
+
+// SetVoter marks the validator at the given index of the bitset as voted. The
+// indexes beyond 63 are set in VoteAddressSetExt.
+func (a *VoteAttestation) SetVoter(index int) {
+	if index < 64 {
+		a.VoteAddressSet |= 1 << index
+		return
+	}
+	word := index/64 - 1
+	for len(a.VoteAddressSetExt) <= word {
+		a.VoteAddressSetExt = append(a.VoteAddressSetExt, 0)
+	}
+	a.VoteAddressSetExt[word] |= 1 << (index % 64)
+}
+
+// HasVoter returns whether the validator at the given index of the bitset is
+// marked as voted.
+func (a *VoteAttestation) HasVoter(index int) bool {
+	if index < 64 {
+		return a.VoteAddressSet&(1<<index) != 0
+	}
+	word := index/64 - 1
+	return word < len(a.VoteAddressSetExt) && a.VoteAddressSetExt[word]&(1<<(index%64)) != 0
 }
 
 // Hash returns the vote's hash.
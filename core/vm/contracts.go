@@ -121,7 +121,33 @@ var PrecompiledContractsBLS = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{18}): &bls12381MapG2{},
 }
 
+// PrecompiledContractsOasysBLS contains the set of pre-compiled contracts used
+// since the Oasys BLS12-381 fork, which enables the precompiles of the final
+// EIP-2537 on top of the Cancun ones, at the same addresses as Prague. The
+// multiplications of the earlier draft are left out in favour of the multi
+// exponentiations of a single pair.
+var PrecompiledContractsOasysBLS = map[common.Address]PrecompiledContract{
+	common.BytesToAddress([]byte{1}):    &ecrecover{},
+	common.BytesToAddress([]byte{2}):    &sha256hash{},
+	common.BytesToAddress([]byte{3}):    &ripemd160hash{},
+	common.BytesToAddress([]byte{4}):    &dataCopy{},
+	common.BytesToAddress([]byte{5}):    &bigModExp{eip2565: true},
+	common.BytesToAddress([]byte{6}):    &bn256AddIstanbul{},
+	common.BytesToAddress([]byte{7}):    &bn256ScalarMulIstanbul{},
+	common.BytesToAddress([]byte{8}):    &bn256PairingIstanbul{},
+	common.BytesToAddress([]byte{9}):    &blake2F{},
+	common.BytesToAddress([]byte{0x0a}): &kzgPointEvaluation{},
+	common.BytesToAddress([]byte{0x0b}): &bls12381G1Add{final: true},
+	common.BytesToAddress([]byte{0x0c}): &bls12381G1MultiExp{final: true},
+	common.BytesToAddress([]byte{0x0d}): &bls12381G2Add{final: true},
+	common.BytesToAddress([]byte{0x0e}): &bls12381G2MultiExp{final: true},
+	common.BytesToAddress([]byte{0x0f}): &bls12381Pairing{final: true},
+	common.BytesToAddress([]byte{0x10}): &bls12381MapG1{final: true},
+	common.BytesToAddress([]byte{0x11}): &bls12381MapG2{final: true},
+}
+
 var (
+	PrecompiledAddressesOasysBLS  []common.Address
 	PrecompiledAddressesCancun    []common.Address
 	PrecompiledAddressesBerlin    []common.Address
 	PrecompiledAddressesIstanbul  []common.Address
@@ -145,11 +171,16 @@ func init() {
 	for k := range PrecompiledContractsCancun {
 		PrecompiledAddressesCancun = append(PrecompiledAddressesCancun, k)
 	}
+	for k := range PrecompiledContractsOasysBLS {
+		PrecompiledAddressesOasysBLS = append(PrecompiledAddressesOasysBLS, k)
+	}
 }
 
 // ActivePrecompiles returns the precompiles enabled with the current configuration.
 func ActivePrecompiles(rules params.Rules) []common.Address {
 	switch {
+	case rules.IsOasysBLS12381:
+		return PrecompiledAddressesOasysBLS
 	case rules.IsCancun:
 		return PrecompiledAddressesCancun
 	case rules.IsBerlin:
@@ -654,10 +685,15 @@ var (
 )
 
 // bls12381G1Add implements EIP-2537 G1Add precompile.
-type bls12381G1Add struct{}
+type bls12381G1Add struct {
+	final bool // Priced as in the final EIP-2537
+}
 
 // RequiredGas returns the gas required to execute the pre-compiled contract.
 func (c *bls12381G1Add) RequiredGas(input []byte) uint64 {
+	if c.final {
+		return params.Bls12381G1AddGasFinal
+	}
 	return params.Bls12381G1AddGas
 }
 
@@ -728,7 +764,9 @@ func (c *bls12381G1Mul) Run(input []byte) ([]byte, error) {
 }
 
 // bls12381G1MultiExp implements EIP-2537 G1MultiExp precompile.
-type bls12381G1MultiExp struct{}
+type bls12381G1MultiExp struct {
+	final bool // Priced and checked as in the final EIP-2537
+}
 
 // RequiredGas returns the gas required to execute the pre-compiled contract.
 func (c *bls12381G1MultiExp) RequiredGas(input []byte) uint64 {
@@ -739,14 +777,18 @@ func (c *bls12381G1MultiExp) RequiredGas(input []byte) uint64 {
 		return 0
 	}
 	// Lookup discount value for G1 point, scalar value pair length
+	table, mulGas := params.Bls12381MultiExpDiscountTable[:], params.Bls12381G1MulGas
+	if c.final {
+		table, mulGas = params.Bls12381G1MultiExpDiscountTableFinal[:], params.Bls12381G1MulGasFinal
+	}
 	var discount uint64
-	if dLen := len(params.Bls12381MultiExpDiscountTable); k < dLen {
-		discount = params.Bls12381MultiExpDiscountTable[k-1]
+	if dLen := len(table); k < dLen {
+		discount = table[k-1]
 	} else {
-		discount = params.Bls12381MultiExpDiscountTable[dLen-1]
+		discount = table[dLen-1]
 	}
 	// Calculate gas and return the result
-	return (uint64(k) * params.Bls12381G1MulGas * discount) / 1000
+	return (uint64(k) * mulGas * discount) / 1000
 }
 
 func (c *bls12381G1MultiExp) Run(input []byte) ([]byte, error) {
@@ -772,6 +814,10 @@ func (c *bls12381G1MultiExp) Run(input []byte) ([]byte, error) {
 		if points[i], err = g.DecodePoint(input[t0:t1]); err != nil {
 			return nil, err
 		}
+		// The final EIP-2537 also requires the points in the subgroup.
+		if c.final && !g.InCorrectSubgroup(points[i]) {
+			return nil, errBLS12381G1PointSubgroup
+		}
 		// Decode scalar value
 		scalars[i] = new(big.Int).SetBytes(input[t1:t2])
 	}
@@ -785,10 +831,15 @@ func (c *bls12381G1MultiExp) Run(input []byte) ([]byte, error) {
 }
 
 // bls12381G2Add implements EIP-2537 G2Add precompile.
-type bls12381G2Add struct{}
+type bls12381G2Add struct {
+	final bool // Priced as in the final EIP-2537
+}
 
 // RequiredGas returns the gas required to execute the pre-compiled contract.
 func (c *bls12381G2Add) RequiredGas(input []byte) uint64 {
+	if c.final {
+		return params.Bls12381G2AddGasFinal
+	}
 	return params.Bls12381G2AddGas
 }
 
@@ -859,7 +910,9 @@ func (c *bls12381G2Mul) Run(input []byte) ([]byte, error) {
 }
 
 // bls12381G2MultiExp implements EIP-2537 G2MultiExp precompile.
-type bls12381G2MultiExp struct{}
+type bls12381G2MultiExp struct {
+	final bool // Priced and checked as in the final EIP-2537
+}
 
 // RequiredGas returns the gas required to execute the pre-compiled contract.
 func (c *bls12381G2MultiExp) RequiredGas(input []byte) uint64 {
@@ -870,14 +923,18 @@ func (c *bls12381G2MultiExp) RequiredGas(input []byte) uint64 {
 		return 0
 	}
 	// Lookup discount value for G2 point, scalar value pair length
+	table, mulGas := params.Bls12381MultiExpDiscountTable[:], params.Bls12381G2MulGas
+	if c.final {
+		table, mulGas = params.Bls12381G2MultiExpDiscountTableFinal[:], params.Bls12381G2MulGasFinal
+	}
 	var discount uint64
-	if dLen := len(params.Bls12381MultiExpDiscountTable); k < dLen {
-		discount = params.Bls12381MultiExpDiscountTable[k-1]
+	if dLen := len(table); k < dLen {
+		discount = table[k-1]
 	} else {
-		discount = params.Bls12381MultiExpDiscountTable[dLen-1]
+		discount = table[dLen-1]
 	}
 	// Calculate gas and return the result
-	return (uint64(k) * params.Bls12381G2MulGas * discount) / 1000
+	return (uint64(k) * mulGas * discount) / 1000
 }
 
 func (c *bls12381G2MultiExp) Run(input []byte) ([]byte, error) {
@@ -903,6 +960,10 @@ func (c *bls12381G2MultiExp) Run(input []byte) ([]byte, error) {
 		if points[i], err = g.DecodePoint(input[t0:t1]); err != nil {
 			return nil, err
 		}
+		// The final EIP-2537 also requires the points in the subgroup.
+		if c.final && !g.InCorrectSubgroup(points[i]) {
+			return nil, errBLS12381G2PointSubgroup
+		}
 		// Decode scalar value
 		scalars[i] = new(big.Int).SetBytes(input[t1:t2])
 	}
@@ -916,10 +977,15 @@ func (c *bls12381G2MultiExp) Run(input []byte) ([]byte, error) {
 }
 
 // bls12381Pairing implements EIP-2537 Pairing precompile.
-type bls12381Pairing struct{}
+type bls12381Pairing struct {
+	final bool // Priced as in the final EIP-2537
+}
 
 // RequiredGas returns the gas required to execute the pre-compiled contract.
 func (c *bls12381Pairing) RequiredGas(input []byte) uint64 {
+	if c.final {
+		return params.Bls12381PairingBaseGasFinal + uint64(len(input)/384)*params.Bls12381PairingPerPairGasFinal
+	}
 	return params.Bls12381PairingBaseGas + uint64(len(input)/384)*params.Bls12381PairingPerPairGas
 }
 
@@ -995,10 +1061,15 @@ func decodeBLS12381FieldElement(in []byte) ([]byte, error) {
 }
 
 // bls12381MapG1 implements EIP-2537 MapG1 precompile.
-type bls12381MapG1 struct{}
+type bls12381MapG1 struct {
+	final bool // Priced as in the final EIP-2537
+}
 
 // RequiredGas returns the gas required to execute the pre-compiled contract.
 func (c *bls12381MapG1) RequiredGas(input []byte) uint64 {
+	if c.final {
+		return params.Bls12381MapG1GasFinal
+	}
 	return params.Bls12381MapG1Gas
 }
 
@@ -1030,10 +1101,15 @@ func (c *bls12381MapG1) Run(input []byte) ([]byte, error) {
 }
 
 // bls12381MapG2 implements EIP-2537 MapG2 precompile.
-type bls12381MapG2 struct{}
+type bls12381MapG2 struct {
+	final bool // Priced as in the final EIP-2537
+}
 
 // RequiredGas returns the gas required to execute the pre-compiled contract.
 func (c *bls12381MapG2) RequiredGas(input []byte) uint64 {
+	if c.final {
+		return params.Bls12381MapG2GasFinal
+	}
 	return params.Bls12381MapG2Gas
 }
 
@@ -21,10 +21,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 // precompiledTest defines the input/output pairs for precompiled contract tests.
@@ -395,3 +397,118 @@ func BenchmarkPrecompiledBLS12381G2MultiExpWorstCase(b *testing.B) {
 	}
 	benchmarkPrecompiled("0f", testcase, b)
 }
+
+func TestPrecompiledContractsOasysBLS(t *testing.T) {
+	// The Cancun precompiles are kept as they are
+	for addr, p := range PrecompiledContractsCancun {
+		if have := PrecompiledContractsOasysBLS[addr]; fmt.Sprintf("%T", have) != fmt.Sprintf("%T", p) {
+			t.Errorf("precompile %x mismatch: have %T, want %T", addr, have, p)
+		}
+	}
+	// The BLS12-381 precompiles of the final EIP-2537 follow them
+	for i, p := range []PrecompiledContract{
+		&bls12381G1Add{final: true}, &bls12381G1MultiExp{final: true},
+		&bls12381G2Add{final: true}, &bls12381G2MultiExp{final: true},
+		&bls12381Pairing{final: true}, &bls12381MapG1{final: true}, &bls12381MapG2{final: true},
+	} {
+		addr := common.BytesToAddress([]byte{byte(0x0b + i)})
+		if have := PrecompiledContractsOasysBLS[addr]; !reflect.DeepEqual(have, p) {
+			t.Errorf("precompile %x mismatch: have %#v, want %#v", addr, have, p)
+		}
+	}
+	if have, want := len(PrecompiledContractsOasysBLS), len(PrecompiledContractsCancun)+7; have != want {
+		t.Errorf("precompile count mismatch: have %d, want %d", have, want)
+	}
+	if have := len(ActivePrecompiles(params.Rules{IsCancun: true, IsOasysBLS12381: true})); have != len(PrecompiledContractsOasysBLS) {
+		t.Errorf("active precompile count mismatch: have %d, want %d", have, len(PrecompiledContractsOasysBLS))
+	}
+}
+
+func TestPrecompiledOasysBLSGas(t *testing.T) {
+	precompile := func(addr byte) PrecompiledContract {
+		return PrecompiledContractsOasysBLS[common.BytesToAddress([]byte{addr})]
+	}
+	for _, tt := range []struct {
+		addr  byte
+		input int
+		gas   uint64
+	}{
+		{0x0b, 256, 375},
+		{0x0c, 160, 12000},
+		{0x0c, 2 * 160, 22776},
+		{0x0c, 128 * 160, 797184},
+		{0x0c, 200 * 160, 1245600},
+		{0x0d, 512, 600},
+		{0x0e, 288, 22500},
+		{0x0e, 2 * 288, 45000},
+		{0x0e, 200 * 288, 2358000},
+		{0x0f, 384, 70300},
+		{0x0f, 2 * 384, 102900},
+		{0x10, 64, 5500},
+		{0x11, 128, 23800},
+	} {
+		if have := precompile(tt.addr).RequiredGas(make([]byte, tt.input)); have != tt.gas {
+			t.Errorf("precompile %x with %d bytes: gas mismatch, have %d, want %d", tt.addr, tt.input, have, tt.gas)
+		}
+	}
+}
+
+func TestPrecompiledOasysBLSMultiExp(t *testing.T) {
+	// The results are the same as the earlier draft
+	for _, tt := range []struct {
+		name    string
+		final   PrecompiledContract
+		vectors string
+	}{
+		{"G1", &bls12381G1MultiExp{final: true}, "blsG1MultiExp"},
+		{"G2", &bls12381G2MultiExp{final: true}, "blsG2MultiExp"},
+	} {
+		tests, err := loadJson(tt.vectors)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, test := range tests {
+			in := common.Hex2Bytes(test.Input)
+			if res, err := tt.final.Run(in); err != nil {
+				t.Errorf("%s %s: %v", tt.name, test.Name, err)
+			} else if common.Bytes2Hex(res) != test.Expected {
+				t.Errorf("%s %s: result mismatch, have %x, want %s", tt.name, test.Name, res, test.Expected)
+			}
+		}
+	}
+
+	// but the points out of the subgroup are rejected, taken from the pairs of
+	// the failing pairing vectors
+	fails, err := loadJsonFail("blsPairing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scalar := common.LeftPadBytes([]byte{0x02}, 32)
+	for _, fail := range fails {
+		in := common.Hex2Bytes(fail.Input)
+		switch fail.Name {
+		case "bls_pairing_g1_not_in_correct_subgroup":
+			var input []byte
+			for i := 0; i < len(in)/384; i++ {
+				input = append(append(input, in[384*i:384*i+128]...), scalar...)
+			}
+			if _, err := (&bls12381G1MultiExp{}).Run(input); err != nil {
+				t.Errorf("draft G1: %v", err)
+			}
+			if _, err := (&bls12381G1MultiExp{final: true}).Run(input); err != errBLS12381G1PointSubgroup {
+				t.Errorf("final G1: error mismatch, have %v, want %v", err, errBLS12381G1PointSubgroup)
+			}
+		case "bls_pairing_g2_not_in_correct_subgroup":
+			var input []byte
+			for i := 0; i < len(in)/384; i++ {
+				input = append(append(input, in[384*i+128:384*(i+1)]...), scalar...)
+			}
+			if _, err := (&bls12381G2MultiExp{}).Run(input); err != nil {
+				t.Errorf("draft G2: %v", err)
+			}
+			if _, err := (&bls12381G2MultiExp{final: true}).Run(input); err != errBLS12381G2PointSubgroup {
+				t.Errorf("final G2: error mismatch, have %v, want %v", err, errBLS12381G2PointSubgroup)
+			}
+		}
+	}
+}
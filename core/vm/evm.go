@@ -40,6 +40,8 @@ type (
 func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
 	var precompiles map[common.Address]PrecompiledContract
 	switch {
+	case evm.chainRules.IsOasysBLS12381:
+		precompiles = PrecompiledContractsOasysBLS
 	case evm.chainRules.IsCancun:
 		precompiles = PrecompiledContractsCancun
 	case evm.chainRules.IsBerlin:
@@ -443,7 +445,16 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	// because this check targets raw transactions from EOA, and `CREATE2`
 	// within internal transactions is excluded.
 	// Need to check after nonce increment to evict failed tx from the pool.
-	if evm.depth == 0 && typ == CREATE && !IsAllowedToCreate(evm.StateDB, caller.Address()) {
+	// Since the deployer allow list fork, the creations at any depth are checked,
+	// and the internal ones are reverted with the reason, leaving the gas.
+	if evm.chainRules.IsOasysDeployerAllowList {
+		if !IsAllowedDeployer(evm.chainConfig, evm.StateDB, caller.Address()) {
+			if evm.depth == 0 {
+				return nil, common.Address{}, 0, ErrUnauthorizedCreate
+			}
+			return unauthorizedCreateRevert(caller.Address()), common.Address{}, gas, ErrExecutionReverted
+		}
+	} else if evm.depth == 0 && typ == CREATE && !IsAllowedToCreate(evm.StateDB, caller.Address()) {
 		return nil, common.Address{}, 0, ErrUnauthorizedCreate
 	}
 	// We add this to the access list _before_ taking a snapshot. Even if the creation fails,
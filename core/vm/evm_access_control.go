@@ -3,11 +3,13 @@ package vm
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/contracts/oasys"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 var (
@@ -21,6 +23,9 @@ var (
 	emptyHash        common.Hash
 	buildInPrefix1   = common.FromHex(oasys.BuiltInContractPrefix1)
 	buildInPrefix2   = common.FromHex(oasys.BuiltInContractPrefix2)
+
+	// revertSelector is the selector of `Error(string)`, the revert reason.
+	revertSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
 )
 
 // Check the `_createAllowedList` mappings in the `EVMAccessControl` contract
@@ -30,10 +35,27 @@ func IsAllowedToCreate(state StateDB, from common.Address) bool {
 	return val.Cmp(emptyHash) != 0
 }
 
+// IsAllowedDeployer returns whether the deployer may create contracts at any depth
+// after the deployer allow list fork. Besides the deployers in the `_createAllowedList`,
+// the built-in contracts and the bypass list of the chain config are allowed.
+func IsAllowedDeployer(config *params.ChainConfig, state StateDB, deployer common.Address) bool {
+	return isBuiltInContract(deployer) || config.Oasys.IsDeployerAllowListBypassed(deployer) || IsAllowedToCreate(state, deployer)
+}
+
+// unauthorizedCreateRevert returns the revert data of a contract creation refused
+// by the deployer allow list, encoded as `Error(string)`.
+func unauthorizedCreateRevert(deployer common.Address) []byte {
+	reason := []byte(fmt.Sprintf("%v: deployer %v is not allowed by EVMAccessControl", ErrUnauthorizedCreate, deployer))
+	data := append([]byte{}, revertSelector...)
+	data = append(data, common.LeftPadBytes(big.NewInt(32).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(int64(len(reason))).Bytes(), 32)...)
+	return append(data, common.RightPadBytes(reason, (len(reason)+31)/32*32)...)
+}
+
 // Check the `_callAllowedList` mappings in the `EVMAccessControl` contract
 func IsDeniedToCall(state StateDB, to common.Address) bool {
 	// Don't deny calls to built-in contracts
-	if bytes.HasPrefix(to.Bytes(), buildInPrefix1) || bytes.HasPrefix(to.Bytes(), buildInPrefix2) {
+	if isBuiltInContract(to) {
 		return false
 	}
 	hash := computeAddressMapStorageKey(to, 2)
@@ -41,6 +63,10 @@ func IsDeniedToCall(state StateDB, to common.Address) bool {
 	return val.Cmp(emptyHash) != 0
 }
 
+func isBuiltInContract(addr common.Address) bool {
+	return bytes.HasPrefix(addr.Bytes(), buildInPrefix1) || bytes.HasPrefix(addr.Bytes(), buildInPrefix2)
+}
+
 func computeAddressMapStorageKey(address common.Address, slot uint64) common.Hash {
 	paddedAddress := common.LeftPadBytes(address.Bytes(), 32)
 	paddedSlot := common.LeftPadBytes(big.NewInt(int64(slot)).Bytes(), 32)
@@ -0,0 +1,88 @@
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+func TestDeployerAllowList(t *testing.T) {
+	var (
+		allowed  = common.Address{0x01}
+		denied   = common.Address{0x02}
+		bypassed = common.Address{0x03}
+		factory  = common.Address{0x04}
+
+		// Creates an empty contract, and returns the return data of the creation
+		factoryCode = []byte{
+			byte(PUSH1), 0x0, byte(DUP1), byte(DUP1), byte(CREATE), byte(POP),
+			byte(RETURNDATASIZE), byte(PUSH1), 0x0, byte(PUSH1), 0x0, byte(RETURNDATACOPY),
+			byte(RETURNDATASIZE), byte(PUSH1), 0x0, byte(RETURN),
+		}
+	)
+	newEVM := func(fork *big.Int) *EVM {
+		config := *params.TestChainConfig
+		config.Oasys = &params.OasysConfig{
+			Period:                  1,
+			Epoch:                   20,
+			DeployerAllowListBlock:  fork,
+			DeployerAllowListBypass: []common.Address{bypassed},
+		}
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.SetState(evmAccessControl, computeAddressMapStorageKey(allowed, 1), common.Hash{0x01})
+		statedb.SetCode(factory, factoryCode)
+		vmctx := BlockContext{
+			CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+			BlockNumber: big.NewInt(1),
+		}
+		return NewEVM(vmctx, TxContext{}, statedb, &config, Config{})
+	}
+	create := func(evm *EVM, deployer common.Address) error {
+		_, _, _, err := evm.Create(AccountRef(deployer), nil, 100000, new(uint256.Int))
+		return err
+	}
+
+	// Before the fork, only the raw creations are checked
+	evm := newEVM(nil)
+	if err := create(evm, allowed); err != nil {
+		t.Fatalf("allowed deployer refused: %v", err)
+	}
+	if err := create(evm, denied); !errors.Is(err, ErrUnauthorizedCreate) {
+		t.Fatalf("denied deployer not refused: %v", err)
+	}
+	if err := create(evm, bypassed); !errors.Is(err, ErrUnauthorizedCreate) {
+		t.Fatalf("bypassed deployer not refused before the fork: %v", err)
+	}
+	ret, _, err := evm.Call(AccountRef(denied), factory, nil, 100000, new(uint256.Int))
+	if err != nil || len(ret) != 0 {
+		t.Fatalf("internal creation refused before the fork: %x, %v", ret, err)
+	}
+
+	// After the fork, the internal creations are checked as well
+	evm = newEVM(common.Big0)
+	if err := create(evm, allowed); err != nil {
+		t.Fatalf("allowed deployer refused: %v", err)
+	}
+	if err := create(evm, denied); !errors.Is(err, ErrUnauthorizedCreate) {
+		t.Fatalf("denied deployer not refused: %v", err)
+	}
+	if err := create(evm, bypassed); err != nil {
+		t.Fatalf("bypassed deployer refused: %v", err)
+	}
+	ret, _, err = evm.Call(AccountRef(allowed), factory, nil, 100000, new(uint256.Int))
+	if err != nil {
+		t.Fatalf("failed to call factory: %v", err)
+	}
+	if want := unauthorizedCreateRevert(factory); !bytes.Equal(ret, want) {
+		t.Fatalf("revert reason mismatch: have %x, want %x", ret, want)
+	}
+}
@@ -2,6 +2,7 @@ package vote
 
 import (
 	"encoding/json"
+	"os"
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/tidwall/wal"
@@ -26,11 +27,13 @@ type VoteJournal struct {
 
 var voteJournalErrorCounter = metrics.NewRegisteredCounter("voteJournal/error", nil)
 
+var walOptions = &wal.Options{
+	LogFormat:        wal.JSON,
+	SegmentCacheSize: maxSizeOfRecentEntry,
+}
+
 func NewVoteJournal(filePath string) (*VoteJournal, error) {
-	walLog, err := wal.Open(filePath, &wal.Options{
-		LogFormat:        wal.JSON,
-		SegmentCacheSize: maxSizeOfRecentEntry,
-	})
+	walLog, err := wal.Open(filePath, walOptions)
 	if err != nil {
 		log.Error("Failed to open vote journal", "err", err)
 		return nil, err
@@ -123,3 +126,66 @@ func (journal *VoteJournal) ReadVote(index uint64) (*types.VoteEnvelope, error)
 
 	return vote, nil
 }
+
+// TruncateAfter removes the votes whose target number is greater than the given
+// number from the journal. It is used when the local chain is rolled back.
+func (journal *VoteJournal) TruncateAfter(number uint64) error {
+	walLog := journal.walLog
+
+	firstIndex, err := walLog.FirstIndex()
+	if err != nil {
+		return err
+	}
+	lastIndex, err := walLog.LastIndex()
+	if err != nil {
+		return err
+	}
+	if lastIndex == 0 {
+		return nil // empty journal
+	}
+
+	// Find the last vote to keep
+	keep := lastIndex
+	for ; keep >= firstIndex && keep > 0; keep-- {
+		vote, err := journal.ReadVote(keep)
+		if err != nil {
+			return err
+		}
+		if vote != nil && vote.Data.TargetNumber <= number {
+			break
+		}
+	}
+
+	switch {
+	case keep == lastIndex:
+		// nothing to truncate
+	case keep < firstIndex || keep == 0:
+		// The wal can not be emptied by truncation, so recreate it.
+		if err := walLog.Close(); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(journal.journalPath); err != nil {
+			return err
+		}
+		if journal.walLog, err = wal.Open(journal.journalPath, walOptions); err != nil {
+			return err
+		}
+	default:
+		if err := walLog.TruncateBack(keep); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range journal.voteDataBuffer.Keys() {
+		if key.(uint64) > number {
+			journal.voteDataBuffer.Remove(key)
+		}
+	}
+	log.Info("Truncated vote journal", "number", number, "removed", lastIndex-keep)
+	return nil
+}
+
+// Close closes the underlying journal file.
+func (journal *VoteJournal) Close() error {
+	return journal.walLog.Close()
+}
@@ -140,6 +140,10 @@ func (voteManager *VoteManager) loop() {
 
 			curHead := cHead.Header
 			if o, ok := voteManager.engine.(*oasys.Oasys); ok {
+				if !o.SyncStateVerified() {
+					log.Warn("skip voting because the synced state is not verified", "curHead", curHead.Number)
+					continue
+				}
 				nextBlockMinedTime := time.Unix(int64((curHead.Time + o.Period(voteManager.chain, curHead))), 0)
 				timeForBroadcast := 50 * time.Millisecond // enough to broadcast a vote
 				if time.Now().Add(timeForBroadcast).After(nextBlockMinedTime) {
@@ -214,6 +218,11 @@ func (voteManager *VoteManager) loop() {
 	}
 }
 
+// VoteAddress returns the BLS public key of the local vote signer.
+func (voteManager *VoteManager) VoteAddress() types.BLSPublicKey {
+	return voteManager.signer.PubKey
+}
+
 // UnderRules checks if the produced header under the following rules:
 // A validator must not publish two distinct votes for the same height. (Rule 1)
 // A validator must not vote within the span of its other votes . (Rule 2)
@@ -31,7 +31,8 @@ var (
 	localCurVotesCounter    = metrics.NewRegisteredCounter("curVotes/local", nil)
 	localFutureVotesCounter = metrics.NewRegisteredCounter("futureVotes/local", nil)
 
-	localReceivedVotesGauge = metrics.NewRegisteredGauge("receivedVotes/local", nil)
+	localReceivedVotesGauge  = metrics.NewRegisteredGauge("receivedVotes/local", nil)
+	localInvalidVotesCounter = metrics.NewRegisteredCounter("invalidVotes/local", nil)
 
 	localCurVotesPqGauge    = metrics.NewRegisteredGauge("curVotesPq/local", nil)
 	localFutureVotesPqGauge = metrics.NewRegisteredGauge("futureVotesPq/local", nil)
@@ -60,11 +61,18 @@ type VotePool struct {
 	highestVerifiedBlockCh  chan core.HighestVerifiedBlockEvent
 	highestVerifiedBlockSub event.Subscription
 
-	votesCh chan *types.VoteEnvelope
+	votesCh chan *voteRequest
 
 	engine consensus.PoS
 }
 
+// voteRequest is a vote waiting to be put into the pool, along with the
+// callback notified if its signature is invalid.
+type voteRequest struct {
+	vote      *types.VoteEnvelope
+	onInvalid func(error)
+}
+
 type votesPriorityQueue []*types.VoteData
 
 func NewVotePool(chain *core.BlockChain, engine consensus.PoS) *VotePool {
@@ -76,7 +84,7 @@ func NewVotePool(chain *core.BlockChain, engine consensus.PoS) *VotePool {
 		curVotesPq:             &votesPriorityQueue{},
 		futureVotesPq:          &votesPriorityQueue{},
 		highestVerifiedBlockCh: make(chan core.HighestVerifiedBlockEvent, highestVerifiedBlockChanSize),
-		votesCh:                make(chan *types.VoteEnvelope, voteBufferForPut),
+		votesCh:                make(chan *voteRequest, voteBufferForPut),
 		engine:                 engine,
 	}
 
@@ -104,17 +112,24 @@ func (pool *VotePool) loop() {
 			return
 
 		// Handle votes channel and put the vote into vote pool.
-		case vote := <-pool.votesCh:
-			pool.putIntoVotePool(vote)
+		case req := <-pool.votesCh:
+			pool.putIntoVotePool(req.vote, req.onInvalid)
 		}
 	}
 }
 
 func (pool *VotePool) PutVote(vote *types.VoteEnvelope) {
-	pool.votesCh <- vote
+	pool.votesCh <- &voteRequest{vote: vote}
+}
+
+// PutPeerVote puts a vote received from a remote peer into the pool. The
+// onInvalid callback is invoked from the pool's loop if the vote signature
+// fails the verification, so that the sender can be penalised.
+func (pool *VotePool) PutPeerVote(vote *types.VoteEnvelope, onInvalid func(error)) {
+	pool.votesCh <- &voteRequest{vote: vote, onInvalid: onInvalid}
 }
 
-func (pool *VotePool) putIntoVotePool(vote *types.VoteEnvelope) bool {
+func (pool *VotePool) putIntoVotePool(vote *types.VoteEnvelope, onInvalid func(error)) bool {
 	targetNumber := vote.Data.TargetNumber
 	targetHash := vote.Data.TargetHash
 	header := pool.chain.CurrentBlock()
@@ -146,7 +161,7 @@ func (pool *VotePool) putIntoVotePool(vote *types.VoteEnvelope) bool {
 	}
 
 	voteHash := vote.Hash()
-	if ok := pool.basicVerify(vote, headNumber, votes, isFutureVote, voteHash); !ok {
+	if ok := pool.basicVerify(vote, headNumber, votes, isFutureVote, voteHash, onInvalid); !ok {
 		return false
 	}
 
@@ -322,6 +337,21 @@ func (pool *VotePool) GetVotes() []*types.VoteEnvelope {
 	return votesRes
 }
 
+// Stats returns the number of the current and the future votes in the pool.
+func (pool *VotePool) Stats() (int, int) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	var cur, future int
+	for _, voteBox := range pool.curVotes {
+		cur += len(voteBox.voteMessages)
+	}
+	for _, voteBox := range pool.futureVotes {
+		future += len(voteBox.voteMessages)
+	}
+	return cur, future
+}
+
 func (pool *VotePool) FetchVoteByBlockHash(blockHash common.Hash) []*types.VoteEnvelope {
 	pool.mu.RLock()
 	defer pool.mu.RUnlock()
@@ -331,7 +361,7 @@ func (pool *VotePool) FetchVoteByBlockHash(blockHash common.Hash) []*types.VoteE
 	return nil
 }
 
-func (pool *VotePool) basicVerify(vote *types.VoteEnvelope, headNumber uint64, m map[common.Hash]*VoteBox, isFutureVote bool, voteHash common.Hash) bool {
+func (pool *VotePool) basicVerify(vote *types.VoteEnvelope, headNumber uint64, m map[common.Hash]*VoteBox, isFutureVote bool, voteHash common.Hash, onInvalid func(error)) bool {
 	targetHash := vote.Data.TargetHash
 	pool.mu.RLock()
 	defer pool.mu.RUnlock()
@@ -356,7 +386,11 @@ func (pool *VotePool) basicVerify(vote *types.VoteEnvelope, headNumber uint64, m
 
 	// Verify bls signature.
 	if err := vote.Verify(); err != nil {
+		localInvalidVotesCounter.Inc(1)
 		log.Error("Failed to verify voteMessage", "err", err)
+		if onInvalid != nil {
+			onInvalid(err)
+		}
 		return false
 	}
 
@@ -24,6 +24,8 @@ import (
 	"os"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -141,3 +143,41 @@ func (api *AdminAPI) ImportChain(file string) (bool, error) {
 	}
 	return true, nil
 }
+
+// FinalityBreaker returns the state of the finality breaker.
+func (api *AdminAPI) FinalityBreaker() (*FinalityBreakerStatus, error) {
+	if api.eth.finalityBreaker == nil {
+		return nil, errors.New("engine is not Oasys type")
+	}
+	return api.eth.finalityBreaker.status(), nil
+}
+
+// SetFinalityBreaker sets the number of the blocks without a justified block to
+// trip the finality breaker, zero disables it.
+func (api *AdminAPI) SetFinalityBreaker(limit hexutil.Uint64) (*FinalityBreakerStatus, error) {
+	if api.eth.finalityBreaker == nil {
+		return nil, errors.New("engine is not Oasys type")
+	}
+	api.eth.finalityBreaker.setLimit(uint64(limit))
+	return api.eth.finalityBreaker.status(), nil
+}
+
+// ReleaseFinalityBreaker resumes serving the actual latest and safe blocks while
+// the finality still stalls, until it recovers and stalls again.
+func (api *AdminAPI) ReleaseFinalityBreaker() (*FinalityBreakerStatus, error) {
+	if api.eth.finalityBreaker == nil {
+		return nil, errors.New("engine is not Oasys type")
+	}
+	api.eth.finalityBreaker.release()
+	return api.eth.finalityBreaker.status(), nil
+}
+
+// ReloadEngineConfig applies the reloadable configuration to the running Oasys
+// engine without restart, and returns the names of the fields applied.
+func (api *AdminAPI) ReloadEngineConfig(cfg oasys.ReloadConfig) ([]string, error) {
+	engine, ok := api.eth.engine.(*oasys.Oasys)
+	if !ok {
+		return nil, errors.New("engine is not Oasys type")
+	}
+	return engine.Reload(&cfg)
+}
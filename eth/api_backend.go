@@ -27,6 +27,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -38,6 +39,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -60,9 +62,24 @@ func (b *EthAPIBackend) CurrentBlock() *types.Header {
 	return b.eth.blockchain.CurrentBlock()
 }
 
+func (b *EthAPIBackend) CurrentSafeBlock() *types.Header {
+	return b.eth.blockchain.CurrentSafeBlock()
+}
+
+func (b *EthAPIBackend) CurrentFinalBlock() *types.Header {
+	return b.eth.blockchain.CurrentFinalBlock()
+}
+
 func (b *EthAPIBackend) SetHead(number uint64) {
 	b.eth.handler.downloader.Cancel()
 	b.eth.blockchain.SetHead(number)
+
+	// Drop the consensus state that refers to the rolled-back blocks
+	if o, ok := b.eth.engine.(*oasys.Oasys); ok {
+		if err := o.Rewind(number); err != nil {
+			log.Error("Failed to rewind oasys consensus state", "number", number, "err", err)
+		}
+	}
 }
 
 func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
@@ -76,7 +93,7 @@ func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumb
 	}
 	// Otherwise resolve and return the block
 	if number == rpc.LatestBlockNumber {
-		return b.eth.blockchain.CurrentBlock(), nil
+		return b.latestHeader(), nil
 	}
 	if number == rpc.FinalizedBlockNumber {
 		block := b.eth.blockchain.CurrentFinalBlock()
@@ -86,7 +103,7 @@ func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumb
 		return block, nil
 	}
 	if number == rpc.SafeBlockNumber {
-		block := b.eth.blockchain.CurrentSafeBlock()
+		block := b.safeHeader()
 		if block == nil {
 			return nil, errors.New("safe block not found")
 		}
@@ -95,6 +112,53 @@ func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumb
 	return b.eth.blockchain.GetHeaderByNumber(uint64(number)), nil
 }
 
+// latestHeader returns the header served as the latest block. In the deferred
+// finality mode, it's the justified block or the block with the configured
+// confirmations, whichever is newer. It doesn't advance beyond the head frozen
+// by the finality breaker while the finality stalls.
+func (b *EthAPIBackend) latestHeader() *types.Header {
+	header := b.deferredHeader()
+	if b.eth.finalityBreaker != nil {
+		if latest, _ := b.eth.finalityBreaker.frozen(); latest != nil && header.Number.Cmp(latest.Number) > 0 {
+			return latest
+		}
+	}
+	return header
+}
+
+// safeHeader returns the header served as the safe block, which doesn't advance
+// beyond the one frozen by the finality breaker while the finality stalls.
+func (b *EthAPIBackend) safeHeader() *types.Header {
+	safe := b.eth.blockchain.CurrentSafeBlock()
+	if safe != nil && b.eth.finalityBreaker != nil {
+		if _, frozen := b.eth.finalityBreaker.frozen(); frozen != nil && safe.Number.Cmp(frozen.Number) > 0 {
+			return frozen
+		}
+	}
+	return safe
+}
+
+// deferredHeader returns the latest block of the deferred finality mode, or the
+// head if the mode is disabled.
+func (b *EthAPIBackend) deferredHeader() *types.Header {
+	head := b.eth.blockchain.CurrentBlock()
+	confirmations := b.eth.config.FinalityConfirmations
+	if confirmations == 0 {
+		return head
+	}
+	var number uint64
+	if head.Number.Uint64() > confirmations {
+		number = head.Number.Uint64() - confirmations
+	}
+	if safe := b.eth.blockchain.CurrentSafeBlock(); safe != nil && safe.Number.Uint64() > number && safe.Number.Cmp(head.Number) <= 0 {
+		return safe
+	}
+	if header := b.eth.blockchain.GetHeaderByNumber(number); header != nil {
+		return header
+	}
+	return head
+}
+
 func (b *EthAPIBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
 	if blockNr, ok := blockNrOrHash.Number(); ok {
 		return b.HeaderByNumber(ctx, blockNr)
@@ -127,7 +191,7 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumbe
 	}
 	// Otherwise resolve and return the block
 	if number == rpc.LatestBlockNumber {
-		header := b.eth.blockchain.CurrentBlock()
+		header := b.latestHeader()
 		return b.eth.blockchain.GetBlock(header.Hash(), header.Number.Uint64()), nil
 	}
 	if number == rpc.FinalizedBlockNumber {
@@ -138,7 +202,7 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumbe
 		return b.eth.blockchain.GetBlock(header.Hash(), header.Number.Uint64()), nil
 	}
 	if number == rpc.SafeBlockNumber {
-		header := b.eth.blockchain.CurrentSafeBlock()
+		header := b.safeHeader()
 		if header == nil {
 			return nil, errors.New("safe block not found")
 		}
@@ -339,7 +403,11 @@ func (b *EthAPIBackend) GetTransaction(ctx context.Context, txHash common.Hash)
 }
 
 func (b *EthAPIBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
-	return b.eth.txPool.Nonce(addr), nil
+	nonce := b.eth.txPool.Nonce(addr)
+	if engine, ok := b.eth.engine.(*oasys.Oasys); ok {
+		nonce = engine.PendingNonce(b.eth.blockchain.CurrentHeader(), addr, nonce)
+	}
+	return nonce, nil
 }
 
 func (b *EthAPIBackend) Stats() (runnable int, blocked int) {
@@ -0,0 +1,60 @@
+package eth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestDeferredFinality(t *testing.T) {
+	genesis := &core.Genesis{Config: params.TestChainConfig, Alloc: types.GenesisAlloc{}}
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 10, nil)
+
+	tests := []struct {
+		confirmations uint64
+		justified     uint64
+		want          uint64
+	}{
+		{0, 2, 10},  // Disabled
+		{3, 2, 7},   // The confirmed block is newer
+		{3, 8, 8},   // The justified block is newer
+		{20, 0, 0},  // Not enough blocks to confirm
+		{20, 4, 4},  // Not enough blocks, but justified
+		{1, 10, 10}, // The head is justified
+	}
+	for i, tt := range tests {
+		engine := &testFinalityEngine{Ethash: ethash.NewFaker(), justified: tt.justified}
+		chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("test %d: failed to create chain: %v", i, err)
+		}
+		if _, err := chain.InsertChain(blocks); err != nil {
+			t.Fatalf("test %d: failed to insert chain: %v", i, err)
+		}
+		backend := &EthAPIBackend{eth: &Ethereum{blockchain: chain, config: &ethconfig.Config{FinalityConfirmations: tt.confirmations}}}
+
+		header, err := backend.HeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+		if err != nil {
+			t.Fatalf("test %d: failed to get header: %v", i, err)
+		}
+		if have := header.Number.Uint64(); have != tt.want {
+			t.Errorf("test %d: latest header mismatch: have %d, want %d", i, have, tt.want)
+		}
+		block, err := backend.BlockByNumber(context.Background(), rpc.LatestBlockNumber)
+		if err != nil {
+			t.Fatalf("test %d: failed to get block: %v", i, err)
+		}
+		if block.Hash() != header.Hash() {
+			t.Errorf("test %d: latest block mismatch: have %x, want %x", i, block.Hash(), header.Hash())
+		}
+		chain.Stop()
+	}
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -443,3 +444,18 @@ func (api *DebugAPI) GetTrieFlushInterval() (string, error) {
 	}
 	return api.eth.blockchain.GetTrieFlushInterval().String(), nil
 }
+
+// GetConsensusState dumps the engine-internal state of the Oasys consensus for
+// debugging the stuck validators without restarting them.
+func (api *DebugAPI) GetConsensusState() (*oasys.ConsensusState, error) {
+	engine, ok := api.eth.engine.(*oasys.Oasys)
+	if !ok {
+		return nil, errors.New("not the oasys consensus engine")
+	}
+	state := engine.ConsensusState()
+	if api.eth.votePool != nil {
+		cur, future := api.eth.votePool.Stats()
+		state.VotePool = &oasys.VotePoolState{Current: cur, Future: future}
+	}
+	return state, nil
+}
@@ -49,6 +49,24 @@ func (api *MinerAPI) Stop() {
 	api.e.StopMining()
 }
 
+// PauseSealing stops proposing new blocks without stopping the miner, so that
+// the node keeps voting for finality during maintenance.
+func (api *MinerAPI) PauseSealing() bool {
+	api.e.Miner().PauseSealing()
+	return true
+}
+
+// ResumeSealing resumes proposing new blocks paused by PauseSealing.
+func (api *MinerAPI) ResumeSealing() bool {
+	api.e.Miner().ResumeSealing()
+	return true
+}
+
+// SealingPaused returns whether proposing new blocks is paused.
+func (api *MinerAPI) SealingPaused() bool {
+	return api.e.Miner().SealingPaused()
+}
+
 // SetExtra sets the extra data string that is included when this miner mines a block.
 func (api *MinerAPI) SetExtra(extra string) (bool, error) {
 	if err := api.e.Miner().SetExtra([]byte(extra)); err != nil {
@@ -0,0 +1,155 @@
+package eth
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// OasysAPI provides the Oasys specific APIs which require the full node.
+type OasysAPI struct {
+	e      *Ethereum
+	engine *oasys.Oasys
+}
+
+// NewOasysAPI creates a new OasysAPI instance.
+func NewOasysAPI(e *Ethereum, engine *oasys.Oasys) *OasysAPI {
+	return &OasysAPI{e: e, engine: engine}
+}
+
+// SystemTransaction is a transaction injected by the engine into the block.
+type SystemTransaction struct {
+	Hash    common.Hash    `json:"hash"`
+	To      common.Address `json:"to"`
+	Data    hexutil.Bytes  `json:"data"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Status  hexutil.Uint64 `json:"status"`
+}
+
+// BlockTemplate is the block the local validator would produce on top of the
+// current head, if it was sealed now.
+type BlockTemplate struct {
+	Number             hexutil.Uint64           `json:"number"`
+	ParentHash         common.Hash              `json:"parentHash"`
+	Coinbase           common.Address           `json:"miner"`
+	Difficulty         *hexutil.Big             `json:"difficulty"`
+	Timestamp          hexutil.Uint64           `json:"timestamp"`
+	BackOffTime        hexutil.Uint64           `json:"backOffTime"` // Seconds added to the block period
+	GasLimit           hexutil.Uint64           `json:"gasLimit"`
+	GasTarget          hexutil.Uint64           `json:"gasTarget"`
+	GasUsed            hexutil.Uint64           `json:"gasUsed"`
+	BaseFee            *hexutil.Big             `json:"baseFeePerGas,omitempty"`
+	Extra              hexutil.Bytes            `json:"extraData"`
+	Transactions       hexutil.Uint64           `json:"transactions"` // Number of the user transactions
+	SystemTransactions []*SystemTransaction     `json:"systemTransactions"`
+	SystemTxFailures   []*oasys.SystemTxFailure `json:"systemTransactionFailures"`
+	VoteAttestation    *types.VoteAttestation   `json:"voteAttestation"`
+}
+
+// BuildBlockTemplate runs the block production pipeline on top of the current
+// head without sealing, and returns the resulting block, so that the operator
+// can check that the validator would produce a valid block before its turn.
+func (api *OasysAPI) BuildBlockTemplate() (*BlockTemplate, error) {
+	block, receipts, err := api.e.Miner().BuildBlockTemplate()
+	if err != nil {
+		return nil, err
+	}
+	header := block.Header()
+	if len(receipts) != len(block.Transactions()) {
+		return nil, errors.New("mismatching receipts")
+	}
+	chain := api.e.BlockChain()
+	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return nil, errors.New("missing parent")
+	}
+
+	template := &BlockTemplate{
+		Number:             hexutil.Uint64(header.Number.Uint64()),
+		ParentHash:         header.ParentHash,
+		Coinbase:           header.Coinbase,
+		Difficulty:         (*hexutil.Big)(header.Difficulty),
+		Timestamp:          hexutil.Uint64(header.Time),
+		GasLimit:           hexutil.Uint64(header.GasLimit),
+		GasTarget:          hexutil.Uint64(header.GasLimit / chain.Config().ElasticityMultiplier()),
+		GasUsed:            hexutil.Uint64(header.GasUsed),
+		BaseFee:            (*hexutil.Big)(header.BaseFee),
+		Extra:              header.Extra,
+		SystemTransactions: make([]*SystemTransaction, 0),
+	}
+	backOff, err := api.engine.BackOffTime(chain, header, header.Coinbase)
+	if err != nil {
+		return nil, err
+	}
+	template.BackOffTime = hexutil.Uint64(backOff)
+	for i, tx := range block.Transactions() {
+		if system, err := api.engine.IsSystemTransaction(tx, header); err != nil || !system {
+			template.Transactions++
+			continue
+		}
+		template.SystemTransactions = append(template.SystemTransactions, &SystemTransaction{
+			Hash:    tx.Hash(),
+			To:      *tx.To(),
+			Data:    tx.Data(),
+			GasUsed: hexutil.Uint64(receipts[i].GasUsed),
+			Status:  hexutil.Uint64(receipts[i].Status),
+		})
+	}
+	if template.SystemTxFailures = api.engine.SystemTxFailures(header); template.SystemTxFailures == nil {
+		template.SystemTxFailures = make([]*oasys.SystemTxFailure, 0)
+	}
+	if template.VoteAttestation, err = api.engine.PreviewVoteAttestation(chain, header); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// InclusionStats returns per validator whether the proposers included the
+// pending transactions paying at least the gas price floor, since the node
+// started. It requires the inclusion tracking enabled by --oasys.inclusion-floor.
+func (api *OasysAPI) InclusionStats() (map[common.Address]*InclusionStats, error) {
+	if api.e.inclusionTracker == nil {
+		return nil, errors.New("inclusion tracking is disabled")
+	}
+	return api.e.inclusionTracker.statsByValidator(), nil
+}
+
+// OasysChainConfig is the Oasys forks scheduled on the chain, along with their
+// activation at the current head.
+type OasysChainConfig struct {
+	ChainID *hexutil.Big              `json:"chainId"`
+	Number  hexutil.Uint64            `json:"number"`
+	Hash    common.Hash               `json:"hash"`
+	Epoch   hexutil.Uint64            `json:"epoch"`
+	Forks   []*params.OasysForkStatus `json:"forks"`
+}
+
+// ChainConfig returns the Oasys forks scheduled on the chain and whether they
+// are activated at the current head, so that the fork readiness can be compared
+// across the validators.
+func (api *OasysAPI) ChainConfig() (*OasysChainConfig, error) {
+	var (
+		chain  = api.e.BlockChain()
+		config = chain.Config()
+		head   = chain.CurrentHeader()
+	)
+	epoch, err := api.engine.Epoch(chain, head)
+	if err != nil {
+		return nil, err
+	}
+	forks := config.OasysForkStatuses(head.Number.Uint64(), epoch)
+	if forks == nil {
+		forks = make([]*params.OasysForkStatus, 0)
+	}
+	return &OasysChainConfig{
+		ChainID: (*hexutil.Big)(config.ChainID),
+		Number:  hexutil.Uint64(head.Number.Uint64()),
+		Hash:    head.Hash(),
+		Epoch:   hexutil.Uint64(epoch),
+		Forks:   forks,
+	}, nil
+}
@@ -18,6 +18,7 @@
 package eth
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -48,6 +49,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/protocols/bsc"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
@@ -78,11 +80,21 @@ type Ethereum struct {
 	handler             *handler
 	ethDialCandidates   enode.Iterator
 	snapDialCandidates  enode.Iterator
+	validatorMesh       *validatorMesh
+	jailWatcher         *jailWatcher
+	standby             *standby
+	shadowFork          *shadowFork
+	cachePruner         *cachePruner
+	epochEventEmitter   *epochEventEmitter
+	finalityBreaker     *finalityBreaker
+	inclusionTracker    *inclusionTracker
+	epochWebhook        *epochWebhook
 	emptyDialCandidates enode.Iterator
 	merger              *consensus.Merger
 
 	// DB interfaces
-	chainDb ethdb.Database // Block chain database
+	chainDb     ethdb.Database // Block chain database
+	consensusDb ethdb.Database // Consensus snapshot database, same as chainDb unless separated
 
 	eventMux       *event.TypeMux
 	engine         consensus.Engine
@@ -107,7 +119,8 @@ type Ethereum struct {
 
 	shutdownTracker *shutdowncheck.ShutdownTracker // Tracks if and when the node has shutdown ungracefully
 
-	votePool *vote.VotePool
+	votePool    *vote.VotePool
+	voteManager *vote.VoteManager
 }
 
 // New creates a new Ethereum object (including the
@@ -135,8 +148,27 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	}
 	log.Info("Allocated trie memory caches", "clean", common.StorageSize(config.TrieCleanCache)*1024*1024, "dirty", common.StorageSize(config.TrieDirtyCache)*1024*1024)
 
-	// Assemble the Ethereum object
-	chainDb, err := stack.OpenDatabaseWithFreezer("chaindata", config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer, "eth/db/chaindata/", false)
+	// Assemble the Ethereum object, replaying the shadow fork into a scratch
+	// copy of the databases
+	var (
+		chainDbName     = "chaindata"
+		chainDbAncient  = config.DatabaseFreezer
+		consensusDbName = config.ConsensusDatabase
+		err             error
+	)
+	if config.ShadowForkDir != "" && stack.Config().DataDir != "" {
+		if chainDbAncient, err = copyShadowForkDatabase(stack, chainDbName, config.DatabaseFreezer, shadowForkChainDatabase); err != nil {
+			return nil, err
+		}
+		chainDbName = shadowForkChainDatabase
+		if consensusDbName != "" {
+			if _, err = copyShadowForkDatabase(stack, consensusDbName, "", shadowForkConsensusDatabase); err != nil {
+				return nil, err
+			}
+			consensusDbName = shadowForkConsensusDatabase
+		}
+	}
+	chainDb, err := stack.OpenDatabaseWithFreezer(chainDbName, config.DatabaseCache, config.DatabaseHandles, chainDbAncient, "eth/db/chaindata/", false)
 	if err != nil {
 		return nil, err
 	}
@@ -198,10 +230,30 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		log.Info("Unprotected transactions allowed")
 	}
 	ethAPI := ethapi.NewBlockChainAPI(eth.APIBackend)
-	eth.engine, err = ethconfig.CreateConsensusEngine(chainConfig, chainDb, ethAPI)
+	eth.consensusDb = chainDb
+	if consensusDbName != "" {
+		eth.consensusDb, err = stack.OpenDatabase(consensusDbName, config.ConsensusDatabaseCache, ethconfig.ConsensusDatabaseHandles, "eth/db/consensus/", false)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("Using separate consensus database", "path", stack.ResolvePath(consensusDbName), "cache", config.ConsensusDatabaseCache)
+	}
+	eth.engine, err = ethconfig.CreateConsensusEngine(chainConfig, eth.consensusDb, ethAPI)
 	if err != nil {
 		return nil, err
 	}
+	if engine, ok := eth.engine.(*oasys.Oasys); ok && config.OasysConsensusTrace != "" {
+		var traceConfig json.RawMessage
+		if config.OasysConsensusTraceJsonConfig != "" {
+			traceConfig = json.RawMessage(config.OasysConsensusTraceJsonConfig)
+		}
+		hooks, err := tracers.ConsensusDirectory.New(config.OasysConsensusTrace, traceConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tracer %s: %v", config.OasysConsensusTrace, err)
+		}
+		engine.SetHooks(hooks)
+		log.Info("Enabled consensus tracer", "name", config.OasysConsensusTrace)
+	}
 
 	bcVersion := rawdb.ReadDatabaseVersion(chainDb)
 	var dbVer = "<nil>"
@@ -234,6 +286,8 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			Preimages:           config.Preimages,
 			StateHistory:        config.StateHistory,
 			StateScheme:         scheme,
+			TxIndexFinalized:    config.TransactionHistoryFinalized,
+			ParallelTx:          config.ParallelTx,
 		}
 	)
 	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, config.Genesis, &overrides, eth.engine, vmConfig, eth.shouldPreserve, &config.TransactionHistory)
@@ -241,6 +295,35 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		return nil, err
 	}
 	eth.blockchain.ReconstructVerificationDataForHeadBlock()
+	if engine, ok := eth.engine.(*oasys.Oasys); ok {
+		if err := engine.CheckEnvironment(eth.blockchain, eth.blockchain.CurrentBlock()); err != nil {
+			log.Error("Environment value at head disagrees with the chain config, check the genesis", "err", err)
+		}
+		if err := engine.MigrateValidatorCache(eth.blockchain, eth.blockchain.CurrentBlock()); err != nil {
+			log.Warn("Failed to migrate validator cache", "err", err)
+		}
+		switch checked, err := engine.CheckShortenedBlockTime(eth.blockchain, eth.blockchain.CurrentBlock()); {
+		case err != nil && !checked:
+			log.Warn("Failed to check the readiness for the shortened block time fork", "err", err)
+		case err != nil:
+			log.Error("##################################################################")
+			log.Error("The binary is NOT ready for the shortened block time fork, upgrade", "err", err)
+			log.Error("##################################################################")
+		case checked:
+			log.Info("Checked the readiness for the shortened block time fork", "epoch", chainConfig.OasysShortenedBlockTimeStartEpoch())
+		}
+		if config.OasysRewardAudit {
+			if !config.NoPruning {
+				log.Warn("Skipped the reward emission audit, which requires the archive mode")
+			} else {
+				go func() {
+					if err := engine.AuditRewardEmission(eth.blockchain); err != nil {
+						log.Error("Reward emission audit failed", "err", err)
+					}
+				}()
+			}
+		}
+	}
 	eth.bloomIndexer.Start(eth.blockchain)
 
 	if config.BlobPool.Datadir != "" {
@@ -257,6 +340,18 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(config.TxPoolPolicies) > 0 {
+		var cfgs map[string]json.RawMessage
+		if config.TxPoolPolicyConfig != "" {
+			if err := json.Unmarshal([]byte(config.TxPoolPolicyConfig), &cfgs); err != nil {
+				return nil, fmt.Errorf("invalid txpool policy config: %v", err)
+			}
+		}
+		if err := eth.txPool.InstallPolicies(types.LatestSigner(chainConfig), config.TxPoolPolicies, cfgs); err != nil {
+			return nil, fmt.Errorf("failed to install txpool policies: %v", err)
+		}
+		log.Info("Installed txpool policies", "names", config.TxPoolPolicies)
+	}
 	// Permit the downloader to use the trie cache allowance during fast sync
 	cacheLimit := cacheConfig.TrieCleanLimit + cacheConfig.TrieDirtyLimit + cacheConfig.SnapshotLimit
 	if eth.handler, err = newHandler(&handlerConfig{
@@ -269,22 +364,43 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		BloomCache:     uint64(cacheLimit),
 		EventMux:       eth.eventMux,
 		RequiredBlocks: config.RequiredBlocks,
+
+		VoteProposersFirst: config.VoteProposersFirst,
 	}); err != nil {
 		return nil, err
 	}
 
 	eth.miner = miner.New(eth, &config.Miner, eth.blockchain.Config(), eth.EventMux(), eth.engine, eth.isLocalBlock)
 	eth.miner.SetExtra(makeExtraData(config.Miner.ExtraData))
+	if engine, ok := eth.engine.(*oasys.Oasys); ok && config.Miner.HealthBeacon {
+		engine.SetHealthBeacon(true, config.Miner.VoteEnable)
+	}
 
-	// Create voteManager instance
-	if posa, ok := eth.engine.(consensus.PoS); ok {
+	// Record the block seals apart from the chain data to refuse double signs, if
+	// opted in or run as a hot standby sharing the keys of the primary node
+	if engine, ok := eth.engine.(*oasys.Oasys); ok && !config.OasysReadOnly && (config.SealGuard || config.StandbyLease > 0) {
+		sealGuardDb, err := stack.OpenDatabase("slashingprotection", 0, 0, "eth/db/slashingprotection/", false)
+		if err != nil {
+			return nil, err
+		}
+		engine.SetSealGuard(sealGuardDb)
+	}
+
+	// Create voteManager instance, unless the engine is read-only
+	if engine, ok := eth.engine.(*oasys.Oasys); ok && config.OasysReadOnly {
+		engine.SetReadOnly()
+		log.Info("Oasys engine is read-only, skipped the signer and vote machinery")
+	} else if posa, ok := eth.engine.(consensus.PoS); ok {
 		// Create votePool instance
 		votePool := vote.NewVotePool(eth.blockchain, posa)
 		eth.votePool = votePool
 		if oasys, ok := eth.engine.(*oasys.Oasys); ok {
 			if !config.Miner.DisableVoteAttestation {
-				// if there is no VotePool in Oasys Engine, the miner can't get votes for assembling
-				oasys.VotePool = votePool
+				// Without the vote pool, the engine only verifies the vote attestations
+				oasys.SetVotePool(votePool)
+				oasys.SetAttestationWait(config.Miner.AttestationWait)
+			} else {
+				log.Info("Oasys engine is verification-only, skipped assembling the vote attestations")
 			}
 		} else {
 			return nil, errors.New("Engine is not Oasys type")
@@ -302,7 +418,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			blsWalletPath := stack.ResolvePath(conf.BLSWalletDir)
 			blsAccountName := conf.VoteKeyName
 			voteJournalPath := stack.ResolvePath(conf.VoteJournalDir)
-			if _, err := vote.NewVoteManager(eth, eth.blockchain, votePool, voteJournalPath, blsPasswordPath, blsWalletPath, blsAccountName, posa); err != nil {
+			if eth.voteManager, err = vote.NewVoteManager(eth, eth.blockchain, votePool, voteJournalPath, blsPasswordPath, blsWalletPath, blsAccountName, posa); err != nil {
 				log.Error("Failed to Initialize voteManager", "err", err)
 				return nil, err
 			}
@@ -322,6 +438,36 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(eth.config.ValidatorMeshURLs) > 0 {
+		engine, _ := eth.engine.(*oasys.Oasys)
+		eth.validatorMesh = newValidatorMesh(eth.p2pServer, eth.blockchain, engine, eth.config.ValidatorMeshURLs)
+	}
+	if engine, ok := eth.engine.(*oasys.Oasys); ok && eth.config.JailWatcherOwner != (common.Address{}) {
+		eth.jailWatcher = newJailWatcher(eth, engine, eth.config.JailWatcherOwner, eth.config.JailWatcherDryRun)
+	}
+	if engine, ok := eth.engine.(*oasys.Oasys); ok && eth.config.StandbyLease > 0 {
+		eth.standby = newStandby(eth, engine, eth.config.StandbyLease)
+		if eth.voteManager != nil {
+			eth.standby.setVoteAddress(eth.voteManager.VoteAddress())
+		}
+	}
+	if engine, ok := eth.engine.(*oasys.Oasys); ok {
+		engine.SetMaxPeers(eth.p2pServer.MaxPeers)
+		eth.cachePruner = newCachePruner(eth.blockchain, engine)
+		eth.epochEventEmitter = newEpochEventEmitter(eth.blockchain, engine)
+		eth.finalityBreaker = newFinalityBreaker(eth.blockchain, engine, config.FinalityStallLimit)
+	}
+	if engine, ok := eth.engine.(*oasys.Oasys); ok && config.InclusionGasPriceFloor != nil {
+		eth.inclusionTracker = newInclusionTracker(eth.blockchain, eth.txPool, engine, config.InclusionGasPriceFloor)
+	}
+	if engine, ok := eth.engine.(*oasys.Oasys); ok && config.EpochWebhookURL != "" {
+		eth.epochWebhook = newEpochWebhook(eth.blockchain, engine, chainDb, config.EpochWebhookURL)
+	}
+	if eth.config.ShadowForkDir != "" {
+		if eth.shadowFork, err = newShadowFork(eth, eth.config.ShadowForkDir); err != nil {
+			return nil, err
+		}
+	}
 	eth.emptyDialCandidates, err = dnsclient.NewIterator()
 	if err != nil {
 		return nil, err
@@ -365,6 +511,24 @@ func (s *Ethereum) APIs() []rpc.API {
 
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
+	if engine, ok := s.engine.(*oasys.Oasys); ok {
+		apis = append(apis, rpc.API{
+			Namespace: "oasys",
+			Service:   NewOasysAPI(s, engine),
+		})
+	}
+	if engine, ok := s.engine.(*oasys.Oasys); ok && s.config.OasysDebugSchedule {
+		apis = append(apis, rpc.API{
+			Namespace: "oasys",
+			Service:   oasys.NewDebugScheduleAPI(engine),
+		})
+	}
+	if engine, ok := s.engine.(*oasys.Oasys); ok && s.config.OasysCliqueCompat {
+		apis = append(apis, rpc.API{
+			Namespace: "clique",
+			Service:   oasys.NewCliqueCompatAPI(s.BlockChain(), engine),
+		})
+	}
 
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
@@ -513,12 +677,25 @@ func (s *Ethereum) StartMining() error {
 			cli.Authorize(eb, wallet.SignData)
 		}
 		if oas != nil {
+			if oas.ReadOnly() {
+				return errors.New("cannot start mining on the read-only engine, remove --oasys.readonly")
+			}
 			wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
 			if wallet == nil || err != nil {
 				log.Error("Etherbase account unavailable locally", "err", err)
 				return fmt.Errorf("signer missing: %v", err)
 			}
 			oas.Authorize(eb, wallet.SignData, wallet.SignTx)
+			for _, signer := range s.config.Miner.Signers {
+				wallet, err := s.accountManager.Find(accounts.Account{Address: signer})
+				if wallet == nil || err != nil {
+					log.Error("Additional signer account unavailable locally", "signer", signer, "err", err)
+					return fmt.Errorf("signer %s missing: %v", signer, err)
+				}
+				if err := oas.AddSigner(signer, wallet.SignData, wallet.SignTx); err != nil {
+					return fmt.Errorf("failed to authorize signer %s: %v", signer, err)
+				}
+			}
 
 			// Temporarily force miners to enable voting to prompt validators to encourage validators to register voting keys
 			if !s.config.Miner.VoteEnable {
@@ -527,6 +704,10 @@ func (s *Ethereum) StartMining() error {
 				return fmt.Errorf("temporarily force validators to enable voting. Please proceed with registering your BLS key. For more details, refer to the technical documentation: %s, err: %v", techDocRef, err)
 			}
 		}
+		// The hot standby starts once the lease of the primary expires
+		if s.standby != nil && s.standby.hold(eb) {
+			return nil
+		}
 		// If mining is started, we can disable the transaction rejection mechanism
 		// introduced to speed sync times.
 		s.handler.enableSyncedFeatures()
@@ -546,6 +727,9 @@ func (s *Ethereum) StopMining() {
 	if th, ok := s.engine.(threaded); ok {
 		th.SetThreads(-1)
 	}
+	if s.standby != nil {
+		s.standby.disarm()
+	}
 	// Stop the block creating itself
 	s.miner.Stop()
 }
@@ -563,6 +747,8 @@ func (s *Ethereum) ChainDb() ethdb.Database            { return s.chainDb }
 func (s *Ethereum) IsListening() bool                  { return true } // Always listening
 func (s *Ethereum) Downloader() *downloader.Downloader { return s.handler.downloader }
 func (s *Ethereum) Synced() bool                       { return s.handler.synced.Load() }
+func (s *Ethereum) PeerCount() int                     { return s.handler.peers.len() }
+func (s *Ethereum) PeersAhead(td *big.Int) int         { return s.handler.peers.lenAhead(td) }
 func (s *Ethereum) SetSynced()                         { s.handler.enableSyncedFeatures() }
 func (s *Ethereum) ArchiveMode() bool                  { return s.config.NoPruning }
 func (s *Ethereum) BloomIndexer() *core.ChainIndexer   { return s.bloomIndexer }
@@ -604,6 +790,33 @@ func (s *Ethereum) Start() error {
 	}
 	// Start the networking layer and the light server if requested
 	s.handler.Start(maxPeers)
+	if s.validatorMesh != nil {
+		s.validatorMesh.start()
+	}
+	if s.jailWatcher != nil {
+		s.jailWatcher.start()
+	}
+	if s.standby != nil {
+		s.standby.start()
+	}
+	if s.shadowFork != nil {
+		s.shadowFork.start()
+	}
+	if s.cachePruner != nil {
+		s.cachePruner.start()
+	}
+	if s.epochEventEmitter != nil {
+		s.epochEventEmitter.start()
+	}
+	if s.finalityBreaker != nil {
+		s.finalityBreaker.start()
+	}
+	if s.inclusionTracker != nil {
+		s.inclusionTracker.start()
+	}
+	if s.epochWebhook != nil {
+		s.epochWebhook.start()
+	}
 	return nil
 }
 
@@ -613,6 +826,33 @@ func (s *Ethereum) Stop() error {
 	// Stop all the peer-related stuff first.
 	s.ethDialCandidates.Close()
 	s.snapDialCandidates.Close()
+	if s.validatorMesh != nil {
+		s.validatorMesh.stop()
+	}
+	if s.jailWatcher != nil {
+		s.jailWatcher.stop()
+	}
+	if s.standby != nil {
+		s.standby.stop()
+	}
+	if s.shadowFork != nil {
+		s.shadowFork.stop()
+	}
+	if s.cachePruner != nil {
+		s.cachePruner.stop()
+	}
+	if s.epochEventEmitter != nil {
+		s.epochEventEmitter.stop()
+	}
+	if s.finalityBreaker != nil {
+		s.finalityBreaker.stop()
+	}
+	if s.inclusionTracker != nil {
+		s.inclusionTracker.stop()
+	}
+	if s.epochWebhook != nil {
+		s.epochWebhook.stop()
+	}
 	s.handler.Stop()
 
 	// Then stop everything else.
@@ -626,6 +866,9 @@ func (s *Ethereum) Stop() error {
 	// Clean shutdown marker as the last thing before closing db
 	s.shutdownTracker.Stop()
 
+	if s.consensusDb != s.chainDb {
+		s.consensusDb.Close()
+	}
 	s.chainDb.Close()
 	s.eventMux.Stop()
 
@@ -0,0 +1,61 @@
+package eth
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+// cachePruner prunes the block hash caches of the oasys engine whenever the
+// finalized block advances with the chain head, including after reorgs.
+type cachePruner struct {
+	chain  *core.BlockChain
+	engine *oasys.Oasys
+
+	pruned uint64 // Finalized block number the caches were last pruned at
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newCachePruner(chain *core.BlockChain, engine *oasys.Oasys) *cachePruner {
+	return &cachePruner{
+		chain:  chain,
+		engine: engine,
+		quit:   make(chan struct{}),
+	}
+}
+
+func (p *cachePruner) start() {
+	p.wg.Add(1)
+	go p.loop()
+}
+
+func (p *cachePruner) stop() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+func (p *cachePruner) loop() {
+	defer p.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 1)
+	sub := p.chain.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-headCh:
+			finalized := p.chain.CurrentFinalBlock()
+			if finalized == nil || finalized.Number.Uint64() <= p.pruned {
+				continue
+			}
+			p.pruned = finalized.Number.Uint64()
+			p.engine.PruneCaches(p.pruned)
+		case <-sub.Err():
+			return
+		case <-p.quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package eth
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+// epochEventEmitter feeds the chain heads to the oasys engine, which derives
+// the epoch lifecycle events served by the oasys_subscribe("epochEvents") API
+// and the finalized blocks served by the oasys_subscribe("finalityRoots") API.
+type epochEventEmitter struct {
+	chain  *core.BlockChain
+	engine *oasys.Oasys
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newEpochEventEmitter(chain *core.BlockChain, engine *oasys.Oasys) *epochEventEmitter {
+	return &epochEventEmitter{
+		chain:  chain,
+		engine: engine,
+		quit:   make(chan struct{}),
+	}
+}
+
+func (e *epochEventEmitter) start() {
+	e.wg.Add(1)
+	go e.loop()
+}
+
+func (e *epochEventEmitter) stop() {
+	close(e.quit)
+	e.wg.Wait()
+}
+
+func (e *epochEventEmitter) loop() {
+	defer e.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 1)
+	sub := e.chain.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-headCh:
+			e.engine.EmitEpochEvents(e.chain, ev.Block.Header())
+			e.engine.EmitFinalityHead(e.chain, ev.Block.Header())
+		case <-sub.Err():
+			return
+		case <-e.quit:
+			return
+		}
+	}
+}
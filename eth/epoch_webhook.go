@@ -0,0 +1,240 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// epochWebhookTimeout is the timeout of a single delivery.
+	epochWebhookTimeout = 10 * time.Second
+
+	// epochWebhookMinBackoff and epochWebhookMaxBackoff bound the delay before
+	// retrying a failed delivery, doubled on every failure.
+	epochWebhookMinBackoff = time.Second
+	epochWebhookMaxBackoff = 5 * time.Minute
+
+	// maxEpochWebhookQueue is the maximum number of the events waiting for the
+	// delivery, the oldest ones are dropped beyond it.
+	maxEpochWebhookQueue = 1024
+)
+
+// epochWebhookKey tracks the last epoch delivered to the webhook, so that the
+// epochs started while the node was down are delivered on the restart.
+var epochWebhookKey = []byte("oasys-epoch-webhook-delivered")
+
+var (
+	epochWebhookDeliveredCounter = metrics.NewRegisteredCounter("oasys/epochwebhook/delivered", nil)
+	epochWebhookFailedCounter    = metrics.NewRegisteredCounter("oasys/epochwebhook/failed", nil)
+	epochWebhookQueueGauge       = metrics.NewRegisteredGauge("oasys/epochwebhook/queue", nil)
+)
+
+// epochWebhook posts the epochStarted events of the canonical chain, carrying
+// the validator set and the environment value of the new epoch, to the webhook
+// of the external staking services, so that they can trigger their payout
+// pipelines without polling. The deliveries are retried until the webhook
+// responds with a 2xx status, in the order of the epochs, and the last epoch
+// delivered is persisted to catch up on the restart. An epoch may therefore be
+// delivered more than once, also with another block after a reorg, so the
+// receivers should deduplicate by the epoch and the block hash.
+type epochWebhook struct {
+	chain  *core.BlockChain
+	engine *oasys.Oasys
+	db     ethdb.KeyValueStore
+	url    string
+	client *http.Client
+
+	queue []*oasys.EpochEvent // Events waiting for the delivery, oldest first
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newEpochWebhook(chain *core.BlockChain, engine *oasys.Oasys, db ethdb.KeyValueStore, url string) *epochWebhook {
+	return &epochWebhook{
+		chain:  chain,
+		engine: engine,
+		db:     db,
+		url:    url,
+		client: &http.Client{Timeout: epochWebhookTimeout},
+		quit:   make(chan struct{}),
+	}
+}
+
+func (w *epochWebhook) start() {
+	w.wg.Add(1)
+	go w.loop()
+}
+
+func (w *epochWebhook) stop() {
+	close(w.quit)
+	w.wg.Wait()
+}
+
+func (w *epochWebhook) loop() {
+	defer w.wg.Done()
+
+	events := make(chan *oasys.EpochEvent, 64)
+	sub := w.engine.SubscribeEpochEvents(events)
+	defer sub.Unsubscribe()
+
+	w.catchUp()
+
+	var (
+		retry   <-chan time.Time
+		backoff time.Duration
+	)
+	deliver := func() {
+		if err := w.deliver(); err != nil {
+			if backoff = 2 * backoff; backoff < epochWebhookMinBackoff {
+				backoff = epochWebhookMinBackoff
+			} else if backoff > epochWebhookMaxBackoff {
+				backoff = epochWebhookMaxBackoff
+			}
+			log.Warn("Failed to deliver epoch event to webhook", "epoch", w.queue[0].Epoch, "queued", len(w.queue), "retry", backoff, "err", err)
+			retry = time.After(backoff)
+			return
+		}
+		retry, backoff = nil, 0
+	}
+	deliver()
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type != oasys.EpochStarted {
+				continue
+			}
+			w.enqueue(ev)
+			if retry == nil {
+				deliver()
+			}
+		case <-retry:
+			deliver()
+		case <-sub.Err():
+			return
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// catchUp queues the epochs started since the last epoch delivered. Nothing is
+// queued on the first run, the delivery starts from the next epoch.
+func (w *epochWebhook) catchUp() {
+	head := w.chain.CurrentHeader()
+	epoch, err := w.engine.Epoch(w.chain, head)
+	if err != nil {
+		log.Warn("Failed to retrieve epoch for webhook catch-up", "number", head.Number, "err", err)
+		return
+	}
+	delivered, ok := w.delivered()
+	if !ok {
+		w.setDelivered(epoch)
+		return
+	}
+	from := delivered + 1
+	if epoch >= maxEpochWebhookQueue && from <= epoch-maxEpochWebhookQueue {
+		log.Warn("Skipping epochs missed by webhook", "from", from, "to", epoch-maxEpochWebhookQueue)
+		from = epoch - maxEpochWebhookQueue + 1
+	}
+	for e := from; e <= epoch; e++ {
+		ev, err := w.engine.EpochStartedEvent(w.chain, head, e)
+		if err != nil {
+			log.Warn("Failed to retrieve missed epoch for webhook", "epoch", e, "err", err)
+			return
+		}
+		w.enqueue(ev)
+	}
+	if len(w.queue) > 0 {
+		log.Info("Catching up epochs missed by webhook", "from", from, "to", epoch)
+	}
+}
+
+// enqueue queues the event for the delivery, dropping the oldest one if full.
+func (w *epochWebhook) enqueue(ev *oasys.EpochEvent) {
+	if len(w.queue) >= maxEpochWebhookQueue {
+		log.Warn("Dropping epoch event undelivered to webhook", "epoch", w.queue[0].Epoch)
+		w.queue = w.queue[1:]
+	}
+	w.queue = append(w.queue, ev)
+	epochWebhookQueueGauge.Update(int64(len(w.queue)))
+}
+
+// deliver posts the queued events in order, stopping at the first failure.
+func (w *epochWebhook) deliver() error {
+	for len(w.queue) > 0 {
+		ev := w.queue[0]
+		if err := w.post(ev); err != nil {
+			epochWebhookFailedCounter.Inc(1)
+			return err
+		}
+		epochWebhookDeliveredCounter.Inc(1)
+		if delivered, ok := w.delivered(); !ok || ev.Epoch > delivered {
+			w.setDelivered(ev.Epoch)
+		}
+		w.queue = w.queue[1:]
+		epochWebhookQueueGauge.Update(int64(len(w.queue)))
+	}
+	return nil
+}
+
+// post sends the event to the webhook as JSON.
+func (w *epochWebhook) post(ev *oasys.EpochEvent) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), epochWebhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Oasys-Epoch", strconv.FormatUint(ev.Epoch, 10))
+	req.Header.Set("X-Oasys-Block-Hash", ev.Hash.Hex())
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return nil
+}
+
+// delivered returns the last epoch delivered, if any.
+func (w *epochWebhook) delivered() (uint64, bool) {
+	blob, _ := w.db.Get(epochWebhookKey)
+	if len(blob) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(blob), true
+}
+
+func (w *epochWebhook) setDelivered(epoch uint64) {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, epoch)
+	if err := w.db.Put(epochWebhookKey, enc); err != nil {
+		log.Warn("Failed to store epoch delivered to webhook", "epoch", epoch, "err", err)
+	}
+}
@@ -0,0 +1,82 @@
+package eth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+func TestEpochWebhookDeliver(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		fail     = true
+		received []uint64
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var ev oasys.EpochEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("X-Oasys-Block-Hash") != ev.Hash.Hex() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received = append(received, ev.Epoch)
+	}))
+	defer server.Close()
+
+	w := newEpochWebhook(nil, nil, memorydb.New(), server.URL)
+	for epoch := uint64(5); epoch <= 7; epoch++ {
+		w.enqueue(&oasys.EpochEvent{Type: oasys.EpochStarted, Epoch: epoch, Hash: common.Hash{byte(epoch)}})
+	}
+
+	// The failed events are kept for the retries
+	if err := w.deliver(); err == nil {
+		t.Fatal("delivered to failing webhook")
+	}
+	if len(w.queue) != 3 {
+		t.Fatalf("queue mismatch: have %d, want 3", len(w.queue))
+	}
+	if _, ok := w.delivered(); ok {
+		t.Fatal("delivery recorded on failure")
+	}
+
+	lock.Lock()
+	fail = false
+	lock.Unlock()
+	if err := w.deliver(); err != nil {
+		t.Fatalf("failed to deliver: %v", err)
+	}
+	if len(w.queue) != 0 {
+		t.Fatalf("queue mismatch: have %d, want 0", len(w.queue))
+	}
+	if len(received) != 3 || received[0] != 5 || received[2] != 7 {
+		t.Fatalf("received epochs mismatch: have %v, want [5 6 7]", received)
+	}
+	if delivered, ok := w.delivered(); !ok || delivered != 7 {
+		t.Fatalf("delivered epoch mismatch: have %d, want 7", delivered)
+	}
+
+	// An epoch redelivered after a reorg doesn't rewind the record
+	w.enqueue(&oasys.EpochEvent{Type: oasys.EpochStarted, Epoch: 6, Hash: common.Hash{0xff}})
+	if err := w.deliver(); err != nil {
+		t.Fatalf("failed to deliver: %v", err)
+	}
+	if delivered, _ := w.delivered(); delivered != 7 {
+		t.Fatalf("delivered epoch mismatch: have %d, want 7", delivered)
+	}
+}
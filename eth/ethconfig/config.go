@@ -19,6 +19,8 @@ package ethconfig
 
 import (
 	"errors"
+	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -34,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/params"
 )
@@ -48,28 +51,33 @@ var FullNodeGPO = gasprice.Config{
 	IgnorePrice:      gasprice.DefaultIgnorePrice,
 }
 
+// ConsensusDatabaseHandles is the number of file handles allocated to the
+// separate consensus database, which is only written once per checkpoint.
+const ConsensusDatabaseHandles = 64
+
 // Defaults contains default settings for use on the Ethereum main net.
 var Defaults = Config{
-	SyncMode:           downloader.SnapSync,
-	NetworkId:          0, // enable auto configuration of networkID == chainID
-	TxLookupLimit:      2350000,
-	TransactionHistory: 2350000,
-	StateHistory:       params.FullImmutabilityThreshold,
-	LightPeers:         100,
-	DatabaseCache:      512,
-	TrieCleanCache:     154,
-	TrieDirtyCache:     256,
-	TrieTimeout:        60 * time.Minute,
-	SnapshotCache:      102,
-	FilterLogCacheSize: 32,
-	Miner:              miner.DefaultConfig,
-	TxPool:             legacypool.DefaultConfig,
-	BlobPool:           blobpool.DefaultConfig,
-	RPCGasCap:          50000000,
-	RPCEVMTimeout:      5 * time.Second,
-	GPO:                FullNodeGPO,
-	RPCTxFeeCap:        1,                                         // 1 ether
-	BlobExtraReserve:   params.DefaultExtraReserveForBlobRequests, // Extra reserve threshold for blob, blob never expires when -1 is set, default 14400
+	SyncMode:               downloader.SnapSync,
+	NetworkId:              0, // enable auto configuration of networkID == chainID
+	TxLookupLimit:          2350000,
+	TransactionHistory:     2350000,
+	StateHistory:           params.FullImmutabilityThreshold,
+	LightPeers:             100,
+	DatabaseCache:          512,
+	ConsensusDatabaseCache: 16,
+	TrieCleanCache:         154,
+	TrieDirtyCache:         256,
+	TrieTimeout:            60 * time.Minute,
+	SnapshotCache:          102,
+	FilterLogCacheSize:     32,
+	Miner:                  miner.DefaultConfig,
+	TxPool:                 legacypool.DefaultConfig,
+	BlobPool:               blobpool.DefaultConfig,
+	RPCGasCap:              50000000,
+	RPCEVMTimeout:          5 * time.Second,
+	GPO:                    FullNodeGPO,
+	RPCTxFeeCap:            1,                                         // 1 ether
+	BlobExtraReserve:       params.DefaultExtraReserveForBlobRequests, // Extra reserve threshold for blob, blob never expires when -1 is set, default 14400
 }
 
 //go:generate go run github.com/fjl/gencodec -type Config -formats toml -out gen_config.go
@@ -90,14 +98,52 @@ type Config struct {
 	EthDiscoveryURLs  []string
 	SnapDiscoveryURLs []string
 
+	// This can be set to list of enode://, enr: or enrtree:// URLs of the validator
+	// nodes to keep persistent connections to. The nodes whose records are signed
+	// by the current validators are also trusted.
+	ValidatorMeshURLs []string `toml:",omitempty"`
+
+	// VoteProposersFirst sends the votes to the peers delivering the blocks of
+	// the upcoming proposers first, regardless of their head.
+	VoteProposersFirst bool `toml:",omitempty"`
+
+	// JailWatcherOwner is the owner of the local validator. If set, the jail
+	// status of the validator is watched and the validator is activated from
+	// the owner account once the jail period has elapsed.
+	JailWatcherOwner common.Address `toml:",omitempty"`
+
+	// JailWatcherDryRun disables sending the activation transaction.
+	JailWatcherDryRun bool `toml:",omitempty"`
+
+	// StandbyLease makes the node a hot standby of the primary validator node
+	// sharing its keys. Sealing and voting are held until neither a block nor a
+	// vote of the validator has been seen for the lease.
+	StandbyLease time.Duration `toml:",omitempty"`
+
+	// SealGuard records the block seals in a slashing protection database apart
+	// from the chain data, refusing to seal a second block at the same height.
+	// It's always enabled on the hot standby nodes.
+	SealGuard bool `toml:",omitempty"`
+
+	// ShadowForkDir is the data directory of the canonical chain to replay on
+	// top of a scratch copy of the local chain, which runs with locally modified
+	// consensus parameters or contracts. Networking is disabled in this mode.
+	ShadowForkDir string `toml:",omitempty"`
+
 	NoPruning  bool // Whether to disable pruning and flush everything to disk
 	NoPrefetch bool // Whether to disable prefetching and only load state on demand
+	ParallelTx bool // Whether to execute the transactions of the imported blocks in parallel
 
 	// Deprecated, use 'TransactionHistory' instead.
 	TxLookupLimit      uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 	TransactionHistory uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 	StateHistory       uint64 `toml:",omitempty"` // The maximum number of blocks from head whose state histories are reserved.
 
+	// TransactionHistoryFinalized reserves the tx indices back from the finalized
+	// block instead of head, so that the ones of the non-finalized blocks are
+	// never pruned.
+	TransactionHistoryFinalized bool `toml:",omitempty"`
+
 	// State scheme represents the scheme used to store ethereum states and trie
 	// nodes on top. It can be 'hash', 'path', or none which means use the scheme
 	// consistent with persistent state.
@@ -122,6 +168,12 @@ type Config struct {
 	DatabaseCache      int
 	DatabaseFreezer    string
 
+	// Consensus database options. The Oasys snapshots are stored in the chain
+	// database unless a separate directory is specified, which allows them to
+	// be placed on their own volume.
+	ConsensusDatabase      string `toml:",omitempty"`
+	ConsensusDatabaseCache int    `toml:",omitempty"`
+
 	TrieCleanCache int
 	TrieDirtyCache int
 	TrieTimeout    time.Duration
@@ -138,12 +190,22 @@ type Config struct {
 	TxPool   legacypool.Config
 	BlobPool blobpool.Config
 
+	// TxPoolPolicies are the names of the admission policies installed on the
+	// transaction pool, configured by the JSON object in TxPoolPolicyConfig
+	// keyed by the names.
+	TxPoolPolicies     []string `toml:",omitempty"`
+	TxPoolPolicyConfig string   `toml:",omitempty"`
+
 	// Gas Price Oracle options
 	GPO gasprice.Config
 
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Enables the tracer receiving the consensus events of the Oasys engine
+	OasysConsensusTrace           string `toml:",omitempty"`
+	OasysConsensusTraceJsonConfig string `toml:",omitempty"`
+
 	// Miscellaneous options
 	DocRoot string `toml:"-"`
 
@@ -157,6 +219,44 @@ type Config struct {
 	// send-transaction variants. The unit is ether.
 	RPCTxFeeCap float64
 
+	// FinalityConfirmations enables the deferred finality mode if non-zero, in
+	// which the latest block served over RPC is the highest justified block, or
+	// the block with this number of confirmations if it is newer.
+	FinalityConfirmations uint64 `toml:",omitempty"`
+
+	// FinalityStallLimit enables the finality breaker if non-zero, which freezes
+	// the latest and the safe blocks served over RPC once no block has been
+	// justified for this number of blocks, until the finality recovers.
+	FinalityStallLimit uint64 `toml:",omitempty"`
+
+	// InclusionGasPriceFloor enables the inclusion tracking if set, which records
+	// per validator whether the proposers include the pending transactions paying
+	// at least this gas price.
+	InclusionGasPriceFloor *big.Int `toml:",omitempty"`
+
+	// EpochWebhookURL enables the epoch webhook if set, which posts the start of
+	// the epochs with the new validator set and environment value to this URL.
+	EpochWebhookURL string `toml:",omitempty"`
+
+	// FinalityHeads includes the justified and finalized block numbers in the
+	// payloads of the newHeads subscriptions.
+	FinalityHeads bool `toml:",omitempty"`
+
+	// OasysDebugSchedule enables the oasys_debugSchedule API for testing.
+	OasysDebugSchedule bool `toml:",omitempty"`
+
+	// OasysCliqueCompat serves the validators under the clique API namespace
+	// for the tooling built for the clique based networks.
+	OasysCliqueCompat bool `toml:",omitempty"`
+
+	// OasysRewardAudit verifies the rewards minted to the StakeManager against
+	// the emission formula on startup, which requires the archive mode.
+	OasysRewardAudit bool `toml:",omitempty"`
+
+	// OasysReadOnly constructs the engine without the signer and the vote
+	// machinery, for the nodes only serving RPC.
+	OasysReadOnly bool `toml:",omitempty"`
+
 	// OverrideCancun (TODO: remove after the fork)
 	OverrideCancun *uint64 `toml:",omitempty"`
 
@@ -177,6 +277,13 @@ func CreateConsensusEngine(config *params.ChainConfig, db ethdb.Database, ethAPI
 	}
 	// If proof-of-stake is requested, set it up
 	if config.Oasys != nil {
+		// Only the new genesis is rejected, the existing chains keep running.
+		if err := config.Oasys.Validate(); err != nil {
+			log.Warn("Inconsistent oasys config", "err", err)
+		}
+		if err := config.CheckOasysForks(); err != nil {
+			return nil, fmt.Errorf("invalid oasys config: %v", err)
+		}
 		return oasys.New(config, config.Oasys, db, ethAPI), nil
 	}
 	// If defaulting to proof-of-work, enforce an already merged network since
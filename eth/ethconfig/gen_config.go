@@ -3,6 +3,7 @@
 package ethconfig
 
 import (
+	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -17,46 +18,70 @@ import (
 // MarshalTOML marshals as TOML.
 func (c Config) MarshalTOML() (interface{}, error) {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               uint64
-		SyncMode                downloader.SyncMode
-		EthDiscoveryURLs        []string
-		SnapDiscoveryURLs       []string
-		NoPruning               bool
-		NoPrefetch              bool
-		TxLookupLimit           uint64                 `toml:",omitempty"`
-		TransactionHistory      uint64                 `toml:",omitempty"`
-		StateHistory            uint64                 `toml:",omitempty"`
-		StateScheme             string                 `toml:",omitempty"`
-		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
-		LightServ               int                    `toml:",omitempty"`
-		LightIngress            int                    `toml:",omitempty"`
-		LightEgress             int                    `toml:",omitempty"`
-		LightPeers              int                    `toml:",omitempty"`
-		LightNoPrune            bool                   `toml:",omitempty"`
-		LightNoSyncServe        bool                   `toml:",omitempty"`
-		SkipBcVersionCheck      bool                   `toml:"-"`
-		DatabaseHandles         int                    `toml:"-"`
-		DatabaseCache           int
-		DatabaseFreezer         string
-		TrieCleanCache          int
-		TrieDirtyCache          int
-		TrieTimeout             time.Duration
-		SnapshotCache           int
-		Preimages               bool
-		FilterLogCacheSize      int
-		Miner                   miner.Config
-		TxPool                  legacypool.Config
-		BlobPool                blobpool.Config
-		GPO                     gasprice.Config
-		EnablePreimageRecording bool
-		DocRoot                 string `toml:"-"`
-		RPCGasCap               uint64
-		RPCEVMTimeout           time.Duration
-		RPCTxFeeCap             float64
-		OverrideCancun          *uint64 `toml:",omitempty"`
-		OverrideVerkle          *uint64 `toml:",omitempty"`
-		BlobExtraReserve        uint64
+		Genesis                       *core.Genesis `toml:",omitempty"`
+		NetworkId                     uint64
+		SyncMode                      downloader.SyncMode
+		EthDiscoveryURLs              []string
+		SnapDiscoveryURLs             []string
+		ValidatorMeshURLs             []string       `toml:",omitempty"`
+		VoteProposersFirst            bool           `toml:",omitempty"`
+		JailWatcherOwner              common.Address `toml:",omitempty"`
+		JailWatcherDryRun             bool           `toml:",omitempty"`
+		StandbyLease                  time.Duration  `toml:",omitempty"`
+		SealGuard                     bool           `toml:",omitempty"`
+		ShadowForkDir                 string         `toml:",omitempty"`
+		NoPruning                     bool
+		NoPrefetch                    bool
+		ParallelTx                    bool
+		TxLookupLimit                 uint64                 `toml:",omitempty"`
+		TransactionHistory            uint64                 `toml:",omitempty"`
+		StateHistory                  uint64                 `toml:",omitempty"`
+		TransactionHistoryFinalized   bool                   `toml:",omitempty"`
+		StateScheme                   string                 `toml:",omitempty"`
+		RequiredBlocks                map[uint64]common.Hash `toml:"-"`
+		LightServ                     int                    `toml:",omitempty"`
+		LightIngress                  int                    `toml:",omitempty"`
+		LightEgress                   int                    `toml:",omitempty"`
+		LightPeers                    int                    `toml:",omitempty"`
+		LightNoPrune                  bool                   `toml:",omitempty"`
+		LightNoSyncServe              bool                   `toml:",omitempty"`
+		SkipBcVersionCheck            bool                   `toml:"-"`
+		DatabaseHandles               int                    `toml:"-"`
+		DatabaseCache                 int
+		DatabaseFreezer               string
+		ConsensusDatabase             string `toml:",omitempty"`
+		ConsensusDatabaseCache        int    `toml:",omitempty"`
+		TrieCleanCache                int
+		TrieDirtyCache                int
+		TrieTimeout                   time.Duration
+		SnapshotCache                 int
+		Preimages                     bool
+		FilterLogCacheSize            int
+		Miner                         miner.Config
+		TxPool                        legacypool.Config
+		BlobPool                      blobpool.Config
+		TxPoolPolicies                []string `toml:",omitempty"`
+		TxPoolPolicyConfig            string   `toml:",omitempty"`
+		GPO                           gasprice.Config
+		EnablePreimageRecording       bool
+		OasysConsensusTrace           string `toml:",omitempty"`
+		OasysConsensusTraceJsonConfig string `toml:",omitempty"`
+		DocRoot                       string `toml:"-"`
+		RPCGasCap                     uint64
+		RPCEVMTimeout                 time.Duration
+		RPCTxFeeCap                   float64
+		FinalityConfirmations         uint64   `toml:",omitempty"`
+		FinalityStallLimit            uint64   `toml:",omitempty"`
+		InclusionGasPriceFloor        *big.Int `toml:",omitempty"`
+		EpochWebhookURL               string   `toml:",omitempty"`
+		FinalityHeads                 bool     `toml:",omitempty"`
+		OasysDebugSchedule            bool     `toml:",omitempty"`
+		OasysCliqueCompat             bool     `toml:",omitempty"`
+		OasysRewardAudit              bool     `toml:",omitempty"`
+		OasysReadOnly                 bool     `toml:",omitempty"`
+		OverrideCancun                *uint64  `toml:",omitempty"`
+		OverrideVerkle                *uint64  `toml:",omitempty"`
+		BlobExtraReserve              uint64
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -64,11 +89,20 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.SyncMode = c.SyncMode
 	enc.EthDiscoveryURLs = c.EthDiscoveryURLs
 	enc.SnapDiscoveryURLs = c.SnapDiscoveryURLs
+	enc.ValidatorMeshURLs = c.ValidatorMeshURLs
+	enc.VoteProposersFirst = c.VoteProposersFirst
+	enc.JailWatcherOwner = c.JailWatcherOwner
+	enc.JailWatcherDryRun = c.JailWatcherDryRun
+	enc.StandbyLease = c.StandbyLease
+	enc.SealGuard = c.SealGuard
+	enc.ShadowForkDir = c.ShadowForkDir
 	enc.NoPruning = c.NoPruning
 	enc.NoPrefetch = c.NoPrefetch
+	enc.ParallelTx = c.ParallelTx
 	enc.TxLookupLimit = c.TxLookupLimit
 	enc.TransactionHistory = c.TransactionHistory
 	enc.StateHistory = c.StateHistory
+	enc.TransactionHistoryFinalized = c.TransactionHistoryFinalized
 	enc.StateScheme = c.StateScheme
 	enc.RequiredBlocks = c.RequiredBlocks
 	enc.LightServ = c.LightServ
@@ -81,6 +115,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
 	enc.DatabaseFreezer = c.DatabaseFreezer
+	enc.ConsensusDatabase = c.ConsensusDatabase
+	enc.ConsensusDatabaseCache = c.ConsensusDatabaseCache
 	enc.TrieCleanCache = c.TrieCleanCache
 	enc.TrieDirtyCache = c.TrieDirtyCache
 	enc.TrieTimeout = c.TrieTimeout
@@ -90,12 +126,25 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Miner = c.Miner
 	enc.TxPool = c.TxPool
 	enc.BlobPool = c.BlobPool
+	enc.TxPoolPolicies = c.TxPoolPolicies
+	enc.TxPoolPolicyConfig = c.TxPoolPolicyConfig
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
+	enc.OasysConsensusTrace = c.OasysConsensusTrace
+	enc.OasysConsensusTraceJsonConfig = c.OasysConsensusTraceJsonConfig
 	enc.DocRoot = c.DocRoot
 	enc.RPCGasCap = c.RPCGasCap
 	enc.RPCEVMTimeout = c.RPCEVMTimeout
 	enc.RPCTxFeeCap = c.RPCTxFeeCap
+	enc.FinalityConfirmations = c.FinalityConfirmations
+	enc.FinalityStallLimit = c.FinalityStallLimit
+	enc.InclusionGasPriceFloor = c.InclusionGasPriceFloor
+	enc.EpochWebhookURL = c.EpochWebhookURL
+	enc.FinalityHeads = c.FinalityHeads
+	enc.OasysDebugSchedule = c.OasysDebugSchedule
+	enc.OasysCliqueCompat = c.OasysCliqueCompat
+	enc.OasysRewardAudit = c.OasysRewardAudit
+	enc.OasysReadOnly = c.OasysReadOnly
 	enc.OverrideCancun = c.OverrideCancun
 	enc.OverrideVerkle = c.OverrideVerkle
 	enc.BlobExtraReserve = c.BlobExtraReserve
@@ -105,46 +154,70 @@ func (c Config) MarshalTOML() (interface{}, error) {
 // UnmarshalTOML unmarshals from TOML.
 func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               *uint64
-		SyncMode                *downloader.SyncMode
-		EthDiscoveryURLs        []string
-		SnapDiscoveryURLs       []string
-		NoPruning               *bool
-		NoPrefetch              *bool
-		TxLookupLimit           *uint64                `toml:",omitempty"`
-		TransactionHistory      *uint64                `toml:",omitempty"`
-		StateHistory            *uint64                `toml:",omitempty"`
-		StateScheme             *string                `toml:",omitempty"`
-		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
-		LightServ               *int                   `toml:",omitempty"`
-		LightIngress            *int                   `toml:",omitempty"`
-		LightEgress             *int                   `toml:",omitempty"`
-		LightPeers              *int                   `toml:",omitempty"`
-		LightNoPrune            *bool                  `toml:",omitempty"`
-		LightNoSyncServe        *bool                  `toml:",omitempty"`
-		SkipBcVersionCheck      *bool                  `toml:"-"`
-		DatabaseHandles         *int                   `toml:"-"`
-		DatabaseCache           *int
-		DatabaseFreezer         *string
-		TrieCleanCache          *int
-		TrieDirtyCache          *int
-		TrieTimeout             *time.Duration
-		SnapshotCache           *int
-		Preimages               *bool
-		FilterLogCacheSize      *int
-		Miner                   *miner.Config
-		TxPool                  *legacypool.Config
-		BlobPool                *blobpool.Config
-		GPO                     *gasprice.Config
-		EnablePreimageRecording *bool
-		DocRoot                 *string `toml:"-"`
-		RPCGasCap               *uint64
-		RPCEVMTimeout           *time.Duration
-		RPCTxFeeCap             *float64
-		OverrideCancun          *uint64 `toml:",omitempty"`
-		OverrideVerkle          *uint64 `toml:",omitempty"`
-		BlobExtraReserve        *uint64
+		Genesis                       *core.Genesis `toml:",omitempty"`
+		NetworkId                     *uint64
+		SyncMode                      *downloader.SyncMode
+		EthDiscoveryURLs              []string
+		SnapDiscoveryURLs             []string
+		ValidatorMeshURLs             []string        `toml:",omitempty"`
+		VoteProposersFirst            *bool           `toml:",omitempty"`
+		JailWatcherOwner              *common.Address `toml:",omitempty"`
+		JailWatcherDryRun             *bool           `toml:",omitempty"`
+		StandbyLease                  *time.Duration  `toml:",omitempty"`
+		SealGuard                     *bool           `toml:",omitempty"`
+		ShadowForkDir                 *string         `toml:",omitempty"`
+		NoPruning                     *bool
+		NoPrefetch                    *bool
+		ParallelTx                    *bool
+		TxLookupLimit                 *uint64                `toml:",omitempty"`
+		TransactionHistory            *uint64                `toml:",omitempty"`
+		StateHistory                  *uint64                `toml:",omitempty"`
+		TransactionHistoryFinalized   *bool                  `toml:",omitempty"`
+		StateScheme                   *string                `toml:",omitempty"`
+		RequiredBlocks                map[uint64]common.Hash `toml:"-"`
+		LightServ                     *int                   `toml:",omitempty"`
+		LightIngress                  *int                   `toml:",omitempty"`
+		LightEgress                   *int                   `toml:",omitempty"`
+		LightPeers                    *int                   `toml:",omitempty"`
+		LightNoPrune                  *bool                  `toml:",omitempty"`
+		LightNoSyncServe              *bool                  `toml:",omitempty"`
+		SkipBcVersionCheck            *bool                  `toml:"-"`
+		DatabaseHandles               *int                   `toml:"-"`
+		DatabaseCache                 *int
+		DatabaseFreezer               *string
+		ConsensusDatabase             *string `toml:",omitempty"`
+		ConsensusDatabaseCache        *int    `toml:",omitempty"`
+		TrieCleanCache                *int
+		TrieDirtyCache                *int
+		TrieTimeout                   *time.Duration
+		SnapshotCache                 *int
+		Preimages                     *bool
+		FilterLogCacheSize            *int
+		Miner                         *miner.Config
+		TxPool                        *legacypool.Config
+		BlobPool                      *blobpool.Config
+		TxPoolPolicies                []string `toml:",omitempty"`
+		TxPoolPolicyConfig            *string  `toml:",omitempty"`
+		GPO                           *gasprice.Config
+		EnablePreimageRecording       *bool
+		OasysConsensusTrace           *string `toml:",omitempty"`
+		OasysConsensusTraceJsonConfig *string `toml:",omitempty"`
+		DocRoot                       *string `toml:"-"`
+		RPCGasCap                     *uint64
+		RPCEVMTimeout                 *time.Duration
+		RPCTxFeeCap                   *float64
+		FinalityConfirmations         *uint64  `toml:",omitempty"`
+		FinalityStallLimit            *uint64  `toml:",omitempty"`
+		InclusionGasPriceFloor        *big.Int `toml:",omitempty"`
+		EpochWebhookURL               *string  `toml:",omitempty"`
+		FinalityHeads                 *bool    `toml:",omitempty"`
+		OasysDebugSchedule            *bool    `toml:",omitempty"`
+		OasysCliqueCompat             *bool    `toml:",omitempty"`
+		OasysRewardAudit              *bool    `toml:",omitempty"`
+		OasysReadOnly                 *bool    `toml:",omitempty"`
+		OverrideCancun                *uint64  `toml:",omitempty"`
+		OverrideVerkle                *uint64  `toml:",omitempty"`
+		BlobExtraReserve              *uint64
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -165,12 +238,36 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.SnapDiscoveryURLs != nil {
 		c.SnapDiscoveryURLs = dec.SnapDiscoveryURLs
 	}
+	if dec.ValidatorMeshURLs != nil {
+		c.ValidatorMeshURLs = dec.ValidatorMeshURLs
+	}
+	if dec.VoteProposersFirst != nil {
+		c.VoteProposersFirst = *dec.VoteProposersFirst
+	}
+	if dec.JailWatcherOwner != nil {
+		c.JailWatcherOwner = *dec.JailWatcherOwner
+	}
+	if dec.JailWatcherDryRun != nil {
+		c.JailWatcherDryRun = *dec.JailWatcherDryRun
+	}
+	if dec.StandbyLease != nil {
+		c.StandbyLease = *dec.StandbyLease
+	}
+	if dec.SealGuard != nil {
+		c.SealGuard = *dec.SealGuard
+	}
+	if dec.ShadowForkDir != nil {
+		c.ShadowForkDir = *dec.ShadowForkDir
+	}
 	if dec.NoPruning != nil {
 		c.NoPruning = *dec.NoPruning
 	}
 	if dec.NoPrefetch != nil {
 		c.NoPrefetch = *dec.NoPrefetch
 	}
+	if dec.ParallelTx != nil {
+		c.ParallelTx = *dec.ParallelTx
+	}
 	if dec.TxLookupLimit != nil {
 		c.TxLookupLimit = *dec.TxLookupLimit
 	}
@@ -180,6 +277,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.StateHistory != nil {
 		c.StateHistory = *dec.StateHistory
 	}
+	if dec.TransactionHistoryFinalized != nil {
+		c.TransactionHistoryFinalized = *dec.TransactionHistoryFinalized
+	}
 	if dec.StateScheme != nil {
 		c.StateScheme = *dec.StateScheme
 	}
@@ -216,6 +316,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DatabaseFreezer != nil {
 		c.DatabaseFreezer = *dec.DatabaseFreezer
 	}
+	if dec.ConsensusDatabase != nil {
+		c.ConsensusDatabase = *dec.ConsensusDatabase
+	}
+	if dec.ConsensusDatabaseCache != nil {
+		c.ConsensusDatabaseCache = *dec.ConsensusDatabaseCache
+	}
 	if dec.TrieCleanCache != nil {
 		c.TrieCleanCache = *dec.TrieCleanCache
 	}
@@ -243,12 +349,24 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.BlobPool != nil {
 		c.BlobPool = *dec.BlobPool
 	}
+	if dec.TxPoolPolicies != nil {
+		c.TxPoolPolicies = dec.TxPoolPolicies
+	}
+	if dec.TxPoolPolicyConfig != nil {
+		c.TxPoolPolicyConfig = *dec.TxPoolPolicyConfig
+	}
 	if dec.GPO != nil {
 		c.GPO = *dec.GPO
 	}
 	if dec.EnablePreimageRecording != nil {
 		c.EnablePreimageRecording = *dec.EnablePreimageRecording
 	}
+	if dec.OasysConsensusTrace != nil {
+		c.OasysConsensusTrace = *dec.OasysConsensusTrace
+	}
+	if dec.OasysConsensusTraceJsonConfig != nil {
+		c.OasysConsensusTraceJsonConfig = *dec.OasysConsensusTraceJsonConfig
+	}
 	if dec.DocRoot != nil {
 		c.DocRoot = *dec.DocRoot
 	}
@@ -261,6 +379,33 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.RPCTxFeeCap != nil {
 		c.RPCTxFeeCap = *dec.RPCTxFeeCap
 	}
+	if dec.FinalityConfirmations != nil {
+		c.FinalityConfirmations = *dec.FinalityConfirmations
+	}
+	if dec.FinalityStallLimit != nil {
+		c.FinalityStallLimit = *dec.FinalityStallLimit
+	}
+	if dec.InclusionGasPriceFloor != nil {
+		c.InclusionGasPriceFloor = dec.InclusionGasPriceFloor
+	}
+	if dec.EpochWebhookURL != nil {
+		c.EpochWebhookURL = *dec.EpochWebhookURL
+	}
+	if dec.FinalityHeads != nil {
+		c.FinalityHeads = *dec.FinalityHeads
+	}
+	if dec.OasysDebugSchedule != nil {
+		c.OasysDebugSchedule = *dec.OasysDebugSchedule
+	}
+	if dec.OasysCliqueCompat != nil {
+		c.OasysCliqueCompat = *dec.OasysCliqueCompat
+	}
+	if dec.OasysRewardAudit != nil {
+		c.OasysRewardAudit = *dec.OasysRewardAudit
+	}
+	if dec.OasysReadOnly != nil {
+		c.OasysReadOnly = *dec.OasysReadOnly
+	}
 	if dec.OverrideCancun != nil {
 		c.OverrideCancun = dec.OverrideCancun
 	}
@@ -46,6 +46,15 @@ const (
 	blockLimit   = 64  // Maximum number of unique blocks a peer may have delivered
 )
 
+// Ranks of the blocks queued at the same height, higher ranks imported first.
+const (
+	RankInactive  = 0 // Sealed by a validator not in the active set
+	RankOutOfTurn = 1 // Sealed by an out-of-turn validator, or not ranked
+	RankInTurn    = 2 // Sealed by the in-turn validator
+
+	rankLevels = RankInTurn + 1
+)
+
 var (
 	blockAnnounceInMeter   = metrics.NewRegisteredMeter("eth/fetcher/block/announces/in", nil)
 	blockAnnounceOutTimer  = metrics.NewRegisteredTimer("eth/fetcher/block/announces/out", nil)
@@ -64,6 +73,9 @@ var (
 	headerFilterOutMeter = metrics.NewRegisteredMeter("eth/fetcher/block/filter/headers/out", nil)
 	bodyFilterInMeter    = metrics.NewRegisteredMeter("eth/fetcher/block/filter/bodies/in", nil)
 	bodyFilterOutMeter   = metrics.NewRegisteredMeter("eth/fetcher/block/filter/bodies/out", nil)
+
+	blockRankInTurnMeter   = metrics.NewRegisteredMeter("eth/fetcher/block/rank/inturn", nil)
+	blockRankInactiveMeter = metrics.NewRegisteredMeter("eth/fetcher/block/rank/inactive", nil)
 )
 
 var errTerminated = errors.New("terminated")
@@ -92,6 +104,10 @@ type chainHeightFn func() uint64
 // chainFinalizedHeightFn is a callback type to retrieve the current chain finalized height.
 type chainFinalizedHeightFn func() uint64
 
+// headerRankFn is a callback type to rank a header among the ones queued at the
+// same height.
+type headerRankFn func(header *types.Header) int
+
 // headersInsertFn is a callback type to insert a batch of headers into the local chain.
 type headersInsertFn func(headers []*types.Header) (int, error)
 
@@ -135,6 +151,7 @@ type bodyFilterTask struct {
 // blockOrHeaderInject represents a schedules import operation.
 type blockOrHeaderInject struct {
 	origin string
+	rank   int // Rank among the blocks at the same height
 
 	header *types.Header // Used for light mode fetcher which only cares about header.
 	block  *types.Block  // Used for normal mode fetcher which imports full block.
@@ -156,6 +173,12 @@ func (inject *blockOrHeaderInject) hash() common.Hash {
 	return inject.block.Hash()
 }
 
+// priority returns the priority in the import queue, lower block numbers first
+// and higher ranks first within the same block number.
+func (inject *blockOrHeaderInject) priority() int64 {
+	return -int64(inject.number())*rankLevels + int64(inject.rank)
+}
+
 // BlockFetcher is responsible for accumulating block announcements from various peers
 // and scheduling them for retrieval.
 type BlockFetcher struct {
@@ -190,6 +213,7 @@ type BlockFetcher struct {
 	broadcastBlock       blockBroadcasterFn     // Broadcasts a block to connected peers
 	chainHeight          chainHeightFn          // Retrieves the current chain's height
 	chainFinalizedHeight chainFinalizedHeightFn // Retrieves the current chain's finalized height
+	rankHeader           headerRankFn           // Ranks a header among the ones at the same height
 	insertHeaders        headersInsertFn        // Injects a batch of headers into the chain
 	insertChain          chainInsertFn          // Injects a batch of blocks into the chain
 	dropPeer             peerDropFn             // Drops a peer for misbehaving
@@ -205,7 +229,7 @@ type BlockFetcher struct {
 // NewBlockFetcher creates a block fetcher to retrieve blocks based on hash announcements.
 func NewBlockFetcher(light bool, getHeader HeaderRetrievalFn, getBlock blockRetrievalFn, verifyHeader headerVerifierFn,
 	broadcastBlock blockBroadcasterFn, chainHeight chainHeightFn, chainFinalizedHeight chainFinalizedHeightFn,
-	insertHeaders headersInsertFn, insertChain chainInsertFn, dropPeer peerDropFn) *BlockFetcher {
+	rankHeader headerRankFn, insertHeaders headersInsertFn, insertChain chainInsertFn, dropPeer peerDropFn) *BlockFetcher {
 	return &BlockFetcher{
 		light:                light,
 		notify:               make(chan *blockAnnounce),
@@ -228,6 +252,7 @@ func NewBlockFetcher(light bool, getHeader HeaderRetrievalFn, getBlock blockRetr
 		broadcastBlock:       broadcastBlock,
 		chainHeight:          chainHeight,
 		chainFinalizedHeight: chainFinalizedHeight,
+		rankHeader:           rankHeader,
 		insertHeaders:        insertHeaders,
 		insertChain:          insertChain,
 		dropPeer:             dropPeer,
@@ -367,7 +392,7 @@ func (f *BlockFetcher) loop() {
 			// If too high up the chain or phase, continue later
 			number := op.number()
 			if number > height+1 {
-				f.queue.Push(op, -int64(number))
+				f.queue.Push(op, op.priority())
 				if f.queueChangeHook != nil {
 					f.queueChangeHook(hash, true)
 				}
@@ -809,19 +834,30 @@ func (f *BlockFetcher) enqueue(peer string, header *types.Header, block *types.B
 	}
 	// Schedule the block for future importing
 	if _, ok := f.queued[hash]; !ok {
-		op := &blockOrHeaderInject{origin: peer}
+		op := &blockOrHeaderInject{origin: peer, rank: RankOutOfTurn}
 		if header != nil {
 			op.header = header
 		} else {
 			op.block = block
 		}
+		if f.rankHeader != nil {
+			if header == nil {
+				header = block.Header()
+			}
+			switch op.rank = min(max(f.rankHeader(header), RankInactive), RankInTurn); op.rank {
+			case RankInTurn:
+				blockRankInTurnMeter.Mark(1)
+			case RankInactive:
+				blockRankInactiveMeter.Mark(1)
+			}
+		}
 		f.queues[peer] = count
 		f.queued[hash] = op
-		f.queue.Push(op, -int64(number))
+		f.queue.Push(op, op.priority())
 		if f.queueChangeHook != nil {
 			f.queueChangeHook(hash, true)
 		}
-		log.Debug("Queued delivered header or block", "peer", peer, "number", number, "hash", hash, "queued", f.queue.Size())
+		log.Debug("Queued delivered header or block", "peer", peer, "number", number, "hash", hash, "rank", op.rank, "queued", f.queue.Size())
 	}
 }
 
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/prque"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -103,7 +104,7 @@ func newTester(light bool) *fetcherTester {
 		drops:   make(map[string]bool),
 	}
 	tester.fetcher = NewBlockFetcher(light, tester.getHeader, tester.getBlock, tester.verifyHeader,
-		tester.broadcastBlock, tester.chainHeight, tester.chainFinalizedHeight, tester.insertHeaders,
+		tester.broadcastBlock, tester.chainHeight, tester.chainFinalizedHeight, nil, tester.insertHeaders,
 		tester.insertChain, tester.dropPeer)
 	tester.fetcher.Start()
 
@@ -1021,3 +1022,25 @@ func TestBlockMemoryExhaustionAttack(t *testing.T) {
 	}
 	verifyImportDone(t, imported)
 }
+
+// Tests that the queued blocks are imported in the order of the block number,
+// and of the rank within the same block number.
+func TestRankedImportOrder(t *testing.T) {
+	var (
+		queue = prque.New[int64, *blockOrHeaderInject](nil)
+		ops   = []*blockOrHeaderInject{
+			{origin: "inactive", rank: RankInactive, header: &types.Header{Number: big.NewInt(1)}},
+			{origin: "outofturn", rank: RankOutOfTurn, header: &types.Header{Number: big.NewInt(1)}},
+			{origin: "next", rank: RankInTurn, header: &types.Header{Number: big.NewInt(2)}},
+			{origin: "inturn", rank: RankInTurn, header: &types.Header{Number: big.NewInt(1)}},
+		}
+	)
+	for _, op := range ops {
+		queue.Push(op, op.priority())
+	}
+	for _, want := range []string{"inturn", "outofturn", "inactive", "next"} {
+		if have := queue.PopItem().origin; have != want {
+			t.Fatalf("import order mismatch: have %s, want %s", have, want)
+		}
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/gopool"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -296,6 +297,10 @@ func (api *FilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 
 	rpcSub := notifier.CreateSubscription()
 
+	if api.sys.cfg.FinalityHeads {
+		go api.notifyHeadsWithFinality(notifier, rpcSub)
+		return rpcSub, nil
+	}
 	go func() {
 		headers := make(chan *types.Header)
 		headersSub := api.events.SubscribeNewHeads(headers)
@@ -316,6 +321,29 @@ func (api *FilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 	return rpcSub, nil
 }
 
+// notifyHeadsWithFinality sends the headers of the imported blocks along with
+// the justified and finalized block numbers at each block, so that the clients
+// don't need to poll the finality on every block.
+func (api *FilterAPI) notifyHeadsWithFinality(notifier *rpc.Notifier, rpcSub *rpc.Subscription) {
+	heads := make(chan core.ChainEvent)
+	headsSub := api.events.SubscribeNewHeadsWithFinality(heads)
+	defer headsSub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-heads:
+			head := ethapi.RPCMarshalHeader(ev.Block.Header())
+			head["justifiedNumber"] = hexutil.Uint64(ev.JustifiedNumber)
+			head["finalizedNumber"] = hexutil.Uint64(ev.FinalizedNumber)
+			notifier.Notify(rpcSub.ID, head)
+		case <-rpcSub.Err():
+			return
+		case <-notifier.Closed():
+			return
+		}
+	}
+}
+
 // NewFinalizedHeaderFilter creates a filter that fetches finalized headers that are reached.
 func (api *FilterAPI) NewFinalizedHeaderFilter() rpc.ID {
 	var (
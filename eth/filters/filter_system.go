@@ -41,8 +41,9 @@ import (
 
 // Config represents the configuration of the filter system.
 type Config struct {
-	LogCacheSize int           // maximum number of cached blocks (default: 32)
-	Timeout      time.Duration // how long filters stay active (default: 5min)
+	LogCacheSize  int           // maximum number of cached blocks (default: 32)
+	Timeout       time.Duration // how long filters stay active (default: 5min)
+	FinalityHeads bool          // whether the newHeads payloads include the justified and finalized numbers
 }
 
 func (cfg Config) withDefaults() Config {
@@ -196,6 +197,7 @@ type subscription struct {
 	logs      chan []*types.Log
 	txs       chan []*types.Transaction
 	headers   chan *types.Header
+	heads     chan core.ChainEvent // receives the chain events instead of the headers if set
 	votes     chan *types.VoteEnvelope
 	installed chan struct{} // closed when the filter is installed
 	err       chan error    // closed when the filter is uninstalled
@@ -304,6 +306,7 @@ func (sub *Subscription) Unsubscribe() {
 			case <-sub.f.logs:
 			case <-sub.f.txs:
 			case <-sub.f.headers:
+			case <-sub.f.heads:
 			case <-sub.f.votes:
 			}
 		}
@@ -435,6 +438,25 @@ func (es *EventSystem) SubscribeNewHeads(headers chan *types.Header) *Subscripti
 	return es.subscribe(sub)
 }
 
+// SubscribeNewHeadsWithFinality creates a subscription that writes the chain
+// event of a block that is imported in the chain, carrying the justified and
+// finalized block numbers along with the block.
+func (es *EventSystem) SubscribeNewHeadsWithFinality(heads chan core.ChainEvent) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       BlocksSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		txs:       make(chan []*types.Transaction),
+		headers:   make(chan *types.Header),
+		heads:     heads,
+		votes:     make(chan *types.VoteEnvelope),
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
 // SubscribeNewFinalizedHeaders creates a subscription that writes the finalized header of a block that is
 // reached recently.
 func (es *EventSystem) SubscribeNewFinalizedHeaders(headers chan *types.Header) *Subscription {
@@ -520,7 +542,11 @@ func (es *EventSystem) handleTxsEvent(filters filterIndex, ev core.NewTxsEvent)
 
 func (es *EventSystem) handleChainEvent(filters filterIndex, ev core.ChainEvent) {
 	for _, f := range filters[BlocksSubscription] {
-		f.headers <- ev.Block.Header()
+		if f.heads != nil {
+			f.heads <- ev
+		} else {
+			f.headers <- ev.Block.Header()
+		}
 	}
 	if es.lightMode && len(filters[LogsSubscription]) > 0 {
 		es.lightFilterNewHead(ev.Block.Header(), func(header *types.Header, remove bool) {
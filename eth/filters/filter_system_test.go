@@ -253,6 +253,64 @@ func TestBlockSubscription(t *testing.T) {
 	<-sub1.Err()
 }
 
+// TestBlockSubscriptionWithFinality tests if a block subscription with finality
+// receives the justified and finalized numbers of the posted chain events, next
+// to a plain block subscription.
+func TestBlockSubscriptionWithFinality(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{FinalityHeads: true})
+		api          = NewFilterAPI(sys, false)
+		genesis      = &core.Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		_, chain, _ = core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 5, func(i int, gen *core.BlockGen) {})
+		chainEvents = []core.ChainEvent{}
+	)
+	for i, blk := range chain {
+		chainEvents = append(chainEvents, core.ChainEvent{Hash: blk.Hash(), Block: blk, JustifiedNumber: uint64(i), FinalizedNumber: uint64(i / 2)})
+	}
+
+	chan0 := make(chan core.ChainEvent)
+	sub0 := api.events.SubscribeNewHeadsWithFinality(chan0)
+	chan1 := make(chan *types.Header)
+	sub1 := api.events.SubscribeNewHeads(chan1)
+
+	go func() { // simulate client
+		i0, i1 := 0, 0
+		for i0 != len(chainEvents) || i1 != len(chainEvents) {
+			select {
+			case ev := <-chan0:
+				want := chainEvents[i0]
+				if ev.Hash != want.Hash || ev.JustifiedNumber != want.JustifiedNumber || ev.FinalizedNumber != want.FinalizedNumber {
+					t.Errorf("sub0 received invalid event on index %d, want %x (%d/%d), got %x (%d/%d)", i0,
+						want.Hash, want.JustifiedNumber, want.FinalizedNumber, ev.Hash, ev.JustifiedNumber, ev.FinalizedNumber)
+				}
+				i0++
+			case header := <-chan1:
+				if chainEvents[i1].Hash != header.Hash() {
+					t.Errorf("sub1 received invalid hash on index %d, want %x, got %x", i1, chainEvents[i1].Hash, header.Hash())
+				}
+				i1++
+			}
+		}
+
+		sub0.Unsubscribe()
+		sub1.Unsubscribe()
+	}()
+
+	time.Sleep(1 * time.Second)
+	for _, e := range chainEvents {
+		backend.chainFeed.Send(e)
+	}
+
+	<-sub0.Err()
+	<-sub1.Err()
+}
+
 // TestPendingTxFilter tests whether pending tx filters retrieve all pending transactions that are posted to the event mux.
 func TestPendingTxFilter(t *testing.T) {
 	t.Parallel()
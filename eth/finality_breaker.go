@@ -0,0 +1,214 @@
+package eth
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	finalityBreakerTrippedGauge = metrics.NewRegisteredGauge("oasys/finality/breaker/tripped", nil)
+	finalityBreakerTripsCounter = metrics.NewRegisteredCounter("oasys/finality/breaker/trips", nil)
+)
+
+// finalityEngine is the part of the consensus engine used by the finality breaker.
+type finalityEngine interface {
+	GetJustifiedNumberAndHash(chain consensus.ChainHeaderReader, headers []*types.Header) (uint64, common.Hash, error)
+}
+
+// finalityBreaker freezes the latest and the safe blocks served over RPC while
+// the finality stalls, so that the exchanges and the other consumers relying on
+// them don't act on the blocks which may be reorged. It trips once no block has
+// been justified for the limit of blocks, and resets once a block within the
+// limit of the head is justified again. The frozen blocks follow the reorgs, so
+// that no block dropped from the chain is served.
+type finalityBreaker struct {
+	chain  *core.BlockChain
+	engine finalityEngine
+
+	lock     sync.RWMutex
+	limit    uint64        // Number of the blocks without a justified block to trip, zero if disabled, protected by lock
+	latest   *types.Header // Latest block frozen while tripped, protected by lock
+	safe     *types.Header // Safe block frozen while tripped, protected by lock
+	released bool          // Whether released by the operator until the finality recovers, protected by lock
+	head     *types.Header // Last head checked, protected by lock
+	lag      uint64        // Blocks since the justified block at the last head, protected by lock
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newFinalityBreaker(chain *core.BlockChain, engine finalityEngine, limit uint64) *finalityBreaker {
+	return &finalityBreaker{
+		chain:  chain,
+		engine: engine,
+		limit:  limit,
+		quit:   make(chan struct{}),
+	}
+}
+
+func (b *finalityBreaker) start() {
+	b.wg.Add(1)
+	go b.loop()
+}
+
+func (b *finalityBreaker) stop() {
+	close(b.quit)
+	b.wg.Wait()
+}
+
+func (b *finalityBreaker) loop() {
+	defer b.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 1)
+	sub := b.chain.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-headCh:
+			b.update(ev.Block.Header())
+		case <-sub.Err():
+			return
+		case <-b.quit:
+			return
+		}
+	}
+}
+
+// update checks the finality at the new chain head, tripping or resetting the
+// breaker.
+func (b *finalityBreaker) update(head *types.Header) {
+	justified, _, err := b.engine.GetJustifiedNumberAndHash(b.chain, []*types.Header{head})
+	if err != nil {
+		log.Debug("Failed to retrieve justified block for finality breaker", "number", head.Number, "err", err)
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.head, b.lag = head, head.Number.Uint64()-justified
+	if b.latest != nil {
+		b.latest = b.canonical(b.latest, head)
+		if b.safe != nil {
+			b.safe = b.canonical(b.safe, head)
+		}
+	}
+	stalled := b.limit > 0 && b.lag >= b.limit
+	switch {
+	case stalled && b.latest == nil && !b.released:
+		b.trip()
+	case !stalled:
+		if b.latest != nil {
+			log.Warn("Finality recovered, resuming the latest and safe blocks served over RPC", "head", head.Number, "justified", justified)
+		}
+		b.reset()
+	}
+}
+
+// canonical returns the frozen header if it's still canonical, or the canonical
+// one at its number, not beyond the head, if it was reorged out.
+func (b *finalityBreaker) canonical(header, head *types.Header) *types.Header {
+	number := header.Number.Uint64()
+	if number > head.Number.Uint64() {
+		number = head.Number.Uint64()
+	}
+	if number == header.Number.Uint64() && b.chain.GetCanonicalHash(number) == header.Hash() {
+		return header
+	}
+	if canonical := b.chain.GetHeaderByNumber(number); canonical != nil {
+		return canonical
+	}
+	return head
+}
+
+// trip freezes the latest and the safe blocks at the last head, protected by lock.
+func (b *finalityBreaker) trip() {
+	b.latest, b.safe = b.head, b.chain.CurrentSafeBlock()
+	finalityBreakerTrippedGauge.Update(1)
+	finalityBreakerTripsCounter.Inc(1)
+	log.Error("Finality stalled, freezing the latest and safe blocks served over RPC",
+		"head", b.head.Number, "lag", b.lag, "limit", b.limit)
+}
+
+// reset resumes serving the actual blocks, protected by lock.
+func (b *finalityBreaker) reset() {
+	b.latest, b.safe, b.released = nil, nil, false
+	finalityBreakerTrippedGauge.Update(0)
+}
+
+// frozen returns the latest and the safe blocks at the time the breaker tripped,
+// which cap the ones served over RPC, or nil if not tripped.
+func (b *finalityBreaker) frozen() (*types.Header, *types.Header) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.latest, b.safe
+}
+
+// FinalityBreakerStatus is the state of the finality circuit breaker.
+type FinalityBreakerStatus struct {
+	Limit    hexutil.Uint64  `json:"limit"` // Zero if disabled
+	Lag      hexutil.Uint64  `json:"lag"`   // Blocks since the justified block at the head
+	Tripped  bool            `json:"tripped"`
+	Released bool            `json:"released"`
+	Latest   *hexutil.Uint64 `json:"latest,omitempty"` // Latest block served while tripped
+	Safe     *hexutil.Uint64 `json:"safe,omitempty"`   // Safe block served while tripped
+}
+
+func (b *finalityBreaker) status() *FinalityBreakerStatus {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	status := &FinalityBreakerStatus{
+		Limit:    hexutil.Uint64(b.limit),
+		Lag:      hexutil.Uint64(b.lag),
+		Tripped:  b.latest != nil,
+		Released: b.released,
+	}
+	if b.latest != nil {
+		latest := hexutil.Uint64(b.latest.Number.Uint64())
+		status.Latest = &latest
+	}
+	if b.safe != nil {
+		safe := hexutil.Uint64(b.safe.Number.Uint64())
+		status.Safe = &safe
+	}
+	return status
+}
+
+// setLimit changes the limit, zero disables the breaker and resumes serving the
+// actual blocks. The breaker trips at once if the finality already lags behind
+// the new limit.
+func (b *finalityBreaker) setLimit(limit uint64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.limit = limit
+	switch {
+	case limit == 0 || b.lag < limit:
+		b.reset()
+	case b.latest == nil && !b.released && b.head != nil:
+		b.trip()
+	}
+	log.Info("Updated finality breaker", "limit", limit)
+}
+
+// release resumes serving the actual blocks while the finality still stalls,
+// until it recovers and stalls again.
+func (b *finalityBreaker) release() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.latest != nil {
+		log.Warn("Finality breaker released by the operator", "lag", b.lag)
+	}
+	b.latest, b.safe, b.released = nil, nil, true
+	finalityBreakerTrippedGauge.Update(0)
+}
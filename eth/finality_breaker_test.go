@@ -0,0 +1,180 @@
+package eth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testPoS carries the methods of the PoS engine not used by the tests.
+type testPoS struct{ consensus.PoS }
+
+// testFinalityEngine is a fake PoS engine justifying the block of a fixed number
+// on any branch, which also drives the safe block of the chain.
+type testFinalityEngine struct {
+	*ethash.Ethash
+	testPoS
+	justified uint64
+}
+
+func (e *testFinalityEngine) GetJustifiedNumberAndHash(chain consensus.ChainHeaderReader, headers []*types.Header) (uint64, common.Hash, error) {
+	header := headers[0]
+	for header.Number.Uint64() > e.justified {
+		header = chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	return header.Number.Uint64(), header.Hash(), nil
+}
+
+func (e *testFinalityEngine) GetFinalizedHeader(chain consensus.ChainHeaderReader, header *types.Header) *types.Header {
+	return nil
+}
+
+func TestFinalityBreaker(t *testing.T) {
+	var (
+		engine  = &testFinalityEngine{Ethash: ethash.NewFaker()}
+		genesis = &core.Genesis{Config: params.TestChainConfig, Alloc: types.GenesisAlloc{}}
+	)
+	// Two forks sharing the first block, the longer one replacing the other
+	generate := func(n int, coinbase common.Address) []*types.Block {
+		_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), n, func(i int, gen *core.BlockGen) {
+			if i > 0 {
+				gen.SetCoinbase(coinbase)
+			}
+		})
+		return blocks
+	}
+	short, long := generate(4, common.Address{0x01}), generate(5, common.Address{0x02})
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(short); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	var (
+		breaker = newFinalityBreaker(chain, engine, 3)
+		eth     = &Ethereum{blockchain: chain, config: &ethconfig.Config{}, finalityBreaker: breaker}
+		api     = NewAdminAPI(eth)
+		backend = &EthAPIBackend{eth: eth}
+	)
+	served := func(number rpc.BlockNumber) common.Hash {
+		t.Helper()
+		header, err := backend.HeaderByNumber(context.Background(), number)
+		if err != nil {
+			t.Fatalf("failed to get header %d: %v", number, err)
+		}
+		block, err := backend.BlockByNumber(context.Background(), number)
+		if err != nil {
+			t.Fatalf("failed to get block %d: %v", number, err)
+		}
+		if block.Hash() != header.Hash() {
+			t.Fatalf("block %d mismatch: header %x, block %x", number, header.Hash(), block.Hash())
+		}
+		return header.Hash()
+	}
+	check := func(name string, tripped, released bool, latest, safe *types.Block) {
+		t.Helper()
+		status, err := api.FinalityBreaker()
+		if err != nil {
+			t.Fatalf("%s: failed to get status: %v", name, err)
+		}
+		if status.Tripped != tripped || status.Released != released {
+			t.Fatalf("%s: status mismatch: %+v", name, status)
+		}
+		if have := served(rpc.LatestBlockNumber); have != latest.Hash() {
+			t.Fatalf("%s: latest mismatch: have %x, want %x", name, have, latest.Hash())
+		}
+		if have := served(rpc.SafeBlockNumber); have != safe.Hash() {
+			t.Fatalf("%s: safe mismatch: have %x, want %x", name, have, safe.Hash())
+		}
+	}
+
+	// Trips once no block has been justified for the limit, and caps the
+	// blocks served at the ones of that time
+	root := chain.Genesis()
+	breaker.update(short[1].Header())
+	check("within limit", false, false, short[3], root)
+
+	breaker.update(short[2].Header())
+	check("tripped", true, false, short[2], root)
+
+	engine.justified = 1
+	breaker.update(short[3].Header())
+	check("still stalled", true, false, short[2], root)
+
+	// Follows the reorg to the canonical block at the same number
+	if _, err := chain.InsertChain(long[1:]); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	breaker.update(long[4].Header())
+	check("reorged", true, false, long[2], root)
+
+	// Recovers once a block within the limit is justified
+	engine.justified = 3
+	breaker.update(long[4].Header())
+	check("recovered", false, false, long[4], long[2])
+
+	// Stays released until the finality recovers and stalls again
+	engine.justified = 0
+	breaker.update(long[4].Header())
+	check("stalled again", true, false, long[4], root)
+
+	if _, err := api.ReleaseFinalityBreaker(); err != nil {
+		t.Fatalf("failed to release: %v", err)
+	}
+	breaker.update(long[4].Header())
+	check("released", false, true, long[4], root)
+
+	engine.justified = 4
+	breaker.update(long[4].Header())
+	engine.justified = 0
+	breaker.update(long[4].Header())
+	check("stalled after release", true, false, long[4], root)
+
+	// Disabling resumes at once, and a limit already exceeded trips at once
+	if _, err := api.SetFinalityBreaker(0); err != nil {
+		t.Fatalf("failed to disable: %v", err)
+	}
+	check("disabled", false, false, long[4], root)
+
+	status, err := api.SetFinalityBreaker(10)
+	if err != nil {
+		t.Fatalf("failed to set limit: %v", err)
+	}
+	if status.Tripped || status.Limit != 10 || status.Lag != 5 {
+		t.Fatalf("status mismatch within limit: %+v", status)
+	}
+	status, err = api.SetFinalityBreaker(4)
+	if err != nil {
+		t.Fatalf("failed to set limit: %v", err)
+	}
+	if !status.Tripped || status.Latest == nil || *status.Latest != hexutil.Uint64(5) {
+		t.Fatalf("status mismatch beyond limit: %+v", status)
+	}
+}
+
+func TestFinalityBreakerAPIDisabled(t *testing.T) {
+	api := NewAdminAPI(&Ethereum{})
+	if _, err := api.FinalityBreaker(); err == nil {
+		t.Fatal("status served without breaker")
+	}
+	if _, err := api.SetFinalityBreaker(1); err == nil {
+		t.Fatal("limit set without breaker")
+	}
+	if _, err := api.ReleaseFinalityBreaker(); err == nil {
+		t.Fatal("released without breaker")
+	}
+}
@@ -18,6 +18,7 @@ package gasprice
 
 import (
 	"context"
+	"math"
 	"math/big"
 	"slices"
 	"sync"
@@ -34,6 +35,11 @@ import (
 
 const sampleNumber = 3 // Number of transactions sampled in a block
 
+// ethereumBlockTime is the block time the default sample size of the oracle is
+// tuned for. The sample size is scaled up on the chains with a shorter one, so
+// that the suggestion covers the same period of time.
+const ethereumBlockTime = 12
+
 var (
 	DefaultMaxPrice    = big.NewInt(500 * params.GWei)
 	DefaultIgnorePrice = big.NewInt(2 * params.Wei)
@@ -46,6 +52,7 @@ type Config struct {
 	MaxBlockHistory  uint64
 	MaxPrice         *big.Int `toml:",omitempty"`
 	IgnorePrice      *big.Int `toml:",omitempty"`
+	MinPrice         *big.Int `toml:",omitempty"` // Minimum tip accepted by the validators of Oasys, nil for the start price
 }
 
 // OracleBackend includes all necessary background APIs for oracle.
@@ -66,6 +73,7 @@ type Oracle struct {
 	lastPrice   *big.Int
 	maxPrice    *big.Int
 	ignorePrice *big.Int
+	floorPrice  *big.Int // Minimum tip accepted by the validators, nil if the chain has none
 	cacheLock   sync.RWMutex
 	fetchLock   sync.Mutex
 
@@ -116,6 +124,24 @@ func NewOracle(backend OracleBackend, params Config, startPrice *big.Int) *Oracl
 	if startPrice == nil {
 		startPrice = new(big.Int)
 	}
+	// The validators of Oasys accept a fixed minimum tip and produce blocks more
+	// often than Ethereum, so most blocks are empty or priced at the minimum.
+	// Sample the same period of time as on Ethereum, and never suggest below the
+	// minimum so that the suggestion stays stable over the empty blocks.
+	var floorPrice *big.Int
+	if config := backend.ChainConfig(); config != nil && config.Oasys != nil {
+		floorPrice = startPrice
+		if params.MinPrice != nil && params.MinPrice.Sign() > 0 {
+			floorPrice = params.MinPrice
+		}
+		if startPrice.Cmp(floorPrice) < 0 {
+			startPrice = floorPrice
+		}
+		if blockPeriod, _ := config.OasysPeriods(math.MaxUint64); blockPeriod > 0 && blockPeriod < ethereumBlockTime {
+			blocks = blocks * ethereumBlockTime / int(blockPeriod)
+		}
+		log.Info("Gasprice oracle is using the Oasys minimum price", "floor", floorPrice, "blocks", blocks)
+	}
 
 	cache := lru.NewCache[cacheKey, processedFees](2048)
 	headEvent := make(chan core.ChainHeadEvent, 1)
@@ -135,6 +161,7 @@ func NewOracle(backend OracleBackend, params Config, startPrice *big.Int) *Oracl
 		lastPrice:        startPrice,
 		maxPrice:         maxPrice,
 		ignorePrice:      ignorePrice,
+		floorPrice:       floorPrice,
 		checkBlocks:      blocks,
 		percentile:       percent,
 		maxHeaderHistory: maxHeaderHistory,
@@ -193,9 +220,14 @@ func (oracle *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
 		// Nothing returned. There are two special cases here:
 		// - The block is empty
 		// - All the transactions included are sent by the miner itself.
-		// In these cases, use the latest calculated price for sampling.
+		// In these cases, use the latest calculated price for sampling, or the
+		// minimum price if the chain has one.
 		if len(res.values) == 0 {
-			res.values = []*big.Int{lastPrice}
+			if oracle.floorPrice != nil {
+				res.values = []*big.Int{oracle.floorPrice}
+			} else {
+				res.values = []*big.Int{lastPrice}
+			}
 		}
 		// Besides, in order to collect enough data for sampling, if nothing
 		// meaningful returned, try to query more blocks. But the maximum
@@ -213,6 +245,9 @@ func (oracle *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
 		slices.SortFunc(results, func(a, b *big.Int) int { return a.Cmp(b) })
 		price = results[(len(results)-1)*oracle.percentile/100]
 	}
+	if oracle.floorPrice != nil && price.Cmp(oracle.floorPrice) < 0 {
+		price = new(big.Int).Set(oracle.floorPrice)
+	}
 	if price.Cmp(oracle.maxPrice) > 0 {
 		price = new(big.Int).Set(oracle.maxPrice)
 	}
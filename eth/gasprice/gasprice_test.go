@@ -259,3 +259,100 @@ func TestSuggestTipCap(t *testing.T) {
 		}
 	}
 }
+
+// emptyBackend serves a chain of empty blocks with the given chain config.
+type emptyBackend struct {
+	config *params.ChainConfig
+	blocks []*types.Block
+}
+
+func newEmptyBackend(config *params.ChainConfig, n int) *emptyBackend {
+	b := &emptyBackend{config: config}
+	parent := common.Hash{}
+	for i := 0; i <= n; i++ {
+		header := &types.Header{
+			ParentHash: parent,
+			Number:     big.NewInt(int64(i)),
+			GasLimit:   30_000_000,
+			BaseFee:    big.NewInt(0),
+			Time:       uint64(i) * 6,
+		}
+		block := types.NewBlockWithHeader(header)
+		b.blocks = append(b.blocks, block)
+		parent = block.Hash()
+	}
+	return b
+}
+
+func (b *emptyBackend) block(number rpc.BlockNumber) *types.Block {
+	if number < 0 {
+		return b.blocks[len(b.blocks)-1]
+	}
+	if int(number) >= len(b.blocks) {
+		return nil
+	}
+	return b.blocks[number]
+}
+
+func (b *emptyBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if block := b.block(number); block != nil {
+		return block.Header(), nil
+	}
+	return nil, nil
+}
+
+func (b *emptyBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	return b.block(number), nil
+}
+
+func (b *emptyBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return types.Receipts{}, nil
+}
+
+func (b *emptyBackend) PendingBlockAndReceipts() (*types.Block, types.Receipts) {
+	return nil, nil
+}
+
+func (b *emptyBackend) ChainConfig() *params.ChainConfig {
+	return b.config
+}
+
+func (b *emptyBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return nil
+}
+
+func TestSuggestTipCapOasys(t *testing.T) {
+	config := *params.TestChainConfig
+	config.Oasys = &params.OasysConfig{Period: 15, Epoch: 40}
+	backend := newEmptyBackend(&config, 64)
+
+	// The suggestion is not dragged by the start price over the empty blocks.
+	minPrice := big.NewInt(params.GWei)
+	oracle := NewOracle(backend, Config{Blocks: 20, Percentile: 60, MaxHeaderHistory: 100, MaxBlockHistory: 100, MinPrice: minPrice}, big.NewInt(params.Wei))
+	if want := 20 * ethereumBlockTime / params.SHORT_BLOCK_TIME_SECONDS; oracle.checkBlocks != want {
+		t.Fatalf("sample blocks mismatch, want %d, got %d", want, oracle.checkBlocks)
+	}
+	got, err := oracle.SuggestTipCap(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to retrieve recommended gas price: %v", err)
+	}
+	if got.Cmp(minPrice) != 0 {
+		t.Fatalf("Gas price mismatch, want %d, got %d", minPrice, got)
+	}
+
+	// The fee history reports the actual rewards of the empty blocks.
+	_, reward, _, _, _, _, err := oracle.FeeHistory(context.Background(), 4, rpc.LatestBlockNumber, []float64{10, 90})
+	if err != nil {
+		t.Fatalf("Failed to retrieve fee history: %v", err)
+	}
+	if len(reward) != 4 {
+		t.Fatalf("reward rows mismatch, want 4, got %d", len(reward))
+	}
+	for i, row := range reward {
+		for j, r := range row {
+			if r.Sign() != 0 {
+				t.Fatalf("reward %d/%d mismatch, want 0, got %d", i, j, r)
+			}
+		}
+	}
+}
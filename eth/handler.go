@@ -25,8 +25,10 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/core/monitor"
@@ -61,10 +63,20 @@ const (
 	// All transactions with a higher size will be announced and need to be fetched
 	// by the peer.
 	txMaxBroadcastSize = 4096
+
+	// expectedProposers is the number of upcoming proposers whose peers receive
+	// the propagated blocks first.
+	expectedProposers = 3
+
+	// proposerPeersLimit is the maximum number of validators to remember the
+	// delivering peers for.
+	proposerPeersLimit = 256
 )
 
 var syncChallengeTimeout = 15 * time.Second // Time allowance for a node to reply to the sync progress challenge
 
+var syncVerifyRetryInterval = 30 * time.Second // Interval between the attempts to verify the snap-synced state
+
 // txPool defines the methods needed from a transaction pool implementation to
 // support all the operations needed by the Ethereum chain protocols.
 type txPool interface {
@@ -92,7 +104,7 @@ type txPool interface {
 // votePool defines the methods needed from a votes pool implementation to
 // support all the operations needed by the Ethereum chain protocols.
 type votePool interface {
-	PutVote(vote *types.VoteEnvelope)
+	PutPeerVote(vote *types.VoteEnvelope, onInvalid func(error))
 	GetVotes() []*types.VoteEnvelope
 
 	// SubscribeNewVoteEvent should return an event subscription of
@@ -113,7 +125,8 @@ type handlerConfig struct {
 	EventMux       *event.TypeMux         // Legacy event mux, deprecate for `feed`
 	RequiredBlocks map[uint64]common.Hash // Hard coded map of required block hashes for sync challenges
 	// for finality
-	VotePool votePool
+	VotePool           votePool
+	VoteProposersFirst bool // Whether to send the votes to the peers of the upcoming proposers first
 }
 
 type handler struct {
@@ -151,6 +164,11 @@ type handler struct {
 
 	requiredBlocks map[uint64]common.Hash
 
+	// Peers which first delivered the blocks proposed by each validator, they
+	// are most likely operated by or directly connected to the validator.
+	proposerPeers      *lru.Cache[common.Address, proposerPeer]
+	voteProposersFirst bool // Whether to send the votes to the peers of the upcoming proposers first
+
 	// channels for fetcher, syncer, txsyncLoop
 	quitSync chan struct{}
 
@@ -161,6 +179,12 @@ type handler struct {
 	handlerDoneCh  chan struct{}
 }
 
+// proposerPeer is the peer which first delivered the latest block of a validator.
+type proposerPeer struct {
+	hash common.Hash
+	peer string
+}
+
 // newHandler returns a handler for all Ethereum chain management protocol.
 func newHandler(config *handlerConfig) (*handler, error) {
 	// Create the protocol manager with the base fields
@@ -178,9 +202,12 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		peers:          newPeerSet(),
 		merger:         config.Merger,
 		requiredBlocks: config.RequiredBlocks,
+		proposerPeers:  lru.NewCache[common.Address, proposerPeer](proposerPeersLimit),
 		quitSync:       make(chan struct{}),
 		handlerDoneCh:  make(chan struct{}),
 		handlerStartCh: make(chan struct{}),
+
+		voteProposersFirst: config.VoteProposersFirst,
 	}
 	if config.Sync == downloader.FullSync {
 		// The database seems empty as the current block is the genesis. Yet the snap
@@ -306,7 +333,17 @@ func newHandler(config *handlerConfig) (*handler, error) {
 			}
 			return 0, nil
 		}
-		return h.chain.InsertChain(blocks)
+		n, err := h.chain.InsertChain(blocks)
+		if engine, ok := h.chain.Engine().(*oasys.Oasys); ok {
+			imported := blocks
+			if err != nil {
+				imported = blocks[:n]
+			}
+			for _, block := range imported {
+				engine.MarkBlockReceived(h.chain, block.Header(), block.ReceivedAt)
+			}
+		}
+		return n, err
 	}
 
 	broadcastBlockWithCheck := func(block *types.Block, propagate bool) {
@@ -320,7 +357,7 @@ func newHandler(config *handlerConfig) (*handler, error) {
 	}
 
 	h.blockFetcher = fetcher.NewBlockFetcher(false, nil, h.chain.GetBlockByHash, validator, broadcastBlockWithCheck,
-		heighter, finalizeHeighter, nil, inserter, h.removePeer)
+		heighter, finalizeHeighter, h.rankHeader, nil, inserter, h.removePeer)
 
 	fetchTx := func(peer string, hashes []common.Hash) error {
 		p := h.peers.peer(peer)
@@ -456,6 +493,9 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 	if h.votepool != nil && p.bscExt != nil {
 		h.syncVotes(p.bscExt)
 	}
+	if p.bscExt != nil {
+		h.syncConsensusSnapshot(p.bscExt)
+	}
 
 	// Create a notification channel for pending requests if the peer goes down
 	dead := make(chan struct{})
@@ -680,7 +720,10 @@ func (h *handler) BroadcastBlock(block *types.Block, propagate bool) {
 			log.Error("Propagating dangling block", "number", block.Number(), "hash", hash)
 			return
 		}
-		// Send the block to a subset of our peers
+		// Send the block to the peers of the upcoming proposers first, so that
+		// they can build on top of it without waiting for the gossip, then to a
+		// subset of the rest of our peers
+		peers = h.prioritizeProposerPeers(block, peers)
 		transfer := peers[:int(math.Sqrt(float64(len(peers))))]
 		for _, peer := range transfer {
 			peer.AsyncSendNewBlock(block, td)
@@ -697,6 +740,131 @@ func (h *handler) BroadcastBlock(block *types.Block, propagate bool) {
 	}
 }
 
+// prioritizeProposerPeers moves the peers known to deliver the blocks of the
+// validators scheduled after the given block to the front of the peer list.
+func (h *handler) prioritizeProposerPeers(block *types.Block, peers []*ethPeer) []*ethPeer {
+	pos, ok := h.chain.Engine().(consensus.PoS)
+	if !ok || len(peers) == 0 {
+		return peers
+	}
+	parent := h.chain.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return peers
+	}
+	// The first scheduled proposer is the one of the block itself
+	proposers, err := pos.ExpectedProposers(h.chain, parent, expectedProposers+1)
+	if err != nil {
+		log.Debug("Failed to retrieve expected proposers", "number", block.Number(), "err", err)
+		return peers
+	}
+	peers, _ = h.frontProposerPeers(proposers[min(1, len(proposers)):], block.Coinbase(), peers)
+	return peers
+}
+
+// frontProposerPeers moves the peers known to deliver the blocks of the given
+// proposers, except the skipped one, to the front of the peer list, returning
+// the number of the peers moved.
+func (h *handler) frontProposerPeers(proposers []common.Address, skip common.Address, peers []*ethPeer) ([]*ethPeer, int) {
+	front := 0
+	for _, proposer := range proposers {
+		if proposer == skip {
+			continue
+		}
+		last, ok := h.proposerPeers.Get(proposer)
+		if !ok {
+			continue
+		}
+		for j := front; j < len(peers); j++ {
+			if peers[j].ID() == last.peer {
+				peers[front], peers[j] = peers[j], peers[front]
+				front++
+				break
+			}
+		}
+	}
+	return peers, front
+}
+
+// prioritizeVotePeers moves the peers known to deliver the blocks of the
+// validators scheduled after the target of the vote to the front of the peer
+// list, as their proposers need the vote to assemble the attestation.
+func (h *handler) prioritizeVotePeers(vote *types.VoteEnvelope, peers []*ethPeer) ([]*ethPeer, int) {
+	pos, ok := h.chain.Engine().(consensus.PoS)
+	if !ok || len(peers) == 0 {
+		return peers, 0
+	}
+	target := h.chain.GetHeader(vote.Data.TargetHash, vote.Data.TargetNumber)
+	if target == nil {
+		return peers, 0
+	}
+	proposers, err := pos.ExpectedProposers(h.chain, target, expectedProposers)
+	if err != nil {
+		log.Debug("Failed to retrieve expected proposers", "number", target.Number, "err", err)
+		return peers, 0
+	}
+	return h.frontProposerPeers(proposers, common.Address{}, peers)
+}
+
+// votePeers returns the peers to transfer the vote directly in the sending
+// order, starting from the peers of the upcoming proposers if requested.
+func (h *handler) votePeers(vote *types.VoteEnvelope) []*ethPeer {
+	var (
+		transfer []*ethPeer // Peers to transfer the vote directly, in order
+		front    int        // Number of the peers of the upcoming proposers
+	)
+	// Broadcast vote to a batch of peers not knowing about it
+	peers := h.peers.peersWithoutVote(vote.Hash())
+	if h.voteProposersFirst {
+		peers, front = h.prioritizeVotePeers(vote, peers)
+	}
+	headBlock := h.chain.CurrentBlock()
+	currentTD := h.chain.GetTd(headBlock.Hash(), headBlock.Number.Uint64())
+	var averageDifficulty *big.Int
+	if h.firstTDInBroadcastVote == nil || h.firstHeightInBroadcastVote == 0 {
+		h.firstTDInBroadcastVote = currentTD
+		h.firstHeightInBroadcastVote = headBlock.Number.Uint64()
+	} else if headBlock.Number.Uint64() > h.firstHeightInBroadcastVote {
+		averageDifficulty = new(big.Int).Div(new(big.Int).Sub(currentTD, h.firstTDInBroadcastVote), big.NewInt(int64(headBlock.Number.Uint64()-h.firstHeightInBroadcastVote)))
+	}
+	for i, peer := range peers {
+		_, peerTD := peer.Head()
+		deltaTD := new(big.Int).Abs(new(big.Int).Sub(currentTD, peerTD))
+		// broadcast if
+		// - bscExt is set
+		// - the peer delivers the blocks of an upcoming proposer
+		// - the first time (averageDifficulty is not set)
+		// - The total difficulty of peer is within the [+|-] range of average difficulty per block * deltaTdThreshold (blocks)
+		broadCasts := peer.bscExt != nil && (i < front || averageDifficulty == nil || deltaTD.Cmp(new(big.Int).Mul(big.NewInt(deltaTdThreshold), averageDifficulty)) < 1)
+		if broadCasts {
+			transfer = append(transfer, peer)
+		}
+	}
+	return transfer
+}
+
+// rankHeader ranks the announced block among the ones at the same height by
+// its proposer, so that the block of the in-turn validator is imported first
+// and the ones of the validators not in the active set last, resisting the
+// announcement spam during the validator set churn.
+func (h *handler) rankHeader(header *types.Header) int {
+	pos, ok := h.chain.Engine().(consensus.PoS)
+	if !ok || header.Number.Sign() == 0 || h.chain.GetHeader(header.ParentHash, header.Number.Uint64()-1) == nil {
+		return fetcher.RankOutOfTurn
+	}
+	inTurn, active, err := pos.ProposerTurn(h.chain, header)
+	switch {
+	case err != nil:
+		log.Debug("Failed to rank announced block", "number", header.Number, "hash", header.Hash(), "err", err)
+		return fetcher.RankOutOfTurn
+	case inTurn:
+		return fetcher.RankInTurn
+	case !active:
+		return fetcher.RankInactive
+	default:
+		return fetcher.RankOutOfTurn
+	}
+}
+
 // BroadcastTransactions will propagate a batch of transactions
 // - To a square root of all peers for non-blob transactions
 // - And, separately, as announcements to all peers which are not known to
@@ -716,6 +884,11 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 	)
 	// Broadcast transactions to a batch of peers not knowing about it
 	for _, tx := range txs {
+		// Keep the conditional transactions local, the peers would include them
+		// without the preconditions
+		if tx.Conditional() != nil {
+			continue
+		}
 		peers := h.peers.peersWithoutTransaction(tx.Hash())
 
 		var numDirect int
@@ -756,38 +929,11 @@ func (h *handler) BroadcastVote(vote *types.VoteEnvelope) {
 	var (
 		directCount int // Count of announcements made
 		directPeers int
-
-		voteMap = make(map[*ethPeer]*types.VoteEnvelope) // Set peer->hash to transfer directly
 	)
-
-	// Broadcast vote to a batch of peers not knowing about it
-	peers := h.peers.peersWithoutVote(vote.Hash())
-	headBlock := h.chain.CurrentBlock()
-	currentTD := h.chain.GetTd(headBlock.Hash(), headBlock.Number.Uint64())
-	var averageDifficulty *big.Int
-	if h.firstTDInBroadcastVote == nil || h.firstHeightInBroadcastVote == 0 {
-		h.firstTDInBroadcastVote = currentTD
-		h.firstHeightInBroadcastVote = headBlock.Number.Uint64()
-	} else if headBlock.Number.Uint64() > h.firstHeightInBroadcastVote {
-		averageDifficulty = new(big.Int).Div(new(big.Int).Sub(currentTD, h.firstTDInBroadcastVote), big.NewInt(int64(headBlock.Number.Uint64()-h.firstHeightInBroadcastVote)))
-	}
-	for _, peer := range peers {
-		_, peerTD := peer.Head()
-		deltaTD := new(big.Int).Abs(new(big.Int).Sub(currentTD, peerTD))
-		// broadcast if
-		// - bscExt is set
-		// - the first time (averageDifficulty is not set)
-		// - The total difficulty of peer is within the [+|-] range of average difficulty per block * deltaTdThreshold (blocks)
-		broadCasts := peer.bscExt != nil && (averageDifficulty == nil || deltaTD.Cmp(new(big.Int).Mul(big.NewInt(deltaTdThreshold), averageDifficulty)) < 1)
-		if broadCasts {
-			voteMap[peer] = vote
-		}
-	}
-
-	for peer, _vote := range voteMap {
+	for _, peer := range h.votePeers(vote) {
 		directPeers++
 		directCount += 1
-		votes := []*types.VoteEnvelope{_vote}
+		votes := []*types.VoteEnvelope{vote}
 		peer.bscExt.AsyncSendVotes(votes)
 	}
 	log.Debug("Vote broadcast", "vote packs", directPeers, "broadcast vote", directCount, "source", vote.Data.SourceNumber, "target", vote.Data.TargetNumber)
@@ -859,8 +1005,40 @@ func (h *handler) enableSyncedFeatures() {
 	if h.snapSync.Load() {
 		log.Info("Snap sync complete, auto disabling")
 		h.snapSync.Store(false)
+
+		// Cross-check the downloaded state before the node starts voting
+		if engine, ok := h.chain.Engine().(*oasys.Oasys); ok {
+			engine.HoldSyncedStateVotes()
+			h.wg.Add(1)
+			go h.verifySyncedState(engine)
+		}
 	}
 	if h.chain.TrieDB().Scheme() == rawdb.PathScheme {
 		h.chain.TrieDB().SetBufferSize(pathdb.DefaultBufferSize)
 	}
 }
+
+// verifySyncedState verifies the state downloaded by the snap sync, retrying on
+// the transient failures. The votes stay held until the verification succeeds,
+// or for good if the state mismatches the epoch headers.
+func (h *handler) verifySyncedState(engine *oasys.Oasys) {
+	defer h.wg.Done()
+
+	for {
+		err := engine.VerifySyncedState(h.chain)
+		if err == nil {
+			return
+		}
+		if errors.Is(err, oasys.ErrCorruptedSyncState) {
+			log.Error("Synced state is corrupted, resync to resume voting", "err", err)
+			return
+		}
+		log.Warn("Failed to verify the synced state, retrying", "err", err)
+
+		select {
+		case <-time.After(syncVerifyRetryInterval):
+		case <-h.quitSync:
+			return
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package eth
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/ethereum/go-ethereum/consensus/oasys"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/protocols/bsc"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 )
 
@@ -53,6 +56,12 @@ func (h *bscHandler) Handle(peer *bsc.Peer, packet bsc.Packet) error {
 	case *bsc.VotesPacket:
 		return h.handleVotesBroadcast(peer, packet.Votes)
 
+	case *bsc.GetSnapshotPacket:
+		return h.handleGetSnapshot(peer, packet)
+
+	case *bsc.SnapshotPacket:
+		return h.handleSnapshot(peer, packet)
+
 	default:
 		return fmt.Errorf("unexpected bsc packet type: %T", packet)
 	}
@@ -61,14 +70,59 @@ func (h *bscHandler) Handle(peer *bsc.Peer, packet bsc.Packet) error {
 // handleVotesBroadcast is invoked from a peer's message handler when it transmits a
 // votes broadcast for the local node to process.
 func (h *bscHandler) handleVotesBroadcast(peer *bsc.Peer, votes []*types.VoteEnvelope) error {
+	bsc.IngressVotesMeter.Mark(int64(len(votes)))
 	if peer.IsOverLimitAfterReceiving() {
+		bsc.IngressVotesDroppedMeter.Mark(int64(len(votes)))
 		return nil
 	}
+	// Drop the peer keeping flooding us with votes
+	if peer.IsSpammer() {
+		return errors.New("too many votes")
+	}
+	// Drop the peer keeping sending us votes with invalid signatures
+	if peer.IsInvalidVoter() {
+		return errors.New("too many invalid votes")
+	}
 	// Here we only put the first vote, to avoid ddos attack by sending a large batch of votes.
 	// This won't abandon any valid vote, because one vote is sent every time referring to func voteBroadcastLoop
-	if len(votes) > 0 {
-		h.votepool.PutVote(votes[0])
+	if len(votes) > 0 && h.votepool != nil {
+		// The signature is verified by the pool, which accounts the invalid
+		// ones to the peer
+		target := votes[0].Data.TargetNumber
+		h.votepool.PutPeerVote(votes[0], func(err error) {
+			peer.Log().Debug("Invalid vote received", "target", target, "err", err)
+			peer.MarkInvalidVote()
+		})
+	}
+
+	return nil
+}
+
+// handleGetSnapshot serves the persisted consensus snapshot of the requested
+// checkpoint block, or an empty one if it is not available.
+func (h *bscHandler) handleGetSnapshot(peer *bsc.Peer, req *bsc.GetSnapshotPacket) error {
+	var blob []byte
+	if engine, ok := h.chain.Engine().(*oasys.Oasys); ok {
+		blob, _ = engine.EncodedSnapshot(req.Hash)
 	}
+	if len(blob) > 0 {
+		bsc.EgressSnapshotsMeter.Mark(1)
+	}
+	return peer.ReplySnapshot(req.RequestId, blob)
+}
 
+// handleSnapshot imports the consensus snapshot received from the peer. The
+// peer is dropped if the snapshot does not match the local header chain.
+func (h *bscHandler) handleSnapshot(peer *bsc.Peer, res *bsc.SnapshotPacket) error {
+	engine, ok := h.chain.Engine().(*oasys.Oasys)
+	if !ok || len(res.Snapshot) == 0 {
+		return nil
+	}
+	snap, err := engine.ImportSnapshot(h.chain, res.Snapshot)
+	if err != nil {
+		return fmt.Errorf("invalid consensus snapshot: %v", err)
+	}
+	bsc.IngressSnapshotsMeter.Mark(1)
+	log.Debug("Imported consensus snapshot from peer", "peer", peer.ID(), "number", snap.Number, "hash", snap.Hash)
 	return nil
 }
@@ -0,0 +1,206 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/protocols/bsc"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/prysmaticlabs/prysm/v5/crypto/bls"
+)
+
+// testProposerEngine is a fake PoS engine expecting the given proposers after
+// any block.
+type testProposerEngine struct {
+	*testFinalityEngine
+	proposers []common.Address
+}
+
+func (e *testProposerEngine) ExpectedProposers(chain consensus.ChainHeaderReader, header *types.Header, n int) ([]common.Address, error) {
+	return e.proposers[:min(n, len(e.proposers))], nil
+}
+
+func TestVotePeersProposersFirst(t *testing.T) {
+	var (
+		proposers = []common.Address{{0x01}, {0x02}}
+		engine    = &testProposerEngine{testFinalityEngine: &testFinalityEngine{Ethash: ethash.NewFaker()}, proposers: proposers}
+		genesis   = &core.Genesis{Config: params.TestChainConfig, Alloc: types.GenesisAlloc{}}
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 3, nil)
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	var (
+		head    = chain.CurrentBlock()
+		td      = chain.GetTd(head.Hash(), head.Number.Uint64())
+		first   = chain.GetTd(blocks[0].Hash(), 1)
+		average = new(big.Int).Div(new(big.Int).Sub(td, first), big.NewInt(2))
+		far     = new(big.Int).Add(td, new(big.Int).Mul(big.NewInt(2*deltaTdThreshold), average))
+	)
+
+	// The peers near the head, and far beyond it
+	peers := newPeerSet()
+	defer peers.close()
+	var (
+		forkID = forkid.NewID(chain.Config(), chain.Genesis(), head.Number.Uint64(), head.Time)
+		filter = forkid.NewFilter(chain)
+	)
+	register := func(id byte, td *big.Int) string {
+		app, net := p2p.MsgPipe()
+		t.Cleanup(func() { app.Close(); net.Close() })
+
+		// The peer learns the head of the remote end by the handshake
+		p2pPeer := p2p.NewPeerPipe(enode.ID{id}, "", nil, app)
+		peer := eth.NewPeer(eth.ETH68, p2pPeer, app, nil)
+		remote := eth.NewPeer(eth.ETH68, p2p.NewPeerPipe(enode.ID{}, "", nil, net), net, nil)
+		bscPeer := bsc.NewPeer(bsc.Bsc1, p2pPeer, app)
+		t.Cleanup(func() { peer.Close(); remote.Close(); bscPeer.Close() })
+
+		errc := make(chan error, 1)
+		go func() { errc <- remote.Handshake(1, td, head.Hash(), chain.Genesis().Hash(), forkID, filter) }()
+		if err := peer.Handshake(1, td, head.Hash(), chain.Genesis().Hash(), forkID, filter); err != nil {
+			t.Fatalf("failed to handshake: %v", err)
+		}
+		if err := <-errc; err != nil {
+			t.Fatalf("failed to handshake remotely: %v", err)
+		}
+		if err := peers.registerPeer(peer, nil, bscPeer); err != nil {
+			t.Fatalf("failed to register peer: %v", err)
+		}
+		return peer.ID()
+	}
+	var (
+		near1 = register(1, td)
+		near2 = register(2, td)
+		farP1 = register(3, far) // Delivering the blocks of the first proposer
+		nearP = register(4, td)  // Delivering the blocks of the second proposer
+		_     = register(5, far)
+	)
+	h := &handler{
+		chain:         chain,
+		peers:         peers,
+		proposerPeers: lru.NewCache[common.Address, proposerPeer](proposerPeersLimit),
+
+		firstTDInBroadcastVote:     first,
+		firstHeightInBroadcastVote: 1,
+	}
+	h.proposerPeers.Add(proposers[0], proposerPeer{peer: farP1})
+	h.proposerPeers.Add(proposers[1], proposerPeer{peer: nearP})
+
+	vote := &types.VoteEnvelope{Data: &types.VoteData{TargetNumber: head.Number.Uint64(), TargetHash: head.Hash()}}
+	ids := func(peers []*ethPeer) []string {
+		ids := make([]string, len(peers))
+		for i, peer := range peers {
+			ids[i] = peer.ID()
+		}
+		return ids
+	}
+
+	// By default the peers far from the head are skipped, in no order
+	have := ids(h.votePeers(vote))
+	if len(have) != 3 {
+		t.Fatalf("peers mismatch: have %v, want %v", have, []string{near1, near2, nearP})
+	}
+	for _, id := range have {
+		if id != near1 && id != near2 && id != nearP {
+			t.Fatalf("unexpected peer %s in %v", id, have)
+		}
+	}
+
+	// The peers of the upcoming proposers come first in the order of the
+	// proposers, even if far from the head
+	h.voteProposersFirst = true
+	have = ids(h.votePeers(vote))
+	if len(have) != 4 || have[0] != farP1 || have[1] != nearP {
+		t.Fatalf("peers mismatch: have %v, want %v first", have, []string{farP1, nearP})
+	}
+	for _, id := range have[2:] {
+		if id != near1 && id != near2 {
+			t.Fatalf("unexpected peer %s in %v", id, have)
+		}
+	}
+}
+
+// testVotePool is a vote pool recording the votes put, verifying their
+// signatures like the real one.
+type testVotePool struct {
+	votes []*types.VoteEnvelope
+}
+
+func (p *testVotePool) PutPeerVote(vote *types.VoteEnvelope, onInvalid func(error)) {
+	if err := vote.Verify(); err != nil {
+		onInvalid(err)
+		return
+	}
+	p.votes = append(p.votes, vote)
+}
+func (p *testVotePool) GetVotes() []*types.VoteEnvelope { return p.votes }
+func (p *testVotePool) SubscribeNewVoteEvent(ch chan<- core.NewVoteEvent) event.Subscription {
+	return new(event.Feed).Subscribe(ch)
+}
+
+func TestHandleInvalidVotes(t *testing.T) {
+	key, err := bls.RandKey()
+	if err != nil {
+		t.Fatalf("failed to create vote key: %v", err)
+	}
+	newVote := func(target uint64) *types.VoteEnvelope {
+		vote := &types.VoteEnvelope{Data: &types.VoteData{TargetNumber: target, TargetHash: common.Hash{byte(target)}}}
+		copy(vote.VoteAddress[:], key.PublicKey().Marshal())
+		digest := vote.Data.Hash()
+		copy(vote.Signature[:], key.Sign(digest[:]).Marshal())
+		return vote
+	}
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+	defer net.Close()
+	var (
+		pool = new(testVotePool)
+		h    = &handler{votepool: pool}
+		peer = bsc.NewPeer(bsc.Bsc1, p2p.NewPeerPipe(enode.ID{1}, "", nil, app), app)
+	)
+	defer peer.Close()
+
+	// The valid votes are put into the pool
+	if err := (*bscHandler)(h).handleVotesBroadcast(peer, []*types.VoteEnvelope{newVote(1)}); err != nil {
+		t.Fatalf("failed to handle valid vote: %v", err)
+	}
+	if len(pool.votes) != 1 || peer.InvalidVotes() != 0 {
+		t.Fatalf("valid vote mismatch: pooled %d, invalid %d", len(pool.votes), peer.InvalidVotes())
+	}
+
+	// The invalid ones are accounted to the peer until it is dropped
+	var drop error
+	for i := 0; i < 100 && drop == nil; i++ {
+		vote := newVote(uint64(i + 2))
+		vote.Data.TargetNumber++
+		drop = (*bscHandler)(h).handleVotesBroadcast(peer, []*types.VoteEnvelope{vote})
+	}
+	if drop == nil {
+		t.Fatal("peer sending invalid votes not dropped")
+	}
+	if len(pool.votes) != 1 {
+		t.Errorf("invalid votes pooled: %d", len(pool.votes)-1)
+	}
+	if have := (&bscPeer{peer}).info().InvalidVotes; have == 0 || have != peer.InvalidVotes() {
+		t.Errorf("invalid votes mismatch: info %d, peer %d", have, peer.InvalidVotes())
+	}
+}
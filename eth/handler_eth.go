@@ -129,6 +129,13 @@ func (h *ethHandler) handleBlockBroadcast(peer *eth.Peer, packet *eth.NewBlockPa
 		block = block.WithSidecars(sidecars)
 	}
 
+	// Remember the peer delivering a block of a validator for the first time,
+	// it's likely to be directly connected to the validator
+	if last, ok := h.proposerPeers.Peek(block.Coinbase()); !ok || last.hash != block.Hash() {
+		if !h.chain.HasBlock(block.Hash(), block.NumberU64()) {
+			h.proposerPeers.Add(block.Coinbase(), proposerPeer{hash: block.Hash(), peer: peer.ID()})
+		}
+	}
 	// Schedule the block for import
 	h.blockFetcher.Enqueue(peer.ID(), block)
 
@@ -0,0 +1,266 @@
+package eth
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// maxInclusionValidators bounds the number of the validators tracked, in case
+// the blocks come from more validators than expected.
+const maxInclusionValidators = 1000
+
+var (
+	inclusionLoadedMeter  = metrics.NewRegisteredMeter("oasys/inclusion/blocks/loaded", nil)
+	inclusionLazyMeter    = metrics.NewRegisteredMeter("oasys/inclusion/blocks/lazy", nil)
+	inclusionEmptyMeter   = metrics.NewRegisteredMeter("oasys/inclusion/blocks/empty", nil)
+	inclusionSkippedMeter = metrics.NewRegisteredMeter("oasys/inclusion/txs/skipped", nil)
+)
+
+// inclusionCounterName returns the name of the lazy block counter of the
+// validator.
+func inclusionCounterName(validator common.Address) string {
+	return fmt.Sprintf("oasys/inclusion/lazy/%s", validator.String())
+}
+
+// InclusionStats is the record of a proposer including the pending transactions
+// paying at least the gas price floor. A block is loaded if such transactions
+// had been pending for more than a block period when it was sealed, and lazy if
+// it left any of them out while having room for it.
+type InclusionStats struct {
+	Blocks       hexutil.Uint64 `json:"blocks"`
+	LoadedBlocks hexutil.Uint64 `json:"loadedBlocks"`
+	LazyBlocks   hexutil.Uint64 `json:"lazyBlocks"`
+	EmptyBlocks  hexutil.Uint64 `json:"emptyBlocks"` // Loaded blocks without any user transaction
+	IncludedTxs  hexutil.Uint64 `json:"includedTxs"`
+	SkippedTxs   hexutil.Uint64 `json:"skippedTxs"`
+	LastLazy     hexutil.Uint64 `json:"lastLazyBlock,omitempty"`
+}
+
+// inclusionEngine is the part of the consensus engine used by the inclusion
+// tracker.
+type inclusionEngine interface {
+	IsSystemTransaction(tx *types.Transaction, header *types.Header) (bool, error)
+	BlockPeriod(chain consensus.ChainHeaderReader, header *types.Header) uint64
+}
+
+// inclusionCandidate is a transaction pending at the previous head, which the
+// next proposer is expected to include.
+type inclusionCandidate struct {
+	time      time.Time // Time the transaction was first seen
+	gas       uint64
+	gasFeeCap *big.Int
+	gasTipCap *big.Int
+}
+
+// price returns the gas price paid by the candidate at the given base fee, or
+// nil if the candidate can't be included at the base fee.
+func (c *inclusionCandidate) price(baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return c.gasFeeCap
+	}
+	if c.gasFeeCap.Cmp(baseFee) < 0 {
+		return nil
+	}
+	price := new(big.Int).Add(baseFee, c.gasTipCap)
+	if price.Cmp(c.gasFeeCap) > 0 {
+		price.Set(c.gasFeeCap)
+	}
+	return price
+}
+
+// inclusionTracker tracks whether the proposers include the pending transactions
+// paying at least the gas price floor, since some validators seal empty blocks
+// even under load. Only the next transaction of each account is expected, so
+// that the transactions waiting for their predecessors are never counted.
+type inclusionTracker struct {
+	chain  *core.BlockChain
+	pool   txPool
+	engine inclusionEngine
+	floor  *big.Int
+
+	head       common.Hash                         // Previous head
+	candidates map[common.Hash]*inclusionCandidate // Transactions pending at the previous head
+
+	lock  sync.RWMutex
+	stats map[common.Address]*InclusionStats // Protected by lock
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newInclusionTracker(chain *core.BlockChain, pool txPool, engine inclusionEngine, floor *big.Int) *inclusionTracker {
+	return &inclusionTracker{
+		chain:  chain,
+		pool:   pool,
+		engine: engine,
+		floor:  new(big.Int).Set(floor),
+		stats:  make(map[common.Address]*InclusionStats),
+		quit:   make(chan struct{}),
+	}
+}
+
+func (t *inclusionTracker) start() {
+	t.wg.Add(1)
+	go t.loop()
+}
+
+func (t *inclusionTracker) stop() {
+	close(t.quit)
+	t.wg.Wait()
+}
+
+func (t *inclusionTracker) loop() {
+	defer t.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 1)
+	sub := t.chain.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-headCh:
+			t.update(ev.Block)
+		case <-sub.Err():
+			return
+		case <-t.quit:
+			return
+		}
+	}
+}
+
+// update evaluates the new head against the transactions pending at the
+// previous one, and collects the transactions expected in the next block. The
+// head skipping blocks after a reorg or a sync is not evaluated.
+func (t *inclusionTracker) update(block *types.Block) {
+	header := block.Header()
+	if t.candidates != nil && block.ParentHash() == t.head {
+		var user int
+		for _, tx := range block.Transactions() {
+			if system, err := t.engine.IsSystemTransaction(tx, header); err != nil || !system {
+				user++
+			}
+		}
+		period := time.Duration(t.engine.BlockPeriod(t.chain, header)) * time.Second
+		t.evaluate(block, user, period)
+	}
+	t.head, t.candidates = block.Hash(), t.collect(header)
+}
+
+// collect returns the next pending transaction of each account at the given
+// head, paying at least the gas price floor at its base fee.
+func (t *inclusionTracker) collect(head *types.Header) map[common.Hash]*inclusionCandidate {
+	state, err := t.chain.StateAt(head.Root)
+	if err != nil {
+		log.Debug("Failed to retrieve state for inclusion tracking", "number", head.Number, "err", err)
+		return nil
+	}
+	candidates := make(map[common.Hash]*inclusionCandidate)
+	for addr, txs := range t.pool.Pending(txpool.PendingFilter{}) {
+		nonce := state.GetNonce(addr)
+		for _, ltx := range txs {
+			tx := ltx.Resolve()
+			if tx == nil || tx.Nonce() != nonce {
+				continue
+			}
+			candidate := &inclusionCandidate{
+				time:      ltx.Time,
+				gas:       ltx.Gas,
+				gasFeeCap: ltx.GasFeeCap.ToBig(),
+				gasTipCap: ltx.GasTipCap.ToBig(),
+			}
+			if price := candidate.price(head.BaseFee); price != nil && price.Cmp(t.floor) >= 0 {
+				candidates[ltx.Hash] = candidate
+			}
+			break
+		}
+	}
+	return candidates
+}
+
+// evaluate records the inclusion of the candidates by the proposer of the given
+// block, with the given number of user transactions. A candidate is expected if
+// it had been pending for more than the block period, still pays the floor at
+// the base fee of the block, and fits in the gas left by the block.
+func (t *inclusionTracker) evaluate(block *types.Block, user int, period time.Duration) {
+	var (
+		sealed   = time.Unix(int64(block.Time()), 0)
+		left     = block.GasLimit() - block.GasUsed()
+		txs      = make(map[common.Hash]bool, len(block.Transactions()))
+		included uint64
+		skipped  uint64
+	)
+	for _, tx := range block.Transactions() {
+		txs[tx.Hash()] = true
+	}
+	for hash, candidate := range t.candidates {
+		if candidate.time.Add(period).After(sealed) {
+			continue
+		}
+		if price := candidate.price(block.BaseFee()); price == nil || price.Cmp(t.floor) < 0 {
+			continue
+		}
+		if txs[hash] {
+			included++
+		} else if candidate.gas <= left {
+			skipped++
+		}
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	proposer := block.Coinbase()
+	stats, ok := t.stats[proposer]
+	if !ok {
+		if len(t.stats) >= maxInclusionValidators {
+			return
+		}
+		stats = new(InclusionStats)
+		t.stats[proposer] = stats
+	}
+	stats.Blocks++
+	if included+skipped == 0 {
+		return
+	}
+	stats.LoadedBlocks++
+	stats.IncludedTxs += hexutil.Uint64(included)
+	inclusionLoadedMeter.Mark(1)
+	if user == 0 {
+		stats.EmptyBlocks++
+		inclusionEmptyMeter.Mark(1)
+	}
+	if skipped > 0 {
+		stats.LazyBlocks++
+		stats.SkippedTxs += hexutil.Uint64(skipped)
+		stats.LastLazy = hexutil.Uint64(block.NumberU64())
+		inclusionLazyMeter.Mark(1)
+		inclusionSkippedMeter.Mark(int64(skipped))
+		metrics.GetOrRegisterCounter(inclusionCounterName(proposer), nil).Inc(1)
+
+		log.Debug("Proposer left out pending transactions", "number", block.Number(), "proposer", proposer, "skipped", skipped, "included", included)
+	}
+}
+
+// statsByValidator returns a copy of the inclusion records of the proposers.
+func (t *inclusionTracker) statsByValidator() map[common.Address]*InclusionStats {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	stats := make(map[common.Address]*InclusionStats, len(t.stats))
+	for validator, s := range t.stats {
+		cpy := *s
+		stats[validator] = &cpy
+	}
+	return stats
+}
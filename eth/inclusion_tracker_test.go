@@ -0,0 +1,158 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testInclusionEngine is an inclusion engine with a fixed block period, which
+// never classifies a transaction as a system one.
+type testInclusionEngine struct {
+	period uint64
+}
+
+func (e *testInclusionEngine) IsSystemTransaction(tx *types.Transaction, header *types.Header) (bool, error) {
+	return false, nil
+}
+
+func (e *testInclusionEngine) BlockPeriod(chain consensus.ChainHeaderReader, header *types.Header) uint64 {
+	return e.period
+}
+
+func TestInclusionTrackerEvaluate(t *testing.T) {
+	var (
+		proposer = common.Address{0x01}
+		sealed   = time.Unix(1_000_000, 0)
+		period   = 6 * time.Second
+		included = types.NewTx(&types.LegacyTx{Nonce: 1, Gas: 21000, GasPrice: big.NewInt(10)})
+	)
+	candidate := func(age time.Duration, price int64, gas uint64) *inclusionCandidate {
+		return &inclusionCandidate{
+			time:      sealed.Add(-age),
+			gas:       gas,
+			gasFeeCap: big.NewInt(price),
+			gasTipCap: big.NewInt(price),
+		}
+	}
+	tracker := &inclusionTracker{
+		floor: big.NewInt(5),
+		stats: make(map[common.Address]*InclusionStats),
+		candidates: map[common.Hash]*inclusionCandidate{
+			included.Hash(): candidate(time.Minute, 10, 21000),
+			{0x01}:          candidate(time.Minute, 10, 21000),   // Left out
+			{0x02}:          candidate(time.Second, 10, 21000),   // Too recent
+			{0x03}:          candidate(time.Minute, 1, 21000),    // Below the floor
+			{0x04}:          candidate(time.Minute, 10, 100_000), // Larger than the gas left
+		},
+	}
+	block := types.NewBlockWithHeader(&types.Header{
+		Number:   big.NewInt(10),
+		Coinbase: proposer,
+		Time:     uint64(sealed.Unix()),
+		GasLimit: 50_000,
+		GasUsed:  21000,
+	}).WithBody([]*types.Transaction{included}, nil)
+
+	tracker.evaluate(block, 1, period)
+	want := InclusionStats{Blocks: 1, LoadedBlocks: 1, LazyBlocks: 1, IncludedTxs: 1, SkippedTxs: 1, LastLazy: 10}
+	if got := *tracker.statsByValidator()[proposer]; got != want {
+		t.Fatalf("stats mismatch: have %+v, want %+v", got, want)
+	}
+
+	// An empty block without any expected transaction is not loaded
+	tracker.candidates = map[common.Hash]*inclusionCandidate{{0x02}: candidate(time.Second, 10, 21000)}
+	tracker.evaluate(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(11), Coinbase: proposer, Time: uint64(sealed.Unix()), GasLimit: 50_000}), 0, period)
+
+	// An empty block leaving out an expected transaction is lazy
+	tracker.candidates = map[common.Hash]*inclusionCandidate{{0x01}: candidate(time.Minute, 10, 21000)}
+	tracker.evaluate(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(12), Coinbase: proposer, Time: uint64(sealed.Unix()), GasLimit: 50_000}), 0, period)
+
+	want = InclusionStats{Blocks: 3, LoadedBlocks: 2, LazyBlocks: 2, EmptyBlocks: 1, IncludedTxs: 1, SkippedTxs: 2, LastLazy: 12}
+	if got := *tracker.statsByValidator()[proposer]; got != want {
+		t.Fatalf("stats mismatch: have %+v, want %+v", got, want)
+	}
+}
+
+func TestInclusionTrackerUpdate(t *testing.T) {
+	var (
+		start    = time.Now().Add(-time.Hour)
+		proposer = common.Address{0x01}
+		signer   = types.HomesteadSigner{}
+		tx, _    = types.SignTx(types.NewTransaction(0, common.Address{0x02}, big.NewInt(1), 21000, big.NewInt(10*params.GWei), nil), signer, testKey)
+		genesis  = &core.Genesis{
+			Config:    params.TestChainConfig,
+			Timestamp: uint64(start.Unix()),
+			GasLimit:  params.GenesisGasLimit,
+			BaseFee:   big.NewInt(params.InitialBaseFee),
+			Alloc:     types.GenesisAlloc{testAddr: {Balance: big.NewInt(params.Ether)}},
+		}
+	)
+	// Two forks sharing the first block, including the pending transaction in
+	// the second block or not.
+	generate := func(include bool) []*types.Block {
+		_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 2, func(i int, gen *core.BlockGen) {
+			gen.SetCoinbase(proposer)
+			if i == 1 && include {
+				gen.AddTx(tx)
+			}
+		})
+		return blocks
+	}
+	tx.SetTime(start.Add(-time.Minute))
+	lazy, loaded := generate(false), generate(true)
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(lazy); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if _, err := chain.InsertChain(loaded[1:]); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	pool := newTestTxPool()
+	pool.Add([]*types.Transaction{tx}, true, false)
+
+	// The candidate pending for less than the block period is not expected
+	engine := &testInclusionEngine{period: uint64(2 * time.Hour / time.Second)}
+	tracker := newInclusionTracker(chain, pool, engine, big.NewInt(params.GWei))
+	tracker.update(lazy[0])
+	if len(tracker.candidates) != 1 {
+		t.Fatalf("candidates mismatch: have %d, want 1", len(tracker.candidates))
+	}
+	tracker.update(lazy[1])
+	want := InclusionStats{Blocks: 1}
+	if got := *tracker.statsByValidator()[proposer]; got != want {
+		t.Fatalf("stats mismatch: have %+v, want %+v", got, want)
+	}
+
+	// The candidate left out of an empty block is lazy, and the one included is
+	// counted once the head switches to the other fork
+	engine.period = 6
+	tracker.update(lazy[0])
+	tracker.update(lazy[1])
+	tracker.update(lazy[0])
+	tracker.update(loaded[1])
+	want = InclusionStats{Blocks: 3, LoadedBlocks: 2, LazyBlocks: 1, EmptyBlocks: 1, IncludedTxs: 1, SkippedTxs: 1, LastLazy: 2}
+	if got := *tracker.statsByValidator()[proposer]; got != want {
+		t.Fatalf("stats mismatch: have %+v, want %+v", got, want)
+	}
+
+	// The head skipping a block is not evaluated
+	tracker.update(loaded[1])
+	if got := *tracker.statsByValidator()[proposer]; got != want {
+		t.Fatalf("stats mismatch after skipped block: have %+v, want %+v", got, want)
+	}
+}
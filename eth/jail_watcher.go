@@ -0,0 +1,184 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// activateValidatorGas is the gas limit of the activateValidator transaction.
+const activateValidatorGas = 500_000
+
+var (
+	jailedGauge        = metrics.NewRegisteredGauge("oasys/jailwatcher/jailed", nil)
+	activationCounter  = metrics.NewRegisteredCounter("oasys/jailwatcher/activations", nil)
+	activationFailures = metrics.NewRegisteredCounter("oasys/jailwatcher/failures", nil)
+)
+
+// jailEngine is the subset of the Oasys engine used by the jail watcher.
+type jailEngine interface {
+	Epoch(chain consensus.ChainHeaderReader, header *types.Header) (uint64, error)
+	ValidatorStatus(header *types.Header, owner common.Address, epoch uint64) (*oasys.ValidatorStatus, error)
+	PackActivateValidator(owner common.Address, epochs []uint64) (common.Address, []byte, error)
+	PendingNonce(head *types.Header, account common.Address, poolNonce uint64) uint64
+}
+
+// jailWatcher watches the jail status of the local validator once per epoch,
+// and activates the validator again from the owner account when the jail
+// period has elapsed.
+type jailWatcher struct {
+	eth    *Ethereum
+	engine jailEngine
+	owner  common.Address
+	dryRun bool
+
+	activate func(head *types.Header, epoch uint64) error // Submits the activation, replaced in tests
+
+	checked uint64 // Last epoch the status was checked
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newJailWatcher(eth *Ethereum, engine jailEngine, owner common.Address, dryRun bool) *jailWatcher {
+	w := &jailWatcher{
+		eth:    eth,
+		engine: engine,
+		owner:  owner,
+		dryRun: dryRun,
+		quit:   make(chan struct{}),
+	}
+	w.activate = w.submitActivation
+	return w
+}
+
+func (w *jailWatcher) start() {
+	w.wg.Add(1)
+	go w.loop()
+}
+
+func (w *jailWatcher) stop() {
+	close(w.quit)
+	w.wg.Wait()
+}
+
+func (w *jailWatcher) loop() {
+	defer w.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 1)
+	sub := w.eth.blockchain.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-headCh:
+			w.check(ev.Block.Header())
+		case <-sub.Err():
+			return
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// check looks up the status of the validator in the current and the next
+// epoch. Only the validator whose jail period ends with the current epoch is
+// activated, so that the validator deactivated by the owner stays inactive.
+// The epoch is checked again on the next head if the activation failed.
+func (w *jailWatcher) check(head *types.Header) {
+	epoch, err := w.engine.Epoch(w.eth.blockchain, head)
+	if err != nil {
+		log.Debug("Failed to get the epoch", "number", head.Number, "err", err)
+		return
+	}
+	if epoch <= w.checked {
+		return
+	}
+
+	current, err := w.engine.ValidatorStatus(head, w.owner, epoch)
+	if err != nil {
+		log.Warn("Failed to get the validator status", "owner", w.owner, "epoch", epoch, "err", err)
+		return
+	}
+	next, err := w.engine.ValidatorStatus(head, w.owner, epoch+1)
+	if err != nil {
+		log.Warn("Failed to get the validator status", "owner", w.owner, "epoch", epoch+1, "err", err)
+		return
+	}
+
+	if current.Jailed {
+		jailedGauge.Update(1)
+	} else {
+		jailedGauge.Update(0)
+	}
+	if !current.Jailed || next.Jailed || next.Active {
+		if next.Jailed {
+			log.Info("Validator is jailed", "owner", w.owner, "epoch", epoch)
+		}
+		w.checked = epoch
+		return
+	}
+
+	log.Info("Jail period elapsed, activating validator", "owner", w.owner, "epoch", epoch+1, "dryrun", w.dryRun)
+	if w.dryRun {
+		w.checked = epoch
+		return
+	}
+	if err := w.activate(head, epoch+1); err != nil {
+		activationFailures.Inc(1)
+		log.Error("Failed to activate validator", "owner", w.owner, "err", err)
+		return
+	}
+	w.checked = epoch
+	activationCounter.Inc(1)
+}
+
+// submitActivation signs the activateValidator transaction of the given epoch
+// with the owner account and adds it into the transaction pool.
+func (w *jailWatcher) submitActivation(head *types.Header, epoch uint64) error {
+	to, data, err := w.engine.PackActivateValidator(w.owner, []uint64{epoch})
+	if err != nil {
+		return err
+	}
+	tip, err := w.eth.APIBackend.SuggestGasTipCap(context.Background())
+	if err != nil {
+		return err
+	}
+	feeCap := new(big.Int).Set(tip)
+	if head.BaseFee != nil {
+		feeCap.Add(feeCap, new(big.Int).Mul(head.BaseFee, common.Big2))
+	}
+
+	account := accounts.Account{Address: w.owner}
+	wallet, err := w.eth.accountManager.Find(account)
+	if err != nil {
+		return err
+	}
+	nonce := w.engine.PendingNonce(head, w.owner, w.eth.txPool.Nonce(w.owner))
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   w.eth.blockchain.Config().ChainID,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       activateValidatorGas,
+		To:        &to,
+		Data:      data,
+	})
+	signed, err := wallet.SignTx(account, tx, w.eth.blockchain.Config().ChainID)
+	if err != nil {
+		return err
+	}
+	if errs := w.eth.txPool.Add([]*types.Transaction{signed}, true, false); errs[0] != nil {
+		return errs[0]
+	}
+	log.Info("Submitted validator activation", "owner", w.owner, "epoch", epoch, "hash", signed.Hash())
+	return nil
+}
@@ -0,0 +1,104 @@
+package eth
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// testJailEngine serves the validator status of each epoch.
+type testJailEngine struct {
+	epoch    uint64
+	statuses map[uint64]*oasys.ValidatorStatus
+}
+
+func (e *testJailEngine) Epoch(chain consensus.ChainHeaderReader, header *types.Header) (uint64, error) {
+	return e.epoch, nil
+}
+
+func (e *testJailEngine) ValidatorStatus(header *types.Header, owner common.Address, epoch uint64) (*oasys.ValidatorStatus, error) {
+	if status, ok := e.statuses[epoch]; ok {
+		return status, nil
+	}
+	return nil, errors.New("unknown epoch")
+}
+
+func (e *testJailEngine) PackActivateValidator(owner common.Address, epochs []uint64) (common.Address, []byte, error) {
+	return common.Address{}, nil, nil
+}
+
+func (e *testJailEngine) PendingNonce(head *types.Header, account common.Address, poolNonce uint64) uint64 {
+	return poolNonce
+}
+
+func TestJailWatcherCheck(t *testing.T) {
+	var (
+		engine    = &testJailEngine{epoch: 10}
+		w         = newJailWatcher(new(Ethereum), engine, common.Address{1}, false)
+		head      = &types.Header{Number: big.NewInt(100)}
+		activated []uint64
+		fail      error
+	)
+	w.activate = func(head *types.Header, epoch uint64) error {
+		if fail != nil {
+			return fail
+		}
+		activated = append(activated, epoch)
+		return nil
+	}
+
+	// The status lookup failures are retried on the next head
+	w.check(head)
+	if w.checked != 0 || len(activated) != 0 {
+		t.Fatalf("checked on lookup failure: checked %d, activated %v", w.checked, activated)
+	}
+
+	// The validator not jailed is left as is
+	engine.statuses = map[uint64]*oasys.ValidatorStatus{
+		10: {Active: true},
+		11: {Active: true},
+	}
+	w.check(head)
+	if w.checked != 10 || len(activated) != 0 {
+		t.Fatalf("not jailed mismatch: checked %d, activated %v", w.checked, activated)
+	}
+
+	// The validator still jailed in the next epoch is left as is
+	engine.epoch = 11
+	engine.statuses = map[uint64]*oasys.ValidatorStatus{
+		11: {Jailed: true},
+		12: {Jailed: true},
+	}
+	w.check(head)
+	if w.checked != 11 || len(activated) != 0 {
+		t.Fatalf("jailed mismatch: checked %d, activated %v", w.checked, activated)
+	}
+
+	// The validator whose jail period ends is activated, retrying the failures
+	engine.epoch = 12
+	engine.statuses = map[uint64]*oasys.ValidatorStatus{
+		12: {Jailed: true},
+		13: {},
+	}
+	fail = errors.New("nonce too low")
+	w.check(head)
+	if w.checked != 11 || len(activated) != 0 {
+		t.Fatalf("checked on activation failure: checked %d, activated %v", w.checked, activated)
+	}
+	fail = nil
+	w.check(head)
+	if w.checked != 12 || len(activated) != 1 || activated[0] != 13 {
+		t.Fatalf("activation mismatch: checked %d, activated %v", w.checked, activated)
+	}
+
+	// The epoch is activated only once
+	w.check(head)
+	if len(activated) != 1 {
+		t.Fatalf("activated again: %v", activated)
+	}
+}
@@ -373,6 +373,21 @@ func (ps *peerSet) len() int {
 	return len(ps.peers)
 }
 
+// lenAhead returns the number of the `eth` peers whose head has a total
+// difficulty higher than the given one.
+func (ps *peerSet) lenAhead(td *big.Int) int {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	var ahead int
+	for _, p := range ps.peers {
+		if _, ptd := p.Head(); ptd.Cmp(td) > 0 {
+			ahead++
+		}
+	}
+	return ahead
+}
+
 // snapLen returns if the current number of `snap` peers in the set.
 func (ps *peerSet) snapLen() int {
 	ps.lock.RLock()
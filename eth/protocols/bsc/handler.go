@@ -91,6 +91,12 @@ var bsc1 = map[uint64]msgHandler{
 	VotesMsg: handleVotes,
 }
 
+var bsc2 = map[uint64]msgHandler{
+	VotesMsg:       handleVotes,
+	GetSnapshotMsg: handleGetSnapshot,
+	SnapshotMsg:    handleSnapshot,
+}
+
 // handleMessage is invoked whenever an inbound message is received from a
 // remote peer on the `bsc` protocol. The remote connection is torn down upon
 // returning any error.
@@ -106,6 +112,9 @@ func handleMessage(backend Backend, peer *Peer) error {
 	defer msg.Discard()
 
 	var handlers = bsc1
+	if peer.Version() >= Bsc2 {
+		handlers = bsc2
+	}
 
 	// Track the amount of time it takes to serve the request and run the handler
 	if metrics.Enabled {
@@ -135,6 +144,26 @@ func handleVotes(backend Backend, msg Decoder, peer *Peer) error {
 	return backend.Handle(peer, ann)
 }
 
+func handleGetSnapshot(backend Backend, msg Decoder, peer *Peer) error {
+	req := new(GetSnapshotPacket)
+	if err := msg.Decode(req); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	return backend.Handle(peer, req)
+}
+
+func handleSnapshot(backend Backend, msg Decoder, peer *Peer) error {
+	res := new(SnapshotPacket)
+	if err := msg.Decode(res); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	// Drop the peer sending unsolicited snapshots, as they are costly to verify
+	if !peer.deliverSnapshot(res.RequestId) {
+		return fmt.Errorf("%w: id %d", errUnexpectedSnapshot, res.RequestId)
+	}
+	return backend.Handle(peer, res)
+}
+
 // NodeInfo represents a short summary of the `bsc` sub-protocol metadata
 // known about the host peer.
 type NodeInfo struct{}
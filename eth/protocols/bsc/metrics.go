@@ -23,7 +23,19 @@ import (
 var (
 	ingressRegistrationErrorName = "eth/protocols/bsc/ingress/registration/error"
 	egressRegistrationErrorName  = "eth/protocols/bsc/egress/registration/error"
+	ingressVotesName             = "eth/protocols/bsc/ingress/votes"
+	ingressVotesDroppedName      = "eth/protocols/bsc/ingress/votes/dropped"
+	ingressVotesInvalidName      = "eth/protocols/bsc/ingress/votes/invalid"
+	ingressSnapshotsName         = "eth/protocols/bsc/ingress/snapshots"
+	egressSnapshotsName          = "eth/protocols/bsc/egress/snapshots"
 
 	IngressRegistrationErrorMeter = metrics.NewRegisteredMeter(ingressRegistrationErrorName, nil)
 	EgressRegistrationErrorMeter  = metrics.NewRegisteredMeter(egressRegistrationErrorName, nil)
+
+	IngressVotesMeter        = metrics.NewRegisteredMeter(ingressVotesName, nil)        // Votes received from the peers
+	IngressVotesDroppedMeter = metrics.NewRegisteredMeter(ingressVotesDroppedName, nil) // Votes dropped due to the rate limit
+	IngressInvalidVotesMeter = metrics.NewRegisteredMeter(ingressVotesInvalidName, nil) // Votes with invalid signatures
+
+	IngressSnapshotsMeter = metrics.NewRegisteredMeter(ingressSnapshotsName, nil) // Consensus snapshots imported from the peers
+	EgressSnapshotsMeter  = metrics.NewRegisteredMeter(egressSnapshotsName, nil)  // Consensus snapshots served to the peers
 )
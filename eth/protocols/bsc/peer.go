@@ -1,6 +1,10 @@
 package bsc
 
 import (
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
@@ -24,6 +28,14 @@ const (
 
 	// the time span of one period
 	secondsPerPeriod = float64(30)
+
+	// maxOverLimitPeriods is the number of consecutive periods a peer can
+	// exceed the rate limit before being considered as a spammer
+	maxOverLimitPeriods = 3
+
+	// maxInvalidVotes is the number of the votes with invalid signatures a peer
+	// can send within a period before being dropped
+	maxInvalidVotes = 16
 )
 
 // max is a helper function which returns the larger of the two given integers.
@@ -41,6 +53,12 @@ type Peer struct {
 	voteBroadcast chan []*types.VoteEnvelope // Channel used to queue votes propagation requests
 	periodBegin   time.Time                  // Begin time of the latest period for votes counting
 	periodCounter uint                       // Votes number in the latest period
+	overLimits    uint                       // Number of consecutive periods over the rate limit
+	invalidVotes  atomic.Uint64              // Number of the votes with invalid signatures received
+	invalidBegin  time.Time                  // Begin time of the latest period for invalid votes counting
+	invalidCount  uint                       // Invalid votes number in the latest period
+	invalidLock   sync.Mutex                 // Mutex protecting the invalid votes period
+	snapshotReq   atomic.Uint64              // ID of the pending snapshot request, zero if none
 
 	*p2p.Peer                   // The embedded P2P package peer
 	rw        p2p.MsgReadWriter // Input/output streams for bsc
@@ -126,12 +144,37 @@ func (p *Peer) AsyncSendVotes(votes []*types.VoteEnvelope) {
 	}
 }
 
+// RequestSnapshot fetches the consensus snapshot of the given checkpoint block
+// from the remote peer. Only one request can be pending at a time.
+func (p *Peer) RequestSnapshot(hash common.Hash) error {
+	id := rand.Uint64() | 1
+	if !p.snapshotReq.CompareAndSwap(0, id) {
+		return errors.New("snapshot request already pending")
+	}
+	p.Log().Debug("Fetching consensus snapshot", "hash", hash)
+	return p2p.Send(p.rw, GetSnapshotMsg, &GetSnapshotPacket{RequestId: id, Hash: hash})
+}
+
+// ReplySnapshot is the response to GetSnapshot.
+func (p *Peer) ReplySnapshot(id uint64, snapshot []byte) error {
+	return p2p.Send(p.rw, SnapshotMsg, &SnapshotPacket{RequestId: id, Snapshot: snapshot})
+}
+
+// deliverSnapshot clears the pending snapshot request, and returns whether the
+// response is for the pending request.
+func (p *Peer) deliverSnapshot(id uint64) bool {
+	return id != 0 && p.snapshotReq.CompareAndSwap(id, 0)
+}
+
 // Step into the next period when secondsPerPeriod seconds passed,
 // Otherwise, check whether the number of received votes extra (secondsPerPeriod * receiveRateLimitPerSecond)
 func (p *Peer) IsOverLimitAfterReceiving() bool {
 	if timeInterval := time.Since(p.periodBegin).Seconds(); timeInterval >= secondsPerPeriod {
 		if p.periodCounter > uint(secondsPerPeriod*receiveRateLimitPerSecond) {
 			p.Log().Debug("sending votes too much", "secondsPerPeriod", secondsPerPeriod, "count ", p.periodCounter)
+			p.overLimits++
+		} else {
+			p.overLimits = 0
 		}
 		p.periodBegin = time.Now()
 		p.periodCounter = 0
@@ -141,6 +184,43 @@ func (p *Peer) IsOverLimitAfterReceiving() bool {
 	return p.periodCounter > uint(secondsPerPeriod*receiveRateLimitPerSecond)
 }
 
+// IsSpammer returns whether the peer kept exceeding the rate limit for
+// maxOverLimitPeriods consecutive periods.
+func (p *Peer) IsSpammer() bool {
+	return p.overLimits >= maxOverLimitPeriods
+}
+
+// MarkInvalidVote records a vote with an invalid signature received from the
+// peer, stepping into the next period when secondsPerPeriod seconds passed.
+func (p *Peer) MarkInvalidVote() {
+	IngressInvalidVotesMeter.Mark(1)
+	p.invalidVotes.Add(1)
+
+	p.invalidLock.Lock()
+	defer p.invalidLock.Unlock()
+
+	if time.Since(p.invalidBegin).Seconds() >= secondsPerPeriod {
+		p.invalidBegin = time.Now()
+		p.invalidCount = 0
+	}
+	p.invalidCount++
+}
+
+// IsInvalidVoter returns whether the peer has sent maxInvalidVotes votes with
+// invalid signatures within the latest period.
+func (p *Peer) IsInvalidVoter() bool {
+	p.invalidLock.Lock()
+	defer p.invalidLock.Unlock()
+
+	return p.invalidCount >= maxInvalidVotes && time.Since(p.invalidBegin).Seconds() < secondsPerPeriod
+}
+
+// InvalidVotes returns the total number of the votes with invalid signatures
+// received from the peer.
+func (p *Peer) InvalidVotes() uint64 {
+	return p.invalidVotes.Load()
+}
+
 // broadcastVotes is a write loop that schedules votes broadcasts
 // to the remote peer. The goal is to have an async writer that does not lock up
 // node internals and at the same time rate limits queued data.
@@ -0,0 +1,73 @@
+package bsc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func newTestPeer(t *testing.T) *Peer {
+	app, net := p2p.MsgPipe()
+	t.Cleanup(func() { app.Close(); net.Close() })
+	peer := NewPeer(Bsc1, p2p.NewPeerPipe(enode.ID{1}, "", nil, app), app)
+	t.Cleanup(peer.Close)
+	return peer
+}
+
+func TestPeerSpammer(t *testing.T) {
+	peer := newTestPeer(t)
+	limit := int(secondsPerPeriod * receiveRateLimitPerSecond)
+
+	// flood sends the votes over the limit within a period, and steps into the
+	// next one
+	flood := func(votes int) {
+		for i := 0; i < votes; i++ {
+			if over := peer.IsOverLimitAfterReceiving(); over != (i >= limit) {
+				t.Fatalf("vote %d: over limit mismatch: have %v", i, over)
+			}
+		}
+		peer.periodBegin = time.Now().Add(-time.Duration(secondsPerPeriod) * time.Second)
+		peer.IsOverLimitAfterReceiving()
+	}
+	for i := 1; i < maxOverLimitPeriods; i++ {
+		flood(limit + 1)
+		if peer.IsSpammer() {
+			t.Fatalf("period %d: spammer before %d periods over the limit", i, maxOverLimitPeriods)
+		}
+	}
+	// A period within the limit resets the count
+	flood(limit)
+	for i := 0; i < maxOverLimitPeriods; i++ {
+		flood(limit + 1)
+	}
+	if !peer.IsSpammer() {
+		t.Fatalf("not a spammer after %d periods over the limit", maxOverLimitPeriods)
+	}
+}
+
+func TestPeerInvalidVotes(t *testing.T) {
+	peer := newTestPeer(t)
+	for i := 1; i < maxInvalidVotes; i++ {
+		peer.MarkInvalidVote()
+		if peer.IsInvalidVoter() {
+			t.Fatalf("vote %d: dropped before %d invalid votes", i, maxInvalidVotes)
+		}
+	}
+	// The invalid votes of the past periods are forgotten
+	peer.invalidBegin = time.Now().Add(-time.Duration(secondsPerPeriod) * time.Second)
+	peer.MarkInvalidVote()
+	if peer.IsInvalidVoter() {
+		t.Fatal("dropped after the invalid votes of the past period")
+	}
+	for i := 1; i < maxInvalidVotes; i++ {
+		peer.MarkInvalidVote()
+	}
+	if !peer.IsInvalidVoter() {
+		t.Fatalf("not dropped after %d invalid votes in a period", maxInvalidVotes)
+	}
+	if have, want := peer.InvalidVotes(), uint64(2*maxInvalidVotes-1); have != want {
+		t.Errorf("invalid votes mismatch: have %d, want %d", have, want)
+	}
+}
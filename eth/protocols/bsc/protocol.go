@@ -3,6 +3,7 @@ package bsc
 import (
 	"errors"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
 )
@@ -10,6 +11,7 @@ import (
 // Constants to match up protocol versions and messages
 const (
 	Bsc1 = 1
+	Bsc2 = 2
 )
 
 // ProtocolName is the official short name of the `bsc` protocol used during
@@ -18,11 +20,11 @@ const ProtocolName = "bsc"
 
 // ProtocolVersions are the supported versions of the `bsc` protocol (first
 // is primary).
-var ProtocolVersions = []uint{Bsc1}
+var ProtocolVersions = []uint{Bsc2, Bsc1}
 
 // protocolLengths are the number of implemented message corresponding to
 // different protocol versions.
-var protocolLengths = map[uint]uint64{Bsc1: 2}
+var protocolLengths = map[uint]uint64{Bsc2: 4, Bsc1: 2}
 
 // maxMessageSize is the maximum cap on the size of a protocol message.
 const maxMessageSize = 10 * 1024 * 1024
@@ -30,6 +32,10 @@ const maxMessageSize = 10 * 1024 * 1024
 const (
 	BscCapMsg = 0x00 // bsc capability msg used upon handshake
 	VotesMsg  = 0x01
+
+	// Protocol messages overloaded in bsc/2
+	GetSnapshotMsg = 0x02
+	SnapshotMsg    = 0x03
 )
 
 var defaultExtra = []byte{0x00}
@@ -40,6 +46,7 @@ var (
 	errDecode                  = errors.New("invalid message")
 	errInvalidMsgCode          = errors.New("invalid message code")
 	errProtocolVersionMismatch = errors.New("protocol version mismatch")
+	errUnexpectedSnapshot      = errors.New("unexpected snapshot response")
 )
 
 // Packet represents a p2p message in the `bsc` protocol.
@@ -59,8 +66,27 @@ type VotesPacket struct {
 	Votes []*types.VoteEnvelope
 }
 
+// GetSnapshotPacket represents a consensus snapshot query.
+type GetSnapshotPacket struct {
+	RequestId uint64      // Request ID to match up responses with
+	Hash      common.Hash // Hash of the checkpoint block
+}
+
+// SnapshotPacket is the network packet for the consensus snapshot, which is
+// empty if the snapshot is not available.
+type SnapshotPacket struct {
+	RequestId uint64 // ID of the request this is a response for
+	Snapshot  []byte // Snapshot in the database encoding of the engine
+}
+
 func (*BscCapPacket) Name() string { return "BscCap" }
 func (*BscCapPacket) Kind() byte   { return BscCapMsg }
 
 func (*VotesPacket) Name() string { return "Votes" }
 func (*VotesPacket) Kind() byte   { return VotesMsg }
+
+func (*GetSnapshotPacket) Name() string { return "GetSnapshot" }
+func (*GetSnapshotPacket) Kind() byte   { return GetSnapshotMsg }
+
+func (*SnapshotPacket) Name() string { return "Snapshot" }
+func (*SnapshotPacket) Kind() byte   { return SnapshotMsg }
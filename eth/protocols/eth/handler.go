@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -128,6 +129,14 @@ type NodeInfo struct {
 	Genesis    common.Hash         `json:"genesis"`    // SHA3 hash of the host's genesis block
 	Config     *params.ChainConfig `json:"config"`     // Chain configuration for the fork rules
 	Head       common.Hash         `json:"head"`       // Hex hash of the host's best owned block
+
+	OasysForks []*params.OasysForkStatus `json:"oasysForks,omitempty"` // Oasys forks and their activation at the head
+}
+
+// epochReader is implemented by the consensus engines numbering the blocks by
+// the epochs, which some Oasys forks are activated at.
+type epochReader interface {
+	Epoch(chain consensus.ChainHeaderReader, header *types.Header) (uint64, error)
 }
 
 // nodeInfo retrieves some `eth` protocol metadata about the running host node.
@@ -135,12 +144,17 @@ func nodeInfo(chain *core.BlockChain, network uint64) *NodeInfo {
 	head := chain.CurrentBlock()
 	hash := head.Hash()
 
+	var epoch uint64
+	if engine, ok := chain.Engine().(epochReader); ok {
+		epoch, _ = engine.Epoch(chain, head)
+	}
 	return &NodeInfo{
 		Network:    network,
 		Difficulty: chain.GetTd(hash, head.Number.Uint64()),
 		Genesis:    chain.Genesis().Hash(),
 		Config:     chain.Config(),
 		Head:       hash,
+		OasysForks: chain.Config().OasysForkStatuses(head.Number.Uint64(), epoch),
 	}
 }
 
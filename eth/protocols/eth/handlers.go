@@ -441,7 +441,7 @@ func answerGetPooledTransactions(backend Backend, query GetPooledTransactionsReq
 		}
 		// Retrieve the requested transaction, skipping if unknown to us
 		tx := backend.TxPool().Get(hash)
-		if tx == nil {
+		if tx == nil || tx.Conditional() != nil {
 			continue
 		}
 		// If known, encode and queue for response packet
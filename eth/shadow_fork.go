@@ -0,0 +1,208 @@
+package eth
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/node"
+)
+
+// shadowForkBatch is the number of source blocks inserted at once.
+const shadowForkBatch = 128
+
+// Names of the scratch copies of the chain and the consensus databases, which
+// the shadow fork replays into instead of the databases of the node.
+const (
+	shadowForkChainDatabase     = "shadowfork/chaindata"
+	shadowForkConsensusDatabase = "shadowfork/consensus"
+)
+
+var (
+	shadowForkHeadGauge     = metrics.NewRegisteredGauge("oasys/shadowfork/head", nil)
+	shadowForkDivergedGauge = metrics.NewRegisteredGauge("oasys/shadowfork/diverged", nil)
+)
+
+// shadowFork replays the canonical blocks of another data directory on top of
+// the local chain, which runs with locally modified consensus parameters or
+// contracts. The replay stops at the first block the local chain rejects, e.g.
+// for a different state root, which is reported as the divergence. The local
+// chain is a scratch copy of the databases of the node, so the replay never
+// modifies them.
+type shadowFork struct {
+	eth    *Ethereum
+	source ethdb.Database
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newShadowFork opens the chain database of the source data directory. The
+// node owning the directory must be stopped.
+func newShadowFork(eth *Ethereum, datadir string) (*shadowFork, error) {
+	dir := filepath.Join(datadir, "geth", "chaindata")
+	source, err := rawdb.Open(rawdb.OpenOptions{
+		Directory:         dir,
+		AncientsDirectory: filepath.Join(dir, "ancient"),
+		Namespace:         "eth/db/shadowfork/",
+		Cache:             16,
+		Handles:           16,
+		ReadOnly:          true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open shadow fork source: %v", err)
+	}
+	genesis := rawdb.ReadCanonicalHash(source, 0)
+	if genesis != eth.blockchain.Genesis().Hash() {
+		source.Close()
+		return nil, fmt.Errorf("shadow fork source has a different genesis, source: %x, local: %x", genesis, eth.blockchain.Genesis().Hash())
+	}
+	return &shadowFork{
+		eth:    eth,
+		source: source,
+		quit:   make(chan struct{}),
+	}, nil
+}
+
+func (s *shadowFork) start() {
+	s.wg.Add(1)
+	go s.loop()
+}
+
+func (s *shadowFork) stop() {
+	close(s.quit)
+	s.wg.Wait()
+	s.source.Close()
+}
+
+func (s *shadowFork) loop() {
+	defer s.wg.Done()
+
+	number := s.eth.blockchain.CurrentBlock().Number.Uint64() + 1
+	log.Info("Started shadow fork replay", "number", number)
+	for {
+		select {
+		case <-s.quit:
+			return
+		default:
+		}
+		blocks := s.read(number)
+		if len(blocks) == 0 {
+			log.Info("Shadow fork replay reached the source head", "number", number-1)
+			return
+		}
+		if n, err := s.eth.blockchain.InsertChain(blocks); err != nil {
+			block := blocks[n]
+			shadowForkDivergedGauge.Update(block.Number().Int64())
+			log.Error("Shadow fork diverged from the source chain", "number", block.Number(), "hash", block.Hash(), "err", err)
+			return
+		}
+		number += uint64(len(blocks))
+		shadowForkHeadGauge.Update(int64(number - 1))
+	}
+}
+
+// read retrieves the next batch of the canonical blocks from the source.
+func (s *shadowFork) read(number uint64) []*types.Block {
+	var blocks []*types.Block
+	for i := uint64(0); i < shadowForkBatch; i++ {
+		hash := rawdb.ReadCanonicalHash(s.source, number+i)
+		if hash == (common.Hash{}) {
+			break
+		}
+		block := rawdb.ReadBlock(s.source, hash, number+i)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// copyShadowForkDatabase copies the named database of the node, along with its
+// ancients if any, into the scratch database replacing the previous copy, and
+// returns the ancient directory of the copy. The previous copy is kept until
+// the next start, so that the divergence can be inspected.
+func copyShadowForkDatabase(stack *node.Node, name, ancient, scratch string) (string, error) {
+	var (
+		src        = stack.ResolvePath(name)
+		srcAncient = stack.ResolveAncient(name, ancient)
+		dst        = stack.ResolvePath(scratch)
+		dstAncient = filepath.Join(dst, "ancient")
+	)
+	if err := os.RemoveAll(dst); err != nil {
+		return "", fmt.Errorf("failed to remove shadow fork database: %v", err)
+	}
+	// The ancients are copied along if nested in the database, or separately
+	// into the copy otherwise
+	var skip string
+	if ancient != "" {
+		skip = srcAncient
+	}
+	if err := copyDir(src, dst, skip); err != nil {
+		return "", fmt.Errorf("failed to copy %s to shadow fork database: %v", src, err)
+	}
+	if skip != "" {
+		if err := copyDir(srcAncient, dstAncient, ""); err != nil {
+			return "", fmt.Errorf("failed to copy %s to shadow fork database: %v", srcAncient, err)
+		}
+	}
+	log.Info("Copied database for shadow fork", "from", src, "to", dst)
+	return dstAncient, nil
+}
+
+// copyDir copies the files of the directory recursively, skipping the given
+// subdirectory. A missing directory is copied as an empty one.
+func copyDir(src, dst, skip string) error {
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		return err
+	}
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path == skip {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0700)
+		}
+		return copyFile(path, target)
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,82 @@
+package eth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/node"
+)
+
+func TestCopyShadowForkDatabase(t *testing.T) {
+	stack, err := node.New(&node.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	defer stack.Close()
+
+	var (
+		src     = stack.ResolvePath("chaindata")
+		ancient = filepath.Join(t.TempDir(), "ancient")
+		files   = map[string]string{
+			filepath.Join(src, "000001.log"):             "log",
+			filepath.Join(src, "MANIFEST-000001"):        "manifest",
+			filepath.Join(src, "ancient", "chain", "x"):  "nested",
+			filepath.Join(ancient, "chain", "headers.0"): "headers",
+		}
+	)
+	for path, data := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(path, want string) {
+		t.Helper()
+		if data, err := os.ReadFile(path); err != nil || string(data) != want {
+			t.Errorf("%s mismatch: have %q (%v), want %q", path, data, err, want)
+		}
+	}
+
+	// The database is copied along with the ancients outside of it
+	dst := stack.ResolvePath(shadowForkChainDatabase)
+	dstAncient, err := copyShadowForkDatabase(stack, "chaindata", ancient, shadowForkChainDatabase)
+	if err != nil {
+		t.Fatalf("failed to copy database: %v", err)
+	}
+	if want := filepath.Join(dst, "ancient"); dstAncient != want {
+		t.Fatalf("ancient mismatch: have %s, want %s", dstAncient, want)
+	}
+	check(filepath.Join(dst, "MANIFEST-000001"), "manifest")
+	check(filepath.Join(dstAncient, "chain", "headers.0"), "headers")
+
+	// The replay modifies the copy only, which is replaced on the next start
+	if err := os.WriteFile(filepath.Join(dst, "000002.log"), []byte("replayed"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := copyShadowForkDatabase(stack, "chaindata", ancient, shadowForkChainDatabase); err != nil {
+		t.Fatalf("failed to copy database again: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "000002.log")); !os.IsNotExist(err) {
+		t.Errorf("previous copy not replaced: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(src, "000002.log")); !os.IsNotExist(err) {
+		t.Errorf("source database modified: %v", err)
+	}
+	for path, data := range files {
+		check(path, data)
+	}
+
+	// The ancients nested in the database are copied with it
+	if _, err := copyShadowForkDatabase(stack, "chaindata", "", shadowForkChainDatabase); err != nil {
+		t.Fatalf("failed to copy database with nested ancients: %v", err)
+	}
+	check(filepath.Join(dst, "ancient", "chain", "x"), "nested")
+
+	// A missing database is copied as an empty one
+	if _, err := copyShadowForkDatabase(stack, "consensus", "", shadowForkConsensusDatabase); err != nil {
+		t.Fatalf("failed to copy missing database: %v", err)
+	}
+}
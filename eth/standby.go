@@ -0,0 +1,189 @@
+package eth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// standbyCheckInterval is the interval to check the expiry of the lease.
+const standbyCheckInterval = time.Second
+
+var (
+	standbyActiveGauge = metrics.NewRegisteredGauge("oasys/standby/active", nil)
+	takeoverCounter    = metrics.NewRegisteredCounter("oasys/standby/takeovers", nil)
+	stepdownCounter    = metrics.NewRegisteredCounter("oasys/standby/stepdowns", nil)
+)
+
+// standby holds the sealing and the voting of a hot standby node sharing the
+// keys of the primary validator node. The lease of the primary is renewed by the
+// blocks and the votes of the validator seen on the network, and the standby
+// takes over once the lease expires. If a block of the validator not sealed
+// locally shows up after the takeover, the primary is back and the standby steps
+// down. The double signs in between are refused by the seal guard of the engine
+// and by the vote journal, which records the votes of the primary.
+type standby struct {
+	eth    *Ethereum
+	engine *oasys.Oasys
+	lease  time.Duration
+
+	lock        sync.Mutex
+	armed       bool               // Whether mining was requested, protected by lock
+	active      bool               // Whether the standby took over, protected by lock
+	validator   common.Address     // Validator of the lease, protected by lock
+	voteAddress types.BLSPublicKey // Vote address of the validator, protected by lock
+	renewed     time.Time          // Last time the lease was renewed, protected by lock
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newStandby(eth *Ethereum, engine *oasys.Oasys, lease time.Duration) *standby {
+	return &standby{
+		eth:     eth,
+		engine:  engine,
+		lease:   lease,
+		renewed: time.Now(),
+		quit:    make(chan struct{}),
+	}
+}
+
+func (s *standby) start() {
+	s.wg.Add(1)
+	go s.loop()
+}
+
+func (s *standby) stop() {
+	close(s.quit)
+	s.wg.Wait()
+}
+
+// hold returns whether mining must be held as the lease of the primary is not
+// expired, arming the standby to take over the validator once it expires.
+func (s *standby) hold(validator common.Address) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.active {
+		return false
+	}
+	if !s.armed || s.validator != validator {
+		log.Info("Standby holds the sealing until the lease of the primary expires", "validator", validator, "lease", s.lease)
+	}
+	s.armed, s.validator = true, validator
+	return true
+}
+
+// disarm stops taking over the validator, as mining was stopped.
+func (s *standby) disarm() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.armed, s.active = false, false
+	standbyActiveGauge.Update(0)
+}
+
+// setVoteAddress sets the vote address of the validator, renewing the lease by
+// the votes of the primary.
+func (s *standby) setVoteAddress(voteAddress types.BLSPublicKey) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.voteAddress = voteAddress
+}
+
+func (s *standby) loop() {
+	defer s.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, 1)
+	headSub := s.eth.blockchain.SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+
+	voteCh := make(chan core.NewVoteEvent, voteChanSize)
+	if s.eth.votePool != nil {
+		voteSub := s.eth.votePool.SubscribeNewVoteEvent(voteCh)
+		defer voteSub.Unsubscribe()
+	}
+	ticker := time.NewTicker(standbyCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev := <-headCh:
+			s.onBlock(ev.Block.Header())
+		case ev := <-voteCh:
+			s.onVote(ev.Vote)
+		case <-ticker.C:
+			s.check()
+		case <-headSub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// onBlock renews the lease by a block of the validator. After the takeover, a
+// block not sealed locally means the primary is back, so the standby steps down.
+func (s *standby) onBlock(header *types.Header) {
+	s.lock.Lock()
+	if header.Coinbase != s.validator {
+		s.lock.Unlock()
+		return
+	}
+	stepdown := s.active && !s.engine.HasSealed(header)
+	if !s.active || stepdown {
+		s.renewed = time.Now()
+	}
+	if stepdown {
+		s.active = false
+		standbyActiveGauge.Update(0)
+	}
+	s.lock.Unlock()
+
+	if stepdown {
+		log.Warn("Primary validator is back, standby steps down", "validator", header.Coinbase, "number", header.Number, "hash", header.Hash())
+		stepdownCounter.Inc(1)
+		s.eth.miner.Stop()
+	}
+}
+
+// onVote renews the lease by a vote of the validator, unless the standby took
+// over, as the votes can't be told apart from the local ones.
+func (s *standby) onVote(vote *types.VoteEnvelope) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.active && s.voteAddress != (types.BLSPublicKey{}) && vote.VoteAddress == s.voteAddress {
+		s.renewed = time.Now()
+	}
+}
+
+// check takes over the validator once the lease expires, if mining was requested
+// and the local chain is synced.
+func (s *standby) check() {
+	s.lock.Lock()
+	expired := s.armed && !s.active && time.Since(s.renewed) > s.lease && s.eth.handler.synced.Load()
+	if expired {
+		s.active = true
+		standbyActiveGauge.Update(1)
+	}
+	validator, renewed := s.validator, s.renewed
+	s.lock.Unlock()
+
+	if !expired {
+		return
+	}
+	log.Warn("Lease of the primary validator expired, standby takes over", "validator", validator, "renewed", renewed)
+	takeoverCounter.Inc(1)
+	if err := s.eth.StartMining(); err != nil {
+		log.Error("Standby failed to start mining", "err", err)
+		s.disarm()
+	}
+}
@@ -23,10 +23,12 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/protocols/bsc"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/log"
 )
@@ -41,6 +43,9 @@ func (h *handler) syncTransactions(p *eth.Peer) {
 	var hashes []common.Hash
 	for _, batch := range h.txpool.Pending(txpool.PendingFilter{OnlyPlainTxs: true}) {
 		for _, tx := range batch {
+			if tx.Tx != nil && tx.Tx.Conditional() != nil {
+				continue
+			}
 			hashes = append(hashes, tx.Hash)
 		}
 	}
@@ -59,6 +64,27 @@ func (h *handler) syncVotes(p *bscPeer) {
 	p.AsyncSendVotes(votes)
 }
 
+// syncConsensusSnapshot requests the consensus snapshot of the latest checkpoint
+// of the local header chain from the peer, if it has not been persisted yet, so
+// that the snapshot does not need to be reconstructed from the older checkpoints.
+func (h *handler) syncConsensusSnapshot(p *bscPeer) {
+	engine, ok := h.chain.Engine().(*oasys.Oasys)
+	if !ok || p.Version() < bsc.Bsc2 || h.synced.Load() {
+		return
+	}
+	checkpoint := engine.LatestCheckpoint(h.chain.CurrentHeader().Number.Uint64())
+	if checkpoint == 0 {
+		return
+	}
+	header := h.chain.GetHeaderByNumber(checkpoint)
+	if header == nil || engine.HasSnapshot(header.Hash()) {
+		return
+	}
+	if err := p.RequestSnapshot(header.Hash()); err != nil {
+		p.Log().Debug("Failed to request consensus snapshot", "number", checkpoint, "err", err)
+	}
+}
+
 // chainSyncer coordinates blockchain sync components.
 type chainSyncer struct {
 	handler     *handler
@@ -0,0 +1,86 @@
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BuiltinContractsTracer is the name of the native tracer attributing the gas and
+// the storage accesses of the execution to the built-in contracts.
+const BuiltinContractsTracer = "builtinContractsTracer"
+
+// BuiltinContractAccess is the gas and the storage accesses attributed to a
+// built-in contract. The gas excludes the gas forwarded to the sub calls.
+type BuiltinContractAccess struct {
+	Name   string         `json:"name"`
+	Calls  hexutil.Uint64 `json:"calls"`
+	Gas    hexutil.Uint64 `json:"gas"`
+	Reads  hexutil.Uint64 `json:"reads"`
+	Writes hexutil.Uint64 `json:"writes"`
+}
+
+// Add adds up the accesses of another execution.
+func (a *BuiltinContractAccess) Add(other *BuiltinContractAccess) {
+	a.Calls += other.Calls
+	a.Gas += other.Gas
+	a.Reads += other.Reads
+	a.Writes += other.Writes
+}
+
+// BlockBuiltinContractAccess is the breakdown of the accesses to the built-in
+// contracts by the transactions of a block.
+type BlockBuiltinContractAccess struct {
+	Number       hexutil.Uint64                            `json:"number"`
+	Hash         common.Hash                               `json:"hash"`
+	Transactions hexutil.Uint64                            `json:"transactions"`
+	Contracts    map[common.Address]*BuiltinContractAccess `json:"contracts"`
+}
+
+// TraceBuiltinContracts re-executes the transactions of the block and returns the
+// gas and the storage reads and writes attributed to each built-in contract, to
+// find out which of them are worth optimizing the storage layout.
+func (api *API) TraceBuiltinContracts(ctx context.Context, number rpc.BlockNumber, config *TraceConfig) (*BlockBuiltinContractAccess, error) {
+	block, err := api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	tracer := BuiltinContractsTracer
+	traceConfig := &TraceConfig{Tracer: &tracer}
+	if config != nil {
+		traceConfig.Timeout = config.Timeout
+		traceConfig.Reexec = config.Reexec
+	}
+	results, err := api.traceBlock(ctx, block, traceConfig)
+	if err != nil {
+		return nil, err
+	}
+	access := &BlockBuiltinContractAccess{
+		Number:       hexutil.Uint64(block.NumberU64()),
+		Hash:         block.Hash(),
+		Transactions: hexutil.Uint64(len(results)),
+		Contracts:    make(map[common.Address]*BuiltinContractAccess),
+	}
+	for _, result := range results {
+		raw, ok := result.Result.(json.RawMessage)
+		if !ok {
+			return nil, fmt.Errorf("unexpected trace result of tx %s", result.TxHash)
+		}
+		var contracts map[common.Address]*BuiltinContractAccess
+		if err := json.Unmarshal(raw, &contracts); err != nil {
+			return nil, err
+		}
+		for addr, contract := range contracts {
+			if total, ok := access.Contracts[addr]; ok {
+				total.Add(contract)
+			} else {
+				access.Contracts[addr] = contract
+			}
+		}
+	}
+	return access, nil
+}
@@ -0,0 +1,31 @@
+package tracers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/tracing"
+)
+
+type consensusCtorFn func(config json.RawMessage) (*tracing.ConsensusHooks, error)
+
+// ConsensusDirectory is the collection of the tracers receiving the events of
+// the Oasys consensus engine, which never trace the VM.
+var ConsensusDirectory = consensusDirectory{elems: make(map[string]consensusCtorFn)}
+
+type consensusDirectory struct {
+	elems map[string]consensusCtorFn
+}
+
+// Register registers a consensus tracer constructor by name.
+func (d *consensusDirectory) Register(name string, f consensusCtorFn) {
+	d.elems[name] = f
+}
+
+// New instantiates a consensus tracer by name.
+func (d *consensusDirectory) New(name string, config json.RawMessage) (*tracing.ConsensusHooks, error) {
+	if f, ok := d.elems[name]; ok {
+		return f(config)
+	}
+	return nil, fmt.Errorf("consensus tracer %q not found", name)
+}
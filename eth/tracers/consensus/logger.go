@@ -0,0 +1,44 @@
+// Package consensus contains the consensus tracers bundled by default.
+package consensus
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func init() {
+	tracers.ConsensusDirectory.Register("logger", newLogger)
+}
+
+// newLogger creates a consensus tracer logging every consensus event.
+func newLogger(cfg json.RawMessage) (*tracing.ConsensusHooks, error) {
+	return &tracing.ConsensusHooks{
+		OnSnapshotApplied: func(number uint64, hash common.Hash, validators []common.Address) {
+			log.Info("Consensus event", "event", "snapshotApplied", "number", number, "hash", hash, "validators", len(validators))
+		},
+		OnEpochTransition: func(epoch uint64, number uint64, env *params.EnvironmentValue) {
+			log.Info("Consensus event", "event", "epochTransition", "epoch", epoch, "number", number, "period", env.EpochPeriod)
+		},
+		OnValidatorSetChange: func(number uint64, prev, next []common.Address) {
+			log.Info("Consensus event", "event", "validatorSetChange", "number", number, "prev", len(prev), "next", len(next))
+		},
+		OnSystemTx: func(header *types.Header, tx *types.Transaction) {
+			log.Info("Consensus event", "event", "systemTx", "number", header.Number, "hash", tx.Hash(), "to", tx.To())
+		},
+		OnSlash: func(header *types.Header, validator common.Address, blocks int64) {
+			log.Info("Consensus event", "event", "slash", "number", header.Number, "validator", validator, "blocks", blocks)
+		},
+		OnAttestationAssembled: func(header *types.Header, attestation *types.VoteAttestation) {
+			log.Info("Consensus event", "event", "attestationAssembled", "number", header.Number, "source", attestation.Data.SourceNumber, "target", attestation.Data.TargetNumber)
+		},
+		OnAttestationVerified: func(header *types.Header, attestation *types.VoteAttestation) {
+			log.Info("Consensus event", "event", "attestationVerified", "number", header.Number, "source", attestation.Data.SourceNumber, "target", attestation.Data.TargetNumber)
+		},
+	}, nil
+}
@@ -0,0 +1,20 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func TestConsensusDirectory(t *testing.T) {
+	if _, err := tracers.ConsensusDirectory.New("unknown", nil); err == nil {
+		t.Fatal("created unknown tracer")
+	}
+	hooks, err := tracers.ConsensusDirectory.New("logger", nil)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	if hooks.OnSnapshotApplied == nil || hooks.OnSlash == nil || hooks.OnAttestationVerified == nil {
+		t.Errorf("missing consensus hooks: %+v", hooks)
+	}
+}
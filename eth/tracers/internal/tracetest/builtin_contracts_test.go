@@ -0,0 +1,81 @@
+package tracetest
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/contracts/oasys"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+func TestBuiltinContractsTracer(t *testing.T) {
+	testBuiltinContractsTracer(t, params.MainnetChainConfig, common.HexToAddress(oasys.StakeManagerAddress))
+
+	// The system contracts at the addresses configured in the genesis
+	config := *params.MainnetChainConfig
+	config.Oasys = &params.OasysConfig{Contracts: &params.OasysContracts{StakeManager: common.HexToAddress("0x5000")}}
+	testBuiltinContractsTracer(t, &config, common.HexToAddress("0x5000"))
+}
+
+func testBuiltinContractsTracer(t *testing.T, config *params.ChainConfig, to common.Address) {
+	var (
+		origin  = common.HexToAddress("0x00000000000000000000000000000000feed")
+		context = vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			BlockNumber: new(big.Int).SetUint64(8000000),
+			Time:        5,
+			Difficulty:  big.NewInt(0x30000),
+			GasLimit:    uint64(6000000),
+		}
+	)
+	// Increment the slot 0
+	code := []byte{
+		byte(vm.PUSH1), 0x0, byte(vm.SLOAD),
+		byte(vm.PUSH1), 0x1, byte(vm.ADD),
+		byte(vm.PUSH1), 0x0, byte(vm.SSTORE),
+		byte(vm.STOP),
+	}
+	state := tests.MakePreState(rawdb.NewMemoryDatabase(),
+		types.GenesisAlloc{
+			to:     types.Account{Code: code},
+			origin: types.Account{Balance: big.NewInt(500000000000000)},
+		}, false, rawdb.HashScheme)
+	defer state.Close()
+
+	tracer, err := tracers.DefaultDirectory.New(tracers.BuiltinContractsTracer, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	evm := vm.NewEVM(context, vm.TxContext{Origin: origin, GasPrice: big.NewInt(1)}, state.StateDB, config, vm.Config{Tracer: tracer})
+	msg := &core.Message{
+		To:        &to,
+		From:      origin,
+		Value:     big.NewInt(0),
+		GasLimit:  80000,
+		GasPrice:  big.NewInt(0),
+		GasFeeCap: big.NewInt(0),
+		GasTipCap: big.NewInt(0),
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(msg.GasLimit))
+	if _, err := st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	want := fmt.Sprintf(`{"%s":{"name":"StakeManager","calls":"0x1","gas":"0x4ef4","reads":"0x1","writes":"0x1"}}`, strings.ToLower(to.Hex()))
+	if string(res) != want {
+		t.Errorf("trace mismatch\n have: %v\n want: %v\n", string(res), want)
+	}
+}
@@ -0,0 +1,119 @@
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/contracts/oasys"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register(tracers.BuiltinContractsTracer, newBuiltinContractsTracer, false)
+}
+
+// builtinContractsTracer attributes the gas and the storage reads and writes of
+// a transaction to the built-in contracts (StakeManager, Environment, L1Build*,
+// bridges, ...) executing them. The delegated executions are attributed to the
+// contract owning the storage. The gas of the call and create opcodes is left out,
+// as it's mostly forwarded to the callee.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x...", {tracer: "builtinContractsTracer"})
+//	{
+//	  "0x0000000000000000000000000000000000001001": {
+//	    "name": "StakeManager",
+//	    "calls": "0x1",
+//	    "gas": "0x7d3c",
+//	    "reads": "0xc",
+//	    "writes": "0x3"
+//	  }
+//	}
+type builtinContractsTracer struct {
+	noopTracer
+	names     map[common.Address]string // Names of the built-in contracts of the chain traced
+	contracts map[common.Address]*tracers.BuiltinContractAccess
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+// newBuiltinContractsTracer returns a native go tracer which attributes the
+// accesses of a tx to the built-in contracts, and implements vm.EVMLogger.
+func newBuiltinContractsTracer(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	return &builtinContractsTracer{
+		contracts: make(map[common.Address]*tracers.BuiltinContractAccess),
+	}, nil
+}
+
+// access returns the accesses of the address, or nil if it's not a built-in contract.
+func (t *builtinContractsTracer) access(addr common.Address) *tracers.BuiltinContractAccess {
+	if access, ok := t.contracts[addr]; ok {
+		return access
+	}
+	name, ok := t.names[addr]
+	if !ok {
+		return nil
+	}
+	access := &tracers.BuiltinContractAccess{Name: name}
+	t.contracts[addr] = access
+	return access
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *builtinContractsTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.names = oasys.BuiltinContracts(env.ChainConfig())
+	if access := t.access(to); access != nil {
+		access.Calls++
+	}
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *builtinContractsTracer) CaptureEnter(op vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	if access := t.access(to); access != nil {
+		access.Calls++
+	}
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *builtinContractsTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if t.interrupt.Load() || err != nil {
+		return
+	}
+	access := t.access(scope.Contract.Address())
+	if access == nil {
+		return
+	}
+	switch op {
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL, vm.CREATE, vm.CREATE2:
+		return
+	case vm.SLOAD:
+		access.Reads++
+	case vm.SSTORE:
+		access.Writes++
+	}
+	access.Gas += hexutil.Uint64(cost)
+}
+
+// GetResult returns the json-encoded accesses by built-in contract address, and
+// any error arising from the encoding or forceful termination (via `Stop`).
+func (t *builtinContractsTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.contracts)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *builtinContractsTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
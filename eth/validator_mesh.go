@@ -0,0 +1,254 @@
+package eth
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/oasys"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/dnsdisc"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// validatorMeshRefresh is the interval to resolve the validator node lists again.
+const validatorMeshRefresh = 10 * time.Minute
+
+// validatorEntry is the ENR entry of the nodes run by the validators, holding
+// the signature of the validator over the node ID. It binds the node to the
+// validator, as the record itself is signed by the node key.
+type validatorEntry struct {
+	Validator common.Address
+	Sig       []byte
+}
+
+// ENRKey implements enr.Entry.
+func (e validatorEntry) ENRKey() string {
+	return "oasys"
+}
+
+// validatorNodeData returns the data signed by the validator to prove that it
+// runs the node.
+func validatorNodeData(id enode.ID) []byte {
+	return append([]byte("oasys validator node:"), id[:]...)
+}
+
+// nodeValidator returns the validator proven to run the node by the entry of its
+// record, the zero address if none.
+func nodeValidator(node *enode.Node) common.Address {
+	var entry validatorEntry
+	if err := node.Load(&entry); err != nil {
+		return common.Address{}
+	}
+	pub, err := crypto.SigToPub(crypto.Keccak256(validatorNodeData(node.ID())), entry.Sig)
+	if err != nil || crypto.PubkeyToAddress(*pub) != entry.Validator {
+		return common.Address{}
+	}
+	return entry.Validator
+}
+
+// meshServer is the part of the p2p server used by the validator mesh.
+type meshServer interface {
+	Self() *enode.Node
+	LocalNode() *enode.LocalNode
+	AddPeer(node *enode.Node)
+	RemovePeer(node *enode.Node)
+	AddTrustedPeer(node *enode.Node)
+	RemoveTrustedPeer(node *enode.Node)
+}
+
+// validatorMesh maintains persistent connections to the nodes of the current
+// validators, so that blocks and votes are exchanged directly among them. The
+// validators are taken from the StakeManager at each epoch, and their nodes are
+// resolved from the configured enode:// or enrtree:// URLs. Only the nodes whose
+// records carry the signature of a current validator are trusted, the others
+// listed are merely kept connected.
+//
+// The node of a local validator signs its own record, so that it can be
+// published in the node lists of the network.
+type validatorMesh struct {
+	server meshServer
+	chain  *core.BlockChain
+	engine *oasys.Oasys
+	sign   func(data []byte) (common.Address, []byte, error) // Signs with the local validator key, nil if not a validator
+	client *dnsdisc.Client
+	urls   []string
+
+	validators []common.Address         // Validators of the current epoch
+	nodes      map[enode.ID]*enode.Node // Nodes currently kept connected
+	quit       chan struct{}
+	wg         sync.WaitGroup
+}
+
+func newValidatorMesh(server meshServer, chain *core.BlockChain, engine *oasys.Oasys, urls []string) *validatorMesh {
+	m := &validatorMesh{
+		server: server,
+		chain:  chain,
+		engine: engine,
+		client: dnsdisc.NewClient(dnsdisc.Config{}),
+		urls:   urls,
+		nodes:  make(map[enode.ID]*enode.Node),
+		quit:   make(chan struct{}),
+	}
+	if engine != nil {
+		m.sign = engine.SignData
+	}
+	return m
+}
+
+func (m *validatorMesh) start() {
+	m.wg.Add(1)
+	go m.loop()
+}
+
+func (m *validatorMesh) stop() {
+	close(m.quit)
+	m.wg.Wait()
+}
+
+func (m *validatorMesh) loop() {
+	defer m.wg.Done()
+
+	var (
+		epochCh  = make(chan *oasys.EpochEvent, 16)
+		epochErr <-chan error
+	)
+	if m.engine != nil {
+		sub := m.engine.SubscribeEpochEvents(epochCh)
+		defer sub.Unsubscribe()
+		epochErr = sub.Err()
+
+		m.validators = m.currentValidators()
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case ev := <-epochCh:
+			if ev.Type != oasys.EpochStarted {
+				continue
+			}
+			m.validators = ev.Validators
+			m.refresh()
+		case <-timer.C:
+			m.refresh()
+			timer.Reset(validatorMeshRefresh)
+		case <-epochErr:
+			return
+		case <-m.quit:
+			for _, node := range m.nodes {
+				m.server.RemoveTrustedPeer(node)
+				m.server.RemovePeer(node)
+			}
+			return
+		}
+	}
+}
+
+// currentValidators returns the validators of the epoch at the chain head, nil
+// if they can't be retrieved yet.
+func (m *validatorMesh) currentValidators() []common.Address {
+	head := m.chain.CurrentHeader()
+	epoch, err := m.engine.Epoch(m.chain, head)
+	if err != nil {
+		log.Debug("Failed to get the epoch for the validator mesh", "number", head.Number, "err", err)
+		return nil
+	}
+	ev, err := m.engine.EpochStartedEvent(m.chain, head, epoch)
+	if err != nil {
+		log.Debug("Failed to get the validators for the validator mesh", "epoch", epoch, "err", err)
+		return nil
+	}
+	return ev.Validators
+}
+
+// signRecord sets the entry proving the local node is run by the validator the
+// engine is authorized with, if not set yet.
+func (m *validatorMesh) signRecord() {
+	if m.sign == nil {
+		return
+	}
+	self := m.server.Self()
+	data := validatorNodeData(self.ID())
+	validator, sig, err := m.sign(data)
+	if err != nil {
+		log.Warn("Failed to sign the validator node record", "err", err)
+		return
+	}
+	if validator == (common.Address{}) || nodeValidator(self) == validator {
+		return
+	}
+	m.server.LocalNode().Set(validatorEntry{Validator: validator, Sig: sig})
+	log.Info("Signed the validator node record", "validator", validator, "id", self.ID())
+}
+
+// refresh resolves the nodes of the configured URLs, and updates the connected
+// nodes, trusting the ones of the current validators. The nodes of the lists
+// failed to be resolved are kept until the next refresh.
+func (m *validatorMesh) refresh() {
+	m.signRecord()
+
+	var (
+		resolved = make(map[enode.ID]*enode.Node)
+		failed   bool
+	)
+	for _, url := range m.urls {
+		nodes, err := m.resolve(url)
+		if err != nil {
+			log.Warn("Failed to resolve validator nodes", "url", url, "err", err)
+			failed = true
+			continue
+		}
+		for _, node := range nodes {
+			resolved[node.ID()] = node
+		}
+	}
+	delete(resolved, m.server.Self().ID())
+
+	trusted := make(map[enode.ID]bool)
+	for id, node := range resolved {
+		if validator := nodeValidator(node); validator != (common.Address{}) && slices.Contains(m.validators, validator) {
+			trusted[id] = true
+		}
+		old, ok := m.nodes[id]
+		if !ok || old.Seq() < node.Seq() {
+			m.server.AddPeer(node)
+			m.nodes[id] = node
+		}
+		if trusted[id] {
+			m.server.AddTrustedPeer(node)
+		} else {
+			m.server.RemoveTrustedPeer(node)
+		}
+	}
+	if !failed {
+		for id, node := range m.nodes {
+			if _, ok := resolved[id]; !ok {
+				m.server.RemoveTrustedPeer(node)
+				m.server.RemovePeer(node)
+				delete(m.nodes, id)
+			}
+		}
+	}
+	log.Debug("Refreshed validator mesh", "validators", len(m.validators), "nodes", len(m.nodes), "trusted", len(trusted))
+}
+
+func (m *validatorMesh) resolve(url string) ([]*enode.Node, error) {
+	if strings.HasPrefix(url, "enrtree://") {
+		tree, err := m.client.SyncTree(url)
+		if err != nil {
+			return nil, err
+		}
+		return tree.Nodes(), nil
+	}
+	node, err := enode.Parse(enode.ValidSchemes, url)
+	if err != nil {
+		return nil, err
+	}
+	return []*enode.Node{node}, nil
+}
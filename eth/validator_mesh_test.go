@@ -0,0 +1,139 @@
+package eth
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// testMeshServer records the nodes kept connected by the validator mesh.
+type testMeshServer struct {
+	local   *enode.LocalNode
+	peers   map[enode.ID]bool
+	trusted map[enode.ID]bool
+}
+
+func newTestMeshServer(t *testing.T) *testMeshServer {
+	db, err := enode.OpenDB("")
+	if err != nil {
+		t.Fatalf("failed to open node database: %v", err)
+	}
+	t.Cleanup(db.Close)
+	key, _ := crypto.GenerateKey()
+	return &testMeshServer{
+		local:   enode.NewLocalNode(db, key),
+		peers:   make(map[enode.ID]bool),
+		trusted: make(map[enode.ID]bool),
+	}
+}
+
+func (s *testMeshServer) Self() *enode.Node                  { return s.local.Node() }
+func (s *testMeshServer) LocalNode() *enode.LocalNode        { return s.local }
+func (s *testMeshServer) AddPeer(node *enode.Node)           { s.peers[node.ID()] = true }
+func (s *testMeshServer) RemovePeer(node *enode.Node)        { delete(s.peers, node.ID()) }
+func (s *testMeshServer) AddTrustedPeer(node *enode.Node)    { s.trusted[node.ID()] = true }
+func (s *testMeshServer) RemoveTrustedPeer(node *enode.Node) { delete(s.trusted, node.ID()) }
+
+func newTestMeshNode(t *testing.T) *enode.Node {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return enode.NewV4(&key.PublicKey, nil, 30303, 30303)
+}
+
+// newTestValidatorNode returns the record of a node signed by the validator key.
+func newTestValidatorNode(t *testing.T, validator *ecdsa.PrivateKey) *enode.Node {
+	server := newTestMeshServer(t)
+	mesh := newValidatorMesh(server, nil, nil, nil)
+	mesh.sign = testMeshSigner(validator)
+	mesh.signRecord()
+	return server.Self()
+}
+
+func testMeshSigner(key *ecdsa.PrivateKey) func([]byte) (common.Address, []byte, error) {
+	return func(data []byte) (common.Address, []byte, error) {
+		sig, err := crypto.Sign(crypto.Keccak256(data), key)
+		return crypto.PubkeyToAddress(key.PublicKey), sig, err
+	}
+}
+
+func TestValidatorMeshResolve(t *testing.T) {
+	const url = "enode://a979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef29b69ad0dce72a4d8db5ebb4968de0e3bec910127f134779fbcb0cb6d3331163c@52.16.188.185:30303"
+
+	mesh := newValidatorMesh(newTestMeshServer(t), nil, nil, nil)
+	nodes, err := mesh.resolve(url)
+	if err != nil {
+		t.Fatalf("failed to resolve enode URL: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].URLv4() != url {
+		t.Fatalf("resolved nodes mismatch: have %v, want %s", nodes, url)
+	}
+	if _, err := mesh.resolve("enode://invalid"); err == nil {
+		t.Fatal("expected error for invalid URL")
+	}
+}
+
+func TestValidatorMeshSignRecord(t *testing.T) {
+	var (
+		key, _    = crypto.GenerateKey()
+		other, _  = crypto.GenerateKey()
+		validator = crypto.PubkeyToAddress(key.PublicKey)
+	)
+	node := newTestValidatorNode(t, key)
+	if have := nodeValidator(node); have != validator {
+		t.Fatalf("validator mismatch: have %v, want %v", have, validator)
+	}
+	// The signature of the other key or the other node proves nothing
+	server := newTestMeshServer(t)
+	mesh := newValidatorMesh(server, nil, nil, nil)
+	mesh.sign = func(data []byte) (common.Address, []byte, error) {
+		sig, err := crypto.Sign(crypto.Keccak256(data), other)
+		return validator, sig, err
+	}
+	mesh.signRecord()
+	if have := nodeValidator(server.Self()); have != (common.Address{}) {
+		t.Fatalf("forged record accepted for %v", have)
+	}
+	if have := nodeValidator(newTestMeshNode(t)); have != (common.Address{}) {
+		t.Fatalf("unsigned record accepted for %v", have)
+	}
+}
+
+func TestValidatorMeshRefresh(t *testing.T) {
+	var (
+		key1, _  = crypto.GenerateKey()
+		key2, _  = crypto.GenerateKey()
+		node1    = newTestValidatorNode(t, key1)
+		node2    = newTestValidatorNode(t, key2)
+		listed   = newTestMeshNode(t)
+		server   = newTestMeshServer(t)
+		address1 = crypto.PubkeyToAddress(key1.PublicKey)
+		address2 = crypto.PubkeyToAddress(key2.PublicKey)
+	)
+	mesh := newValidatorMesh(server, nil, nil, []string{node1.String(), node2.String(), listed.URLv4(), server.Self().String()})
+
+	// The listed nodes are connected except for the local node, and only the
+	// ones signed by the current validators are trusted
+	mesh.validators = []common.Address{address1, address2}
+	mesh.refresh()
+	if len(server.peers) != 3 {
+		t.Fatalf("connected nodes mismatch: have %v", server.peers)
+	}
+	if len(server.trusted) != 2 || !server.trusted[node1.ID()] || !server.trusted[node2.ID()] {
+		t.Fatalf("trusted nodes mismatch: have %v", server.trusted)
+	}
+
+	// The node of the validator leaving the set at the next epoch is no longer trusted
+	mesh.validators = []common.Address{address1}
+	mesh.refresh()
+	if len(server.peers) != 3 {
+		t.Fatalf("connected nodes mismatch after epoch: have %v", server.peers)
+	}
+	if len(server.trusted) != 1 || !server.trusted[node1.ID()] {
+		t.Fatalf("trusted nodes mismatch after epoch: have %v", server.trusted)
+	}
+}
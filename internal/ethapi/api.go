@@ -1867,8 +1867,14 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 		return common.Hash{}, errors.New("state not found")
 	}
 	// Fail if the caller is not allowed to create
-	if to == nil && !vm.IsAllowedToCreate(state, from) {
-		return common.Hash{}, fmt.Errorf("the deployer address is not allowed. please submit application form. from: %s", from)
+	if to == nil {
+		allowed := vm.IsAllowedToCreate(state, from)
+		if b.ChainConfig().IsOasysDeployerAllowList(new(big.Int).Add(head.Number, common.Big1)) {
+			allowed = vm.IsAllowedDeployer(b.ChainConfig(), state, from)
+		}
+		if !allowed {
+			return common.Hash{}, fmt.Errorf("the deployer address is not allowed. please submit application form. from: %s", from)
+		}
 	}
 	// Fail if the address is not allowed to call
 	if to != nil && vm.IsDeniedToCall(state, *to) {
@@ -1951,6 +1957,46 @@ func (s *TransactionAPI) SendRawTransaction(ctx context.Context, input hexutil.B
 	return SubmitTransaction(ctx, s.b, tx)
 }
 
+// SendRawTransactionConditional will add the signed transaction to the transaction
+// pool, if the preconditions hold against the latest block and the state referenced
+// by them. The preconditions are checked again when the transaction is included in
+// a block.
+//
+// The preconditions are not part of the transaction encoding, so the transaction
+// is never broadcast to the peers, which would include it unconditionally. It is
+// only included in the blocks sealed by this node, hence it must be submitted to
+// the validators expected to propose the blocks in the conditional range.
+func (s *TransactionAPI) SendRawTransactionConditional(ctx context.Context, input hexutil.Bytes, cond types.TransactionConditional) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	if err := cond.Validate(); err != nil {
+		return common.Hash{}, &invalidParamsError{err.Error()}
+	}
+	// Resolve the blocks from the chain as the miner does, rather than the ones
+	// served over RPC
+	head := s.b.CurrentHeader()
+	finalized, header, err := cond.Resolve(s.b)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if header == nil {
+		header = head
+	}
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, rpc.BlockNumberOrHashWithHash(header.Hash(), false))
+	if state == nil || err != nil {
+		return common.Hash{}, err
+	}
+	// The transaction is included in the next block at the earliest
+	next := &types.Header{Number: new(big.Int).Add(head.Number, common.Big1), Time: head.Time}
+	if err := cond.Check(next, finalized, state); err != nil {
+		return common.Hash{}, &conditionalError{err.Error()}
+	}
+	tx.SetConditional(&cond)
+	return SubmitTransaction(ctx, s.b, tx)
+}
+
 // Sign calculates an ECDSA signature for:
 // keccak256("\x19Ethereum Signed Message:\n" + len(message) + message).
 //
@@ -28,6 +28,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -501,8 +502,10 @@ func (b testBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc
 	}
 	panic("unknown type rpc.BlockNumberOrHash")
 }
-func (b testBackend) CurrentHeader() *types.Header { return b.chain.CurrentBlock() }
-func (b testBackend) CurrentBlock() *types.Header  { return b.chain.CurrentBlock() }
+func (b testBackend) CurrentHeader() *types.Header     { return b.chain.CurrentBlock() }
+func (b testBackend) CurrentBlock() *types.Header      { return b.chain.CurrentBlock() }
+func (b testBackend) CurrentSafeBlock() *types.Header  { return b.chain.CurrentSafeBlock() }
+func (b testBackend) CurrentFinalBlock() *types.Header { return b.chain.CurrentFinalBlock() }
 func (b testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
 	if number == rpc.LatestBlockNumber {
 		head := b.chain.CurrentBlock()
@@ -546,7 +549,15 @@ func (b testBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOr
 	if blockNr, ok := blockNrOrHash.Number(); ok {
 		return b.StateAndHeaderByNumber(ctx, blockNr)
 	}
-	panic("only implemented for number")
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		header := b.chain.GetHeaderByHash(hash)
+		if header == nil {
+			return nil, nil, errors.New("header not found")
+		}
+		stateDb, err := b.chain.StateAt(header.Root)
+		return stateDb, header, err
+	}
+	panic("invalid arguments")
 }
 func (b testBackend) PendingBlockAndReceipts() (*types.Block, types.Receipts) { panic("implement me") }
 func (b testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
@@ -1063,6 +1074,44 @@ func TestSignBlobTransaction(t *testing.T) {
 	}
 }
 
+func TestSendRawTransactionConditional(t *testing.T) {
+	t.Parallel()
+	var (
+		key, _  = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		genesis = &core.Genesis{Config: params.TestChainConfig, Alloc: types.GenesisAlloc{}}
+		b       = newTestBackend(t, 3, genesis, ethash.NewFaker(), nil)
+		api     = NewTransactionAPI(b, nil)
+		signer  = types.LatestSigner(params.TestChainConfig)
+		tx      = types.MustSignNewTx(key, signer, &types.LegacyTx{Gas: 21000, GasPrice: big.NewInt(params.InitialBaseFee)})
+		u64     = func(v uint64) *hexutil.Uint64 { return (*hexutil.Uint64)(&v) }
+	)
+	input, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		cond types.TransactionConditional
+		want string
+	}{
+		// The block range is checked against the next block
+		{types.TransactionConditional{BlockNumberMax: u64(3)}, types.ErrConditionalBlockNumber.Error()},
+		{types.TransactionConditional{BlockNumberMin: u64(5)}, types.ErrConditionalBlockNumber.Error()},
+		// The blocks are resolved from the chain, which has no safe block
+		{types.TransactionConditional{KnownAccountsBlock: types.ConditionalSafe}, "safe block not found"},
+		{types.TransactionConditional{KnownAccountsBlock: types.ConditionalFinalized}, "finalized block not found"},
+		{types.TransactionConditional{KnownAccounts: map[common.Address]types.KnownAccount{
+			b.acc.Address: {StorageSlots: map[common.Hash]common.Hash{{}: {0x01}}},
+		}}, types.ErrConditionalStorage.Error()},
+		{types.TransactionConditional{BlockNumberMin: u64(2), BlockNumberMax: u64(1)}, "range inverted"},
+	}
+	for i, tt := range tests {
+		_, err := api.SendRawTransactionConditional(context.Background(), input, tt.cond)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("test %d: error mismatch, have: %v, want: %s", i, err, tt.want)
+		}
+	}
+}
+
 func TestSendBlobTransaction(t *testing.T) {
 	t.Parallel()
 	// Initialize test accounts
@@ -76,3 +76,19 @@ func (e *TxIndexingError) ErrorCode() int {
 
 // ErrorData returns the hex encoded revert reason.
 func (e *TxIndexingError) ErrorData() interface{} { return "transaction indexing is in progress" }
+
+// invalidParamsError is an API error for the parameters failing the validation.
+type invalidParamsError struct{ message string }
+
+func (e *invalidParamsError) Error() string { return e.message }
+
+// ErrorCode returns the JSON error code for invalid parameters.
+func (e *invalidParamsError) ErrorCode() int { return -32602 }
+
+// conditionalError is an API error for the transaction whose preconditions fail.
+type conditionalError struct{ message string }
+
+func (e *conditionalError) Error() string { return e.message }
+
+// ErrorCode returns the JSON error code for a rejected transaction.
+func (e *conditionalError) ErrorCode() int { return -32003 }
@@ -25,6 +25,7 @@ var Modules = map[string]string{
 	"eth":      EthJs,
 	"miner":    MinerJs,
 	"net":      NetJs,
+	"oasys":    OasysJs,
 	"personal": PersonalJs,
 	"rpc":      RpcJs,
 	"txpool":   TxpoolJs,
@@ -90,6 +91,172 @@ web3._extend({
 });
 `
 
+const OasysJs = `
+web3._extend({
+	property: 'oasys',
+	methods: [
+		new web3._extend.Method({
+			name: 'getSnapshot',
+			call: 'oasys_getSnapshot',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getSnapshotAtHash',
+			call: 'oasys_getSnapshotAtHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getSigners',
+			call: 'oasys_getSigners',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getSignersAtHash',
+			call: 'oasys_getSignersAtHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getSigner',
+			call: 'oasys_getSigner',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'propose',
+			call: 'oasys_propose',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'discard',
+			call: 'oasys_discard',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getProposal',
+			call: 'oasys_getProposal',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getAttestationParticipation',
+			call: 'oasys_getAttestationParticipation',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'estimateClaimableCommissions',
+			call: 'oasys_estimateClaimableCommissions',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'estimateClaimableRewards',
+			call: 'oasys_estimateClaimableRewards',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getAllValidatorStakes',
+			call: 'oasys_getAllValidatorStakes',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getBlockAttestation',
+			call: 'oasys_getBlockAttestation',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getVoteAddresses',
+			call: 'oasys_getVoteAddresses',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getFleetStatus',
+			call: 'oasys_getFleetStatus',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getMintedRewards',
+			call: 'oasys_getMintedRewards',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'getFinalityProof',
+			call: 'oasys_getFinalityProof',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getFinalityRoots',
+			call: 'oasys_getFinalityRoots',
+			params: 3,
+			inputFormatter: [web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal, null]
+		}),
+		new web3._extend.Method({
+			name: 'inclusionStats',
+			call: 'oasys_inclusionStats',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'chainConfig',
+			call: 'oasys_chainConfig',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getNextEpochValidators',
+			call: 'oasys_getNextEpochValidators',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getValidatorSchedule',
+			call: 'oasys_getValidatorSchedule',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getSlashHistory',
+			call: 'oasys_getSlashHistory',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'buildBlockTemplate',
+			call: 'oasys_buildBlockTemplate',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getStakingOverview',
+			call: 'oasys_getStakingOverview',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'simulateEnvironmentUpdate',
+			call: 'oasys_simulateEnvironmentUpdate',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'debugSchedule',
+			call: 'oasys_debugSchedule',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'proposals',
+			getter: 'oasys_proposals'
+		}),
+	]
+});
+`
+
 const EthashJs = `
 web3._extend({
 	property: 'ethash',
@@ -153,6 +320,27 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'finalityBreaker',
+			call: 'admin_finalityBreaker',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'setFinalityBreaker',
+			call: 'admin_setFinalityBreaker',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'releaseFinalityBreaker',
+			call: 'admin_releaseFinalityBreaker',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'reloadEngineConfig',
+			call: 'admin_reloadEngineConfig',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -389,6 +577,17 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new web3._extend.Method({
+			name: 'traceEpochTransition',
+			call: 'debug_traceEpochTransition',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'badAttestations',
+			call: 'debug_badAttestations',
+			params: 0
+		}),
 		new web3._extend.Method({
 			name: 'traceBadBlock',
 			call: 'debug_traceBadBlock',
@@ -419,6 +618,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null]
 		}),
+		new web3._extend.Method({
+			name: 'traceBuiltinContracts',
+			call: 'debug_traceBuiltinContracts',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 		new web3._extend.Method({
 			name: 'traceBlockByHash',
 			call: 'debug_traceBlockByHash',
@@ -501,6 +706,11 @@ web3._extend({
 			call: 'debug_getTrieFlushInterval',
 			params: 0
 		}),
+		new web3._extend.Method({
+			name: 'getConsensusState',
+			call: 'debug_getConsensusState',
+			params: 0
+		}),
 	],
 	properties: []
 });
@@ -695,6 +905,18 @@ web3._extend({
 			call: 'miner_setRecommitInterval',
 			params: 1,
 		}),
+		new web3._extend.Method({
+			name: 'pauseSealing',
+			call: 'miner_pauseSealing',
+		}),
+		new web3._extend.Method({
+			name: 'resumeSealing',
+			call: 'miner_resumeSealing',
+		}),
+		new web3._extend.Method({
+			name: 'sealingPaused',
+			call: 'miner_sealingPaused',
+		}),
 		new web3._extend.Method({
 			name: 'getHashrate',
 			call: 'miner_getHashrate'
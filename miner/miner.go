@@ -41,6 +41,8 @@ import (
 type Backend interface {
 	BlockChain() *core.BlockChain
 	TxPool() *txpool.TxPool
+	PeerCount() int
+	PeersAhead(td *big.Int) int
 }
 
 // Config is the configuration parameters of mining.
@@ -54,8 +56,17 @@ type Config struct {
 
 	NewPayloadTimeout time.Duration // The maximum time allowance for creating a new payload
 
-	VoteEnable             bool // Whether to vote when mining
-	DisableVoteAttestation bool // Whether to skip assembling vote attestation
+	VoteEnable             bool          // Whether to vote when mining
+	DisableVoteAttestation bool          // Whether to skip assembling vote attestation
+	AttestationWait        time.Duration // Maximum time to postpone sealing for the votes close to the quorum, 0 disables the wait
+
+	MinPeers int // Minimum number of connected peers required to seal blocks, 0 disables the check
+
+	HealthBeacon bool // Whether to embed the client version and the vote status in the vanity of the sealed blocks
+
+	SystemGasReserve uint64 // Gas of each block kept out of the transaction selection for the Oasys system transactions
+
+	Signers []common.Address `toml:",omitempty"` // Additional validators to seal the blocks for along the etherbase, test networks only
 }
 
 // DefaultConfig contains default settings for miner.
@@ -69,6 +80,8 @@ var DefaultConfig = Config{
 	// run 3 rounds.
 	Recommit:          2 * time.Second,
 	NewPayloadTimeout: 2 * time.Second,
+
+	SystemGasReserve: 1000000,
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -185,6 +198,24 @@ func (miner *Miner) Mining() bool {
 	return miner.worker.isRunning()
 }
 
+// PauseSealing stops proposing new blocks from the next sealing task on. The
+// block being sealed, if any, is completed and the node keeps voting.
+func (miner *Miner) PauseSealing() {
+	miner.worker.pause()
+	log.Info("Sealing paused")
+}
+
+// ResumeSealing resumes proposing new blocks after PauseSealing.
+func (miner *Miner) ResumeSealing() {
+	miner.worker.resume()
+	log.Info("Sealing resumed")
+}
+
+// SealingPaused returns whether sealing is paused by PauseSealing.
+func (miner *Miner) SealingPaused() bool {
+	return miner.worker.isPaused()
+}
+
 func (miner *Miner) Hashrate() uint64 {
 	if pow, ok := miner.engine.(consensus.PoW); ok {
 		return uint64(pow.Hashrate())
@@ -248,6 +279,20 @@ func (miner *Miner) SubscribePendingLogs(ch chan<- []*types.Log) event.Subscript
 	return miner.worker.pendingLogsFeed.Subscribe(ch)
 }
 
+// BuildBlockTemplate builds a block on top of the current head with the pending
+// transactions, as the local etherbase would produce it now. The block is
+// neither sealed nor inserted into the chain.
+func (miner *Miner) BuildBlockTemplate() (*types.Block, types.Receipts, error) {
+	res := miner.worker.getSealingBlock(&generateParams{
+		timestamp: uint64(time.Now().Unix()),
+		coinbase:  miner.worker.etherbase(),
+	})
+	if res.err != nil {
+		return nil, nil, res.err
+	}
+	return res.block, res.receipts, nil
+}
+
 // BuildPayload builds the payload according to the provided parameters.
 func (miner *Miner) BuildPayload(args *BuildPayloadArgs) (*Payload, error) {
 	return miner.worker.buildPayload(args)
@@ -60,6 +60,14 @@ func (m *mockBackend) TxPool() *txpool.TxPool {
 	return m.txPool
 }
 
+func (m *mockBackend) PeerCount() int {
+	return 0
+}
+
+func (m *mockBackend) PeersAhead(td *big.Int) int {
+	return 0
+}
+
 func (m *mockBackend) StateAtBlock(block *types.Block, reexec uint64, base *state.StateDB, checkLive bool, preferDisk bool) (statedb *state.StateDB, err error) {
 	return nil, errors.New("not supported")
 }
@@ -250,6 +258,26 @@ func TestMinerSetEtherbase(t *testing.T) {
 	}
 }
 
+func TestBuildBlockTemplate(t *testing.T) {
+	t.Parallel()
+	miner, _, cleanup := createMiner(t)
+	defer cleanup(false)
+
+	block, receipts, err := miner.BuildBlockTemplate()
+	if err != nil {
+		t.Fatalf("failed to build block template: %v", err)
+	}
+	if block.NumberU64() != 1 {
+		t.Fatalf("unexpected block number, want: 1, got: %d", block.NumberU64())
+	}
+	if len(receipts) != len(block.Transactions()) {
+		t.Fatalf("mismatching receipts, want: %d, got: %d", len(block.Transactions()), len(receipts))
+	}
+	if current := miner.worker.chain.CurrentBlock().Number.Uint64(); current != 0 {
+		t.Fatalf("template must not be inserted, head: %d", current)
+	}
+}
+
 // waitForMiningState waits until either
 // * the desired mining state was reached
 // * a timeout was reached which fails the test
@@ -36,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/holiman/uint256"
@@ -79,6 +80,12 @@ var (
 	errBlockInterruptedByNewHead  = errors.New("new head arrived while building block")
 	errBlockInterruptedByRecommit = errors.New("recommit interrupt while building block")
 	errBlockInterruptedByTimeout  = errors.New("timeout while building block")
+
+	refusedSealCounter = metrics.NewRegisteredCounter("miner/seal/refused", nil)
+
+	// sealHeadLagBlocks is the number of in-turn blocks the peers may lead the
+	// local head by while the latest blocks propagate.
+	sealHeadLagBlocks = big.NewInt(3)
 )
 
 // environment is the worker's current environment and holds all
@@ -160,6 +167,7 @@ type newPayloadResult struct {
 	block    *types.Block
 	fees     *big.Int           // total block fees
 	sidecars types.BlobSidecars // collected blobs of blob transactions
+	receipts types.Receipts     // receipts of the block transactions
 }
 
 // getWorkReq represents a request for getting a new sealing work with provided parameters.
@@ -224,6 +232,7 @@ type worker struct {
 	running atomic.Bool  // The indicator whether the consensus engine is running or not.
 	newTxs  atomic.Int32 // New arrival transaction count since last sealing work submitting.
 	syncing atomic.Bool  // The indicator whether the node is still syncing.
+	paused  atomic.Bool  // The indicator whether sealing new blocks is paused by the operator.
 
 	// newpayloadTimeout is the maximum timeout allowance for creating payload.
 	// The default value is 2 seconds but node operator can set it to arbitrary
@@ -392,6 +401,24 @@ func (w *worker) isRunning() bool {
 	return w.running.Load()
 }
 
+// pause stops submitting new sealing tasks while keeping the worker running,
+// so that the node continues to build the pending block and to vote.
+func (w *worker) pause() {
+	w.paused.Store(true)
+}
+
+// resume restarts submitting sealing tasks and triggers new work submitting.
+func (w *worker) resume() {
+	if w.paused.CompareAndSwap(true, false) && w.isRunning() {
+		w.startCh <- struct{}{}
+	}
+}
+
+// isPaused returns an indicator whether sealing is paused or not.
+func (w *worker) isPaused() bool {
+	return w.paused.Load()
+}
+
 // close terminates all background threads maintained by the worker.
 // Note the worker does not support being closed multiple times.
 func (w *worker) close() {
@@ -810,10 +837,37 @@ func (w *worker) applyTransaction(env *environment, tx *types.Transaction) (*typ
 	return receipt, err
 }
 
+// checkConditional checks the preconditions of the transaction against the block
+// being assembled, on top of the state of the transactions already included.
+func (w *worker) checkConditional(env *environment, cond *types.TransactionConditional) error {
+	finalized, header, err := cond.Resolve(w.chain)
+	if err != nil {
+		return err
+	}
+	statedb := env.state
+	if header != nil {
+		if statedb, err = w.chain.StateAt(header.Root); err != nil {
+			return err
+		}
+	}
+	return cond.Check(env.header, finalized, statedb)
+}
+
+// systemGasReserve returns the gas of the block with the given gas limit which
+// is kept for the system transactions the Oasys engine applies on finalization,
+// so that the user transactions never leave them short of gas. At least half of
+// the block remains available to the transactions.
+func (w *worker) systemGasReserve(gasLimit uint64) uint64 {
+	if w.chainConfig.Oasys == nil {
+		return 0
+	}
+	return min(w.config.SystemGasReserve, gasLimit/2)
+}
+
 func (w *worker) commitTransactions(env *environment, plainTxs, blobTxs *transactionsByPriceAndNonce, interrupt *atomic.Int32) error {
 	gasLimit := env.header.GasLimit
 	if env.gasPool == nil {
-		env.gasPool = new(core.GasPool).AddGas(gasLimit)
+		env.gasPool = new(core.GasPool).AddGas(gasLimit - w.systemGasReserve(gasLimit))
 	}
 	var coalescedLogs []*types.Log
 
@@ -888,6 +942,14 @@ func (w *worker) commitTransactions(env *environment, plainTxs, blobTxs *transac
 			txs.Pop()
 			continue
 		}
+		// Skip the transaction whose preconditions of the local submission fail
+		if cond := tx.Conditional(); cond != nil {
+			if err := w.checkConditional(env, cond); err != nil {
+				log.Debug("Skipping transaction with failed conditional", "hash", ltx.Hash, "err", err)
+				txs.Pop()
+				continue
+			}
+		}
 		// Start executing the transaction
 		env.state.SetTxContext(tx.Hash(), env.tcount)
 
@@ -1019,7 +1081,13 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 	// Could potentially happen if starting to mine in an odd state.
 	// Note genParams.coinbase can be different with header.Coinbase
 	// since clique algorithm can modify the coinbase field in header.
-	env, err := w.makeEnv(parent, header, genParams.coinbase)
+	// The Oasys engine may seal for another validator authorized on the
+	// node, which is then the fee recipient.
+	coinbase := genParams.coinbase
+	if w.chainConfig.Oasys != nil {
+		coinbase = header.Coinbase
+	}
+	env, err := w.makeEnv(parent, header, coinbase)
 	if err != nil {
 		log.Error("Failed to create sealing context", "err", err)
 		return nil, err
@@ -1118,6 +1186,7 @@ func (w *worker) generateWork(params *generateParams) *newPayloadResult {
 		block:    block,
 		fees:     totalFees(block, receipts),
 		sidecars: work.sidecars,
+		receipts: receipts,
 	}
 }
 
@@ -1128,6 +1197,10 @@ func (w *worker) commitWork(interrupt *atomic.Int32, timestamp int64) {
 	if w.syncing.Load() {
 		return
 	}
+	// Refuse to seal if the node is isolated from the network or follows a
+	// minority fork, the blocks sealed would only extend the fork. The pending
+	// block is still built for the local users.
+	refused := w.sealingRefused()
 	start := time.Now()
 
 	// Set the coinbase if the worker is running or it's required
@@ -1149,6 +1222,10 @@ func (w *worker) commitWork(interrupt *atomic.Int32, timestamp int64) {
 	}
 	// Deploy oasys built-in contracts
 	contracts.Deploy(w.chainConfig, work.state, work.header.Number.Uint64())
+	// Warm the state read by the finalization while filling the transactions
+	if pos, ok := w.engine.(consensus.PoS); ok && w.isRunning() {
+		go pos.PrefetchFinalize(w.chain, types.CopyHeader(work.header), work.state.Copy())
+	}
 	// Fill pending transactions from the txpool into the block.
 	err = w.fillTransactions(interrupt, work)
 	switch {
@@ -1160,7 +1237,7 @@ func (w *worker) commitWork(interrupt *atomic.Int32, timestamp int64) {
 	case errors.Is(err, errBlockInterruptedByRecommit):
 		// Notify resubmit loop to increase resubmitting interval if the
 		// interruption is due to frequent commits.
-		gaslimit := work.header.GasLimit
+		gaslimit := work.header.GasLimit - w.systemGasReserve(work.header.GasLimit)
 		ratio := float64(gaslimit-work.gasPool.Gas()) / float64(gaslimit)
 		if ratio < 0.1 {
 			ratio = 0.1
@@ -1178,8 +1255,17 @@ func (w *worker) commitWork(interrupt *atomic.Int32, timestamp int64) {
 		work.discard()
 		return
 	}
+	// Postpone the empty block to the heartbeat of the chain, the sealing is
+	// replaced by the resubmitted work once a transaction arrives.
+	if pos, ok := w.engine.(consensus.PoS); ok && w.isRunning() && work.tcount == 0 {
+		if pos.DelayEmptyBlock(w.chain, work.header) {
+			log.Debug("Postponed empty block to the heartbeat", "number", work.header.Number, "time", work.header.Time)
+		}
+	}
 	// Submit the generated block for consensus sealing.
-	if err = w.commit(work.copy(), w.fullTaskHook, true, start); err != nil {
+	if refused {
+		w.updateSnapshot(work)
+	} else if err = w.commit(work.copy(), w.fullTaskHook, true, start); err != nil {
 		log.Warn("Failed to commit work", "in", "commitWork", "err", err)
 	}
 
@@ -1191,12 +1277,50 @@ func (w *worker) commitWork(interrupt *atomic.Int32, timestamp int64) {
 	w.current = work
 }
 
+// sealingRefused returns whether the worker must not seal on the current head,
+// as the node is connected to too few peers, or at least half of them lead the
+// head by more than the blocks in propagation.
+func (w *worker) sealingRefused() bool {
+	if !w.isRunning() || w.config.MinPeers <= 0 {
+		return false
+	}
+	peers := w.eth.PeerCount()
+	if peers < w.config.MinPeers {
+		refusedSealCounter.Inc(1)
+		log.Warn("Refusing to seal with too few peers", "peers", peers, "required", w.config.MinPeers)
+		return true
+	}
+	head := w.chain.CurrentBlock()
+	td := w.chain.GetTd(head.Hash(), head.Number.Uint64())
+	if td == nil {
+		return false
+	}
+	// The difficulty of the blocks scales with the total supply after the
+	// extended difficulty fork, so ask the engine for the in-turn one.
+	lag := head.Difficulty
+	if pos, ok := w.engine.(consensus.PoS); ok {
+		diff, err := pos.InTurnDifficulty(w.chain, head)
+		if err != nil {
+			log.Debug("Failed to get the in-turn difficulty", "number", head.Number, "hash", head.Hash(), "err", err)
+			return false
+		}
+		lag = diff
+	}
+	lag = new(big.Int).Mul(lag, sealHeadLagBlocks)
+	if ahead := w.eth.PeersAhead(lag.Add(lag, td)); 2*ahead >= peers {
+		refusedSealCounter.Inc(1)
+		log.Warn("Refusing to seal behind the peers", "number", head.Number, "hash", head.Hash(), "peers", peers, "ahead", ahead)
+		return true
+	}
+	return false
+}
+
 // commit runs any post-transaction state modifications, assembles the final block
 // and commits new work if consensus engine is running.
 // Note the assumption is held that the mutation is allowed to the passed env, do
 // the deep copy first.
 func (w *worker) commit(env *environment, interval func(), update bool, start time.Time) error {
-	if w.isRunning() {
+	if w.isRunning() && !w.isPaused() {
 		if interval != nil {
 			interval()
 		}
@@ -112,6 +112,8 @@ type testWorkerBackend struct {
 	txPool  *txpool.TxPool
 	chain   *core.BlockChain
 	genesis *core.Genesis
+	peers   int // Number of the connected peers
+	ahead   int // Number of the peers leading the head
 }
 
 func newTestWorkerBackend(t *testing.T, chainConfig *params.ChainConfig, engine consensus.Engine, db ethdb.Database, n int) *testWorkerBackend {
@@ -147,6 +149,8 @@ func newTestWorkerBackend(t *testing.T, chainConfig *params.ChainConfig, engine
 
 func (b *testWorkerBackend) BlockChain() *core.BlockChain { return b.chain }
 func (b *testWorkerBackend) TxPool() *txpool.TxPool       { return b.txPool }
+func (b *testWorkerBackend) PeerCount() int               { return b.peers }
+func (b *testWorkerBackend) PeersAhead(td *big.Int) int   { return b.ahead }
 
 func (b *testWorkerBackend) newRandomTx(creation bool) *types.Transaction {
 	var tx *types.Transaction
@@ -255,6 +259,113 @@ func testEmptyWork(t *testing.T, chainConfig *params.ChainConfig, engine consens
 	}
 }
 
+func TestPauseSealing(t *testing.T) {
+	t.Parallel()
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, _ := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	taskCh := make(chan struct{}, 2)
+	w.newTaskHook = func(task *task) {
+		taskCh <- struct{}{}
+	}
+	w.skipSealHook = func(task *task) bool { return true }
+
+	w.pause()
+	w.start()
+	select {
+	case <-taskCh:
+		t.Fatal("sealing task submitted while paused")
+	case <-time.NewTimer(500 * time.Millisecond).C:
+	}
+	if block, _ := w.pendingBlockAndReceipts(); block == nil {
+		t.Error("pending block not updated while paused")
+	}
+
+	w.resume()
+	select {
+	case <-taskCh:
+	case <-time.NewTimer(3 * time.Second).C:
+		t.Error("new task timeout after resume")
+	}
+}
+
+func TestRefuseSealingWithTooFewPeers(t *testing.T) {
+	t.Parallel()
+	testRefuseSealing(t, 0, 0)
+}
+
+func TestRefuseSealingBehindPeers(t *testing.T) {
+	t.Parallel()
+	testRefuseSealing(t, 4, 2)
+}
+
+func testRefuseSealing(t *testing.T, peers, ahead int) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	w, b := newTestWorker(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+	b.peers, b.ahead = peers, ahead
+
+	config := *testConfig
+	config.MinPeers = 1
+	w.config = &config
+
+	taskCh := make(chan struct{}, 2)
+	w.newTaskHook = func(task *task) {
+		taskCh <- struct{}{}
+	}
+	w.skipSealHook = func(task *task) bool { return true }
+
+	w.start()
+	select {
+	case <-taskCh:
+		t.Fatal("sealing task submitted")
+	case <-time.NewTimer(500 * time.Millisecond).C:
+	}
+	// The pending block is still built
+	if block := w.pendingBlock(); block == nil || block.NumberU64() != 1 {
+		t.Fatalf("pending block mismatch: have %v, want block #1", block)
+	}
+
+	// The sealing resumes once the peers agree with the head
+	w.stop()
+	b.peers, b.ahead = 4, 1
+	w.start()
+	select {
+	case <-taskCh:
+	case <-time.NewTimer(3 * time.Second).C:
+		t.Fatal("sealing task not submitted")
+	}
+}
+
+func TestSystemGasReserve(t *testing.T) {
+	config := *testConfig
+	config.SystemGasReserve = 1000000
+
+	oasysConfig := *ethashChainConfig
+	oasysConfig.Oasys = &params.OasysConfig{Period: 15, Epoch: 5760}
+
+	tests := []struct {
+		chainConfig *params.ChainConfig
+		gasLimit    uint64
+		want        uint64
+	}{
+		{ethashChainConfig, 30000000, 0},
+		{&oasysConfig, 30000000, 1000000},
+		{&oasysConfig, 1500000, 750000},
+	}
+	for i, tt := range tests {
+		w := &worker{chainConfig: tt.chainConfig, config: &config}
+		if have := w.systemGasReserve(tt.gasLimit); have != tt.want {
+			t.Errorf("test %d: reserve mismatch: have %d, want %d", i, have, tt.want)
+		}
+	}
+}
+
 func TestAdjustIntervalEthash(t *testing.T) {
 	t.Parallel()
 	testAdjustInterval(t, ethashChainConfig, ethash.NewFaker())
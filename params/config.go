@@ -457,6 +457,32 @@ func (c *CliqueConfig) String() string {
 type OasysConfig struct {
 	Period uint64 `json:"period"` // Number of seconds between blocks to enforce
 	Epoch  uint64 `json:"epoch"`  // Epoch length to reset votes and checkpoint
+
+	AttestationForkChoiceBlock *big.Int `json:"attestationForkChoiceBlock,omitempty"` // Attestation weighted fork choice switch block (nil = no fork, 0 = already activated)
+	CompactExtraBlock          *big.Int `json:"compactExtraBlock,omitempty"`          // Compact validators encoding in the epoch header switch block (nil = no fork, 0 = already activated)
+	AttestationHeaderBlock     *big.Int `json:"attestationHeaderBlock,omitempty"`     // Vote attestation in the header field switch block, requires Cancun (nil = no fork, 0 = already activated)
+	LargeVoteSetBlock          *big.Int `json:"largeVoteSetBlock,omitempty"`          // Vote address set beyond 64 validators switch block (nil = no fork, 0 = already activated)
+	ValidatorCapBlock          *big.Int `json:"validatorCapBlock,omitempty"`          // Validator set capped by the contract switch block (nil = no fork, 0 = already activated)
+	BLS12381Block              *big.Int `json:"bls12381Block,omitempty"`              // BLS12-381 precompiles switch block, requires Cancun (nil = no fork, 0 = already activated)
+	HeartbeatBlock             *big.Int `json:"heartbeatBlock,omitempty"`             // Heartbeat timestamp rules for the suppressed empty blocks switch block (nil = no fork, 0 = already activated)
+	DeployerAllowListBlock     *big.Int `json:"deployerAllowListBlock,omitempty"`     // Deployer allow list enforced on every contract creation switch block (nil = no fork, 0 = already activated)
+	MillisecondTimestampBlock  *big.Int `json:"millisecondTimestampBlock,omitempty"`  // Millisecond precision timestamps in the mix digest switch block (nil = no fork, 0 = already activated)
+	ExtraSizeLimitBlock        *big.Int `json:"extraSizeLimitBlock,omitempty"`        // Header extra data capped by the maximum size switch block (nil = no fork, 0 = already activated)
+
+	// Deployers creating contracts without being allowed by the EVMAccessControl
+	// contract after the deployer allow list fork, such as the system addresses
+	DeployerAllowListBypass []common.Address `json:"deployerAllowListBypass,omitempty"`
+
+	// Parameters for private networks such as local devnets
+	BackoffWiggleTime *uint64 `json:"backoffWiggleTime,omitempty"` // Seconds added to the back-off time of out-of-turn validators (nil = 1)
+	KeepPeriods       bool    `json:"keepPeriods,omitempty"`       // Keep the block and epoch periods over the shortened block time fork
+	MaxExtraSize      *uint64 `json:"maxExtraSize,omitempty"`      // Maximum size of the extra data of the headers since the extra size limit fork (nil = bounded by the maximum validators)
+	MilliPeriod       *uint64 `json:"milliPeriod,omitempty"`       // Milliseconds between the blocks since the millisecond timestamp fork (nil = block period of the environment)
+	Heartbeat         *uint64 `json:"heartbeat,omitempty"`         // Seconds since the parent before an empty block may be sealed since the heartbeat fork (nil = every block period)
+
+	// Overrides for private networks deploying customized genesis contracts
+	Contracts   *OasysContracts   `json:"contracts,omitempty"`   // Addresses of the system contracts (nil = default)
+	Environment *OasysEnvironment `json:"environment,omitempty"` // Initial environment value (nil = default)
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -539,6 +565,36 @@ func (c *ChainConfig) Description() string {
 	if c.OasysFastFinalityEnabledBlock() != nil {
 		banner += fmt.Sprintf(" - Oasys Fast Finality Enabled: #%-8v (https://github.com/oasysgames/oasys-validator/releases/tag/v1.6.0)\n", c.OasysFastFinalityEnabledBlock())
 	}
+	if c.OasysAttestationForkChoiceBlock() != nil {
+		banner += fmt.Sprintf(" - Oasys Attestation Fork Choice: #%-8v\n", c.OasysAttestationForkChoiceBlock())
+	}
+	if c.OasysCompactExtraBlock() != nil {
+		banner += fmt.Sprintf(" - Oasys Compact Extra:         #%-8v\n", c.OasysCompactExtraBlock())
+	}
+	if c.OasysAttestationHeaderBlock() != nil {
+		banner += fmt.Sprintf(" - Oasys Attestation Header:    #%-8v\n", c.OasysAttestationHeaderBlock())
+	}
+	if c.OasysLargeVoteSetBlock() != nil {
+		banner += fmt.Sprintf(" - Oasys Large Vote Set:        #%-8v\n", c.OasysLargeVoteSetBlock())
+	}
+	if c.OasysValidatorCapBlock() != nil {
+		banner += fmt.Sprintf(" - Oasys Validator Cap:         #%-8v\n", c.OasysValidatorCapBlock())
+	}
+	if c.OasysBLS12381Block() != nil {
+		banner += fmt.Sprintf(" - Oasys BLS12-381 Precompiles: #%-8v\n", c.OasysBLS12381Block())
+	}
+	if c.OasysHeartbeatBlock() != nil {
+		banner += fmt.Sprintf(" - Oasys Heartbeat:             #%-8v\n", c.OasysHeartbeatBlock())
+	}
+	if c.OasysDeployerAllowListBlock() != nil {
+		banner += fmt.Sprintf(" - Oasys Deployer Allow List:   #%-8v\n", c.OasysDeployerAllowListBlock())
+	}
+	if c.OasysMillisecondTimestampBlock() != nil {
+		banner += fmt.Sprintf(" - Oasys Millisecond Timestamp: #%-8v\n", c.OasysMillisecondTimestampBlock())
+	}
+	if c.OasysExtraSizeLimitBlock() != nil {
+		banner += fmt.Sprintf(" - Oasys Extra Size Limit:      #%-8v\n", c.OasysExtraSizeLimitBlock())
+	}
 	if c.CancunTime != nil {
 		banner += fmt.Sprintf(" - Cancun:                      @%-10v (https://github.com/ethereum/execution-specs/blob/master/network-upgrades/mainnet-upgrades/cancun.md)\n", *c.CancunTime)
 	}
@@ -675,6 +731,19 @@ func (c *ChainConfig) OasysShortenedBlockTimeStartEpoch() *big.Int {
 	return big.NewInt(SHORT_BLOCK_TIME_FORK_EPOCH_OTHERS)
 }
 
+// OasysKeepPeriods returns whether the block and epoch periods in the genesis
+// are kept over the shortened block time fork, which is only allowed for the
+// private networks.
+func (c *ChainConfig) OasysKeepPeriods() bool {
+	if c.Oasys == nil {
+		return false
+	}
+	if c.ChainID.Cmp(OasysMainnetChainConfig.ChainID) == 0 || c.ChainID.Cmp(OasysTestnetChainConfig.ChainID) == 0 {
+		return false
+	}
+	return c.Oasys.KeepPeriods
+}
+
 // OasysFastFinalityEnabledBlock returns the hard fork of Oasys.
 // TODO: Set correct block number for mainnet and testnet.
 func (c *ChainConfig) OasysFastFinalityEnabledBlock() *big.Int {
@@ -695,6 +764,319 @@ func (c *ChainConfig) IsFastFinalityEnabled(num *big.Int) bool {
 	return isBlockForked(c.OasysFastFinalityEnabledBlock(), num)
 }
 
+// OasysAttestationForkChoiceBlock returns the fork block from which branches with
+// equal total difficulty are chosen by the number of attested blocks. It's not
+// scheduled on the mainnet and testnet yet, and configured in the genesis otherwise.
+func (c *ChainConfig) OasysAttestationForkChoiceBlock() *big.Int {
+	if c.Oasys == nil {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysMainnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysTestnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	return c.Oasys.AttestationForkChoiceBlock
+}
+
+// IsOasysAttestationForkChoice returns whether num is either equal to the attestation fork choice block or greater.
+func (c *ChainConfig) IsOasysAttestationForkChoice(num *big.Int) bool {
+	return isBlockForked(c.OasysAttestationForkChoiceBlock(), num)
+}
+
+// OasysCompactExtraBlock returns the fork block from which the validators in the
+// epoch headers are encoded as an RLP list with the variable-length stakes. It's
+// not scheduled on the mainnet and testnet yet, and configured in the genesis otherwise.
+func (c *ChainConfig) OasysCompactExtraBlock() *big.Int {
+	if c.Oasys == nil {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysMainnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysTestnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	return c.Oasys.CompactExtraBlock
+}
+
+// IsOasysCompactExtra returns whether num is either equal to the compact extra block or greater.
+func (c *ChainConfig) IsOasysCompactExtra(num *big.Int) bool {
+	return isBlockForked(c.OasysCompactExtraBlock(), num)
+}
+
+// OasysAttestationHeaderBlock returns the fork block from which the vote attestation
+// is carried in the dedicated header field instead of the extra-data. It's not
+// scheduled on the mainnet and testnet yet, and configured in the genesis otherwise.
+func (c *ChainConfig) OasysAttestationHeaderBlock() *big.Int {
+	if c.Oasys == nil {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysMainnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysTestnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	return c.Oasys.AttestationHeaderBlock
+}
+
+// IsOasysAttestationHeader returns whether num is either equal to the attestation header block or greater.
+func (c *ChainConfig) IsOasysAttestationHeader(num *big.Int) bool {
+	return isBlockForked(c.OasysAttestationHeaderBlock(), num)
+}
+
+// OasysLargeVoteSetBlock returns the fork block from which the vote attestation
+// may mark the voted validators beyond the first 64 ones. It's not scheduled on
+// the mainnet and testnet yet, and configured in the genesis otherwise.
+func (c *ChainConfig) OasysLargeVoteSetBlock() *big.Int {
+	if c.Oasys == nil {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysMainnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysTestnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	return c.Oasys.LargeVoteSetBlock
+}
+
+// IsOasysLargeVoteSet returns whether num is either equal to the large vote set block or greater.
+func (c *ChainConfig) IsOasysLargeVoteSet(num *big.Int) bool {
+	return isBlockForked(c.OasysLargeVoteSetBlock(), num)
+}
+
+// OasysValidatorCapBlock returns the fork block from which the validator set of
+// an epoch is trimmed to the maximum validator count set on the contract. It's
+// not scheduled on the mainnet and testnet yet, and configured in the genesis
+// otherwise.
+func (c *ChainConfig) OasysValidatorCapBlock() *big.Int {
+	if c.Oasys == nil {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysMainnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysTestnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	return c.Oasys.ValidatorCapBlock
+}
+
+// IsOasysValidatorCap returns whether num is either equal to the validator cap block or greater.
+func (c *ChainConfig) IsOasysValidatorCap(num *big.Int) bool {
+	return isBlockForked(c.OasysValidatorCapBlock(), num)
+}
+
+// OasysBLS12381Block returns the fork block from which the BLS12-381 precompiles
+// of EIP-2537 are enabled ahead of Ethereum. It's not scheduled on the mainnet
+// and testnet yet, and configured in the genesis otherwise.
+func (c *ChainConfig) OasysBLS12381Block() *big.Int {
+	if c.Oasys == nil {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysMainnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysTestnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	return c.Oasys.BLS12381Block
+}
+
+// IsOasysBLS12381 returns whether num is either equal to the BLS12-381 block or greater.
+func (c *ChainConfig) IsOasysBLS12381(num *big.Int) bool {
+	return isBlockForked(c.OasysBLS12381Block(), num)
+}
+
+// OasysHeartbeatBlock returns the fork block from which the empty blocks are
+// sealed no earlier than the configured heartbeat after their parent, and the
+// out-of-turn validators keep their back-off time after an idle period. It's not scheduled on the
+// mainnet and testnet yet, and configured in the genesis otherwise.
+func (c *ChainConfig) OasysHeartbeatBlock() *big.Int {
+	if c.Oasys == nil {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysMainnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysTestnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	return c.Oasys.HeartbeatBlock
+}
+
+// IsOasysHeartbeat returns whether num is either equal to the heartbeat block or greater.
+func (c *ChainConfig) IsOasysHeartbeat(num *big.Int) bool {
+	return isBlockForked(c.OasysHeartbeatBlock(), num)
+}
+
+// OasysDeployerAllowListBlock returns the fork block from which the contract
+// creations at any depth, including CREATE2, are limited to the deployers allowed
+// by the EVMAccessControl contract. It's not scheduled on the mainnet and testnet
+// yet, and configured in the genesis otherwise.
+func (c *ChainConfig) OasysDeployerAllowListBlock() *big.Int {
+	if c.Oasys == nil {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysMainnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysTestnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	return c.Oasys.DeployerAllowListBlock
+}
+
+// IsOasysDeployerAllowList returns whether num is either equal to the deployer allow list block or greater.
+func (c *ChainConfig) IsOasysDeployerAllowList(num *big.Int) bool {
+	return isBlockForked(c.OasysDeployerAllowListBlock(), num)
+}
+
+// OasysMillisecondTimestampBlock returns the fork block from which the headers
+// carry the millisecond part of the timestamp in the mix digest, and the block
+// times are scheduled and verified in milliseconds. It's not scheduled on the
+// mainnet and testnet yet, and configured in the genesis otherwise.
+func (c *ChainConfig) OasysMillisecondTimestampBlock() *big.Int {
+	if c.Oasys == nil {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysMainnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysTestnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	return c.Oasys.MillisecondTimestampBlock
+}
+
+// IsOasysMillisecondTimestamp returns whether num is either equal to the millisecond timestamp block or greater.
+func (c *ChainConfig) IsOasysMillisecondTimestamp(num *big.Int) bool {
+	return isBlockForked(c.OasysMillisecondTimestampBlock(), num)
+}
+
+// OasysExtraSizeLimitBlock returns the fork block from which the headers with the
+// extra data beyond the configured maximum size are rejected before parsing it,
+// while the default size bounds them before. It's not scheduled on the mainnet
+// and testnet yet, and configured in the genesis otherwise.
+func (c *ChainConfig) OasysExtraSizeLimitBlock() *big.Int {
+	if c.Oasys == nil {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysMainnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	if c.ChainID.Cmp(OasysTestnetChainConfig.ChainID) == 0 {
+		return nil
+	}
+	return c.Oasys.ExtraSizeLimitBlock
+}
+
+// IsOasysExtraSizeLimit returns whether num is either equal to the extra size limit block or greater.
+func (c *ChainConfig) IsOasysExtraSizeLimit(num *big.Int) bool {
+	return isBlockForked(c.OasysExtraSizeLimitBlock(), num)
+}
+
+// OasysForkBlocks returns the block numbers of the Oasys forks to be included in
+// the fork ID, so that the nodes running binaries with the incompatible consensus
+// rules are filtered out on the handshake. The forks already passed on the mainnet
+// and testnet are left out to keep the fork ID of the deployed nodes, and only the
+// forks scheduled in the genesis are included there.
+func (c *ChainConfig) OasysForkBlocks() []*big.Int {
+	if c.Oasys == nil {
+		return nil
+	}
+	var forks []*big.Int
+	if c.ChainID.Cmp(OasysMainnetChainConfig.ChainID) != 0 && c.ChainID.Cmp(OasysTestnetChainConfig.ChainID) != 0 {
+		forks = append(forks, c.OasysPublicationBlock(), c.OasysExtendDifficultyBlock(), c.OasysFastFinalityEnabledBlock())
+		if c.Oasys.Epoch != 0 {
+			env := InitialEnvironmentValue(c.Oasys)
+			forks = append(forks, new(big.Int).SetUint64(env.NewValueStartBlock(c.OasysShortenedBlockTimeStartEpoch().Uint64())))
+		}
+	}
+	for _, fork := range []*big.Int{
+		c.OasysAttestationForkChoiceBlock(),
+		c.OasysCompactExtraBlock(),
+		c.OasysAttestationHeaderBlock(),
+		c.OasysLargeVoteSetBlock(),
+		c.OasysValidatorCapBlock(),
+		c.OasysBLS12381Block(),
+		c.OasysDeployerAllowListBlock(),
+		c.OasysMillisecondTimestampBlock(),
+		c.OasysExtraSizeLimitBlock(),
+	} {
+		if fork != nil {
+			forks = append(forks, fork)
+		}
+	}
+	return forks
+}
+
+// OasysFork is an Oasys fork scheduled on the chain, activated either at the
+// block or at the epoch.
+type OasysFork struct {
+	Name  string   `json:"name"`
+	Block *big.Int `json:"block,omitempty"`
+	Epoch *big.Int `json:"epoch,omitempty"`
+}
+
+// IsActive returns whether the fork is activated at the given block number and
+// its epoch.
+func (f *OasysFork) IsActive(number, epoch uint64) bool {
+	if f.Epoch != nil {
+		return f.Epoch.Cmp(new(big.Int).SetUint64(epoch)) <= 0
+	}
+	return isBlockForked(f.Block, new(big.Int).SetUint64(number))
+}
+
+// OasysForkStatus is an Oasys fork along with whether it's activated.
+type OasysForkStatus struct {
+	OasysFork
+	Active bool `json:"active"`
+}
+
+// OasysForks returns the Oasys forks scheduled on the chain, in the order shown
+// in the banner. The forks not scheduled are left out.
+func (c *ChainConfig) OasysForks() []*OasysFork {
+	if c.Oasys == nil {
+		return nil
+	}
+	var forks []*OasysFork
+	for _, fork := range []*OasysFork{
+		{Name: "publication", Block: c.OasysPublicationBlock()},
+		{Name: "extendDifficulty", Block: c.OasysExtendDifficultyBlock()},
+		{Name: "shortenedBlockTime", Epoch: c.OasysShortenedBlockTimeStartEpoch()},
+		{Name: "fastFinality", Block: c.OasysFastFinalityEnabledBlock()},
+		{Name: "attestationForkChoice", Block: c.OasysAttestationForkChoiceBlock()},
+		{Name: "compactExtra", Block: c.OasysCompactExtraBlock()},
+		{Name: "attestationHeader", Block: c.OasysAttestationHeaderBlock()},
+		{Name: "largeVoteSet", Block: c.OasysLargeVoteSetBlock()},
+		{Name: "validatorCap", Block: c.OasysValidatorCapBlock()},
+		{Name: "bls12381", Block: c.OasysBLS12381Block()},
+		{Name: "heartbeat", Block: c.OasysHeartbeatBlock()},
+		{Name: "deployerAllowList", Block: c.OasysDeployerAllowListBlock()},
+		{Name: "millisecondTimestamp", Block: c.OasysMillisecondTimestampBlock()},
+		{Name: "extraSizeLimit", Block: c.OasysExtraSizeLimitBlock()},
+	} {
+		if fork.Block != nil || fork.Epoch != nil {
+			forks = append(forks, fork)
+		}
+	}
+	return forks
+}
+
+// OasysForkStatuses returns the Oasys forks scheduled on the chain along with
+// whether they are activated at the given block number and its epoch.
+func (c *ChainConfig) OasysForkStatuses(number, epoch uint64) []*OasysForkStatus {
+	var statuses []*OasysForkStatus
+	for _, fork := range c.OasysForks() {
+		statuses = append(statuses, &OasysForkStatus{OasysFork: *fork, Active: fork.IsActive(number, epoch)})
+	}
+	return statuses
+}
+
 // IsTerminalPoWBlock returns whether the given block is the last block of PoW stage.
 func (c *ChainConfig) IsTerminalPoWBlock(parentTotalDiff *big.Int, totalDiff *big.Int) bool {
 	if c.TerminalTotalDifficulty == nil {
@@ -1063,6 +1445,7 @@ type Rules struct {
 	IsBerlin, IsLondon                                      bool
 	IsMerge, IsShanghai, IsCancun, IsPrague                 bool
 	IsVerkle                                                bool
+	IsOasysBLS12381, IsOasysDeployerAllowList               bool
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -1090,5 +1473,8 @@ func (c *ChainConfig) Rules(num *big.Int, isMerge bool, timestamp uint64) Rules
 		IsCancun:         c.IsCancun(num, timestamp),
 		IsPrague:         c.IsPrague(num, timestamp),
 		IsVerkle:         c.IsVerkle(num, timestamp),
+		IsOasysBLS12381:  c.IsOasysBLS12381(num) && c.IsCancun(num, timestamp),
+
+		IsOasysDeployerAllowList: c.IsOasysDeployerAllowList(num),
 	}
 }
@@ -1,8 +1,10 @@
 package params
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -47,16 +49,26 @@ func (p *EnvironmentValue) Epoch(number uint64) uint64 {
 	return p.StartEpoch.Uint64() + (number-p.StartBlock.Uint64())/p.EpochPeriod.Uint64()
 }
 
-// Determine if the given block number is the start block of the corresponding epoch.
+// Calculate the start block of the epoch including the given block number.
 func (p *EnvironmentValue) GetFirstBlock(number uint64) uint64 {
-	elapsedEpoch := p.Epoch(number) - p.StartEpoch.Uint64()
-	return p.StartBlock.Uint64() + elapsedEpoch*p.EpochPeriod.Uint64()
+	return p.EpochStartBlock(p.Epoch(number))
+}
+
+// Calculate the start block of the given epoch, which must not be before the
+// start epoch of this environment.
+func (p *EnvironmentValue) EpochStartBlock(epoch uint64) uint64 {
+	return p.StartBlock.Uint64() + (epoch-p.StartEpoch.Uint64())*p.EpochPeriod.Uint64()
 }
 
 // Calculate the block number where the next environment should start based on this environment.
 func (p *EnvironmentValue) NewValueStartBlock(newValueStartEpoch uint64) uint64 {
-	return p.StartBlock.Uint64() +
-		(newValueStartEpoch-p.StartEpoch.Uint64())*p.EpochPeriod.Uint64()
+	return p.EpochStartBlock(newValueStartEpoch)
+}
+
+// Determine if the next value set on the contract takes effect at the given
+// epoch, as `Environment.value` switches to it once its start epoch is reached.
+func (p *EnvironmentValue) ShouldUpdate(epoch uint64, next *EnvironmentValue) bool {
+	return next.StartEpoch.Cmp(p.StartEpoch) > 0 && next.StartEpoch.Uint64() <= epoch
 }
 
 // Safely copy all values and return a new pointer.
@@ -108,9 +120,43 @@ func (p *EnvironmentValue) Equal(expect *EnvironmentValue) error {
 	return nil
 }
 
+// OasysContracts is the addresses of the Oasys system contracts. The zero
+// address keeps the default one.
+type OasysContracts struct {
+	Environment               common.Address `json:"environment,omitempty"`
+	StakeManager              common.Address `json:"stakeManager,omitempty"`
+	AllowList                 common.Address `json:"allowList,omitempty"`
+	CandidateValidatorManager common.Address `json:"candidateValidatorManager,omitempty"`
+
+	// Expected code hashes of the genesis contracts verified before they are
+	// initialized in the first block. The zero hash expects the code of the
+	// contracts built into the client, the networks deploying patched contracts
+	// need to supply the hashes of their code.
+	EnvironmentCodeHash  common.Hash `json:"environmentCodeHash,omitempty"`
+	StakeManagerCodeHash common.Hash `json:"stakeManagerCodeHash,omitempty"`
+}
+
+// OasysEnvironment is the initial environment value written in Genesis.
+// The block and epoch periods are taken from the OasysConfig, and the
+// unset fields keep the default values.
+type OasysEnvironment struct {
+	RewardRate         *big.Int `json:"rewardRate,omitempty"`
+	CommissionRate     *big.Int `json:"commissionRate,omitempty"`
+	ValidatorThreshold *big.Int `json:"validatorThreshold,omitempty"`
+	JailThreshold      *big.Int `json:"jailThreshold,omitempty"`
+	JailPeriod         *big.Int `json:"jailPeriod,omitempty"`
+}
+
+// IsDeployerAllowListBypassed returns whether the deployer may create contracts
+// without being allowed by the EVMAccessControl contract after the deployer allow
+// list fork.
+func (o *OasysConfig) IsDeployerAllowListBypassed(deployer common.Address) bool {
+	return o != nil && slices.Contains(o.DeployerAllowListBypass, deployer)
+}
+
 // Returns the environment value in Genesis.
 func InitialEnvironmentValue(cfg *OasysConfig) *EnvironmentValue {
-	return &EnvironmentValue{
+	env := &EnvironmentValue{
 		StartBlock:         common.Big0,
 		StartEpoch:         common.Big1,
 		BlockPeriod:        new(big.Int).SetUint64(cfg.Period),
@@ -121,4 +167,128 @@ func InitialEnvironmentValue(cfg *OasysConfig) *EnvironmentValue {
 		JailThreshold:      big.NewInt(500),
 		JailPeriod:         big.NewInt(2),
 	}
+	if override := cfg.Environment; override != nil {
+		for _, field := range []struct{ dst, src **big.Int }{
+			{&env.RewardRate, &override.RewardRate},
+			{&env.CommissionRate, &override.CommissionRate},
+			{&env.ValidatorThreshold, &override.ValidatorThreshold},
+			{&env.JailThreshold, &override.JailThreshold},
+			{&env.JailPeriod, &override.JailPeriod},
+		} {
+			if *field.src != nil {
+				*field.dst = new(big.Int).Set(*field.src)
+			}
+		}
+	}
+	return env
+}
+
+// Validate checks the consistency between the genesis parameters and the
+// initial environment value, which are otherwise only found to be broken by
+// the Environment contract once the chain has started.
+func (o *OasysConfig) Validate() error {
+	if o.Period == 0 {
+		return errors.New("block period must be greater than zero")
+	}
+	if o.MilliPeriod != nil && *o.MilliPeriod == 0 {
+		return errors.New("millisecond block period must be greater than zero")
+	}
+	if o.Heartbeat != nil && *o.Heartbeat == 0 {
+		return errors.New("heartbeat must be greater than zero")
+	}
+	// The extra data holds at least the 32-byte vanity and the 65-byte seal
+	if o.MaxExtraSize != nil && *o.MaxExtraSize < 32+65 {
+		return fmt.Errorf("max extra size must be at least 97, got %d", *o.MaxExtraSize)
+	}
+	env := InitialEnvironmentValue(o)
+	if env.EpochPeriod.Sign() == 0 {
+		// The engine falls back to the default epoch length.
+		return nil
+	}
+	if env.RewardRate.Cmp(big.NewInt(100)) > 0 {
+		return fmt.Errorf("reward rate must be at most 100, got %v", env.RewardRate)
+	}
+	if env.CommissionRate.Cmp(big.NewInt(100)) > 0 {
+		return fmt.Errorf("commission rate must be at most 100, got %v", env.CommissionRate)
+	}
+	if env.ValidatorThreshold.Sign() <= 0 {
+		return errors.New("validator threshold must be greater than zero")
+	}
+	if env.JailThreshold.Sign() <= 0 || env.JailThreshold.Cmp(env.EpochPeriod) > 0 {
+		return fmt.Errorf("jail threshold must be within the epoch period %v, got %v", env.EpochPeriod, env.JailThreshold)
+	}
+	if env.JailPeriod.Sign() <= 0 {
+		return errors.New("jail period must be greater than zero")
+	}
+	return nil
+}
+
+// OasysPeriods returns the block and epoch periods the Environment contract is
+// expected to hold at the given epoch if they are never updated by governance.
+func (c *ChainConfig) OasysPeriods(epoch uint64) (blockPeriod, epochPeriod uint64) {
+	blockPeriod, epochPeriod = c.Oasys.Period, c.Oasys.Epoch
+	if fork := c.OasysShortenedBlockTimeStartEpoch(); fork != nil && epoch >= fork.Uint64() && !c.OasysKeepPeriods() {
+		blockPeriod, epochPeriod = SHORT_BLOCK_TIME_SECONDS, SHORT_BLOCK_TIME_EPOCH_PERIOD
+	}
+	return blockPeriod, epochPeriod
+}
+
+// CheckOasysForks checks the ordering of the Oasys forks and their dependencies
+// on the other forks and the periods, so that a broken genesis is rejected on
+// startup rather than found while syncing.
+func (c *ChainConfig) CheckOasysForks() error {
+	if c.Oasys == nil {
+		return nil
+	}
+	var last struct {
+		name  string
+		block *big.Int
+	}
+	for _, cur := range []struct {
+		name  string
+		block *big.Int
+	}{
+		{"publicationBlock", c.OasysPublicationBlock()},
+		{"extendDifficultyBlock", c.OasysExtendDifficultyBlock()},
+		{"fastFinalityEnabledBlock", c.OasysFastFinalityEnabledBlock()},
+	} {
+		if last.block != nil && last.block.Cmp(cur.block) > 0 {
+			return fmt.Errorf("unsupported fork ordering: %v enabled at block %v, but %v enabled at block %v",
+				last.name, last.block, cur.name, cur.block)
+		}
+		last = cur
+	}
+	// The forks below rely on the votes or the contracts of the fast finality fork,
+	// so they can't be enabled before it.
+	for _, cur := range []struct {
+		name  string
+		block *big.Int
+	}{
+		{"attestationForkChoiceBlock", c.OasysAttestationForkChoiceBlock()},
+		{"compactExtraBlock", c.OasysCompactExtraBlock()},
+		{"attestationHeaderBlock", c.OasysAttestationHeaderBlock()},
+		{"largeVoteSetBlock", c.OasysLargeVoteSetBlock()},
+		{"validatorCapBlock", c.OasysValidatorCapBlock()},
+	} {
+		if cur.block != nil && cur.block.Cmp(last.block) < 0 {
+			return fmt.Errorf("unsupported fork ordering: %v enabled at block %v, but %v enabled at block %v",
+				cur.name, cur.block, last.name, last.block)
+		}
+	}
+	if c.OasysAttestationHeaderBlock() != nil && c.CancunTime == nil {
+		return errors.New("attestationHeaderBlock requires cancunTime to be set")
+	}
+	if c.OasysBLS12381Block() != nil && c.CancunTime == nil {
+		return errors.New("bls12381Block requires cancunTime to be set")
+	}
+	// The jail threshold is kept over the shortened block time fork, so it must
+	// fit in the shortened epoch as well.
+	if fork := c.OasysShortenedBlockTimeStartEpoch(); fork != nil && c.Oasys.Epoch != 0 {
+		_, epochPeriod := c.OasysPeriods(fork.Uint64())
+		if env := InitialEnvironmentValue(c.Oasys); env.JailThreshold.Cmp(new(big.Int).SetUint64(epochPeriod)) > 0 {
+			return fmt.Errorf("jail threshold must be within the epoch period %v after the shortened block time fork, got %v",
+				epochPeriod, env.JailThreshold)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,52 @@
+package params
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// OasysNetwork is a preset of the parameters an Oasys network is joined with,
+// selected by the --oasys.network flag instead of the individual flags.
+type OasysNetwork struct {
+	Name        string                 `json:"name"`
+	NetworkID   uint64                 `json:"networkId,omitempty"`
+	GenesisHash common.Hash            `json:"genesisHash,omitempty"` // Zero if the genesis is not pinned
+	Bootnodes   []string               `json:"bootnodes,omitempty"`
+	Checkpoints map[uint64]common.Hash `json:"checkpoints,omitempty"` // Trusted block number -> hash mappings
+}
+
+// The built-in presets of the public networks carry no bootnodes, since none
+// are published yet, so these are still supplied with the --bootnodes flag.
+var (
+	// OasysMainnetNetwork is the preset of the Oasys mainnet.
+	OasysMainnetNetwork = &OasysNetwork{
+		Name:        "mainnet",
+		NetworkID:   OasysMainnetChainConfig.ChainID.Uint64(),
+		GenesisHash: OasysMainnetGenesisHash,
+	}
+
+	// OasysTestnetNetwork is the preset of the Oasys testnet.
+	OasysTestnetNetwork = &OasysNetwork{
+		Name:        "testnet",
+		NetworkID:   OasysTestnetChainConfig.ChainID.Uint64(),
+		GenesisHash: OasysTestnetGenesisHash,
+	}
+
+	// OasysPrivateNetwork is the preset of a private network, which only keeps
+	// the node away from the databases of the public networks.
+	OasysPrivateNetwork = &OasysNetwork{
+		Name: "private",
+	}
+
+	// OasysNetworks are the built-in presets by name.
+	OasysNetworks = map[string]*OasysNetwork{
+		OasysMainnetNetwork.Name: OasysMainnetNetwork,
+		OasysTestnetNetwork.Name: OasysTestnetNetwork,
+		OasysPrivateNetwork.Name: OasysPrivateNetwork,
+	}
+)
+
+// IsPublicOasysGenesis returns whether the genesis hash is the one of the
+// Oasys mainnet or testnet.
+func IsPublicOasysGenesis(hash common.Hash) bool {
+	return hash == OasysMainnetGenesisHash || hash == OasysTestnetGenesisHash
+}
@@ -3,6 +3,7 @@ package params
 import (
 	"math/big"
 	"testing"
+	"testing/quick"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -87,3 +88,165 @@ func TestEnvironmentValue(t *testing.T) {
 		t.Errorf("Equal(env): want=`%s` got=`%s`", wantErr, gotErr)
 	}
 }
+
+func TestEnvironmentValueEpochMath(t *testing.T) {
+	// The epoch math holds for any environment, bounded to avoid overflowing.
+	check := func(startBlock, startEpoch uint32, epochPeriod uint16, offset uint32) bool {
+		env := &EnvironmentValue{
+			StartBlock:  new(big.Int).SetUint64(uint64(startBlock)),
+			StartEpoch:  new(big.Int).SetUint64(uint64(startEpoch)),
+			EpochPeriod: new(big.Int).SetUint64(uint64(epochPeriod) + 1),
+		}
+		number := uint64(startBlock) + uint64(offset)
+		epoch, first := env.Epoch(number), env.GetFirstBlock(number)
+		switch {
+		case epoch < env.StartEpoch.Uint64():
+			return false
+		case first != env.EpochStartBlock(epoch) || first > number || number >= env.EpochStartBlock(epoch+1):
+			return false
+		case env.Epoch(first) != epoch || env.Epoch(env.EpochStartBlock(epoch+1)) != epoch+1:
+			return false
+		case env.IsEpoch(number) != (first == number):
+			return false
+		case env.NewValueStartBlock(epoch+1) != env.EpochStartBlock(epoch+1):
+			return false
+		}
+		// A value scheduled for a later epoch continues the epochs seamlessly.
+		next := &EnvironmentValue{
+			StartBlock:  new(big.Int).SetUint64(env.NewValueStartBlock(epoch + 1)),
+			StartEpoch:  new(big.Int).SetUint64(epoch + 1),
+			EpochPeriod: new(big.Int).SetUint64(uint64(epochPeriod)%7 + 1),
+		}
+		return next.Epoch(next.StartBlock.Uint64()) == epoch+1 && next.IsEpoch(next.StartBlock.Uint64()) &&
+			!env.ShouldUpdate(epoch, next) && env.ShouldUpdate(epoch+1, next) && !next.ShouldUpdate(epoch+1, next)
+	}
+	if err := quick.Check(check, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInitialEnvironmentValueOverride(t *testing.T) {
+	cfg := &OasysConfig{
+		Period: 6,
+		Epoch:  100,
+		Environment: &OasysEnvironment{
+			RewardRate:    big.NewInt(5),
+			JailThreshold: big.NewInt(50),
+		},
+	}
+	want := &EnvironmentValue{
+		StartBlock:         common.Big0,
+		StartEpoch:         common.Big1,
+		BlockPeriod:        big.NewInt(6),
+		EpochPeriod:        big.NewInt(100),
+		RewardRate:         big.NewInt(5),
+		CommissionRate:     big.NewInt(10),
+		ValidatorThreshold: new(big.Int).Mul(big.NewInt(Ether), big.NewInt(10_000_000)),
+		JailThreshold:      big.NewInt(50),
+		JailPeriod:         big.NewInt(2),
+	}
+	if err := InitialEnvironmentValue(cfg).Equal(want); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOasysConfigValidate(t *testing.T) {
+	tests := []struct {
+		cfg     *OasysConfig
+		wantErr bool
+	}{
+		{&OasysConfig{Period: 15, Epoch: 5760}, false},
+		{&OasysConfig{Period: 1, Epoch: 20, Environment: &OasysEnvironment{JailThreshold: big.NewInt(10)}}, false},
+		{&OasysConfig{Period: 0, Epoch: 20}, true},
+		{&OasysConfig{Period: 1, Epoch: 20}, true}, // default jail threshold exceeds the epoch
+		{&OasysConfig{Period: 1, Epoch: 5760, Environment: &OasysEnvironment{CommissionRate: big.NewInt(101)}}, true},
+		{&OasysConfig{Period: 1, Epoch: 5760, Environment: &OasysEnvironment{ValidatorThreshold: common.Big0}}, true},
+		{&OasysConfig{Period: 15, Epoch: 5760, MaxExtraSize: newUint64(97)}, false},
+		{&OasysConfig{Period: 15, Epoch: 5760, MaxExtraSize: newUint64(96)}, true},
+		{&OasysConfig{Period: 15, Epoch: 5760, MilliPeriod: newUint64(500)}, false},
+		{&OasysConfig{Period: 15, Epoch: 5760, MilliPeriod: newUint64(0)}, true},
+		{&OasysConfig{Period: 15, Epoch: 5760, Heartbeat: newUint64(60)}, false},
+		{&OasysConfig{Period: 15, Epoch: 5760, Heartbeat: newUint64(0)}, true},
+	}
+	for i, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("test %d: unexpected result: %v", i, err)
+		}
+	}
+}
+
+func TestOasysPeriods(t *testing.T) {
+	cfg := &ChainConfig{ChainID: big.NewInt(12345), Oasys: &OasysConfig{Period: 15, Epoch: 5760}}
+	tests := []struct {
+		epoch                    uint64
+		keep                     bool
+		blockPeriod, epochPeriod uint64
+	}{
+		{1, false, 15, 5760},
+		{SHORT_BLOCK_TIME_FORK_EPOCH_OTHERS - 1, false, 15, 5760},
+		{SHORT_BLOCK_TIME_FORK_EPOCH_OTHERS, false, SHORT_BLOCK_TIME_SECONDS, SHORT_BLOCK_TIME_EPOCH_PERIOD},
+		{SHORT_BLOCK_TIME_FORK_EPOCH_OTHERS, true, 15, 5760},
+	}
+	for i, tt := range tests {
+		cfg.Oasys.KeepPeriods = tt.keep
+		if blockPeriod, epochPeriod := cfg.OasysPeriods(tt.epoch); blockPeriod != tt.blockPeriod || epochPeriod != tt.epochPeriod {
+			t.Errorf("test %d: periods mismatch, got %d/%d, want %d/%d", i, blockPeriod, epochPeriod, tt.blockPeriod, tt.epochPeriod)
+		}
+	}
+}
+
+func TestCheckOasysForks(t *testing.T) {
+	tests := []struct {
+		cfg     *ChainConfig
+		wantErr bool
+	}{
+		{OasysMainnetChainConfig, false},
+		{OasysTestnetChainConfig, false},
+		{&ChainConfig{ChainID: big.NewInt(12345)}, false},
+		{&ChainConfig{ChainID: big.NewInt(12345), Oasys: &OasysConfig{Period: 1, Epoch: 20}}, false},
+		{&ChainConfig{ChainID: big.NewInt(12345), Oasys: &OasysConfig{Period: 1, Epoch: 20, AttestationForkChoiceBlock: big.NewInt(1)}}, true},
+		{&ChainConfig{ChainID: big.NewInt(12345), Oasys: &OasysConfig{Period: 1, Epoch: 20, CompactExtraBlock: big.NewInt(10)}}, false},
+		{&ChainConfig{ChainID: big.NewInt(12345), Oasys: &OasysConfig{Period: 1, Epoch: 20, AttestationHeaderBlock: big.NewInt(10)}}, true},
+		{&ChainConfig{ChainID: big.NewInt(12345), CancunTime: newUint64(0), Oasys: &OasysConfig{Period: 1, Epoch: 20, AttestationHeaderBlock: big.NewInt(10)}}, false},
+		{&ChainConfig{ChainID: big.NewInt(12345), Oasys: &OasysConfig{Period: 1, Epoch: 20, LargeVoteSetBlock: big.NewInt(1)}}, true},
+		{&ChainConfig{ChainID: big.NewInt(12345), Oasys: &OasysConfig{Period: 1, Epoch: 20, LargeVoteSetBlock: big.NewInt(10)}}, false},
+		{&ChainConfig{ChainID: big.NewInt(12345), Oasys: &OasysConfig{Period: 1, Epoch: 20, ValidatorCapBlock: big.NewInt(1)}}, true},
+		{&ChainConfig{ChainID: big.NewInt(12345), Oasys: &OasysConfig{Period: 1, Epoch: 20, ValidatorCapBlock: big.NewInt(10)}}, false},
+		{&ChainConfig{ChainID: big.NewInt(12345), Oasys: &OasysConfig{Period: 1, Epoch: 20, BLS12381Block: big.NewInt(10)}}, true},
+		{&ChainConfig{ChainID: big.NewInt(12345), CancunTime: newUint64(0), Oasys: &OasysConfig{Period: 1, Epoch: 20, BLS12381Block: big.NewInt(10)}}, false},
+		{&ChainConfig{ChainID: big.NewInt(12345), Oasys: &OasysConfig{Period: 1, Epoch: 20000, Environment: &OasysEnvironment{JailThreshold: big.NewInt(15000)}}}, true},
+		{&ChainConfig{ChainID: big.NewInt(12345), Oasys: &OasysConfig{Period: 1, Epoch: 20000, KeepPeriods: true, Environment: &OasysEnvironment{JailThreshold: big.NewInt(15000)}}}, false},
+	}
+	for i, tt := range tests {
+		if err := tt.cfg.CheckOasysForks(); (err != nil) != tt.wantErr {
+			t.Errorf("test %d: unexpected result: %v", i, err)
+		}
+	}
+}
+
+func TestOasysForkStatuses(t *testing.T) {
+	cfg := &ChainConfig{ChainID: big.NewInt(12345), Oasys: &OasysConfig{Period: 1, Epoch: 20, ValidatorCapBlock: big.NewInt(100)}}
+
+	names := make(map[string]*OasysForkStatus)
+	for _, fork := range cfg.OasysForkStatuses(99, 5) {
+		names[fork.Name] = fork
+	}
+	if fork := names["validatorCap"]; fork == nil || fork.Block.Uint64() != 100 || fork.Active {
+		t.Fatalf("validator cap fork mismatch: %+v", fork)
+	}
+	if _, ok := names["heartbeat"]; ok {
+		t.Fatal("unscheduled fork listed")
+	}
+	if fork := names["shortenedBlockTime"]; fork == nil || fork.Epoch == nil || fork.Block != nil {
+		t.Fatalf("shortened block time fork mismatch: %+v", fork)
+	}
+	epoch := names["shortenedBlockTime"].Epoch.Uint64()
+	for _, fork := range cfg.OasysForkStatuses(100, epoch) {
+		if !fork.Active {
+			t.Errorf("fork %s not active", fork.Name)
+		}
+	}
+	if forks := (&ChainConfig{ChainID: big.NewInt(12345)}).OasysForkStatuses(100, 5); forks != nil {
+		t.Fatalf("forks listed without oasys: %v", forks)
+	}
+}